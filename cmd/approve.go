@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitexleo/backtide/internal/approval"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// approveCmd issues approval tokens for restores of jobs with restore_approval
+var approveCmd = &cobra.Command{
+	Use:   "approve [backup-id]",
+	Short: "Approve a restore of a job that requires a second operator",
+	Long: `Approve one restore of a backup of a job with restore_approval = true.
+
+The daemon issues a token that is valid once, for that backup only, and for
+[approval] token_validity (default 15m). Hand it to the operator running the
+restore, who passes it with 'backtide restore --approval <token>'. The daemon
+rejects a token redeemed by the operator who approved it, so one compromised
+session cannot approve its own restore.
+
+Operators are told apart by their own accounts: run approve and restore
+through sudo from each operator's login, whose login UID the kernel keeps,
+or as members of [access] operator_groups. The daemon refuses approvals and
+redemptions from root sessions without a login UID, where it cannot tell who
+is behind them.
+
+Without the daemon, restores can be approved with a code from an
+authenticator app; --new-totp-secret prints a secret to set it up. Each
+code approves one restore.
+
+Examples:
+  backtide approve backup-20241201-143000-db-1a2b3c --job db
+  backtide approve --new-totp-secret`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runApprove,
+}
+
+var (
+	approveJob       string
+	approveNewSecret bool
+)
+
+func init() {
+	approveCmd.Flags().StringVarP(&approveJob, "job", "j", "", "job the backup belongs to")
+	approveCmd.Flags().BoolVar(&approveNewSecret, "new-totp-secret", false, "print a new TOTP secret for [approval] totp_secret_file")
+
+	// Register with command registry
+	commands.RegisterCommand("approve", approveCmd)
+}
+
+func runApprove(cmd *cobra.Command, args []string) {
+	if approveNewSecret {
+		secret, err := approval.NewTOTPSecret()
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		hostname, _ := os.Hostname()
+		render.Printf("Secret: %s\n", secret)
+		render.Printf("URI:    %s\n", approval.TOTPURI(secret, hostname))
+		render.Println("\n💡 Save the secret in a file owned by root with mode 0600, set it as")
+		render.Println("   [approval] totp_secret_file and add the URI to an authenticator app")
+		return
+	}
+	if len(args) != 1 || approveJob == "" {
+		render.Println("Error: a backup ID and --job are required")
+		render.Println("Usage: backtide approve <backup-id> --job <name>")
+		os.Exit(1)
+	}
+
+	client := control.NewClient(state.FindSocket())
+	if !client.Available() {
+		render.Println("❌ Approval tokens are issued by the daemon, which is not running")
+		render.Println("💡 Use a TOTP code instead; see 'backtide approve --help'")
+		os.Exit(1)
+	}
+	var approved control.Approval
+	if err := client.Post("/v1/approvals", control.ApprovalRequest{Job: approveJob, BackupID: args[0]}, &approved); err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	render.Printf("✅ Approved one restore of %s of job %s until %s\n",
+		approved.BackupID, approved.Job, approved.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	render.Printf("\n%s\n\n", approved.Token)
+	render.Printf("💡 The restoring operator passes it with: backtide restore %s --job %s --approval <token>\n", approved.BackupID, approved.Job)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/spf13/cobra"
+)
+
+// archiveWorkerCmd archives the directories of a job with run_as or sandbox;
+// backup runs start it with the user's credentials and pass the job on stdin
+var archiveWorkerCmd = &cobra.Command{
+	Use:    backup.WorkerCommand,
+	Short:  "Archive the directories of a backup run (internal)",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run:    runArchiveWorker,
+}
+
+var (
+	workerSandbox bool
+	workerRead    []string
+	workerWrite   []string
+)
+
+func init() {
+	archiveWorkerCmd.Flags().BoolVar(&workerSandbox, "sandbox", false, "restrict the worker to the --read and --write paths")
+	archiveWorkerCmd.Flags().StringArrayVar(&workerRead, "read", nil, "directory the sandboxed worker may read")
+	archiveWorkerCmd.Flags().StringArrayVar(&workerWrite, "write", nil, "directory the sandboxed worker may write")
+
+	// The worker only has the permissions of its run_as user, and the run
+	// that started it has passed the access check
+	commands.MarkReadOnly(archiveWorkerCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("archive-worker", archiveWorkerCmd)
+}
+
+func runArchiveWorker(cmd *cobra.Command, args []string) {
+	// The sandbox is entered before the job is read; this restarts the worker
+	if workerSandbox {
+		if err := backup.EnterSandbox(backup.SandboxRules{Read: workerRead, Write: workerWrite}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signalChan
+		cancel()
+	}()
+
+	// The run reads the result from the pipe passed as the first extra file
+	result := os.NewFile(3, "result")
+	if err := backup.RunArchiveWorker(ctx, os.Stdin, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
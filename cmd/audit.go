@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditLimit  int
+	auditAction string
+	auditSince  string
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of configuration and destructive operations",
+	Long: `Show the append-only audit log.
+
+Every configuration change (jobs, buckets), cleanup that removes backups,
+and restore is recorded with a timestamp, the invoking user and a summary
+of what changed.
+
+Examples:
+  backtide audit
+  backtide audit --action cleanup --since 7d
+  backtide audit --limit 0 (show all entries)`,
+	Run: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 50, "number of most recent entries to show (0 for all)")
+	auditCmd.Flags().StringVar(&auditAction, "action", "", "only show entries for this action (e.g., cleanup, restore, jobs.add)")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "only show entries newer than this duration (e.g., 24h, 7d)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(auditCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("audit", auditCmd)
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	entries, err := audit.List()
+	if err != nil {
+		render.Printf("Error reading audit log: %v\n", err)
+		os.Exit(1)
+	}
+
+	var since time.Time
+	if auditSince != "" {
+		duration, err := utils.ParseDuration(auditSince)
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		since = time.Now().Add(-duration)
+	}
+
+	var filtered []audit.Entry
+	for _, entry := range entries {
+		if auditAction != "" && entry.Action != auditAction && !strings.HasPrefix(entry.Action, auditAction+".") {
+			continue
+		}
+		if !since.IsZero() && entry.Timestamp.Before(since) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+
+	if len(filtered) == 0 {
+		render.Println("No audit entries found.")
+		return
+	}
+
+	if auditLimit > 0 && len(filtered) > auditLimit {
+		filtered = filtered[len(filtered)-auditLimit:]
+	}
+
+	render.Printf("=== Audit Log (%s) ===\n", audit.LogPath())
+	for _, entry := range filtered {
+		icon := "✅"
+		if entry.Result == audit.ResultFailure {
+			icon = "❌"
+		}
+		render.Printf("\n%s %s  %s", icon, entry.Timestamp.Format("2006-01-02 15:04:05"), entry.Action)
+		if entry.Target != "" {
+			render.Printf(" %s", entry.Target)
+		}
+		render.Printf("  by %s\n", entry.User)
+		for _, change := range entry.Changes {
+			render.Printf("   - %s\n", change)
+		}
+		if entry.Error != "" {
+			render.Printf("   Error: %s\n", entry.Error)
+		}
+	}
+}
+
+// saveConfigWithAudit saves the configuration and records the change in the audit log
+func saveConfigWithAudit(cfg *config.BackupConfig, configPath, action, target string) error {
+	// Diff against what is on disk, not what the command started from
+	previous, _ := config.LoadConfig(configPath)
+
+	err := config.SaveConfig(cfg, configPath)
+	audit.RecordResult(action, target, audit.DiffConfig(previous, cfg), err)
+	return err
+}
@@ -2,26 +2,48 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mitexleo/backtide/internal/backup"
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/prompt"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/throttle"
+	"github.com/mitexleo/backtide/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 var (
-	backupJobName string
-	backupAll     bool
+	backupJobName   string
+	backupAll       bool
+	backupDetach    bool
+	backupLocal     bool
+	backupComment   string
+	backupKeepFor   string
+	backupLabels    []string
+	backupParallel  int
+	backupSerialize string
+	backupNice      int
+	backupIONice    string
+	backupCPULimit  string
+	backupIOLimit   string
 )
 
 // backupCmd represents the backup command
 var backupCmd = &cobra.Command{
-	Use:   "backup",
+	Use:   "backup [job-name]",
 	Short: "Run backup operations",
 	Long: `Run backup operations for configured jobs.
 
@@ -30,22 +52,97 @@ This command can:
 - Run all enabled backup jobs
 - Show backup progress and results
 
+When the Backtide daemon is running, the run is handed to the daemon so
+locking, metrics and history stay in one place; the command follows the
+run until it finishes. Use --detach to queue the run and return
+immediately, or --local to run in this process regardless.
+
+With --all, jobs run one after another unless --parallel allows several at
+once. Parallel jobs run in this process; jobs writing to the same S3 bucket or
+reading from the same filesystem still wait for each other (see --serialize).
+A summary of every job is printed at the end.
+
+To keep a backup run by hand from slowing down production, --nice and
+--ionice lower its CPU and I/O priority, and --cpu-limit and --io-limit cap it
+with a cgroup (cgroup v2, root only). docker and s3fs started by the backup
+inherit the limits. Under systemd, use the [systemd] settings instead.
+
 Examples:
+  backtide backup daily-backup
+  backtide backup daily-backup --detach
   backtide backup --job daily-backup
   backtide backup --all
+  backtide backup --all --parallel 4
+  backtide backup --all --parallel 4 --serialize bucket
+  backtide backup daily-backup --comment "pre-upgrade snapshot" --label release=2.3
+  backtide backup daily-backup --keep-for 180d --comment "end of Q3"
+  backtide backup daily-backup --nice 19 --ionice idle
+  backtide backup daily-backup --cpu-limit 50% --io-limit 20MB
   backtide backup (runs all enabled jobs)`,
-	Run: runBackup,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runBackup,
 }
 
 func init() {
 	backupCmd.Flags().StringVarP(&backupJobName, "job", "j", "", "run specific backup job by name")
 	backupCmd.Flags().BoolVarP(&backupAll, "all", "a", false, "run all enabled backup jobs")
+	backupCmd.Flags().BoolVarP(&backupDetach, "detach", "d", false, "queue the run on the daemon and return immediately")
+	backupCmd.Flags().BoolVar(&backupLocal, "local", false, "run in this process even if the daemon is running")
+	backupCmd.Flags().StringVar(&backupComment, "comment", "", "comment stored with the backup, e.g. \"pre-upgrade snapshot\"")
+	backupCmd.Flags().StringArrayVar(&backupLabels, "label", nil, "label stored with the backup as key=value (repeatable)")
+	backupCmd.Flags().IntVarP(&backupParallel, "parallel", "p", 1, "with --all, run up to this many jobs at once")
+	backupCmd.Flags().StringVar(&backupSerialize, "serialize", "bucket,disk", "with --parallel, never overlap jobs sharing a bucket, a disk, both or none")
+	backupCmd.Flags().IntVar(&backupNice, "nice", 0, "run with this CPU priority, -20 (highest) to 19 (lowest)")
+	backupCmd.Flags().StringVar(&backupIONice, "ionice", "", "run with this I/O priority: idle, best-effort[:0-7] or realtime[:0-7]")
+	backupCmd.Flags().StringVar(&backupCPULimit, "cpu-limit", "", "limit the run to this share of one CPU, e.g. 50%")
+	backupCmd.Flags().StringVar(&backupIOLimit, "io-limit", "", "limit reads and writes per disk to this many bytes a second, e.g. 20MB")
+	backupCmd.Flags().StringVar(&backupKeepFor, "keep-for", "", "keep the backup for this long regardless of the job retention, e.g. 180d")
 
 	// Register with command registry
 	commands.RegisterCommand("backup", backupCmd)
 }
 
 func runBackup(cmd *cobra.Command, args []string) {
+	if len(args) > 0 {
+		if backupJobName != "" && backupJobName != args[0] {
+			render.Println("Error: Cannot specify both a job argument and --job")
+			os.Exit(1)
+		}
+		backupJobName = args[0]
+	}
+
+	if backupDetach && backupLocal {
+		render.Println("Error: Cannot specify both --detach and --local")
+		os.Exit(1)
+	}
+	parallel, err := parseParallelOptions()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if parallel.Parallel > 1 && backupDetach {
+		render.Println("Error: Cannot specify both --detach and --parallel")
+		os.Exit(1)
+	}
+	throttleOpts, err := parseThrottleOptions()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	labels, err := backup.ParseLabels(backupLabels)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	annotations := backup.Annotations{Comment: backupComment, Labels: labels}
+	if backupKeepFor != "" {
+		if annotations.KeepFor, err = parseKeepFor(backupKeepFor); err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Set up signal handling for graceful cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -55,122 +152,364 @@ func runBackup(cmd *cobra.Command, args []string) {
 
 	go func() {
 		<-signalChan
-		fmt.Println("\n🛑 Received interrupt signal, cancelling backup...")
+		render.Println("\n🛑 Received interrupt signal, cancelling backup...")
 		cancel()
 	}()
 
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Check if we have any jobs configured
 	if len(cfg.Jobs) == 0 {
-		fmt.Println("No backup jobs configured.")
-		fmt.Println("Use 'backtide jobs add' to create backup jobs.")
+		render.Println("No backup jobs configured.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
 		return
 	}
 
+	// Hand the run to the daemon when one is running
+	var daemon *control.Client
+	throttled := throttleOpts.Nice != 0 || throttleOpts.IONice != "" || throttleOpts.CPULimit != "" || throttleOpts.IOLimit > 0
+	if throttled && backupDetach {
+		render.Println("Error: --detach runs on the daemon, which the priority and limit flags cannot change")
+		os.Exit(1)
+	}
+
+	// Parallel and throttled runs are set up here, so they stay in this process
+	if !dryRun && !backupLocal && parallel.Parallel <= 1 && !throttled {
+		if client := control.NewClient(state.FindSocket()); client.Available() {
+			daemon = client
+		}
+	}
+	if backupDetach && daemon == nil {
+		render.Println("Error: --detach requires a running daemon")
+		render.Println("💡 Start it with 'backtide daemon' or run without --detach")
+		os.Exit(1)
+	}
+	if daemon != nil {
+		var jobNames []string
+		if backupJobName != "" {
+			jobNames = []string{backupJobName}
+		} else if backupAll || len(cfg.Jobs) == 1 {
+			for _, job := range cfg.Jobs {
+				if job.Enabled {
+					jobNames = append(jobNames, job.Name)
+				}
+			}
+		}
+		if len(jobNames) > 0 {
+			delegateBackup(ctx, daemon, jobNames, annotations)
+			return
+		}
+		if backupDetach {
+			render.Println("Error: --detach requires a job name or --all")
+			os.Exit(1)
+		}
+	}
+
+	if throttled && !dryRun {
+		throttleOpts.Paths = throttlePaths(cfg)
+		release, err := throttle.Apply(throttleOpts)
+		if err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		defer release()
+	}
+
 	backupRunner := backup.NewBackupRunner(*cfg)
 	backupRunner.SetDryRun(dryRun)
+	backupRunner.SetAnnotations(annotations)
 
 	// Determine which jobs to run
 	if backupJobName != "" {
 		// Run specific job
-		fmt.Printf("Running backup job: %s\n", backupJobName)
-		fmt.Println("💡 Press Ctrl+C to cancel the backup")
+		render.Printf("Running backup job: %s\n", backupJobName)
+		render.Println("💡 Press Ctrl+C to cancel the backup")
 		metadata, err := backupRunner.RunJob(ctx, backupJobName)
 		if err != nil {
-			if ctx.Err() != nil {
-				fmt.Println("❌ Backup cancelled by user")
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				render.Println("❌ Backup cancelled")
 			} else {
-				fmt.Printf("Error running backup job: %v\n", err)
+				render.Printf("Error running backup job: %v\n", err)
 			}
 			os.Exit(1)
 		}
-		fmt.Printf("✅ Backup completed successfully: %s\n", metadata.ID)
+		render.Printf("✅ Backup completed successfully: %s\n", metadata.ID)
+		uploadQueuedBackups(ctx, backupRunner)
+		replicateQueuedBackups(ctx, backupRunner)
 	} else if backupAll || len(cfg.Jobs) == 1 {
 		// Run all enabled jobs
-		fmt.Println("Running all enabled backup jobs...")
-		fmt.Println("💡 Press Ctrl+C to cancel the backup")
-		metadatas, err := backupRunner.RunAllJobs(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				fmt.Println("❌ Backup cancelled by user")
-			} else {
-				fmt.Printf("Error running backup jobs: %v\n", err)
-			}
-			os.Exit(1)
-		}
-		fmt.Printf("✅ All backup jobs completed successfully (%d jobs)\n", len(metadatas))
+		render.Println("Running all enabled backup jobs...")
+		render.Println("💡 Press Ctrl+C to cancel the backup")
+		runAllBackups(ctx, backupRunner, cfg, parallel)
 	} else {
 		// Show available jobs and let user choose
-		fmt.Println("Available backup jobs:")
+		render.Println("Available backup jobs:")
 		for i, job := range cfg.Jobs {
 			status := "❌ disabled"
 			if job.Enabled {
 				status = "✅ enabled"
 			}
-			fmt.Printf("%d. %s - %s\n", i+1, job.Name, status)
+			render.Printf("%d. %s - %s\n", i+1, job.Name, status)
 			if job.Description != "" {
-				fmt.Printf("   Description: %s\n", job.Description)
+				render.Printf("   Description: %s\n", job.Description)
 			}
-			fmt.Printf("   Directories: %d\n", len(job.Directories))
-			fmt.Printf("   Storage: ")
+			render.Printf("   Directories: %d\n", len(job.Directories))
+			render.Printf("   Storage: ")
 			if job.Storage.Local && job.Storage.S3 {
-				fmt.Printf("Local + S3\n")
+				render.Printf("Local + S3\n")
 			} else if job.Storage.Local {
-				fmt.Printf("Local only\n")
+				render.Printf("Local only\n")
 			} else if job.Storage.S3 {
-				fmt.Printf("S3 only\n")
+				render.Printf("S3 only\n")
 			} else {
-				fmt.Printf("None configured\n")
+				render.Printf("None configured\n")
 			}
-			fmt.Println()
+			render.Println()
 		}
 
-		fmt.Print("Select job to run (number) or 'all' for all enabled jobs: ")
-		var choice string
-		fmt.Scanln(&choice)
+		choice, err := newPrompter().String("Select job to run (number) or 'all' for all enabled jobs", "", jobChoice(len(cfg.Jobs)))
+		if err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
 
 		if choice == "all" {
-			fmt.Println("Running all enabled backup jobs...")
-			fmt.Println("💡 Press Ctrl+C to cancel the backup")
-			metadatas, err := backupRunner.RunAllJobs(ctx)
-			if err != nil {
-				if ctx.Err() != nil {
-					fmt.Println("❌ Backup cancelled by user")
-				} else {
-					fmt.Printf("Error running backup jobs: %v\n", err)
-				}
-				os.Exit(1)
-			}
-			fmt.Printf("✅ All backup jobs completed successfully (%d jobs)\n", len(metadatas))
+			render.Println("Running all enabled backup jobs...")
+			render.Println("💡 Press Ctrl+C to cancel the backup")
+			runAllBackups(ctx, backupRunner, cfg, parallel)
 		} else {
 			var jobIndex int
 			if _, err := fmt.Sscanf(choice, "%d", &jobIndex); err == nil && jobIndex >= 1 && jobIndex <= len(cfg.Jobs) {
 				job := cfg.Jobs[jobIndex-1]
 				if !job.Enabled {
-					fmt.Printf("Job '%s' is disabled. Enable it in the configuration first.\n", job.Name)
+					render.Printf("Job '%s' is disabled. Enable it in the configuration first.\n", job.Name)
 					return
 				}
-				fmt.Printf("Running backup job: %s\n", job.Name)
-				fmt.Println("💡 Press Ctrl+C to cancel the backup")
+				render.Printf("Running backup job: %s\n", job.Name)
+				render.Println("💡 Press Ctrl+C to cancel the backup")
 				metadata, err := backupRunner.RunJob(ctx, job.Name)
 				if err != nil {
-					if ctx.Err() != nil {
-						fmt.Println("❌ Backup cancelled by user")
+					if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+						render.Println("❌ Backup cancelled")
 					} else {
-						fmt.Printf("Error running backup job: %v\n", err)
+						render.Printf("Error running backup job: %v\n", err)
 					}
 					os.Exit(1)
 				}
-				fmt.Printf("✅ Backup completed successfully: %s\n", metadata.ID)
+				render.Printf("✅ Backup completed successfully: %s\n", metadata.ID)
+				uploadQueuedBackups(ctx, backupRunner)
+				replicateQueuedBackups(ctx, backupRunner)
 			} else {
-				fmt.Println("Invalid selection")
+				render.Println("Invalid selection")
+			}
+		}
+	}
+}
+
+// runAllBackups runs every enabled job and prints a summary, exiting with an
+// error if any job failed
+func runAllBackups(ctx context.Context, backupRunner *backup.BackupRunner, cfg *config.BackupConfig, parallel backup.ParallelOptions) {
+	var jobNames []string
+	for _, job := range cfg.Jobs {
+		if job.Enabled {
+			jobNames = append(jobNames, job.Name)
+		}
+	}
+	if parallel.Parallel > 1 {
+		render.Printf("Running up to %d jobs at once\n", parallel.Parallel)
+	}
+
+	started := time.Now()
+	results := backupRunner.RunJobs(ctx, jobNames, parallel)
+
+	render.Println("\n=== Backup Summary ===")
+	table := render.NewTable("JOB", "RESULT", "BACKUP", "SIZE", "DURATION")
+	table.Right[3] = true
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			outcome := "failed"
+			if errors.Is(result.Err, context.Canceled) {
+				outcome = "cancelled"
+			}
+			table.Row(result.Job, render.Status(outcome), "-", "-", result.Duration.Round(time.Second).String())
+			continue
+		}
+		table.Row(result.Job, render.Status("ok"), result.Metadata.ID,
+			utils.FormatBytes(result.Metadata.TotalSize), result.Duration.Round(time.Second).String())
+	}
+	table.Print()
+	render.Printf("📊 %d of %d jobs succeeded in %s\n", len(results)-failed, len(results), time.Since(started).Round(time.Second))
+	if ctx.Err() == nil {
+		uploadQueuedBackups(ctx, backupRunner)
+		replicateQueuedBackups(ctx, backupRunner)
+	}
+
+	if ctx.Err() != nil {
+		render.Println("❌ Backup cancelled")
+		os.Exit(1)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+	render.Printf("✅ All backup jobs completed successfully (%d jobs)\n", len(results))
+}
+
+// uploadQueuedBackups uploads the backups of forwarding jobs from the outbox
+// once the runs are done; uploads that fail stay queued for a later attempt
+func uploadQueuedBackups(ctx context.Context, backupRunner *backup.BackupRunner) {
+	uploads, err := state.LoadUploads()
+	if err != nil || len(uploads) == 0 {
+		return
+	}
+	render.Println("\nUploading backups from the outbox...")
+	if _, err := backupRunner.UploadOutbox(ctx, false); err != nil {
+		render.Println("⚠️  Backups that failed to upload stay in the outbox; retry with 'backtide uploads retry'")
+	}
+}
+
+// replicateQueuedBackups copies the backups of jobs with replication rules to
+// their secondary buckets once the runs and uploads are done; replications
+// that fail stay queued for a later attempt
+func replicateQueuedBackups(ctx context.Context, backupRunner *backup.BackupRunner) {
+	replications, err := state.LoadReplications()
+	if err != nil || len(replications) == 0 || ctx.Err() != nil {
+		return
+	}
+	render.Println("\nReplicating backups to secondary buckets...")
+	if _, err := backupRunner.Replicate(ctx, false); err != nil {
+		render.Println("⚠️  Backups that failed to replicate stay queued; retry with 'backtide replicate'")
+	}
+}
+
+// parseParallelOptions builds the parallel run options from the backup flags
+func parseParallelOptions() (backup.ParallelOptions, error) {
+	opts := backup.ParallelOptions{Parallel: backupParallel}
+	if backupParallel < 1 {
+		return opts, fmt.Errorf("--parallel must be at least 1")
+	}
+	for _, item := range strings.Split(backupSerialize, ",") {
+		switch strings.TrimSpace(item) {
+		case "bucket":
+			opts.SerializeBuckets = true
+		case "disk":
+			opts.SerializeDisks = true
+		case "none", "":
+		default:
+			return opts, fmt.Errorf("invalid --serialize %q (use bucket, disk, bucket,disk or none)", item)
+		}
+	}
+	return opts, nil
+}
+
+// parseThrottleOptions builds the priority and limit options from the backup flags
+func parseThrottleOptions() (throttle.Options, error) {
+	opts := throttle.Options{Nice: backupNice, IONice: backupIONice, CPULimit: backupCPULimit}
+	if backupIOLimit != "" {
+		limit, err := utils.ParseSize(backupIOLimit)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --io-limit: %w", err)
+		}
+		opts.IOLimit = limit
+	}
+	return opts, opts.Validate()
+}
+
+// throttlePaths returns the directories a backup reads and writes, whose disks
+// --io-limit applies to
+func throttlePaths(cfg *config.BackupConfig) []string {
+	paths := []string{cfg.BackupPath, cfg.TempPath}
+	for _, job := range cfg.Jobs {
+		for _, dir := range job.Directories {
+			paths = append(paths, dir.Path)
+		}
+	}
+	return paths
+}
+
+// delegateBackup runs jobs on the daemon, following each run unless --detach is set
+func delegateBackup(ctx context.Context, daemon *control.Client, jobNames []string, annotations backup.Annotations) {
+	render.Println("🔌 Daemon is running; delegating backup to the daemon")
+
+	failed := 0
+	for _, name := range jobNames {
+		var run control.RunStatus
+		request := control.RunRequest{Trigger: "cli", Comment: annotations.Comment, Labels: annotations.Labels, KeepFor: backupKeepFor}
+		if err := daemon.Post("/v1/jobs/"+url.PathEscape(name)+"/run", request, &run); err != nil {
+			render.Printf("❌ Failed to queue job %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		render.Printf("📨 Queued job %s (run %s)\n", name, run.ID)
+
+		if backupDetach {
+			continue
+		}
+
+		render.Println("💡 Press Ctrl+C to cancel the backup")
+		final, err := waitForDaemonRun(ctx, daemon, run.ID)
+		if err != nil {
+			render.Printf("❌ Lost track of run %s: %v\n", run.ID, err)
+			failed++
+			continue
+		}
+
+		switch final.State {
+		case control.RunSucceeded:
+			render.Printf("✅ Backup completed successfully: %s\n", final.BackupID)
+		case control.RunCancelled:
+			render.Println("❌ Backup cancelled")
+			os.Exit(1)
+		default:
+			render.Printf("Error running backup job: %s\n", final.Error)
+			failed++
+		}
+	}
+
+	if backupDetach {
+		render.Println("💡 Follow progress with 'backtide status'")
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// waitForDaemonRun polls the daemon until a run finishes, printing phase changes
+func waitForDaemonRun(ctx context.Context, daemon *control.Client, runID string) (control.RunStatus, error) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	lastPhase := ""
+	cancelRequested := false
+	for {
+		var run control.RunStatus
+		if err := daemon.Get("/v1/runs/"+url.PathEscape(runID), &run); err != nil {
+			return run, err
+		}
+		if run.Finished() {
+			return run, nil
+		}
+		if run.Phase != "" && run.Phase != lastPhase {
+			render.Printf("   ⏳ %s\n", run.Phase)
+			lastPhase = run.Phase
+		}
+
+		select {
+		case <-ctx.Done():
+			// Ctrl+C cancels the daemon-side run instead of abandoning it
+			if !cancelRequested {
+				if err := state.RequestCancel(runID); err != nil {
+					return run, fmt.Errorf("failed to request cancellation: %w", err)
+				}
+				cancelRequested = true
 			}
+		case <-ticker.C:
 		}
 	}
 }
@@ -181,26 +520,37 @@ func getConfigPath() string {
 		return cfgFile
 	}
 
+	// Named profiles must exist; they are never created implicitly
+	if name := activeProfile(); name != "" {
+		path, err := config.FindProfile(name)
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			render.Printf("💡 Create it with: sudo backtide init --profile %s\n", name)
+			os.Exit(1)
+		}
+		return path
+	}
+
 	// Try to find config file in common locations (system config first)
 	if found := config.FindConfigFile(); found != "" {
 		return found
 	}
 
-	// Create system configuration if none exists
-	systemPath := "/etc/backtide/config.toml"
+	// Create the default configuration (system-wide for root) if none exists
+	systemPath := paths.ConfigFile()
 	if _, err := os.Stat(systemPath); os.IsNotExist(err) {
-		fmt.Printf("No configuration file found. Creating system config at %s\n", systemPath)
-		fmt.Println("💡 For production use, system configuration is recommended")
+		render.Printf("No configuration file found. Creating config at %s\n", systemPath)
+		render.Println("💡 For production use, system configuration is recommended")
 		if err := config.CreateDefaultConfig(systemPath); err != nil {
-			fmt.Printf("Error creating system config: %v\n", err)
-			fmt.Println("Falling back to user configuration...")
+			render.Printf("Error creating system config: %v\n", err)
+			render.Println("Falling back to user configuration...")
 
 			// Fall back to user configuration
 			defaultPath := filepath.Join(os.Getenv("HOME"), ".backtide.toml")
 			if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
-				fmt.Printf("Creating user configuration at %s\n", defaultPath)
+				render.Printf("Creating user configuration at %s\n", defaultPath)
 				if err := config.CreateDefaultConfig(defaultPath); err != nil {
-					fmt.Printf("Error creating user config: %v\n", err)
+					render.Printf("Error creating user config: %v\n", err)
 					os.Exit(1)
 				}
 			}
@@ -211,3 +561,28 @@ func getConfigPath() string {
 
 	return systemPath
 }
+
+// jobChoice accepts 'all' or the number of one of n listed jobs
+func jobChoice(n int) prompt.Validator {
+	return func(answer string) error {
+		if answer == "all" {
+			return nil
+		}
+		if prompt.Range(1, n)(answer) != nil {
+			return fmt.Errorf("enter a job number from 1 to %d or 'all'", n)
+		}
+		return nil
+	}
+}
+
+// parseKeepFor parses the lifetime given with --keep-for
+func parseKeepFor(value string) (time.Duration, error) {
+	keepFor, err := utils.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if keepFor <= 0 {
+		return 0, fmt.Errorf("--keep-for must be positive: %s", value)
+	}
+	return keepFor, nil
+}
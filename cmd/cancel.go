@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cancelWait time.Duration
+)
+
+// cancelCmd represents the cancel command
+var cancelCmd = &cobra.Command{
+	Use:   "cancel [job-name|run-id]",
+	Short: "Cancel a running backup",
+	Long: `Gracefully cancel an in-flight backup started by the daemon or a standalone run.
+
+The running process is asked to cancel through its run state file. It stops
+archiving, removes the partial backup data, and restarts any Docker containers
+it stopped before exiting.
+
+Without arguments, the currently running backups are listed.
+
+Examples:
+  backtide cancel
+  backtide cancel daily-backup
+  backtide cancel run-20241201-143000-a1b2c3`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runCancel,
+}
+
+func init() {
+	cancelCmd.Flags().DurationVar(&cancelWait, "wait", 60*time.Second, "how long to wait for the run to stop (0 to return immediately)")
+
+	// Register with command registry
+	commands.RegisterCommand("cancel", cancelCmd)
+}
+
+func runCancel(cmd *cobra.Command, args []string) {
+	runs, err := state.ListRuns()
+	if err != nil {
+		render.Printf("Error reading running backups: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 0 {
+		if len(runs) == 0 {
+			render.Println("No backups are currently running.")
+			return
+		}
+		render.Println("=== Running Backups ===")
+		for _, run := range runs {
+			render.Printf("\n🔄 %s\n", run.ID)
+			render.Printf("   Job: %s\n", run.JobName)
+			render.Printf("   PID: %d\n", run.PID)
+			render.Printf("   Phase: %s\n", run.Phase)
+			render.Printf("   Running for: %s\n", time.Since(run.StartedAt).Round(time.Second))
+		}
+		render.Println("\nUse 'backtide cancel <job-name|run-id>' to cancel a run.")
+		return
+	}
+
+	target := args[0]
+	var matched []state.RunRecord
+	for _, run := range runs {
+		if run.ID == target || run.JobName == target {
+			matched = append(matched, run)
+		}
+	}
+
+	if len(matched) == 0 {
+		render.Printf("No running backup found for '%s'\n", target)
+		os.Exit(1)
+	}
+
+	if dryRun {
+		for _, run := range matched {
+			render.Printf("DRY RUN: Would cancel run %s (job %s, PID %d)\n", run.ID, run.JobName, run.PID)
+		}
+		return
+	}
+
+	for _, run := range matched {
+		if err := state.RequestCancel(run.ID); err != nil {
+			render.Printf("❌ Failed to cancel run %s: %v\n", run.ID, err)
+			os.Exit(1)
+		}
+		render.Printf("🛑 Cancel requested for run %s (job %s, phase %s)\n", run.ID, run.JobName, run.Phase)
+	}
+
+	if cancelWait <= 0 {
+		return
+	}
+
+	render.Println("⏳ Waiting for the backup to stop and restore containers...")
+	deadline := time.Now().Add(cancelWait)
+	for time.Now().Before(deadline) {
+		if !anyRunActive(matched) {
+			render.Println("✅ Backup cancelled")
+			return
+		}
+		time.Sleep(time.Second)
+	}
+
+	render.Printf("⚠️  Run still active after %s; it may be finishing a container restart\n", cancelWait)
+	render.Println("💡 Check again with: backtide cancel")
+	os.Exit(1)
+}
+
+// anyRunActive reports whether any of the given runs is still in progress
+func anyRunActive(targets []state.RunRecord) bool {
+	runs, err := state.ListRuns()
+	if err != nil {
+		return false
+	}
+	for _, run := range runs {
+		for _, target := range targets {
+			if run.ID == target.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
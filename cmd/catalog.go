@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	catalogPaths   []string
+	catalogBuckets []string
+	catalogJob     string
+	catalogLabels  []string
+)
+
+// catalogCmd represents the catalog command
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Show or rebuild the local backup catalog",
+	Long: `The catalog records every backup written or found by this host and where it
+is stored. It is updated by backups and cleanup, and can be rebuilt from the
+metadata stored with the backups themselves.`,
+}
+
+// catalogListCmd represents the catalog list command
+var catalogListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backups recorded in the catalog",
+	Run:   runCatalogList,
+}
+
+// catalogRebuildCmd represents the catalog rebuild command
+var catalogRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Rebuild the catalog by scanning storage locations",
+	Long: `Scan storage locations for backup metadata and replace their catalog
+entries with what is found, for example after /var/lib/backtide was lost or
+backups were copied in from another host.
+
+Without flags the storage locations of all configured jobs are scanned.
+On-demand buckets are mounted while they are scanned.
+
+Examples:
+  backtide catalog rebuild
+  backtide catalog rebuild --path /mnt/restored-backups
+  backtide catalog rebuild --bucket bucket-1234567890`,
+	Run: runCatalogRebuild,
+}
+
+func init() {
+	catalogCmd.AddCommand(catalogListCmd)
+	catalogCmd.AddCommand(catalogRebuildCmd)
+
+	catalogListCmd.Flags().StringVarP(&catalogJob, "job", "j", "", "only list backups of this job")
+	catalogListCmd.Flags().StringArrayVar(&catalogLabels, "label", nil, "only list backups with this key=value label (repeatable)")
+	catalogRebuildCmd.Flags().StringSliceVar(&catalogPaths, "path", nil, "scan this directory (repeatable)")
+	catalogRebuildCmd.Flags().StringSliceVar(&catalogBuckets, "bucket", nil, "scan this bucket by ID or name (repeatable)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(catalogCmd, catalogListCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("catalog", catalogCmd)
+}
+
+func runCatalogList(cmd *cobra.Command, args []string) {
+	selector, err := backup.ParseLabels(catalogLabels)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	entries, err := state.LoadCatalog()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Println("=== Backup Catalog ===")
+	count := 0
+	for _, entry := range entries {
+		if catalogJob != "" && entry.Job != catalogJob {
+			continue
+		}
+		if !backup.MatchesLabels(entry.Labels, selector) {
+			continue
+		}
+		count++
+		render.Printf("\n%s\n", entry.BackupID)
+		render.Printf("   Timestamp: %s\n", entry.Timestamp.Format("2006-01-02 15:04:05"))
+		if entry.Job != "" {
+			render.Printf("   Job: %s\n", entry.Job)
+		}
+		render.Printf("   Location: %s\n", entry.Location)
+		if entry.BucketID != "" {
+			render.Printf("   Bucket: %s\n", entry.BucketID)
+		}
+		if entry.Host != "" {
+			render.Printf("   Host: %s\n", entry.Host)
+		}
+		render.Printf("   Total Size: %s\n", utils.FormatBytes(entry.TotalSize))
+		if entry.Pinned {
+			render.Println("   📌 Pinned (kept by retention cleanup)")
+		} else if !entry.ExpiresAt.IsZero() {
+			render.Printf("   Expires: %s (overrides retention)\n", entry.ExpiresAt.Format("2006-01-02 15:04"))
+		}
+		if entry.Comment != "" {
+			render.Printf("   Comment: %s\n", entry.Comment)
+		}
+		if len(entry.Labels) > 0 {
+			render.Printf("   Labels: %s\n", backup.FormatLabels(entry.Labels))
+		}
+	}
+
+	if count == 0 {
+		render.Println("No backups in the catalog.")
+		render.Println("💡 Run 'backtide catalog rebuild' to scan existing backups")
+		return
+	}
+	render.Printf("\n📊 Total backups: %d\n", count)
+}
+
+func runCatalogRebuild(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		// Scanning an explicit path works without a configuration
+		if len(catalogPaths) == 0 {
+			render.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		cfg = config.DefaultConfig()
+	}
+	runner := backup.NewBackupRunner(*cfg)
+
+	var locations []backup.CatalogLocation
+	for _, path := range catalogPaths {
+		locations = append(locations, backup.CatalogLocation{Path: path})
+	}
+	for _, bucket := range catalogBuckets {
+		location, err := runner.BucketLocation(bucket)
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		locations = append(locations, location)
+	}
+	if len(catalogPaths) == 0 && len(catalogBuckets) == 0 {
+		locations = runner.CatalogLocations()
+	}
+
+	if len(locations) == 0 {
+		render.Println("No storage locations to scan.")
+		return
+	}
+
+	failed := false
+	total := 0
+	for _, location := range locations {
+		if dryRun {
+			render.Printf("DRY RUN: Would scan %s\n", location.Path)
+			continue
+		}
+		count, err := runner.RebuildCatalogLocation(location)
+		if err != nil {
+			render.Printf("❌ %s: %v\n", location.Path, err)
+			failed = true
+			continue
+		}
+		render.Printf("✅ %s: %d backups\n", location.Path, count)
+		total += count
+	}
+
+	if !dryRun {
+		render.Printf("\n📊 Catalog now records %d backups from the scanned locations\n", total)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Nagios plugin exit codes
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+	nagiosUnknown  = 3
+)
+
+// nagiosStates names the exit codes in plugin output
+var nagiosStates = map[int]string{
+	nagiosOK:       "OK",
+	nagiosWarning:  "WARNING",
+	nagiosCritical: "CRITICAL",
+	nagiosUnknown:  "UNKNOWN",
+}
+
+var (
+	checkJob     string
+	checkWarnAge string
+	checkCritAge string
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check a job's backups as a Nagios/Icinga plugin",
+	Long: `Check the newest successful backup of a job on this host and print one line
+in the Nagios plugin format, with perfdata for the backup's age, size and
+duration, exiting 0 (OK), 1 (WARNING), 2 (CRITICAL) or 3 (UNKNOWN).
+
+The backup is WARNING or CRITICAL when older than --warn-age or --crit-age;
+--crit-age defaults to the job's max_age. A job without any successful
+backup is CRITICAL, and a failed run since the last success is at least
+WARNING.
+
+Examples:
+  backtide check --job daily-backup --warn-age 26h --crit-age 50h
+  backtide check --job weekly --crit-age 8d
+
+Icinga 2:
+  object CheckCommand "backtide" {
+    command = [ "/usr/local/bin/backtide", "check" ]
+    arguments = { "--job" = "$backtide_job$", "--warn-age" = "$backtide_warn_age$", "--crit-age" = "$backtide_crit_age$" }
+  }`,
+	Run: runCheck,
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkJob, "job", "", "job to check (required)")
+	checkCmd.Flags().StringVar(&checkWarnAge, "warn-age", "", "WARNING when the newest successful backup is older (e.g., 26h, 2d)")
+	checkCmd.Flags().StringVar(&checkCritAge, "crit-age", "", "CRITICAL when the newest successful backup is older (default: the job's max_age)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(checkCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("check", checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) {
+	code, message := checkJobBackups(time.Now())
+	render.Printf("BACKTIDE %s - %s\n", nagiosStates[code], message)
+	os.Exit(code)
+}
+
+// checkJobBackups evaluates the checked job's run history at now and returns
+// the exit code and the rest of the plugin output
+func checkJobBackups(now time.Time) (int, string) {
+	if checkJob == "" {
+		return nagiosUnknown, "--job is required"
+	}
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		return nagiosUnknown, fmt.Sprintf("failed to load configuration: %v", err)
+	}
+	var job *config.BackupJob
+	for i := range cfg.Jobs {
+		if cfg.Jobs[i].Name == checkJob || cfg.Jobs[i].ID == checkJob {
+			job = &cfg.Jobs[i]
+		}
+	}
+	if job == nil {
+		return nagiosUnknown, fmt.Sprintf("job %s not found", checkJob)
+	}
+
+	var warnAge, critAge time.Duration
+	if checkWarnAge != "" {
+		if warnAge, err = utils.ParseDuration(checkWarnAge); err != nil {
+			return nagiosUnknown, fmt.Sprintf("invalid --warn-age: %v", err)
+		}
+	}
+	critAge = job.MaxAgeDuration()
+	if checkCritAge != "" {
+		if critAge, err = utils.ParseDuration(checkCritAge); err != nil {
+			return nagiosUnknown, fmt.Sprintf("invalid --crit-age: %v", err)
+		}
+	}
+	if warnAge == 0 && critAge == 0 {
+		return nagiosUnknown, fmt.Sprintf("set --warn-age or --crit-age, or max_age for job %s", job.Name)
+	}
+
+	history, err := state.LoadHistory(time.Time{})
+	if err != nil {
+		return nagiosUnknown, err.Error()
+	}
+	var last, success *state.HistoryEntry
+	for i, entry := range history {
+		if entry.Job != job.Name {
+			continue
+		}
+		if last == nil || entry.FinishedAt.After(last.FinishedAt) {
+			last = &history[i]
+		}
+		if entry.State == control.RunSucceeded && (success == nil || entry.FinishedAt.After(success.FinishedAt)) {
+			success = &history[i]
+		}
+	}
+	if !job.Enabled && success == nil {
+		return nagiosUnknown, fmt.Sprintf("%s is disabled and has no successful backup", job.Name)
+	}
+	if success == nil {
+		return nagiosCritical, fmt.Sprintf("%s has no successful backup", job.Name)
+	}
+
+	age := now.Sub(success.FinishedAt)
+	code := nagiosOK
+	switch {
+	case critAge > 0 && age > critAge:
+		code = nagiosCritical
+	case warnAge > 0 && age > warnAge:
+		code = nagiosWarning
+	}
+
+	message := fmt.Sprintf("%s: last success %s ago, %s in %s", job.Name,
+		utils.FormatDuration(age), utils.FormatBytes(success.TotalSize), utils.FormatDuration(success.Duration()))
+	if last != success && last.State == control.RunFailed {
+		if code == nagiosOK {
+			code = nagiosWarning
+		}
+		message += fmt.Sprintf("; last run failed %s ago: %s", utils.FormatDuration(now.Sub(last.FinishedAt)), last.Error)
+	}
+
+	perfdata := []string{
+		fmt.Sprintf("age=%ds;%s;%s;0", int64(age.Seconds()), threshold(warnAge), threshold(critAge)),
+		fmt.Sprintf("size=%dB;;;0", success.TotalSize),
+		fmt.Sprintf("duration=%ds;;;0", int64(success.Duration().Seconds())),
+	}
+	return code, message + " | " + strings.Join(perfdata, " ")
+}
+
+// threshold formats an age threshold for perfdata, empty when unset
+func threshold(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprint(int64(d.Seconds()))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/mitexleo/backtide/internal/backup"
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -45,14 +46,14 @@ func runCleanup(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Check if we have any jobs configured
 	if len(cfg.Jobs) == 0 {
-		fmt.Println("No backup jobs configured.")
-		fmt.Println("Use 'backtide jobs add' to create backup jobs.")
+		render.Println("No backup jobs configured.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
 		return
 	}
 
@@ -61,15 +62,15 @@ func runCleanup(cmd *cobra.Command, args []string) {
 	// Determine which jobs to clean up
 	if cleanupJobName != "" {
 		// Clean up specific job
-		fmt.Printf("Cleaning up backups for job: %s\n", cleanupJobName)
+		render.Printf("Cleaning up backups for job: %s\n", cleanupJobName)
 		if err := backupRunner.RunJobCleanup(cleanupJobName); err != nil {
-			fmt.Printf("Error cleaning up backups: %v\n", err)
+			render.Printf("Error cleaning up backups: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("✅ Cleanup completed for job: %s\n", cleanupJobName)
+		render.Printf("✅ Cleanup completed for job: %s\n", cleanupJobName)
 	} else if cleanupAll {
 		// Clean up all jobs
-		fmt.Println("Cleaning up backups for all jobs...")
+		render.Println("Cleaning up backups for all jobs...")
 		var cleanedJobs int
 		var errors []string
 
@@ -84,36 +85,38 @@ func runCleanup(cmd *cobra.Command, args []string) {
 		}
 
 		if len(errors) > 0 {
-			fmt.Printf("⚠️  Cleanup completed with %d errors:\n", len(errors))
+			render.Printf("⚠️  Cleanup completed with %d errors:\n", len(errors))
 			for _, err := range errors {
-				fmt.Printf("   - %s\n", err)
+				render.Printf("   - %s\n", err)
 			}
 		}
 
-		fmt.Printf("✅ Cleanup completed for %d jobs\n", cleanedJobs)
+		render.Printf("✅ Cleanup completed for %d jobs\n", cleanedJobs)
 	} else {
 		// Show available jobs and let user choose
-		fmt.Println("Available backup jobs for cleanup:")
+		render.Println("Available backup jobs for cleanup:")
 		for i, job := range cfg.Jobs {
 			status := "❌ disabled"
 			if job.Enabled {
 				status = "✅ enabled"
 			}
-			fmt.Printf("%d. %s - %s\n", i+1, job.Name, status)
+			render.Printf("%d. %s - %s\n", i+1, job.Name, status)
 			if job.Description != "" {
-				fmt.Printf("   Description: %s\n", job.Description)
+				render.Printf("   Description: %s\n", job.Description)
 			}
-			fmt.Printf("   Retention: %d days, %d recent, %d monthly\n",
+			render.Printf("   Retention: %d days, %d recent, %d monthly\n",
 				job.Retention.KeepDays, job.Retention.KeepCount, job.Retention.KeepMonthly)
-			fmt.Println()
+			render.Println()
 		}
 
-		fmt.Print("Select job to clean up (number) or 'all' for all enabled jobs: ")
-		var choice string
-		fmt.Scanln(&choice)
+		choice, err := newPrompter().String("Select job to clean up (number) or 'all' for all enabled jobs", "", jobChoice(len(cfg.Jobs)))
+		if err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
 
 		if choice == "all" {
-			fmt.Println("Cleaning up backups for all enabled jobs...")
+			render.Println("Cleaning up backups for all enabled jobs...")
 			var cleanedJobs int
 			var errors []string
 
@@ -128,29 +131,29 @@ func runCleanup(cmd *cobra.Command, args []string) {
 			}
 
 			if len(errors) > 0 {
-				fmt.Printf("⚠️  Cleanup completed with %d errors:\n", len(errors))
+				render.Printf("⚠️  Cleanup completed with %d errors:\n", len(errors))
 				for _, err := range errors {
-					fmt.Printf("   - %s\n", err)
+					render.Printf("   - %s\n", err)
 				}
 			}
 
-			fmt.Printf("✅ Cleanup completed for %d jobs\n", cleanedJobs)
+			render.Printf("✅ Cleanup completed for %d jobs\n", cleanedJobs)
 		} else {
 			var jobIndex int
 			if _, err := fmt.Sscanf(choice, "%d", &jobIndex); err == nil && jobIndex >= 1 && jobIndex <= len(cfg.Jobs) {
 				job := cfg.Jobs[jobIndex-1]
 				if !job.Enabled {
-					fmt.Printf("Job '%s' is disabled. Enable it in the configuration first.\n", job.Name)
+					render.Printf("Job '%s' is disabled. Enable it in the configuration first.\n", job.Name)
 					return
 				}
-				fmt.Printf("Cleaning up backups for job: %s\n", job.Name)
+				render.Printf("Cleaning up backups for job: %s\n", job.Name)
 				if err := backupRunner.RunJobCleanup(job.Name); err != nil {
-					fmt.Printf("Error cleaning up backups: %v\n", err)
+					render.Printf("Error cleaning up backups: %v\n", err)
 					os.Exit(1)
 				}
-				fmt.Printf("✅ Cleanup completed for job: %s\n", job.Name)
+				render.Printf("✅ Cleanup completed for job: %s\n", job.Name)
 			} else {
-				fmt.Println("Invalid selection.")
+				render.Println("Invalid selection.")
 			}
 		}
 	}
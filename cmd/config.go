@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/redact"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateOutput string
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration file",
+	Long:  `Manage the Backtide configuration file.`,
+}
+
+// configMigrateCmd represents the config migrate command
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Convert a legacy configuration to the job/bucket format",
+	Long: `Convert a legacy configuration file into the current job/bucket model.
+
+Legacy files configure top-level 'directories' and a single 's3_config'
+section. They are converted into one job named 'default-backup' and, if S3
+was configured, one bucket. Legacy files still load with a warning, but
+should be migrated so they can be edited with 'backtide jobs' and
+'backtide s3'.
+
+The original file is kept as <file>.legacy.bak unless --output is given.
+
+Examples:
+  backtide config migrate
+  backtide config migrate --config /etc/backtide/old.toml --output /etc/backtide/config.toml
+  backtide config migrate --dry-run`,
+	Run: runConfigMigrate,
+}
+
+func init() {
+	configMigrateCmd.Flags().StringVarP(&migrateOutput, "output", "o", "", "write the migrated configuration to this file instead of in place")
+	configCmd.AddCommand(configMigrateCmd)
+
+	// Safe for read-only users
+	commands.MarkReadOnly(configCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("config", configCmd)
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) {
+	configPath := getConfigPath()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		render.Printf("Error reading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	legacy, ok := config.ParseLegacyConfig(data)
+	if !ok {
+		render.Printf("✅ %s already uses the current configuration format\n", configPath)
+		return
+	}
+
+	migrated := legacy.Migrate()
+	if err := config.ValidateConfig(migrated); err != nil {
+		render.Printf("❌ Migrated configuration is invalid: %v\n", err)
+		render.Println("💡 Fix the legacy file and run the migration again")
+		os.Exit(1)
+	}
+
+	render.Printf("Migrating legacy configuration: %s\n", configPath)
+	render.Printf("   Job: %s (%d directories)\n", migrated.Jobs[0].Name, len(migrated.Jobs[0].Directories))
+	for _, bucket := range migrated.Buckets {
+		render.Printf("   Bucket: %s (%s, mounted at %s)\n", bucket.Name, bucket.Bucket, bucket.MountPoint)
+	}
+
+	if dryRun {
+		out, err := toml.Marshal(redact.Config(migrated))
+		if err != nil {
+			render.Printf("Error rendering configuration: %v\n", err)
+			os.Exit(1)
+		}
+		render.Println("\nDRY RUN: Migrated configuration (not written, secrets masked):")
+		render.Println(string(out))
+		return
+	}
+
+	outputPath := migrateOutput
+	if outputPath == "" {
+		outputPath = configPath
+		backupPath := configPath + ".legacy.bak"
+		if err := os.WriteFile(backupPath, data, 0600); err != nil {
+			render.Printf("❌ Error saving backup of legacy configuration: %v\n", err)
+			os.Exit(1)
+		}
+		render.Printf("💾 Original configuration saved to: %s\n", backupPath)
+	}
+
+	err = config.SaveConfig(migrated, outputPath)
+	audit.RecordResult("config.migrate", outputPath, audit.DiffConfig(nil, migrated), err)
+	if err != nil {
+		render.Printf("❌ Error saving configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Printf("✅ Configuration migrated: %s\n", outputPath)
+	render.Println("💡 Review the job with 'backtide jobs show default-backup'")
+}
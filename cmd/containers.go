@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/docker"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var containersOlderThan string
+
+// containersCmd represents the containers command
+var containersCmd = &cobra.Command{
+	Use:   "containers",
+	Short: "Show Docker containers stopped by backup runs",
+	Long: `Show the Docker containers held stopped by backup runs. Containers held by a
+run whose process has exited were never restarted, usually because backtide
+crashed or the host rebooted mid-backup; they are marked as orphaned.
+
+The daemon restarts orphaned containers on boot and once they have been down
+for [docker] orphan_threshold (default 15m), unless skip_recover is set.`,
+	Run: runContainers,
+}
+
+// containersRecoverCmd represents the containers recover command
+var containersRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Restart containers left stopped by crashed backup runs",
+	Long: `Restart the containers held by backup runs whose process has exited.
+Containers still needed stopped by a backup in progress are left alone.
+
+Examples:
+  backtide containers recover
+  backtide containers recover --older-than 1h`,
+	Run: runContainersRecover,
+}
+
+func init() {
+	containersCmd.AddCommand(containersRecoverCmd)
+
+	containersRecoverCmd.Flags().StringVar(&containersOlderThan, "older-than", "0s", "only recover containers stopped for at least this long")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(containersCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("containers", containersCmd)
+}
+
+func runContainers(cmd *cobra.Command, args []string) {
+	holds, err := state.ContainerHolds()
+	if err != nil {
+		render.Printf("❌ Failed to read container state: %v\n", err)
+		os.Exit(1)
+	}
+	if len(holds) == 0 {
+		render.Println("No containers are held stopped by backup runs")
+		return
+	}
+
+	orphaned := 0
+	for _, hold := range holds {
+		holdState := "🔄 running"
+		if hold.Orphaned() {
+			holdState = "⚠️  orphaned"
+			orphaned++
+		}
+		render.Printf("\n%s (%s, %s)\n", hold.RunID, holdState, utils.FormatDuration(hold.Age()))
+		if hold.Job != "" {
+			render.Printf("   Job: %s\n", hold.Job)
+		}
+		render.Printf("   Containers: %s\n", strings.Join(containerNames(hold), ", "))
+	}
+
+	if orphaned > 0 {
+		render.Printf("\n%d runs left containers stopped; restart them with 'backtide containers recover'\n", orphaned)
+	}
+}
+
+func runContainersRecover(cmd *cobra.Command, args []string) {
+	olderThan, err := utils.ParseDuration(containersOlderThan)
+	if err != nil {
+		render.Printf("Error: invalid --older-than: %v\n", err)
+		os.Exit(1)
+	}
+
+	holds, err := state.OrphanedHolds(olderThan)
+	if err != nil {
+		render.Printf("❌ Failed to read container state: %v\n", err)
+		os.Exit(1)
+	}
+	if len(holds) == 0 {
+		render.Println("No orphaned containers to recover")
+		return
+	}
+
+	if dryRun {
+		for _, hold := range holds {
+			render.Printf("DRY RUN: Would restart containers stopped by %s: %s\n", hold.RunID, strings.Join(containerNames(hold), ", "))
+		}
+		return
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		// Containers can be recovered without a configuration
+		cfg = config.DefaultConfig()
+	}
+
+	released, err := docker.NewDockerManager("", "", cfg.Docker).RecoverOrphaned(olderThan)
+	var runIDs []string
+	for _, hold := range released {
+		runIDs = append(runIDs, hold.RunID)
+	}
+	audit.RecordResult("containers-recover", strings.Join(runIDs, ","), nil, err)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	render.Printf("✅ Recovered containers of %d crashed runs\n", len(released))
+}
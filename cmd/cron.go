@@ -4,9 +4,18 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/schedule"
 	"github.com/spf13/cobra"
 )
 
@@ -14,8 +23,18 @@ var (
 	cronUser     string
 	cronSchedule string
 	cronConfig   string
+	cronTimezone string
+	cronMailTo   string
+	cronJob      string
 )
 
+// cronJobTagPrefix marks the crontab line of a job, followed by the job ID
+const cronJobTagPrefix = "# backtide-job:"
+
+// cronTimezoneTag marks the CRON_TZ lines backtide writes, on the line above
+// each, since cron takes everything after = as the value
+const cronTimezoneTag = "# backtide-timezone"
+
 // cronCmd represents the cron command
 var cronCmd = &cobra.Command{
 	Use:   "cron",
@@ -29,25 +48,49 @@ backup scheduling as an alternative to systemd.`,
 // cronInstallCmd represents the cron install command
 var cronInstallCmd = &cobra.Command{
 	Use:   "install",
-	Short: "Install cron job for automated backups",
-	Long: `Install a cron job for automated backups.
-
-This command will:
-1. Get the absolute path to the backtide binary
-2. Create a cron job entry
-3. Install it in the user's crontab
-
-The cron job will run the backup command according to the specified schedule.`,
+	Short: "Install cron jobs for automated backups",
+	Long: `Install one cron entry per enabled, scheduled backup job.
+
+Each entry runs 'backtide backup --job <name>' on the job's schedule, converted
+to cron fields (daily, weekly, monthly, hourly, cron expressions and intervals
+that divide an hour or a day), in the job's schedule timezone. Entries are
+tagged with the job ID so they can be removed or updated individually.
+
+Schedule timezones are set with CRON_TZ lines, which only cronie honors.
+Backtide tags the CRON_TZ lines it writes, removes only those, and switches
+back to the host timezone after its entries.
+
+Output is appended to /var/log/backtide.log (for other users, backtide.log in
+$XDG_STATE_HOME/backtide). With --mailto, cron also mails a
+failure summary with the end of the log whenever a backup fails; notifier
+plugins configured for a job are notified of its failures either way.
+
+Examples:
+  sudo backtide cron install
+  sudo backtide cron install --mailto ops@example.com
+  sudo backtide cron install --schedule "30 1 * * *"   # same time for all jobs`,
 	Run: runCronInstall,
 }
 
+// cronSyncCmd represents the cron sync command
+var cronSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Update cron entries to match the configured jobs",
+	Long: `Reconcile backtide's cron entries with the configuration: add entries for new
+scheduled jobs, update changed schedules and remove entries of deleted or
+disabled jobs. Run it after editing jobs. An existing MAILTO is kept unless
+--mailto is given.`,
+	Run: runCronSync,
+}
+
 // cronUninstallCmd represents the cron uninstall command
 var cronUninstallCmd = &cobra.Command{
 	Use:   "uninstall",
-	Short: "Uninstall cron job",
-	Long: `Uninstall the backtide cron job.
+	Short: "Uninstall cron jobs",
+	Long: `Uninstall backtide cron jobs.
 
-This command will remove any backtide-related entries from the user's crontab.`,
+This command will remove any backtide-related entries from the user's crontab,
+or only the entry of one job with --job.`,
 	Run: runCronUninstall,
 }
 
@@ -61,282 +104,527 @@ var cronStatusCmd = &cobra.Command{
 
 func init() {
 	cronCmd.AddCommand(cronInstallCmd)
+	cronCmd.AddCommand(cronSyncCmd)
 	cronCmd.AddCommand(cronUninstallCmd)
 	cronCmd.AddCommand(cronStatusCmd)
 
-	cronInstallCmd.Flags().StringVar(&cronUser, "user", "", "user to install cron job for (default: current user)")
-	cronInstallCmd.Flags().StringVar(&cronSchedule, "schedule", "0 2 * * *", "cron schedule expression (default: daily at 2 AM)")
-	cronInstallCmd.Flags().StringVar(&cronConfig, "config", "", "config file path (default: auto-detected)")
+	for _, cmd := range []*cobra.Command{cronInstallCmd, cronSyncCmd} {
+		cmd.Flags().StringVar(&cronSchedule, "schedule", "", "cron schedule expression used for all jobs instead of their own schedules")
+		cmd.Flags().StringVar(&cronConfig, "config", "", "config file path (default: auto-detected)")
+		cmd.Flags().StringVar(&cronTimezone, "timezone", "", "timezone for all cron entries (default: each job's schedule timezone)")
+		cmd.Flags().StringVar(&cronMailTo, "mailto", "", "mail failed backups to this address (sets MAILTO; requires a local MTA)")
+	}
+	for _, cmd := range []*cobra.Command{cronInstallCmd, cronSyncCmd, cronUninstallCmd, cronStatusCmd} {
+		cmd.Flags().StringVar(&cronUser, "user", "", "user whose crontab to manage (default: current user)")
+	}
+	cronUninstallCmd.Flags().StringVarP(&cronJob, "job", "j", "", "only remove the entry of this job (name or ID)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(cronCmd, cronStatusCmd)
 
 	// Register with command registry
 	commands.RegisterCommand("cron", cronCmd)
 }
 
 func runCronInstall(cmd *cobra.Command, args []string) {
-	fmt.Println("Installing cron job...")
+	render.Println("Installing cron jobs...")
 
-	// Get binary path
-	binaryPath, err := os.Executable()
+	lines, err := readCrontab()
 	if err != nil {
-		fmt.Printf("Error getting binary path: %v\n", err)
+		render.Printf("Error reading current crontab: %v\n", err)
 		os.Exit(1)
 	}
+	entries := desiredCronEntries(lines)
 
-	// Get config path
-	if cronConfig == "" {
-		cronConfig = getConfigPath()
+	render.Printf("Installing cron jobs for user: %s\n", cronUser)
+	for _, line := range entries {
+		render.Printf("  %s\n", line)
 	}
 
-	// Validate config exists
-	if _, err := os.Stat(cronConfig); os.IsNotExist(err) {
-		fmt.Printf("Error: Config file not found: %s\n", cronConfig)
-		fmt.Println("Please create a configuration file first or specify with --config")
+	if dryRun {
+		render.Println("DRY RUN: Would replace backtide cron entries with the entries above")
+		return
+	}
+
+	// Replace any existing backtide entries
+	kept, _ := removeBacktideCronEntries(lines, "")
+	if err := writeCrontab(append(kept, entries...)); err != nil {
+		render.Printf("Error installing crontab: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Build the cron command
-	cronCommand := fmt.Sprintf("%s backup --config %s", binaryPath, cronConfig)
+	render.Println("Cron jobs installed successfully!")
+	render.Printf("Logs will be written to: %s\n", paths.LogFile())
+	render.Println("💡 Run 'backtide cron sync' after changing job schedules")
+	render.Println("To verify: crontab -l")
+}
 
-	// Add log redirection for better logging
-	cronCommand += " >> /var/log/backtide.log 2>&1"
+func runCronSync(cmd *cobra.Command, args []string) {
+	lines, err := readCrontab()
+	if err != nil {
+		render.Printf("Error reading current crontab: %v\n", err)
+		os.Exit(1)
+	}
+	entries := desiredCronEntries(lines)
+
+	// Compare with the installed entries
+	var installed []string
+	kept, _ := removeBacktideCronEntries(lines, "")
+	keptSet := make(map[string]bool)
+	for _, line := range kept {
+		keptSet[line] = true
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !keptSet[line] {
+			installed = append(installed, line)
+		}
+	}
 
-	// Create cron entry
-	cronEntry := fmt.Sprintf("%s %s\n", cronSchedule, cronCommand)
+	if strings.Join(installed, "\n") == strings.Join(entries, "\n") {
+		render.Println("✅ Cron entries are up to date")
+		return
+	}
 
-	// Determine which user's crontab to modify
-	if cronUser == "" {
-		cronUser = os.Getenv("USER")
-		if cronUser == "" {
-			cronUser = os.Getenv("LOGNAME")
+	render.Printf("Cron changes for user %s:\n", cronUser)
+	desired := make(map[string]bool)
+	for _, line := range entries {
+		desired[line] = true
+	}
+	current := make(map[string]bool)
+	for _, line := range installed {
+		current[line] = true
+		if !desired[line] {
+			render.Printf("  - %s\n", line)
+		}
+	}
+	for _, line := range entries {
+		if !current[line] {
+			render.Printf("  + %s\n", line)
 		}
 	}
-
-	fmt.Printf("Installing cron job for user: %s\n", cronUser)
-	fmt.Printf("Schedule: %s\n", cronSchedule)
-	fmt.Printf("Command: %s\n", cronCommand)
 
 	if dryRun {
-		fmt.Println("DRY RUN: Would add the following cron entry:")
-		fmt.Println(cronEntry)
+		render.Println("DRY RUN: Crontab not changed")
 		return
 	}
 
-	// Get current crontab
-	var currentCrontab string
-	if cronUser == "root" || os.Geteuid() == 0 {
-		// For root, we can use crontab -l directly
-		cmd := exec.Command("crontab", "-l")
-		output, err := cmd.Output()
-		if err != nil && err.Error() != "exit status 1" {
-			// exit status 1 means no crontab, which is fine
-			fmt.Printf("Error reading current crontab: %v\n", err)
-			os.Exit(1)
+	if err := writeCrontab(append(kept, entries...)); err != nil {
+		render.Printf("Error updating crontab: %v\n", err)
+		os.Exit(1)
+	}
+	render.Println("✅ Cron entries synchronized")
+}
+
+func runCronUninstall(cmd *cobra.Command, args []string) {
+	render.Println("Uninstalling cron jobs...")
+
+	resolveCronUser()
+	tag := ""
+	if cronJob != "" {
+		// Entries are tagged by job ID; accept a job name when the configuration knows it
+		tag = cronJob
+		if cfg, err := config.LoadConfig(getConfigPath()); err == nil {
+			if job := findJobByName(cfg, cronJob); job != nil && job.ID != "" {
+				tag = job.ID
+			}
 		}
-		currentCrontab = string(output)
+		render.Printf("Removing cron entry of job %s for user: %s\n", cronJob, cronUser)
 	} else {
-		// For non-root users, we need to use sudo if installing for different user
-		if cronUser != os.Getenv("USER") {
-			fmt.Printf("Error: Cannot install cron job for user '%s' without root privileges\n", cronUser)
-			os.Exit(1)
-		}
-		cmd := exec.Command("crontab", "-l")
-		output, err := cmd.Output()
-		if err != nil && err.Error() != "exit status 1" {
-			fmt.Printf("Error reading current crontab: %v\n", err)
-			os.Exit(1)
-		}
-		currentCrontab = string(output)
+		render.Printf("Removing backtide cron jobs for user: %s\n", cronUser)
 	}
 
-	// Remove any existing backtide entries
-	lines := strings.Split(currentCrontab, "\n")
-	var newCrontabLines []string
-	for _, line := range lines {
-		if !strings.Contains(line, "backtide") && strings.TrimSpace(line) != "" {
-			newCrontabLines = append(newCrontabLines, line)
-		}
+	if dryRun {
+		render.Println("DRY RUN: Would remove the matching backtide entries from crontab")
+		return
 	}
 
-	// Add the new entry
-	newCrontabLines = append(newCrontabLines, cronEntry)
-	newCrontab := strings.Join(newCrontabLines, "\n") + "\n"
+	lines, err := readCrontab()
+	if err != nil {
+		render.Printf("Error reading current crontab: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Install new crontab
-	if cronUser == "root" || os.Geteuid() == 0 {
-		// For root, we can write directly
-		cmd := exec.Command("crontab", "-")
-		cmd.Stdin = strings.NewReader(newCrontab)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			fmt.Printf("Error installing crontab: %v\n", string(output))
-			os.Exit(1)
-		}
-	} else {
-		// For current user
-		cmd := exec.Command("crontab", "-")
-		cmd.Stdin = strings.NewReader(newCrontab)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			fmt.Printf("Error installing crontab: %v\n", string(output))
-			os.Exit(1)
+	kept, removedCount := removeBacktideCronEntries(lines, tag)
+	if err := writeCrontab(kept); err != nil {
+		render.Printf("Error updating crontab: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cronJob != "" && removedCount == 0 {
+		render.Printf("⚠️  No cron entry found for job %s\n", cronJob)
+		return
+	}
+	render.Printf("Cron job uninstalled successfully! Removed %d entries\n", removedCount)
+}
+
+func runCronStatus(cmd *cobra.Command, args []string) {
+	render.Println("Checking cron job status...")
+
+	resolveCronUser()
+	render.Printf("Cron jobs for user: %s\n", cronUser)
+
+	lines, err := readCrontab()
+	if err != nil {
+		render.Printf("Error reading crontab: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Find backtide entries
+	var backtideEntries []string
+	for _, line := range lines {
+		if isBacktideCronLine(line) {
+			backtideEntries = append(backtideEntries, line)
 		}
 	}
 
-	// Create log directory if it doesn't exist
-	logDir := "/var/log"
-	if err := os.MkdirAll(logDir, 0755); err != nil && !os.IsExist(err) {
-		fmt.Printf("Warning: Could not create log directory: %v\n", err)
+	if len(backtideEntries) == 0 {
+		render.Println("No backtide cron jobs found")
+		return
+	}
+
+	render.Printf("Found %d backtide cron job(s):\n", len(backtideEntries))
+	for i, entry := range backtideEntries {
+		render.Printf("  %d. %s\n", i+1, strings.TrimSpace(entry))
 	}
 
-	fmt.Println("Cron job installed successfully!")
-	fmt.Printf("Logs will be written to: %s\n", "/var/log/backtide.log")
-	fmt.Println("To verify: crontab -l")
+	// Check if cron service is running
+	render.Println("\nCron service status:")
+	if output, err := exec.Command("systemctl", "is-active", "cron").Output(); err == nil {
+		render.Printf("  cron service: %s", string(output))
+	} else if output, err := exec.Command("systemctl", "is-active", "crond").Output(); err == nil {
+		render.Printf("  crond service: %s", string(output))
+	} else {
+		render.Println("  cron service: unknown (neither cron nor crond service found)")
+	}
 }
 
-func runCronUninstall(cmd *cobra.Command, args []string) {
-	fmt.Println("Uninstalling cron job...")
+// desiredCronEntries builds the crontab lines for all enabled, scheduled jobs,
+// exiting on configuration errors. existing is the current crontab, whose MAILTO
+// is kept when --mailto is not given.
+func desiredCronEntries(existing []string) []string {
+	binaryPath, err := os.Executable()
+	if err != nil {
+		render.Printf("Error getting binary path: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Determine which user's crontab to modify
-	if cronUser == "" {
-		cronUser = os.Getenv("USER")
-		if cronUser == "" {
-			cronUser = os.Getenv("LOGNAME")
+	if cronConfig == "" {
+		cronConfig = getConfigPath()
+	}
+	if absPath, err := filepath.Abs(cronConfig); err == nil {
+		cronConfig = absPath
+	}
+	cfg, err := config.LoadConfig(cronConfig)
+	if err != nil {
+		render.Printf("Error loading configuration %s: %v\n", cronConfig, err)
+		render.Println("Please create a configuration file first or specify with --config")
+		os.Exit(1)
+	}
+
+	if cronTimezone != "" {
+		if _, err := time.LoadLocation(cronTimezone); err != nil {
+			render.Printf("Error: Invalid timezone %q: %v\n", cronTimezone, err)
+			os.Exit(1)
 		}
 	}
+	if cronMailTo == "" {
+		cronMailTo = installedMailTo(existing)
+	}
+	if strings.ContainsAny(cronMailTo, " \t\n") {
+		render.Printf("Error: Invalid --mailto address: %q\n", cronMailTo)
+		os.Exit(1)
+	}
 
-	fmt.Printf("Removing backtide cron jobs for user: %s\n", cronUser)
+	// Scheduled runs of non-root users log below their XDG state directory
+	logFile := paths.LogFile()
+	if !dryRun {
+		os.MkdirAll(filepath.Dir(logFile), 0755)
+	}
 
-	if dryRun {
-		fmt.Println("DRY RUN: Would remove all backtide entries from crontab")
-		return
+	type cronEntry struct {
+		timezone string
+		line     string
 	}
+	var entries []cronEntry
+	for _, job := range cfg.Jobs {
+		if !job.Enabled || !job.Schedule.Enabled {
+			continue
+		}
 
-	// Get current crontab
-	var currentCrontab string
-	if cronUser == "root" || os.Geteuid() == 0 {
-		cmd := exec.Command("crontab", "-l")
-		output, err := cmd.Output()
-		if err != nil && err.Error() != "exit status 1" {
-			fmt.Printf("Error reading current crontab: %v\n", err)
-			os.Exit(1)
+		expr := cronSchedule
+		if expr == "" {
+			sched, err := schedule.Parse(job.Schedule)
+			if err != nil {
+				render.Printf("⚠️  Skipping job %s: %v\n", job.Name, err)
+				continue
+			}
+			if expr, err = sched.CronExpression(); err != nil {
+				render.Printf("⚠️  Skipping job %s: %v; use the daemon or --schedule\n", job.Name, err)
+				continue
+			}
 		}
-		currentCrontab = string(output)
-	} else {
-		if cronUser != os.Getenv("USER") {
-			fmt.Printf("Error: Cannot modify cron job for user '%s' without root privileges\n", cronUser)
-			os.Exit(1)
+
+		command := fmt.Sprintf("%s backup --config %s --job %s", binaryPath, cronConfig, shellQuote(job.Name))
+		if offset := jitterOffset(job); offset > 0 {
+			// Cron has no random delay; start at this host's fixed offset
+			command = fmt.Sprintf("sleep %d && %s", int(offset.Seconds()), command)
 		}
-		cmd := exec.Command("crontab", "-l")
-		output, err := cmd.Output()
-		if err != nil && err.Error() != "exit status 1" {
-			fmt.Printf("Error reading current crontab: %v\n", err)
-			os.Exit(1)
+		if name := activeProfile(); name != "" {
+			command += " --profile " + name
+		}
+		command += fmt.Sprintf(" >> %s 2>&1", shellQuote(logFile))
+
+		// Cron mails whatever a job prints, so only print on failure
+		if cronMailTo != "" {
+			command += fmt.Sprintf(` || { echo "backtide backup of job %s failed with exit code $?; last lines of the log:"; tail -n 50 %s; }`,
+				strings.ReplaceAll(job.Name, `"`, ""), shellQuote(logFile))
+		}
+
+		timezone := cronTimezone
+		if timezone == "" {
+			timezone = job.Schedule.Timezone
 		}
-		currentCrontab = string(output)
+		entries = append(entries, cronEntry{
+			timezone: timezone,
+			line:     fmt.Sprintf("%s %s %s%s", expr, command, cronJobTagPrefix, cronJobKey(job)),
+		})
 	}
 
-	// Remove backtide entries
-	lines := strings.Split(currentCrontab, "\n")
-	var newCrontabLines []string
-	removedCount := 0
-	for _, line := range lines {
-		if strings.Contains(line, "backtide") {
-			removedCount++
-			continue
+	if len(entries) == 0 {
+		render.Println("Error: No enabled jobs with a schedule that cron can run")
+		render.Println("💡 Enable schedules with 'backtide jobs edit' or use --schedule")
+		os.Exit(1)
+	}
+
+	if cronMailTo != "" {
+		if _, err := exec.LookPath("sendmail"); err != nil {
+			if _, err := os.Stat("/usr/sbin/sendmail"); err != nil {
+				render.Println("⚠️  No sendmail found; cron cannot deliver mail until an MTA (e.g., postfix, msmtp-mta) is installed")
+			}
 		}
-		if strings.TrimSpace(line) != "" {
-			newCrontabLines = append(newCrontabLines, line)
+	} else if !hasNotifierPlugins(cfg) {
+		render.Printf("⚠️  Failures will only be written to %s\n", logFile)
+		render.Println("💡 Use --mailto or configure a notifier plugin so failed backups reach someone")
+	}
+
+	// CRON_TZ applies to all following lines, so entries in the host timezone go first
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].timezone == "" && entries[j].timezone != ""
+	})
+
+	var lines []string
+	if cronMailTo != "" {
+		lines = append(lines, "MAILTO="+cronMailTo)
+	}
+	resetTimezone := func() {
+		if host := hostTimezone(); host != "" {
+			lines = append(lines, cronTimezoneTag, "CRON_TZ="+host)
+		} else {
+			render.Println("⚠️  Could not determine the host timezone to reset CRON_TZ to")
 		}
 	}
 
-	newCrontab := strings.Join(newCrontabLines, "\n")
-	if newCrontab != "" {
-		newCrontab += "\n"
+	// A CRON_TZ of the user's further up would also apply to backtide's entries
+	kept, _ := removeBacktideCronEntries(existing, "")
+	inherited := slices.ContainsFunc(kept, func(line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), "CRON_TZ=")
+	})
+	zoned := false
+	for i, entry := range entries {
+		if entry.timezone != "" {
+			lines = append(lines, cronTimezoneTag, "CRON_TZ="+entry.timezone)
+			zoned = true
+		} else if i == 0 && inherited {
+			resetTimezone()
+		}
+		lines = append(lines, entry.line)
 	}
+	if zoned {
+		render.Println("⚠️  Schedule timezones are set with CRON_TZ, which only cronie honors; other cron daemons use the host timezone")
+		// Switch back so lines added after backtide's entries keep the host timezone
+		resetTimezone()
+	}
+	return lines
+}
 
-	// Install updated crontab
-	if cronUser == "root" || os.Geteuid() == 0 {
-		cmd := exec.Command("crontab", "-")
-		cmd.Stdin = strings.NewReader(newCrontab)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			fmt.Printf("Error updating crontab: %v\n", string(output))
-			os.Exit(1)
+// hostTimezone returns the name of the host's timezone, or "" if unknown
+func hostTimezone() string {
+	if data, err := os.ReadFile("/etc/timezone"); err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" {
+			return name
 		}
-	} else {
-		cmd := exec.Command("crontab", "-")
-		cmd.Stdin = strings.NewReader(newCrontab)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			fmt.Printf("Error updating crontab: %v\n", string(output))
-			os.Exit(1)
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
 		}
 	}
+	return ""
+}
 
-	fmt.Printf("Cron job uninstalled successfully! Removed %d entries\n", removedCount)
+// cronJobKey returns the tag identifying a job's cron entry
+func cronJobKey(job config.BackupJob) string {
+	if job.ID != "" {
+		return job.ID
+	}
+	return job.Name
 }
 
-func runCronStatus(cmd *cobra.Command, args []string) {
-	fmt.Println("Checking cron job status...")
+// shellQuote quotes a value for /bin/sh when it contains special characters
+func shellQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n'\"\\$`&|;<>()*?[]#~%") {
+		return value
+	}
+	// Cron treats % as a newline unless escaped
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, "'", `'\''`), "%", `\%`) + "'"
+}
 
-	// Determine which user's crontab to check
+// isBacktideCronLine reports whether a crontab line is a backtide entry
+func isBacktideCronLine(line string) bool {
+	return strings.Contains(line, "backtide") && strings.TrimSpace(line) != cronTimezoneTag
+}
+
+// installedMailTo returns the MAILTO set directly above backtide's entries, if any
+func installedMailTo(lines []string) string {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if mailTo, ok := strings.CutPrefix(line, "MAILTO="); ok {
+			for _, next := range lines[i+1:] {
+				next = strings.TrimSpace(next)
+				if next == cronTimezoneTag || strings.HasPrefix(next, "CRON_TZ=") {
+					continue
+				}
+				if isBacktideCronLine(next) {
+					return mailTo
+				}
+				break
+			}
+		}
+	}
+	return ""
+}
+
+// resolveCronUser defaults --user to the current user
+func resolveCronUser() {
 	if cronUser == "" {
 		cronUser = os.Getenv("USER")
 		if cronUser == "" {
 			cronUser = os.Getenv("LOGNAME")
 		}
+		if current, err := user.Current(); cronUser == "" && err == nil {
+			cronUser = current.Username
+		}
 	}
+}
 
-	fmt.Printf("Cron jobs for user: %s\n", cronUser)
-
-	// Get current crontab
-	var cmdOutput []byte
-	var err error
-	if cronUser == "root" || os.Geteuid() == 0 {
-		cmd := exec.Command("crontab", "-l")
-		cmdOutput, err = cmd.Output()
-	} else {
-		if cronUser != os.Getenv("USER") {
-			fmt.Printf("Error: Cannot read cron jobs for user '%s' without root privileges\n", cronUser)
-			os.Exit(1)
+// crontabArgs returns the crontab arguments selecting --user's crontab
+func crontabArgs(args ...string) ([]string, error) {
+	resolveCronUser()
+	if os.Geteuid() == 0 {
+		if cronUser != "" && cronUser != "root" {
+			return append([]string{"-u", cronUser}, args...), nil
 		}
-		cmd := exec.Command("crontab", "-l")
-		cmdOutput, err = cmd.Output()
+		return args, nil
+	}
+	if current, err := user.Current(); err == nil && cronUser != current.Username {
+		return nil, fmt.Errorf("cannot manage cron jobs for user '%s' without root privileges", cronUser)
 	}
+	return args, nil
+}
 
+// readCrontab returns the lines of --user's crontab, empty if there is none
+func readCrontab() ([]string, error) {
+	args, err := crontabArgs("-l")
+	if err != nil {
+		return nil, err
+	}
+	output, err := exec.Command("crontab", args...).Output()
 	if err != nil {
+		// exit status 1 means no crontab, which is fine
 		if err.Error() == "exit status 1" {
-			fmt.Println("No crontab found for this user")
-			return
+			return nil, nil
 		}
-		fmt.Printf("Error reading crontab: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n"), nil
+}
 
-	currentCrontab := string(cmdOutput)
-	lines := strings.Split(currentCrontab, "\n")
+// writeCrontab installs lines as --user's crontab
+func writeCrontab(lines []string) error {
+	args, err := crontabArgs("-")
+	if err != nil {
+		return err
+	}
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
 
-	// Find backtide entries
-	var backtideEntries []string
-	for _, line := range lines {
-		if strings.Contains(line, "backtide") {
-			backtideEntries = append(backtideEntries, line)
+	cmd := exec.Command("crontab", args...)
+	cmd.Stdin = strings.NewReader(content)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// removeBacktideCronEntries filters backtide entries, the CRON_TZ lines
+// backtide wrote and the MAILTO line that precedes them out of crontab lines.
+// With a job key only that job's entry and its CRON_TZ are removed. CRON_TZ
+// lines without backtide's tag belong to the user and are kept.
+func removeBacktideCronEntries(lines []string, jobKey string) ([]string, int) {
+	remove := make([]bool, len(lines))
+	removedCount := 0
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if jobKey == "" && isBacktideCronLine(line) || jobKey != "" && strings.HasSuffix(line, cronJobTagPrefix+jobKey) {
+			remove[i] = true
+			removedCount++
 		}
 	}
 
-	if len(backtideEntries) == 0 {
-		fmt.Println("No backtide cron jobs found")
-		return
+	// Tagged CRON_TZ lines go with all entries, or with the one entry below
+	for i := 0; i+1 < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != cronTimezoneTag || !strings.HasPrefix(strings.TrimSpace(lines[i+1]), "CRON_TZ=") {
+			continue
+		}
+		if jobKey == "" || i+2 < len(lines) && remove[i+2] {
+			remove[i], remove[i+1] = true, true
+		}
 	}
 
-	fmt.Printf("Found %d backtide cron job(s):\n", len(backtideEntries))
-	for i, entry := range backtideEntries {
-		fmt.Printf("  %d. %s\n", i+1, strings.TrimSpace(entry))
+	// MAILTO directly above the removed entries
+	if jobKey == "" {
+		for i := len(lines) - 2; i >= 0; i-- {
+			if strings.HasPrefix(strings.TrimSpace(lines[i]), "MAILTO=") && remove[i+1] {
+				remove[i] = true
+			}
+		}
 	}
 
-	// Check if cron service is running
-	fmt.Println("\nCron service status:")
-	if output, err := exec.Command("systemctl", "is-active", "cron").Output(); err == nil {
-		fmt.Printf("  cron service: %s", string(output))
-	} else if output, err := exec.Command("systemctl", "is-active", "crond").Output(); err == nil {
-		fmt.Printf("  crond service: %s", string(output))
-	} else {
-		fmt.Println("  cron service: unknown (neither cron nor crond service found)")
+	var kept []string
+	for i, line := range lines {
+		if !remove[i] && strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return kept, removedCount
+}
+
+// hasNotifierPlugins reports whether any enabled job notifies a plugin of failures
+func hasNotifierPlugins(cfg *config.BackupConfig) bool {
+	notifiers := make(map[string]bool)
+	for _, plugin := range cfg.Plugins {
+		if plugin.Type == "notifier" {
+			notifiers[plugin.Name] = true
+		}
+	}
+	for _, job := range cfg.Jobs {
+		for _, name := range job.Plugins {
+			if job.Enabled && notifiers[name] {
+				return true
+			}
+		}
 	}
+	return false
 }
@@ -2,16 +2,25 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"strings"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/mitexleo/backtide/internal/access"
 	"github.com/mitexleo/backtide/internal/backup"
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3fs"
+	"github.com/mitexleo/backtide/internal/schedule"
+	"github.com/mitexleo/backtide/internal/state"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +36,7 @@ This daemon:
 - Acts as "our own cron" - no external scheduling dependencies
 - Automatically runs jobs according to their configured schedules
 - Handles dynamic job configuration changes
+- Checks S3 mounts and remounts broken s3fs mounts
 
 The daemon reads the configuration file and runs each backup job
 according to its individual schedule.`,
@@ -39,10 +49,10 @@ func init() {
 }
 
 func runDaemon(cmd *cobra.Command, args []string) {
-	fmt.Println("🚀 Starting Backtide Scheduling Daemon...")
-	fmt.Println("📋 Internal cron: Managing ALL backup job schedules")
-	fmt.Println("💡 Use Ctrl+C to stop the daemon")
-	fmt.Println()
+	render.Println("🚀 Starting Backtide Scheduling Daemon...")
+	render.Println("📋 Internal cron: Managing ALL backup job schedules")
+	render.Println("💡 Use Ctrl+C to stop the daemon")
+	render.Println()
 
 	// Set up signal handling for graceful shutdown
 	signalChan := make(chan os.Signal, 1)
@@ -52,61 +62,169 @@ func runDaemon(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("❌ Error loading configuration: %v\n", err)
+		render.Printf("❌ Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Create and start job scheduler
 	scheduler := NewJobScheduler(cfg)
 	if err := scheduler.Start(); err != nil {
-		fmt.Printf("❌ Error starting scheduler: %v\n", err)
+		render.Printf("❌ Error starting scheduler: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("✅ Daemon started successfully!")
-	fmt.Printf("📊 Monitoring %d backup jobs\n", len(cfg.Jobs))
-	fmt.Println()
+	// Start the control API so CLI commands can delegate runs to the daemon
+	apiServer := control.NewServer(state.SocketPath())
+	if gid, err := access.SocketGID(cfg.Access); err != nil {
+		render.Printf("⚠️  %v; socket restricted to root\n", err)
+	} else if gid >= 0 {
+		apiServer.SetSocketGroup(gid)
+	}
+	scheduler.RegisterAPI(apiServer)
+	if err := apiServer.Start(); err != nil {
+		render.Printf("⚠️  Control API unavailable: %v\n", err)
+		apiServer = nil
+	} else {
+		render.Printf("🔌 Control API listening on %s\n", apiServer.SocketPath())
+	}
+
+	render.Println("✅ Daemon started successfully!")
+	render.Printf("📊 Monitoring %d backup jobs\n", len(cfg.Jobs))
+	render.Println()
 
 	// Wait for shutdown signal
 	<-signalChan
 
-	fmt.Println("\n🛑 Shutting down daemon...")
-	scheduler.Stop()
-	fmt.Println("✅ Daemon stopped gracefully")
+	render.Println("\n🛑 Shutting down daemon...")
+	if apiServer != nil {
+		apiServer.Stop()
+	}
+	scheduler.Stop(daemonStopTimeout)
+	render.Println("✅ Daemon stopped gracefully")
 }
 
+// daemonStopTimeout is how long the daemon waits for cancelled runs to stop;
+// systemd kills the service after 90 seconds by default
+const daemonStopTimeout = 75 * time.Second
+
+// misfireTolerance is how late a scheduled run may start and still count as
+// on time rather than missed
+const misfireTolerance = 5 * time.Minute
+
 // JobScheduler manages the scheduling and execution of ALL backup jobs
 type JobScheduler struct {
-	config   *config.BackupConfig
-	stopChan chan struct{}
-	ticker   *time.Ticker
-	lastRun  map[string]time.Time
+	config    *config.BackupConfig
+	stopChan  chan struct{}
+	ticker    *time.Ticker
+	lastRun   map[string]time.Time
+	startedAt time.Time
+	// pauseNotified tracks paused jobs already reported, to avoid logging every tick
+	pauseNotified map[string]bool
+	// orphanNotified tracks container holds of crashed runs already reported
+	orphanNotified map[string]bool
+	// overdueNotified holds, per job reported as overdue, the last success at
+	// the time, so each breach of max_age is reported once
+	overdueNotified map[string]time.Time
+	// digestRetryAt delays resending a digest that failed to send
+	digestRetryAt time.Time
+	// uploading is set while backups are uploaded from the outbox
+	uploading atomic.Bool
+	// replicating is set while backups are copied to secondary buckets
+	replicating atomic.Bool
+	// verifying is set while the verify agent checks its buckets
+	verifying atomic.Bool
+
+	// mu guards config, runs and active, which are shared with the control API
+	mu   sync.Mutex
+	runs map[string]*control.RunStatus
+	// active holds the queued or running run of each job, so a job is never
+	// launched twice at once
+	active map[string]activeRun
+	// workers counts the run goroutines, so Stop can wait for them
+	workers sync.WaitGroup
+
+	// mounts supervises the S3 mounts used by scheduled jobs
+	mounts *s3fs.Supervisor
+
+	// updateCheck is the latest backtide release seen, guarded by mu
+	updateCheck state.UpdateCheck
+
+	// approvalKey signs restore approval tokens; redeemed holds the nonces of
+	// redeemed tokens until they expire. Both are guarded by mu.
+	approvalKey []byte
+	redeemed    map[string]time.Time
+}
+
+// activeRun is a run the daemon is executing
+type activeRun struct {
+	id     string
+	cancel context.CancelFunc
 }
 
 // NewJobScheduler creates a new job scheduler
 func NewJobScheduler(cfg *config.BackupConfig) *JobScheduler {
 	return &JobScheduler{
-		config:   cfg,
-		stopChan: make(chan struct{}),
-		ticker:   time.NewTicker(1 * time.Minute), // Check every minute
-		lastRun:  make(map[string]time.Time),
+		config:        cfg,
+		stopChan:      make(chan struct{}),
+		ticker:        time.NewTicker(1 * time.Minute), // Check every minute
+		lastRun:       make(map[string]time.Time),
+		startedAt:     time.Now(),
+		pauseNotified: make(map[string]bool),
+		runs:          make(map[string]*control.RunStatus),
+		active:        make(map[string]activeRun),
+
+		orphanNotified:  make(map[string]bool),
+		overdueNotified: make(map[string]time.Time),
+		redeemed:        make(map[string]time.Time),
 	}
 }
 
 // Start begins the scheduling loop
 func (js *JobScheduler) Start() error {
-	fmt.Println("⏰ Starting internal job scheduler...")
+	render.Println("⏰ Starting internal job scheduler...")
+
+	// Containers left stopped by a run that crashed before this boot
+	js.checkOrphanedContainers()
 
 	// Start the scheduling loop in a goroutine
 	go js.schedulingLoop()
 
+	// Watch S3 mounts so broken FUSE mounts are repaired before jobs need them
+	js.startMountSupervisor()
+
+	// Look up new releases for the status API and fleet reports
+	js.startUpdateChecker()
+
 	return nil
 }
 
-// Stop gracefully stops the scheduler
-func (js *JobScheduler) Stop() {
+// Stop gracefully stops the scheduler, cancelling the runs in progress and
+// waiting up to timeout for them to clean up and restart their containers
+func (js *JobScheduler) Stop(timeout time.Duration) {
 	close(js.stopChan)
 	js.ticker.Stop()
+
+	js.mu.Lock()
+	for _, active := range js.active {
+		active.cancel()
+	}
+	count := len(js.active)
+	js.mu.Unlock()
+	if count == 0 {
+		return
+	}
+
+	render.Printf("⏳ Waiting for %d running backups to stop...\n", count)
+	done := make(chan struct{})
+	go func() {
+		js.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		render.Printf("⚠️  Backups still running after %s; stopping anyway\n", timeout)
+	}
 }
 
 // schedulingLoop is the main scheduling logic
@@ -123,89 +241,267 @@ func (js *JobScheduler) schedulingLoop() {
 
 // checkAndRunJobs checks if any jobs are due to run and executes them
 func (js *JobScheduler) checkAndRunJobs() {
-	// Reload configuration to pick up any changes
-	configPath := getConfigPath()
-	if cfg, err := config.LoadConfig(configPath); err == nil {
-		js.config = cfg
+	cfg := js.reloadConfig()
+	now := time.Now()
+
+	js.checkOrphanedContainers()
+	js.alertOverdueJobs(cfg)
+	js.sendDigestIfDue(cfg)
+	js.uploadOutbox(cfg)
+	js.replicateBackups(cfg)
+	js.verifyBucketsIfDue(cfg)
+
+	// Clear maintenance flags left by runs that were killed
+	if err := state.SyncMaintenance(); err != nil {
+		render.Printf("⚠️  Failed to update maintenance flag: %v\n", err)
 	}
 
-	now := time.Now()
+	// Job timers run scheduled backups; only keep them up to date
+	if cfg.Systemd.SyncTimers {
+		js.syncJobTimers(cfg)
+		return
+	}
 
-	for _, job := range js.config.Jobs {
+	for _, job := range cfg.Jobs {
 		if !job.Enabled || !job.Schedule.Enabled {
 			continue
 		}
 
+		// Skip jobs paused with 'backtide pause'; skipped runs count as run, so
+		// the misfire policy does not catch them up when the pause ends
+		if pause, paused := state.ActivePause(job.Name); paused {
+			if js.isJobDue(job, now) {
+				if !js.pauseNotified[job.Name] {
+					render.Printf("⏸️  Skipping scheduled backup %s: paused until %s\n", job.Name, pause.Until.Format("2006-01-02 15:04:05"))
+					js.pauseNotified[job.Name] = true
+				}
+				js.lastRun[job.Name] = now
+			}
+			continue
+		}
+		delete(js.pauseNotified, job.Name)
+
 		// Check if this job is due to run
 		if js.isJobDue(job, now) {
-			fmt.Printf("🔄 Running scheduled backup: %s\n", job.Name)
-			go js.runBackupJob(job) // Run in goroutine to not block other jobs
+			// Runs in a goroutine to not block other jobs
+			if _, err := js.startRun(cfg, job, "schedule", backup.Annotations{}); err != nil {
+				render.Printf("⏭️  Skipping scheduled backup %s: %v\n", job.Name, err)
+			} else {
+				render.Printf("🔄 Running scheduled backup: %s\n", job.Name)
+			}
 			js.lastRun[job.Name] = now
 		}
 	}
 }
 
-// isJobDue checks if a job should run based on its schedule and last run time
+// isJobDue checks if a job should run based on its schedule and last run time,
+// applying the job's misfire policy to runs that were missed
 func (js *JobScheduler) isJobDue(job config.BackupJob, now time.Time) bool {
+	policy := job.Schedule.Misfire()
 	lastRun, exists := js.lastRun[job.Name]
-
-	// If never run before, schedule it
 	if !exists {
+		// Continue from the run history, so a restart does not rerun every job
+		entry := lastJobRun(job.Name)
+		switch {
+		case entry != nil:
+			lastRun = entry.StartedAt
+		case policy == config.MisfireRunImmediately:
+			// Never run before, schedule it
+			return true
+		default:
+			lastRun = now
+		}
+		js.lastRun[job.Name] = lastRun
+	}
+
+	// Parse the schedule, evaluated in the job's configured timezone
+	sched, err := schedule.Parse(job.Schedule)
+	if err != nil {
+		render.Printf("⚠️  Could not parse schedule for job %s: %v, defaulting to daily\n", job.Name, err)
+		sched, err = schedule.Parse(config.ScheduleConfig{Interval: "daily", Timezone: job.Schedule.Timezone})
+		if err != nil {
+			sched, _ = schedule.Parse(config.ScheduleConfig{Interval: "daily"})
+		}
+	}
+
+	// Calendar runs start late by this host's jitter; interval runs count from
+	// the last run, which already carries it
+	var offset time.Duration
+	if !sched.IsInterval() {
+		offset = jitterOffset(job)
+	}
+
+	// Check if the next scheduled time after the last run has passed
+	if now.Before(sched.Next(lastRun).Add(offset)) {
+		return false
+	}
+
+	// A run is late when the host was off or asleep or the daemon was down
+	scheduled := sched.Previous(lastRun, now.Add(-offset))
+	late := now.Sub(scheduled.Add(offset))
+	if scheduled.IsZero() || late <= misfireTolerance || policy == config.MisfireRunImmediately {
 		return true
 	}
+	if policy == config.MisfireNextWindow && late <= sched.Next(scheduled).Sub(scheduled)/2 {
+		return true
+	}
+
+	render.Printf("⏭️  Skipping missed backup %s scheduled at %s (misfire_policy %s)\n",
+		job.Name, scheduled.Format("2006-01-02 15:04:05"), policy)
+	js.lastRun[job.Name] = now
+	return false
+}
 
-	// Parse the schedule interval
-	duration, err := parseScheduleInterval(job.Schedule.Interval)
+// jitterOffset returns how long this host delays the scheduled runs of a job
+// within the job's schedule jitter
+func jitterOffset(job config.BackupJob) time.Duration {
+	jitter, err := job.Schedule.JitterDuration()
 	if err != nil {
-		fmt.Printf("⚠️  Could not parse schedule for job %s: %v, defaulting to daily\n", job.Name, err)
-		duration = 24 * time.Hour
+		return 0
 	}
+	return schedule.JitterOffset(jitter, backup.MachineID()+backup.Hostname()+cronJobKey(job))
+}
 
-	// Check if enough time has passed since last run
-	return now.Sub(lastRun) >= duration
+// reloadConfig re-reads the configuration to pick up any changes
+func (js *JobScheduler) reloadConfig() *config.BackupConfig {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if cfg, err := config.LoadConfig(getConfigPath()); err == nil {
+		js.config = cfg
+	}
+	return js.config
 }
 
-// parseScheduleInterval parses human-readable schedule intervals
-func parseScheduleInterval(interval string) (time.Duration, error) {
-	// First try to parse as Go duration (e.g., "24h", "1h30m")
-	if duration, err := time.ParseDuration(interval); err == nil {
-		return duration, nil
+// startRun queues a job run and executes it in the background. It fails
+// when the job is already queued or running, in the daemon or elsewhere.
+func (js *JobScheduler) startRun(cfg *config.BackupConfig, job config.BackupJob, trigger string, annotations backup.Annotations) (control.RunStatus, error) {
+	// Runs started by hand or from cron register the same run records
+	if records, err := state.ListRuns(); err == nil {
+		for _, record := range records {
+			if record.JobName == job.Name && record.PID != os.Getpid() {
+				return control.RunStatus{}, fmt.Errorf("job '%s' is already running as %s (PID %d)", job.Name, record.ID, record.PID)
+			}
+		}
+	}
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if active, ok := js.active[job.Name]; ok {
+		return control.RunStatus{}, fmt.Errorf("job '%s' is already %s as %s", job.Name, js.runs[active.id].State, active.id)
 	}
 
-	// Handle human-readable intervals
-	switch strings.ToLower(interval) {
-	case "daily", "1d", "24h":
-		return 24 * time.Hour, nil
-	case "hourly", "1h":
-		return time.Hour, nil
-	case "weekly", "7d", "168h":
-		return 7 * 24 * time.Hour, nil
-	case "monthly", "30d", "720h":
-		return 30 * 24 * time.Hour, nil
-	case "15m", "15min":
-		return 15 * time.Minute, nil
-	case "30m", "30min":
-		return 30 * time.Minute, nil
-	default:
-		return 0, fmt.Errorf("unknown schedule interval: %s", interval)
+	run := &control.RunStatus{
+		ID:       state.NewRunID(),
+		Job:      job.Name,
+		State:    control.RunQueued,
+		Trigger:  trigger,
+		QueuedAt: time.Now(),
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	js.pruneRuns()
+	js.runs[run.ID] = run
+	js.active[job.Name] = activeRun{id: run.ID, cancel: cancel}
+
+	js.workers.Add(1)
+	go func() {
+		defer js.workers.Done()
+		defer js.releaseRun(job.Name, run.ID)
+		js.runBackupJob(ctx, *cfg, job, run.ID, annotations)
+	}()
+	return *run, nil
 }
 
-// runBackupJob executes a specific backup job
-func (js *JobScheduler) runBackupJob(job config.BackupJob) {
-	fmt.Printf("   📦 Starting backup: %s\n", job.Name)
+// releaseRun removes a finished run from the active runs. A run that ended
+// without reaching a final state, such as after a panic, is marked failed.
+func (js *JobScheduler) releaseRun(jobName, runID string) {
+	recovered := recover()
+
+	js.mu.Lock()
+	if active, ok := js.active[jobName]; ok && active.id == runID {
+		active.cancel()
+		delete(js.active, jobName)
+	}
+	run, ok := js.runs[runID]
+	unfinished := ok && !run.Finished()
+	if unfinished {
+		run.State = control.RunFailed
+		run.Error = "run ended unexpectedly"
+		if recovered != nil {
+			run.Error = fmt.Sprintf("run ended unexpectedly: %v", recovered)
+		}
+		run.FinishedAt = time.Now()
+	}
+	js.mu.Unlock()
 
-	// Run actual backup using the backup runner with background context
-	backupRunner := backup.NewBackupRunner(*js.config)
-	metadata, err := backupRunner.RunJob(context.Background(), job.Name)
+	if recovered != nil {
+		render.Printf("   ❌ Backup %s of job %s crashed: %v\n%s", runID, jobName, recovered, debug.Stack())
+	}
+}
+
+// activeRuns returns the number of queued or running runs
+func (js *JobScheduler) activeRuns() int {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	return len(js.active)
+}
+
+// pruneRuns drops finished runs older than a day; callers must hold mu
+func (js *JobScheduler) pruneRuns() {
+	cutoff := time.Now().Add(-24 * time.Hour)
+	for id, run := range js.runs {
+		if run.Finished() && run.FinishedAt.Before(cutoff) {
+			delete(js.runs, id)
+		}
+	}
+}
+
+// updateRun applies a change to a tracked run
+func (js *JobScheduler) updateRun(runID string, update func(run *control.RunStatus)) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if run, ok := js.runs[runID]; ok {
+		update(run)
+	}
+}
+
+// runBackupJob executes a specific backup job
+func (js *JobScheduler) runBackupJob(ctx context.Context, cfg config.BackupConfig, job config.BackupJob, runID string, annotations backup.Annotations) {
+	render.Printf("   📦 Starting backup: %s\n", job.Name)
+	js.updateRun(runID, func(run *control.RunStatus) {
+		run.State = control.RunRunning
+		run.StartedAt = time.Now()
+	})
+
+	// Run actual backup using the backup runner; the context is cancelled when
+	// the daemon stops
+	backupRunner := backup.NewBackupRunner(cfg)
+	backupRunner.SetAnnotations(annotations)
+	metadata, err := backupRunner.RunJobWithID(ctx, job.Name, runID)
 	if err != nil {
-		fmt.Printf("   ❌ Backup failed for job %s: %v\n", job.Name, err)
+		render.Printf("   ❌ Backup failed for job %s: %v\n", job.Name, err)
+		js.updateRun(runID, func(run *control.RunStatus) {
+			run.State = control.RunFailed
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				run.State = control.RunCancelled
+			}
+			run.Error = err.Error()
+			run.FinishedAt = time.Now()
+		})
+		js.reportRun(cfg, runID)
 		return
 	}
 
-	fmt.Printf("   ✅ Completed backup: %s (ID: %s)\n", job.Name, metadata.ID)
-	fmt.Printf("   📊 Backup size: %d bytes\n", metadata.TotalSize)
+	js.updateRun(runID, func(run *control.RunStatus) {
+		run.State = control.RunSucceeded
+		run.BackupID = metadata.ID
+		run.TotalSize = metadata.TotalSize
+		run.FinishedAt = time.Now()
+	})
+	js.reportRun(cfg, runID)
+
+	render.Printf("   ✅ Completed backup: %s (ID: %s)\n", job.Name, metadata.ID)
+	render.Printf("   📊 Backup size: %d bytes\n", metadata.TotalSize)
 
 	// Log the execution
-	fmt.Printf("   📝 Job %s completed at %s\n", job.Name, time.Now().Format("15:04:05"))
+	render.Printf("   📝 Job %s completed at %s\n", job.Name, time.Now().Format("15:04:05"))
 }
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/access"
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// RegisterAPI registers the daemon control API handlers
+func (js *JobScheduler) RegisterAPI(server *control.Server) {
+	server.HandleFunc("GET /v1/status", js.handleStatus)
+	server.HandleFunc("GET /v1/maintenance", js.handleMaintenance)
+	server.HandleFunc("POST /v1/jobs/{name}/run", js.requireOperator(js.handleRunJob))
+	server.HandleFunc("GET /v1/runs/{id}", js.handleGetRun)
+	server.HandleFunc("GET /v1/uploads", js.handleListUploads)
+	server.HandleFunc("POST /v1/uploads/retry", js.requireOperator(js.handleRetryUploads))
+	server.HandleFunc("POST /v1/uploads/{id}/cancel", js.requireOperator(js.handleCancelUpload))
+	server.HandleFunc("POST /v1/approvals", js.requireOperator(js.handleApproveRestore))
+	server.HandleFunc("POST /v1/approvals/redeem", js.requireOperator(js.handleRedeemApproval))
+}
+
+// requireOperator rejects requests from clients without the operator role
+func (js *JobScheduler) requireOperator(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, ok := control.PeerUID(r)
+		if !ok {
+			control.WriteError(w, http.StatusForbidden, fmt.Errorf("could not identify client"))
+			return
+		}
+
+		js.mu.Lock()
+		accessConfig := js.config.Access
+		js.mu.Unlock()
+
+		if access.RoleForUID(accessConfig, uid) != access.RoleOperator {
+			control.WriteError(w, http.StatusForbidden, fmt.Errorf("operation requires the operator role"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// handleStatus reports the daemon state and its known runs
+func (js *JobScheduler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	check := js.latestVersion()
+	control.WriteJSON(w, http.StatusOK, control.DaemonStatus{
+		PID:             os.Getpid(),
+		Version:         version,
+		LatestVersion:   check.LatestVersion,
+		UpdateAvailable: updateAvailable(version, check.LatestVersion),
+		UpdateCheckedAt: check.CheckedAt,
+		StartedAt:       js.startedAt,
+		ConfigPath:      getConfigPath(),
+		Runs:            js.listRuns(),
+		ActiveRuns:      js.activeRuns(),
+		Goroutines:      runtime.NumGoroutine(),
+		Mounts:          js.mountStatuses(),
+	})
+}
+
+// handleRunJob queues a job to run immediately
+func (js *JobScheduler) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	cfg := js.reloadConfig()
+	job := findJobByName(cfg, name)
+	if job == nil {
+		control.WriteError(w, http.StatusNotFound, fmt.Errorf("job '%s' not found", name))
+		return
+	}
+	if !job.Enabled {
+		control.WriteError(w, http.StatusConflict, fmt.Errorf("job '%s' is disabled", name))
+		return
+	}
+
+	trigger := "api"
+	var req control.RunRequest
+	var keepFor time.Duration
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			control.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+		if req.Trigger != "" {
+			trigger = req.Trigger
+		}
+		if err := backup.ValidateLabels(req.Labels); err != nil {
+			control.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.KeepFor != "" {
+			var err error
+			if keepFor, err = parseKeepFor(req.KeepFor); err != nil {
+				control.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+	}
+
+	run, err := js.startRun(cfg, *job, trigger, backup.Annotations{Comment: req.Comment, Labels: req.Labels, KeepFor: keepFor})
+	if err != nil {
+		control.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	render.Printf("🔄 Running on-demand backup: %s (trigger: %s)\n", job.Name, trigger)
+	control.WriteJSON(w, http.StatusAccepted, run)
+}
+
+// handleMaintenance reports the backups in progress on this host, including
+// those started outside the daemon, for health checks and load balancers
+func (js *JobScheduler) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	m, err := state.CurrentMaintenance()
+	if err != nil {
+		control.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	control.WriteJSON(w, http.StatusOK, m)
+}
+
+// handleGetRun reports the status of a single run
+func (js *JobScheduler) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := js.getRun(r.PathValue("id"))
+	if !ok {
+		control.WriteError(w, http.StatusNotFound, fmt.Errorf("run '%s' not found", r.PathValue("id")))
+		return
+	}
+	control.WriteJSON(w, http.StatusOK, run)
+}
+
+// getRun returns a snapshot of a run, including its current phase
+func (js *JobScheduler) getRun(runID string) (control.RunStatus, bool) {
+	js.mu.Lock()
+	run, ok := js.runs[runID]
+	var snapshot control.RunStatus
+	if ok {
+		snapshot = *run
+	}
+	js.mu.Unlock()
+
+	if ok && snapshot.State == control.RunRunning {
+		if record, err := state.LoadRun(runID); err == nil {
+			snapshot.Phase = record.Phase
+		}
+	}
+	return snapshot, ok
+}
+
+// listRuns returns snapshots of all known runs, oldest first
+func (js *JobScheduler) listRuns() []control.RunStatus {
+	js.mu.Lock()
+	ids := make([]string, 0, len(js.runs))
+	for id := range js.runs {
+		ids = append(ids, id)
+	}
+	js.mu.Unlock()
+
+	runs := make([]control.RunStatus, 0, len(ids))
+	for _, id := range ids {
+		if run, ok := js.getRun(id); ok {
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].QueuedAt.Before(runs[j].QueuedAt)
+	})
+	return runs
+}
+
+// handleListUploads reports the backups waiting in the outbox
+func (js *JobScheduler) handleListUploads(w http.ResponseWriter, r *http.Request) {
+	queue, err := backup.UploadQueue(*js.reloadConfig())
+	if err != nil {
+		control.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	control.WriteJSON(w, http.StatusOK, queue)
+}
+
+// handleRetryUploads makes failed uploads due and starts uploading them
+func (js *JobScheduler) handleRetryUploads(w http.ResponseWriter, r *http.Request) {
+	var req control.UploadsRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			control.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+	}
+	retried, err := backup.RetryUploads(req.BackupIDs)
+	if errors.Is(err, state.ErrUploadNotQueued) {
+		control.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		control.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	js.uploadOutbox(js.reloadConfig())
+	control.WriteJSON(w, http.StatusAccepted, control.UploadsRequest{BackupIDs: retried})
+}
+
+// handleCancelUpload removes a backup from the upload queue
+func (js *JobScheduler) handleCancelUpload(w http.ResponseWriter, r *http.Request) {
+	upload, err := state.RemoveUpload(r.PathValue("id"))
+	if errors.Is(err, state.ErrUploadNotQueued) {
+		control.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		control.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	render.Printf("🛑 Cancelled the upload of %s\n", upload.BackupID)
+	control.WriteJSON(w, http.StatusOK, control.UploadStatus{
+		BackupID: upload.BackupID,
+		Job:      upload.Job,
+		BucketID: upload.BucketID,
+		Size:     upload.Size,
+		State:    upload.State(),
+		QueuedAt: upload.QueuedAt,
+		Attempts: upload.Attempts,
+		Error:    upload.LastError,
+	})
+}
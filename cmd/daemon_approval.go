@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/access"
+	"github.com/mitexleo/backtide/internal/approval"
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// handleApproveRestore issues a token approving one restore of a backup of a
+// job with restore_approval, for another operator to redeem
+func (js *JobScheduler) handleApproveRestore(w http.ResponseWriter, r *http.Request) {
+	var req control.ApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		control.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if req.BackupID == "" {
+		control.WriteError(w, http.StatusBadRequest, fmt.Errorf("backup_id is required"))
+		return
+	}
+	cfg := js.reloadConfig()
+	job := findJobByName(cfg, req.Job)
+	if job == nil {
+		control.WriteError(w, http.StatusNotFound, fmt.Errorf("job '%s' not found", req.Job))
+		return
+	}
+	if !job.RestoreApproval {
+		control.WriteError(w, http.StatusBadRequest, fmt.Errorf("job '%s' does not require restore approval", req.Job))
+		return
+	}
+	uid, err := approvalOperator(r, cfg.Access)
+	if err != nil {
+		control.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	js.mu.Lock()
+	if js.approvalKey == nil {
+		key, err := approval.NewKey()
+		if err != nil {
+			js.mu.Unlock()
+			control.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		js.approvalKey = key
+	}
+	key := js.approvalKey
+	js.mu.Unlock()
+
+	grant := approval.Grant{Job: job.Name, BackupID: req.BackupID, Approver: uid, ExpiresAt: time.Now().Add(cfg.Approval.Validity())}
+	token, err := approval.Issue(key, grant)
+	if err != nil {
+		control.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	render.Printf("🔑 Approved a restore of %s of job %s (uid %d, valid until %s)\n",
+		req.BackupID, job.Name, uid, grant.ExpiresAt.Format("15:04:05"))
+	audit.RecordResult("approve", req.BackupID, []string{fmt.Sprintf("restore of job %s approved by uid %d", job.Name, uid)}, nil)
+	control.WriteJSON(w, http.StatusCreated, control.Approval{
+		Job:       grant.Job,
+		BackupID:  grant.BackupID,
+		Token:     token,
+		Approver:  grant.Approver,
+		ExpiresAt: grant.ExpiresAt,
+	})
+}
+
+// approvalOperator identifies the operator behind an approval request from
+// the connection alone: the connecting user, or for root the login UID of
+// its session, which sudo and su keep. Root without a login UID names no
+// operator, so its approvals and restores cannot be told apart and are
+// refused.
+func approvalOperator(r *http.Request, accessCfg config.AccessConfig) (int, error) {
+	uid, ok := control.PeerUID(r)
+	if !ok {
+		return 0, fmt.Errorf("%w: could not identify client", approval.ErrInvalid)
+	}
+	if loginUID, ok := control.PeerLoginUID(r); uid == 0 && ok {
+		uid = loginUID
+	}
+	if uid == 0 {
+		return 0, fmt.Errorf("%w: approvals need an operator's own login, through sudo or as a member of [access] operator_groups, not a root session", approval.ErrInvalid)
+	}
+	if access.RoleForUID(accessCfg, uid) != access.RoleOperator {
+		return 0, fmt.Errorf("%w: uid %d is not an operator", approval.ErrInvalid, uid)
+	}
+	return uid, nil
+}
+
+// handleRedeemApproval accepts an approval token once for the restore it
+// approves, and only from another operator than the approver
+func (js *JobScheduler) handleRedeemApproval(w http.ResponseWriter, r *http.Request) {
+	var req control.ApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		control.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	uid, err := approvalOperator(r, js.reloadConfig().Access)
+	if err != nil {
+		control.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if js.approvalKey == nil {
+		control.WriteError(w, http.StatusForbidden, fmt.Errorf("%w: the daemon has issued no tokens since it started", approval.ErrInvalid))
+		return
+	}
+	now := time.Now()
+	grant, err := approval.Verify(js.approvalKey, req.Token, now)
+	switch {
+	case err != nil:
+	case grant.Job != req.Job || grant.BackupID != req.BackupID:
+		err = fmt.Errorf("%w: the token approves %s of job %s", approval.ErrInvalid, grant.BackupID, grant.Job)
+	case grant.Approver == uid:
+		err = fmt.Errorf("%w: the restore must be approved by another operator", approval.ErrInvalid)
+	case !js.redeemed[grant.Nonce].IsZero():
+		err = fmt.Errorf("%w: the token was already used", approval.ErrInvalid)
+	}
+	if err != nil {
+		status := http.StatusForbidden
+		if !errors.Is(err, approval.ErrInvalid) {
+			status = http.StatusInternalServerError
+		}
+		control.WriteError(w, status, err)
+		return
+	}
+
+	for nonce, expires := range js.redeemed {
+		if now.After(expires) {
+			delete(js.redeemed, nonce)
+		}
+	}
+	js.redeemed[grant.Nonce] = grant.ExpiresAt
+	control.WriteJSON(w, http.StatusOK, control.Approval{
+		Job:       grant.Job,
+		BackupID:  grant.BackupID,
+		Approver:  grant.Approver,
+		ExpiresAt: grant.ExpiresAt,
+	})
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+)
+
+func TestApprovalOperatorIgnoresForgedSudoUID(t *testing.T) {
+	if data, err := os.ReadFile("/proc/self/loginuid"); os.Geteuid() != 0 || err != nil || strings.TrimSpace(string(data)) != "4294967295" {
+		t.Skip("needs a root process outside a login session")
+	}
+
+	server := control.NewServer(filepath.Join(t.TempDir(), "control.sock"))
+	server.HandleFunc("POST /v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		uid, err := approvalOperator(r, config.AccessConfig{})
+		if err != nil {
+			control.WriteError(w, http.StatusForbidden, err)
+			return
+		}
+		control.WriteJSON(w, http.StatusCreated, control.Approval{Approver: uid})
+	})
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	// A root session claiming to be another user through the request body
+	request := map[string]interface{}{"job": "db", "backup_id": "backup-1", "sudo_uid": 1000}
+	var approved control.Approval
+	err := control.NewClient(server.SocketPath()).Post("/v1/approvals", request, &approved)
+	if err == nil {
+		t.Fatalf("root session approved as uid %d", approved.Approver)
+	}
+	if !strings.Contains(err.Error(), "not a root session") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
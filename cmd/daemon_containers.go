@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/docker"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// checkOrphanedContainers reports, and unless disabled restarts, containers left
+// stopped by backup runs that exited without restarting them
+func (js *JobScheduler) checkOrphanedContainers() {
+	js.mu.Lock()
+	cfg := js.config
+	js.mu.Unlock()
+
+	threshold := cfg.Docker.OrphanAfter()
+	holds, err := state.OrphanedHolds(threshold)
+	if err != nil {
+		render.Printf("Warning: Failed to check for orphaned containers: %v\n", err)
+		return
+	}
+
+	for _, hold := range holds {
+		if js.orphanNotified[hold.RunID] {
+			continue
+		}
+		js.orphanNotified[hold.RunID] = true
+		render.Printf("⚠️  %d containers stopped by run %s have been down for %s: %s\n",
+			len(hold.Containers), hold.RunID, utils.FormatDuration(hold.Age()), strings.Join(containerNames(hold), ", "))
+		js.notifyContainers(cfg, hold, plugin.EventContainersOrphaned, nil)
+	}
+
+	if len(holds) == 0 || cfg.Docker.SkipRecover {
+		return
+	}
+
+	released, err := docker.NewDockerManager("", "", cfg.Docker).RecoverOrphaned(threshold)
+	for _, hold := range released {
+		delete(js.orphanNotified, hold.RunID)
+		js.notifyContainers(cfg, hold, plugin.EventContainersRecovered, err)
+	}
+	if err != nil {
+		render.Printf("❌ Failed to restart orphaned containers: %v\n", err)
+	}
+}
+
+// notifyContainers sends a container event to the notifiers of the hold's job
+func (js *JobScheduler) notifyContainers(cfg *config.BackupConfig, hold state.ContainerHold, eventType string, err error) {
+	names := containerNames(hold)
+	event := plugin.Event{
+		Type:       eventType,
+		Job:        hold.Job,
+		RunID:      hold.RunID,
+		Containers: names,
+		Timestamp:  time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	backup.NotifyJob(context.Background(), cfg, hold.Job, event)
+
+	record := logging.Record{
+		Priority: logging.PriorityNotice,
+		Event:    eventType,
+		Message:  fmt.Sprintf("Restarted containers left stopped by run %s: %s", hold.RunID, strings.Join(names, ", ")),
+		Fields:   map[string]string{"run_id": hold.RunID, "containers": strings.Join(names, ",")},
+	}
+	if hold.Job != "" {
+		record.Fields["job"] = hold.Job
+	}
+	if eventType == plugin.EventContainersOrphaned {
+		record.Priority = logging.PriorityWarning
+		record.Message = fmt.Sprintf("Containers stopped by run %s have been down for %s: %s",
+			hold.RunID, utils.FormatDuration(hold.Age()), strings.Join(names, ", "))
+	}
+	if err != nil {
+		record.Priority = logging.PriorityErr
+		record.Message = fmt.Sprintf("Failed to restart containers left stopped by run %s: %v", hold.RunID, err)
+		record.Fields["error"] = err.Error()
+	}
+	logging.Emit(cfg.Logging, record)
+}
+
+// containerNames returns the names of the containers in a hold
+func containerNames(hold state.ContainerHold) []string {
+	names := make([]string, 0, len(hold.Containers))
+	for _, container := range hold.Containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
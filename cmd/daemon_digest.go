@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/digest"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// sendDigestIfDue sends the daily or weekly digest once its scheduled time has
+// passed, covering the runs since the previous digest
+func (js *JobScheduler) sendDigestIfDue(cfg *config.BackupConfig) {
+	if !cfg.Digest.Enabled() {
+		return
+	}
+	lastSent, err := state.LoadDigestSent()
+	if err != nil {
+		render.Printf("Warning: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+	if lastSent.IsZero() {
+		// First start with a digest configured: begin the period now rather
+		// than mailing a summary of all history
+		if err := state.SaveDigestSent(now); err != nil {
+			render.Printf("Warning: %v\n", err)
+		}
+		return
+	}
+	if !digest.Due(cfg.Digest, lastSent, now) || now.Before(js.digestRetryAt) {
+		return
+	}
+
+	d, err := digest.Build(cfg, backup.Hostname(), lastSent, now)
+	if err != nil {
+		render.Printf("Warning: Failed to build digest: %v\n", err)
+		return
+	}
+	if err := digest.Send(context.Background(), cfg, d); err != nil {
+		// Retried hourly; a digest is not worth skipping for a transient
+		// mail or webhook failure
+		js.digestRetryAt = now.Add(time.Hour)
+		render.Printf("⚠️  Failed to send %s digest: %v\n", cfg.Digest.Interval, err)
+		logging.Emit(cfg.Logging, logging.Record{
+			Priority: logging.PriorityWarning,
+			Event:    "digest.failed",
+			Message:  fmt.Sprintf("Failed to send %s digest: %v", cfg.Digest.Interval, err),
+		})
+		return
+	}
+	render.Printf("📬 Sent %s digest: %s\n", cfg.Digest.Interval, d.Subject())
+	if err := state.SaveDigestSent(now); err != nil {
+		render.Printf("Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/fleet"
+	"github.com/mitexleo/backtide/internal/network"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// reportRun sends the summary of a finished run to the fleet collector, if one is configured
+func (js *JobScheduler) reportRun(cfg config.BackupConfig, runID string) {
+	if cfg.Fleet.ReportTo == "" {
+		return
+	}
+	run, ok := js.getRun(runID)
+	if !ok {
+		return
+	}
+
+	report := fleet.Report{
+		Host:          backup.Hostname(),
+		MachineID:     backup.MachineID(),
+		Version:       version,
+		LatestVersion: js.latestVersion().LatestVersion,
+		RunID:         run.ID,
+		Job:           run.Job,
+		State:         run.State,
+		Trigger:       run.Trigger,
+		BackupID:      run.BackupID,
+		TotalSize:     run.TotalSize,
+		Error:         run.Error,
+		StartedAt:     run.StartedAt,
+		FinishedAt:    run.FinishedAt,
+	}
+	for _, job := range cfg.Jobs {
+		if job.Name == run.Job {
+			report.MaxAge = job.MaxAge
+		}
+	}
+	client, err := network.Client(cfg.Network, 0)
+	if err != nil {
+		render.Printf("   ⚠️  Failed to report run to fleet collector: %v\n", err)
+		return
+	}
+	if err := fleet.Send(context.Background(), client, cfg.Fleet.ReportTo, cfg.Fleet.Secret, report); err != nil {
+		render.Printf("   ⚠️  Failed to report run to fleet collector: %v\n", err)
+	}
+}
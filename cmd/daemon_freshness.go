@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// alertOverdueJobs reports jobs whose newest successful backup is older than
+// their max_age to their notifier plugins and the log sink, once per breach
+func (js *JobScheduler) alertOverdueJobs(cfg *config.BackupConfig) {
+	checks, err := backup.JobFreshness(cfg.Jobs)
+	if err != nil {
+		render.Printf("Warning: Failed to check backup freshness: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+	for _, check := range checks {
+		if !check.Overdue(now) {
+			delete(js.overdueNotified, check.Job)
+			continue
+		}
+		if last, notified := js.overdueNotified[check.Job]; notified && last.Equal(check.LastSuccess) {
+			continue
+		}
+		js.overdueNotified[check.Job] = check.LastSuccess
+
+		description := check.Describe(now)
+		render.Printf("⚠️  Backups of %s are overdue: %s\n", check.Job, description)
+		backup.NotifyJob(context.Background(), cfg, check.Job, plugin.Event{
+			Type:      plugin.EventBackupOverdue,
+			Job:       check.Job,
+			Error:     description,
+			Timestamp: now,
+		})
+
+		record := logging.Record{
+			Priority: logging.PriorityWarning,
+			Event:    plugin.EventBackupOverdue,
+			Message:  fmt.Sprintf("Backups of job %s are overdue: %s", check.Job, description),
+			Fields:   map[string]string{"job": check.Job, "max_age": check.Setting},
+		}
+		if !check.LastSuccess.IsZero() {
+			record.Fields["last_success"] = check.LastSuccess.UTC().Format(time.RFC3339)
+		}
+		logging.Emit(cfg.Logging, record)
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3fs"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// startMountSupervisor checks S3 mounts in the background until the scheduler stops
+func (js *JobScheduler) startMountSupervisor() {
+	js.mu.Lock()
+	cfg := js.config
+	js.mu.Unlock()
+
+	js.mounts = s3fs.NewSupervisor(cfg.Mounts.Timeout(), !cfg.Mounts.SkipRemount)
+	js.mounts.OnFailure = func(bucket config.BucketConfig, err error) {
+		render.Printf("❌ S3 mount %s (%s) is broken: %v\n", bucket.Name, bucket.MountPoint, err)
+		js.notifyMount(bucket, plugin.EventMountFailed, err)
+	}
+	js.mounts.OnRecover = func(bucket config.BucketConfig) {
+		render.Printf("✅ S3 mount %s (%s) is healthy\n", bucket.Name, bucket.MountPoint)
+		js.notifyMount(bucket, plugin.EventMountRecovered, nil)
+	}
+
+	go js.mountLoop(cfg.Mounts.Interval())
+}
+
+// mountLoop runs mount checks, following interval changes in the configuration
+func (js *JobScheduler) mountLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	js.checkMounts()
+	for {
+		select {
+		case <-js.stopChan:
+			return
+		case <-ticker.C:
+			js.checkMounts()
+			js.mu.Lock()
+			next := js.config.Mounts.Interval()
+			js.mu.Unlock()
+			if next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// checkMounts checks the buckets used by enabled S3 jobs
+func (js *JobScheduler) checkMounts() {
+	js.mu.Lock()
+	cfg := js.config
+	js.mu.Unlock()
+
+	js.mounts.SetBuckets(supervisedBuckets(cfg))
+	js.mounts.CheckAll()
+}
+
+// supervisedBuckets returns the permanently mounted buckets that enabled jobs back up to
+func supervisedBuckets(cfg *config.BackupConfig) []config.BucketConfig {
+	used := make(map[string]bool)
+	for _, job := range cfg.Jobs {
+		if job.Enabled && job.Storage.S3 && !job.SkipS3 {
+			used[job.BucketID] = true
+		}
+	}
+
+	var buckets []config.BucketConfig
+	for _, bucket := range cfg.Buckets {
+		// On-demand buckets are expected to be unmounted between runs
+		if used[bucket.ID] && !bucket.MountOnDemand {
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets
+}
+
+// notifyMount sends a mount event to the notifiers of jobs using the bucket
+func (js *JobScheduler) notifyMount(bucket config.BucketConfig, eventType string, err error) {
+	js.mu.Lock()
+	cfg := js.config
+	js.mu.Unlock()
+
+	event := plugin.Event{
+		Type:       eventType,
+		Bucket:     bucket.Name,
+		MountPoint: bucket.MountPoint,
+		Timestamp:  time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	backup.NotifyBucket(context.Background(), cfg, bucket.ID, event)
+
+	record := logging.Record{
+		Priority: logging.PriorityNotice,
+		Event:    eventType,
+		Message:  fmt.Sprintf("S3 mount %s (%s) is healthy", bucket.Name, bucket.MountPoint),
+		Fields:   map[string]string{"bucket": bucket.Name, "mount_point": bucket.MountPoint},
+	}
+	if err != nil {
+		record.Priority = logging.PriorityErr
+		record.Message = fmt.Sprintf("S3 mount %s (%s) is broken: %v", bucket.Name, bucket.MountPoint, err)
+		record.Fields["error"] = err.Error()
+	}
+	logging.Emit(cfg.Logging, record)
+}
+
+// mountStatuses reports the supervised mounts for the control API
+func (js *JobScheduler) mountStatuses() []control.MountStatus {
+	if js.mounts == nil {
+		return nil
+	}
+
+	var statuses []control.MountStatus
+	for _, status := range js.mounts.Status() {
+		statuses = append(statuses, control.MountStatus{
+			Bucket:     status.Bucket,
+			MountPoint: status.MountPoint,
+			Healthy:    status.Healthy,
+			Error:      status.Error,
+			CheckedAt:  status.CheckedAt,
+			Remounts:   status.Remounts,
+		})
+	}
+	return statuses
+}
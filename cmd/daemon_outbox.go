@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// uploadOutbox starts uploading the backups queued in the outbox in the
+// background, unless an upload is still going on, so slow uploads never hold
+// up scheduled runs
+func (js *JobScheduler) uploadOutbox(cfg *config.BackupConfig) {
+	uploads, err := state.LoadUploads()
+	if err != nil {
+		render.Printf("Warning: %v\n", err)
+		return
+	}
+	if len(uploads) == 0 || !js.uploading.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-js.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		defer js.uploading.Store(false)
+		defer cancel()
+		// Each failed upload is reported and retried after the retry interval
+		backup.NewBackupRunner(*cfg).UploadOutbox(ctx, false)
+	}()
+}
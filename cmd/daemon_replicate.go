@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// replicateBackups starts copying queued backups to their secondary buckets
+// in the background, unless a replication is still going on, so slow copies
+// never hold up scheduled runs
+func (js *JobScheduler) replicateBackups(cfg *config.BackupConfig) {
+	replications, err := state.LoadReplications()
+	if err != nil {
+		render.Printf("Warning: %v\n", err)
+		return
+	}
+	if len(replications) == 0 || !js.replicating.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-js.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		defer js.replicating.Store(false)
+		defer cancel()
+		// Each failed replication is reported and retried after the outbox retry interval
+		backup.NewBackupRunner(*cfg).Replicate(ctx, false)
+	}()
+}
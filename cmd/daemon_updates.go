@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/mitexleo/backtide/internal/network"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// updateCheckTimeout bounds a single lookup of the latest release
+const updateCheckTimeout = 30 * time.Second
+
+// startUpdateChecker looks up the latest release in the background until the
+// scheduler stops, so the daemon API and fleet reports can show version skew
+func (js *JobScheduler) startUpdateChecker() {
+	if check, err := state.LoadUpdateCheck(); err == nil {
+		js.mu.Lock()
+		js.updateCheck = check
+		js.mu.Unlock()
+	}
+	go js.updateLoop()
+}
+
+// updateLoop checks for a new release whenever the last check is older than
+// the configured interval; the result is persisted so restarts do not re-check
+func (js *JobScheduler) updateLoop() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		js.checkForUpdate()
+		select {
+		case <-js.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkForUpdate looks up the latest release if a check is due
+func (js *JobScheduler) checkForUpdate() {
+	js.mu.Lock()
+	cfg := js.config
+	last := js.updateCheck.CheckedAt
+	js.mu.Unlock()
+
+	if cfg.Updates.SkipCheck || time.Since(last) < cfg.Updates.Interval() {
+		return
+	}
+
+	check := state.UpdateCheck{CheckedAt: time.Now()}
+	client, err := network.Client(cfg.Network, updateCheckTimeout)
+	if err == nil {
+		var release *ReleaseInfo
+		if release, err = getLatestRelease(client); err == nil {
+			check.LatestVersion = release.Version
+		}
+	}
+	if err != nil {
+		check.Error = err.Error()
+		render.Printf("⚠️  Failed to check for backtide updates: %v\n", err)
+	}
+	if err := state.SaveUpdateCheck(check); err != nil {
+		render.Printf("⚠️  Failed to save update check: %v\n", err)
+	}
+
+	js.mu.Lock()
+	if check.LatestVersion == "" {
+		check.LatestVersion = js.updateCheck.LatestVersion
+	}
+	js.updateCheck = check
+	js.mu.Unlock()
+
+	if updateAvailable(version, check.LatestVersion) {
+		render.Printf("🚀 backtide %s is available (running %s)\n", check.LatestVersion, version)
+	}
+}
+
+// latestVersion returns the newest release the daemon has seen, if any
+func (js *JobScheduler) latestVersion() state.UpdateCheck {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	return js.updateCheck
+}
+
+// updateAvailable reports whether latest is a newer release than current;
+// development builds are never reported as outdated
+func updateAvailable(current, latest string) bool {
+	if !utils.IsReleaseVersion(current) || !utils.IsReleaseVersion(latest) {
+		return false
+	}
+	return utils.CompareVersions(current, latest) < 0
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// verifyBucketsIfDue starts checking the verify agent's buckets in the
+// background once the interval has passed since the last check; downloading
+// archives can take long and must not hold up scheduled runs
+func (js *JobScheduler) verifyBucketsIfDue(cfg *config.BackupConfig) {
+	if !cfg.VerifyAgent.Enabled() {
+		return
+	}
+	last, err := state.LoadVerifyAgentCheck()
+	if err != nil {
+		render.Printf("Warning: %v\n", err)
+		return
+	}
+	if time.Since(last.CheckedAt) < cfg.VerifyAgent.Period() || !js.verifying.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-js.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		defer js.verifying.Store(false)
+		defer cancel()
+		check := checkBuckets(ctx, cfg, cfg.VerifyAgent)
+		if ctx.Err() != nil {
+			// Stopped by the daemon shutting down; check again after the restart
+			return
+		}
+		if err := state.SaveVerifyAgentCheck(check); err != nil {
+			render.Printf("Warning: %v\n", err)
+		}
+		reportVerifyAgentCheck(ctx, cfg, check)
+	}()
+}
+
+// reportVerifyAgentCheck logs the result of a check and tells the verify
+// agent's notifiers about problems
+func reportVerifyAgentCheck(ctx context.Context, cfg *config.BackupConfig, check state.VerifyAgentCheck) {
+	fields := map[string]string{
+		"buckets":  strings.Join(check.Buckets, ","),
+		"backups":  fmt.Sprint(check.Backups),
+		"archives": fmt.Sprint(check.Archives),
+	}
+	if len(check.Problems) == 0 {
+		logging.Emit(cfg.Logging, logging.Record{
+			Priority: logging.PriorityInfo,
+			Event:    "verify.passed",
+			Message:  fmt.Sprintf("Verified %d backups in %s, checksummed %d archives", check.Backups, strings.Join(check.Buckets, ", "), check.Archives),
+			Fields:   fields,
+		})
+		return
+	}
+
+	fields["problems"] = fmt.Sprint(len(check.Problems))
+	logging.Emit(cfg.Logging, logging.Record{
+		Priority: logging.PriorityWarning,
+		Event:    plugin.EventVerifyFailed,
+		Message:  fmt.Sprintf("Verifying %s found %d problems: %s", strings.Join(check.Buckets, ", "), len(check.Problems), strings.Join(check.Problems, "; ")),
+		Fields:   fields,
+	})
+	backup.NotifyPlugins(ctx, cfg, cfg.VerifyAgent.Plugins, plugin.Event{
+		Type:      plugin.EventVerifyFailed,
+		Bucket:    strings.Join(check.Buckets, ", "),
+		Error:     strings.Join(check.Problems, "; "),
+		Timestamp: check.CheckedAt,
+	})
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteJob        string
+	deleteOlderThan  string
+	deleteLargerThan string
+	deleteKeepLatest int
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete backups of a job by age and size",
+	Long: `Delete this host's backups of a job selected by filters, for cleanups the
+retention policy does not express. A backup is deleted when it matches every
+given filter:
+
+  --older-than   taken longer ago than this period (e.g., 90d)
+  --larger-than  bigger than this size (e.g., 50GB)
+  --keep-latest  never delete the newest N backups
+
+The backups to delete are always listed first and removed only after
+confirmation (or with --force). Pinned backups are never deleted and do not
+count toward --keep-latest.
+
+Examples:
+  backtide delete --job app --older-than 90d --dry-run
+  sudo backtide delete --job app --larger-than 50GB --keep-latest 5
+  sudo backtide delete --job app --keep-latest 3 --force`,
+	Run: runDelete,
+}
+
+func init() {
+	deleteCmd.Flags().StringVarP(&deleteJob, "job", "j", "", "job whose backups to delete (required)")
+	deleteCmd.Flags().StringVar(&deleteOlderThan, "older-than", "", "only delete backups older than this (e.g., 90d)")
+	deleteCmd.Flags().StringVar(&deleteLargerThan, "larger-than", "", "only delete backups larger than this (e.g., 50GB)")
+	deleteCmd.Flags().IntVar(&deleteKeepLatest, "keep-latest", 0, "always keep the newest N backups")
+
+	// Register with command registry
+	commands.RegisterCommand("delete", deleteCmd)
+}
+
+func runDelete(cmd *cobra.Command, args []string) {
+	filter, err := parseDeleteFilter()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	runner := backup.NewBackupRunner(*cfg)
+	plan, err := runner.PlanDelete(deleteJob, filter)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Printf("=== Delete Backups: %s ===\n", deleteJob)
+	for _, metadata := range plan.Pinned {
+		render.Printf("📌 %s  %s  pinned, kept\n", metadata.ID, metadata.Timestamp.Format("2006-01-02 15:04"))
+	}
+	if len(plan.Delete) == 0 {
+		render.Println("✅ No backups match the filters")
+		return
+	}
+
+	var total int64
+	for _, metadata := range plan.Delete {
+		render.Printf("🗑️  %s  %s  %s\n", metadata.ID, metadata.Timestamp.Format("2006-01-02 15:04"), utils.FormatBytes(metadata.TotalSize))
+		total += metadata.TotalSize
+	}
+	render.Printf("\n📊 %d backups, %s in %s\n", len(plan.Delete), utils.FormatBytes(total), plan.Path)
+
+	if dryRun {
+		render.Println("DRY RUN: Nothing was deleted")
+		return
+	}
+
+	if !force {
+		if !confirmOrCancel(newPrompter(), fmt.Sprintf("Delete these %d backups?", len(plan.Delete))) {
+			return
+		}
+	}
+
+	removed, err := runner.DeleteBackups(deleteJob, plan)
+	audit.RecordResult("delete", deleteJob, removed, err)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		if len(removed) > 0 {
+			render.Printf("   %d backups were deleted before the error\n", len(removed))
+		}
+		os.Exit(1)
+	}
+	render.Printf("✅ Deleted %d backups, freed %s\n", len(removed), utils.FormatBytes(total))
+}
+
+// parseDeleteFilter builds the filter from the delete flags
+func parseDeleteFilter() (backup.DeleteFilter, error) {
+	var filter backup.DeleteFilter
+	var err error
+
+	if deleteJob == "" {
+		return filter, fmt.Errorf("--job is required")
+	}
+	if deleteOlderThan == "" && deleteLargerThan == "" && deleteKeepLatest == 0 {
+		return filter, fmt.Errorf("give at least one of --older-than, --larger-than or --keep-latest")
+	}
+	if deleteOlderThan != "" {
+		if filter.OlderThan, err = utils.ParseDuration(deleteOlderThan); err != nil {
+			return filter, fmt.Errorf("invalid --older-than: %w", err)
+		}
+		if filter.OlderThan <= 0 {
+			return filter, fmt.Errorf("--older-than must be positive")
+		}
+	}
+	if deleteLargerThan != "" {
+		if filter.LargerThan, err = utils.ParseSize(deleteLargerThan); err != nil {
+			return filter, fmt.Errorf("invalid --larger-than: %w", err)
+		}
+	}
+	if deleteKeepLatest < 0 {
+		return filter, fmt.Errorf("--keep-latest cannot be negative")
+	}
+	filter.KeepLatest = deleteKeepLatest
+	return filter, nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/digest"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestSince string
+	digestSend  bool
+)
+
+// digestCmd represents the digest command
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Preview or send the summary of all jobs' runs",
+	Long: `Print the digest the daemon sends when [digest] is configured: the runs,
+successes and failures of every job, and how its backups and storage grew.
+
+By default the digest covers the configured interval up to now (a day, or a
+week for weekly digests). --send also delivers it to the configured email
+recipients and webhook, without changing when the daemon sends the next one.
+
+Examples:
+  backtide digest
+  backtide digest --since 3d
+  backtide digest --send`,
+	Run: runDigest,
+}
+
+func init() {
+	digestCmd.Flags().StringVar(&digestSince, "since", "", "period to summarize up to now (e.g., 12h, 3d; default: the digest interval)")
+	digestCmd.Flags().BoolVar(&digestSend, "send", false, "deliver the digest to the configured email recipients and webhook")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(digestCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("digest", digestCmd)
+}
+
+func runDigest(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	period := cfg.Digest.Period()
+	if digestSince != "" {
+		if period, err = utils.ParseDuration(digestSince); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --since: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	now := time.Now()
+	d, err := digest.Build(cfg, backup.Hostname(), now.Add(-period), now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to build digest: %v\n", err)
+		os.Exit(1)
+	}
+	render.Println(d.Subject())
+	render.Println()
+	render.Print(d.Text())
+
+	if !digestSend {
+		return
+	}
+	if !cfg.Digest.Enabled() {
+		fmt.Fprintf(os.Stderr, "Error: no digest is configured; set [digest] interval with email or webhook\n")
+		os.Exit(1)
+	}
+	if err := digest.Send(context.Background(), cfg, d); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	render.Println("\n✅ Digest sent")
+}
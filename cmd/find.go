@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findJob   string
+	findSince string
+)
+
+// findCmd represents the find command
+var findCmd = &cobra.Command{
+	Use:   "find <pattern>",
+	Short: "Find which backups contain matching files",
+	Long: `Search the file indexes stored with this host's backups and list every
+backup containing a matching file, with the file's size and modification time.
+No archive is read, so searching is fast even for large backups.
+
+The pattern uses shell glob syntax (quote it so the shell does not expand it):
+
+  *.sql            files named like *.sql in any directory
+  /srv/db/*.sql    files directly in /srv/db
+  dumps/*.sql      files in any directory named dumps
+
+Backups made by versions that did not write indexes cannot be searched and are
+counted separately.
+
+Examples:
+  backtide find "*.sql"
+  backtide find "*.sql" --job db --since 30d
+  backtide find "/etc/nginx/nginx.conf" --since 2025-01-01`,
+	Args: cobra.ExactArgs(1),
+	Run:  runFind,
+}
+
+func init() {
+	findCmd.Flags().StringVarP(&findJob, "job", "j", "", "only search backups of this job")
+	findCmd.Flags().StringVar(&findSince, "since", "", "only search backups taken since a date (YYYY-MM-DD[ HH:MM]) or within a period (e.g., 30d)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(findCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("find", findCmd)
+}
+
+func runFind(cmd *cobra.Command, args []string) {
+	pattern := args[0]
+
+	var since time.Time
+	if findSince != "" {
+		var err error
+		if since, err = parseListTime(findSince); err != nil {
+			render.Printf("Error: invalid --since: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := backup.NewBackupRunner(*cfg).FindFiles(findJob, pattern, since)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Printf("=== Find: %s ===\n", pattern)
+	backups := 0
+	var current *config.BackupMetadata
+	for _, match := range result.Matches {
+		if match.Backup != current {
+			current = match.Backup
+			backups++
+			render.Printf("\n📦 %s  %s  (job %s)\n", current.ID, current.Timestamp.Format("2006-01-02 15:04"), current.JobName)
+		}
+		render.Printf("   %s  %s  %s\n", match.Path, utils.FormatBytes(match.Size), match.ModTime.Local().Format("2006-01-02 15:04"))
+	}
+
+	if len(result.Matches) == 0 {
+		render.Printf("No matching files in %d backups\n", result.Searched)
+	} else {
+		render.Printf("\n📊 %d files in %d of %d backups\n", len(result.Matches), backups, result.Searched)
+	}
+	if result.Unindexed > 0 {
+		render.Printf("⚠️  %d older backups have no file index and were not searched\n", result.Unindexed)
+	}
+}
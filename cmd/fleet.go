@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/fleet"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fleetListen string
+	fleetStale  string
+)
+
+// fleetCmd represents the fleet command
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Collect and show backup health of many hosts",
+	Long: `Aggregate backup health across hosts. Daemons with [fleet] report_to set POST
+a signed summary of every run to a collector started with 'backtide fleet serve';
+'backtide fleet status' on the collector host shows the latest state of every
+host and job.
+
+Reports are signed with the shared [fleet] secret, which must be the same on
+the collector and all reporting hosts.`,
+}
+
+// fleetServeCmd represents the fleet serve command
+var fleetServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the fleet collector",
+	Long: `Receive run summaries from reporting hosts on POST /v1/reports.
+
+The collector serves plain HTTP; put it behind a TLS-terminating reverse proxy
+when reports cross untrusted networks.
+
+Examples:
+  backtide fleet serve
+  backtide fleet serve --listen 127.0.0.1:8750`,
+	Run: runFleetServe,
+}
+
+// fleetStatusCmd represents the fleet status command
+var fleetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the latest backup state of every reporting host",
+	Long: `Show the latest reported run of every host and job known to the collector
+on this host. Jobs whose last run failed or that have not succeeded within
+their max_age, or --stale for jobs without one, are flagged, and the command
+exits non-zero so it can drive monitoring.`,
+	Run: runFleetStatus,
+}
+
+func init() {
+	fleetCmd.AddCommand(fleetServeCmd)
+	fleetCmd.AddCommand(fleetStatusCmd)
+
+	fleetServeCmd.Flags().StringVar(&fleetListen, "listen", "", "address to listen on (default from [fleet] listen, or :8750)")
+	fleetStatusCmd.Flags().StringVar(&fleetStale, "stale", "", "flag jobs without a success for this long (default from [fleet] stale_after, or 26h)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(fleetCmd, fleetStatusCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("fleet", fleetCmd)
+}
+
+func runFleetServe(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Fleet.Secret == "" {
+		render.Println("Error: [fleet] secret must be set to verify reports")
+		os.Exit(1)
+	}
+
+	address := fleetListen
+	if address == "" {
+		address = cfg.Fleet.ListenAddress()
+	}
+
+	collector, err := fleet.NewCollector(cfg.Fleet.Secret)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	server := &http.Server{
+		Addr:              address,
+		Handler:           collector.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signalChan
+		render.Println("\n🛑 Shutting down fleet collector...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	render.Printf("📡 Fleet collector listening on %s\n", address)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runFleetStatus(cmd *cobra.Command, args []string) {
+	var fleetConfig config.FleetConfig
+	if cfg, err := config.LoadConfig(getConfigPath()); err == nil {
+		fleetConfig = cfg.Fleet
+	}
+
+	stale := fleetConfig.StaleDuration()
+	if fleetStale != "" {
+		d, err := utils.ParseDuration(fleetStale)
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		stale = d
+	}
+
+	statuses, err := fleet.LoadStatus()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Println("=== Fleet Status ===")
+	if len(statuses) == 0 {
+		render.Println("No reports received yet.")
+		render.Println("💡 Set [fleet] report_to on each host and run 'backtide fleet serve' here")
+		return
+	}
+
+	unhealthy := 0
+	hosts := make(map[string]bool)
+	for _, status := range statuses {
+		hosts[status.Host] = true
+
+		icon := "✅"
+		note := ""
+		switch {
+		case status.Last.State == control.RunFailed:
+			icon = "❌"
+			note = fmt.Sprintf("failed %d time(s) in a row: %s", status.Failures, status.Last.Error)
+		case status.LastSuccess.IsZero():
+			icon = "⚠️ "
+			note = "never succeeded"
+		case status.MaxAge() > 0 && status.Overdue(status.MaxAge(), time.Now()):
+			icon = "❌"
+			note = fmt.Sprintf("no success for %s, above max_age %s", time.Since(status.LastSuccess).Round(time.Minute), status.Last.MaxAge)
+		case status.MaxAge() == 0 && status.Overdue(stale, time.Now()):
+			icon = "⚠️ "
+			note = fmt.Sprintf("no success for %s", time.Since(status.LastSuccess).Round(time.Minute))
+		}
+		if icon != "✅" {
+			unhealthy++
+		}
+
+		render.Printf("\n%s %s / %s\n", icon, status.Host, status.Job)
+		render.Printf("   Last run: %s at %s\n", status.Last.State, status.Last.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+		if !status.LastSuccess.IsZero() {
+			render.Printf("   Last success: %s (%s ago)\n", status.LastSuccess.Local().Format("2006-01-02 15:04:05"),
+				time.Since(status.LastSuccess).Round(time.Minute))
+		}
+		if status.Last.BackupID != "" {
+			render.Printf("   Backup: %s, %s\n", status.Last.BackupID, utils.FormatBytes(status.Last.TotalSize))
+		}
+		if note != "" {
+			render.Printf("   %s\n", note)
+		}
+	}
+
+	versions, newest := fleet.HostVersions(statuses)
+	lagging := 0
+	render.Println("\n=== Versions ===")
+	for _, hv := range versions {
+		if hv.Lagging {
+			lagging++
+			render.Printf("⬆️  %s: %s (%s available)\n", hv.Host, hv.Version, newest)
+		} else if hv.Version != "" {
+			render.Printf("   %s: %s\n", hv.Host, hv.Version)
+		} else {
+			render.Printf("   %s: unknown\n", hv.Host)
+		}
+	}
+
+	render.Printf("\n📊 %d hosts, %d jobs, %d need attention\n", len(hosts), len(statuses), unhealthy)
+	if lagging > 0 {
+		render.Printf("⬆️  %d host(s) behind backtide %s\n", lagging, newest)
+	}
+	if unhealthy > 0 {
+		os.Exit(1)
+	}
+}
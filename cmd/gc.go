@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcMinAge string
+)
+
+// gcCmd represents the gc command
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove data left behind by failed runs and removed buckets",
+	Long: `Find and remove orphaned data:
+- backup directories without metadata (failed or interrupted runs)
+- system state captures and staged backups left in the temp path
+- run records, mount state and interrupted writes in the state directory
+- credentials files of buckets that are no longer configured
+
+Backup and temp directories are only considered when no runs are active and
+they are older than --min-age. Found items are listed and removed after
+confirmation.
+
+Examples:
+  backtide gc --dry-run
+  sudo backtide gc
+  sudo backtide gc --min-age 7d --force`,
+	Run: runGC,
+}
+
+func init() {
+	gcCmd.Flags().StringVar(&gcMinAge, "min-age", "24h", "only remove backup and temp directories older than this (e.g., 12h, 7d)")
+
+	// Register with command registry
+	commands.RegisterCommand("gc", gcCmd)
+}
+
+func runGC(cmd *cobra.Command, args []string) {
+	minAge, err := utils.ParseDuration(gcMinAge)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Println("=== Garbage Collection ===")
+	garbage, err := backup.NewBackupRunner(*cfg).FindGarbage(minAge)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(garbage) == 0 {
+		render.Println("✅ Nothing to clean up")
+		return
+	}
+
+	var total int64
+	for _, item := range garbage {
+		render.Printf("🗑️  %s\n    %s, %s\n", item.Path, item.Reason, utils.FormatBytes(item.Size))
+		total += item.Size
+	}
+	render.Printf("\n📊 %d items, %s\n", len(garbage), utils.FormatBytes(total))
+
+	if dryRun {
+		render.Println("DRY RUN: Nothing was removed")
+		return
+	}
+
+	if !force {
+		if !confirmOrCancel(newPrompter(), "Remove these items?") {
+			return
+		}
+	}
+
+	if err := backup.RemoveGarbage(garbage); err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	render.Printf("✅ Removed %d items\n", len(garbage))
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFormat string
+	historySince  string
+	historyOutput string
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Work with the history of backup runs",
+	Long: `Every finished backup run, manual or scheduled, is recorded with its job,
+outcome, duration, size and file count.`,
+}
+
+// historyExportCmd represents the history export command
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export run history for dashboards",
+	Long: `Export run history as JSON, CSV or an SQLite database for Grafana, Metabase
+or spreadsheets.
+
+SQLite export requires the sqlite3 command. Rows are keyed by run ID, so
+exporting repeatedly into the same database adds new runs without duplicates.
+
+Examples:
+  backtide history export --format json --since 90d
+  backtide history export --format csv --output runs.csv
+  backtide history export --format sqlite --output /var/lib/grafana/backtide.db`,
+	Run: runHistoryExport,
+}
+
+func init() {
+	historyCmd.AddCommand(historyExportCmd)
+
+	historyExportCmd.Flags().StringVar(&historyFormat, "format", "json", "output format: json, csv or sqlite")
+	historyExportCmd.Flags().StringVar(&historySince, "since", "", "only export runs started within this period (e.g., 90d, 12h)")
+	historyExportCmd.Flags().StringVarP(&historyOutput, "output", "o", "", "write to this file instead of stdout (required for sqlite)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(historyCmd, historyExportCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("history", historyCmd)
+}
+
+// historyColumns are the exported fields, in order
+var historyColumns = []string{"run_id", "job", "host", "state", "backup_id", "started_at", "finished_at",
+	"duration_seconds", "total_size", "file_count", "error"}
+
+// historyRow is a run in export form
+type historyRow struct {
+	RunID           string  `json:"run_id"`
+	Job             string  `json:"job"`
+	Host            string  `json:"host"`
+	State           string  `json:"state"`
+	BackupID        string  `json:"backup_id"`
+	StartedAt       string  `json:"started_at"`
+	FinishedAt      string  `json:"finished_at"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	TotalSize       int64   `json:"total_size"`
+	FileCount       int     `json:"file_count"`
+	Error           string  `json:"error"`
+}
+
+// newHistoryRow converts a history entry, using UTC RFC 3339 timestamps that dashboards parse natively
+func newHistoryRow(entry state.HistoryEntry) historyRow {
+	return historyRow{
+		RunID:           entry.RunID,
+		Job:             entry.Job,
+		Host:            entry.Host,
+		State:           entry.State,
+		BackupID:        entry.BackupID,
+		StartedAt:       entry.StartedAt.UTC().Format(time.RFC3339),
+		FinishedAt:      entry.FinishedAt.UTC().Format(time.RFC3339),
+		DurationSeconds: entry.Duration().Seconds(),
+		TotalSize:       entry.TotalSize,
+		FileCount:       entry.FileCount,
+		Error:           entry.Error,
+	}
+}
+
+// values returns the row's fields as strings, in historyColumns order
+func (r historyRow) values() []string {
+	return []string{r.RunID, r.Job, r.Host, r.State, r.BackupID, r.StartedAt, r.FinishedAt,
+		strconv.FormatFloat(r.DurationSeconds, 'f', 3, 64), strconv.FormatInt(r.TotalSize, 10),
+		strconv.Itoa(r.FileCount), r.Error}
+}
+
+func runHistoryExport(cmd *cobra.Command, args []string) {
+	var since time.Time
+	if historySince != "" {
+		period, err := utils.ParseDuration(historySince)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --since: %v\n", err)
+			os.Exit(1)
+		}
+		since = time.Now().Add(-period)
+	}
+
+	entries, err := state.LoadHistory(since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	rows := make([]historyRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, newHistoryRow(entry))
+	}
+
+	switch historyFormat {
+	case "json", "csv":
+		out := io.Writer(os.Stdout)
+		if historyOutput != "" {
+			file, err := os.Create(historyOutput)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			out = file
+		}
+		if historyFormat == "json" {
+			err = writeHistoryJSON(out, rows)
+		} else {
+			err = writeHistoryCSV(out, rows)
+		}
+	case "sqlite":
+		if historyOutput == "" {
+			fmt.Fprintln(os.Stderr, "Error: --output is required for sqlite export")
+			os.Exit(1)
+		}
+		err = writeHistorySQLite(historyOutput, rows)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format '%s' (use json, csv or sqlite)\n", historyFormat)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if historyOutput != "" {
+		render.Printf("✅ Exported %d runs to %s\n", len(rows), historyOutput)
+	}
+}
+
+// writeHistoryJSON writes rows as a JSON array
+func writeHistoryJSON(out io.Writer, rows []historyRow) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// writeHistoryCSV writes rows as CSV with a header line
+func writeHistoryCSV(out io.Writer, rows []historyRow) error {
+	writer := csv.NewWriter(out)
+	writer.Write(historyColumns)
+	for _, row := range rows {
+		writer.Write(row.values())
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeHistorySQLite upserts rows into the runs table of an SQLite database using the sqlite3 command
+func writeHistorySQLite(path string, rows []historyRow) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return fmt.Errorf("sqlite3 is not installed (e.g., apt install sqlite3); use --format csv or json instead")
+	}
+
+	var script strings.Builder
+	script.WriteString(`CREATE TABLE IF NOT EXISTS runs (
+  run_id TEXT PRIMARY KEY,
+  job TEXT NOT NULL,
+  host TEXT,
+  state TEXT NOT NULL,
+  backup_id TEXT,
+  started_at TEXT NOT NULL,
+  finished_at TEXT NOT NULL,
+  duration_seconds REAL,
+  total_size INTEGER,
+  file_count INTEGER,
+  error TEXT
+);
+CREATE INDEX IF NOT EXISTS runs_started_at ON runs (started_at);
+BEGIN;
+`)
+	for _, row := range rows {
+		values := row.values()
+		for i, value := range values {
+			switch historyColumns[i] {
+			case "duration_seconds", "total_size", "file_count":
+				// Numeric columns are written as is
+			default:
+				values[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+			}
+		}
+		fmt.Fprintf(&script, "INSERT OR REPLACE INTO runs (%s) VALUES (%s);\n",
+			strings.Join(historyColumns, ", "), strings.Join(values, ", "))
+	}
+	script.WriteString("COMMIT;\n")
+
+	cmd := exec.Command("sqlite3", "-bail", path)
+	cmd.Stdin = strings.NewReader(script.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sqlite3 failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -4,14 +4,46 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/mitexleo/backtide/internal/audit"
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/schedule"
+	"github.com/mitexleo/backtide/internal/wizard"
 	"github.com/spf13/cobra"
 )
 
 var (
-	initForce bool
+	initForce    bool
+	initFromFile string
+
+	// Job provisioning
+	initJobName     string
+	initSchedule    string
+	initTimezone    string
+	initDirs        []string
+	initStorage     string
+	initBackupPath  string
+	initKeepDays    int
+	initKeepCount   int
+	initKeepMonthly int
+	initSkipDocker  bool
+
+	// Bucket provisioning
+	initBucket          string
+	initBucketName      string
+	initBucketProvider  string
+	initBucketRegion    string
+	initBucketEndpoint  string
+	initBucketPathStyle bool
+	initBucketMount     string
+	initBucketOnDemand  bool
+	initBucketAccessKey string
+	initBucketSecretKey string
 )
 
 var initCmd = &cobra.Command{
@@ -21,107 +53,388 @@ var initCmd = &cobra.Command{
 
 This command creates:
 - Configuration file at /etc/backtide/config.toml
+  (or /etc/backtide/profiles/<name>.toml with --profile <name>)
 - Required system directories
 - S3 credentials directory
 
-Use this command once during initial setup.`,
+Use this command once during initial setup. --dry-run shows the directories
+and configuration it would create; with --force the changes to the existing
+configuration are shown and the previous file is kept as <config>.bak.
+
+Without a TTY (cloud-init, user-data scripts), a host can be provisioned in
+one command: --from-file starts from an existing configuration, --bucket adds
+an S3 bucket and --job-name/--dirs add a backup job. S3 keys can also be
+passed as BACKTIDE_S3_ACCESS_KEY and BACKTIDE_S3_SECRET_KEY to keep them out
+of the process list.
+
+Examples:
+  sudo backtide init
+  sudo backtide init --from-file /root/backtide.toml
+  sudo backtide init --job-name app --schedule daily --dirs /srv/app,/etc/nginx \
+    --bucket acme-backups --bucket-region eu-central-1 --bucket-mount /mnt/backups
+  sudo backtide init --job-name app --dirs /srv/app --storage local --backup-path /var/backups`,
 	Run: runInit,
 }
 
 func init() {
 	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite existing configuration")
+	initCmd.Flags().StringVar(&initFromFile, "from-file", "", "start from this configuration file instead of the defaults")
+
+	initCmd.Flags().StringVar(&initJobName, "job-name", "", "add a backup job with this name")
+	initCmd.Flags().StringVar(&initSchedule, "schedule", "daily", "job schedule: daily, weekly, monthly, hourly, a cron expression, a duration or manual")
+	initCmd.Flags().StringVar(&initTimezone, "timezone", "", "timezone the job schedule is evaluated in (default: system timezone)")
+	initCmd.Flags().StringSliceVar(&initDirs, "dirs", nil, "directories the job backs up, as path or name=path (comma-separated)")
+	initCmd.Flags().StringVar(&initStorage, "storage", "", "job storage: s3, local or both (default: s3 with --bucket, otherwise local)")
+	initCmd.Flags().StringVar(&initBackupPath, "backup-path", "", "local backup directory for local storage")
+	initCmd.Flags().IntVar(&initKeepDays, "keep-days", 30, "keep backups for this many days")
+	initCmd.Flags().IntVar(&initKeepCount, "keep-count", 10, "keep this many recent backups")
+	initCmd.Flags().IntVar(&initKeepMonthly, "keep-monthly", 6, "keep this many monthly backups")
+	initCmd.Flags().BoolVar(&initSkipDocker, "skip-docker", false, "do not stop Docker containers during backups")
+
+	initCmd.Flags().StringVar(&initBucket, "bucket", "", "add an S3 bucket with this bucket name")
+	initCmd.Flags().StringVar(&initBucketName, "bucket-display-name", "", "display name of the bucket (default: the bucket name)")
+	initCmd.Flags().StringVar(&initBucketProvider, "bucket-provider", "AWS S3", "S3 provider name")
+	initCmd.Flags().StringVar(&initBucketRegion, "bucket-region", "", "bucket region")
+	initCmd.Flags().StringVar(&initBucketEndpoint, "bucket-endpoint", "", "S3 endpoint URL (default: AWS)")
+	initCmd.Flags().BoolVar(&initBucketPathStyle, "bucket-path-style", false, "use path-style endpoints")
+	initCmd.Flags().StringVar(&initBucketMount, "bucket-mount", "", "bucket mount point (default: /mnt/backtide-<bucket>)")
+	initCmd.Flags().BoolVar(&initBucketOnDemand, "bucket-on-demand", false, "mount the bucket only while backups and restores run")
+	initCmd.Flags().StringVar(&initBucketAccessKey, "bucket-access-key", "", "S3 access key (or BACKTIDE_S3_ACCESS_KEY)")
+	initCmd.Flags().StringVar(&initBucketSecretKey, "bucket-secret-key", "", "S3 secret key (or BACKTIDE_S3_SECRET_KEY)")
 
 	// Register with command registry
 	commands.RegisterCommand("init", initCmd)
 }
 
 func runInit(cmd *cobra.Command, args []string) {
-	fmt.Println("Initializing backtide...")
+	render.Println("Initializing backtide...")
 
 	// Use specified config file or default to system location
 	configPath := cfgFile
-	if configPath == "" {
-		configPath = "/etc/backtide/config.toml"
+	if configPath == "" && activeProfile() != "" {
+		configPath = config.ProfilePath(activeProfile())
+	} else if configPath == "" {
+		configPath = paths.ConfigFile()
 	}
 
 	// Check if config file already exists
-	if _, err := os.Stat(configPath); err == nil {
-		if !initForce {
-			fmt.Printf("Configuration file already exists: %s\n", configPath)
-			fmt.Println("Use --force to overwrite existing configuration")
-			os.Exit(1)
+	_, statErr := os.Stat(configPath)
+	exists := statErr == nil
+	if exists && !initForce {
+		render.Printf("Configuration file already exists: %s\n", configPath)
+		render.Println("Use --force to overwrite existing configuration")
+		os.Exit(1)
+	}
+
+	// Create default configuration, or provision one from flags
+	defaultConfig, newBucket, err := initialConfig()
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	configDir := filepath.Dir(configPath)
+	dirs := paths.New().Dirs()
+
+	if dryRun {
+		render.Println("DRY RUN: No changes will be made")
+		render.Println("\n📁 Directories that would be created:")
+		var created []string
+		for _, dir := range append([]string{configDir}, dirs...) {
+			if _, err := os.Stat(dir); os.IsNotExist(err) && !slices.Contains(created, dir) {
+				render.Printf("  %s\n", dir)
+				created = append(created, dir)
+			}
+		}
+		if len(created) == 0 {
+			render.Println("  none (all exist)")
 		}
+		if exists {
+			render.Printf("\n💾 Would back up %s to %s.bak and write:\n", configPath, configPath)
+		} else {
+			render.Printf("\n💾 Would write %s with:\n", configPath)
+		}
+		printInitConfigChanges(configPath, exists, defaultConfig)
+		if newBucket != nil {
+			render.Printf("\n🔧 Would set up s3fs credentials for bucket %s", newBucket.Name)
+			if !newBucket.MountOnDemand {
+				render.Printf(" and mount it at %s via /etc/fstab", newBucket.MountPoint)
+			}
+			render.Println()
+		}
+		if os.Geteuid() == 0 {
+			render.Println("⏰ Would install or update the systemd service")
+		}
+		return
 	}
 
 	// Create configuration directory
-	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
-		fmt.Printf("Error creating configuration directory: %v\n", err)
-		fmt.Println("💡 You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide init")
+		render.Printf("Error creating configuration directory: %v\n", err)
+		render.Println("💡 You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo backtide init")
 		os.Exit(1)
 	}
 
-	// Create default configuration
-	defaultConfig := config.DefaultConfig()
+	// Show what --force changes and keep the previous file
+	if exists {
+		render.Printf("📝 Changes to %s:\n", configPath)
+		printInitConfigChanges(configPath, exists, defaultConfig)
+		if err := backupConfigFile(configPath); err != nil {
+			render.Printf("❌ Error backing up existing configuration: %v\n", err)
+			os.Exit(1)
+		}
+		render.Printf("🗄️  Previous configuration saved to: %s.bak\n", configPath)
+	}
 
 	// Save configuration to system location
-	fmt.Printf("💾 Saving configuration to: %s\n", configPath)
-	if err := config.SaveConfig(defaultConfig, configPath); err != nil {
-		fmt.Printf("❌ Error saving configuration: %v\n", err)
-		fmt.Println("💡 You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide init")
+	render.Printf("💾 Saving configuration to: %s\n", configPath)
+	if err := saveConfigWithAudit(defaultConfig, configPath, "init", configPath); err != nil {
+		render.Printf("❌ Error saving configuration: %v\n", err)
+		render.Println("💡 You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo backtide init")
 		os.Exit(1)
 	}
 
 	// Create necessary system directories
-	fmt.Println("📁 Creating system directories...")
-	dirs := []string{
-		"/etc/backtide",
-		"/etc/backtide/s3-credentials",
-		"/var/lib/backtide",
-		"/var/log/backtide",
-		"/tmp/backtide",
-	}
-
+	render.Println("📁 Creating system directories...")
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			fmt.Printf("  Warning: Could not create %s: %v\n", dir, err)
+			render.Printf("  Warning: Could not create %s: %v\n", dir, err)
 		} else {
-			fmt.Printf("  Created: %s\n", dir)
+			render.Printf("  Created: %s\n", dir)
 		}
 	}
 
+	if newBucket != nil {
+		setupBucketMount(*newBucket)
+	}
+
 	// Automatically set up systemd daemon if running as root
 	if os.Geteuid() == 0 {
-		fmt.Println("\n⏰ Setting up scheduling daemon...")
+		render.Println("\n⏰ Setting up scheduling daemon...")
 
 		// Automatically set up systemd service
-		fmt.Println("  🔄 Setting up systemd service...")
+		render.Println("  🔄 Setting up systemd service...")
 		if err := ensureSystemdService(configPath); err != nil {
-			fmt.Printf("  ⚠️  Warning: Could not set up systemd service: %v\n", err)
+			render.Printf("  ⚠️  Warning: Could not set up systemd service: %v\n", err)
 		} else {
-			fmt.Println("  ✅ Systemd service configured automatically")
-			fmt.Println("     Service will be updated automatically during future updates")
+			render.Println("  ✅ Systemd service configured automatically")
+			render.Println("     Service will be updated automatically during future updates")
 		}
 	} else {
-		fmt.Println("\n💡 To enable automated backups, run:")
-		fmt.Println("   sudo backtide systemd install")
+		render.Println("\n💡 To enable automated backups, run:")
+		render.Println("   sudo backtide systemd install")
 	}
 
-	fmt.Printf("\n✅ Configuration created successfully: %s\n", configPath)
-	fmt.Println("\nNext steps:")
-	fmt.Println("1. Edit the configuration file with your specific settings")
-	fmt.Println("2. Add backup jobs: backtide jobs add")
-	fmt.Println("3. Add S3 buckets: backtide s3 add")
-	fmt.Println("4. Configure directories to backup")
-	fmt.Println("5. Test the backup: backtide backup --dry-run")
+	render.Printf("\n✅ Configuration created successfully: %s\n", configPath)
+	if len(defaultConfig.Jobs) > 0 {
+		render.Println("\nNext steps:")
+		render.Println("1. Test the backup: backtide backup --dry-run")
+		render.Println("2. Run the backup: backtide backup")
+		return
+	}
+	render.Println("\nNext steps:")
+	render.Println("1. Edit the configuration file with your specific settings")
+	render.Println("2. Add backup jobs: backtide jobs add")
+	render.Println("3. Add S3 buckets: backtide s3 add")
+	render.Println("4. Configure directories to backup")
+	render.Println("5. Test the backup: backtide backup --dry-run")
 	if os.Geteuid() != 0 {
-		fmt.Println("6. Set up automated backups: sudo backtide systemd install")
+		render.Println("6. Set up automated backups: sudo backtide systemd install")
+	}
+	render.Println("\nExample commands:")
+	render.Println("  backtide jobs add                  # Add backup job")
+	render.Println("  backtide s3 add                    # Add S3 bucket")
+	render.Println("  backtide backup --dry-run          # Test backup")
+	render.Println("  backtide systemd install           # Set up systemd service")
+}
+
+// initialConfig returns the configuration init writes: the defaults or
+// --from-file, plus the bucket and job given by flags. The bucket added by
+// --bucket is returned so its mount can be set up once the file is saved.
+func initialConfig() (*config.BackupConfig, *config.BucketConfig, error) {
+	cfg := config.DefaultConfig()
+	if initFromFile != "" {
+		loaded, err := config.LoadConfig(initFromFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s: %w", initFromFile, err)
+		}
+		cfg = loaded
+	}
+	if initBackupPath != "" {
+		cfg.BackupPath = initBackupPath
+	}
+
+	var newBucket *config.BucketConfig
+	if initBucket != "" {
+		bucket := initBucketConfig()
+		cfg.Buckets = append(cfg.Buckets, bucket)
+		newBucket = &bucket
+	}
+
+	if initJobName != "" || len(initDirs) > 0 {
+		job, err := initJobConfig(cfg, newBucket)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Jobs = append(cfg.Jobs, job)
+	}
+
+	if err := config.ValidateConfig(cfg); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, newBucket, nil
+}
+
+// initBucketConfig builds the bucket given by the --bucket flags
+func initBucketConfig() config.BucketConfig {
+	bucket := config.BucketConfig{
+		ID:            wizard.NewBucketID(),
+		Name:          initBucketName,
+		Provider:      initBucketProvider,
+		Bucket:        initBucket,
+		Region:        initBucketRegion,
+		Endpoint:      initBucketEndpoint,
+		UsePathStyle:  initBucketPathStyle,
+		MountPoint:    initBucketMount,
+		MountOnDemand: initBucketOnDemand,
+		AccessKey:     initBucketAccessKey,
+		SecretKey:     initBucketSecretKey,
+	}
+	if bucket.Name == "" {
+		bucket.Name = initBucket
+	}
+	if bucket.MountPoint == "" {
+		bucket.MountPoint = filepath.Join(paths.MountDir(), "backtide-"+initBucket)
+	}
+	if bucket.AccessKey == "" {
+		bucket.AccessKey = os.Getenv("BACKTIDE_S3_ACCESS_KEY")
+	}
+	if bucket.SecretKey == "" {
+		bucket.SecretKey = os.Getenv("BACKTIDE_S3_SECRET_KEY")
+	}
+	return bucket
+}
+
+// initJobConfig builds the job given by the --job-name and --dirs flags
+func initJobConfig(cfg *config.BackupConfig, newBucket *config.BucketConfig) (config.BackupJob, error) {
+	job := config.BackupJob{
+		ID:          wizard.NewJobID(),
+		Name:        initJobName,
+		Enabled:     true,
+		SkipDocker:  initSkipDocker,
+		Directories: []config.DirectoryConfig{},
+		Retention: config.RetentionPolicy{
+			KeepDays:    initKeepDays,
+			KeepCount:   initKeepCount,
+			KeepMonthly: initKeepMonthly,
+		},
+	}
+	if job.Name == "" {
+		job.Name = "default-backup"
+	}
+	if findJobByName(cfg, job.Name) != nil {
+		return job, fmt.Errorf("a job named %s already exists", job.Name)
+	}
+
+	// Schedule
+	if initSchedule != "" && initSchedule != "manual" {
+		job.Schedule = config.ScheduleConfig{Type: "systemd", Interval: initSchedule, Enabled: true, Timezone: initTimezone}
+		if len(strings.Fields(initSchedule)) == 5 {
+			job.Schedule.Type = "cron"
+		}
+		if _, err := schedule.Parse(job.Schedule); err != nil {
+			return job, fmt.Errorf("invalid --schedule: %w", err)
+		}
+	}
+
+	// Storage
+	storage := initStorage
+	if storage == "" {
+		storage = "local"
+		if newBucket != nil {
+			storage = "s3"
+		}
+	}
+	switch storage {
+	case "s3":
+		job.Storage.S3 = true
+	case "local":
+		job.Storage.Local = true
+		job.SkipS3 = true
+	case "both":
+		job.Storage.S3 = true
+		job.Storage.Local = true
+	default:
+		return job, fmt.Errorf("invalid --storage: %s (use s3, local or both)", storage)
+	}
+	if job.Storage.S3 {
+		switch {
+		case newBucket != nil:
+			job.BucketID = newBucket.ID
+		case len(cfg.Buckets) == 1:
+			job.BucketID = cfg.Buckets[0].ID
+		case len(cfg.Buckets) == 0:
+			return job, fmt.Errorf("--storage %s needs a bucket; add one with --bucket", storage)
+		default:
+			return job, fmt.Errorf("--storage %s is ambiguous with several buckets in %s; add the job with 'backtide jobs add'", storage, initFromFile)
+		}
+	}
+	if job.Storage.Local && cfg.BackupPath == "" {
+		return job, fmt.Errorf("--storage %s needs --backup-path", storage)
+	}
+
+	// Directories, as path or name=path
+	for _, entry := range initDirs {
+		name, path, found := strings.Cut(entry, "=")
+		if !found {
+			path, name = entry, filepath.Base(entry)
+		}
+		if _, err := os.Stat(path); err != nil {
+			render.Printf("⚠️  Warning: Directory does not exist: %s\n", path)
+		}
+		job.Directories = append(job.Directories, config.DirectoryConfig{Path: path, Name: name, Compression: true})
+	}
+	if len(job.Directories) == 0 {
+		render.Println("⚠️  No directories configured. You can add them later in the configuration file.")
+	}
+
+	return job, nil
+}
+
+// printInitConfigChanges lists how cfg differs from the configuration at
+// configPath, or what it contains when there is none; secrets are not shown
+func printInitConfigChanges(configPath string, exists bool, cfg *config.BackupConfig) {
+	var previous *config.BackupConfig
+	if exists {
+		loaded, err := config.LoadConfig(configPath)
+		if err != nil {
+			render.Printf("  ⚠️  Existing configuration could not be read (%v); it is replaced entirely\n", err)
+			return
+		}
+		previous = loaded
+	}
+
+	changes := audit.DiffConfig(previous, cfg)
+	if len(changes) == 0 {
+		render.Println("  no changes (default configuration)")
+		return
+	}
+	for _, change := range changes {
+		render.Printf("  %s\n", change)
+	}
+}
+
+// backupConfigFile copies a configuration file to <path>.bak, keeping its permissions
+func backupConfigFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
 	}
-	fmt.Println("\nExample commands:")
-	fmt.Println("  backtide jobs add                  # Add backup job")
-	fmt.Println("  backtide s3 add                    # Add S3 bucket")
-	fmt.Println("  backtide backup --dry-run          # Test backup")
-	fmt.Println("  backtide systemd install           # Set up systemd service")
+	return os.WriteFile(path+".bak", data, info.Mode().Perm())
 }
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installBinDir    string
+	installInit      bool
+	installFromFile  string
+	installScheduler string
+)
+
+// installCmd represents the install command
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install backtide and its scheduler on this host",
+	Long: `Install the running binary and set up scheduled backups in one step:
+
+1. Copy this binary to --bin-dir (default /usr/local/bin) and check it runs
+2. Create the configuration, credentials, state, log and temp directories
+3. With --init, create the configuration if there is none, from the defaults
+   or --from-file
+4. Install and start the daemon service (--scheduler systemd), or install cron
+   entries for the scheduled jobs (--scheduler cron)
+
+Every step is skipped when it is already done, so install can be run again to
+repair or upgrade an installation. Use --dry-run to see what would change.
+
+Examples:
+  sudo ./backtide install --init
+  sudo ./backtide install --init --from-file /root/backtide.toml
+  sudo backtide install --scheduler cron`,
+	Run: runInstall,
+}
+
+func init() {
+	installCmd.Flags().StringVar(&installBinDir, "bin-dir", "/usr/local/bin", "directory to install the binary to")
+	installCmd.Flags().BoolVar(&installInit, "init", false, "create the configuration if it does not exist")
+	installCmd.Flags().StringVar(&installFromFile, "from-file", "", "with --init, start from this configuration file")
+	installCmd.Flags().StringVar(&installScheduler, "scheduler", "", "systemd, cron or none (default: systemd when available, otherwise cron)")
+
+	// Register with command registry
+	commands.RegisterCommand("install", installCmd)
+}
+
+func runInstall(cmd *cobra.Command, args []string) {
+	if os.Geteuid() != 0 && !dryRun {
+		render.Println("❌ Installing requires root")
+		render.Println("   Try: sudo backtide install")
+		os.Exit(1)
+	}
+
+	scheduler := installScheduler
+	if scheduler == "" {
+		scheduler = "cron"
+		if _, err := os.Stat("/etc/systemd/system"); err == nil {
+			scheduler = "systemd"
+		}
+	}
+	if scheduler != "systemd" && scheduler != "cron" && scheduler != "none" {
+		render.Printf("Error: invalid --scheduler %q (use systemd, cron or none)\n", scheduler)
+		os.Exit(1)
+	}
+	if installFromFile != "" && !installInit {
+		render.Println("Error: --from-file needs --init")
+		os.Exit(1)
+	}
+
+	configPath := getConfigPath()
+	var changes []string
+	err := installSteps(configPath, scheduler, &changes)
+	if !dryRun {
+		audit.RecordResult("install", filepath.Join(installBinDir, "backtide"), changes, err)
+	}
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if dryRun {
+		if len(changes) == 0 {
+			render.Println("\n✅ Nothing to do, backtide is installed")
+		}
+		return
+	}
+	render.Println("\n✅ backtide is installed")
+}
+
+// installSteps runs the install steps, recording what changed
+func installSteps(configPath, scheduler string, changes *[]string) error {
+	record := func(format string, args ...any) {
+		change := fmt.Sprintf(format, args...)
+		*changes = append(*changes, change)
+		if dryRun {
+			render.Printf("DRY RUN: Would %s\n", change)
+		} else {
+			render.Printf("✅ %s\n", strings.ToUpper(change[:1])+change[1:])
+		}
+	}
+
+	// Binary
+	render.Println("📦 Binary")
+	target := filepath.Join(installBinDir, "backtide")
+	changed, err := binaryDiffers(target)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if !dryRun {
+			if err := copyBinary(target); err != nil {
+				return err
+			}
+		}
+		record("install binary %s", target)
+	} else {
+		render.Printf("   %s is up to date\n", target)
+	}
+	if !dryRun {
+		output, err := exec.Command(target, "version").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("installed binary does not run: %v: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	// Directories
+	render.Println("📁 Directories")
+	for _, dir := range append([]string{filepath.Dir(configPath)}, paths.New().Dirs()...) {
+		if _, err := os.Stat(dir); err == nil {
+			continue
+		}
+		if !dryRun {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+		record("create %s", dir)
+	}
+
+	// Configuration
+	render.Println("📝 Configuration")
+	if _, err := os.Stat(configPath); err == nil {
+		render.Printf("   Keeping existing %s\n", configPath)
+	} else if installInit {
+		if !dryRun {
+			initArgs := []string{"init", "--config", configPath}
+			if installFromFile != "" {
+				initArgs = append(initArgs, "--from-file", installFromFile)
+			}
+			if err := runInstalled(target, initArgs...); err != nil {
+				return fmt.Errorf("init failed: %w", err)
+			}
+		}
+		record("create configuration %s", configPath)
+	} else {
+		render.Printf("   No configuration at %s; run 'backtide init' or install with --init\n", configPath)
+	}
+
+	// Scheduler
+	render.Printf("⏰ Scheduler (%s)\n", scheduler)
+	switch scheduler {
+	case "systemd":
+		return installService(configPath, changed, record)
+	case "cron":
+		if dryRun {
+			render.Println("   Cron entries would be synced with the scheduled jobs")
+			return nil
+		}
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil || !slices.ContainsFunc(cfg.Jobs, func(job config.BackupJob) bool {
+			return job.Enabled && job.Schedule.Enabled
+		}) {
+			render.Println("   No scheduled jobs yet; run 'backtide cron sync' after adding jobs")
+			return nil
+		}
+		return runInstalled(target, "cron", "sync", "--config", configPath)
+	}
+	return nil
+}
+
+// installService writes the daemon unit and makes sure the service is enabled
+// and running, restarting it when the binary was replaced
+func installService(configPath string, binaryChanged bool, record func(string, ...any)) error {
+	manager := systemd.NewServiceManager("backtide", "", configPath, "root")
+	applyServiceConfig(manager, configPath)
+
+	current, _ := os.ReadFile(manager.GetServiceFilePath())
+	unitChanged := string(current) != manager.GenerateServiceFile()
+	if unitChanged {
+		if !dryRun {
+			if err := manager.UpdateServiceFile(); err != nil {
+				return err
+			}
+		}
+		record("write %s", manager.GetServiceFilePath())
+	}
+
+	status, err := manager.GetServiceStatus()
+	if err != nil && !dryRun {
+		return fmt.Errorf("failed to check the daemon service: %w", err)
+	}
+	if !manager.IsServiceEnabled() {
+		if !dryRun {
+			if err := manager.EnableService(); err != nil {
+				return err
+			}
+		}
+		record("enable backtide.service")
+	}
+	switch {
+	case status == nil || !status.IsRunning:
+		if !dryRun {
+			if err := manager.StartService(); err != nil {
+				return err
+			}
+		}
+		record("start backtide.service")
+	case binaryChanged || unitChanged:
+		if !dryRun {
+			if err := manager.StopService(); err != nil {
+				return err
+			}
+			if err := manager.StartService(); err != nil {
+				return err
+			}
+		}
+		record("restart backtide.service")
+	default:
+		render.Println("   backtide.service is enabled and running")
+	}
+	return nil
+}
+
+// binaryDiffers reports whether target differs from the running binary
+func binaryDiffers(target string) (bool, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("could not determine current executable path: %w", err)
+	}
+	current, err := os.ReadFile(self)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", self, err)
+	}
+	installed, err := os.ReadFile(target)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	return !bytes.Equal(current, installed), nil
+}
+
+// copyBinary atomically replaces target with the running binary
+func copyBinary(target string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not determine current executable path: %w", err)
+	}
+	data, err := os.ReadFile(self)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", self, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+
+	// Rename over the old binary so a running daemon keeps its copy
+	tempFile := target + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tempFile, err)
+	}
+	if err := os.Rename(tempFile, target); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to install %s: %w", target, err)
+	}
+	return nil
+}
+
+// runInstalled runs the installed binary with output passed through
+func runInstalled(binary string, args ...string) error {
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -1,21 +1,22 @@
 package cmd
 
 import (
-	"bufio"
-	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/schedule"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/mitexleo/backtide/internal/wizard"
 	"github.com/spf13/cobra"
 )
 
 var (
-	jobsShowAll bool
+	jobsShowAll  bool
+	jobsNextRuns int
 )
 
 // jobsCmd represents the jobs command
@@ -64,7 +65,12 @@ This command displays:
 - Directory paths and settings
 - Retention policy
 - Storage configuration
-- Schedule details`,
+- Schedule details and the next scheduled runs
+- The result of the last run and the latest backup
+
+Examples:
+  backtide jobs show daily-backup
+  backtide jobs show daily-backup --next-runs 10`,
 	Args: cobra.ExactArgs(1),
 	Run:  runJobsShow,
 }
@@ -112,6 +118,11 @@ func init() {
 	jobsCmd.AddCommand(jobsAddCmd)
 
 	jobsListCmd.Flags().BoolVar(&jobsShowAll, "all", false, "show all jobs including disabled ones")
+	jobsShowCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "show bucket credentials unmasked (root only)")
+	jobsShowCmd.Flags().IntVar(&jobsNextRuns, "next-runs", 3, "number of upcoming scheduled runs to show")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(jobsCmd, jobsListCmd, jobsShowCmd)
 
 	// Register with command registry
 	commands.RegisterCommand("jobs", jobsCmd)
@@ -121,15 +132,15 @@ func runJobsList(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("=== Backup Jobs ===")
+	render.Println("=== Backup Jobs ===")
 
 	if len(cfg.Jobs) == 0 {
-		fmt.Println("No backup jobs configured.")
-		fmt.Println("Use 'backtide jobs add' to create backup jobs.")
+		render.Println("No backup jobs configured.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
 		return
 	}
 
@@ -138,45 +149,51 @@ func runJobsList(cmd *cobra.Command, args []string) {
 			continue
 		}
 
-		fmt.Printf("\n%d. %s\n", i+1, job.Name)
+		render.Printf("\n%d. %s\n", i+1, job.Name)
 
 		status := "❌ disabled"
 		if job.Enabled {
 			status = "✅ enabled"
 		}
-		fmt.Printf("   Status: %s\n", status)
+		render.Printf("   Status: %s\n", status)
 
 		if job.Description != "" {
-			fmt.Printf("   Description: %s\n", job.Description)
+			render.Printf("   Description: %s\n", job.Description)
 		}
 
 		// Schedule information
 		if job.Schedule.Enabled {
-			fmt.Printf("   Schedule: %s (%s)\n", job.Schedule.Type, job.Schedule.Interval)
+			render.Printf("   Schedule: %s (%s)\n", job.Schedule.Type, job.Schedule.Interval)
+			if job.Schedule.Timezone != "" {
+				render.Printf("   Timezone: %s\n", job.Schedule.Timezone)
+			}
 		} else {
-			fmt.Printf("   Schedule: manual only\n")
+			render.Printf("   Schedule: manual only\n")
 		}
 
 		// Directories
-		fmt.Printf("   Directories: %d\n", len(job.Directories))
+		render.Printf("   Directories: %d\n", len(job.Directories))
 		for _, dir := range job.Directories {
-			compression := ""
+			notes := ""
 			if dir.Compression {
-				compression = " (compressed)"
+				notes = " (compressed)"
 			}
-			fmt.Printf("     - %s -> %s%s\n", dir.Path, dir.Name, compression)
+			if dir.Required {
+				notes += " (required)"
+			}
+			render.Printf("     - %s -> %s%s\n", dir.Path, dir.Name, notes)
 		}
 
 		// Storage configuration
-		fmt.Printf("   Storage: ")
+		render.Printf("   Storage: ")
 		if job.Storage.Local && job.Storage.S3 {
-			fmt.Printf("Local + S3\n")
+			render.Printf("Local + S3\n")
 		} else if job.Storage.Local {
-			fmt.Printf("Local only\n")
+			render.Printf("Local only\n")
 		} else if job.Storage.S3 {
-			fmt.Printf("S3 only\n")
+			render.Printf("S3 only\n")
 		} else {
-			fmt.Printf("None configured\n")
+			render.Printf("None configured\n")
 		}
 
 		// Bucket reference
@@ -188,23 +205,28 @@ func runJobsList(cmd *cobra.Command, args []string) {
 					break
 				}
 			}
-			fmt.Printf("   S3 Bucket: %s (%s)\n", bucketName, job.BucketID)
+			render.Printf("   S3 Bucket: %s (%s)\n", bucketName, job.BucketID)
 		}
 
 		// Retention policy
-		fmt.Printf("   Retention: %d days, %d recent, %d monthly\n",
+		render.Printf("   Retention: %d days, %d recent, %d monthly\n",
 			job.Retention.KeepDays, job.Retention.KeepCount, job.Retention.KeepMonthly)
 
 		// Docker configuration
-		if job.SkipDocker {
-			fmt.Printf("   Docker: containers will NOT be stopped\n")
+		if len(job.Docker.ExecBefore) > 0 {
+			render.Printf("   Docker: containers are quiesced with exec hooks and keep running\n")
+		} else if job.SkipDocker {
+			render.Printf("   Docker: containers will NOT be stopped\n")
 		} else {
-			fmt.Printf("   Docker: containers will be stopped during backup\n")
+			render.Printf("   Docker: containers will be stopped during backup\n")
+		}
+		if job.Docker.Discover {
+			render.Printf("   Docker: backs up labeled containers and volumes\n")
 		}
 
 		// S3 configuration
 		if job.SkipS3 {
-			fmt.Printf("   S3: operations will be skipped\n")
+			render.Printf("   S3: operations will be skipped\n")
 		}
 	}
 
@@ -215,15 +237,16 @@ func runJobsList(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	fmt.Printf("\n📊 Summary: %d total jobs, %d enabled\n", len(cfg.Jobs), enabledCount)
+	render.Printf("\n📊 Summary: %d total jobs, %d enabled\n", len(cfg.Jobs), enabledCount)
 }
 
 func runJobsShow(cmd *cobra.Command, args []string) {
+	checkShowSecrets()
 	jobName := args[0]
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -236,57 +259,81 @@ func runJobsShow(cmd *cobra.Command, args []string) {
 	}
 
 	if job == nil {
-		fmt.Printf("Error: Job '%s' not found\n", jobName)
-		fmt.Println("Use 'backtide jobs list' to see available jobs.")
+		render.Printf("Error: Job '%s' not found\n", jobName)
+		render.Println("Use 'backtide jobs list' to see available jobs.")
 		os.Exit(1)
 	}
 
-	fmt.Printf("=== Job Details: %s ===\n\n", job.Name)
+	render.Printf("=== Job Details: %s ===\n\n", job.Name)
 
 	status := "❌ disabled"
 	if job.Enabled {
 		status = "✅ enabled"
 	}
-	fmt.Printf("Status: %s\n", status)
-	fmt.Printf("ID: %s\n", job.ID)
+	render.Printf("Status: %s\n", status)
+	render.Printf("ID: %s\n", job.ID)
 
 	if job.Description != "" {
-		fmt.Printf("Description: %s\n", job.Description)
+		render.Printf("Description: %s\n", job.Description)
 	}
 
-	fmt.Println("\n--- Schedule ---")
+	render.Println("\n--- Schedule ---")
 	if job.Schedule.Enabled {
-		fmt.Printf("Type: %s\n", job.Schedule.Type)
-		fmt.Printf("Interval: %s\n", job.Schedule.Interval)
+		render.Printf("Type: %s\n", job.Schedule.Type)
+		render.Printf("Interval: %s\n", job.Schedule.Interval)
+		if job.Schedule.Timezone != "" {
+			render.Printf("Timezone: %s\n", job.Schedule.Timezone)
+		} else {
+			render.Println("Timezone: host default")
+		}
+		render.Printf("Missed runs: %s\n", job.Schedule.Misfire())
+		if job.Schedule.Jitter != "" {
+			render.Printf("Jitter: up to %s (this host: +%s)\n", job.Schedule.Jitter, utils.FormatDuration(jitterOffset(*job)))
+		}
 	} else {
-		fmt.Println("Manual only (no automatic scheduling)")
+		render.Println("Manual only (no automatic scheduling)")
+	}
+
+	lastRun := lastJobRun(job.Name)
+	if job.Schedule.Enabled && jobsNextRuns > 0 {
+		printNextRuns(*job, lastRun, jobsNextRuns)
 	}
+	printLastRun(job.Name, lastRun)
 
-	fmt.Println("\n--- Directories ---")
+	render.Println("\n--- Directories ---")
+	if job.Docker.Discover {
+		render.Println("Plus the mounts of containers and volumes labeled backtide.enable=true")
+	}
 	if len(job.Directories) == 0 {
-		fmt.Println("No directories configured")
+		render.Println("No directories configured")
 	} else {
 		for i, dir := range job.Directories {
 			compression := "no"
 			if dir.Compression {
 				compression = "yes"
 			}
-			fmt.Printf("%d. %s\n", i+1, dir.Name)
-			fmt.Printf("   Path: %s\n", dir.Path)
-			fmt.Printf("   Compression: %s\n", compression)
-			fmt.Println()
+			render.Printf("%d. %s\n", i+1, dir.Name)
+			render.Printf("   Path: %s\n", dir.Path)
+			render.Printf("   Compression: %s\n", compression)
+			if dir.Required {
+				render.Println("   Required: yes (the backup fails if it is missing)")
+			}
+			for _, filter := range dir.Filters {
+				render.Printf("   Filter: %s\n", filter.Name)
+			}
+			render.Println()
 		}
 	}
 
-	fmt.Println("--- Storage ---")
+	render.Println("--- Storage ---")
 	if job.Storage.Local && job.Storage.S3 {
-		fmt.Println("Type: Local + S3 (redundant storage)")
+		render.Println("Type: Local + S3 (redundant storage)")
 	} else if job.Storage.Local {
-		fmt.Println("Type: Local only")
+		render.Println("Type: Local only")
 	} else if job.Storage.S3 {
-		fmt.Println("Type: S3 only")
+		render.Println("Type: S3 only")
 	} else {
-		fmt.Println("Type: None configured")
+		render.Println("Type: None configured")
 	}
 
 	if job.BucketID != "" {
@@ -299,37 +346,131 @@ func runJobsShow(cmd *cobra.Command, args []string) {
 				break
 			}
 		}
-		fmt.Printf("S3 Bucket: %s (%s)\n", bucketName, job.BucketID)
+		render.Printf("S3 Bucket: %s (%s)\n", bucketName, job.BucketID)
 		if bucketConfig != nil {
-			fmt.Printf("  - Provider: %s\n", bucketConfig.Provider)
-			fmt.Printf("  - Bucket: %s\n", bucketConfig.Bucket)
-			fmt.Printf("  - Region: %s\n", bucketConfig.Region)
-			fmt.Printf("  - Endpoint: %s\n", func() string {
+			render.Printf("  - Provider: %s\n", bucketConfig.Provider)
+			render.Printf("  - Bucket: %s\n", bucketConfig.Bucket)
+			render.Printf("  - Region: %s\n", bucketConfig.Region)
+			render.Printf("  - Endpoint: %s\n", func() string {
 				if bucketConfig.Endpoint == "" {
 					return "AWS default"
 				}
-				return bucketConfig.Endpoint
+				return secretURL(bucketConfig.Endpoint)
 			}())
-			fmt.Printf("  - Mount Point: %s\n", bucketConfig.MountPoint)
+			render.Printf("  - Mount Point: %s\n", bucketConfig.MountPoint)
+			render.Printf("  - Access Key: %s\n", secretValue(bucketConfig.AccessKey))
+			render.Printf("  - Secret Key: %s\n", secretValue(bucketConfig.SecretKey))
 		}
 	}
 
-	fmt.Println("\n--- Retention Policy ---")
-	fmt.Printf("Keep days: %d\n", job.Retention.KeepDays)
-	fmt.Printf("Keep count: %d\n", job.Retention.KeepCount)
-	fmt.Printf("Keep monthly: %d\n", job.Retention.KeepMonthly)
+	render.Println("\n--- Retention Policy ---")
+	render.Printf("Keep days: %d\n", job.Retention.KeepDays)
+	render.Printf("Keep count: %d\n", job.Retention.KeepCount)
+	render.Printf("Keep monthly: %d\n", job.Retention.KeepMonthly)
 
-	fmt.Println("\n--- Configuration ---")
-	if job.SkipDocker {
-		fmt.Println("Docker: Containers will NOT be stopped during backup")
+	render.Println("\n--- Configuration ---")
+	if len(job.Docker.ExecBefore) > 0 {
+		render.Println("Docker: Containers are quiesced in place and keep running")
+	} else if job.SkipDocker {
+		render.Println("Docker: Containers will NOT be stopped during backup")
 	} else {
-		fmt.Println("Docker: Containers will be stopped during backup")
+		render.Println("Docker: Containers will be stopped during backup")
+	}
+	for _, hook := range job.Docker.ExecBefore {
+		render.Printf("  Before backup in %s: %s\n", hook.Container, hook.Cmd)
+	}
+	for _, hook := range job.Docker.ExecAfter {
+		render.Printf("  After backup in %s: %s\n", hook.Container, hook.Cmd)
 	}
 
 	if job.SkipS3 {
-		fmt.Println("S3: Operations will be skipped")
+		render.Println("S3: Operations will be skipped")
+	} else {
+		render.Println("S3: Operations will be performed")
+	}
+}
+
+// lastJobRun returns the most recent run of a job in the run history, if any
+func lastJobRun(jobName string) *state.HistoryEntry {
+	entries, err := state.LoadHistory(time.Time{})
+	if err != nil {
+		render.Printf("Warning: %v\n", err)
+		return nil
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Job == jobName {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// printNextRuns prints the upcoming scheduled runs of a job; interval
+// schedules count from the start of the last run
+func printNextRuns(job config.BackupJob, lastRun *state.HistoryEntry, n int) {
+	sched, err := schedule.Parse(job.Schedule)
+	if err != nil {
+		render.Printf("Next runs: ⚠️  invalid schedule (%v)\n", err)
+		return
+	}
+
+	now := time.Now()
+	after := now
+	if sched.IsInterval() && lastRun != nil {
+		after = lastRun.StartedAt
+	}
+	runs := sched.NextN(after, n)
+
+	render.Println("Next runs:")
+	if pause, paused := state.ActivePause(job.Name); paused {
+		render.Printf("  ⏸️  paused until %s; runs before then are skipped\n", pause.Until.Format("2006-01-02 15:04"))
+	}
+	if !job.Enabled {
+		render.Println("  ❌ job is disabled; nothing runs until it is enabled")
+	}
+	for _, run := range runs {
+		if run.Before(now) {
+			render.Printf("  - %s (due now)\n", run.In(sched.Location()).Format("2006-01-02 15:04 MST"))
+			continue
+		}
+		render.Printf("  - %s (in %s)\n", run.In(sched.Location()).Format("2006-01-02 15:04 MST"), utils.FormatDuration(run.Sub(now)))
+	}
+}
+
+// printLastRun prints the last run of a job and its latest backup in the catalog
+func printLastRun(jobName string, lastRun *state.HistoryEntry) {
+	render.Println("\n--- Last Run ---")
+	if lastRun == nil {
+		render.Println("No runs recorded yet")
 	} else {
-		fmt.Println("S3: Operations will be performed")
+		result := "✅ " + lastRun.State
+		if lastRun.State != "succeeded" {
+			result = "❌ " + lastRun.State
+		}
+		render.Printf("Result: %s\n", result)
+		render.Printf("Started: %s (%s ago)\n", lastRun.StartedAt.Format("2006-01-02 15:04:05"), utils.FormatDuration(time.Since(lastRun.StartedAt)))
+		render.Printf("Duration: %s\n", lastRun.Duration().Round(time.Millisecond))
+		if lastRun.BackupID != "" {
+			render.Printf("Backup: %s (%s, %d files)\n", lastRun.BackupID, utils.FormatBytes(lastRun.TotalSize), lastRun.FileCount)
+		}
+		if lastRun.Error != "" {
+			render.Printf("Error: %s\n", lastRun.Error)
+		}
+	}
+
+	entries, err := state.LoadCatalog()
+	if err != nil {
+		render.Printf("Warning: %v\n", err)
+		return
+	}
+	var latest *state.CatalogEntry
+	for i, entry := range entries {
+		if entry.Job == jobName && (latest == nil || entry.Timestamp.After(latest.Timestamp)) {
+			latest = &entries[i]
+		}
+	}
+	if latest != nil && (lastRun == nil || latest.BackupID != lastRun.BackupID) {
+		render.Printf("Latest backup: %s (%s, %s)\n", latest.BackupID, latest.Timestamp.Format("2006-01-02 15:04:05"), utils.FormatBytes(latest.TotalSize))
 	}
 }
 
@@ -338,7 +479,7 @@ func runJobsEnable(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -351,24 +492,24 @@ func runJobsEnable(cmd *cobra.Command, args []string) {
 	}
 
 	if job == nil {
-		fmt.Printf("Error: Job '%s' not found\n", jobName)
-		fmt.Println("Use 'backtide jobs list' to see available jobs.")
+		render.Printf("Error: Job '%s' not found\n", jobName)
+		render.Println("Use 'backtide jobs list' to see available jobs.")
 		os.Exit(1)
 	}
 
 	if job.Enabled {
-		fmt.Printf("Job '%s' is already enabled\n", jobName)
+		render.Printf("Job '%s' is already enabled\n", jobName)
 		return
 	}
 
 	job.Enabled = true
 
-	if err := config.SaveConfig(cfg, configPath); err != nil {
-		fmt.Printf("Error saving configuration: %v\n", err)
+	if err := saveConfigWithAudit(cfg, configPath, "jobs.enable", jobName); err != nil {
+		render.Printf("Error saving configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Job '%s' enabled successfully\n", jobName)
+	render.Printf("✅ Job '%s' enabled successfully\n", jobName)
 }
 
 func runJobsDisable(cmd *cobra.Command, args []string) {
@@ -376,7 +517,7 @@ func runJobsDisable(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -389,435 +530,58 @@ func runJobsDisable(cmd *cobra.Command, args []string) {
 	}
 
 	if job == nil {
-		fmt.Printf("Error: Job '%s' not found\n", jobName)
-		fmt.Println("Use 'backtide jobs list' to see available jobs.")
+		render.Printf("Error: Job '%s' not found\n", jobName)
+		render.Println("Use 'backtide jobs list' to see available jobs.")
 		os.Exit(1)
 	}
 
 	if !job.Enabled {
-		fmt.Printf("Job '%s' is already disabled\n", jobName)
+		render.Printf("Job '%s' is already disabled\n", jobName)
 		return
 	}
 
 	job.Enabled = false
 
-	if err := config.SaveConfig(cfg, configPath); err != nil {
-		fmt.Printf("Error saving configuration: %v\n", err)
+	if err := saveConfigWithAudit(cfg, configPath, "jobs.disable", jobName); err != nil {
+		render.Printf("Error saving configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Job '%s' disabled successfully\n", jobName)
+	render.Printf("✅ Job '%s' disabled successfully\n", jobName)
 }
 
 func runJobsAdd(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("=== Add Backup Job ===")
-	fmt.Println("Let's create a new backup job with scheduling and retention.")
-	fmt.Println()
+	render.Println("=== Add Backup Job ===")
+	render.Println("Let's create a new backup job with scheduling and retention.")
+	render.Println()
 
 	// Create a complete backup job
-	job := configureBackupJobInteractive(configPath, cfg)
+	job, err := wizard.Job(newPrompter(), cfg)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
 	cfg.Jobs = append(cfg.Jobs, job)
 
 	// Save configuration with new job
-	fmt.Printf("💾 Saving configuration with new job to: %s\n", configPath)
-	if err := config.SaveConfig(cfg, configPath); err != nil {
-		fmt.Printf("❌ Error saving configuration: %v\n", err)
-		fmt.Println("💡 You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide jobs add")
+	render.Printf("💾 Saving configuration with new job to: %s\n", configPath)
+	if err := saveConfigWithAudit(cfg, configPath, "jobs.add", job.Name); err != nil {
+		render.Printf("❌ Error saving configuration: %v\n", err)
+		render.Println("💡 You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo backtide jobs add")
 		os.Exit(1)
 	}
 
-	fmt.Printf("\n🎉 Backup job '%s' added successfully!\n", job.Name)
-	fmt.Println("\nNext steps:")
-	fmt.Println("1. Test the backup: backtide backup --dry-run")
-	fmt.Println("2. Set up automated backups: backtide systemd install")
-	fmt.Println("3. Run the backup: backtide backup")
-}
-
-func configureBackupJobInteractive(configPath string, currentConfig *config.BackupConfig) config.BackupJob {
-	reader := bufio.NewReader(os.Stdin)
-	job := config.BackupJob{
-		ID:         generateJobID(),
-		Enabled:    true,
-		SkipDocker: false,
-		SkipS3:     false,
-		Storage: config.StorageConfig{
-			Local: false,
-			S3:    false,
-		},
-	}
-
-	// Job name and description
-	fmt.Print("Backup job name (e.g., 'daily-docker-backup'): ")
-	name, _ := reader.ReadString('\n')
-	job.Name = strings.TrimSpace(name)
-	if job.Name == "" {
-		job.Name = "default-backup"
-	}
-	fmt.Printf("Job ID: %s\n", job.ID)
-
-	fmt.Print("Job description: ")
-	desc, _ := reader.ReadString('\n')
-	job.Description = strings.TrimSpace(desc)
-
-	// Schedule configuration
-	fmt.Println("\n=== Backup Schedule ===")
-	fmt.Println("When should this backup run automatically?")
-	fmt.Println("1. Daily (at 2 AM)")
-	fmt.Println("2. Weekly (Sunday at 2 AM)")
-	fmt.Println("3. Monthly (1st at 2 AM)")
-	fmt.Println("4. Custom cron schedule")
-	fmt.Println("5. Manual only (no automatic scheduling)")
-	fmt.Print("Choose schedule (1-5): ")
-
-	scheduleChoice, _ := reader.ReadString('\n')
-	scheduleChoice = strings.TrimSpace(scheduleChoice)
-
-	switch scheduleChoice {
-	case "1":
-		job.Schedule = config.ScheduleConfig{
-			Type:     "systemd",
-			Interval: "daily",
-			Enabled:  true,
-		}
-		fmt.Println("✅ Set to run daily at 2 AM")
-	case "2":
-		job.Schedule = config.ScheduleConfig{
-			Type:     "systemd",
-			Interval: "weekly",
-			Enabled:  true,
-		}
-		fmt.Println("✅ Set to run weekly on Sunday at 2 AM")
-	case "3":
-		job.Schedule = config.ScheduleConfig{
-			Type:     "systemd",
-			Interval: "monthly",
-			Enabled:  true,
-		}
-		fmt.Println("✅ Set to run monthly on the 1st at 2 AM")
-	case "4":
-		fmt.Print("Enter cron expression (e.g., '0 2 * * *' for daily at 2 AM): ")
-		cronExpr, _ := reader.ReadString('\n')
-		cronExpr = strings.TrimSpace(cronExpr)
-		if cronExpr != "" {
-			job.Schedule = config.ScheduleConfig{
-				Type:     "cron",
-				Interval: cronExpr,
-				Enabled:  true,
-			}
-			fmt.Printf("✅ Set to run with cron: %s\n", cronExpr)
-		} else {
-			job.Schedule.Enabled = false
-			fmt.Println("❌ No schedule set (manual only)")
-		}
-	case "5":
-		job.Schedule.Enabled = false
-		fmt.Println("✅ Set to manual mode (no automatic scheduling)")
-	default:
-		job.Schedule.Enabled = false
-		fmt.Println("❌ Invalid choice, set to manual mode")
-	}
-
-	// Retention policy
-	fmt.Println("\n=== Retention Policy ===")
-	fmt.Println("How long should we keep backups?")
-	fmt.Print("Keep backups for how many days? [30]: ")
-	daysInput, _ := reader.ReadString('\n')
-	daysInput = strings.TrimSpace(daysInput)
-	keepDays := 30
-	if daysInput != "" {
-		if days, err := strconv.Atoi(daysInput); err == nil && days > 0 {
-			keepDays = days
-		}
-	}
-
-	fmt.Print("Keep how many recent backups? [10]: ")
-	countInput, _ := reader.ReadString('\n')
-	countInput = strings.TrimSpace(countInput)
-	keepCount := 10
-	if countInput != "" {
-		if count, err := strconv.Atoi(countInput); err == nil && count > 0 {
-			keepCount = count
-		}
-	}
-
-	fmt.Print("Keep how many monthly backups? [6]: ")
-	monthlyInput, _ := reader.ReadString('\n')
-	monthlyInput = strings.TrimSpace(monthlyInput)
-	keepMonthly := 6
-	if monthlyInput != "" {
-		if monthly, err := strconv.Atoi(monthlyInput); err == nil && monthly > 0 {
-			keepMonthly = monthly
-		}
-	}
-
-	job.Retention = config.RetentionPolicy{
-		KeepDays:    keepDays,
-		KeepCount:   keepCount,
-		KeepMonthly: keepMonthly,
-	}
-	fmt.Printf("✅ Retention: %d days, %d recent, %d monthly\n", keepDays, keepCount, keepMonthly)
-
-	// Storage location configuration
-	fmt.Println("\n=== Storage Location Configuration ===")
-	fmt.Println("Where should backups be stored?")
-	fmt.Println("1. S3 only (recommended - prevents local disk exhaustion)")
-	fmt.Println("2. Local only (no S3)")
-	fmt.Println("3. Both S3 and local (redundant storage)")
-	fmt.Print("Choose storage location (1-3): ")
-
-	storageChoice, _ := reader.ReadString('\n')
-	storageChoice = strings.TrimSpace(storageChoice)
-
-	switch storageChoice {
-	case "1":
-		job.Storage.S3 = true
-		job.Storage.Local = false
-		fmt.Println("✅ Backups will be stored in S3 only")
-		if len(currentConfig.Buckets) > 0 {
-			bucketID := configureBucketForJob(configPath, currentConfig)
-			job.BucketID = bucketID
-		} else {
-			fmt.Println("⚠️  No S3 buckets configured. You can add one later with 'backtide s3 add'")
-		}
-	case "2":
-		job.Storage.S3 = false
-		job.Storage.Local = true
-		job.SkipS3 = true
-		fmt.Println("✅ Backups will be stored locally only")
-	case "3":
-		job.Storage.S3 = true
-		job.Storage.Local = true
-		fmt.Println("✅ Backups will be stored in both S3 and locally")
-		if len(currentConfig.Buckets) > 0 {
-			bucketID := configureBucketForJob(configPath, currentConfig)
-			job.BucketID = bucketID
-		} else {
-			fmt.Println("⚠️  No S3 buckets configured. You can add one later with 'backtide s3 add'")
-		}
-	default:
-		// Default to S3 only for safety
-		job.Storage.S3 = true
-		job.Storage.Local = false
-		fmt.Println("❌ Invalid choice, defaulting to S3 only")
-		if len(currentConfig.Buckets) > 0 {
-			bucketID := configureBucketForJob(configPath, currentConfig)
-			job.BucketID = bucketID
-		} else {
-			fmt.Println("⚠️  No S3 buckets configured. You can add one later with 'backtide s3 add'")
-		}
-	}
-
-	// Docker configuration
-	fmt.Println("\n=== Docker Configuration ===")
-	fmt.Print("Stop Docker containers during backup? (Y/n): ")
-	stopDocker, _ := reader.ReadString('\n')
-	stopDocker = strings.TrimSpace(stopDocker)
-
-	if stopDocker == "" || strings.ToLower(stopDocker) == "y" {
-		job.SkipDocker = false
-		fmt.Println("✅ Docker containers will be stopped during backup")
-	} else {
-		job.SkipDocker = true
-		fmt.Println("✅ Docker containers will NOT be stopped")
-	}
-
-	// Directory configuration
-	fmt.Println("\n=== Directory Configuration ===")
-	job.Directories = configureDirectoriesInteractive()
-
-	return job
-}
-
-func configureDirectoriesInteractive() []config.DirectoryConfig {
-	reader := bufio.NewReader(os.Stdin)
-	var directories []config.DirectoryConfig
-
-	fmt.Println("Configure directories to backup:")
-	fmt.Println("Enter directory paths one by one (empty line to finish)")
-
-	for {
-		fmt.Print("Directory path (e.g., /var/lib/docker/volumes): ")
-		path, _ := reader.ReadString('\n')
-		path = strings.TrimSpace(path)
-
-		if path == "" {
-			break
-		}
-
-		// Check if directory exists
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			fmt.Printf("⚠️  Warning: Directory does not exist: %s\n", path)
-			fmt.Print("Continue anyway? (y/N): ")
-			confirm, _ := reader.ReadString('\n')
-			confirm = strings.TrimSpace(strings.ToLower(confirm))
-			if confirm != "y" && confirm != "yes" {
-				continue
-			}
-		}
-
-		fmt.Print("Backup name (e.g., 'docker-volumes'): ")
-		name, _ := reader.ReadString('\n')
-		name = strings.TrimSpace(name)
-		if name == "" {
-			name = filepath.Base(path)
-		}
-
-		fmt.Print("Enable compression? (Y/n): ")
-		compression, _ := reader.ReadString('\n')
-		compression = strings.TrimSpace(compression)
-		enableCompression := true
-		if compression == "n" || compression == "no" {
-			enableCompression = false
-		}
-
-		directory := config.DirectoryConfig{
-			Path:        path,
-			Name:        name,
-			Compression: enableCompression,
-		}
-
-		directories = append(directories, directory)
-		fmt.Printf("✅ Added: %s -> %s (compression: %v)\n", path, name, enableCompression)
-	}
-
-	if len(directories) == 0 {
-		fmt.Println("⚠️  No directories configured. You can add them later in the configuration file.")
-	}
-
-	return directories
-}
-
-func generateJobID() string {
-	return fmt.Sprintf("job-%s", time.Now().Format("20060102-150405"))
-}
-
-func configureBucketForJob(configPath string, currentConfig *config.BackupConfig) string {
-	reader := bufio.NewReader(os.Stdin)
-
-	// Check for existing buckets
-	existingBuckets := currentConfig.Buckets
-	if len(existingBuckets) > 0 {
-		fmt.Println("\n=== Existing Bucket Configurations ===")
-		fmt.Println("Choose from existing buckets or create new:")
-		fmt.Println("0. Create new bucket configuration")
-
-		for i, bucket := range existingBuckets {
-			fmt.Printf("%d. %s - %s (%s)\n",
-				i+1, bucket.Name, bucket.Bucket, bucket.Provider)
-		}
-
-		fmt.Print("Choose bucket (0-", len(existingBuckets), "): ")
-		choice, _ := reader.ReadString('\n')
-		choice = strings.TrimSpace(choice)
-
-		if choiceIndex, err := strconv.Atoi(choice); err == nil && choiceIndex > 0 && choiceIndex <= len(existingBuckets) {
-			// User selected existing bucket
-			selectedBucket := existingBuckets[choiceIndex-1]
-			fmt.Printf("✅ Using existing bucket: %s (%s)\n", selectedBucket.Name, selectedBucket.Bucket)
-
-			return selectedBucket.ID
-		}
-		// User chose to create new bucket (choice 0 or invalid)
-		fmt.Println("Creating new bucket configuration...")
-	}
-
-	// No existing buckets or user wants to create new one
-	fmt.Println("No existing buckets found or creating new bucket...")
-
-	// Configure new bucket (basic setup without credentials)
-	newBucket := configureBasicBucketForInit()
-	currentConfig.Buckets = append(currentConfig.Buckets, newBucket)
-
-	fmt.Printf("✅ New bucket configuration '%s' added!\n", newBucket.Name)
-	fmt.Println("💡 Note: You'll need to update the bucket credentials later using 'backtide s3 edit'")
-
-	return newBucket.ID
-}
-
-func configureBasicBucketForInit() config.BucketConfig {
-	reader := bufio.NewReader(os.Stdin)
-	bucket := config.BucketConfig{}
-
-	// Generate a unique ID
-	bucket.ID = generateBucketID()
-
-	fmt.Print("Bucket name (display name): ")
-	name, _ := reader.ReadString('\n')
-	bucket.Name = strings.TrimSpace(name)
-	if bucket.Name == "" {
-		bucket.Name = "default-bucket"
-	}
-
-	fmt.Print("Description (optional): ")
-	desc, _ := reader.ReadString('\n')
-	bucket.Description = strings.TrimSpace(desc)
-
-	// Provider name
-	fmt.Print("Provider name (e.g., AWS S3, Backblaze B2, MinIO): ")
-	provider, _ := reader.ReadString('\n')
-	bucket.Provider = strings.TrimSpace(provider)
-
-	// Bucket name
-	fmt.Print("S3 Bucket name: ")
-	s3Bucket, _ := reader.ReadString('\n')
-	bucket.Bucket = strings.TrimSpace(s3Bucket)
-	if bucket.Bucket == "" {
-		bucket.Bucket = "my-backup-bucket"
-	}
-
-	// Region
-	fmt.Print("Region (leave empty if not applicable): ")
-	region, _ := reader.ReadString('\n')
-	bucket.Region = strings.TrimSpace(region)
-
-	// Path style - set smart defaults based on provider
-	providerLower := strings.ToLower(bucket.Provider)
-	defaultPathStyle := strings.Contains(providerLower, "backblaze") ||
-		strings.Contains(providerLower, "b2") ||
-		strings.Contains(providerLower, "minio")
-
-	if defaultPathStyle {
-		fmt.Print("Use path-style endpoints? (recommended: Y) (Y/n): ")
-	} else {
-		fmt.Print("Use path-style endpoints? (recommended: n) (y/N): ")
-	}
-	pathStyleInput, _ := reader.ReadString('\n')
-
-	// Endpoint
-	fmt.Print("Endpoint URL (leave empty for AWS default): ")
-	endpointInput, _ := reader.ReadString('\n')
-	bucket.Endpoint = strings.TrimSpace(endpointInput)
-
-	// Set path style with smart default
-	pathStyleInput = strings.TrimSpace(strings.ToLower(pathStyleInput))
-	if pathStyleInput == "y" || pathStyleInput == "yes" {
-		bucket.UsePathStyle = true
-	} else if pathStyleInput == "n" || pathStyleInput == "no" {
-		bucket.UsePathStyle = false
-	} else {
-		// Use default based on provider
-		bucket.UsePathStyle = defaultPathStyle
-	}
-
-	// Mount point
-	fmt.Print("Mount point (e.g., /mnt/s3backup): ")
-	mountPoint, _ := reader.ReadString('\n')
-	bucket.MountPoint = strings.TrimSpace(mountPoint)
-
-	// Skip credentials for now - they can be added later
-	bucket.AccessKey = "YOUR_ACCESS_KEY_HERE"
-	bucket.SecretKey = "YOUR_SECRET_KEY_HERE"
-
-	fmt.Printf("✅ S3 bucket configuration for %s completed!\n", bucket.Provider)
-	fmt.Println("💡 Note: You'll need to update the bucket credentials later using 'backtide s3 edit'")
-
-	return bucket
+	render.Printf("\n🎉 Backup job '%s' added successfully!\n", job.Name)
+	render.Println("\nNext steps:")
+	render.Println("1. Test the backup: backtide backup --dry-run")
+	render.Println("2. Set up automated backups: backtide systemd install")
+	render.Println("3. Run the backup: backtide backup")
 }
@@ -3,11 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/mitexleo/backtide/internal/backup"
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/redact"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +20,19 @@ var (
 	listBuckets bool
 	listBackups bool
 	listAll     bool
+	listHost    string
+	listLabels  []string
+	listJob     string
+	listSince   string
+	listUntil   string
+	listMinSize string
+	listSort    string
+	listDetail  bool
+	listLimit   int
+
+	// showSecrets displays credentials unmasked; shared by every command
+	// printing bucket or job details
+	showSecrets bool
 )
 
 // listCmd represents the list command
@@ -33,6 +50,10 @@ Examples:
   backtide list --jobs
   backtide list --buckets
   backtide list --backups
+  backtide list --backups --host web-01
+  backtide list --backups --label release=2.3
+  backtide list --backups --job app --since 7d --sort size --limit 5
+  backtide list --backups --since 2024-11-01 --until 2024-12-01 --min-size 1G
   backtide list --all`,
 	Run: runList,
 }
@@ -42,12 +63,26 @@ func init() {
 	listCmd.Flags().BoolVar(&listBuckets, "buckets", false, "list S3 bucket configurations")
 	listCmd.Flags().BoolVar(&listBackups, "backups", false, "list available backups")
 	listCmd.Flags().BoolVar(&listAll, "all", false, "list all information")
+	listCmd.Flags().StringVar(&listHost, "host", "", "only list backups written by this host (hostname or machine ID)")
+	listCmd.Flags().StringArrayVar(&listLabels, "label", nil, "only list backups with this key=value label (repeatable)")
+	listCmd.Flags().StringVar(&listJob, "job", "", "only list backups of this job")
+	listCmd.Flags().StringVar(&listSince, "since", "", "only list backups taken since a date (YYYY-MM-DD[ HH:MM]) or within a period (e.g., 7d)")
+	listCmd.Flags().StringVar(&listUntil, "until", "", "only list backups taken before a date (YYYY-MM-DD[ HH:MM]) or period ago (e.g., 30d)")
+	listCmd.Flags().StringVar(&listMinSize, "min-size", "", "only list backups of at least this size (e.g., 500MB, 2G)")
+	listCmd.Flags().StringVar(&listSort, "sort", "date", "sort backups by date (newest first) or size (largest first)")
+	listCmd.Flags().BoolVar(&listDetail, "detail", false, "with --backups, show what each backup contains: top-level sizes, file types and largest files")
+	listCmd.Flags().IntVar(&listLimit, "limit", 0, "only show this many backups (0 = all)")
+	listCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "show credentials unmasked (root only)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(listCmd)
 
 	// Register with command registry
 	commands.RegisterCommand("list", listCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) {
+	checkShowSecrets()
 	configPath := getConfigPath()
 	var cfg *config.BackupConfig
 	var err error
@@ -55,7 +90,7 @@ func runList(cmd *cobra.Command, args []string) {
 	if configPath != "" {
 		cfg, err = config.LoadConfig(configPath)
 		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
+			render.Printf("Error loading configuration: %v\n", err)
 			os.Exit(1)
 		}
 	} else {
@@ -82,56 +117,62 @@ func runList(cmd *cobra.Command, args []string) {
 }
 
 func listBackupJobs(cfg *config.BackupConfig) {
-	fmt.Println("=== Backup Jobs ===")
+	render.Println("=== Backup Jobs ===")
 
 	if len(cfg.Jobs) == 0 {
-		fmt.Println("No backup jobs configured.")
-		fmt.Println("Use 'backtide jobs add' to create backup jobs.")
+		render.Println("No backup jobs configured.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
 		return
 	}
 
 	for i, job := range cfg.Jobs {
-		fmt.Printf("\n%d. %s\n", i+1, job.Name)
+		render.Printf("\n%d. %s\n", i+1, job.Name)
 
 		status := "❌ disabled"
 		if job.Enabled {
 			status = "✅ enabled"
 		}
-		fmt.Printf("   Status: %s\n", status)
+		render.Printf("   Status: %s\n", status)
 
 		if job.Description != "" {
-			fmt.Printf("   Description: %s\n", job.Description)
+			render.Printf("   Description: %s\n", job.Description)
 		}
 
-		fmt.Printf("   ID: %s\n", job.ID)
+		render.Printf("   ID: %s\n", job.ID)
 
 		// Schedule information
 		if job.Schedule.Enabled {
-			fmt.Printf("   Schedule: %s (%s)\n", job.Schedule.Type, job.Schedule.Interval)
+			render.Printf("   Schedule: %s (%s)\n", job.Schedule.Type, job.Schedule.Interval)
+			if job.Schedule.Timezone != "" {
+				render.Printf("   Timezone: %s\n", job.Schedule.Timezone)
+			}
 		} else {
-			fmt.Printf("   Schedule: manual only\n")
+			render.Printf("   Schedule: manual only\n")
 		}
 
 		// Directories
-		fmt.Printf("   Directories: %d\n", len(job.Directories))
+		render.Printf("   Directories: %d\n", len(job.Directories))
 		for _, dir := range job.Directories {
-			compression := ""
+			notes := ""
 			if dir.Compression {
-				compression = " (compressed)"
+				notes = " (compressed)"
+			}
+			if dir.Required {
+				notes += " (required)"
 			}
-			fmt.Printf("     - %s -> %s%s\n", dir.Path, dir.Name, compression)
+			render.Printf("     - %s -> %s%s\n", dir.Path, dir.Name, notes)
 		}
 
 		// Storage configuration
-		fmt.Printf("   Storage: ")
+		render.Printf("   Storage: ")
 		if job.Storage.Local && job.Storage.S3 {
-			fmt.Printf("Local + S3\n")
+			render.Printf("Local + S3\n")
 		} else if job.Storage.Local {
-			fmt.Printf("Local only\n")
+			render.Printf("Local only\n")
 		} else if job.Storage.S3 {
-			fmt.Printf("S3 only\n")
+			render.Printf("S3 only\n")
 		} else {
-			fmt.Printf("None configured\n")
+			render.Printf("None configured\n")
 		}
 
 		// Bucket reference
@@ -143,35 +184,40 @@ func listBackupJobs(cfg *config.BackupConfig) {
 					break
 				}
 			}
-			fmt.Printf("   S3 Bucket: %s (%s)\n", bucketName, job.BucketID)
+			render.Printf("   S3 Bucket: %s (%s)\n", bucketName, job.BucketID)
 		}
 
 		// Retention policy
-		fmt.Printf("   Retention: %d days, %d recent, %d monthly\n",
+		render.Printf("   Retention: %d days, %d recent, %d monthly\n",
 			job.Retention.KeepDays, job.Retention.KeepCount, job.Retention.KeepMonthly)
 
 		// Docker configuration
-		if job.SkipDocker {
-			fmt.Printf("   Docker: containers will NOT be stopped\n")
+		if len(job.Docker.ExecBefore) > 0 {
+			render.Printf("   Docker: containers are quiesced with exec hooks and keep running\n")
+		} else if job.SkipDocker {
+			render.Printf("   Docker: containers will NOT be stopped\n")
 		} else {
-			fmt.Printf("   Docker: containers will be stopped during backup\n")
+			render.Printf("   Docker: containers will be stopped during backup\n")
+		}
+		if job.Docker.Discover {
+			render.Printf("   Docker: backs up labeled containers and volumes\n")
 		}
 
 		// S3 configuration
 		if job.SkipS3 {
-			fmt.Printf("   S3: operations will be skipped\n")
+			render.Printf("   S3: operations will be skipped\n")
 		}
 	}
 
-	fmt.Printf("\n📊 Total jobs: %d\n", len(cfg.Jobs))
+	render.Printf("\n📊 Total jobs: %d\n", len(cfg.Jobs))
 }
 
 func listS3Buckets(cfg *config.BackupConfig) {
-	fmt.Println("\n=== S3 Bucket Configurations ===")
+	render.Println("\n=== S3 Bucket Configurations ===")
 
 	if len(cfg.Buckets) == 0 {
-		fmt.Println("No bucket configurations found.")
-		fmt.Println("Use 'backtide s3 add' to add a bucket configuration.")
+		render.Println("No bucket configurations found.")
+		render.Println("Use 'backtide s3 add' to add a bucket configuration.")
 		return
 	}
 
@@ -184,93 +230,307 @@ func listS3Buckets(cfg *config.BackupConfig) {
 	}
 
 	for _, bucket := range cfg.Buckets {
-		fmt.Printf("\n📦 %s\n", bucket.Name)
+		render.Printf("\n📦 %s\n", bucket.Name)
 		if bucket.Description != "" {
-			fmt.Printf("   Description: %s\n", bucket.Description)
+			render.Printf("   Description: %s\n", bucket.Description)
 		}
-		fmt.Printf("   ID: %s\n", bucket.ID)
-		fmt.Printf("   Provider: %s\n", bucket.Provider)
-		fmt.Printf("   Bucket: %s\n", bucket.Bucket)
-		fmt.Printf("   Region: %s\n", bucket.Region)
-		fmt.Printf("   Endpoint: %s\n", func() string {
+		render.Printf("   ID: %s\n", bucket.ID)
+		render.Printf("   Provider: %s\n", bucket.Provider)
+		render.Printf("   Bucket: %s\n", bucket.Bucket)
+		render.Printf("   Region: %s\n", bucket.Region)
+		render.Printf("   Endpoint: %s\n", func() string {
 			if bucket.Endpoint == "" {
 				return "AWS default"
 			}
-			return bucket.Endpoint
+			return secretURL(bucket.Endpoint)
 		}())
-		fmt.Printf("   Mount Point: %s\n", bucket.MountPoint)
-		fmt.Printf("   Path Style: %v\n", bucket.UsePathStyle)
-		fmt.Printf("   Access Key: %s\n", maskString(bucket.AccessKey))
-		fmt.Printf("   Secret Key: %s\n", maskString(bucket.SecretKey))
-		fmt.Printf("   Used by: %d job(s)\n", usageCount[bucket.ID])
+		render.Printf("   Mount Point: %s\n", bucket.MountPoint)
+		render.Printf("   Mount: %s\n", mountMode(bucket))
+		render.Printf("   Path Style: %v\n", bucket.UsePathStyle)
+		render.Printf("   Access Key: %s\n", secretValue(bucket.AccessKey))
+		render.Printf("   Secret Key: %s\n", secretValue(bucket.SecretKey))
+		render.Printf("   Used by: %d job(s)\n", usageCount[bucket.ID])
 	}
 
-	fmt.Printf("\n📊 Total buckets: %d\n", len(cfg.Buckets))
+	render.Printf("\n📊 Total buckets: %d\n", len(cfg.Buckets))
 }
 
 func listAvailableBackups(cfg *config.BackupConfig) {
-	fmt.Println("\n=== Available Backups ===")
+	filter, err := parseBackupFilter()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Println("\n=== Available Backups ===")
 
 	backupRunner := backup.NewBackupRunner(*cfg)
 	var backups []config.BackupMetadata
-	var err error
 
 	// Try config-based discovery first
 	backups, err = backupRunner.ListBackups()
 	if err != nil {
-		fmt.Printf("Warning: Failed to list backups from config: %v\n", err)
+		render.Printf("Warning: Failed to list backups from config: %v\n", err)
 	}
 
 	// If no backups found via config, try automatic discovery
 	if len(backups) == 0 {
-		fmt.Println("No backups found via configuration. Trying automatic discovery...")
+		render.Println("No backups found via configuration. Trying automatic discovery...")
 		backups, err = backupRunner.DiscoverBackups()
 		if err != nil {
-			fmt.Printf("Error discovering backups: %v\n", err)
+			render.Printf("Error discovering backups: %v\n", err)
+			return
+		}
+	}
+
+	// Buckets shared by several hosts hold backups of all of them
+	if listHost != "" {
+		var filtered []config.BackupMetadata
+		for _, metadata := range backups {
+			if backup.MatchesHost(metadata, listHost) {
+				filtered = append(filtered, metadata)
+			}
+		}
+		if len(filtered) == 0 && len(backups) > 0 {
+			render.Printf("No backups from host %s.\n", listHost)
+			return
+		}
+		backups = filtered
+	}
+
+	if len(listLabels) > 0 {
+		selector, err := backup.ParseLabels(listLabels)
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		var filtered []config.BackupMetadata
+		for _, metadata := range backups {
+			if backup.MatchesLabels(metadata.Labels, selector) {
+				filtered = append(filtered, metadata)
+			}
+		}
+		if len(filtered) == 0 && len(backups) > 0 {
+			render.Printf("No backups with labels %s.\n", backup.FormatLabels(selector))
 			return
 		}
+		backups = filtered
+	}
+
+	if filter.active() {
+		var filtered []config.BackupMetadata
+		for _, metadata := range backups {
+			if filter.matches(metadata) {
+				filtered = append(filtered, metadata)
+			}
+		}
+		if len(filtered) == 0 && len(backups) > 0 {
+			render.Println("No backups match the filters.")
+			return
+		}
+		backups = filtered
 	}
 
 	if len(backups) == 0 {
-		fmt.Println("No backups found in any known locations.")
-		fmt.Println("Use 'backtide restore --path /path/to/backup' for path-based restoration.")
+		render.Println("No backups found in any known locations.")
+		render.Println("Use 'backtide restore --path /path/to/backup' for path-based restoration.")
 		return
 	}
 
-	// Sort backups by timestamp (newest first)
-	for i := range backups {
-		for j := i + 1; j < len(backups); j++ {
-			if backups[i].Timestamp.Before(backups[j].Timestamp) {
-				backups[i], backups[j] = backups[j], backups[i]
-			}
+	// Newest first, or largest first with --sort size
+	sort.SliceStable(backups, func(i, j int) bool {
+		if listSort == "size" && backups[i].TotalSize != backups[j].TotalSize {
+			return backups[i].TotalSize > backups[j].TotalSize
 		}
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+
+	total := len(backups)
+	if listLimit > 0 && listLimit < total {
+		backups = backups[:listLimit]
 	}
 
+	formatLabels := backup.FormatLabels
 	for i, backup := range backups {
-		fmt.Printf("\n%d. %s\n", i+1, backup.ID)
-		fmt.Printf("   Timestamp: %s\n", backup.Timestamp.Format("2006-01-02 15:04:05"))
-		fmt.Printf("   Age: %s\n", time.Since(backup.Timestamp).Round(time.Hour))
-		fmt.Printf("   Total Size: %d bytes\n", backup.TotalSize)
-		fmt.Printf("   Compressed: %v\n", backup.Compressed)
-		fmt.Printf("   Checksum: %s\n", backup.Checksum)
+		render.Printf("\n%d. %s\n", i+1, backup.ID)
+		render.Printf("   Timestamp: %s\n", backup.Timestamp.Format("2006-01-02 15:04:05"))
+		render.Printf("   Age: %s\n", time.Since(backup.Timestamp).Round(time.Hour))
+		if backup.JobName != "" {
+			render.Printf("   Job: %s\n", backup.JobName)
+		}
+		if backup.Hostname != "" {
+			render.Printf("   Host: %s\n", backup.Hostname)
+		}
+		if backup.Duration != "" {
+			render.Printf("   Duration: %s\n", backup.Duration)
+		}
+		if backup.Pinned {
+			render.Println("   📌 Pinned (kept by retention cleanup)")
+		} else if !backup.ExpiresAt.IsZero() {
+			render.Printf("   Expires: %s (overrides retention)\n", backup.ExpiresAt.Format("2006-01-02 15:04"))
+		}
+		if backup.Comment != "" {
+			render.Printf("   Comment: %s\n", backup.Comment)
+		}
+		if len(backup.Labels) > 0 {
+			render.Printf("   Labels: %s\n", formatLabels(backup.Labels))
+		}
+		if backup.Undersized {
+			render.Println("   ⚠️  Undersized (smaller than the job's expected_min_size)")
+		}
+		for _, path := range backup.Missing {
+			render.Printf("   ⚠️  Missing: %s (did not exist, not in this backup)\n", path)
+		}
+		render.Printf("   Total Size: %s\n", utils.FormatBytes(backup.TotalSize))
+		render.Printf("   Compressed: %v\n", backup.Compressed)
+		render.Printf("   Checksum: %s\n", backup.Checksum)
 
 		if len(backup.Directories) > 0 {
-			fmt.Printf("   Directories: %d\n", len(backup.Directories))
+			render.Printf("   Directories: %d\n", len(backup.Directories))
 			for _, dir := range backup.Directories {
-				fmt.Printf("     - %s: %d files, %d bytes\n", dir.Name, dir.FileCount, dir.Size)
+				render.Printf("     - %s: %d files, %s\n", dir.Name, dir.FileCount, utils.FormatBytes(dir.Size))
 			}
 		}
+		if listDetail {
+			printBackupSummary(backup.Summary)
+		}
 	}
 
-	fmt.Printf("\n📊 Total backups: %d\n", len(backups))
+	if len(backups) < total {
+		render.Printf("\n📊 Showing %d of %d backups (--limit)\n", len(backups), total)
+	} else {
+		render.Printf("\n📊 Total backups: %d\n", total)
+	}
 }
 
-func maskString(s string) string {
-	if s == "" {
-		return "(not set)"
+// printBackupSummary prints the contents summary of a backup for 'list --backups --detail'
+func printBackupSummary(summary *config.BackupSummary) {
+	if summary == nil {
+		render.Println("   Contents: no summary (backup made by an older version)")
+		return
 	}
-	if len(s) <= 4 {
-		return "****"
+	sections := []struct {
+		title   string
+		entries []config.SummaryEntry
+		counts  bool
+	}{
+		{"Top-level contents", summary.TopLevel, true},
+		{"File types", summary.FileTypes, true},
+		{"Largest files", summary.Largest, false},
+	}
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		render.Printf("   %s:\n", section.title)
+		table := render.NewTable()
+		table.Indent = "     "
+		table.Right[0] = true
+		table.Right[1] = section.counts
+		for _, entry := range section.entries {
+			if section.counts {
+				table.Row(utils.FormatBytes(entry.Size), fmt.Sprintf("%d files", entry.Files), entry.Name)
+			} else {
+				table.Row(utils.FormatBytes(entry.Size), entry.Name)
+			}
+		}
+		table.Print()
+	}
+}
+
+// backupFilter selects backups by job, time and size for 'list --backups'
+type backupFilter struct {
+	job     string
+	since   time.Time
+	until   time.Time
+	minSize int64
+}
+
+// parseBackupFilter builds the filter from the list flags
+func parseBackupFilter() (backupFilter, error) {
+	filter := backupFilter{job: listJob}
+	var err error
+
+	if listSort != "date" && listSort != "size" {
+		return filter, fmt.Errorf("invalid --sort %q: use date or size", listSort)
+	}
+	if listLimit < 0 {
+		return filter, fmt.Errorf("--limit cannot be negative")
+	}
+	if listSince != "" {
+		if filter.since, err = parseListTime(listSince); err != nil {
+			return filter, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if listUntil != "" {
+		if filter.until, err = parseListTime(listUntil); err != nil {
+			return filter, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !filter.since.IsZero() && !filter.until.IsZero() && !filter.since.Before(filter.until) {
+		return filter, fmt.Errorf("--since must be before --until")
+	}
+	if listMinSize != "" {
+		if filter.minSize, err = utils.ParseSize(listMinSize); err != nil {
+			return filter, fmt.Errorf("invalid --min-size: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+// parseListTime parses a local date (YYYY-MM-DD or YYYY-MM-DD HH:MM) or a
+// period counted back from now, such as 7d
+func parseListTime(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	period, err := utils.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date (YYYY-MM-DD[ HH:MM]) nor a period (e.g., 7d)", value)
+	}
+	return time.Now().Add(-period), nil
+}
+
+func (f backupFilter) active() bool {
+	return f.job != "" || !f.since.IsZero() || !f.until.IsZero() || f.minSize > 0
+}
+
+func (f backupFilter) matches(metadata config.BackupMetadata) bool {
+	if f.job != "" && metadata.JobName != f.job {
+		return false
+	}
+	if !f.since.IsZero() && metadata.Timestamp.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !metadata.Timestamp.Before(f.until) {
+		return false
+	}
+	return metadata.TotalSize >= f.minSize
+}
+
+// secretValue masks a secret for display unless --show-secrets was given
+func secretValue(s string) string {
+	if showSecrets && s != "" {
+		return s
+	}
+	return redact.String(s)
+}
+
+// secretURL masks the password embedded in a URL unless --show-secrets was given
+func secretURL(s string) string {
+	if showSecrets {
+		return s
+	}
+	return redact.URL(s)
+}
+
+// checkShowSecrets refuses --show-secrets to anyone but root, as read-only
+// users may run the commands offering it
+func checkShowSecrets() {
+	if showSecrets && os.Geteuid() != 0 {
+		render.Println("❌ --show-secrets requires root privileges")
+		os.Exit(1)
 	}
-	return s[:4] + "****" + s[len(s)-4:]
 }
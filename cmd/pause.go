@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pauseAll    bool
+	pauseFor    string
+	pauseUntil  string
+	pauseReason string
+	resumeAll   bool
+)
+
+// pauseCmd represents the pause command
+var pauseCmd = &cobra.Command{
+	Use:   "pause [job-name]",
+	Short: "Temporarily suppress scheduled backups",
+	Long: `Temporarily suppress scheduled runs of a job (or all jobs) without
+changing the enabled flag in the configuration.
+
+Pauses are persisted and expire automatically, so maintenance windows don't
+require configuration edits that need to be reverted. Runs skipped during
+a pause are not caught up when it ends, whatever the misfire_policy. Manual
+runs with 'backtide backup' are not affected.
+
+Without arguments, active pauses are listed.
+
+Examples:
+  backtide pause daily-backup --for 4h
+  backtide pause --all --for 2h --reason "storage maintenance"
+  backtide pause daily-backup --until "2024-12-01 06:00"
+  backtide resume daily-backup`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPause,
+}
+
+// resumeCmd represents the resume command
+var resumeCmd = &cobra.Command{
+	Use:   "resume [job-name]",
+	Short: "Resume scheduled backups paused with 'backtide pause'",
+	Long: `Remove a pause before it expires so scheduled runs continue.
+
+Examples:
+  backtide resume daily-backup
+  backtide resume --all`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runResume,
+}
+
+func init() {
+	pauseCmd.Flags().BoolVarP(&pauseAll, "all", "a", false, "pause all jobs")
+	pauseCmd.Flags().StringVar(&pauseFor, "for", "", "pause duration (e.g., 30m, 4h, 2d)")
+	pauseCmd.Flags().StringVar(&pauseUntil, "until", "", "pause until a local time (YYYY-MM-DD HH:MM)")
+	pauseCmd.Flags().StringVar(&pauseReason, "reason", "", "reason for the pause, shown in status")
+	resumeCmd.Flags().BoolVarP(&resumeAll, "all", "a", false, "remove the pause affecting all jobs")
+
+	// Register with command registry
+	commands.RegisterCommand("pause", pauseCmd)
+	commands.RegisterCommand("resume", resumeCmd)
+}
+
+func runPause(cmd *cobra.Command, args []string) {
+	if len(args) == 0 && !pauseAll {
+		listPauses()
+		return
+	}
+
+	if len(args) > 0 && pauseAll {
+		render.Println("Error: Cannot specify both a job name and --all")
+		os.Exit(1)
+	}
+
+	target := state.AllJobs
+	if len(args) > 0 {
+		target = args[0]
+		cfg, err := config.LoadConfig(getConfigPath())
+		if err != nil {
+			render.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if findJobByName(cfg, target) == nil {
+			render.Printf("Error: Job '%s' not found\n", target)
+			render.Println("Use 'backtide jobs list' to see available jobs.")
+			os.Exit(1)
+		}
+	}
+
+	until, err := resolvePauseUntil()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if dryRun {
+		render.Printf("DRY RUN: Would pause %s until %s\n", describePauseTarget(target), until.Format("2006-01-02 15:04:05"))
+		return
+	}
+
+	if err := state.PauseJob(target, until, pauseReason); err != nil {
+		render.Printf("❌ Error pausing %s: %v\n", describePauseTarget(target), err)
+		os.Exit(1)
+	}
+
+	render.Printf("⏸️  Paused %s until %s (%s)\n", describePauseTarget(target),
+		until.Format("2006-01-02 15:04:05"), utils.FormatDuration(time.Until(until)))
+	render.Println("💡 Scheduled runs resume automatically when the pause expires")
+}
+
+func runResume(cmd *cobra.Command, args []string) {
+	if len(args) == 0 && !resumeAll {
+		render.Println("Error: Specify a job name or --all")
+		render.Println("Usage: backtide resume <job-name> OR backtide resume --all")
+		os.Exit(1)
+	}
+
+	target := state.AllJobs
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	found, err := state.ResumeJob(target)
+	if err != nil {
+		render.Printf("❌ Error resuming %s: %v\n", describePauseTarget(target), err)
+		os.Exit(1)
+	}
+	if !found {
+		render.Printf("%s is not paused\n", describePauseTarget(target))
+		return
+	}
+
+	render.Printf("▶️  Resumed scheduling for %s\n", describePauseTarget(target))
+	if target != state.AllJobs {
+		if p, paused := state.ActivePause(target); paused {
+			render.Printf("⚠️  All jobs are still paused until %s; use 'backtide resume --all'\n",
+				p.Until.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+// listPauses prints the active pauses
+func listPauses() {
+	pauses, err := state.ListPauses()
+	if err != nil {
+		render.Printf("Error reading pauses: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(pauses) == 0 {
+		render.Println("No jobs are paused.")
+		return
+	}
+
+	render.Println("=== Paused Jobs ===")
+	for _, p := range pauses {
+		render.Printf("\n⏸️  %s\n", describePauseTarget(p.Job))
+		render.Printf("   Until: %s (%s remaining)\n", p.Until.Format("2006-01-02 15:04:05"), utils.FormatDuration(time.Until(p.Until)))
+		if p.PausedBy != "" {
+			render.Printf("   Paused by: %s at %s\n", p.PausedBy, p.PausedAt.Format("2006-01-02 15:04:05"))
+		}
+		if p.Reason != "" {
+			render.Printf("   Reason: %s\n", p.Reason)
+		}
+	}
+}
+
+// resolvePauseUntil computes the pause expiry from --for or --until
+func resolvePauseUntil() (time.Time, error) {
+	if pauseFor != "" && pauseUntil != "" {
+		return time.Time{}, fmt.Errorf("cannot specify both --for and --until")
+	}
+
+	if pauseUntil != "" {
+		until, err := time.ParseInLocation("2006-01-02 15:04", pauseUntil, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --until time %q (expected YYYY-MM-DD HH:MM)", pauseUntil)
+		}
+		if !until.After(time.Now()) {
+			return time.Time{}, fmt.Errorf("--until must be in the future")
+		}
+		return until, nil
+	}
+
+	if pauseFor == "" {
+		return time.Time{}, fmt.Errorf("specify how long to pause with --for (e.g., --for 4h) or --until")
+	}
+
+	duration, err := utils.ParseDuration(pauseFor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if duration <= 0 {
+		return time.Time{}, fmt.Errorf("--for must be positive")
+	}
+	return time.Now().Add(duration), nil
+}
+
+// describePauseTarget returns a display name for a pause target
+func describePauseTarget(target string) string {
+	if target == state.AllJobs {
+		return "all jobs"
+	}
+	return fmt.Sprintf("job '%s'", target)
+}
+
+// findJobByName returns the job with the given name, or nil
+func findJobByName(cfg *config.BackupConfig, name string) *config.BackupJob {
+	for i, job := range cfg.Jobs {
+		if job.Name == name {
+			return &cfg.Jobs[i]
+		}
+	}
+	return nil
+}
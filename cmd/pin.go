@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var pinUnpin bool
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin [backup-id]",
+	Short: "Protect a backup from retention cleanup",
+	Long: `Pin a backup so retention cleanup never removes it, e.g. the last known good
+backup before a migration. The pin is stored in the backup's metadata, so it
+survives catalog rebuilds and applies on every host sharing the storage.
+Pinned backups do not count toward keep_count.
+
+Without arguments, pinned backups recorded in the catalog are listed.
+
+Examples:
+  backtide pin backup-20241201-143000-app-1a2b3c
+  backtide pin --unpin backup-20241201-143000-app-1a2b3c
+  backtide pin`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPin,
+}
+
+func init() {
+	pinCmd.Flags().BoolVar(&pinUnpin, "unpin", false, "remove the pin so retention applies again")
+
+	// Register with command registry
+	commands.RegisterCommand("pin", pinCmd)
+}
+
+func runPin(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		if pinUnpin {
+			render.Println("Error: --unpin needs a backup ID")
+			os.Exit(1)
+		}
+		listPinnedBackups()
+		return
+	}
+	backupID := args[0]
+
+	action, verb := "pin", "Pinned"
+	if pinUnpin {
+		action, verb = "unpin", "Unpinned"
+	}
+	if dryRun {
+		render.Printf("DRY RUN: Would %s backup %s\n", action, backupID)
+		return
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		// Backups recorded in the catalog can be found without a configuration
+		cfg = config.DefaultConfig()
+	}
+
+	metadata, err := backup.NewBackupRunner(*cfg).SetPinned(backupID, !pinUnpin)
+	audit.RecordResult(action, backupID, nil, err)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Printf("📌 %s backup %s (%s", verb, metadata.ID, metadata.Timestamp.Format("2006-01-02 15:04:05"))
+	if metadata.JobName != "" {
+		render.Printf(", job %s", metadata.JobName)
+	}
+	render.Println(")")
+	if !pinUnpin {
+		render.Println("💡 Retention cleanup keeps it until 'backtide pin --unpin' is run")
+	}
+}
+
+// listPinnedBackups prints the pinned backups recorded in the catalog
+func listPinnedBackups() {
+	entries, err := state.LoadCatalog()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if !entry.Pinned {
+			continue
+		}
+		if count == 0 {
+			render.Println("📌 Pinned backups:")
+		}
+		count++
+		render.Printf("  %s  %s  %s\n", entry.BackupID, entry.Timestamp.Format("2006-01-02 15:04"), entry.Job)
+	}
+	if count == 0 {
+		render.Println("No pinned backups in the catalog.")
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pluginsCheck bool
+)
+
+// pluginsCmd represents the plugins command
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List configured storage, notifier and hook plugins",
+	Long: `List the plugins declared in the configuration and the jobs using them.
+
+Plugins are external executables declared with [[plugins]] and enabled per
+job with 'plugins = ["name"]'. For each call Backtide starts the executable,
+writes one JSON request to its stdin and reads one JSON response from its
+stdout:
+
+  request:  {"protocol_version": 1, "plugin": "storj", "method": "store",
+             "store": {"job": "daily", "backup_id": "backup-...", "path": "/..."}}
+  response: {"ok": true} or {"ok": false, "error": "message"}
+
+Methods are "store" (storage), "notify" (notifier), "hook" (hook) and
+"ping". Notifiers and hooks receive an "event" with type backup.pre (hooks
+only; an error aborts the run), backup.succeeded or backup.failed.
+backup.failed events carry the last lines of the run's output in "log" and
+the error output of a failed docker or s3fs command in "error_output".
+Events to notifiers carry "title" and "message" text rendered from the
+notifier's title and message templates, or the defaults.
+
+Examples:
+  backtide plugins
+  backtide plugins --check`,
+	Run: runPlugins,
+}
+
+func init() {
+	pluginsCmd.Flags().BoolVar(&pluginsCheck, "check", false, "ping each plugin to verify it responds")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(pluginsCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("plugins", pluginsCmd)
+}
+
+func runPlugins(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(cfg.Plugins) == 0 {
+		render.Println("No plugins configured.")
+		render.Println("💡 Declare plugins with [[plugins]] in the configuration; see 'backtide plugins --help'")
+		return
+	}
+
+	render.Println("=== Plugins ===")
+	failed := 0
+	for _, p := range cfg.Plugins {
+		var jobs []string
+		for _, job := range cfg.Jobs {
+			for _, name := range job.Plugins {
+				if name == p.Name {
+					jobs = append(jobs, job.Name)
+				}
+			}
+		}
+
+		render.Printf("\n%s (%s)\n", p.Name, p.Type)
+		render.Printf("   Command: %s %s\n", p.Command, strings.Join(p.Args, " "))
+		if len(jobs) > 0 {
+			render.Printf("   Jobs: %s\n", strings.Join(jobs, ", "))
+		} else {
+			render.Println("   Jobs: none")
+		}
+
+		if pluginsCheck {
+			if err := backup.NewExecPlugin(p).Ping(context.Background()); err != nil {
+				render.Printf("   Status: ❌ %v\n", err)
+				failed++
+			} else {
+				render.Println("   Status: ✅ responding")
+			}
+		}
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/spf13/cobra"
+)
+
+// profilesCmd represents the profiles command
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List configuration profiles",
+	Long: `List the named configuration profiles available on this host.
+
+Profiles let one host run backups for several distinct environments, each
+with its own jobs, buckets and retention. A profile is a configuration file
+in /etc/backtide/profiles/<name>.toml (or ~/.config/backtide/profiles/) and
+is selected with --profile <name> or the BACKTIDE_PROFILE environment
+variable. Runs, pauses and the daemon socket are kept separate per profile.
+
+Examples:
+  backtide profiles
+  sudo backtide init --profile staging
+  backtide backup --profile staging --all
+  BACKTIDE_PROFILE=staging backtide daemon`,
+	Run: runProfiles,
+}
+
+func init() {
+	// Safe for read-only users
+	commands.MarkReadOnly(profilesCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("profiles", profilesCmd)
+}
+
+func runProfiles(cmd *cobra.Command, args []string) {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		render.Printf("Error listing profiles: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(profiles) == 0 {
+		render.Println("No profiles configured.")
+		render.Println("Create one with: sudo backtide init --profile <name>")
+		return
+	}
+
+	active := activeProfile()
+	render.Println("=== Configuration Profiles ===")
+	for _, p := range profiles {
+		marker := "  "
+		if p.Name == active {
+			marker = "➡️ "
+		}
+		render.Printf("%s %s", marker, p.Name)
+
+		cfg, err := config.LoadConfig(p.Path)
+		if err != nil {
+			render.Printf("  (⚠️  %v)\n", err)
+			continue
+		}
+		render.Printf("  %d jobs, %d buckets  %s\n", len(cfg.Jobs), len(cfg.Buckets), p.Path)
+	}
+}
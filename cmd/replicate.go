@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var replicateList bool
+
+// replicateCmd represents the replicate command
+var replicateCmd = &cobra.Command{
+	Use:   "replicate",
+	Short: "Copy queued backups to their secondary buckets now",
+	Long: `Jobs with [[jobs.replicate]] rules copy each backup to secondary buckets,
+e.g. in another region or with another provider, once it is on the job's
+bucket. The daemon copies queued backups in the background and retries failed
+copies every retry_interval in [outbox]; 'backtide backup' run without the
+daemon copies them once its jobs are done.
+
+Buckets on the same endpoint copy the files server-side without downloading
+them; otherwise the backup is copied from one bucket mount to the other. The
+replica has the same path on the secondary bucket, hosts/<host>/<backup-id>.
+
+Replicas are never pruned: the job's retention only applies to its own
+bucket. Expire old replicas with a lifecycle rule on the secondary bucket,
+or they accumulate there indefinitely.
+
+This command copies all queued backups now, including ones whose last attempt
+failed. 'backtide status' shows how far each job's replicas are behind.
+
+Example configuration:
+  [[jobs.replicate]]
+  bucket_id = "dr-eu"
+
+Examples:
+  backtide replicate
+  backtide replicate --list`,
+	Args: cobra.NoArgs,
+	Run:  runReplicate,
+}
+
+func init() {
+	replicateCmd.Flags().BoolVarP(&replicateList, "list", "l", false, "list the queued replications without copying them")
+
+	// Register with command registry
+	commands.RegisterCommand("replicate", replicateCmd)
+}
+
+func runReplicate(cmd *cobra.Command, args []string) {
+	if replicateList {
+		listReplications()
+		return
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	replicated, err := backup.NewBackupRunner(*cfg).Replicate(ctx, true)
+	if err != nil {
+		render.Printf("❌ Replicated %d backups; the rest stay queued: %v\n", replicated, err)
+		os.Exit(1)
+	}
+	render.Printf("✅ Replicated %d backups\n", replicated)
+}
+
+// listReplications prints the replication queue
+func listReplications() {
+	replications, err := state.LoadReplications()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(replications) == 0 {
+		render.Println("No backups are waiting to be replicated.")
+		return
+	}
+
+	now := time.Now()
+	table := render.NewTable("BACKUP", "JOB", "FROM", "TO", "QUEUED", "ATTEMPTS", "LAST ERROR")
+	table.Right[5] = true
+	for _, replication := range replications {
+		lastError := "-"
+		if replication.LastError != "" {
+			lastError = replication.LastError
+		}
+		table.Row(replication.BackupID, replication.Job, replication.SourceBucketID, replication.BucketID,
+			utils.FormatDuration(now.Sub(replication.QueuedAt))+" ago", strconv.Itoa(replication.Attempts), lastError)
+	}
+	table.Print()
+}
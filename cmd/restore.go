@@ -1,13 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/mitexleo/backtide/internal/audit"
 	"github.com/mitexleo/backtide/internal/backup"
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/docker"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +22,19 @@ var (
 	restoreForce      bool
 	restorePath       string
 	restoreTargetPath string
+	restoreOnly       []string
+	restoreUIDMap     []string
+	restoreGIDMap     []string
+	restoreNumeric    bool
+	restoreNoTimes    bool
+	restoreAtimes     bool
+	restoreHost       string
+	restoreLabels     []string
+	restoreAt         string
+	restoreContainer  string
+	restoreSandbox    bool
+	// restoreApprovalCode is the --approval token or TOTP code
+	restoreApprovalCode string
 )
 
 // restoreCmd represents the restore command
@@ -37,9 +56,51 @@ This command supports multiple restoration modes:
 3. S3-based restore (after mounting S3 bucket):
    backtide restore backup-20241201-143000  # automatically discovers from mounted S3
 
+4. Selective restore (only the named backup directories, others untouched):
+   backtide restore backup-20241201-143000 --only docker-volumes,app-data
+
+5. Cross-host restore (remap ownership to the new host's IDs):
+   backtide restore --path /mnt/backups/backup-20241201-143000 --uid-map 1000:1001 --gid-map 1000:1001
+
+6. Restore a backup written by another host sharing the bucket:
+   backtide restore backup-20241201-143000 --host web-01
+
+7. Restore the newest backup of a job carrying labels given at backup time:
+   backtide restore --job daily-backup --label release=2.3
+
+8. Point-in-time restore (the newest backup taken at or before a local time):
+   backtide restore --job daily-backup --at "2024-12-01 14:00"
+
+9. Restore drill in a throwaway container, leaving the live filesystem untouched:
+   backtide restore backup-20241201-143000 --to-container alpine
+   The backup is restored into a scratch directory mounted at /restore, the
+   container runs attached to the terminal, and both are removed when it exits.
+
+10. Sandboxed restore (Linux), extracting in a process that may only write the target:
+   backtide restore backup-20241201-143000 --target /srv/restore --sandbox
+
+11. Restore of a job with restore_approval, approved by another operator:
+   backtide approve backup-20241201-143000 --job db     (run by the approver)
+   backtide restore backup-20241201-143000 --job db --approval <token>
+
+When run as root, restored files are owned by the user and group names recorded
+in the backup as they exist on this host, falling back to the recorded numeric
+IDs. --uid-map and --gid-map override specific IDs; --numeric-owner disables
+name resolution.
+
+Restored files and directories get their recorded modification times back;
+--atime restores the recorded access times too, and --no-times leaves the
+current time, e.g. to make sync tools pick up every restored file.
+
+With --dry-run nothing is written; instead every file that would be
+overwritten or have its owner changed is listed per directory, with counts of
+the files that would be created (listed too with --verbose).
+
 Features:
 - Restore files and directories with preserved permissions
 - Restore to original paths or custom target locations
+- Restore only selected directories with --only
+- Ownership remapping for restores onto another host
 - Support for both local and S3 storage
 - Graceful handling of missing files and directories
 - Validation of backup integrity before restoration`,
@@ -52,6 +113,18 @@ func init() {
 	restoreCmd.Flags().BoolVarP(&restoreForce, "force", "f", false, "skip confirmation prompts")
 	restoreCmd.Flags().StringVarP(&restorePath, "path", "p", "", "restore from specific backup path (bypasses config)")
 	restoreCmd.Flags().StringVarP(&restoreTargetPath, "target", "t", "", "restore to custom target path instead of original locations")
+	restoreCmd.Flags().StringSliceVar(&restoreOnly, "only", nil, "restore only these backup directories (comma-separated names)")
+	restoreCmd.Flags().StringSliceVar(&restoreUIDMap, "uid-map", nil, "map backup UIDs to local UIDs, e.g. 1000:1001 (repeatable)")
+	restoreCmd.Flags().StringSliceVar(&restoreGIDMap, "gid-map", nil, "map backup GIDs to local GIDs, e.g. 1000:1001 (repeatable)")
+	restoreCmd.Flags().BoolVar(&restoreNumeric, "numeric-owner", false, "keep recorded numeric IDs instead of resolving user and group names")
+	restoreCmd.Flags().BoolVar(&restoreNoTimes, "no-times", false, "leave restored files and directories with the current time instead of the recorded modification time")
+	restoreCmd.Flags().BoolVar(&restoreAtimes, "atime", false, "also restore the recorded access times")
+	restoreCmd.Flags().StringArrayVar(&restoreLabels, "label", nil, "restore the newest backup with this key=value label instead of naming one (repeatable)")
+	restoreCmd.Flags().StringVar(&restoreAt, "at", "", "restore the newest backup taken at or before a local time (YYYY-MM-DD[ HH:MM]) or period ago (e.g., 2d)")
+	restoreCmd.Flags().StringVar(&restoreContainer, "to-container", "", "restore into a scratch directory and inspect it in a temporary container of this image")
+	restoreCmd.Flags().BoolVar(&restoreSandbox, "sandbox", false, "extract in a Landlock sandbox that may only read the backup and write the restore targets (Linux)")
+	restoreCmd.Flags().StringVar(&restoreApprovalCode, "approval", "", "approval token from 'backtide approve' or TOTP code, for jobs with restore_approval")
+	restoreCmd.Flags().StringVar(&restoreHost, "host", "", "restore a backup written by this host (hostname or machine ID)")
 
 	// Register with command registry
 	commands.RegisterCommand("restore", restoreCmd)
@@ -59,134 +132,220 @@ func init() {
 
 func runRestore(cmd *cobra.Command, args []string) {
 	// Validate arguments
-	if len(args) == 0 && restorePath == "" {
-		fmt.Println("Error: Either backup ID or --path must be specified")
-		fmt.Println("Usage: backtide restore [backup-id] OR backtide restore --path /path/to/backup")
+	selecting := len(restoreLabels) > 0 || restoreAt != ""
+	if selecting && (len(args) > 0 || restorePath != "") {
+		render.Println("Error: --label and --at select the backup to restore; they cannot be combined with a backup ID or --path")
+		os.Exit(1)
+	}
+	if len(args) == 0 && restorePath == "" && !selecting {
+		render.Println("Error: Either backup ID or --path must be specified")
+		render.Println("Usage: backtide restore [backup-id] OR backtide restore --path /path/to/backup")
 		os.Exit(1)
 	}
 
 	if len(args) > 0 && restorePath != "" {
-		fmt.Println("Error: Cannot specify both backup ID and --path")
-		fmt.Println("Use either: backtide restore [backup-id] OR backtide restore --path /path/to/backup")
+		render.Println("Error: Cannot specify both backup ID and --path")
+		render.Println("Use either: backtide restore [backup-id] OR backtide restore --path /path/to/backup")
+		os.Exit(1)
+	}
+
+	if restoreContainer != "" && restoreTargetPath != "" {
+		render.Println("Error: --to-container restores into a scratch directory; it cannot be combined with --target")
+		os.Exit(1)
+	}
+
+	ownership, err := restoreOwnershipMap()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Determine restoration mode
 	if restorePath != "" {
 		// Mode 1: Path-based restoration (config-independent)
-		runPathBasedRestore()
+		runPathBasedRestore(ownership)
 	} else {
-		// Mode 2: Configuration-based restoration
-		backupID := args[0]
-		runConfigBasedRestore(backupID)
+		// Mode 2: Configuration-based restoration, by ID or by labels and time
+		backupID := ""
+		if len(args) > 0 {
+			backupID = args[0]
+		}
+		runConfigBasedRestore(backupID, ownership)
 	}
 }
 
+// restoreSelector builds the backup selection from the --label and --at flags
+func restoreSelector() (backup.BackupSelector, error) {
+	var selector backup.BackupSelector
+	var err error
+	if selector.Labels, err = backup.ParseLabels(restoreLabels); err != nil {
+		return selector, err
+	}
+	if restoreAt != "" {
+		if selector.At, err = parseListTime(restoreAt); err != nil {
+			return selector, fmt.Errorf("invalid --at: %w", err)
+		}
+	}
+	return selector, nil
+}
+
+// restoreOwnershipMap builds the ownership mapping from the --uid-map, --gid-map and --numeric-owner flags
+func restoreOwnershipMap() (*backup.OwnershipMap, error) {
+	uids, err := backup.ParseIDMap(restoreUIDMap)
+	if err != nil {
+		return nil, err
+	}
+	gids, err := backup.ParseIDMap(restoreGIDMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if (len(uids) > 0 || len(gids) > 0) && os.Geteuid() != 0 {
+		render.Println("⚠️  Warning: Ownership can only be changed when running as root; --uid-map and --gid-map will have no effect")
+	}
+
+	return &backup.OwnershipMap{UIDs: uids, GIDs: gids, Numeric: restoreNumeric}, nil
+}
+
 // runPathBasedRestore handles restoration from a specific backup path
-func runPathBasedRestore() {
+func runPathBasedRestore(ownership *backup.OwnershipMap) {
 	// Validate backup path
 	if _, err := os.Stat(restorePath); os.IsNotExist(err) {
-		fmt.Printf("Error: Backup path does not exist: %s\n", restorePath)
+		render.Printf("Error: Backup path does not exist: %s\n", restorePath)
 		os.Exit(1)
 	}
 
 	// Check if it's a valid backup directory
 	metadataPath := filepath.Join(restorePath, "metadata.toml")
 	if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
-		fmt.Printf("Error: Invalid backup directory - metadata file not found: %s\n", metadataPath)
-		fmt.Println("Please ensure the path points to a valid Backtide backup directory")
+		render.Printf("Error: Invalid backup directory - metadata file not found: %s\n", metadataPath)
+		render.Println("Please ensure the path points to a valid Backtide backup directory")
 		os.Exit(1)
 	}
 
 	// Load metadata
 	metadata, err := config.LoadBackupMetadata(metadataPath)
 	if err != nil {
-		fmt.Printf("Error: Failed to load backup metadata: %v\n", err)
+		render.Printf("Error: Failed to load backup metadata: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Restoring backup from path: %s\n", restorePath)
-	fmt.Printf("Backup ID: %s\n", metadata.ID)
-	fmt.Printf("Backup date: %s\n", metadata.Timestamp.Format("2006-01-02 15:04:05"))
+	directories, err := backup.SelectDirectories(metadata, restoreOnly)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Printf("Restoring backup from path: %s\n", restorePath)
+	render.Printf("Backup ID: %s\n", metadata.ID)
+	render.Printf("Backup date: %s\n", metadata.Timestamp.Format("2006-01-02 15:04:05"))
 
 	// Create a minimal backup config for the restore operation
 	backupConfig := config.BackupConfig{
 		BackupPath: filepath.Dir(restorePath), // Use parent directory as backup path
-		TempPath:   "/tmp/backtide",
+		TempPath:   paths.TempDir(),
+	}
+
+	// The configuration is only consulted for restore approval here
+	configPath := cfgFile
+	if configPath == "" {
+		configPath = config.FindConfigFile()
+	}
+	if configPath != "" {
+		if cfg, err := config.LoadConfig(configPath); err == nil {
+			backupConfig.Approval = cfg.Approval
+			if job := findJobByName(cfg, metadata.JobName); job != nil {
+				backupConfig.Jobs = []config.BackupJob{*job}
+			}
+		}
 	}
 
 	backupManager := backup.NewBackupManager(backupConfig)
+	backupManager.SetOwnershipMap(ownership)
+	backupManager.SetRestoreTimes(restoreNoTimes, restoreAtimes)
+	backupManager.SetRestoreSandbox(restoreSandbox)
+	backupManager.SetRestoreApproval(restoreApprovalCode)
+
+	if restoreContainer != "" {
+		if err := restoreToContainer(backupManager, metadata.ID); err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if dryRun {
+		if err := printRestorePlan(backupManager, metadata.ID); err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Confirm restore operation
 	if !restoreForce && !force {
-		fmt.Printf("\nWARNING: This will restore backup '%s'\n", metadata.ID)
-		fmt.Printf("Source: %s\n", restorePath)
+		render.Printf("\nWARNING: This will restore backup '%s'\n", metadata.ID)
+		render.Printf("Source: %s\n", restorePath)
 
 		if restoreTargetPath != "" {
-			fmt.Printf("Target: %s (custom location)\n", restoreTargetPath)
-			fmt.Printf("Original paths will be mapped to: %s/{directory-name}\n", restoreTargetPath)
+			render.Printf("Target: %s (custom location)\n", restoreTargetPath)
+			render.Printf("Original paths will be mapped to: %s/{directory-name}\n", restoreTargetPath)
 		} else {
-			fmt.Printf("Target: Original locations\n")
-			for _, dir := range metadata.Directories {
-				fmt.Printf("  - %s -> %s\n", dir.Name, dir.Path)
+			render.Printf("Target: Original locations\n")
+			for _, dir := range directories {
+				render.Printf("  - %s -> %s\n", dir.Name, dir.Path)
 			}
 		}
+		if len(restoreOnly) > 0 {
+			render.Printf("Only %d of %d directories will be restored; the rest are left untouched\n",
+				len(directories), len(metadata.Directories))
+		}
 
-		fmt.Print("\nAre you sure you want to continue? (yes/no): ")
-
-		var response string
-		fmt.Scanln(&response)
-		if response != "yes" && response != "y" {
-			fmt.Println("Restore cancelled")
+		render.Println()
+		if ok, err := newPrompter().Confirm("Are you sure you want to continue?", false); !ok {
+			if err != nil {
+				render.Printf("❌ %v\n", err)
+			}
+			render.Println("Restore cancelled")
 			return
 		}
 	}
 
-	if dryRun {
-		fmt.Println("DRY RUN: Would restore backup (no changes made)")
-		return
+	if err := backupManager.CheckRestoreApproval(metadata); err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
-	// Perform the restore with custom target path if specified
-	if restoreTargetPath != "" {
-		fmt.Printf("Restoring to custom target: %s\n", restoreTargetPath)
-		if err := backupManager.RestoreBackupToPath(metadata.ID, restoreTargetPath); err != nil {
-			fmt.Printf("Error restoring backup: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		// Restore to original locations
-		if err := backupManager.RestoreBackup(metadata.ID); err != nil {
-			fmt.Printf("Error restoring backup: %v\n", err)
-			os.Exit(1)
-		}
+	if err := performRestore(backupManager, metadata.ID); err != nil {
+		render.Printf("Error restoring backup: %v\n", err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Backup restored successfully: %s\n", metadata.ID)
+	render.Printf("✅ Backup restored successfully: %s\n", metadata.ID)
 }
 
 // runConfigBasedRestore handles restoration using configuration file
-func runConfigBasedRestore(backupID string) {
+func runConfigBasedRestore(backupID string, ownership *backup.OwnershipMap) {
 	configPath := getConfigPath()
 	if configPath == "" {
-		fmt.Println("Error: No configuration file found for config-based restore")
-		fmt.Println("Use one of the following options:")
-		fmt.Println("  1. Create a configuration with 'backtide init'")
-		fmt.Println("  2. Use path-based restore: backtide restore --path /path/to/backup")
-		fmt.Println("  3. Specify config file: backtide restore --config /path/to/config.toml")
+		render.Println("Error: No configuration file found for config-based restore")
+		render.Println("Use one of the following options:")
+		render.Println("  1. Create a configuration with 'backtide init'")
+		render.Println("  2. Use path-based restore: backtide restore --path /path/to/backup")
+		render.Println("  3. Specify config file: backtide restore --config /path/to/config.toml")
 		os.Exit(1)
 	}
 
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Check if we have any jobs configured
 	if len(cfg.Jobs) == 0 {
-		fmt.Println("No backup jobs configured.")
-		fmt.Println("Use 'backtide jobs add' to create backup jobs.")
+		render.Println("No backup jobs configured.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
 		return
 	}
 
@@ -200,8 +359,8 @@ func runConfigBasedRestore(backupID string) {
 			}
 		}
 		if job == nil {
-			fmt.Printf("Error: Job '%s' not found\n", restoreJobName)
-			fmt.Println("Use 'backtide jobs list' to see available jobs.")
+			render.Printf("Error: Job '%s' not found\n", restoreJobName)
+			render.Println("Use 'backtide jobs list' to see available jobs.")
 			os.Exit(1)
 		}
 	} else {
@@ -218,8 +377,8 @@ func runConfigBasedRestore(backupID string) {
 	}
 
 	if job == nil {
-		fmt.Println("No backup job found to use for restore.")
-		fmt.Println("Use 'backtide jobs add' to create backup jobs.")
+		render.Println("No backup job found to use for restore.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
 		return
 	}
 
@@ -236,7 +395,7 @@ func runConfigBasedRestore(backupID string) {
 	backupPath := cfg.BackupPath
 	if job.Storage.S3 && bucketConfig != nil {
 		backupPath = bucketConfig.MountPoint
-		fmt.Printf("Using S3 mount point for restore: %s\n", backupPath)
+		render.Printf("Using S3 mount point for restore: %s\n", backupPath)
 	}
 
 	// Create job-specific backup config
@@ -245,59 +404,223 @@ func runConfigBasedRestore(backupID string) {
 		Buckets:    cfg.Buckets,
 		BackupPath: backupPath,
 		TempPath:   cfg.TempPath,
+		Approval:   cfg.Approval,
+	}
+
+	// Mount an on-demand bucket only for the duration of the restore
+	release, err := backup.NewBackupRunner(*cfg).MountJobStorage(job.Name, fmt.Sprintf("restore-%d", os.Getpid()))
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
+	defer release()
 
 	backupManager := backup.NewBackupManager(jobBackupConfig)
+	backupManager.SetOwnershipMap(ownership)
+	backupManager.SetRestoreTimes(restoreNoTimes, restoreAtimes)
+	backupManager.SetRestoreSandbox(restoreSandbox)
+	backupManager.SetHost(restoreHost)
+	backupManager.SetRestoreApproval(restoreApprovalCode)
+
+	if backupID == "" {
+		selector, err := restoreSelector()
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			release()
+			os.Exit(1)
+		}
+		metadata, err := backupManager.Latest(job.Name, selector)
+		if err != nil {
+			render.Printf("Error: %v\n", err)
+			release()
+			os.Exit(1)
+		}
+		backupID = metadata.ID
+		render.Printf("Selected backup %s (%s; selection: %s)\n", backupID, metadata.Timestamp.Format("2006-01-02 15:04:05"), selector)
+		if metadata.Comment != "" {
+			render.Printf("Comment: %s\n", metadata.Comment)
+		}
+	}
+
+	if restoreContainer != "" {
+		if err := restoreToContainer(backupManager, backupID); err != nil {
+			render.Printf("Error: %v\n", err)
+			release()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if dryRun {
+		if err := printRestorePlan(backupManager, backupID); err != nil {
+			render.Printf("Error: %v\n", err)
+			release()
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Confirm restore operation
 	if !restoreForce && !force {
-		fmt.Printf("WARNING: This will restore backup '%s' for job '%s'\n", backupID, job.Name)
+		render.Printf("WARNING: This will restore backup '%s' for job '%s'\n", backupID, job.Name)
 
 		if restoreTargetPath != "" {
-			fmt.Printf("Target: %s (custom location)\n", restoreTargetPath)
-			fmt.Printf("Original paths will be mapped to: %s/{directory-name}\n", restoreTargetPath)
+			render.Printf("Target: %s (custom location)\n", restoreTargetPath)
+			render.Printf("Original paths will be mapped to: %s/{directory-name}\n", restoreTargetPath)
 		} else {
-			fmt.Printf("Target: Original locations\n")
+			render.Printf("Target: Original locations\n")
 			// Show original paths from the backup (if we can load the metadata)
-			backupDir := filepath.Join(backupPath, backupID)
-			metadataPath := filepath.Join(backupDir, "metadata.toml")
-			if metadata, err := config.LoadBackupMetadata(metadataPath); err == nil {
-				for _, dir := range metadata.Directories {
-					fmt.Printf("  - %s -> %s\n", dir.Name, dir.Path)
+			if metadata, err := backupManager.GetBackupInfo(backupID); err == nil {
+				directories, err := backup.SelectDirectories(metadata, restoreOnly)
+				if err != nil {
+					render.Printf("Error: %v\n", err)
+					release()
+					os.Exit(1)
+				}
+				for _, dir := range directories {
+					render.Printf("  - %s -> %s\n", dir.Name, dir.Path)
 				}
 			}
 		}
+		if len(restoreOnly) > 0 {
+			render.Printf("Only these directories will be restored: %s\n", strings.Join(restoreOnly, ", "))
+		}
 
-		fmt.Printf("This will overwrite existing files in the target directories.\n")
-		fmt.Print("Are you sure you want to continue? (yes/no): ")
-
-		var response string
-		fmt.Scanln(&response)
-		if response != "yes" && response != "y" {
-			fmt.Println("Restore cancelled")
+		render.Printf("This will overwrite existing files in the target directories.\n")
+		if ok, err := newPrompter().Confirm("Are you sure you want to continue?", false); !ok {
+			if err != nil {
+				render.Printf("❌ %v\n", err)
+			}
+			render.Println("Restore cancelled")
 			return
 		}
 	}
 
-	if dryRun {
-		fmt.Println("DRY RUN: Would restore backup (no changes made)")
-		return
+	metadata, err := backupManager.GetBackupInfo(backupID)
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		release()
+		os.Exit(1)
+	}
+	if err := backupManager.CheckRestoreApproval(metadata); err != nil {
+		render.Printf("❌ %v\n", err)
+		release()
+		os.Exit(1)
 	}
 
-	// Perform the restore with custom target path if specified
-	if restoreTargetPath != "" {
-		fmt.Printf("Restoring to custom target: %s\n", restoreTargetPath)
-		if err := backupManager.RestoreBackupToPath(backupID, restoreTargetPath); err != nil {
-			fmt.Printf("Error restoring backup: %v\n", err)
-			os.Exit(1)
+	if err := performRestore(backupManager, backupID); err != nil {
+		render.Printf("Error restoring backup: %v\n", err)
+		release()
+		os.Exit(1)
+	}
+
+	render.Printf("✅ Backup restored successfully: %s\n", backupID)
+}
+
+// printRestorePlan prints which files a restore would create, overwrite or
+// change the owner of, per directory, without changing anything
+func printRestorePlan(backupManager *backup.BackupManager, backupID string) error {
+	plans, err := backupManager.PlanRestore(backupID, restoreTargetPath, restoreOnly)
+	if err != nil {
+		return err
+	}
+
+	render.Printf("DRY RUN: Restore plan for backup %s (no changes made)\n", backupID)
+	var created, overwritten, ownerChanged, skipped int
+	for _, plan := range plans {
+		render.Printf("\n📂 %s -> %s\n", plan.Name, plan.Target)
+		render.Printf("   %d created, %d overwritten, %d ownership changes, %d skipped\n",
+			len(plan.Created), len(plan.Overwritten), len(plan.OwnerChanged), len(plan.Skipped))
+		for _, path := range plan.Overwritten {
+			render.Printf("   overwrite  %s\n", path)
 		}
-	} else {
-		// Restore to original locations
-		if err := backupManager.RestoreBackup(backupID); err != nil {
-			fmt.Printf("Error restoring backup: %v\n", err)
-			os.Exit(1)
+		for _, path := range plan.OwnerChanged {
+			render.Printf("   owner      %s\n", path)
+		}
+		for _, path := range plan.Skipped {
+			render.Printf("   skip       %s\n", path)
+		}
+		if verbose {
+			for _, path := range plan.Created {
+				render.Printf("   create     %s\n", path)
+			}
 		}
+		created += len(plan.Created)
+		overwritten += len(plan.Overwritten)
+		ownerChanged += len(plan.OwnerChanged)
+		skipped += len(plan.Skipped)
 	}
 
-	fmt.Printf("✅ Backup restored successfully: %s\n", backupID)
+	render.Printf("\n📊 Total: %d created, %d overwritten, %d ownership changes, %d skipped\n", created, overwritten, ownerChanged, skipped)
+	if created > 0 && !verbose {
+		render.Println("💡 Use --verbose to also list the files that would be created")
+	}
+	return nil
+}
+
+// restoreToContainer restores a backup into a scratch directory and runs a
+// temporary container with it mounted, removing both afterwards
+func restoreToContainer(backupManager *backup.BackupManager, backupID string) error {
+	if dryRun {
+		render.Printf("DRY RUN: Would restore backup %s into a scratch directory and start %s with it mounted at %s\n", backupID, restoreContainer, docker.ScratchMountPoint)
+		return nil
+	}
+	if err := docker.NewDockerManager("", "", config.DockerConfig{}).CheckDockerAvailable(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(paths.TempDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	scratch, err := os.MkdirTemp(paths.TempDir(), "restore-"+backupID+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	err = backupManager.RestoreToScratch(context.Background(), backupID, scratch, restoreOnly)
+	audit.RecordResult("restore", backupID, []string{"restored into a scratch container of " + restoreContainer}, err)
+	if err != nil {
+		return err
+	}
+
+	render.Printf("\n🐳 Starting %s with the restored data at %s (scratch copy in %s)\n", restoreContainer, docker.ScratchMountPoint, scratch)
+	render.Println("💡 Changes made in the container are discarded when it exits")
+	if err := docker.RunScratch(restoreContainer, scratch, newPrompter().IsInteractive()); err != nil {
+		return err
+	}
+	render.Println("🧹 Container and scratch copy removed")
+	return nil
+}
+
+// performRestore restores a backup to its original locations or --target and records it in the audit log
+func performRestore(backupManager *backup.BackupManager, backupID string) error {
+	destination := "original locations"
+	if restoreTargetPath != "" {
+		// Perform the restore with custom target path if specified
+		render.Printf("Restoring to custom target: %s\n", restoreTargetPath)
+		destination = restoreTargetPath
+	}
+
+	// Restore everything unless --only selected specific directories
+	err := backupManager.RestoreDirectories(context.Background(), backupID, restoreTargetPath, restoreOnly)
+
+	changes := []string{"restored to " + destination}
+	if len(restoreOnly) > 0 {
+		changes = append(changes, "directories: "+strings.Join(restoreOnly, ", "))
+	}
+	if len(restoreUIDMap) > 0 {
+		changes = append(changes, "uid map: "+strings.Join(restoreUIDMap, ", "))
+	}
+	if len(restoreGIDMap) > 0 {
+		changes = append(changes, "gid map: "+strings.Join(restoreGIDMap, ", "))
+	}
+	if restoreSandbox {
+		changes = append(changes, "sandboxed")
+	}
+	if approvedBy := backupManager.RestoreApprovedBy(); approvedBy != "" {
+		changes = append(changes, approvedBy)
+	}
+	audit.RecordResult("restore", backupID, changes, err)
+	return err
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/spf13/cobra"
+)
+
+// restoreWorkerCmd extracts a backup for 'restore --sandbox'; the restore
+// starts it with the sandbox paths and passes the request on stdin
+var restoreWorkerCmd = &cobra.Command{
+	Use:    backup.RestoreWorkerCommand,
+	Short:  "Extract a backup in the restore sandbox (internal)",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run:    runRestoreWorker,
+}
+
+var (
+	restoreWorkerRead  []string
+	restoreWorkerWrite []string
+)
+
+func init() {
+	restoreWorkerCmd.Flags().StringArrayVar(&restoreWorkerRead, "read", nil, "directory the worker may read")
+	restoreWorkerCmd.Flags().StringArrayVar(&restoreWorkerWrite, "write", nil, "directory the worker may write")
+	// The restore passes --sandbox with the paths, as runs do for the archive worker
+	restoreWorkerCmd.Flags().Bool("sandbox", true, "restrict the worker to the --read and --write paths")
+
+	// Register with command registry
+	commands.RegisterCommand("restore-worker", restoreWorkerCmd)
+}
+
+func runRestoreWorker(cmd *cobra.Command, args []string) {
+	// The sandbox is entered before the request is read; this restarts the worker
+	if err := backup.EnterSandbox(backup.SandboxRules{Read: restoreWorkerRead, Write: restoreWorkerWrite}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The restore reads the result from the pipe passed as the first extra file
+	result := os.NewFile(3, "result")
+	if err := backup.RunRestoreWorker(os.Stdin, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -4,17 +4,32 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
+	"github.com/mitexleo/backtide/internal/access"
 	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/fault"
+	"github.com/mitexleo/backtide/internal/prompt"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile string
-	verbose bool
-	dryRun  bool
-	force   bool
+	cfgFile     string
+	verbose     bool
+	dryRun      bool
+	force       bool
+	readOnly    bool
+	profile     string
+	assumeYes   bool
+	noEmoji     bool
+	noColor     bool
+	outputWidth int
+	faultInject string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -39,6 +54,7 @@ Example usage:
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRun: preRunCommand,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -62,6 +78,16 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be done without making changes")
 	rootCmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "force operation, skip confirmation prompts")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to confirmations and accept the defaults of all other prompts")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "configuration profile to use, e.g. staging for /etc/backtide/profiles/staging.toml (also BACKTIDE_PROFILE)")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "refuse operations that modify backups or configuration (also BACKTIDE_READ_ONLY=1)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "print ASCII markers such as [OK] and [FAIL] instead of emoji (also BACKTIDE_NO_EMOJI=1)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "never color output (also NO_COLOR=1; color is only used on terminals)")
+	rootCmd.PersistentFlags().IntVar(&outputWidth, "width", 0, "fit tables into this many columns (default: no limit)")
+
+	// Resilience testing only: fail uploads, cut off compression and delay Docker at random
+	rootCmd.PersistentFlags().StringVar(&faultInject, "fault-inject", "", "inject faults, e.g. upload=0.2,compress,docker,delay=5s,seed=1 (also "+fault.Env+")")
+	rootCmd.PersistentFlags().MarkHidden("fault-inject")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -71,19 +97,138 @@ func init() {
 // registerCommands registers all commands with the centralized registry
 func registerCommands() {
 	// Register all top-level commands with the registry
+	commands.RegisterCommand("approve", approveCmd)
+	commands.RegisterCommand("archive-worker", archiveWorkerCmd)
+	commands.RegisterCommand("audit", auditCmd)
 	commands.RegisterCommand("backup", backupCmd)
+	commands.RegisterCommand("cancel", cancelCmd)
+	commands.RegisterCommand("catalog", catalogCmd)
+	commands.RegisterCommand("check", checkCmd)
 	commands.RegisterCommand("cleanup", cleanupCmd)
+	commands.RegisterCommand("config", configCmd)
+	commands.RegisterCommand("containers", containersCmd)
 	commands.RegisterCommand("cron", cronCmd)
 	commands.RegisterCommand("daemon", daemonCmd)
+	commands.RegisterCommand("delete", deleteCmd)
+	commands.RegisterCommand("digest", digestCmd)
+	commands.RegisterCommand("find", findCmd)
+	commands.RegisterCommand("fleet", fleetCmd)
+	commands.RegisterCommand("gc", gcCmd)
+	commands.RegisterCommand("history", historyCmd)
 	commands.RegisterCommand("init", initCmd)
+	commands.RegisterCommand("install", installCmd)
 	commands.RegisterCommand("jobs", jobsCmd)
 	commands.RegisterCommand("list", listCmd)
+	commands.RegisterCommand("pause", pauseCmd)
+	commands.RegisterCommand("pin", pinCmd)
+	commands.RegisterCommand("plugins", pluginsCmd)
+	commands.RegisterCommand("profiles", profilesCmd)
+	commands.RegisterCommand("replicate", replicateCmd)
 	commands.RegisterCommand("restore", restoreCmd)
+	commands.RegisterCommand("restore-worker", restoreWorkerCmd)
+	commands.RegisterCommand("resume", resumeCmd)
 	commands.RegisterCommand("s3", s3Cmd)
+	commands.RegisterCommand("stats", statsCmd)
+	commands.RegisterCommand("status", statusCmd)
 	commands.RegisterCommand("systemd", systemdCmd)
+	commands.RegisterCommand("systemd-jobs", systemdJobsCmd)
 	commands.RegisterCommand("update", updateCmd)
+	commands.RegisterCommand("uploads", uploadsCmd)
+	commands.RegisterCommand("verify", verifyCmd)
+	commands.RegisterCommand("verify-agent", verifyAgentCmd)
 	commands.RegisterCommand("version", versionCmd)
 
 	// Register all commands with the root command
 	commands.RegisterAllWithRoot(rootCmd)
 }
+
+// preRunCommand applies global settings before any command runs
+func preRunCommand(cmd *cobra.Command, args []string) {
+	render.Configure(render.Options{NoEmoji: noEmoji, NoColor: noColor, Width: outputWidth})
+	configureFaults(cmd)
+
+	if name := activeProfile(); name != "" {
+		if err := config.ValidateProfileName(name); err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		// Keep runs, pauses and the daemon socket separate per profile
+		state.SetProfile(name)
+	}
+
+	enforceAccess(cmd)
+}
+
+// configureFaults enables fault injection requested with --fault-inject or
+// BACKTIDE_FAULT_INJECT; the flag is passed on to archive workers and plugins
+// through the environment
+func configureFaults(cmd *cobra.Command) {
+	spec := os.Getenv(fault.Env)
+	if faultInject != "" {
+		spec = faultInject
+		os.Setenv(fault.Env, spec)
+	}
+	if err := fault.Configure(spec); err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	// Archive workers run within a job that already announced it
+	if fault.Enabled() && cmd != archiveWorkerCmd {
+		fmt.Fprintf(os.Stderr, "⚠️  Fault injection enabled (%s); failures are deliberate\n", fault.Describe())
+	}
+}
+
+// newPrompter returns a prompter on stdin and stdout honoring --yes
+func newPrompter() *prompt.Prompter {
+	p := prompt.Stdio()
+	p.AssumeYes = assumeYes
+	return p
+}
+
+// activeProfile returns the profile selected with --profile or BACKTIDE_PROFILE
+func activeProfile() string {
+	if profile != "" {
+		return profile
+	}
+	return os.Getenv(config.ProfileEnv)
+}
+
+// enforceAccess refuses modifying commands for read-only users
+func enforceAccess(cmd *cobra.Command) {
+	if commands.IsReadOnly(cmd) {
+		return
+	}
+
+	role := access.RoleOperator
+	if readOnly || access.ReadOnlyRequested() {
+		role = access.RoleReadOnly
+	} else {
+		// Only consult an existing configuration; never create one here
+		configPath := cfgFile
+		if configPath == "" && activeProfile() != "" {
+			configPath, _ = config.FindProfile(activeProfile())
+		} else if configPath == "" {
+			configPath = config.LocateConfigFile()
+		}
+		if configPath != "" {
+			if accessConfig, err := config.LoadAccessConfig(configPath); err == nil {
+				role = access.CurrentRole(accessConfig)
+			}
+		}
+	}
+
+	if role != access.RoleOperator {
+		render.Printf("❌ 'backtide %s' modifies backups or configuration and is not permitted in read-only mode\n", commandPath(cmd))
+		render.Println("💡 Read-only users can run list, status, audit and 'jobs list/show'")
+		os.Exit(1)
+	}
+}
+
+// commandPath returns the command path without the program name
+func commandPath(cmd *cobra.Command) string {
+	path := cmd.CommandPath()
+	if root := cmd.Root(); root != nil {
+		path = strings.TrimPrefix(path, root.Name()+" ")
+	}
+	return path
+}
@@ -1,7 +1,8 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,15 +11,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/prompt"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3api"
 	"github.com/mitexleo/backtide/internal/s3fs"
 
 	"github.com/mitexleo/backtide/internal/commands"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/wizard"
 	"github.com/spf13/cobra"
 )
 
 var (
-	s3Force bool
+	s3Force   bool
+	s3TestAPI bool
+
+	s3CreateBucket         bool
+	s3Versioning           bool
+	s3NoEncryption         bool
+	s3AbortMultipartDays   int
+	s3NoncurrentExpiryDays int
+	s3PrintPolicy          bool
 )
 
 // s3Cmd represents the s3 command
@@ -32,6 +46,8 @@ This command allows you to:
 - Add new bucket configurations
 - Remove existing bucket configurations
 - Test bucket connectivity
+- Regenerate /etc/fstab entries
+- Show mount health and mount or unmount buckets
 
 Buckets can be reused by multiple backup jobs.`,
 }
@@ -57,7 +73,16 @@ var s3AddCmd = &cobra.Command{
 	Long: `Add a new S3 bucket configuration to the global configuration.
 
 This configuration can be reused by multiple backup jobs.
-The configuration will be added to the bucket settings.`,
+The configuration will be added to the bucket settings.
+
+With --create-bucket the bucket is created through the S3 API in the configured
+region, with default encryption, optional versioning and lifecycle rules that
+clean up incomplete uploads and old object versions. --print-policy prints a
+minimal IAM policy for the backup credentials.
+
+Examples:
+  sudo backtide s3 add
+  sudo backtide s3 add --create-bucket --versioning --print-policy`,
 	Run: runS3Add,
 }
 
@@ -82,23 +107,53 @@ This command will:
 - Attempt to mount the S3 bucket
 - Create a test file
 - Verify read/write permissions
-- Clean up test files`,
+- Clean up test files
+
+With --api the bucket is tested with signed HTTP requests instead of an s3fs
+mount: credentials, bucket existence, region/endpoint and PUT/GET/DELETE
+permissions are checked without FUSE or root.`,
 	Run: runS3Test,
 }
 
+// s3FstabCmd represents the s3 fstab command
+var s3FstabCmd = &cobra.Command{
+	Use:   "fstab",
+	Short: "Rewrite /etc/fstab entries from the bucket configuration",
+	Long: `Regenerate the /etc/fstab entry of every permanently mounted bucket from
+the configuration, including [buckets.s3fs] tuning options, replacing
+outdated entries. Edit the configuration rather than /etc/fstab so tuning is
+not lost when entries are rewritten.
+
+Changes apply the next time the bucket is mounted.`,
+	Run: runS3Fstab,
+}
+
 func init() {
 	s3Cmd.AddCommand(s3ListCmd)
 	s3Cmd.AddCommand(s3AddCmd)
 	s3Cmd.AddCommand(s3RemoveCmd)
 	s3Cmd.AddCommand(s3TestCmd)
+	s3Cmd.AddCommand(s3FstabCmd)
 
 	s3RemoveCmd.Flags().BoolVarP(&s3Force, "force", "f", false, "force removal without confirmation")
+	s3AddCmd.Flags().BoolVar(&s3CreateBucket, "create-bucket", false, "create the bucket through the S3 API")
+	s3AddCmd.Flags().BoolVar(&s3Versioning, "versioning", false, "enable object versioning on the created bucket")
+	s3AddCmd.Flags().BoolVar(&s3NoEncryption, "no-encryption", false, "do not enable default server-side encryption on the created bucket")
+	s3AddCmd.Flags().IntVar(&s3AbortMultipartDays, "abort-multipart-days", 7, "abort incomplete multipart uploads after this many days (0 to disable)")
+	s3AddCmd.Flags().IntVar(&s3NoncurrentExpiryDays, "noncurrent-days", 30, "with --versioning, expire old object versions after this many days (0 to keep)")
+	s3AddCmd.Flags().BoolVar(&s3PrintPolicy, "print-policy", false, "print a minimal IAM policy for the bucket")
+	s3ListCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "show credentials unmasked (root only)")
+	s3TestCmd.Flags().BoolVar(&s3TestAPI, "api", false, "test through the S3 API without mounting (no FUSE or root needed)")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(s3Cmd, s3ListCmd)
 
 	// Register with command registry
 	commands.RegisterCommand("s3", s3Cmd)
 }
 
 func runS3List(cmd *cobra.Command, args []string) {
+	checkShowSecrets()
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
@@ -106,11 +161,11 @@ func runS3List(cmd *cobra.Command, args []string) {
 		cfg = config.DefaultConfig()
 	}
 
-	fmt.Println("=== S3 Bucket Configurations ===")
+	render.Println("=== S3 Bucket Configurations ===")
 
 	if len(cfg.Buckets) == 0 {
-		fmt.Println("No bucket configurations found.")
-		fmt.Println("Use 'backtide s3 add' to add a bucket configuration.")
+		render.Println("No bucket configurations found.")
+		render.Println("Use 'backtide s3 add' to add a bucket configuration.")
 		return
 	}
 
@@ -126,7 +181,7 @@ func runS3List(cmd *cobra.Command, args []string) {
 		printBucketConfig(bucket, usageCount[bucket.ID])
 	}
 
-	fmt.Printf("\n📊 Summary: %d bucket configurations\n", len(cfg.Buckets))
+	render.Printf("\n📊 Summary: %d bucket configurations\n", len(cfg.Buckets))
 }
 
 func runS3Add(cmd *cobra.Command, args []string) {
@@ -137,113 +192,188 @@ func runS3Add(cmd *cobra.Command, args []string) {
 		cfg = config.DefaultConfig()
 	}
 
-	fmt.Println("=== Add S3 Bucket Configuration ===")
+	render.Println("=== Add S3 Bucket Configuration ===")
 
 	// Check and install s3fs if needed
-	fmt.Println("🔧 Checking for s3fs dependency...")
+	render.Println("🔧 Checking for s3fs dependency...")
 	checkS3FSManager := s3fs.NewS3FSManager(config.BucketConfig{})
 	if !checkS3FSManager.IsS3FSInstalled() {
-		fmt.Println("📦 s3fs not found. Installing...")
+		render.Println("📦 s3fs not found. Installing...")
 		if err := checkS3FSManager.InstallS3FS(); err != nil {
-			fmt.Printf("❌ Failed to install s3fs: %v\n", err)
-			fmt.Println("💡 Please install s3fs manually:")
-			fmt.Println("   Ubuntu/Debian: sudo apt-get install s3fs")
-			fmt.Println("   CentOS/RHEL: sudo yum install s3fs-fuse")
-			fmt.Println("   Fedora: sudo dnf install s3fs-fuse")
-			fmt.Println("   openSUSE: sudo zypper install s3fs-fuse")
-			fmt.Println("   Alpine: sudo apk add s3fs-fuse")
+			render.Printf("❌ Failed to install s3fs: %v\n", err)
+			render.Println("💡 Please install s3fs manually:")
+			render.Println("   Ubuntu/Debian: sudo apt-get install s3fs")
+			render.Println("   CentOS/RHEL: sudo yum install s3fs-fuse")
+			render.Println("   Fedora: sudo dnf install s3fs-fuse")
+			render.Println("   openSUSE: sudo zypper install s3fs-fuse")
+			render.Println("   Alpine: sudo apk add s3fs-fuse")
 			return
 		}
-		fmt.Println("✅ s3fs installed successfully")
+		render.Println("✅ s3fs installed successfully")
 	} else {
-		fmt.Println("✅ s3fs is already installed")
+		render.Println("✅ s3fs is already installed")
 	}
 
 	// Ensure system directories exist (/etc/backtide/)
-	fmt.Println("📁 Ensuring system directories exist...")
+	render.Println("📁 Ensuring system directories exist...")
 	if err := config.EnsureSystemDirectories(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not create system directories: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo mkdir -p /etc/backtide/s3-credentials")
+		render.Printf("⚠️  Warning: Could not create system directories: %v\n", err)
+		render.Println("   You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo mkdir -p /etc/backtide/s3-credentials")
 	}
 
 	// Configure new bucket
-	newBucket := configureBucketForAdd()
+	p := newPrompter()
+	newBucket, err := wizard.Bucket(p, true)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
 
 	// Check for duplicate bucket names
 	for _, existingBucket := range cfg.Buckets {
 		if existingBucket.Bucket == newBucket.Bucket {
-			fmt.Printf("⚠️  A bucket configuration for '%s' already exists.\n", newBucket.Bucket)
-			fmt.Print("Do you want to continue anyway? (y/N): ")
-
-			reader := bufio.NewReader(os.Stdin)
-			response, _ := reader.ReadString('\n')
-			response = strings.TrimSpace(strings.ToLower(response))
-
-			if response != "y" && response != "yes" {
-				fmt.Println("Operation cancelled.")
+			render.Printf("⚠️  A bucket configuration for '%s' already exists.\n", newBucket.Bucket)
+			if !confirmOrCancel(p, "Do you want to continue anyway?") {
 				return
 			}
 			break
 		}
 	}
 
+	if s3CreateBucket {
+		if err := createBucket(newBucket, cfg.Network); err != nil {
+			render.Printf("❌ %v\n", err)
+			if cause := errors.Unwrap(err); cause != nil {
+				render.Printf("💡 %s\n", s3api.Explain(cause))
+			}
+			render.Println("   The configuration was not saved.")
+			os.Exit(1)
+		}
+	}
+
 	cfg.Buckets = append(cfg.Buckets, newBucket)
 
 	// Save configuration
-	if err := config.SaveConfig(cfg, configPath); err != nil {
-		fmt.Printf("Error saving configuration: %v\n", err)
+	if err := saveConfigWithAudit(cfg, configPath, "s3.add", newBucket.Name); err != nil {
+		render.Printf("Error saving configuration: %v\n", err)
 		os.Exit(1)
 	}
 
+	setupBucketMount(newBucket)
+
+	render.Printf("\n✅ S3 bucket configuration added successfully!\n")
+	render.Printf("Name: %s\n", newBucket.Name)
+	render.Printf("Bucket: %s\n", newBucket.Bucket)
+	render.Printf("Provider: %s\n", newBucket.Provider)
+	render.Printf("Mount point: %s\n", newBucket.MountPoint)
+	render.Printf("Configuration saved to: /etc/backtide/\n")
+	render.Printf("Credentials stored in: /etc/backtide/s3-credentials/\n")
+
+	if s3PrintPolicy {
+		policy, err := s3api.MinimalPolicy(newBucket.Bucket)
+		if err != nil {
+			render.Printf("⚠️  Warning: Could not generate IAM policy: %v\n", err)
+			return
+		}
+		render.Println("\n📜 Minimal IAM policy for the backup credentials:")
+		render.Println(policy)
+	}
+}
+
+// setupBucketMount writes the s3fs credentials of a newly added bucket and,
+// unless it is mounted on demand, adds it to /etc/fstab
+func setupBucketMount(bucket config.BucketConfig) {
 	// Note: Mount point directory will be created by S3FS setup
-	fmt.Printf("\n📁 Mount point: %s\n", newBucket.MountPoint)
+	render.Printf("\n📁 Mount point: %s\n", bucket.MountPoint)
 
 	// Setup S3FS (create credentials file and mount point)
-	fmt.Println("🔧 Setting up S3FS configuration...")
-	s3fsManager := s3fs.NewS3FSManager(newBucket)
+	render.Println("🔧 Setting up S3FS configuration...")
+	s3fsManager := s3fs.NewS3FSManager(bucket)
 	if err := s3fsManager.SetupS3FS(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not setup S3FS: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide s3 add")
+		render.Printf("⚠️  Warning: Could not setup S3FS: %v\n", err)
+		render.Println("   You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo backtide s3 add")
 	} else {
-		fmt.Println("✅ S3FS setup completed")
-		fmt.Println("   Credentials stored in: /etc/backtide/s3-credentials/")
+		render.Println("✅ S3FS setup completed")
+		render.Println("   Credentials stored in: /etc/backtide/s3-credentials/")
 	}
 
-	// Add to fstab for persistence (requires sudo)
-	fmt.Println("📝 Adding to /etc/fstab for automatic mounting...")
-	if err := s3fsManager.AddToFstab(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not add to /etc/fstab: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide s3 add")
+	if bucket.MountOnDemand {
+		// On-demand buckets are mounted by backup and restore runs only
+		render.Println("🔌 Bucket will be mounted only while backups and restores run (not added to /etc/fstab)")
 	} else {
-		fmt.Println("✅ Added to /etc/fstab for automatic mounting")
+		// Add to fstab for persistence (requires sudo)
+		render.Println("📝 Adding to /etc/fstab for automatic mounting...")
+		if err := s3fsManager.AddToFstab(); err != nil {
+			render.Printf("⚠️  Warning: Could not add to /etc/fstab: %v\n", err)
+			render.Println("   You may need to run with sudo for system configuration")
+			render.Println("   Try: sudo backtide s3 add")
+		} else {
+			render.Println("✅ Added to /etc/fstab for automatic mounting")
+		}
+
+		// Reload systemd daemon to pick up fstab changes
+		render.Println("🔄 Reloading systemd daemon...")
+		if err := reloadSystemdDaemon(); err != nil {
+			render.Printf("⚠️  Warning: Could not reload systemd daemon: %v\n", err)
+			render.Println("   You may need to run: sudo systemctl daemon-reload")
+		} else {
+			render.Println("✅ Systemd daemon reloaded")
+		}
 	}
+}
 
-	// Reload systemd daemon to pick up fstab changes
-	fmt.Println("🔄 Reloading systemd daemon...")
-	if err := reloadSystemdDaemon(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not reload systemd daemon: %v\n", err)
-		fmt.Println("   You may need to run: sudo systemctl daemon-reload")
-	} else {
-		fmt.Println("✅ Systemd daemon reloaded")
+// createBucket creates a bucket and applies encryption, versioning and lifecycle
+// settings; settings a provider does not support only produce warnings
+func createBucket(bucket config.BucketConfig, netCfg config.NetworkConfig) error {
+	client, err := s3api.NewClient(bucket, netCfg)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	render.Printf("🪣 Creating bucket %s in region %s...\n", bucket.Bucket, client.Region())
+	if err := client.CreateBucket(ctx); err != nil {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	render.Println("✅ Bucket created")
+
+	if !s3NoEncryption {
+		if err := client.PutBucketEncryption(ctx); err != nil {
+			render.Printf("⚠️  Warning: Could not enable default encryption: %v\n", err)
+		} else {
+			render.Println("✅ Default encryption enabled (SSE-S3)")
+		}
 	}
 
-	fmt.Printf("\n✅ S3 bucket configuration added successfully!\n")
-	fmt.Printf("Name: %s\n", newBucket.Name)
-	fmt.Printf("Bucket: %s\n", newBucket.Bucket)
-	fmt.Printf("Provider: %s\n", newBucket.Provider)
-	fmt.Printf("Mount point: %s\n", newBucket.MountPoint)
-	fmt.Printf("Configuration saved to: /etc/backtide/\n")
-	fmt.Printf("Credentials stored in: /etc/backtide/s3-credentials/\n")
+	if s3Versioning {
+		if err := client.PutBucketVersioning(ctx, true); err != nil {
+			render.Printf("⚠️  Warning: Could not enable versioning: %v\n", err)
+		} else {
+			render.Println("✅ Versioning enabled")
+		}
+	}
+
+	rules := s3api.LifecycleRules{AbortMultipartDays: s3AbortMultipartDays}
+	if s3Versioning {
+		rules.NoncurrentVersionDays = s3NoncurrentExpiryDays
+	}
+	if rules.AbortMultipartDays > 0 || rules.NoncurrentVersionDays > 0 {
+		if err := client.PutBucketLifecycle(ctx, rules); err != nil {
+			render.Printf("⚠️  Warning: Could not set lifecycle rules: %v\n", err)
+		} else {
+			render.Println("✅ Lifecycle rules set")
+		}
+	}
+	return nil
 }
 
 func runS3Remove(cmd *cobra.Command, args []string) {
 	if len(args) == 0 {
-		fmt.Println("Error: Please specify which bucket configuration to remove.")
-		fmt.Println("Usage: backtide s3 remove <bucket-id>")
-		fmt.Println("Use 'backtide s3 list' to see available buckets.")
+		render.Println("Error: Please specify which bucket configuration to remove.")
+		render.Println("Usage: backtide s3 remove <bucket-id>")
+		render.Println("Use 'backtide s3 list' to see available buckets.")
 		os.Exit(1)
 	}
 
@@ -251,11 +381,11 @@ func runS3Remove(cmd *cobra.Command, args []string) {
 	configPath := getConfigPath()
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
+		render.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("=== Remove S3 Bucket Configuration ===")
+	render.Println("=== Remove S3 Bucket Configuration ===")
 
 	// Find the bucket to remove
 	var bucketToRemove *config.BucketConfig
@@ -269,8 +399,8 @@ func runS3Remove(cmd *cobra.Command, args []string) {
 	}
 
 	if bucketToRemove == nil {
-		fmt.Printf("Error: No bucket found with ID or name '%s'\n", bucketID)
-		fmt.Println("Use 'backtide s3 list' to see available buckets.")
+		render.Printf("Error: No bucket found with ID or name '%s'\n", bucketID)
+		render.Println("Use 'backtide s3 list' to see available buckets.")
 		os.Exit(1)
 	}
 
@@ -282,25 +412,20 @@ func runS3Remove(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	fmt.Printf("Bucket configuration to remove:\n")
+	render.Printf("Bucket configuration to remove:\n")
 	printBucketConfig(*bucketToRemove, len(dependentJobs))
 
 	if len(dependentJobs) > 0 {
-		fmt.Printf("\n⚠️  Warning: The following jobs depend on this bucket:\n")
+		render.Printf("\n⚠️  Warning: The following jobs depend on this bucket:\n")
 		for _, jobName := range dependentJobs {
-			fmt.Printf("   - %s\n", jobName)
+			render.Printf("   - %s\n", jobName)
 		}
-		fmt.Println("These jobs will need to be updated with different bucket configurations.")
+		render.Println("These jobs will need to be updated with different bucket configurations.")
 	}
 
 	if !s3Force {
-		fmt.Print("\nAre you sure you want to remove this bucket configuration? (y/N): ")
-		reader := bufio.NewReader(os.Stdin)
-		response, _ := reader.ReadString('\n')
-		response = strings.TrimSpace(strings.ToLower(response))
-
-		if response != "y" && response != "yes" {
-			fmt.Println("Operation cancelled.")
+		render.Println()
+		if !confirmOrCancel(newPrompter(), "Are you sure you want to remove this bucket configuration?") {
 			return
 		}
 	}
@@ -309,57 +434,57 @@ func runS3Remove(cmd *cobra.Command, args []string) {
 	bucketName := bucketToRemove.Name
 
 	// Unmount the bucket first
-	fmt.Println("\n🔽 Unmounting bucket...")
+	render.Println("\n🔽 Unmounting bucket...")
 	s3fsManager := s3fs.NewS3FSManager(*bucketToRemove)
 	if err := s3fsManager.UnmountS3FS(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not unmount bucket: %v\n", err)
-		fmt.Println("   You may need to unmount manually with: fusermount -u " + bucketToRemove.MountPoint)
+		render.Printf("⚠️  Warning: Could not unmount bucket: %v\n", err)
+		render.Println("   You may need to unmount manually with: fusermount -u " + bucketToRemove.MountPoint)
 	} else {
-		fmt.Println("✅ Bucket unmounted successfully")
+		render.Println("✅ Bucket unmounted successfully")
 	}
 
 	// Remove the bucket
 	cfg.Buckets = append(cfg.Buckets[:bucketIndex], cfg.Buckets[bucketIndex+1:]...)
 
-	if err := config.SaveConfig(cfg, configPath); err != nil {
-		fmt.Printf("Error saving configuration: %v\n", err)
+	if err := saveConfigWithAudit(cfg, configPath, "s3.remove", bucketName); err != nil {
+		render.Printf("Error saving configuration: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Clean up credentials file (from /etc/backtide/s3-credentials/)
-	fmt.Println("\n🧹 Cleaning up credentials...")
+	render.Println("\n🧹 Cleaning up credentials...")
 	if err := cleanupBucketCredentials(*bucketToRemove); err != nil {
-		fmt.Printf("⚠️  Warning: Could not clean up credentials: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system directories")
-		fmt.Printf("   Try: sudo rm -f /etc/backtide/s3-credentials/passwd-s3fs-%s\n", bucketToRemove.ID)
+		render.Printf("⚠️  Warning: Could not clean up credentials: %v\n", err)
+		render.Println("   You may need to run with sudo for system directories")
+		render.Printf("   Try: sudo rm -f /etc/backtide/s3-credentials/passwd-s3fs-%s\n", bucketToRemove.ID)
 	} else {
-		fmt.Println("✅ Credentials cleaned up successfully")
+		render.Println("✅ Credentials cleaned up successfully")
 	}
 
 	// Remove from fstab (requires sudo)
-	fmt.Println("📝 Removing from /etc/fstab...")
+	render.Println("📝 Removing from /etc/fstab...")
 	if err := s3fsManager.RemoveFromFstab(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not remove from /etc/fstab: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide s3 remove " + bucketToRemove.ID)
+		render.Printf("⚠️  Warning: Could not remove from /etc/fstab: %v\n", err)
+		render.Println("   You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo backtide s3 remove " + bucketToRemove.ID)
 	} else {
-		fmt.Println("✅ Removed from /etc/fstab")
+		render.Println("✅ Removed from /etc/fstab")
 	}
 
 	// Remove mount point directory if empty (requires sudo for system directories)
-	fmt.Println("📁 Removing mount point directory...")
+	render.Println("📁 Removing mount point directory...")
 	if err := removeMountPointIfEmpty(bucketToRemove.MountPoint); err != nil {
-		fmt.Printf("⚠️  Warning: Could not remove mount point: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system directories")
-		fmt.Printf("   Try: sudo rmdir %s\n", bucketToRemove.MountPoint)
+		render.Printf("⚠️  Warning: Could not remove mount point: %v\n", err)
+		render.Println("   You may need to run with sudo for system directories")
+		render.Printf("   Try: sudo rmdir %s\n", bucketToRemove.MountPoint)
 	} else {
-		fmt.Println("✅ Mount point directory removed")
+		render.Println("✅ Mount point directory removed")
 	}
 
-	fmt.Printf("✅ S3 bucket configuration '%s' removed successfully!\n", bucketName)
-	fmt.Printf("Configuration removed from: /etc/backtide/\n")
+	render.Printf("✅ S3 bucket configuration '%s' removed successfully!\n", bucketName)
+	render.Printf("Configuration removed from: /etc/backtide/\n")
 	if len(dependentJobs) > 0 {
-		fmt.Println("Remember to update dependent jobs with different bucket configurations.")
+		render.Println("Remember to update dependent jobs with different bucket configurations.")
 	}
 }
 
@@ -371,170 +496,230 @@ func runS3Test(cmd *cobra.Command, args []string) {
 		cfg = config.DefaultConfig()
 	}
 
-	fmt.Println("=== Test S3 Bucket Connectivity ===")
+	render.Println("=== Test S3 Bucket Connectivity ===")
 
 	if len(cfg.Buckets) == 0 {
-		fmt.Println("No bucket configurations found to test.")
-		fmt.Println("Use 'backtide s3 add' to add a configuration first.")
+		render.Println("No bucket configurations found to test.")
+		render.Println("Use 'backtide s3 add' to add a configuration first.")
+		return
+	}
+
+	// The API test needs neither s3fs nor system directories
+	if s3TestAPI {
+		bucket := selectBucketToTest(cfg, args)
+		if bucket != nil {
+			testBucketAPI(*bucket, cfg.Network)
+		}
 		return
 	}
 
 	// Check and install s3fs if needed
-	fmt.Println("🔧 Checking for s3fs dependency...")
+	render.Println("🔧 Checking for s3fs dependency...")
 	checkS3FSManager := s3fs.NewS3FSManager(config.BucketConfig{})
 	if !checkS3FSManager.IsS3FSInstalled() {
-		fmt.Println("📦 s3fs not found. Installing...")
+		render.Println("📦 s3fs not found. Installing...")
 		if err := checkS3FSManager.InstallS3FS(); err != nil {
-			fmt.Printf("❌ Failed to install s3fs: %v\n", err)
-			fmt.Println("💡 Please install s3fs manually:")
-			fmt.Println("   Ubuntu/Debian: sudo apt-get install s3fs")
-			fmt.Println("   CentOS/RHEL: sudo yum install s3fs-fuse")
-			fmt.Println("   Fedora: sudo dnf install s3fs-fuse")
-			fmt.Println("   openSUSE: sudo zypper install s3fs-fuse")
-			fmt.Println("   Alpine: sudo apk add s3fs-fuse")
+			render.Printf("❌ Failed to install s3fs: %v\n", err)
+			render.Println("💡 Please install s3fs manually:")
+			render.Println("   Ubuntu/Debian: sudo apt-get install s3fs")
+			render.Println("   CentOS/RHEL: sudo yum install s3fs-fuse")
+			render.Println("   Fedora: sudo dnf install s3fs-fuse")
+			render.Println("   openSUSE: sudo zypper install s3fs-fuse")
+			render.Println("   Alpine: sudo apk add s3fs-fuse")
 			return
 		}
-		fmt.Println("✅ s3fs installed successfully")
+		render.Println("✅ s3fs installed successfully")
 	} else {
-		fmt.Println("✅ s3fs is already installed")
+		render.Println("✅ s3fs is already installed")
 	}
 
 	// Ensure system directories exist (/etc/backtide/)
-	fmt.Println("📁 Ensuring system directories exist...")
+	render.Println("📁 Ensuring system directories exist...")
 	if err := config.EnsureSystemDirectories(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not create system directories: %v\n", err)
-		fmt.Println("   You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo mkdir -p /etc/backtide/s3-credentials")
+		render.Printf("⚠️  Warning: Could not create system directories: %v\n", err)
+		render.Println("   You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo mkdir -p /etc/backtide/s3-credentials")
 	}
 
+	if bucket := selectBucketToTest(cfg, args); bucket != nil {
+		testBucket(*bucket)
+	}
+}
+
+// selectBucketToTest returns the bucket named in args, or prompts for one
+func selectBucketToTest(cfg *config.BackupConfig, args []string) *config.BucketConfig {
 	// If no specific bucket specified, show available options
 	if len(args) == 0 {
-		fmt.Println("Available buckets:")
+		render.Println("Available buckets:")
+		options := make([]string, len(cfg.Buckets))
 		for i, bucket := range cfg.Buckets {
-			fmt.Printf("%d. %s (%s)\n", i+1, bucket.Name, bucket.Bucket)
+			options[i] = fmt.Sprintf("%s (%s)", bucket.Name, bucket.Bucket)
+		}
+		choice, err := newPrompter().Select("Select bucket to test", options, -1)
+		if err != nil {
+			render.Printf("❌ %v\n", err)
+			return nil
 		}
-		fmt.Print("\nSelect bucket to test (number): ")
 
-		reader := bufio.NewReader(os.Stdin)
-		choiceStr, _ := reader.ReadString('\n')
-		choiceStr = strings.TrimSpace(choiceStr)
+		return &cfg.Buckets[choice]
+	}
 
-		var choice int
-		fmt.Sscanf(choiceStr, "%d", &choice)
+	// Test specific bucket
+	if bucket := findBucket(cfg, args[0]); bucket != nil {
+		return bucket
+	}
 
-		if choice < 1 || choice > len(cfg.Buckets) {
-			fmt.Println("Invalid selection.")
-			return
+	render.Printf("Error: No bucket found with ID or name '%s'\n", args[0])
+	render.Println("Use 'backtide s3 list' to see available buckets.")
+	return nil
+}
+
+// testBucketAPI checks a bucket through signed S3 API requests without mounting it
+func testBucketAPI(bucket config.BucketConfig, netCfg config.NetworkConfig) {
+	render.Printf("\nTesting bucket: %s (%s)\n", bucket.Name, bucket.Bucket)
+	render.Printf("Endpoint: %s\n", func() string {
+		if bucket.Endpoint == "" {
+			return "AWS default"
 		}
+		return bucket.Endpoint
+	}())
 
-		bucket := cfg.Buckets[choice-1]
-		testBucket(bucket)
-		return
+	client, err := s3api.NewClient(bucket, netCfg)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
+	render.Printf("Region: %s\n", client.Region())
 
-	// Test specific bucket
-	bucketID := args[0]
-	for _, bucket := range cfg.Buckets {
-		if bucket.ID == bucketID || bucket.Name == bucketID {
-			testBucket(bucket)
-			return
-		}
+	ctx := context.Background()
+	fail := func(step string, err error) {
+		render.Printf("❌ %s failed: %v\n", step, err)
+		render.Printf("💡 %s\n", s3api.Explain(err))
+		os.Exit(1)
+	}
+
+	render.Println("\n🔧 Testing bucket through the S3 API...")
+
+	render.Println("1. Checking credentials, bucket and region...")
+	if err := client.HeadBucket(ctx); err != nil {
+		fail("Bucket check", err)
+	}
+	render.Println("✅ Bucket exists and credentials are accepted")
+
+	testKey := fmt.Sprintf("backtide-test-%d.txt", time.Now().UnixNano())
+	testContent := fmt.Sprintf("Backtide connectivity test - %s", time.Now().Format(time.RFC3339))
+
+	render.Println("2. Testing write (PUT)...")
+	if err := client.PutObject(ctx, testKey, []byte(testContent)); err != nil {
+		fail("Write test", err)
+	}
+	render.Println("✅ Write test passed")
+
+	render.Println("3. Testing read (GET)...")
+	data, err := client.GetObject(ctx, testKey)
+	if err != nil {
+		client.DeleteObject(ctx, testKey)
+		fail("Read test", err)
 	}
+	if string(data) != testContent {
+		client.DeleteObject(ctx, testKey)
+		render.Printf("❌ Read verification failed: expected '%s', got '%s'\n", testContent, string(data))
+		os.Exit(1)
+	}
+	render.Println("✅ Read test passed")
+
+	render.Println("4. Testing delete (DELETE)...")
+	if err := client.DeleteObject(ctx, testKey); err != nil {
+		fail("Delete test", err)
+	}
+	render.Println("✅ Delete test passed")
 
-	fmt.Printf("Error: No bucket found with ID or name '%s'\n", bucketID)
-	fmt.Println("Use 'backtide s3 list' to see available buckets.")
+	render.Println("\n🎉 All API tests passed! Credentials, region and permissions are correct.")
+	render.Println("💡 Run 'sudo backtide s3 test " + bucket.ID + "' to also test the s3fs mount")
 }
 
 func printBucketConfig(bucket config.BucketConfig, usageCount int) {
-	fmt.Printf("\n📦 %s\n", bucket.Name)
+	render.Printf("\n📦 %s\n", bucket.Name)
 	if bucket.Description != "" {
-		fmt.Printf("   Description: %s\n", bucket.Description)
+		render.Printf("   Description: %s\n", bucket.Description)
 	}
-	fmt.Printf("   ID: %s\n", bucket.ID)
-	fmt.Printf("   Provider: %s\n", bucket.Provider)
-	fmt.Printf("   Bucket: %s\n", bucket.Bucket)
-	fmt.Printf("   Region: %s\n", bucket.Region)
-	fmt.Printf("   Endpoint: %s\n", func() string {
+	render.Printf("   ID: %s\n", bucket.ID)
+	render.Printf("   Provider: %s\n", bucket.Provider)
+	render.Printf("   Bucket: %s\n", bucket.Bucket)
+	render.Printf("   Region: %s\n", bucket.Region)
+	render.Printf("   Endpoint: %s\n", func() string {
 		if bucket.Endpoint == "" {
 			return "AWS default"
 		}
-		return bucket.Endpoint
+		return secretURL(bucket.Endpoint)
 	}())
-	fmt.Printf("   Mount Point: %s\n", bucket.MountPoint)
-	fmt.Printf("   Path Style: %v\n", bucket.UsePathStyle)
-	fmt.Printf("   Access Key: %s\n", maskString(bucket.AccessKey))
-	fmt.Printf("   Secret Key: %s\n", maskString(bucket.SecretKey))
-	fmt.Printf("   Credentials File: %s\n", getCredentialsFilePath(bucket.ID))
-	fmt.Printf("   Used by: %d job(s)\n", usageCount)
+	render.Printf("   Mount Point: %s\n", bucket.MountPoint)
+	render.Printf("   Mount: %s\n", mountMode(bucket))
+	render.Printf("   Path Style: %v\n", bucket.UsePathStyle)
+	if tls := describeTLS(bucket.TLS); tls != "" {
+		render.Printf("   TLS: %s\n", tls)
+	}
+	render.Printf("   Access Key: %s\n", secretValue(bucket.AccessKey))
+	render.Printf("   Secret Key: %s\n", secretValue(bucket.SecretKey))
+	render.Printf("   Credentials File: %s\n", getCredentialsFilePath(bucket.ID))
+	render.Printf("   Used by: %d job(s)\n", usageCount)
 }
 
-func configureBucketForAdd() config.BucketConfig {
-	reader := bufio.NewReader(os.Stdin)
-	bucket := config.BucketConfig{}
-
-	// Generate a unique ID
-	bucket.ID = generateBucketID()
-
-	fmt.Print("Bucket name (display name): ")
-	name, _ := reader.ReadString('\n')
-	bucket.Name = strings.TrimSpace(name)
-
-	fmt.Print("Description (optional): ")
-	desc, _ := reader.ReadString('\n')
-	bucket.Description = strings.TrimSpace(desc)
-
-	// Provider name
-	fmt.Print("Provider name (e.g., AWS S3, Backblaze B2, MinIO): ")
-	provider, _ := reader.ReadString('\n')
-	bucket.Provider = strings.TrimSpace(provider)
-
-	// Bucket name
-	fmt.Print("S3 Bucket name: ")
-	s3Bucket, _ := reader.ReadString('\n')
-	bucket.Bucket = strings.TrimSpace(s3Bucket)
-
-	// Region
-	fmt.Print("Region (leave empty if not applicable): ")
-	region, _ := reader.ReadString('\n')
-	bucket.Region = strings.TrimSpace(region)
-
-	// Path style
-	fmt.Print("Use path-style endpoints? (y/N): ")
-	pathStyleInput, _ := reader.ReadString('\n')
-
-	// Endpoint
-	fmt.Print("Endpoint URL (leave empty for AWS default): ")
-	endpointInput, _ := reader.ReadString('\n')
-	bucket.Endpoint = strings.TrimSpace(endpointInput)
-	if strings.ToLower(strings.TrimSpace(pathStyleInput)) == "y" {
-		bucket.UsePathStyle = true
-	} else {
-		bucket.UsePathStyle = false
+// describeTLS summarizes custom TLS settings, or returns "" for the defaults
+func describeTLS(tls config.BucketTLS) string {
+	var parts []string
+	if tls.CAFile != "" {
+		parts = append(parts, "CA "+tls.CAFile)
 	}
+	if tls.CertFile != "" {
+		parts = append(parts, "client certificate "+tls.CertFile)
+	}
+	if tls.InsecureSkipVerify {
+		parts = append(parts, "⚠️  certificate verification disabled")
+	}
+	return strings.Join(parts, ", ")
+}
 
-	// Mount point
-	fmt.Print("Mount point (e.g., /mnt/s3backup): ")
-	mountPoint, _ := reader.ReadString('\n')
-	bucket.MountPoint = strings.TrimSpace(mountPoint)
-
-	// Access key
-	fmt.Print("Access Key: ")
-	accessKey, _ := reader.ReadString('\n')
-	bucket.AccessKey = strings.TrimSpace(accessKey)
-
-	// Secret key
-	fmt.Print("Secret Key: ")
-	secretKey, _ := reader.ReadString('\n')
-	bucket.SecretKey = strings.TrimSpace(secretKey)
+func runS3Fstab(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
 
-	fmt.Printf("✅ S3 bucket configuration for %s completed!\n", bucket.Provider)
+	failed := false
+	for _, bucket := range cfg.Buckets {
+		if bucket.MountOnDemand {
+			render.Printf("⏭️  %s: mounted on demand, no fstab entry\n", bucket.Name)
+			continue
+		}
+		if dryRun {
+			render.Printf("DRY RUN: Would update the fstab entry for %s\n", bucket.Name)
+			continue
+		}
+		if err := s3fs.NewS3FSManager(bucket).AddToFstab(); err != nil {
+			render.Printf("❌ %s: %v\n", bucket.Name, err)
+			failed = true
+		}
+	}
 
-	return bucket
+	if failed {
+		render.Println("💡 Updating /etc/fstab requires root: sudo backtide s3 fstab")
+		os.Exit(1)
+	}
 }
 
-func generateBucketID() string {
-	// Simple ID generation - in production you might want something more robust
-	return fmt.Sprintf("bucket-%d", time.Now().Unix())
+// confirmOrCancel asks a yes/no question defaulting to no and reports a
+// cancelled operation unless it is answered yes
+func confirmOrCancel(p *prompt.Prompter, question string) bool {
+	ok, err := p.Confirm(question, false)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+	}
+	if !ok {
+		render.Println("Operation cancelled.")
+	}
+	return ok
 }
 
 // reloadSystemdDaemon reloads the systemd daemon to pick up fstab changes
@@ -546,16 +731,22 @@ func reloadSystemdDaemon() error {
 	return nil
 }
 
+// mountMode describes how a bucket is mounted
+func mountMode(bucket config.BucketConfig) string {
+	if bucket.MountOnDemand {
+		return "on demand (only while backups and restores run)"
+	}
+	return "permanent (/etc/fstab)"
+}
+
 // getCredentialsFilePath returns the path to the credentials file for a bucket
 func getCredentialsFilePath(bucketID string) string {
-	// Use system-wide credentials directory in /etc/backtide
-	return filepath.Join("/etc", "backtide", "s3-credentials", fmt.Sprintf("passwd-s3fs-%s", bucketID))
+	return paths.CredentialsFile(bucketID)
 }
 
 // cleanupBucketCredentials removes the credentials file for a bucket
 func cleanupBucketCredentials(bucket config.BucketConfig) error {
-	// Use system-wide credentials directory in /etc/backtide
-	credsFile := filepath.Join("/etc", "backtide", "s3-credentials", fmt.Sprintf("passwd-s3fs-%s", bucket.ID))
+	credsFile := paths.CredentialsFile(bucket.ID)
 
 	// Check if file exists before trying to remove
 	if _, err := os.Stat(credsFile); err == nil {
@@ -593,107 +784,107 @@ func removeMountPointIfEmpty(mountPoint string) error {
 }
 
 func testBucket(bucket config.BucketConfig) {
-	fmt.Printf("Testing connectivity to: %s\n", bucket.Bucket)
-	fmt.Printf("Provider: %s\n", bucket.Provider)
-	fmt.Printf("Endpoint: %s\n", func() string {
+	render.Printf("Testing connectivity to: %s\n", bucket.Bucket)
+	render.Printf("Provider: %s\n", bucket.Provider)
+	render.Printf("Endpoint: %s\n", func() string {
 		if bucket.Endpoint == "" {
 			return "AWS default"
 		}
 		return bucket.Endpoint
 	}())
-	fmt.Printf("Mount Point: %s\n", bucket.MountPoint)
+	render.Printf("Mount Point: %s\n", bucket.MountPoint)
 
-	fmt.Println("\n🔧 Testing S3 bucket connectivity...")
+	render.Println("\n🔧 Testing S3 bucket connectivity...")
 
 	// Create S3FS manager
 	s3fsManager := s3fs.NewS3FSManager(bucket)
 
 	// Check if s3fs is installed
-	fmt.Println("1. Checking if s3fs is installed...")
+	render.Println("1. Checking if s3fs is installed...")
 	if !s3fsManager.IsS3FSInstalled() {
-		fmt.Println("❌ s3fs is not installed")
-		fmt.Println("💡 Install it with:")
-		fmt.Println("   Ubuntu/Debian: sudo apt-get install s3fs")
-		fmt.Println("   CentOS/RHEL: sudo yum install s3fs-fuse")
-		fmt.Println("   Fedora: sudo dnf install s3fs-fuse")
-		fmt.Println("   openSUSE: sudo zypper install s3fs-fuse")
-		fmt.Println("   Alpine: sudo apk add s3fs-fuse")
+		render.Println("❌ s3fs is not installed")
+		render.Println("💡 Install it with:")
+		render.Println("   Ubuntu/Debian: sudo apt-get install s3fs")
+		render.Println("   CentOS/RHEL: sudo yum install s3fs-fuse")
+		render.Println("   Fedora: sudo dnf install s3fs-fuse")
+		render.Println("   openSUSE: sudo zypper install s3fs-fuse")
+		render.Println("   Alpine: sudo apk add s3fs-fuse")
 		return
 	}
-	fmt.Println("✅ s3fs is installed")
+	render.Println("✅ s3fs is installed")
 
 	// Setup S3FS (create mount point and credentials)
-	fmt.Println("2. Setting up S3FS configuration...")
+	render.Println("2. Setting up S3FS configuration...")
 	if err := s3fsManager.SetupS3FS(); err != nil {
-		fmt.Printf("❌ Setup failed: %v\n", err)
-		fmt.Println("💡 You may need to run with sudo for system configuration")
-		fmt.Println("   Try: sudo backtide s3 test " + bucket.ID)
+		render.Printf("❌ Setup failed: %v\n", err)
+		render.Println("💡 You may need to run with sudo for system configuration")
+		render.Println("   Try: sudo backtide s3 test " + bucket.ID)
 		return
 	}
-	fmt.Println("✅ S3FS setup completed")
-	fmt.Println("   Credentials stored in: /etc/backtide/s3-credentials/")
+	render.Println("✅ S3FS setup completed")
+	render.Println("   Credentials stored in: /etc/backtide/s3-credentials/")
 
 	// Mount the bucket
-	fmt.Println("3. Mounting S3 bucket...")
+	render.Println("3. Mounting S3 bucket...")
 	if err := s3fsManager.MountS3FS(); err != nil {
-		fmt.Printf("❌ Mount failed: %v\n", err)
-		fmt.Println("💡 Check your credentials and network connectivity")
-		fmt.Println("   Also ensure you have proper permissions for system directories")
+		render.Printf("❌ Mount failed: %v\n", err)
+		render.Println("💡 Check your credentials and network connectivity")
+		render.Println("   Also ensure you have proper permissions for system directories")
 		return
 	}
-	fmt.Println("✅ S3 bucket mounted successfully")
+	render.Println("✅ S3 bucket mounted successfully")
 
 	// Test file operations
-	fmt.Println("4. Testing file operations...")
+	render.Println("4. Testing file operations...")
 	testFilePath := filepath.Join(bucket.MountPoint, "backtide-test-file.txt")
 	testContent := fmt.Sprintf("Backtide connectivity test - %s", time.Now().Format(time.RFC3339))
 
 	// Write test file
 	if err := os.WriteFile(testFilePath, []byte(testContent), 0644); err != nil {
-		fmt.Printf("❌ Write test failed: %v\n", err)
+		render.Printf("❌ Write test failed: %v\n", err)
 		s3fsManager.UnmountS3FS()
 		return
 	}
-	fmt.Println("✅ Write test passed")
+	render.Println("✅ Write test passed")
 
 	// Read test file
 	readContent, err := os.ReadFile(testFilePath)
 	if err != nil {
-		fmt.Printf("❌ Read test failed: %v\n", err)
+		render.Printf("❌ Read test failed: %v\n", err)
 		s3fsManager.UnmountS3FS()
 		return
 	}
 
 	if string(readContent) != testContent {
-		fmt.Printf("❌ Read verification failed: expected '%s', got '%s'\n", testContent, string(readContent))
+		render.Printf("❌ Read verification failed: expected '%s', got '%s'\n", testContent, string(readContent))
 		s3fsManager.UnmountS3FS()
 		return
 	}
-	fmt.Println("✅ Read test passed")
+	render.Println("✅ Read test passed")
 
 	// Delete test file
 	if err := os.Remove(testFilePath); err != nil {
-		fmt.Printf("❌ Cleanup failed: %v\n", err)
+		render.Printf("❌ Cleanup failed: %v\n", err)
 		s3fsManager.UnmountS3FS()
 		return
 	}
-	fmt.Println("✅ Cleanup test passed")
+	render.Println("✅ Cleanup test passed")
 
 	// Unmount
-	fmt.Println("5. Unmounting test bucket...")
+	render.Println("5. Unmounting test bucket...")
 	if err := s3fsManager.UnmountS3FS(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not unmount bucket: %v\n", err)
-		fmt.Println("   You may need to unmount manually with: fusermount -u " + bucket.MountPoint)
+		render.Printf("⚠️  Warning: Could not unmount bucket: %v\n", err)
+		render.Println("   You may need to unmount manually with: fusermount -u " + bucket.MountPoint)
 	} else {
-		fmt.Println("✅ Bucket unmounted successfully")
+		render.Println("✅ Bucket unmounted successfully")
 	}
 
 	// Note: Production credentials are preserved for ongoing use
-	fmt.Println("6. Preserving production credentials...")
-	fmt.Println("✅ Production credentials preserved for ongoing use")
+	render.Println("6. Preserving production credentials...")
+	render.Println("✅ Production credentials preserved for ongoing use")
 
-	fmt.Println("\n🎉 All tests passed! S3 bucket connectivity is working correctly.")
-	fmt.Printf("📊 Summary: %s bucket '%s' is accessible and functional\n", bucket.Provider, bucket.Bucket)
-	fmt.Println("💡 Configuration stored in: /etc/backtide/")
-	fmt.Println("💡 Credentials stored in: /etc/backtide/s3-credentials/")
+	render.Println("\n🎉 All tests passed! S3 bucket connectivity is working correctly.")
+	render.Printf("📊 Summary: %s bucket '%s' is accessible and functional\n", bucket.Provider, bucket.Bucket)
+	render.Println("💡 Configuration stored in: /etc/backtide/")
+	render.Println("💡 Credentials stored in: /etc/backtide/s3-credentials/")
 }
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3fs"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	s3UnmountForce bool
+)
+
+// s3StatusCmd represents the s3 status command
+var s3StatusCmd = &cobra.Command{
+	Use:   "status [bucket-id]",
+	Short: "Show mount state and health of buckets",
+	Long: `Show each bucket's mount state, free space, active users and the last
+backup successfully written to it.
+
+Examples:
+  backtide s3 status
+  backtide s3 status bucket-1234567890`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runS3Status,
+}
+
+// s3MountCmd represents the s3 mount command
+var s3MountCmd = &cobra.Command{
+	Use:   "mount <bucket-id>",
+	Short: "Mount a bucket",
+	Long: `Mount a configured bucket at its mount point, remounting it if the existing
+mount is broken. On-demand buckets mounted this way are unmounted again after
+the next backup or restore that uses them.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runS3Mount,
+}
+
+// s3UnmountCmd represents the s3 unmount command
+var s3UnmountCmd = &cobra.Command{
+	Use:   "unmount <bucket-id>",
+	Short: "Unmount a bucket",
+	Long: `Unmount a configured bucket. Refuses while a backup or restore is using an
+on-demand bucket unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runS3Unmount,
+}
+
+func init() {
+	s3Cmd.AddCommand(s3StatusCmd)
+	s3Cmd.AddCommand(s3MountCmd)
+	s3Cmd.AddCommand(s3UnmountCmd)
+
+	s3UnmountCmd.Flags().BoolVarP(&s3UnmountForce, "force", "f", false, "unmount even if runs are using the bucket")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(s3StatusCmd)
+}
+
+// findBucket finds a bucket by ID or name
+func findBucket(cfg *config.BackupConfig, idOrName string) *config.BucketConfig {
+	for i, bucket := range cfg.Buckets {
+		if bucket.ID == idOrName || bucket.Name == idOrName {
+			return &cfg.Buckets[i]
+		}
+	}
+	return nil
+}
+
+// loadBucketArg loads the configuration and the bucket named by the first argument, exiting if missing
+func loadBucketArg(args []string) *config.BucketConfig {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	bucket := findBucket(cfg, args[0])
+	if bucket == nil {
+		render.Printf("Error: No bucket found with ID or name '%s'\n", args[0])
+		render.Println("Use 'backtide s3 list' to see available buckets.")
+		os.Exit(1)
+	}
+	return bucket
+}
+
+func runS3Status(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	buckets := cfg.Buckets
+	if len(args) > 0 {
+		bucket := findBucket(cfg, args[0])
+		if bucket == nil {
+			render.Printf("Error: No bucket found with ID or name '%s'\n", args[0])
+			os.Exit(1)
+		}
+		buckets = []config.BucketConfig{*bucket}
+	}
+
+	if len(buckets) == 0 {
+		render.Println("No bucket configurations found.")
+		render.Println("Use 'backtide s3 add' to add a bucket configuration.")
+		return
+	}
+
+	render.Println("=== S3 Bucket Status ===")
+	unhealthy := false
+	for _, bucket := range buckets {
+		manager := s3fs.NewS3FSManager(bucket)
+
+		render.Printf("\n%s (%s)\n", bucket.Name, bucket.ID)
+		render.Printf("   Mount Point: %s\n", bucket.MountPoint)
+		render.Printf("   Mount: %s\n", mountMode(bucket))
+
+		switch {
+		case !manager.IsMounted():
+			if bucket.MountOnDemand {
+				render.Println("   State: ⏏️  not mounted (mounted on demand)")
+			} else {
+				render.Println("   State: ❌ not mounted")
+				unhealthy = true
+			}
+		default:
+			if err := manager.CheckMount(cfg.Mounts.Timeout()); err != nil {
+				render.Printf("   State: ❌ %v\n", err)
+				unhealthy = true
+			} else {
+				render.Println("   State: ✅ mounted")
+				if free, total, err := utils.GetDiskSpace(bucket.MountPoint); err == nil && total > 0 {
+					render.Printf("   Free Space: %s of %s\n", utils.FormatBytes(int64(free)), utils.FormatBytes(int64(total)))
+				}
+			}
+		}
+
+		if leases, err := state.MountLeases(bucket.ID); err == nil && len(leases) > 0 {
+			var holders []string
+			for _, lease := range leases {
+				holders = append(holders, lease.Holder)
+			}
+			render.Printf("   In Use By: %s\n", strings.Join(holders, ", "))
+		}
+
+		if write, err := state.LastBucketWrite(bucket.ID); err != nil {
+			render.Printf("   Last Write: unknown (%v)\n", err)
+		} else if write == nil {
+			render.Println("   Last Write: never")
+		} else {
+			render.Printf("   Last Write: %s (%s ago, %s by job %s)\n",
+				write.At.Format("2006-01-02 15:04:05"), utils.FormatDuration(time.Since(write.At)), write.BackupID, write.Job)
+		}
+	}
+
+	if unhealthy {
+		os.Exit(1)
+	}
+}
+
+func runS3Mount(cmd *cobra.Command, args []string) {
+	bucket := loadBucketArg(args)
+	manager := s3fs.NewS3FSManager(*bucket)
+
+	if dryRun {
+		render.Printf("DRY RUN: Would mount %s at %s\n", bucket.Name, bucket.MountPoint)
+		return
+	}
+
+	if err := manager.SetupS3FS(); err != nil {
+		render.Printf("❌ Setup failed: %v\n", err)
+		render.Println("💡 Mounting requires root: sudo backtide s3 mount " + args[0])
+		os.Exit(1)
+	}
+	if err := manager.EnsureMounted(0); err != nil {
+		render.Printf("❌ Mount failed: %v\n", err)
+		os.Exit(1)
+	}
+	render.Printf("✅ %s mounted at %s\n", bucket.Name, bucket.MountPoint)
+}
+
+func runS3Unmount(cmd *cobra.Command, args []string) {
+	bucket := loadBucketArg(args)
+	manager := s3fs.NewS3FSManager(*bucket)
+
+	if leases, err := state.MountLeases(bucket.ID); err == nil && len(leases) > 0 && !s3UnmountForce && !force {
+		var holders []string
+		for _, lease := range leases {
+			holders = append(holders, lease.Holder)
+		}
+		render.Printf("❌ %s is in use by: %s\n", bucket.Name, strings.Join(holders, ", "))
+		render.Println("💡 Use --force to unmount anyway")
+		os.Exit(1)
+	}
+
+	if dryRun {
+		render.Printf("DRY RUN: Would unmount %s from %s\n", bucket.Name, bucket.MountPoint)
+		return
+	}
+
+	if err := manager.UnmountS3FS(); err != nil {
+		render.Printf("❌ Unmount failed: %v\n", err)
+		render.Println("💡 For a hung mount try: sudo fusermount -uz " + bucket.MountPoint)
+		os.Exit(1)
+	}
+}
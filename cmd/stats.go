@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/cost"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsJob      string
+	statsRestores float64
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show storage used by each job and estimate its monthly cost",
+	Long: `Show how many backups each job keeps in each storage location and how much
+space they take, from the backup catalog, with estimated monthly costs for
+buckets:
+
+- STORAGE/MO: storing the job's backups for a month
+- EGRESS/MO: downloading the newest backup --restores times a month
+- PER BACKUP/MO: keeping one more backup, to weigh retention settings against
+
+Costs use the bucket's provider and storage_class with built-in list prices
+of AWS, Google Cloud Storage, Backblaze B2, Wasabi, Cloudflare R2 and
+DigitalOcean Spaces, which may be out of date. [[pricing]] entries in the
+configuration override them, e.g. for negotiated prices or other providers.
+Request fees, minimum storage durations and free tiers are not included.
+
+Run 'backtide catalog rebuild' first if the catalog is out of date.
+
+Examples:
+  backtide stats
+  backtide stats --job daily-backup --restores 2`,
+	Run: runStats,
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&statsJob, "job", "", "only show this job")
+	statsCmd.Flags().Float64Var(&statsRestores, "restores", 1, "full restores of the newest backup per month in the egress estimate")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(statsCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("stats", statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if statsRestores < 0 {
+		render.Println("Error: --restores cannot be negative")
+		os.Exit(1)
+	}
+	catalog, err := state.LoadCatalog()
+	if err != nil {
+		render.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var estimates []cost.Estimate
+	for _, estimate := range cost.Estimates(cfg, catalog) {
+		if statsJob == "" || estimate.Job == statsJob {
+			estimates = append(estimates, estimate)
+		}
+	}
+	if len(estimates) == 0 {
+		render.Println("No backups in the catalog.")
+		render.Println("💡 Run 'backtide catalog rebuild' to catalog existing backups")
+		return
+	}
+
+	table := render.NewTable("JOB", "STORAGE", "BACKUPS", "STORED", "STORAGE/MO", "EGRESS/MO", "PER BACKUP/MO")
+	for column := 2; column <= 6; column++ {
+		table.Right[column] = true
+	}
+	var (
+		total, totalStored int64
+		monthly            float64
+		currency           string
+		unpriced           []string
+		mixed              bool
+	)
+	for _, estimate := range estimates {
+		total += int64(estimate.Backups)
+		totalStored += estimate.Stored
+		row := []string{estimate.Job, estimate.Storage, fmt.Sprint(estimate.Backups), utils.FormatBytes(estimate.Stored)}
+		if !estimate.Priced {
+			if estimate.Storage != "local" && !slices.Contains(unpriced, estimate.Storage) {
+				unpriced = append(unpriced, estimate.Storage)
+			}
+			table.Row(append(row, "-", "-", "-")...)
+			continue
+		}
+
+		price := estimate.Price
+		egress := statsRestores * estimate.Restore()
+		table.Row(append(row, price.Format(estimate.StorageMonth()), price.Format(egress), price.Format(estimate.PerBackupMonth()))...)
+		if currency != "" && currency != price.Currency {
+			mixed = true
+		}
+		currency = price.Currency
+		monthly += estimate.StorageMonth() + egress
+	}
+	table.Print()
+
+	render.Printf("\n📊 %d backups, %s stored\n", total, utils.FormatBytes(totalStored))
+	if currency != "" && !mixed {
+		render.Printf("💰 Estimated monthly cost: %s\n", cost.Price{Currency: currency}.Format(monthly))
+	}
+	for _, storage := range unpriced {
+		render.Printf("💡 No price known for %s; set its provider or add a [[pricing]] entry\n", storage)
+	}
+}
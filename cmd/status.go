@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/schedule"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/systemd"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show scheduling and run status of backup jobs",
+	Long: `Show the current operational status of Backtide.
+
+This command shows:
+- Whether the scheduling daemon service is running
+- Health of S3 mounts checked by the daemon
+- Containers left stopped by backups that crashed
+- Each job's schedule and next scheduled run
+- Paused jobs and when the pause expires
+- Backups currently in progress
+- Whether each job's newest successful backup is within its max_age
+
+It exits with status 2 when a job's newest successful backup is older than
+its max_age. --check prints only a one-line summary with Nagios exit codes
+(0 OK, 2 CRITICAL, 3 UNKNOWN), for monitoring systems to run; 'backtide check'
+checks a single job with warning and critical thresholds and perfdata.`,
+	Run: runStatus,
+}
+
+var statusCheck bool
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusCheck, "check", false, "only check backup freshness against each job's max_age, printing one line with Nagios exit codes")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(statusCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("status", statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	configPath := getConfigPath()
+	cfg, err := config.LoadConfig(configPath)
+	if statusCheck {
+		os.Exit(checkFreshness(cfg, err))
+	}
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	render.Println("=== Backtide Status ===")
+
+	manager := systemd.NewServiceManager("backtide", "", configPath, "root")
+	if status, err := manager.GetServiceStatus(); err == nil {
+		daemonState := "❌ not running"
+		if status.IsRunning {
+			daemonState = "✅ running"
+		} else if status.LoadState == "not-found" {
+			daemonState = "❌ not installed"
+		}
+		render.Printf("Daemon: %s\n", daemonState)
+	} else {
+		render.Println("Daemon: unknown (systemd not available)")
+	}
+
+	// Mount health is tracked by the daemon
+	var daemonStatus control.DaemonStatus
+	if err := control.NewClient(state.FindSocket()).Get("/v1/status", &daemonStatus); err == nil {
+		for _, mount := range daemonStatus.Mounts {
+			if mount.Healthy {
+				render.Printf("S3 mount %s (%s): ✅ healthy", mount.Bucket, mount.MountPoint)
+			} else {
+				render.Printf("S3 mount %s (%s): ❌ %s", mount.Bucket, mount.MountPoint, mount.Error)
+			}
+			if mount.Remounts > 0 {
+				render.Printf(", remounted %d times", mount.Remounts)
+			}
+			render.Println()
+		}
+		render.Printf("Daemon runs: %d active", daemonStatus.ActiveRuns)
+		if verbose {
+			render.Printf(" (%d goroutines)", daemonStatus.Goroutines)
+		}
+		render.Println()
+		if daemonStatus.UpdateAvailable {
+			render.Printf("🚀 backtide %s is available (daemon runs %s); run 'backtide update'\n",
+				daemonStatus.LatestVersion, daemonStatus.Version)
+		}
+	}
+
+	// Containers a crashed run never restarted stay down until recovered
+	if holds, err := state.OrphanedHolds(0); err == nil {
+		for _, hold := range holds {
+			render.Printf("⚠️  Containers stopped by crashed run %s for %s: %s\n",
+				hold.RunID, utils.FormatDuration(hold.Age()), strings.Join(containerNames(hold), ", "))
+		}
+		if len(holds) > 0 {
+			render.Println("   Restart them with 'backtide containers recover'")
+		}
+	}
+
+	runs, err := state.ListRuns()
+	if err != nil {
+		render.Printf("Warning: Failed to read running backups: %v\n", err)
+	}
+	running := make(map[string]state.RunRecord)
+	for _, run := range runs {
+		running[run.JobName] = run
+	}
+	// Runs the daemon accepted but has not started yet
+	queued := make(map[string]control.RunStatus)
+	for _, run := range daemonStatus.Runs {
+		if run.State == control.RunQueued {
+			queued[run.Job] = run
+		}
+	}
+
+	if pause, paused := state.ActivePause(state.AllJobs); paused {
+		render.Printf("⏸️  All jobs paused until %s\n", pause.Until.Format("2006-01-02 15:04:05"))
+	}
+
+	if len(cfg.Jobs) == 0 {
+		render.Println("\nNo backup jobs configured.")
+		render.Println("Use 'backtide jobs add' to create backup jobs.")
+		return
+	}
+
+	// Jobs whose newest successful backup misses the max_age SLO
+	freshness := make(map[string]backup.Freshness)
+	checks, err := backup.JobFreshness(cfg.Jobs)
+	if err != nil {
+		render.Printf("Warning: Failed to check backup freshness: %v\n", err)
+	}
+	for _, check := range checks {
+		freshness[check.Job] = check
+	}
+	overdue := 0
+
+	now := time.Now()
+	for _, job := range cfg.Jobs {
+		render.Printf("\n%s\n", job.Name)
+
+		if !job.Enabled {
+			render.Println("   State: ❌ disabled")
+		} else if run, ok := running[job.Name]; ok {
+			render.Printf("   State: 🔄 running (%s, phase %s, %s)\n", run.ID, run.Phase, utils.FormatDuration(now.Sub(run.StartedAt)))
+		} else if run, ok := queued[job.Name]; ok {
+			render.Printf("   State: 🕒 queued (%s, %s)\n", run.ID, run.Trigger)
+		} else if pause, paused := state.ActivePause(job.Name); paused {
+			render.Printf("   State: ⏸️  paused until %s", pause.Until.Format("2006-01-02 15:04:05"))
+			if pause.Reason != "" {
+				render.Printf(" (%s)", pause.Reason)
+			}
+			render.Println()
+		} else {
+			render.Println("   State: ✅ idle")
+		}
+
+		if check, ok := freshness[job.Name]; ok {
+			if check.Overdue(now) {
+				overdue++
+				render.Printf("   Freshness: ❌ %s\n", check.Describe(now))
+			} else {
+				render.Printf("   Freshness: ✅ %s\n", check.Describe(now))
+			}
+		}
+
+		replicas, err := backup.ReplicationStatus(job)
+		if err != nil {
+			render.Printf("   Replication: ⚠️  %v\n", err)
+		}
+		for _, replica := range replicas {
+			switch {
+			case replica.Pending == 0 && replica.LastCopy.IsZero():
+				render.Printf("   Replica %s: ✅ up to date\n", replica.BucketID)
+			case replica.Pending == 0:
+				render.Printf("   Replica %s: ✅ up to date (last copy %s ago)\n", replica.BucketID, utils.FormatDuration(now.Sub(replica.LastCopy)))
+			case replica.LastError != "":
+				render.Printf("   Replica %s: ❌ %d backups behind, lag %s (%s)\n", replica.BucketID, replica.Pending,
+					utils.FormatDuration(replica.Lag(now)), replica.LastError)
+			default:
+				render.Printf("   Replica %s: ⏳ %d backups behind, lag %s\n", replica.BucketID, replica.Pending, utils.FormatDuration(replica.Lag(now)))
+			}
+		}
+
+		if !job.Schedule.Enabled {
+			render.Println("   Schedule: manual only")
+			continue
+		}
+
+		sched, err := schedule.Parse(job.Schedule)
+		if err != nil {
+			render.Printf("   Schedule: ⚠️  invalid (%v)\n", err)
+			continue
+		}
+		render.Printf("   Schedule: %s\n", sched.String())
+		if !sched.IsInterval() {
+			next := sched.Next(now)
+			render.Printf("   Next run: %s (in %s)\n", next.Format("2006-01-02 15:04 MST"), utils.FormatDuration(next.Sub(now)))
+		}
+	}
+
+	if overdue > 0 {
+		render.Printf("\n❌ %d job(s) without a successful backup within their max_age\n", overdue)
+		os.Exit(2)
+	}
+}
+
+// checkFreshness prints a one-line Nagios-style summary of the jobs' backup
+// freshness and returns the exit code
+func checkFreshness(cfg *config.BackupConfig, loadErr error) int {
+	if loadErr != nil {
+		render.Printf("BACKTIDE UNKNOWN - failed to load configuration: %v\n", loadErr)
+		return nagiosUnknown
+	}
+	checks, err := backup.JobFreshness(cfg.Jobs)
+	if err != nil {
+		render.Printf("BACKTIDE UNKNOWN - %v\n", err)
+		return nagiosUnknown
+	}
+	if len(checks) == 0 {
+		render.Println("BACKTIDE OK - no enabled jobs with a max_age")
+		return nagiosOK
+	}
+
+	now := time.Now()
+	var overdue []string
+	for _, check := range checks {
+		if check.Overdue(now) {
+			overdue = append(overdue, check.Job+": "+check.Describe(now))
+		}
+	}
+	if len(overdue) > 0 {
+		render.Printf("BACKTIDE CRITICAL - %d of %d job(s) overdue; %s\n", len(overdue), len(checks), strings.Join(overdue, "; "))
+		return nagiosCritical
+	}
+	render.Printf("BACKTIDE OK - %d job(s) within their max_age\n", len(checks))
+	return nagiosOK
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
 	"github.com/mitexleo/backtide/internal/systemd"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +21,9 @@ var systemdCmd = &cobra.Command{
 }
 
 func init() {
+	// Safe for read-only users
+	commands.MarkReadOnly(systemdCmd)
+
 	// Register with command registry (but keep it hidden)
 	commands.RegisterCommand("systemd", systemdCmd)
 }
@@ -33,6 +38,7 @@ func updateSystemdServiceFileOnly(configPath string) error {
 
 	// Create systemd service manager
 	manager := systemd.NewServiceManager("backtide", "", configPath, "root")
+	applyServiceConfig(manager, configPath)
 
 	// Check if service directory exists
 	systemdDir := "/etc/systemd/system"
@@ -48,8 +54,8 @@ func updateSystemdServiceFileOnly(configPath string) error {
 
 	// Only update the service file, don't restart the service
 	// This prevents hanging during updates
-	fmt.Println("📝 Systemd service file updated")
-	fmt.Println("💡 Service will use the new binary on next restart")
+	render.Println("📝 Systemd service file updated")
+	render.Println("💡 Service will use the new binary on next restart")
 
 	return nil
 }
@@ -64,6 +70,7 @@ func ensureSystemdService(configPath string) error {
 
 	// Create systemd service manager
 	manager := systemd.NewServiceManager("backtide", "", configPath, "root")
+	applyServiceConfig(manager, configPath)
 
 	// Check if service directory exists
 	systemdDir := "/etc/systemd/system"
@@ -80,27 +87,49 @@ func ensureSystemdService(configPath string) error {
 	// Check if service is currently enabled
 	if installed, _ := manager.IsServiceInstalled(); installed {
 		// Service exists, ensure it's using the latest version
-		fmt.Println("🔄 Updating systemd service to latest version...")
+		render.Println("🔄 Updating systemd service to latest version...")
 
 		// Restart service to pick up changes
 		if err := manager.StopService(); err != nil {
-			fmt.Printf("⚠️  Warning: Could not stop service: %v\n", err)
+			render.Printf("⚠️  Warning: Could not stop service: %v\n", err)
 		}
 
 		if err := manager.EnableService(); err != nil {
-			fmt.Printf("⚠️  Warning: Could not enable service: %v\n", err)
+			render.Printf("⚠️  Warning: Could not enable service: %v\n", err)
 		}
 
 		if err := manager.StartService(); err != nil {
-			fmt.Printf("⚠️  Warning: Could not start service: %v\n", err)
+			render.Printf("⚠️  Warning: Could not start service: %v\n", err)
 		}
 
-		fmt.Println("✅ Systemd service updated successfully")
+		render.Println("✅ Systemd service updated successfully")
 	}
 
 	return nil
 }
 
+// applyServiceConfig applies the [systemd] resource settings and Docker use of
+// the configuration to the daemon service, if the configuration can be loaded
+func applyServiceConfig(manager *systemd.ServiceManager, configPath string) {
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+	if cfg, err := config.LoadConfig(configPath); err == nil {
+		manager.Resources = serviceResources(cfg)
+		manager.NoDocker = !jobsUseDocker(cfg.Jobs)
+	}
+}
+
+// jobsUseDocker reports whether any enabled job stops containers or runs commands in them
+func jobsUseDocker(jobs []config.BackupJob) bool {
+	for _, job := range jobs {
+		if job.Enabled && job.UsesDocker() {
+			return true
+		}
+	}
+	return false
+}
+
 // removeSystemdService removes the systemd service (used during uninstall)
 func removeSystemdService() error {
 	// Only remove systemd service when running as root
@@ -112,11 +141,11 @@ func removeSystemdService() error {
 
 	// Stop and disable service
 	if err := manager.StopService(); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to stop service: %v\n", err)
+		render.Printf("⚠️  Warning: Failed to stop service: %v\n", err)
 	}
 
 	if err := manager.DisableService(); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to disable service: %v\n", err)
+		render.Printf("⚠️  Warning: Failed to disable service: %v\n", err)
 	}
 
 	// Remove service file
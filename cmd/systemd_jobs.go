@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/schedule"
+	"github.com/mitexleo/backtide/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+// systemdJobsCmd represents the systemd-jobs command
+var systemdJobsCmd = &cobra.Command{
+	Use:   "systemd-jobs",
+	Short: "Manage per-job systemd timers",
+	Long: `Manage one systemd service and timer per backup job as an alternative to the
+daemon's internal scheduler.
+
+Each enabled, scheduled job gets a backtide-job-<id>.timer whose OnCalendar=
+values follow the job's schedule and timezone, starting a oneshot
+backtide-job-<id>.service that runs 'backtide backup --job <name>'.
+
+Set sync_timers = true in the [systemd] section to let the daemon keep the
+timers in sync with the configuration; it then leaves scheduled runs to them.`,
+}
+
+// systemdJobsSyncCmd represents the systemd-jobs sync command
+var systemdJobsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Install and update job timers to match the configuration",
+	Long: `Reconcile the installed job units with the configuration: install units for
+new scheduled jobs, update the OnCalendar= values of changed schedules and
+remove the units of deleted or disabled jobs.
+
+Examples:
+  sudo backtide systemd-jobs sync
+  sudo backtide systemd-jobs sync --dry-run`,
+	Run: runSystemdJobsSync,
+}
+
+// systemdJobsUninstallCmd represents the systemd-jobs uninstall command
+var systemdJobsUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove all job timers and services",
+	Run:   runSystemdJobsUninstall,
+}
+
+func init() {
+	systemdJobsCmd.AddCommand(systemdJobsSyncCmd)
+	systemdJobsCmd.AddCommand(systemdJobsUninstallCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("systemd-jobs", systemdJobsCmd)
+}
+
+func runSystemdJobsSync(cmd *cobra.Command, args []string) {
+	configPath := getConfigPath()
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		render.Printf("❌ Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	desired, err := desiredJobUnits(cfg, configPath, true)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	applyJobUnitChanges(desired, "✅ Job timers are up to date")
+}
+
+func runSystemdJobsUninstall(cmd *cobra.Command, args []string) {
+	applyJobUnitChanges(map[string]string{}, "No job timers installed")
+}
+
+// applyJobUnitChanges shows how the installed job units differ from desired
+// and applies the changes unless this is a dry run
+func applyJobUnitChanges(desired map[string]string, upToDate string) {
+	changes, err := systemd.DiffJobUnits(desired)
+	if err != nil {
+		render.Printf("❌ Error reading installed units: %v\n", err)
+		os.Exit(1)
+	}
+	if len(changes) == 0 {
+		render.Println(upToDate)
+		return
+	}
+
+	printJobUnitChanges(changes)
+	if dryRun {
+		render.Println("DRY RUN: No units changed")
+		return
+	}
+
+	if os.Geteuid() != 0 {
+		render.Println("❌ Managing systemd units requires root privileges")
+		os.Exit(1)
+	}
+	if err := systemd.NewServiceManager("", "", "", "").ApplyJobUnits(desired, changes); err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	render.Printf("✅ Applied %d unit change(s)\n", len(changes))
+}
+
+// printJobUnitChanges lists unit changes one per line
+func printJobUnitChanges(changes []systemd.UnitChange) {
+	symbols := map[string]string{"added": "+", "updated": "~", "removed": "-"}
+	for _, change := range changes {
+		render.Printf("  %s %s (%s)\n", symbols[change.Action], change.File, change.Action)
+	}
+}
+
+// desiredJobUnits generates the service and timer files of all enabled,
+// scheduled jobs, keyed by file name; jobs whose schedule cannot be
+// expressed as OnCalendar= values are skipped, with a warning if verbose
+func desiredJobUnits(cfg *config.BackupConfig, configPath string, verbose bool) (map[string]string, error) {
+	binaryPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get binary path: %w", err)
+	}
+	if absPath, err := filepath.Abs(configPath); err == nil {
+		configPath = absPath
+	}
+
+	manager := systemd.NewServiceManager("", binaryPath, configPath, "root")
+	manager.Resources = serviceResources(cfg)
+	units := make(map[string]string)
+	for _, job := range cfg.Jobs {
+		if !job.Enabled || !job.Schedule.Enabled {
+			continue
+		}
+
+		sched, err := schedule.Parse(job.Schedule)
+		if err == nil {
+			var onCalendar []string
+			if onCalendar, err = sched.OnCalendar(); err == nil {
+				name := systemd.JobUnitName(cronJobKey(job))
+				manager.NoDocker = !job.UsesDocker()
+				units[name+".service"] = manager.GenerateJobServiceFile(job.Name)
+				jitter, _ := job.Schedule.JitterDuration()
+				units[name+".timer"] = manager.GenerateJobTimerFile(job.Name, onCalendar, job.Schedule.Misfire() != config.MisfireSkip, jitter)
+				continue
+			}
+		}
+		if verbose {
+			render.Printf("⚠️  Skipping job %s: %v\n", job.Name, err)
+		}
+	}
+	return units, nil
+}
+
+// serviceResources returns the [systemd] resource settings of generated services,
+// keeping the paths backups write to writable when the file system is protected
+func serviceResources(cfg *config.BackupConfig) config.SystemdConfig {
+	resources := cfg.Systemd
+	if resources.ProtectSystem == "" || resources.ProtectSystem == "false" {
+		return resources
+	}
+
+	writable := []string{paths.SystemStateDir}
+	for _, path := range []string{cfg.BackupPath, cfg.TempPath} {
+		if path != "" {
+			writable = append(writable, path)
+		}
+	}
+	for _, bucket := range cfg.Buckets {
+		if bucket.MountPoint != "" {
+			writable = append(writable, bucket.MountPoint)
+		}
+	}
+	for _, path := range writable {
+		if !slices.Contains(resources.ReadWritePaths, path) {
+			resources.ReadWritePaths = append(resources.ReadWritePaths, path)
+		}
+	}
+	return resources
+}
+
+// syncJobTimers updates the job units when the configuration changed; used by
+// the daemon when sync_timers is enabled
+func (js *JobScheduler) syncJobTimers(cfg *config.BackupConfig) {
+	desired, err := desiredJobUnits(cfg, getConfigPath(), false)
+	if err != nil {
+		render.Printf("⚠️  Could not sync job timers: %v\n", err)
+		return
+	}
+	changes, err := systemd.DiffJobUnits(desired)
+	if err != nil || len(changes) == 0 {
+		return
+	}
+
+	render.Println("🔄 Syncing job timers with configuration changes:")
+	printJobUnitChanges(changes)
+	if err := systemd.NewServiceManager("", "", "", "").ApplyJobUnits(desired, changes); err != nil {
+		render.Printf("⚠️  Could not sync job timers: %v\n", err)
+	}
+}
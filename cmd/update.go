@@ -10,8 +10,13 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/network"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
 	"github.com/spf13/cobra"
 )
 
@@ -49,14 +54,14 @@ func init() {
 }
 
 func runUpdate(cmd *cobra.Command, args []string) {
-	fmt.Println("🔍 Checking for updates...")
+	render.Println("🔍 Checking for updates...")
 
 	// Get current version
 	currentVersion := version
 	if currentVersion == "dev" {
-		fmt.Println("⚠️  You're running a development build. Update command may not work correctly.")
+		render.Println("⚠️  You're running a development build. Update command may not work correctly.")
 		if !updateForce {
-			fmt.Println("Use --force to update anyway.")
+			render.Println("Use --force to update anyway.")
 			return
 		}
 	}
@@ -64,7 +69,7 @@ func runUpdate(cmd *cobra.Command, args []string) {
 	// Check if we're running from a writable location
 	currentExec, err := os.Executable()
 	if err != nil {
-		fmt.Printf("❌ Could not determine current executable path: %v\n", err)
+		render.Printf("❌ Could not determine current executable path: %v\n", err)
 		return
 	}
 
@@ -74,11 +79,11 @@ func runUpdate(cmd *cobra.Command, args []string) {
 		// Only perform the check when not running as root
 		if os.Geteuid() != 0 {
 			if !canWriteToBinary(currentExec) {
-				fmt.Println("⚠️  Cannot update binary in current location due to permissions.")
-				fmt.Println("💡 Try one of these options:")
-				fmt.Println("   1. Run with sudo: sudo backtide update")
-				fmt.Println("   2. Install to user directory: backtide update --user")
-				fmt.Println("   3. Download manually from: https://github.com/mitexleo/backtide/releases")
+				render.Println("⚠️  Cannot update binary in current location due to permissions.")
+				render.Println("💡 Try one of these options:")
+				render.Println("   1. Run with sudo: sudo backtide update")
+				render.Println("   2. Install to user directory: backtide update --user")
+				render.Println("   3. Download manually from: https://github.com/mitexleo/backtide/releases")
 				return
 			}
 		}
@@ -88,56 +93,70 @@ func runUpdate(cmd *cobra.Command, args []string) {
 	if updateUser {
 		userBinDir, err := getUserBinaryDir()
 		if err != nil {
-			fmt.Printf("❌ Cannot determine user binary directory: %v\n", err)
+			render.Printf("❌ Cannot determine user binary directory: %v\n", err)
 			return
 		}
 		currentExec = filepath.Join(userBinDir, "backtide")
-		fmt.Printf("📁 Will install to user directory: %s\n", userBinDir)
+		render.Printf("📁 Will install to user directory: %s\n", userBinDir)
 	}
 
-	// Get latest release info
-	latestRelease, err := getLatestRelease()
+	// Outbound requests follow the [network] proxy and CA settings
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	client, err := network.Client(cfg.Network, 0)
+	if err != nil {
+		render.Printf("❌ %v\n", err)
+		return
+	}
+
+	// Get latest release info, remembering it for the daemon status
+	latestRelease, err := getLatestRelease(client)
+	if os.Geteuid() == 0 && err == nil {
+		state.SaveUpdateCheck(state.UpdateCheck{LatestVersion: latestRelease.Version, CheckedAt: time.Now()})
+	}
 	if err != nil {
 		// Check if error is due to no releases available
 		if strings.Contains(err.Error(), "could not find download URL") ||
 			strings.Contains(err.Error(), "could not find version") ||
 			strings.Contains(err.Error(), "no releases available") {
-			fmt.Println("❌ No releases available for update.")
-			fmt.Println("   Visit https://github.com/mitexleo/backtide/releases")
-			fmt.Println("   Or build from source: git clone https://github.com/mitexleo/backtide")
+			render.Println("❌ No releases available for update.")
+			render.Println("   Visit https://github.com/mitexleo/backtide/releases")
+			render.Println("   Or build from source: git clone https://github.com/mitexleo/backtide")
 		} else {
-			fmt.Printf("❌ Failed to check for updates: %v\n", err)
+			render.Printf("❌ Failed to check for updates: %v\n", err)
 		}
 		return
 	}
 
-	fmt.Printf("📦 Current version: %s\n", currentVersion)
-	fmt.Printf("🚀 Latest version: %s\n", latestRelease.Version)
+	render.Printf("📦 Current version: %s\n", currentVersion)
+	render.Printf("🚀 Latest version: %s\n", latestRelease.Version)
 
 	if currentVersion == latestRelease.Version && !updateForce {
-		fmt.Println("✅ You're already on the latest version!")
+		render.Println("✅ You're already on the latest version!")
 		return
 	}
 
 	if updateDryRun {
-		fmt.Printf("📋 Dry run: Would update from %s to %s\n", currentVersion, latestRelease.Version)
-		fmt.Printf("📋 Would download: %s\n", latestRelease.DownloadURL)
+		render.Printf("📋 Dry run: Would update from %s to %s\n", currentVersion, latestRelease.Version)
+		render.Printf("📋 Would download: %s\n", latestRelease.DownloadURL)
 		return
 	}
 
-	fmt.Printf("⬇️  Downloading Backtide %s...\n", latestRelease.Version)
+	render.Printf("⬇️  Downloading Backtide %s...\n", latestRelease.Version)
 
 	// Download the new binary
-	tempFile, err := downloadBinary(latestRelease.DownloadURL)
+	tempFile, err := downloadBinary(client, latestRelease.DownloadURL)
 	if err != nil {
-		fmt.Printf("❌ Download failed: %v\n", err)
+		render.Printf("❌ Download failed: %v\n", err)
 		return
 	}
 	defer os.Remove(tempFile)
 
 	// Verify the downloaded binary works
 	if err := verifyBinary(tempFile, latestRelease.Version); err != nil {
-		fmt.Printf("❌ Downloaded binary verification failed: %v\n", err)
+		render.Printf("❌ Downloaded binary verification failed: %v\n", err)
 		return
 	}
 
@@ -147,7 +166,7 @@ func runUpdate(cmd *cobra.Command, args []string) {
 	if updateUser {
 		userBinDir := filepath.Dir(currentExec)
 		if err := os.MkdirAll(userBinDir, 0755); err != nil {
-			fmt.Printf("❌ Cannot create user binary directory: %v\n", err)
+			render.Printf("❌ Cannot create user binary directory: %v\n", err)
 			return
 		}
 	}
@@ -156,37 +175,37 @@ func runUpdate(cmd *cobra.Command, args []string) {
 	if err := replaceBinary(currentExec, tempFile); err != nil {
 		// Check for specific error types to provide better user guidance
 		if strings.Contains(err.Error(), "text file busy") {
-			fmt.Printf("❌ Update failed: %v\n", err)
-			fmt.Println("💡 The binary is currently running. Please:")
-			fmt.Println("   - Stop any running backtide processes")
-			fmt.Println("   - Close any terminals using backtide")
-			fmt.Println("   - Try the update again")
+			render.Printf("❌ Update failed: %v\n", err)
+			render.Println("💡 The binary is currently running. Please:")
+			render.Println("   - Stop any running backtide processes")
+			render.Println("   - Close any terminals using backtide")
+			render.Println("   - Try the update again")
 		} else {
-			fmt.Printf("❌ Update failed: %v\n", err)
+			render.Printf("❌ Update failed: %v\n", err)
 		}
 		return
 	}
 
 	// For user installation, provide instructions
 	if updateUser {
-		fmt.Println("💡 User installation complete! Make sure your PATH includes:")
-		fmt.Printf("   %s\n", filepath.Dir(currentExec))
-		fmt.Println("   You may need to restart your shell or add this to your shell profile.")
+		render.Println("💡 User installation complete! Make sure your PATH includes:")
+		render.Printf("   %s\n", filepath.Dir(currentExec))
+		render.Println("   You may need to restart your shell or add this to your shell profile.")
 	} else {
 		// System installation: update systemd service file without restarting service
 		// This prevents hanging during update - service will use new binary on next restart
-		fmt.Println("📝 Updating systemd service file...")
+		render.Println("📝 Updating systemd service file...")
 		if err := updateSystemdServiceFileOnly(""); err != nil {
-			fmt.Printf("⚠️  Warning: Could not update systemd service file: %v\n", err)
+			render.Printf("⚠️  Warning: Could not update systemd service file: %v\n", err)
 		} else {
-			fmt.Println("✅ Systemd service file updated (service will use new binary on next restart)")
+			render.Println("✅ Systemd service file updated (service will use new binary on next restart)")
 		}
 	}
 
-	fmt.Printf("✅ Successfully updated Backtide from %s to %s!\n", currentVersion, latestRelease.Version)
+	render.Printf("✅ Successfully updated Backtide from %s to %s!\n", currentVersion, latestRelease.Version)
 
-	fmt.Println("💡 The update is complete. You may need to restart your shell or terminal session.")
-	fmt.Println("   Run 'backtide version' to verify the new version is active.")
+	render.Println("💡 The update is complete. You may need to restart your shell or terminal session.")
+	render.Println("   Run 'backtide version' to verify the new version is active.")
 }
 
 // ReleaseInfo holds information about a GitHub release
@@ -206,11 +225,10 @@ type GitHubRelease struct {
 }
 
 // getLatestRelease fetches the latest release information from GitHub
-func getLatestRelease() (*ReleaseInfo, error) {
+func getLatestRelease(client *http.Client) (*ReleaseInfo, error) {
 	// GitHub API URL for latest release
 	apiURL := "https://api.github.com/repos/mitexleo/backtide/releases/latest"
 
-	client := &http.Client{}
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
 		return nil, err
@@ -312,8 +330,8 @@ func getBinaryNameForPlatform() string {
 }
 
 // downloadBinary downloads the binary to a temporary file
-func downloadBinary(url string) (string, error) {
-	resp, err := http.Get(url)
+func downloadBinary(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// uploadsCmd represents the uploads command
+var uploadsCmd = &cobra.Command{
+	Use:   "uploads",
+	Short: "Show, retry or cancel uploads of backups in the outbox",
+	Long: `Jobs with forward = true in [jobs.storage] write S3 backups to the outbox
+on local disk and finish without waiting for the bucket. The daemon uploads
+queued backups in the background, retrying failed uploads every
+retry_interval in [outbox], and 'backtide backup' run without the daemon
+uploads them once its jobs are done. An interrupted upload resumes with the
+files it had not copied yet.
+
+Old backups of a job are cleaned up on the bucket after its new backup is
+uploaded.`,
+}
+
+// uploadsListCmd represents the uploads list command
+var uploadsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pending, uploading and failed uploads",
+	Run:   runUploadsList,
+}
+
+// uploadsRetryCmd represents the uploads retry command
+var uploadsRetryCmd = &cobra.Command{
+	Use:   "retry [backup-id...]",
+	Short: "Retry failed uploads now",
+	Long: `Retry the uploads of the given backups, or of all failed uploads, without
+waiting for the retry interval. The daemon starts the uploads in the
+background; without a daemon they run in this process.
+
+Examples:
+  backtide uploads retry
+  backtide uploads retry backup-20241201-143000-daily-3f9a1c`,
+	Run: runUploadsRetry,
+}
+
+// uploadsCancelCmd represents the uploads cancel command
+var uploadsCancelCmd = &cobra.Command{
+	Use:   "cancel <backup-id>",
+	Short: "Remove a backup from the upload queue",
+	Long: `Remove a backup from the upload queue. An upload in progress stops before
+its next file and what it copied to the bucket is removed. The backup itself
+stays in the outbox directory as a local backup.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runUploadsCancel,
+}
+
+func init() {
+	uploadsCmd.AddCommand(uploadsListCmd)
+	uploadsCmd.AddCommand(uploadsRetryCmd)
+	uploadsCmd.AddCommand(uploadsCancelCmd)
+
+	// Safe for read-only users
+	commands.MarkReadOnly(uploadsCmd, uploadsListCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("uploads", uploadsCmd)
+}
+
+func runUploadsList(cmd *cobra.Command, args []string) {
+	var queue []control.UploadStatus
+	if err := control.NewClient(state.FindSocket()).Get("/v1/uploads", &queue); err != nil {
+		cfg, err := config.LoadConfig(getConfigPath())
+		if err != nil {
+			render.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if queue, err = backup.UploadQueue(*cfg); err != nil {
+			render.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(queue) == 0 {
+		render.Println("No backups are waiting to be uploaded.")
+		return
+	}
+
+	now := time.Now()
+	var total int64
+	failed := 0
+	table := render.NewTable("BACKUP", "JOB", "BUCKET", "SIZE", "STATE", "QUEUED", "ATTEMPTS", "DETAILS")
+	table.Right[3] = true
+	table.Right[6] = true
+	for _, upload := range queue {
+		details := "-"
+		switch upload.State {
+		case state.UploadUploading:
+			details = fmt.Sprintf("started %s ago", utils.FormatDuration(now.Sub(upload.StartedAt)))
+		case state.UploadFailed:
+			failed++
+			details = upload.Error
+			if upload.NextAttempt.After(now) {
+				details = fmt.Sprintf("retry in %s: %s", utils.FormatDuration(upload.NextAttempt.Sub(now)), upload.Error)
+			}
+		}
+		table.Row(upload.BackupID, upload.Job, upload.BucketID, utils.FormatBytes(upload.Size), render.Status(upload.State),
+			utils.FormatDuration(now.Sub(upload.QueuedAt))+" ago", strconv.Itoa(upload.Attempts), details)
+		total += upload.Size
+	}
+	table.Print()
+	render.Printf("\n📊 %d backups (%s) waiting to be uploaded, %d failed\n", len(queue), utils.FormatBytes(total), failed)
+	if failed > 0 {
+		render.Println("💡 Retry now with: backtide uploads retry")
+	}
+}
+
+func runUploadsRetry(cmd *cobra.Command, args []string) {
+	if client := control.NewClient(state.FindSocket()); client.Available() {
+		var retried control.UploadsRequest
+		if err := client.Post("/v1/uploads/retry", control.UploadsRequest{BackupIDs: args}, &retried); err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		render.Printf("✅ The daemon is retrying %d uploads\n", len(retried.BackupIDs))
+		return
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := backup.RetryUploads(args); err != nil {
+		render.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	uploaded, err := backup.NewBackupRunner(*cfg).UploadOutbox(ctx, false)
+	if err != nil {
+		render.Printf("❌ Uploaded %d backups; the rest stay in the outbox: %v\n", uploaded, err)
+		os.Exit(1)
+	}
+	render.Printf("✅ Uploaded %d backups\n", uploaded)
+}
+
+func runUploadsCancel(cmd *cobra.Command, args []string) {
+	var cancelled control.UploadStatus
+	if client := control.NewClient(state.FindSocket()); client.Available() {
+		if err := client.Post("/v1/uploads/"+args[0]+"/cancel", nil, &cancelled); err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		upload, err := state.RemoveUpload(args[0])
+		if err != nil {
+			render.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		cancelled = control.UploadStatus{BackupID: upload.BackupID, BucketID: upload.BucketID}
+	}
+	render.Printf("✅ Cancelled the upload of %s to bucket %s; the backup stays in the outbox\n", cancelled.BackupID, cancelled.BucketID)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyJobName string
+	verifyAll     bool
+	verifyCommand string
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [backup-id]",
+	Short: "Check that a backup is usable by the application",
+	Long: `Restore a backup into a scratch directory and run the job's verify_command
+against it, so "backup succeeded" also means "the application can use the data".
+
+The command runs with sh in the scratch directory. BACKTIDE_RESTORE_DIR,
+BACKTIDE_BACKUP_ID and BACKTIDE_JOB tell it what was restored; each backup
+directory is restored to $BACKTIDE_RESTORE_DIR/<directory-name>. The scratch
+directory is removed afterwards and the live filesystem is not touched.
+
+Without a backup ID the newest backup of the job is verified.
+
+Example job configuration:
+  [[jobs]]
+  name = "postgres"
+  verify_command = "pg_verifybackup \"$BACKTIDE_RESTORE_DIR/basebackup\""
+
+Examples:
+  backtide verify --job postgres
+  backtide verify --job postgres backup-20241201-143000-postgres-1a2b3c
+  backtide verify --all
+  backtide verify --job app --command 'docker run --rm -v "$BACKTIDE_RESTORE_DIR:/data" app:latest --check /data'`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().StringVarP(&verifyJobName, "job", "j", "", "verify a backup of this job")
+	verifyCmd.Flags().BoolVarP(&verifyAll, "all", "a", false, "verify the newest backup of every job with a verify_command")
+	verifyCmd.Flags().StringVar(&verifyCommand, "command", "", "run this command instead of the job's verify_command")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(verifyCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("verify", verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	if verifyAll && (verifyJobName != "" || len(args) > 0 || verifyCommand != "") {
+		render.Println("Error: --all verifies the newest backup of each job with its own verify_command")
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		render.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	var jobNames []string
+	switch {
+	case verifyAll:
+		for _, job := range cfg.Jobs {
+			if job.VerifyCommand != "" {
+				jobNames = append(jobNames, job.Name)
+			}
+		}
+		if len(jobNames) == 0 {
+			render.Println("No jobs have a verify_command configured.")
+			return
+		}
+	case verifyJobName != "":
+		jobNames = []string{verifyJobName}
+	case len(cfg.Jobs) == 1:
+		jobNames = []string{cfg.Jobs[0].Name}
+	default:
+		render.Println("Error: specify the job with --job or use --all")
+		os.Exit(1)
+	}
+
+	backupID := ""
+	if len(args) > 0 {
+		backupID = args[0]
+	}
+
+	if dryRun {
+		for _, name := range jobNames {
+			render.Printf("DRY RUN: Would restore a backup of %s into a scratch directory and run its verify command\n", name)
+		}
+		return
+	}
+
+	// Set up signal handling for graceful cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-signalChan
+		render.Println("\n🛑 Received interrupt signal, cancelling verification...")
+		cancel()
+	}()
+
+	runner := backup.NewBackupRunner(*cfg)
+	failed := 0
+	for _, name := range jobNames {
+		render.Printf("\n=== Verifying %s ===\n", name)
+		metadata, err := runner.VerifyBackup(ctx, name, backupID, verifyCommand)
+
+		target := name
+		if metadata != nil {
+			target = metadata.ID
+		}
+		audit.RecordResult("verify", target, nil, err)
+
+		if err != nil {
+			render.Printf("❌ Verification of %s failed: %v\n", target, err)
+			failed++
+			continue
+		}
+		render.Printf("✅ Backup %s is usable\n", metadata.ID)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyAgentBuckets []string
+	verifyAgentSample  int
+	verifyAgentLast    bool
+)
+
+// verifyAgentCmd represents the verify-agent command
+var verifyAgentCmd = &cobra.Command{
+	Use:   "verify-agent",
+	Short: "Check the backups in buckets independently of the hosts writing them",
+	Long: `Check the backups stored in buckets from a separate host, as independent
+assurance that the hosts writing them are not wrong about their health.
+
+The check uses the S3 API with the bucket's access keys, which only need
+permission to list and read objects, and never mounts the bucket. It reads the
+metadata of every backup of every host, checks that the archives, indexes and
+manifests it names exist, and downloads a random sample of archives to compare
+with the checksums recorded when they were written. With max_age, a host whose
+newest backup is older also fails the check.
+
+Configure the buckets in [verify_agent] and the daemon checks them at the
+interval, logging the result and telling the notifier plugins about problems.
+This command runs a check now; it exits with status 1 if problems are found.
+
+Example configuration:
+  [verify_agent]
+  buckets = ["offsite"]
+  interval = "24h"
+  sample = 3
+  max_age = "26h"
+  plugins = ["chat"]
+
+Examples:
+  backtide verify-agent
+  backtide verify-agent --bucket offsite --sample 10
+  backtide verify-agent --last`,
+	Args: cobra.NoArgs,
+	Run:  runVerifyAgent,
+}
+
+func init() {
+	verifyAgentCmd.Flags().StringSliceVarP(&verifyAgentBuckets, "bucket", "b", nil, "check this bucket instead of the configured ones (repeatable)")
+	verifyAgentCmd.Flags().IntVar(&verifyAgentSample, "sample", 0, "archives to download and checksum (default: the configured sample)")
+	verifyAgentCmd.Flags().BoolVar(&verifyAgentLast, "last", false, "show the result of the daemon's last check")
+
+	// Safe for read-only users
+	commands.MarkReadOnly(verifyAgentCmd)
+
+	// Register with command registry
+	commands.RegisterCommand("verify-agent", verifyAgentCmd)
+}
+
+func runVerifyAgent(cmd *cobra.Command, args []string) {
+	if verifyAgentLast {
+		showLastVerifyAgentCheck()
+		return
+	}
+
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	agent := cfg.VerifyAgent
+	if len(verifyAgentBuckets) > 0 {
+		agent.Buckets = nil
+		for _, idOrName := range verifyAgentBuckets {
+			bucket := findBucket(cfg, idOrName)
+			if bucket == nil {
+				fmt.Fprintf(os.Stderr, "Error: bucket not found: %s\n", idOrName)
+				os.Exit(1)
+			}
+			agent.Buckets = append(agent.Buckets, bucket.ID)
+		}
+	}
+	if verifyAgentSample > 0 {
+		agent.Sample = verifyAgentSample
+	}
+	if !agent.Enabled() {
+		fmt.Fprintf(os.Stderr, "Error: no buckets to check; set [verify_agent] buckets or use --bucket\n")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	check := checkBuckets(ctx, cfg, agent)
+	if len(check.Problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkBuckets checks the verify agent's buckets, printing the results
+func checkBuckets(ctx context.Context, cfg *config.BackupConfig, agent config.VerifyAgentConfig) state.VerifyAgentCheck {
+	result := state.VerifyAgentCheck{CheckedAt: time.Now()}
+	for _, id := range agent.Buckets {
+		bucket := findBucket(cfg, id)
+		if bucket == nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("bucket %s does not exist", id))
+			continue
+		}
+		result.Buckets = append(result.Buckets, bucket.Name)
+
+		render.Printf("🔍 Checking bucket %s...\n", bucket.Name)
+		check, err := backup.CheckBucket(ctx, *bucket, cfg.Network, agent)
+		if err != nil {
+			render.Printf("❌ %v\n", err)
+			result.Problems = append(result.Problems, err.Error())
+			continue
+		}
+		for _, host := range check.Hosts {
+			render.Printf("   %s: %d backups, newest %s (%s ago)\n", host.Name, host.Backups, host.Newest,
+				utils.FormatDuration(time.Since(host.NewestAt)))
+		}
+		render.Printf("   Checksummed %d archives (%s)\n", check.Archives, utils.FormatBytes(check.Bytes))
+		for _, problem := range check.Problems {
+			render.Printf("❌ %s: %s\n", bucket.Name, problem)
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: %s", bucket.Name, problem))
+		}
+		if len(check.Problems) == 0 {
+			render.Printf("✅ %d backups in %s are intact\n", check.Backups, bucket.Name)
+		}
+		result.Backups += check.Backups
+		result.Archives += check.Archives
+	}
+	return result
+}
+
+// showLastVerifyAgentCheck prints the result of the daemon's last check
+func showLastVerifyAgentCheck() {
+	check, err := state.LoadVerifyAgentCheck()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if check.CheckedAt.IsZero() {
+		render.Println("The daemon has not checked any buckets yet")
+		return
+	}
+	render.Printf("Last check: %s (%s ago)\n", check.CheckedAt.Format("2006-01-02 15:04:05"),
+		utils.FormatDuration(time.Since(check.CheckedAt)))
+	render.Printf("Buckets: %v\n", check.Buckets)
+	render.Printf("Backups: %d, archives checksummed: %d\n", check.Backups, check.Archives)
+	if len(check.Problems) == 0 {
+		render.Println("✅ No problems found")
+		return
+	}
+	for _, problem := range check.Problems {
+		render.Printf("❌ %s\n", problem)
+	}
+	os.Exit(1)
+}
@@ -1,9 +1,9 @@
 package cmd
 
 import (
-	"fmt"
-
+	"github.com/mitexleo/backtide/internal/backup"
 	"github.com/mitexleo/backtide/internal/commands"
+	"github.com/mitexleo/backtide/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -19,11 +19,17 @@ var versionCmd = &cobra.Command{
 This command shows the version number that was set during the build process.
 For development builds, this will show "dev".`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("Backtide version %s\n", version)
+		render.Printf("Backtide version %s\n", version)
 	},
 }
 
 func init() {
+	// Record the build version in backup metadata
+	backup.Version = version
+
+	// Safe for read-only users
+	commands.MarkReadOnly(versionCmd)
+
 	// Register with command registry
 	commands.RegisterCommand("version", versionCmd)
 }
@@ -0,0 +1,86 @@
+package access
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Role determines which operations a user may perform
+type Role string
+
+const (
+	// RoleReadOnly may list, inspect and verify backups
+	RoleReadOnly Role = "read-only"
+	// RoleOperator may additionally run, delete and restore backups and edit configuration
+	RoleOperator Role = "operator"
+)
+
+// ReadOnlyEnv forces read-only mode when set to a true value
+const ReadOnlyEnv = "BACKTIDE_READ_ONLY"
+
+// ReadOnlyRequested reports whether read-only mode is forced through the environment
+func ReadOnlyRequested() bool {
+	value, err := strconv.ParseBool(os.Getenv(ReadOnlyEnv))
+	return err == nil && value
+}
+
+// RoleForUID resolves the role of a user from the access configuration
+func RoleForUID(cfg config.AccessConfig, uid int) Role {
+	if uid == 0 {
+		return RoleOperator
+	}
+	// Without operator groups, file permissions are the only restriction
+	if len(cfg.OperatorGroups) == 0 {
+		return RoleOperator
+	}
+
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return RoleReadOnly
+	}
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		return RoleReadOnly
+	}
+
+	for _, gid := range groupIDs {
+		group, err := user.LookupGroupId(gid)
+		if err != nil {
+			continue
+		}
+		for _, operatorGroup := range cfg.OperatorGroups {
+			if group.Name == operatorGroup {
+				return RoleOperator
+			}
+		}
+	}
+	return RoleReadOnly
+}
+
+// CurrentRole resolves the role of the invoking user
+func CurrentRole(cfg config.AccessConfig) Role {
+	if ReadOnlyRequested() {
+		return RoleReadOnly
+	}
+	return RoleForUID(cfg, os.Geteuid())
+}
+
+// SocketGID returns the group ID the daemon socket should belong to, or -1
+func SocketGID(cfg config.AccessConfig) (int, error) {
+	if cfg.SocketGroup == "" {
+		return -1, nil
+	}
+	group, err := user.LookupGroup(cfg.SocketGroup)
+	if err != nil {
+		return -1, fmt.Errorf("failed to look up socket group %s: %w", cfg.SocketGroup, err)
+	}
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return -1, fmt.Errorf("invalid gid for group %s: %w", cfg.SocketGroup, err)
+	}
+	return gid, nil
+}
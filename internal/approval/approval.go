@@ -0,0 +1,175 @@
+// Package approval implements the second-channel approval of restores into
+// jobs with restore_approval: single-use tokens signed by the daemon for
+// another operator, and TOTP codes from an authenticator app.
+package approval
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned for tokens and codes that do not approve the restore
+var ErrInvalid = errors.New("invalid approval")
+
+// Grant is what an approval token allows: one restore of a backup of a job
+type Grant struct {
+	Job      string `json:"job"`
+	BackupID string `json:"backup_id"`
+	// Approver is the UID of the operator who approved the restore
+	Approver  int       `json:"approver"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Nonce makes every token unique, so each can be redeemed once
+	Nonce string `json:"nonce"`
+}
+
+// NewKey returns a random key for signing tokens
+func NewKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate approval key: %w", err)
+	}
+	return key, nil
+}
+
+// Issue returns a token for grant signed with key, as "<payload>.<signature>"
+func Issue(key []byte, grant Grant) (string, error) {
+	if grant.Nonce == "" {
+		nonce := make([]byte, 12)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("failed to generate approval nonce: %w", err)
+		}
+		grant.Nonce = hex.EncodeToString(nonce)
+	}
+	data, err := json.Marshal(grant)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + sign(key, payload), nil
+}
+
+// Verify returns the grant of a token signed with key that has not expired
+func Verify(key []byte, token string, now time.Time) (Grant, error) {
+	var grant Grant
+	payload, signature, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(key, payload))) {
+		return grant, fmt.Errorf("%w: the token is not signed by this daemon", ErrInvalid)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return grant, fmt.Errorf("%w: malformed token", ErrInvalid)
+	}
+	if err := json.Unmarshal(data, &grant); err != nil {
+		return grant, fmt.Errorf("%w: malformed token", ErrInvalid)
+	}
+	if now.After(grant.ExpiresAt) {
+		return grant, fmt.Errorf("%w: the token expired at %s", ErrInvalid, grant.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	return grant, nil
+}
+
+// sign returns the hex HMAC-SHA256 of payload
+func sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// totpStep is the period of a TOTP code
+const totpStep = 30 * time.Second
+
+// IsTOTPCode reports whether value looks like a 6-digit TOTP code rather than a token
+func IsTOTPCode(value string) bool {
+	value = strings.TrimSpace(value)
+	if len(value) != 6 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewTOTPSecret returns a random base32 TOTP secret
+func NewTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// TOTPURI returns the otpauth URI that adds the secret to an authenticator app
+func TOTPURI(secret, account string) string {
+	return fmt.Sprintf("otpauth://totp/%s?secret=%s&issuer=backtide",
+		url.PathEscape("backtide:"+account), secret)
+}
+
+// TOTP returns the RFC 6238 code (SHA-1, 6 digits, 30 seconds) of a base32
+// secret at a time
+func TOTP(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, uint64(at.Unix()/int64(totpStep/time.Second))), nil
+}
+
+// VerifyTOTP checks a code against the secret, accepting the previous and
+// next code for clock skew
+func VerifyTOTP(secret, code string, now time.Time) error {
+	_, err := MatchTOTP(secret, code, now)
+	return err
+}
+
+// MatchTOTP checks a code like VerifyTOTP and returns the time step it
+// belongs to, so a used code can be refused when it is presented again
+func MatchTOTP(secret, code string, now time.Time) (uint64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	counter := uint64(now.Unix() / int64(totpStep/time.Second))
+	for _, c := range []uint64{counter - 1, counter, counter + 1} {
+		if hmac.Equal([]byte(totpCode(key, c)), []byte(strings.TrimSpace(code))) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: wrong or expired TOTP code", ErrInvalid)
+}
+
+// decodeSecret decodes a base32 secret as shown by authenticator apps
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid TOTP secret: not base32")
+	}
+	return key, nil
+}
+
+// totpCode computes the HOTP value of a counter
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
@@ -0,0 +1,59 @@
+package approval
+
+import (
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTOTPMatchesRFC6238(t *testing.T) {
+	// Test vectors of RFC 6238 for SHA-1, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"} {
+		got, err := TOTP(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("TOTP at %d = %s, want %s", unix, got, want)
+		}
+	}
+	if err := VerifyTOTP(secret, "287082", time.Unix(59+30, 0)); err != nil {
+		t.Errorf("previous code rejected: %v", err)
+	}
+	if err := VerifyTOTP(secret, "287082", time.Unix(59+90, 0)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("stale code accepted: %v", err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	token, err := Issue(key, Grant{Job: "db", BackupID: "backup-1", Approver: 1001, ExpiresAt: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grant, err := Verify(key, token, now)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if grant.Job != "db" || grant.BackupID != "backup-1" || grant.Approver != 1001 || grant.Nonce == "" {
+		t.Errorf("grant is %+v", grant)
+	}
+
+	other, _ := NewKey()
+	if _, err := Verify(other, token, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token verified with another key: %v", err)
+	}
+	if _, err := Verify(key, token, now.Add(2*time.Minute)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expired token verified: %v", err)
+	}
+	if _, err := Verify(key, "x"+token, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("tampered token verified: %v", err)
+	}
+}
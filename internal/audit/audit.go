@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// Results recorded for audited operations
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Entry is a single record in the audit log
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target,omitempty"`
+	Changes   []string  `json:"changes,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// LogPath returns the path of the audit log
+func LogPath() string {
+	return filepath.Join(state.Dir(), "audit.log")
+}
+
+// Record appends an entry to the audit log
+func Record(entry Entry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.User == "" {
+		entry.User = CurrentUser()
+	}
+	if entry.Result == "" {
+		entry.Result = ResultSuccess
+	}
+
+	logPath := LogPath()
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	// Open append-only so existing entries are never rewritten
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// RecordResult records an operation, deriving the result from err
+func RecordResult(action, target string, changes []string, err error) {
+	entry := Entry{Action: action, Target: target, Changes: changes}
+	if err != nil {
+		entry.Result = ResultFailure
+		entry.Error = err.Error()
+	}
+	if recordErr := Record(entry); recordErr != nil {
+		render.Printf("Warning: Failed to write audit log: %v\n", recordErr)
+	}
+}
+
+// List returns all audit entries, oldest first
+func List() ([]Entry, error) {
+	file, err := os.Open(LogPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue // Skip corrupt lines rather than hiding the rest of the log
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// CurrentUser returns the invoking user, including the original user under sudo
+func CurrentUser() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && sudoUser != name {
+		return fmt.Sprintf("%s (sudo by %s)", name, sudoUser)
+	}
+	return name
+}
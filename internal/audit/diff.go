@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/redact"
+)
+
+// DiffConfig summarizes the changes between two configurations
+func DiffConfig(before, after *config.BackupConfig) []string {
+	if before == nil {
+		before = &config.BackupConfig{}
+	}
+	if after == nil {
+		after = &config.BackupConfig{}
+	}
+
+	var changes []string
+	if before.BackupPath != after.BackupPath {
+		changes = append(changes, fmt.Sprintf("backup_path: %q → %q", before.BackupPath, after.BackupPath))
+	}
+	if before.TempPath != after.TempPath {
+		changes = append(changes, fmt.Sprintf("temp_path: %q → %q", before.TempPath, after.TempPath))
+	}
+
+	beforeJobs := make(map[string]config.BackupJob)
+	for _, job := range before.Jobs {
+		beforeJobs[job.Name] = job
+	}
+	afterJobs := make(map[string]config.BackupJob)
+	for _, job := range after.Jobs {
+		afterJobs[job.Name] = job
+	}
+	for _, name := range sortedKeys(beforeJobs, afterJobs) {
+		old, hadOld := beforeJobs[name]
+		cur, hasNew := afterJobs[name]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("job '%s' added", name))
+		case !hasNew:
+			changes = append(changes, fmt.Sprintf("job '%s' removed", name))
+		default:
+			for _, change := range diffStruct(old, cur) {
+				changes = append(changes, fmt.Sprintf("job '%s': %s", name, change))
+			}
+		}
+	}
+
+	beforeBuckets := make(map[string]config.BucketConfig)
+	for _, bucket := range before.Buckets {
+		beforeBuckets[bucket.ID] = bucket
+	}
+	afterBuckets := make(map[string]config.BucketConfig)
+	for _, bucket := range after.Buckets {
+		afterBuckets[bucket.ID] = bucket
+	}
+	for _, id := range sortedKeys(beforeBuckets, afterBuckets) {
+		old, hadOld := beforeBuckets[id]
+		cur, hasNew := afterBuckets[id]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("bucket '%s' (%s) added", cur.Name, cur.Bucket))
+		case !hasNew:
+			changes = append(changes, fmt.Sprintf("bucket '%s' (%s) removed", old.Name, old.Bucket))
+		default:
+			for _, change := range diffStruct(old, cur) {
+				changes = append(changes, fmt.Sprintf("bucket '%s': %s", cur.Name, change))
+			}
+		}
+	}
+
+	// Every other section is compared unmasked and shown with secrets masked
+	shownBefore, shownAfter := redact.Config(before), redact.Config(after)
+	bv, av := reflect.ValueOf(*before), reflect.ValueOf(*after)
+	sbv, sav := reflect.ValueOf(*shownBefore), reflect.ValueOf(*shownAfter)
+	t := bv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		switch t.Field(i).Name {
+		case "Jobs", "Buckets", "BackupPath", "TempPath":
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+		changes = append(changes, diffSection(name, bv.Field(i), av.Field(i), sbv.Field(i), sav.Field(i))...)
+	}
+
+	return changes
+}
+
+// diffSection lists the changes of a top-level configuration section,
+// comparing before and after and showing their masked copies
+func diffSection(name string, before, after, shownBefore, shownAfter reflect.Value) []string {
+	if reflect.DeepEqual(before.Interface(), after.Interface()) {
+		return nil
+	}
+
+	var changes []string
+	switch before.Kind() {
+	case reflect.Struct:
+		for _, change := range diffStruct(shownBefore.Interface(), shownAfter.Interface()) {
+			changes = append(changes, name+"."+change)
+		}
+	case reflect.Slice, reflect.Map:
+		if !reflect.DeepEqual(shownBefore.Interface(), shownAfter.Interface()) {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", name, compact(shownBefore.Interface()), compact(shownAfter.Interface())))
+		}
+	default:
+		changes = append(changes, fmt.Sprintf("%s: %v → %v", name, shownBefore.Interface(), shownAfter.Interface()))
+	}
+	// Masking can hide what changed, e.g. in a short secret
+	if len(changes) == 0 {
+		changes = append(changes, name+" changed")
+	}
+	return changes
+}
+
+// diffStruct lists changed top-level fields of two values of the same struct type
+func diffStruct(before, after interface{}) []string {
+	bv := reflect.ValueOf(before)
+	av := reflect.ValueOf(after)
+	t := bv.Type()
+
+	var changes []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		old := bv.Field(i).Interface()
+		cur := av.Field(i).Interface()
+		if reflect.DeepEqual(old, cur) {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		switch {
+		case redact.IsSecretField(field.Name):
+			changes = append(changes, fmt.Sprintf("%s changed", name))
+		case field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map:
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", name, compact(old), compact(cur)))
+		default:
+			changes = append(changes, fmt.Sprintf("%s: %v → %v", name, old, cur))
+		}
+	}
+	return changes
+}
+
+// compact renders a composite value on a single line
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// sortedKeys returns the union of the keys of two maps in sorted order
+func sortedKeys[T any](a, b map[string]T) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for k := range a {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	for k := range b {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/approval"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// SetRestoreApproval sets the approval token from 'backtide approve', or a
+// TOTP code, for restores of backups of jobs with restore_approval
+func (bm *BackupManager) SetRestoreApproval(code string) {
+	bm.approvalCode = code
+}
+
+// RestoreApprovedBy returns how the restore was approved, for the audit log;
+// it is empty until a restore needing approval was approved
+func (bm *BackupManager) RestoreApprovedBy() string {
+	return bm.approvedBy
+}
+
+// CheckRestoreApproval checks that a restore of a backup is approved when its
+// job has restore_approval, in the backup's metadata or in the manager's
+// configuration. Tokens are redeemed with the daemon and are valid once, so
+// a backup approved through this manager is not checked again.
+func (bm *BackupManager) CheckRestoreApproval(metadata *config.BackupMetadata) error {
+	required := metadata.RestoreApproval
+	for _, job := range bm.config.Jobs {
+		if job.Name == metadata.JobName && job.RestoreApproval {
+			required = true
+		}
+	}
+	if !required || bm.approvedID == metadata.ID {
+		return nil
+	}
+	if bm.approvalCode == "" {
+		return fmt.Errorf("restores of job %s need approval: ask another operator to run 'backtide approve %s --job %s' and pass the token with --approval, or pass a TOTP code",
+			metadata.JobName, metadata.ID, metadata.JobName)
+	}
+
+	approvedBy, err := bm.redeemApproval(metadata)
+	if err != nil {
+		return err
+	}
+	bm.approvedID, bm.approvedBy = metadata.ID, approvedBy
+	return nil
+}
+
+// redeemApproval checks a TOTP code against [approval] totp_secret_file, or
+// redeems a token with the daemon, and returns who approved the restore
+func (bm *BackupManager) redeemApproval(metadata *config.BackupMetadata) (string, error) {
+	if approval.IsTOTPCode(bm.approvalCode) {
+		secret, err := bm.config.Approval.TOTPSecret()
+		if err != nil {
+			return "", err
+		}
+		step, err := approval.MatchTOTP(secret, bm.approvalCode, time.Now())
+		if err != nil {
+			return "", err
+		}
+		// Each code approves one restore; the secret stands for its approver
+		if err := state.UseTOTPStep(bm.config.Approval.TOTPSecretFile, step); err != nil {
+			return "", fmt.Errorf("%w: %w", approval.ErrInvalid, err)
+		}
+		return "approved with a TOTP code", nil
+	}
+
+	client := control.NewClient(state.FindSocket())
+	if !client.Available() {
+		return "", fmt.Errorf("approval tokens are redeemed by the daemon, which is not running; use a TOTP code instead")
+	}
+	var approved control.Approval
+	request := control.ApprovalRequest{Job: metadata.JobName, BackupID: metadata.ID, Token: bm.approvalCode}
+	if err := client.Post("/v1/approvals/redeem", request, &approved); err != nil {
+		return "", err
+	}
+	approver := fmt.Sprintf("uid %d", approved.Approver)
+	if account, err := user.LookupId(fmt.Sprint(approved.Approver)); err == nil {
+		approver = account.Username
+	}
+	return "approved by " + approver, nil
+}
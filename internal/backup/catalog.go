@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/s3fs"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// CatalogLocation is a storage location scanned when rebuilding the catalog
+type CatalogLocation struct {
+	Path     string
+	BucketID string
+	// Job is assigned to backups whose metadata does not name their job
+	Job string
+}
+
+// catalogEntry converts backup metadata into a catalog entry
+func catalogEntry(metadata config.BackupMetadata, location CatalogLocation) state.CatalogEntry {
+	job := metadata.JobName
+	if job == "" {
+		job = location.Job
+	}
+	return state.CatalogEntry{
+		BackupID:  metadata.ID,
+		Job:       job,
+		Location:  location.Path,
+		BucketID:  location.BucketID,
+		Host:      metadata.Hostname,
+		Timestamp: metadata.Timestamp,
+		TotalSize: metadata.TotalSize,
+		Checksum:  metadata.Checksum,
+		Comment:   metadata.Comment,
+		Labels:    metadata.Labels,
+		Pinned:    metadata.Pinned,
+		ExpiresAt: metadata.ExpiresAt,
+	}
+}
+
+// CatalogLocations returns the storage locations of all configured jobs
+func (br *BackupRunner) CatalogLocations() []CatalogLocation {
+	var locations []CatalogLocation
+	index := make(map[string]int)
+
+	for _, job := range br.config.Jobs {
+		location := CatalogLocation{Path: br.backupPath, Job: job.Name}
+		if job.Storage.S3 {
+			for _, bucket := range br.config.Buckets {
+				if bucket.ID == job.BucketID {
+					location = CatalogLocation{Path: bucket.MountPoint, BucketID: bucket.ID, Job: job.Name}
+					break
+				}
+			}
+		} else if !job.Storage.Local {
+			// Plugin-only jobs keep nothing in a scannable location
+			continue
+		}
+		if location.Path == "" {
+			continue
+		}
+
+		// Shared locations cannot attribute backups without a job name to one job
+		if i, ok := index[location.Path]; ok {
+			locations[i].Job = ""
+			continue
+		}
+		index[location.Path] = len(locations)
+		locations = append(locations, location)
+	}
+	return locations
+}
+
+// BucketLocation returns the catalog location of a configured bucket
+func (br *BackupRunner) BucketLocation(idOrName string) (CatalogLocation, error) {
+	for _, bucket := range br.config.Buckets {
+		if bucket.ID == idOrName || bucket.Name == idOrName {
+			return CatalogLocation{Path: bucket.MountPoint, BucketID: bucket.ID}, nil
+		}
+	}
+	return CatalogLocation{}, fmt.Errorf("bucket not found: %s", idOrName)
+}
+
+// mountLocation mounts the bucket behind a location, if any, while it is scanned;
+// the returned function releases it
+func (br *BackupRunner) mountLocation(location CatalogLocation, holder string) (func(), error) {
+	if location.BucketID == "" {
+		return func() {}, nil
+	}
+	for _, bucket := range br.config.Buckets {
+		if bucket.ID == location.BucketID {
+			release, err := s3fs.NewS3FSManager(bucket).Acquire(holder, br.config.Mounts.Timeout())
+			if err != nil {
+				return func() {}, fmt.Errorf("failed to mount S3 bucket %s: %w", bucket.Name, err)
+			}
+			return release, nil
+		}
+	}
+	return func() {}, nil
+}
+
+// RebuildCatalogLocation scans a location for backup metadata and replaces its
+// catalog entries with what was found, returning the number of backups
+func (br *BackupRunner) RebuildCatalogLocation(location CatalogLocation) (int, error) {
+	release, err := br.mountLocation(location, fmt.Sprintf("catalog-%d", os.Getpid()))
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	if _, err := os.Stat(location.Path); err != nil {
+		return 0, fmt.Errorf("cannot scan %s: %w", location.Path, err)
+	}
+
+	// Backups of shared buckets are stored in per-host directories below the mount point
+	manager := NewBackupManager(config.BackupConfig{BackupPath: location.Path})
+	count := 0
+	for _, dir := range backupDirs(location.Path) {
+		backups, err := manager.listBackupsFromPath(dir)
+		if err != nil {
+			return count, err
+		}
+
+		dirLocation := location
+		dirLocation.Path = dir
+		var entries []state.CatalogEntry
+		for _, metadata := range backups {
+			entries = append(entries, catalogEntry(metadata, dirLocation))
+		}
+		if err := state.ReplaceLocation(dir, entries); err != nil {
+			return count, err
+		}
+		count += len(entries)
+	}
+	return count, nil
+}
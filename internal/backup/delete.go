@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// DeleteFilter selects backups of one job for ad-hoc deletion; a backup is
+// selected when it matches every set criterion
+type DeleteFilter struct {
+	OlderThan  time.Duration // taken longer ago than this; 0 for any age
+	LargerThan int64         // bigger than this many bytes; 0 for any size
+	KeepLatest int           // never select the newest this many unpinned backups
+}
+
+// DeletePlan lists the backups a delete would remove and the pinned backups it keeps
+type DeletePlan struct {
+	Path   string
+	Delete []config.BackupMetadata
+	Pinned []config.BackupMetadata
+}
+
+// PlanDelete selects this host's backups of a job matching filter, newest
+// first. Pinned backups are never selected and do not count toward KeepLatest.
+func (br *BackupRunner) PlanDelete(jobName string, filter DeleteFilter) (*DeletePlan, error) {
+	job, err := br.findJob(jobName)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := br.MountJobStorage(job.Name, fmt.Sprintf("delete-%d", os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	plan := &DeletePlan{Path: br.jobStoragePath(job)}
+	backups, err := NewBackupManager(config.BackupConfig{BackupPath: plan.Path}).listBackupsFromPath(plan.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+
+	cutoff := time.Now().Add(-filter.OlderThan)
+	kept := 0
+	for _, metadata := range backups {
+		// Jobs may share a backup path; older backups without a job are left alone
+		if metadata.JobID != job.ID && (metadata.JobID != "" || metadata.JobName != job.Name) {
+			continue
+		}
+		if metadata.Pinned {
+			plan.Pinned = append(plan.Pinned, metadata)
+			continue
+		}
+		if kept < filter.KeepLatest {
+			kept++
+			continue
+		}
+		if filter.OlderThan > 0 && !metadata.Timestamp.Before(cutoff) {
+			continue
+		}
+		if metadata.TotalSize <= filter.LargerThan {
+			continue
+		}
+		plan.Delete = append(plan.Delete, metadata)
+	}
+	return plan, nil
+}
+
+// DeleteBackups removes the backups selected by a plan, returning a description
+// of each removal; pinned backups are refused even if listed
+func (br *BackupRunner) DeleteBackups(jobName string, plan *DeletePlan) ([]string, error) {
+	release, err := br.MountJobStorage(jobName, fmt.Sprintf("delete-%d", os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var removed []string
+	for _, metadata := range plan.Delete {
+		backupDir := filepath.Join(plan.Path, metadata.ID)
+
+		// The pin may have been set since the plan was made
+		current, err := config.LoadBackupMetadata(filepath.Join(backupDir, "metadata.toml"))
+		if err != nil {
+			return removed, fmt.Errorf("failed to read backup %s: %w", metadata.ID, err)
+		}
+		if current.Pinned {
+			return removed, fmt.Errorf("backup %s is pinned; unpin it with 'backtide pin --unpin' first", metadata.ID)
+		}
+
+		if err := os.RemoveAll(backupDir); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", metadata.ID, err)
+		}
+		removed = append(removed, fmt.Sprintf("removed backup %s (%s) from %s", metadata.ID, metadata.Timestamp.Format("2006-01-02 15:04:05"), plan.Path))
+		if err := state.RemoveBackup(plan.Path, metadata.ID); err != nil {
+			render.Printf("Warning: Failed to update catalog: %v\n", err)
+		}
+	}
+	return removed, nil
+}
+
+// jobStoragePath returns where this host stores a job's backups: its directory
+// on the job's S3 mount, or the local backup path
+func (br *BackupRunner) jobStoragePath(job *config.BackupJob) string {
+	if job.Storage.S3 {
+		for _, bucket := range br.config.Buckets {
+			if bucket.ID == job.BucketID {
+				return HostPath(bucket.MountPoint)
+			}
+		}
+	}
+	return br.backupPath
+}
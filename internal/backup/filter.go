@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// fileFilters applies a directory's file filters while its archive is written
+type fileFilters struct {
+	filters []config.FileFilter
+	applied []config.AppliedFilter
+	tempDir string
+}
+
+// newFileFilters returns the filters of a directory, or nil if it has none;
+// transformed content is staged in tempDir
+func newFileFilters(filters []config.FileFilter, tempDir string) *fileFilters {
+	if len(filters) == 0 {
+		return nil
+	}
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	f := &fileFilters{filters: filters, tempDir: tempDir}
+	for _, filter := range filters {
+		f.applied = append(f.applied, config.AppliedFilter{
+			Name:    filter.Name,
+			Builtin: filter.Builtin,
+			Exclude: filter.Exclude,
+			Command: filter.Command,
+			Match:   filter.Match,
+		})
+	}
+	return f
+}
+
+// Applied returns the filters with the number of files each dropped and transformed
+func (f *fileFilters) Applied() []config.AppliedFilter {
+	if f == nil {
+		return nil
+	}
+	return f.applied
+}
+
+// apply runs the filters over an entry in order, matching patterns against
+// path and reading the content from source, which differ when the directory
+// is archived from a copy. It reports whether the entry is left out, or
+// returns a file holding the content to archive instead of the original,
+// which the caller removes.
+func (f *fileFilters) apply(ctx context.Context, path, source string, info os.FileInfo) (bool, *os.File, error) {
+	var replacement *os.File
+	discard := func() {
+		if replacement != nil {
+			replacement.Close()
+			os.Remove(replacement.Name())
+		}
+	}
+
+	for i, filter := range f.filters {
+		if matchAny(filter.Exclude, path) || matchAny(config.BuiltinFilters[filter.Builtin], path) {
+			f.applied[i].Dropped++
+			discard()
+			return true, nil, nil
+		}
+		if filter.Command == "" || !info.Mode().IsRegular() {
+			continue
+		}
+		if len(filter.Match) > 0 && !matchAny(filter.Match, path) {
+			continue
+		}
+
+		// Filters are chained: each command reads what the previous one wrote
+		var input *os.File
+		if replacement != nil {
+			if _, err := replacement.Seek(0, 0); err != nil {
+				discard()
+				return false, nil, err
+			}
+			input = replacement
+		} else {
+			file, err := os.Open(source)
+			if err != nil {
+				return false, nil, err
+			}
+			defer file.Close()
+			input = file
+		}
+
+		output, err := os.CreateTemp(f.tempDir, "backtide-filter-*")
+		if err != nil {
+			discard()
+			return false, nil, fmt.Errorf("failed to create filter output: %w", err)
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", filter.Command)
+		cmd.Stdin = input
+		cmd.Stdout = output
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), "BACKTIDE_FILTER_PATH="+path, "BACKTIDE_FILTER_NAME="+filter.Name)
+		runErr := cmd.Run()
+		discard()
+		replacement = output
+
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && exitErr.ExitCode() == config.FilterDropExitCode {
+			f.applied[i].Dropped++
+			discard()
+			return true, nil, nil
+		}
+		if runErr != nil {
+			discard()
+			return false, nil, fmt.Errorf("filter %s failed on %s: %w", filter.Name, path, runErr)
+		}
+		f.applied[i].Transformed++
+	}
+
+	if replacement != nil {
+		if _, err := replacement.Seek(0, 0); err != nil {
+			discard()
+			return false, nil, err
+		}
+	}
+	return false, replacement, nil
+}
+
+// matchAny reports whether a file's absolute path matches any of patterns
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matchFilePattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredInfo describes a file whose content was replaced by a filter
+type filteredInfo struct {
+	os.FileInfo
+	size int64
+}
+
+// Size implements os.FileInfo
+func (i filteredInfo) Size() int64 {
+	return i.size
+}
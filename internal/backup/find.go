@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// FileMatch is a file found in a backup's index
+type FileMatch struct {
+	Backup    *config.BackupMetadata
+	Directory string // name of the backup directory holding the file
+	Path      string // absolute path of the file when it was backed up
+	Size      int64
+	ModTime   time.Time
+}
+
+// FindResult lists the files matching a search, newest backup first
+type FindResult struct {
+	Matches []FileMatch
+	// Searched counts the backups whose indexes were read; Unindexed counts
+	// backups from versions that wrote no index and could not be searched
+	Searched  int
+	Unindexed int
+}
+
+// FindFiles searches the file indexes of this host's backups of a job, or of
+// every job when jobName is empty, for files matching pattern. Backups taken
+// before since are skipped.
+//
+// The pattern uses shell glob syntax. Without a slash it matches file names
+// ("*.sql"); with a leading slash it matches the full original path
+// ("/srv/db/*.sql"); otherwise it matches the end of the path ("dumps/*.sql").
+func (br *BackupRunner) FindFiles(jobName, pattern string, since time.Time) (*FindResult, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	jobs := br.config.Jobs
+	if jobName != "" {
+		job, err := br.findJob(jobName)
+		if err != nil {
+			return nil, err
+		}
+		jobs = []config.BackupJob{*job}
+	}
+
+	result := &FindResult{}
+	for _, job := range jobs {
+		if err := br.findInJob(&job, pattern, since, result); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Backup.Timestamp.After(result.Matches[j].Backup.Timestamp)
+	})
+	return result, nil
+}
+
+// findInJob adds the matches in the backups of one job to result
+func (br *BackupRunner) findInJob(job *config.BackupJob, pattern string, since time.Time, result *FindResult) error {
+	release, err := br.MountJobStorage(job.Name, fmt.Sprintf("find-%d", os.Getpid()))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	storagePath := br.jobStoragePath(job)
+	backups, err := NewBackupManager(config.BackupConfig{BackupPath: storagePath}).listBackupsFromPath(storagePath)
+	if err != nil {
+		return fmt.Errorf("failed to list backups of job %s: %w", job.Name, err)
+	}
+
+	for i := range backups {
+		metadata := &backups[i]
+		// Jobs may share a backup path; older backups without a job ID are matched by name
+		if metadata.JobID != job.ID && (metadata.JobID != "" || metadata.JobName != job.Name) {
+			continue
+		}
+		if !since.IsZero() && metadata.Timestamp.Before(since) {
+			continue
+		}
+
+		indexed := false
+		for _, dir := range metadata.Directories {
+			if dir.Index == "" {
+				continue
+			}
+			indexed = true
+			indexPath := filepath.Join(storagePath, metadata.ID, dir.Index)
+			err := readIndex(indexPath, func(entry IndexEntry) error {
+				if strings.HasPrefix(entry.Mode, "d") {
+					return nil
+				}
+				// Index paths start with the directory name in place of its source path
+				original := filepath.Join(dir.Path, strings.TrimPrefix(entry.Path, dir.Name+"/"))
+				if !matchFilePattern(pattern, original) {
+					return nil
+				}
+				modTime, _ := time.Parse("2006-01-02T15:04:05Z", entry.ModTime)
+				result.Matches = append(result.Matches, FileMatch{
+					Backup:    metadata,
+					Directory: dir.Name,
+					Path:      original,
+					Size:      entry.Size,
+					ModTime:   modTime,
+				})
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("backup %s: %w", metadata.ID, err)
+			}
+		}
+		if indexed {
+			result.Searched++
+		} else {
+			result.Unindexed++
+		}
+	}
+	return nil
+}
+
+// matchFilePattern reports whether a file's absolute path matches a find pattern
+func matchFilePattern(pattern, filePath string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(filePath))
+		return matched
+	}
+	if strings.HasPrefix(pattern, "/") {
+		matched, _ := path.Match(pattern, filePath)
+		return matched
+	}
+	for i := 0; i < len(filePath); i++ {
+		if filePath[i] != '/' {
+			continue
+		}
+		if matched, _ := path.Match(pattern, filePath[i+1:]); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// footerName is the last entry of every archive. It sits outside the archive's
+// root directory, so restores skip it like the root itself.
+const footerName = ".backtide-footer.json"
+
+// maxFooterSize bounds the footer read from an archive
+const maxFooterSize = 4 << 10
+
+// archiveFooter summarises the entries written before it, so a truncated
+// archive is noticed even where tar and gzip would end cleanly
+type archiveFooter struct {
+	Entries int    `json:"entries"`
+	Size    int64  `json:"size"`  // bytes of file content
+	CRC32   uint32 `json:"crc32"` // IEEE CRC-32 of all file content in order
+}
+
+// archiveWriter writes tar entries, tallying them for the footer
+type archiveWriter struct {
+	tw     *tar.Writer
+	footer archiveFooter
+	crc    hash.Hash32
+}
+
+// newArchiveWriter returns a tar writer on w that ends the archive with a footer
+func newArchiveWriter(w io.Writer) *archiveWriter {
+	return &archiveWriter{tw: tar.NewWriter(w), crc: crc32.NewIEEE()}
+}
+
+// WriteHeader starts a new entry
+func (a *archiveWriter) WriteHeader(header *tar.Header) error {
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	a.footer.Entries++
+	return nil
+}
+
+// Write writes content of the current entry
+func (a *archiveWriter) Write(p []byte) (int, error) {
+	n, err := a.tw.Write(p)
+	a.crc.Write(p[:n])
+	a.footer.Size += int64(n)
+	return n, err
+}
+
+// Close writes the footer and finishes the archive
+func (a *archiveWriter) Close() error {
+	a.footer.CRC32 = a.crc.Sum32()
+	data, err := json.Marshal(a.footer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive footer: %w", err)
+	}
+	header := &tar.Header{
+		Name:     footerName,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+		Format:   tar.FormatPAX,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive footer: %w", err)
+	}
+	if _, err := a.tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive footer: %w", err)
+	}
+	return a.tw.Close()
+}
+
+// archiveReader reads tar entries, checking them against the footer once the
+// archive ends. Archives written before footers existed are accepted unless
+// a footer is required.
+type archiveReader struct {
+	tr            *tar.Reader
+	requireFooter bool
+	seen          archiveFooter
+	crc           hash.Hash32
+	footer        *archiveFooter
+}
+
+// newArchiveReader returns a tar reader on r that validates the footer
+func newArchiveReader(r io.Reader, requireFooter bool) *archiveReader {
+	return &archiveReader{tr: tar.NewReader(r), requireFooter: requireFooter, crc: crc32.NewIEEE()}
+}
+
+// Next advances to the next entry, returning io.EOF at a valid end of the archive
+func (a *archiveReader) Next() (*tar.Header, error) {
+	// Content the caller skipped still counts toward the checksum
+	if _, err := io.Copy(io.Discard, a); err != nil {
+		return nil, err
+	}
+
+	header, err := a.tr.Next()
+	if err == io.EOF {
+		return nil, a.check()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.footer != nil {
+		return nil, fmt.Errorf("archive has entries after its footer")
+	}
+	if header.Name != footerName {
+		a.seen.Entries++
+		return header, nil
+	}
+
+	var footer archiveFooter
+	data, err := io.ReadAll(io.LimitReader(a.tr, maxFooterSize))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &footer); err != nil {
+		return nil, fmt.Errorf("invalid archive footer: %w", err)
+	}
+	a.footer = &footer
+	return a.Next()
+}
+
+// Read reads content of the current entry
+func (a *archiveReader) Read(p []byte) (int, error) {
+	n, err := a.tr.Read(p)
+	a.crc.Write(p[:n])
+	a.seen.Size += int64(n)
+	return n, err
+}
+
+// check compares what was read with the footer
+func (a *archiveReader) check() error {
+	if a.footer == nil {
+		if a.requireFooter {
+			return fmt.Errorf("archive is truncated: footer missing after %d entries", a.seen.Entries)
+		}
+		return io.EOF
+	}
+	a.seen.CRC32 = a.crc.Sum32()
+	if a.seen != *a.footer {
+		return fmt.Errorf("archive does not match its footer: read %d entries, %d bytes, crc %08x; footer has %d entries, %d bytes, crc %08x",
+			a.seen.Entries, a.seen.Size, a.seen.CRC32, a.footer.Entries, a.footer.Size, a.footer.CRC32)
+	}
+	return io.EOF
+}
+
+// checkArchive reads a written archive back, validating its compression and
+// footer, and returns its SHA-256 checksum
+func checkArchive(path string, compressed bool) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	var reader io.Reader = io.TeeReader(file, hash)
+	if compressed {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", fmt.Errorf("archive is unreadable: %w", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	archive := newArchiveReader(reader, true)
+	for {
+		if _, err := archive.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	// Anything after the end of the archive is part of the file checksum too
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return "", fmt.Errorf("archive is unreadable: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// Freshness is how a job stands against its max_age SLO
+type Freshness struct {
+	Job    string
+	MaxAge time.Duration
+	// Setting is max_age as configured, e.g. "26h"
+	Setting string
+	// LastSuccess is when the newest successful run on this host finished,
+	// zero if there was none
+	LastSuccess time.Time
+}
+
+// Overdue reports whether the newest successful backup is older than the SLO
+// at now; a job that never succeeded is overdue
+func (f Freshness) Overdue(now time.Time) bool {
+	return f.LastSuccess.IsZero() || now.Sub(f.LastSuccess) > f.MaxAge
+}
+
+// Describe explains the job's freshness at now, e.g. "last success 3h ago, max_age 26h"
+func (f Freshness) Describe(now time.Time) string {
+	if f.LastSuccess.IsZero() {
+		return fmt.Sprintf("no successful backup, max_age %s", f.Setting)
+	}
+	return fmt.Sprintf("last success %s ago, max_age %s", utils.FormatDuration(now.Sub(f.LastSuccess)), f.Setting)
+}
+
+// JobFreshness returns the freshness of the enabled jobs with a max_age, in
+// configuration order, from this host's run history
+func JobFreshness(jobs []config.BackupJob) ([]Freshness, error) {
+	var checks []Freshness
+	for _, job := range jobs {
+		if maxAge := job.MaxAgeDuration(); job.Enabled && maxAge > 0 {
+			checks = append(checks, Freshness{Job: job.Name, MaxAge: maxAge, Setting: job.MaxAge})
+		}
+	}
+	if len(checks) == 0 {
+		return nil, nil
+	}
+
+	history, err := state.LoadHistory(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range checks {
+		for _, entry := range history {
+			if entry.Job == checks[i].Job && entry.State == control.RunSucceeded && entry.FinishedAt.After(checks[i].LastSuccess) {
+				checks[i].LastSuccess = entry.FinishedAt
+			}
+		}
+	}
+	return checks, nil
+}
@@ -0,0 +1,52 @@
+package backup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/backuptest"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+func TestJobFreshness(t *testing.T) {
+	backuptest.Isolate(t)
+	now := time.Now()
+	for _, entry := range []state.HistoryEntry{
+		{Job: "daily", State: control.RunSucceeded, FinishedAt: now.Add(-30 * time.Hour)},
+		{Job: "daily", State: control.RunSucceeded, FinishedAt: now.Add(-2 * time.Hour)},
+		{Job: "daily", State: control.RunFailed, FinishedAt: now.Add(-time.Hour)},
+		{Job: "weekly", State: control.RunSucceeded, FinishedAt: now.Add(-9 * 24 * time.Hour)},
+		{Job: "new", State: control.RunFailed, FinishedAt: now.Add(-time.Hour)},
+	} {
+		if err := state.RecordHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checks, err := backup.JobFreshness([]config.BackupJob{
+		{Name: "daily", Enabled: true, MaxAge: "26h"},
+		{Name: "weekly", Enabled: true, MaxAge: "8d"},
+		{Name: "new", Enabled: true, MaxAge: "1h"},
+		{Name: "unchecked", Enabled: true},
+		{Name: "disabled", MaxAge: "1h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"daily": false, "weekly": true, "new": true}
+	if len(checks) != len(want) {
+		t.Fatalf("checked %d jobs, want %d: %+v", len(checks), len(want), checks)
+	}
+	for _, check := range checks {
+		if got := check.Overdue(now); got != want[check.Job] {
+			t.Errorf("%s: overdue = %v, want %v (%s)", check.Job, got, want[check.Job], check.Describe(now))
+		}
+	}
+	if checks[0].LastSuccess.Before(now.Add(-3 * time.Hour)) {
+		t.Errorf("daily: last success %s is not the newest successful run", checks[0].LastSuccess)
+	}
+}
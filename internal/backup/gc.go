@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// Garbage is data left behind by failed runs or removed configuration
+type Garbage struct {
+	Path   string
+	Reason string
+	Size   int64
+}
+
+// FindGarbage finds orphaned data older than minAge: backup directories without
+// metadata, temp leftovers, stale state files and credentials of removed buckets.
+// Backup and temp directories are skipped while runs are active.
+func (br *BackupRunner) FindGarbage(minAge time.Duration) ([]Garbage, error) {
+	var garbage []Garbage
+	add := func(path, reason string) {
+		size, _ := utils.GetDirectorySize(path)
+		garbage = append(garbage, Garbage{Path: path, Reason: reason, Size: size})
+	}
+	old := func(path string) bool {
+		info, err := os.Lstat(path)
+		return err == nil && time.Since(info.ModTime()) >= minAge
+	}
+
+	runs, err := state.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > 0 {
+		render.Printf("⚠️  %d runs are active; skipping backup and temp directories\n", len(runs))
+	} else {
+		// Backup directories of failed runs that never wrote metadata
+		for _, location := range br.CatalogLocations() {
+			release, err := br.mountLocation(location, fmt.Sprintf("gc-%d", os.Getpid()))
+			if err != nil {
+				render.Printf("Warning: %v\n", err)
+				continue
+			}
+			// Other hosts' directories may hold runs in progress there
+			dirs := []string{location.Path}
+			if location.BucketID != "" {
+				dirs = append(dirs, HostPath(location.Path))
+			}
+			for _, dir := range dirs {
+				entries, _ := os.ReadDir(dir)
+				for _, entry := range entries {
+					path := filepath.Join(dir, entry.Name())
+					if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "backup-") || !old(path) {
+						continue
+					}
+					if _, err := os.Stat(filepath.Join(path, "metadata.toml")); os.IsNotExist(err) {
+						add(path, "backup directory without metadata")
+					}
+				}
+			}
+			release()
+		}
+
+		// System state captures, staged backups, warm copies and verify restores of interrupted runs
+		if br.config.TempPath != "" {
+			for _, dir := range []string{config.SystemStateComponent, "staging", "warm", "verify"} {
+				entries, _ := os.ReadDir(filepath.Join(br.config.TempPath, dir))
+				for _, entry := range entries {
+					path := filepath.Join(br.config.TempPath, dir, entry.Name())
+					if old(path) {
+						add(path, "temp leftover of an interrupted run")
+					}
+				}
+			}
+		}
+	}
+
+	var bucketIDs []string
+	for _, bucket := range br.config.Buckets {
+		bucketIDs = append(bucketIDs, bucket.ID)
+	}
+	for _, file := range state.StaleFiles(bucketIDs) {
+		add(file.Path, file.Reason)
+	}
+
+	// Credentials of buckets removed from the configuration. All profiles
+	// share the credentials directory, so a bucket still configured in any
+	// of them keeps its file.
+	if profileIDs, ok := profileBucketIDs(); ok {
+		credsDir := paths.CredentialsDir()
+		entries, _ := os.ReadDir(credsDir)
+		for _, entry := range entries {
+			id, ok := strings.CutPrefix(entry.Name(), "passwd-s3fs-")
+			if ok && !slices.Contains(bucketIDs, id) && !slices.Contains(profileIDs, id) {
+				add(filepath.Join(credsDir, entry.Name()), "credentials of removed bucket "+id)
+			}
+		}
+	}
+
+	return garbage, nil
+}
+
+// profileBucketIDs returns the IDs of the buckets in the default
+// configuration and in every profile. It returns false when one of them
+// cannot be loaded, since its buckets are then unknown.
+func profileBucketIDs() ([]string, bool) {
+	var files []string
+	if path := config.LocateConfigFile(); path != "" {
+		files = append(files, path)
+	}
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return nil, false
+	}
+	for _, profile := range profiles {
+		files = append(files, profile.Path)
+	}
+
+	var ids []string
+	for _, path := range files {
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			return nil, false
+		}
+		for _, bucket := range cfg.Buckets {
+			ids = append(ids, bucket.ID)
+		}
+	}
+	return ids, true
+}
+
+// RemoveGarbage removes the given items and records the removal in the audit log
+func RemoveGarbage(garbage []Garbage) error {
+	var removed []string
+	var removeErr error
+	for _, item := range garbage {
+		if err := os.RemoveAll(item.Path); err != nil {
+			render.Printf("Warning: Failed to remove %s: %v\n", item.Path, err)
+			removeErr = fmt.Errorf("failed to remove %s: %w", item.Path, err)
+			continue
+		}
+		removed = append(removed, fmt.Sprintf("removed %s (%s)", item.Path, item.Reason))
+	}
+
+	if len(removed) > 0 || removeErr != nil {
+		audit.RecordResult("gc", "", removed, removeErr)
+	}
+	return removeErr
+}
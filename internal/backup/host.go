@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// hostsDir is the directory of a shared bucket holding one directory per host
+const hostsDir = "hosts"
+
+// Hostname returns the name of this host, or "unknown" if it cannot be determined
+func Hostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "unknown"
+	}
+	return hostname
+}
+
+// MachineID returns the systemd/D-Bus machine ID of this host, if available
+func MachineID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// HostPath returns the directory of a bucket mount this host writes its backups to,
+// so several hosts can share a bucket without touching each other's backups
+func HostPath(mountPoint string) string {
+	return filepath.Join(mountPoint, hostsDir, idSafe(Hostname()))
+}
+
+// MatchesHost reports whether a backup was written by host, given as hostname or
+// machine ID; an empty host matches every backup
+func MatchesHost(metadata config.BackupMetadata, host string) bool {
+	return host == "" || metadata.Hostname == host || (metadata.MachineID != "" && metadata.MachineID == host)
+}
+
+// backupDirs returns path and the per-host directories below it
+func backupDirs(path string) []string {
+	dirs := []string{path}
+	entries, _ := os.ReadDir(filepath.Join(path, hostsDir))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(path, hostsDir, entry.Name()))
+		}
+	}
+	return dirs
+}
+
+// BackupDir returns the directory of a backup stored directly in the backup
+// path or in one of its per-host directories
+func (bm *BackupManager) BackupDir(backupID string) (string, error) {
+	var found []string
+	var hosts []string
+	for _, dir := range backupDirs(bm.backupPath) {
+		backupDir := filepath.Join(dir, backupID)
+		if _, err := os.Stat(backupDir); err != nil {
+			continue
+		}
+		if bm.host != "" {
+			metadata, err := bm.loadMetadata(backupDir)
+			if err != nil || !MatchesHost(*metadata, bm.host) {
+				continue
+			}
+		}
+		found = append(found, backupDir)
+		if dir == bm.backupPath {
+			hosts = append(hosts, "top level")
+		} else {
+			hosts = append(hosts, filepath.Base(dir))
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("backup not found: %s", backupID)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("backup %s exists in several locations (%s); select one with --host", backupID, strings.Join(hosts, ", "))
+	}
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// labelKeyPattern restricts label keys to characters safe in flags and file names
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Annotations are the comment, labels and explicit lifetime given to a backup
+// when it is created
+type Annotations struct {
+	Comment string
+	Labels  map[string]string
+	// KeepFor, when set, overrides the job retention for the backup
+	KeepFor time.Duration
+}
+
+// ParseLabels parses labels given as key=value
+func ParseLabels(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value, found := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !found || !labelKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid label %q: expected key=value with a key of letters, digits, '.', '_' or '-'", entry)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// ValidateLabels checks label keys given other than as flags, e.g. over the daemon API
+func ValidateLabels(labels map[string]string) error {
+	for key := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q: use letters, digits, '.', '_' or '-'", key)
+		}
+	}
+	return nil
+}
+
+// FormatLabels renders labels as sorted key=value pairs
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// MatchesLabels reports whether labels contain every label of selector;
+// an empty selector matches everything
+func MatchesLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
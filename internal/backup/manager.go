@@ -4,22 +4,46 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/mitexleo/backtide/internal/audit"
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/fault"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
 )
 
+// Version is the backtide version recorded in backup metadata
+var Version = "dev"
+
 // BackupManager handles backup operations
 type BackupManager struct {
-	config     config.BackupConfig
-	backupPath string
+	config      config.BackupConfig
+	backupPath  string
+	ownership   *OwnershipMap
+	host        string
+	annotations Annotations
+	// noTimes and atimes control which recorded times restores apply
+	noTimes bool
+	atimes  bool
+	// sandbox restores in a worker that may only write the restore targets
+	sandbox bool
+	// approvalCode approves restores of jobs with restore_approval;
+	// approvedID and approvedBy record the backup it approved and by whom
+	approvalCode string
+	approvedID   string
+	approvedBy   string
 }
 
 // NewBackupManager creates a new backup manager instance
@@ -32,34 +56,73 @@ func NewBackupManager(cfg config.BackupConfig) *BackupManager {
 
 // CreateBackup creates a backup of specified directories
 func (bm *BackupManager) CreateBackup(ctx context.Context) (*config.BackupMetadata, error) {
-	backupID := generateBackupID()
+	startTime := time.Now()
+
+	// Process each directory in the first job (for now, single job support)
+	if len(bm.config.Jobs) == 0 {
+		return nil, fmt.Errorf("no backup jobs configured")
+	}
+
+	job := bm.config.Jobs[0]
+	backupID := generateBackupID(job)
 	backupDir := filepath.Join(bm.backupPath, backupID)
 
-	// Create backup directory
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
+	// Create backup directory, never reusing an existing one
+	if err := os.MkdirAll(bm.backupPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup path: %w", err)
+	}
+	if err := os.Mkdir(backupDir, 0755); err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("backup directory already exists: %s", backupDir)
+		}
 		return nil, fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
+	// Remove partial data if the backup fails or is cancelled
+	completed := false
+	defer func() {
+		if !completed {
+			render.Printf("🧹 Removing incomplete backup: %s\n", backupDir)
+			os.RemoveAll(backupDir)
+		}
+	}()
+
+	// Let the garbage collector work harder instead of exceeding the memory cap
+	if limit := bm.config.Memory.LimitBytes(); limit > 0 {
+		debug.SetMemoryLimit(limit)
+	}
+
 	var backupDirs []config.BackupDirectory
+	var missing []string
+	summary := newSummaryBuilder()
 	totalSize := int64(0)
 	fileCount := 0
 
-	fmt.Printf("Creating backup: %s\n", backupID)
-	fmt.Printf("Backup directory: %s\n", backupDir)
+	render.Printf("Creating backup: %s\n", backupID)
+	render.Printf("Backup directory: %s\n", backupDir)
 
-	// Process each directory in the first job (for now, single job support)
-	if len(bm.config.Jobs) == 0 {
-		return nil, fmt.Errorf("no backup jobs configured")
+	// The manifest lists every file with its checksum for external indexing
+	var manifest *manifestWriter
+	if job.Manifest {
+		var err error
+		manifest, err = newManifestWriter(backupDir, ManifestRecord{BackupID: backupID, Job: job.Name, Host: Hostname()})
+		if err != nil {
+			return nil, err
+		}
+		manifest.sync = bm.config.Fsync()
+		defer manifest.file.Close()
 	}
 
-	job := bm.config.Jobs[0]
-
 	for _, dirConfig := range job.Directories {
-		fmt.Printf("Backing up directory: %s -> %s\n", dirConfig.Path, dirConfig.Name)
+		render.Printf("Backing up directory: %s -> %s\n", dirConfig.Path, dirConfig.Name)
 
 		// Check if source directory exists
-		if _, err := os.Stat(dirConfig.Path); os.IsNotExist(err) {
-			fmt.Printf("⚠️  Warning: Source directory does not exist: %s\n", dirConfig.Path)
+		if _, err := os.Stat(dirConfig.SourcePath()); os.IsNotExist(err) {
+			if dirConfig.Required {
+				return nil, fmt.Errorf("required source directory does not exist: %s", dirConfig.Path)
+			}
+			render.Printf("⚠️  Warning: Source directory does not exist, leaving it out: %s\n", dirConfig.Path)
+			missing = append(missing, dirConfig.Path)
 			continue
 		}
 
@@ -77,60 +140,86 @@ func (bm *BackupManager) CreateBackup(ctx context.Context) (*config.BackupMetada
 			return nil, fmt.Errorf("backup cancelled: %w", err)
 		}
 
-		// Create backup file
-		backupFile, err := os.Create(backupFilePath)
+		// The file index is streamed to disk alongside the archive instead of kept in memory
+		index, err := newIndexWriter(filepath.Join(backupDir, indexFileName(dirConfig.Name)))
 		if err != nil {
-			return nil, fmt.Errorf("failed to create backup file: %w", err)
-		}
-		defer backupFile.Close()
-
-		var writer io.Writer = backupFile
-		if dirConfig.Compression {
-			gzipWriter := gzip.NewWriter(backupFile)
-			defer gzipWriter.Close()
-			writer = gzipWriter
+			return nil, err
 		}
-
-		tarWriter := tar.NewWriter(writer)
-		defer tarWriter.Close()
+		index.sync = bm.config.Fsync()
 
 		// Backup the directory
-		dirSize, dirFileCount, err := bm.backupDirectory(ctx, tarWriter, dirConfig.Path, dirConfig.Name)
+		outputs := archiveOutputs{
+			index:    index,
+			manifest: manifest,
+			filters:  newFileFilters(dirConfig.Filters, bm.config.TempPath),
+			summary:  summary,
+		}
+		dirSize, dirFileCount, err := bm.writeArchive(ctx, backupFilePath, outputs, dirConfig)
+		if closeErr := index.Close(); err == nil {
+			err = closeErr
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to backup directory %s: %w", dirConfig.Path, err)
 		}
 
-		// Calculate checksum
-		checksum, err := bm.calculateChecksum(backupFilePath)
+		// Read the finished archive back so truncation is caught now, not at restore time
+		checksum, err := checkArchive(backupFilePath, dirConfig.Compression)
 		if err != nil {
-			return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+			return nil, fmt.Errorf("archive of %s failed verification: %w", dirConfig.Name, err)
 		}
 
 		backupDirInfo := config.BackupDirectory{
-			Path:        dirConfig.Path,
-			Name:        dirConfig.Name,
-			Size:        dirSize,
-			FileCount:   dirFileCount,
-			Permissions: make(map[string]config.FilePerm),
-			Checksum:    checksum,
-			Compressed:  dirConfig.Compression,
+			Path:       dirConfig.Path,
+			Name:       dirConfig.Name,
+			Size:       dirSize,
+			FileCount:  dirFileCount,
+			Index:      indexFileName(dirConfig.Name),
+			Checksum:   checksum,
+			Compressed: dirConfig.Compression,
+			Footer:     true,
+			Filters:    outputs.filters.Applied(),
 		}
 
 		backupDirs = append(backupDirs, backupDirInfo)
 		totalSize += dirSize
 		fileCount += dirFileCount
 
-		fmt.Printf("✅ Backed up %s: %d files, %d bytes\n", dirConfig.Name, dirFileCount, dirSize)
+		render.Printf("✅ Backed up %s: %d files, %d bytes\n", dirConfig.Name, dirFileCount, dirSize)
+	}
+
+	if manifest != nil {
+		if err := manifest.Close(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create metadata
 	metadata := &config.BackupMetadata{
-		ID:          backupID,
-		Timestamp:   time.Now(),
-		Directories: backupDirs,
-		TotalSize:   totalSize,
-		Checksum:    bm.calculateOverallChecksum(backupDirs),
-		Compressed:  job.Directories[0].Compression, // Assume all same compression for now
+		SchemaVersion:   config.MetadataSchemaVersion,
+		ID:              backupID,
+		Timestamp:       time.Now(),
+		Directories:     backupDirs,
+		TotalSize:       totalSize,
+		Checksum:        overallChecksum(backupDirs),
+		Compressed:      job.Directories[0].Compression, // Assume all same compression for now
+		JobID:           job.ID,
+		JobName:         job.Name,
+		Hostname:        Hostname(),
+		MachineID:       MachineID(),
+		BacktideVersion: Version,
+		Duration:        time.Since(startTime).Round(time.Millisecond).String(),
+		ConfigHash:      config.JobHash(job),
+		Comment:         bm.annotations.Comment,
+		Labels:          bm.annotations.Labels,
+		Missing:         missing,
+		Summary:         summary.Summary(),
+		RestoreApproval: job.RestoreApproval,
+	}
+	if manifest != nil {
+		metadata.Manifest = manifestFileName
+	}
+	if bm.annotations.KeepFor > 0 {
+		metadata.ExpiresAt = metadata.Timestamp.Add(bm.annotations.KeepFor)
 	}
 
 	// Save metadata
@@ -138,39 +227,140 @@ func (bm *BackupManager) CreateBackup(ctx context.Context) (*config.BackupMetada
 		return nil, fmt.Errorf("failed to save metadata: %w", err)
 	}
 
-	fmt.Printf("✅ Backup completed: %s\n", backupID)
-	fmt.Printf("📊 Summary: %d directories, %d total files, %d total bytes\n",
+	// Metadata marks a backup as complete, so it is synced last, followed by
+	// the directories holding the new entries
+	if bm.config.Fsync() {
+		if err := syncFile(filepath.Join(backupDir, "metadata.toml")); err != nil {
+			return nil, err
+		}
+		for _, dir := range []string{backupDir, bm.backupPath} {
+			if err := syncDir(dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	completed = true
+	render.Printf("✅ Backup completed: %s\n", backupID)
+	render.Printf("📊 Summary: %d directories, %d total files, %d total bytes\n",
 		len(backupDirs), fileCount, totalSize)
+	if len(missing) > 0 {
+		render.Printf("⚠️  %d source directories were missing and are not in the backup\n", len(missing))
+	}
 
 	return metadata, nil
 }
 
-// backupDirectory recursively backs up a directory to tar
-func (bm *BackupManager) backupDirectory(ctx context.Context, tarWriter *tar.Writer, sourceDir, backupName string) (int64, int, error) {
-	var totalSize int64
-	var fileCount int
+// archiveOutputs are what is recorded about each entry while a directory is archived
+type archiveOutputs struct {
+	index    *indexWriter
+	manifest *manifestWriter // nil unless the job writes a manifest
+	filters  *fileFilters    // nil unless the directory has filters
+	summary  *summaryBuilder
+}
 
-	err := filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
-		// Check for cancellation
-		if ctx.Err() != nil {
-			return fmt.Errorf("backup cancelled")
-		}
+// writeArchive writes a directory to an archive at path, closing it fully
+// before returning so the archive is complete on disk
+func (bm *BackupManager) writeArchive(ctx context.Context, path string, outputs archiveOutputs, dirConfig config.DirectoryConfig) (int64, int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
 
-		if err != nil {
-			return err
-		}
+	var writer io.Writer = file
+	var gzipWriter *gzip.Writer
+	if dirConfig.Compression {
+		gzipWriter = gzip.NewWriter(fault.Writer(fault.Compress, file))
+		writer = gzipWriter
+	}
+	archive := newArchiveWriter(writer)
 
-		// Skip the directory itself
-		if filePath == sourceDir {
-			return nil
+	size, count, err := bm.backupDirectory(ctx, archive, outputs, dirConfig)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return 0, 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return 0, 0, fmt.Errorf("failed to finish compression: %w", err)
 		}
+	}
+	if bm.config.Fsync() {
+		if err := file.Sync(); err != nil {
+			return 0, 0, fmt.Errorf("failed to sync backup file: %w", err)
+		}
+	}
+	// s3fs uploads on close, so this is where a failed upload shows
+	err = file.Close()
+	if err == nil {
+		err = fault.Fail(fault.Upload)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to close backup file: %w", err)
+	}
+	return size, count, nil
+}
+
+// backupDirectory recursively backs up a directory to tar, recording each entry
+// in the outputs. Entries pass through the directory's filters, if any, first.
+func (bm *BackupManager) backupDirectory(ctx context.Context, tarWriter *archiveWriter, outputs archiveOutputs, dirConfig config.DirectoryConfig) (int64, int, error) {
+	index, manifest, filters := outputs.index, outputs.manifest, outputs.filters
+	sourceDir, backupName := dirConfig.SourcePath(), dirConfig.Name
+	var totalSize int64
+	var fileCount int
 
+	// The source directory itself is recorded so its mode, owner and time are restored
+	rootInfo, err := os.Stat(sourceDir)
+	if err != nil {
+		return 0, 0, err
+	}
+	rootHeader, err := tar.FileInfoHeader(rootInfo, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	rootHeader.Name = backupName + "/"
+	rootHeader.Format = tar.FormatPAX
+	if err := tarWriter.WriteHeader(rootHeader); err != nil {
+		return 0, 0, err
+	}
+
+	err = walkBatched(ctx, sourceDir, bm.config.Memory.Batch(), func(filePath string, info os.FileInfo) error {
 		// Create relative path for tar header
 		relPath, err := filepath.Rel(sourceDir, filePath)
 		if err != nil {
 			return err
 		}
 		tarPath := filepath.Join(backupName, relPath)
+		// Filters and the manifest see the original path when archiving a copy
+		originalPath := filepath.Join(dirConfig.Path, relPath)
+
+		// Filters may leave the entry out or replace the file's content
+		var replacement *os.File
+		if filters != nil {
+			drop, filtered, err := filters.apply(ctx, originalPath, filePath, info)
+			if err != nil {
+				return err
+			}
+			if drop {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filtered != nil {
+				replacement = filtered
+				defer os.Remove(replacement.Name())
+				defer replacement.Close()
+				stat, err := replacement.Stat()
+				if err != nil {
+					return err
+				}
+				info = filteredInfo{FileInfo: info, size: stat.Size()}
+			}
+		}
 
 		// Create tar header
 		header, err := tar.FileInfoHeader(info, "")
@@ -178,24 +368,44 @@ func (bm *BackupManager) backupDirectory(ctx context.Context, tarWriter *tar.Wri
 			return err
 		}
 		header.Name = tarPath
+		// PAX headers keep paths over 255 bytes, newlines and non-ASCII names intact
+		header.Format = tar.FormatPAX
 
 		// Write header
 		if err := tarWriter.WriteHeader(header); err != nil {
 			return err
 		}
+		if err := index.Add(tarPath, info); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
 
 		// If it's a regular file, write its content
 		if info.Mode().IsRegular() {
-			file, err := os.Open(filePath)
-			if err != nil {
-				return err
+			var file io.Reader = replacement
+			if replacement == nil {
+				source, err := os.Open(filePath)
+				if err != nil {
+					return err
+				}
+				defer source.Close()
+				file = source
 			}
-			defer file.Close()
 
-			if _, err := io.Copy(tarWriter, file); err != nil {
+			var content io.Writer = tarWriter
+			hash := sha256.New()
+			if manifest != nil {
+				content = io.MultiWriter(tarWriter, hash)
+			}
+			if _, err := io.Copy(content, &contextReader{ctx: ctx, reader: file}); err != nil {
 				return err
 			}
+			if manifest != nil {
+				if err := manifest.Add(backupName, originalPath, info, hex.EncodeToString(hash.Sum(nil))); err != nil {
+					return fmt.Errorf("failed to write manifest: %w", err)
+				}
+			}
 
+			outputs.summary.Add(tarPath, info.Size())
 			totalSize += info.Size()
 			fileCount++
 		}
@@ -206,24 +416,22 @@ func (bm *BackupManager) backupDirectory(ctx context.Context, tarWriter *tar.Wri
 	return totalSize, fileCount, err
 }
 
-// calculateChecksum calculates SHA256 checksum of a file
-func (bm *BackupManager) calculateChecksum(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
+// contextReader aborts reads once its context is cancelled
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+// Read implements io.Reader
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("backup cancelled: %w", err)
 	}
-
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return r.reader.Read(p)
 }
 
-// calculateOverallChecksum calculates a combined checksum for all backup directories
-func (bm *BackupManager) calculateOverallChecksum(dirs []config.BackupDirectory) string {
+// overallChecksum calculates a combined checksum for all backup directories
+func overallChecksum(dirs []config.BackupDirectory) string {
 	hash := sha256.New()
 	for _, dir := range dirs {
 		hash.Write([]byte(dir.Checksum))
@@ -235,7 +443,7 @@ func (bm *BackupManager) calculateOverallChecksum(dirs []config.BackupDirectory)
 
 // RestoreBackup restores a backup to original locations
 func (bm *BackupManager) RestoreBackup(backupID string) error {
-	return bm.restoreBackupInternal(backupID, "")
+	return bm.restoreBackupInternal(context.Background(), backupID, "", nil)
 }
 
 // RestoreBackupToPath restores a backup to a custom target path
@@ -243,44 +451,130 @@ func (bm *BackupManager) RestoreBackupToPath(backupID string, targetPath string)
 	if targetPath == "" {
 		return fmt.Errorf("target path cannot be empty")
 	}
-	return bm.restoreBackupInternal(backupID, targetPath)
+	return bm.restoreBackupInternal(context.Background(), backupID, targetPath, nil)
+}
+
+// RestoreDirectories restores only the named directories of a backup; an empty
+// targetPath restores to original locations and an empty names list restores
+// everything. Cancelling ctx stops the restore between archive entries.
+func (bm *BackupManager) RestoreDirectories(ctx context.Context, backupID string, targetPath string, names []string) error {
+	return bm.restoreBackupInternal(ctx, backupID, targetPath, names)
+}
+
+// SelectDirectories returns the backup directories matching names, in backup order
+func SelectDirectories(metadata *config.BackupMetadata, names []string) ([]config.BackupDirectory, error) {
+	if len(names) == 0 {
+		return metadata.Directories, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var selected []config.BackupDirectory
+	var available []string
+	for _, dir := range metadata.Directories {
+		available = append(available, dir.Name)
+		if wanted[dir.Name] {
+			selected = append(selected, dir)
+			delete(wanted, dir.Name)
+		}
+	}
+
+	if len(wanted) > 0 {
+		var missing []string
+		for _, name := range names {
+			if wanted[name] {
+				missing = append(missing, name)
+			}
+		}
+		return nil, fmt.Errorf("directory not in backup %s: %s (available: %s)",
+			metadata.ID, strings.Join(missing, ", "), strings.Join(available, ", "))
+	}
+	return selected, nil
+}
+
+// RestoreToScratch restores a backup into a scratch directory that is
+// removed afterwards, to test it or run it in a container. Nothing in use is
+// overwritten, so restore approval is not required.
+func (bm *BackupManager) RestoreToScratch(ctx context.Context, backupID string, scratchDir string, names []string) error {
+	if scratchDir == "" {
+		return fmt.Errorf("scratch directory cannot be empty")
+	}
+	backupDir, err := bm.BackupDir(backupID)
+	if err != nil {
+		return err
+	}
+	return bm.extractBackup(ctx, backupDir, backupID, scratchDir, names)
 }
 
 // restoreBackupInternal handles the core restoration logic
-func (bm *BackupManager) restoreBackupInternal(backupID string, targetPath string) error {
-	backupDir := filepath.Join(bm.backupPath, backupID)
+func (bm *BackupManager) restoreBackupInternal(ctx context.Context, backupID string, targetPath string, names []string) error {
+	backupDir, err := bm.BackupDir(backupID)
+	if err != nil {
+		return err
+	}
+	metadata, err := bm.loadMetadata(backupDir)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+	if err := bm.CheckRestoreApproval(metadata); err != nil {
+		return err
+	}
+	return bm.extractBackup(ctx, backupDir, backupID, targetPath, names)
+}
 
-	// Check if backup exists
-	if _, err := os.Stat(backupDir); os.IsNotExist(err) {
-		return fmt.Errorf("backup not found: %s", backupID)
+// extractBackup restores the backup stored in backupDir, sandboxed if enabled
+func (bm *BackupManager) extractBackup(ctx context.Context, backupDir, backupID, targetPath string, names []string) error {
+	if bm.sandbox {
+		return bm.restoreInWorker(ctx, backupDir, backupID, targetPath, names)
 	}
+	return bm.restoreFromDir(ctx, backupDir, backupID, targetPath, names)
+}
 
+// restoreFromDir restores the backup stored in backupDir
+func (bm *BackupManager) restoreFromDir(ctx context.Context, backupDir, backupID, targetPath string, names []string) error {
 	// Load metadata
 	metadata, err := bm.loadMetadata(backupDir)
 	if err != nil {
 		return fmt.Errorf("failed to load metadata: %w", err)
 	}
 
-	fmt.Printf("Restoring backup: %s\n", backupID)
-	fmt.Printf("Backup date: %s\n", metadata.Timestamp.Format(time.RFC3339))
+	// Resolve the selection before touching any target
+	directories, err := SelectDirectories(metadata, names)
+	if err != nil {
+		return err
+	}
+
+	render.Printf("Restoring backup: %s\n", backupID)
+	render.Printf("Backup date: %s\n", metadata.Timestamp.Format(time.RFC3339))
+	if len(names) > 0 {
+		render.Printf("Restoring %d of %d directories\n", len(directories), len(metadata.Directories))
+	}
 
 	if targetPath != "" {
-		fmt.Printf("Target path: %s\n", targetPath)
+		render.Printf("Target path: %s\n", targetPath)
 		// Validate target path
 		if err := os.MkdirAll(targetPath, 0755); err != nil {
 			return fmt.Errorf("failed to create target directory: %w", err)
 		}
 	}
 
-	for _, dir := range metadata.Directories {
+	for _, dir := range directories {
 		// Determine target directory
 		actualTargetPath := dir.Path
 		if targetPath != "" {
 			// Use custom target path + directory name
-			actualTargetPath = filepath.Join(targetPath, dir.Name)
+			if err := checkDirectoryName(dir.Name); err != nil {
+				return err
+			}
+			if actualTargetPath, err = containedPath(targetPath, dir.Name); err != nil {
+				return err
+			}
 		}
 
-		fmt.Printf("Restoring directory: %s -> %s\n", dir.Name, actualTargetPath)
+		render.Printf("Restoring directory: %s -> %s\n", dir.Name, actualTargetPath)
 
 		// Create target directory
 		if err := os.MkdirAll(actualTargetPath, 0755); err != nil {
@@ -288,39 +582,40 @@ func (bm *BackupManager) restoreBackupInternal(backupID string, targetPath strin
 		}
 
 		// Find backup file
-		backupFileName := fmt.Sprintf("%s.tar", dir.Name)
-		if dir.Compressed {
-			backupFileName = fmt.Sprintf("%s.tar.gz", dir.Name)
+		backupFilePath, err := archivePath(backupDir, dir)
+		if err != nil {
+			return err
 		}
-		backupFilePath := filepath.Join(backupDir, backupFileName)
 
 		if _, err := os.Stat(backupFilePath); os.IsNotExist(err) {
 			return fmt.Errorf("backup file not found: %s", backupFilePath)
 		}
 
 		// Restore from tar
-		if err := bm.restoreFromTar(backupFilePath, actualTargetPath, dir.Compressed); err != nil {
+		if err := bm.restoreFromTar(ctx, backupFilePath, actualTargetPath, dir.Compressed, dir.Footer); err != nil {
 			return fmt.Errorf("failed to restore %s: %w", dir.Name, err)
 		}
 
-		fmt.Printf("✅ Restored %s: %d files, %d bytes\n", dir.Name, dir.FileCount, dir.Size)
+		render.Printf("✅ Restored %s: %d files, %d bytes\n", dir.Name, dir.FileCount, dir.Size)
 	}
 
-	fmt.Printf("✅ Restore completed: %s\n", backupID)
+	render.Printf("✅ Restore completed: %s\n", backupID)
 	return nil
 }
 
-// restoreFromTar extracts files from tar archive
-func (bm *BackupManager) restoreFromTar(tarPath, targetDir string, compressed bool) error {
+// restoreFromTar extracts files from tar archive, failing if the archive does
+// not match its footer; requireFooter rejects archives that lack one. It stops
+// between entries, and within file contents, once ctx is cancelled.
+func (bm *BackupManager) restoreFromTar(ctx context.Context, tarPath, targetDir string, compressed, requireFooter bool) error {
 	file, err := os.Open(tarPath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	var reader io.Reader = file
+	var reader io.Reader = &contextReader{ctx: ctx, reader: file}
 	if compressed {
-		gzipReader, err := gzip.NewReader(file)
+		gzipReader, err := gzip.NewReader(reader)
 		if err != nil {
 			return err
 		}
@@ -328,48 +623,80 @@ func (bm *BackupManager) restoreFromTar(tarPath, targetDir string, compressed bo
 		reader = gzipReader
 	}
 
-	tarReader := tar.NewReader(reader)
+	tarReader := newArchiveReader(reader, requireFooter)
 
+	// Directory modes and times are applied once their contents are restored
+	var dirs []restoredDir
 	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("restore cancelled: %w", err)
+		}
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("restore cancelled: %w", ctx.Err())
+			}
 			return err
 		}
 
 		// Skip the root backup name directory and extract relative paths
-		parts := strings.Split(header.Name, string(filepath.Separator))
-		if len(parts) > 1 {
-			relPath := filepath.Join(parts[1:]...)
-			targetPath := filepath.Join(targetDir, relPath)
-
+		targetPath, ok, err := entryTarget(targetDir, header.Name)
+		if err != nil {
+			return err
+		}
+		if ok {
 			// Create directory if needed
 			if header.Typeflag == tar.TypeDir {
-				if err := os.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
+				if err := checkParents(targetDir, targetPath); err != nil {
+					return err
+				}
+				// Kept writable until the end so read-only directories can be filled
+				if err := os.MkdirAll(targetPath, 0700); err != nil {
 					return err
 				}
+				if err := os.Chmod(targetPath, dirMode(header)|0700); err != nil {
+					render.Printf("⚠️  Warning: Failed to set permissions on %s: %v\n", targetPath, err)
+				}
+				bm.applyOwnership(targetPath, header)
+				dirs = append(dirs, restoredDir{path: targetPath, header: header})
 				continue
 			}
 
-			// Skip sockets and other special files that can't be restored
-			if header.Typeflag == tar.TypeFifo || header.Typeflag == tar.TypeChar ||
-				header.Typeflag == tar.TypeBlock || header.Typeflag == tar.TypeSymlink {
-				fmt.Printf("⚠️  Skipping special file: %s\n", header.Name)
+			// Skip sockets, links and other special files that can't be restored
+			if skippedEntry(header) {
+				if linkEscapes(targetDir, targetPath, header) {
+					render.Printf("⚠️  Skipping link pointing outside the restore target: %s -> %s\n", header.Name, header.Linkname)
+				} else {
+					render.Printf("⚠️  Skipping special file: %s\n", header.Name)
+				}
 				continue
 			}
 
+			// Never write through a symlink already in the target
+			if err := checkParents(targetDir, targetPath); err != nil {
+				return err
+			}
+
 			// Create parent directories
 			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
 				return err
 			}
 
+			// A symlink in place of the file is replaced rather than followed
+			if info, err := os.Lstat(targetPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(targetPath); err != nil {
+					return fmt.Errorf("failed to replace symlink %s: %w", targetPath, err)
+				}
+			}
+
 			// Create file
-			outFile, err := os.Create(targetPath)
+			outFile, err := os.OpenFile(targetPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, 0666)
 			if err != nil {
 				// If we can't create the file (permission issues), skip with warning
-				fmt.Printf("⚠️  Warning: Failed to create file %s: %v\n", targetPath, err)
+				render.Printf("⚠️  Warning: Failed to create file %s: %v\n", targetPath, err)
 				continue
 			}
 
@@ -378,32 +705,213 @@ func (bm *BackupManager) restoreFromTar(tarPath, targetDir string, compressed bo
 				outFile.Close()
 				// If copy fails, remove the partial file
 				os.Remove(targetPath)
-				fmt.Printf("⚠️  Warning: Failed to copy content to %s: %v\n", targetPath, err)
+				if ctx.Err() != nil {
+					return fmt.Errorf("restore cancelled: %w", ctx.Err())
+				}
+				render.Printf("⚠️  Warning: Failed to copy content to %s: %v\n", targetPath, err)
 				continue
 			}
 
 			// Set file permissions
 			if err := outFile.Chmod(os.FileMode(header.Mode)); err != nil {
 				outFile.Close()
-				fmt.Printf("⚠️  Warning: Failed to set permissions on %s: %v\n", targetPath, err)
+				render.Printf("⚠️  Warning: Failed to set permissions on %s: %v\n", targetPath, err)
 				// Continue anyway - better to have the file with wrong permissions than not at all
 			}
 
 			outFile.Close()
+			bm.applyOwnership(targetPath, header)
+			bm.applyTimes(targetPath, header)
+		}
+	}
+
+	bm.restoreDirMetadata(dirs)
+	return nil
+}
+
+// restoredDir is a restored directory whose mode and times are still to be set
+type restoredDir struct {
+	path   string
+	header *tar.Header
+}
+
+// dirMode returns the permissions of a directory entry, including the setgid
+// and sticky bits that shared and spool directories rely on
+func dirMode(header *tar.Header) os.FileMode {
+	return header.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+}
+
+// restoreDirMetadata sets the recorded mode and modification time of restored
+// directories, deepest first, after restoring files into them changed both
+func (bm *BackupManager) restoreDirMetadata(dirs []restoredDir) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		if err := os.Chmod(dir.path, dirMode(dir.header)); err != nil {
+			render.Printf("⚠️  Warning: Failed to set permissions on %s: %v\n", dir.path, err)
 		}
+		bm.applyTimes(dir.path, dir.header)
 	}
+}
+
+// applyTimes sets the recorded modification time of a restored path, and its
+// access time if requested and recorded; a zero time leaves it unchanged
+func (bm *BackupManager) applyTimes(path string, header *tar.Header) {
+	if bm.noTimes {
+		return
+	}
+	var accessTime time.Time
+	if bm.atimes {
+		accessTime = header.AccessTime
+	}
+	if err := os.Chtimes(path, accessTime, header.ModTime); err != nil {
+		render.Printf("⚠️  Warning: Failed to set times on %s: %v\n", path, err)
+	}
+}
+
+// skippedEntry reports whether an archive entry is a special file or link,
+// which restores leave out
+func skippedEntry(header *tar.Header) bool {
+	switch header.Typeflag {
+	case tar.TypeFifo, tar.TypeChar, tar.TypeBlock, tar.TypeSymlink, tar.TypeLink:
+		return true
+	}
+	return false
+}
+
+// archivePath returns the path of a directory's archive within a backup
+func archivePath(backupDir string, dir config.BackupDirectory) (string, error) {
+	if err := checkDirectoryName(dir.Name); err != nil {
+		return "", err
+	}
+	if dir.Compressed {
+		return containedPath(backupDir, dir.Name+".tar.gz")
+	}
+	return containedPath(backupDir, dir.Name+".tar")
+}
 
+// checkDirectoryName rejects a directory name from a backup's metadata that
+// is not a single path element; metadata is read from storage other hosts can
+// write, and the name is joined into archive and restore target paths
+func checkDirectoryName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.IsAbs(name) || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("unsafe directory name in backup metadata: %q", name)
+	}
 	return nil
 }
 
-// ListBackups lists available backups
+// entryTarget returns where an archive entry is restored below targetDir; the
+// archive's root directory itself is not restored. Absolute names and ".."
+// components are rejected rather than cleaned, as no backup contains them
+func entryTarget(targetDir, name string) (string, bool, error) {
+	if strings.HasPrefix(name, "/") || strings.ContainsRune(name, 0) {
+		return "", false, fmt.Errorf("unsafe archive entry: %q", name)
+	}
+	parts := strings.Split(name, string(filepath.Separator))
+	for _, part := range parts {
+		if part == ".." {
+			return "", false, fmt.Errorf("unsafe archive entry: %q", name)
+		}
+	}
+	if len(parts) < 2 {
+		return "", false, nil
+	}
+	targetPath, err := containedPath(targetDir, filepath.Join(parts[1:]...))
+	if err != nil {
+		return "", false, err
+	}
+	return targetPath, true, nil
+}
+
+// containedPath joins relPath to targetDir, rejecting paths that would escape it
+func containedPath(targetDir, relPath string) (string, error) {
+	targetPath := filepath.Join(targetDir, relPath)
+	if !within(targetDir, targetPath) {
+		return "", fmt.Errorf("archive entry escapes restore target: %s", relPath)
+	}
+	return targetPath, nil
+}
+
+// within reports whether path is dir or lies below it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../") && !filepath.IsAbs(rel)
+}
+
+// linkEscapes reports whether a link entry restored at targetPath would point
+// outside targetDir
+func linkEscapes(targetDir, targetPath string, header *tar.Header) bool {
+	link := header.Linkname
+	if link == "" {
+		return false
+	}
+	if header.Typeflag == tar.TypeLink {
+		// Hard link names are archive paths like the entry names
+		_, _, err := entryTarget(targetDir, link)
+		return err != nil
+	}
+	if !filepath.IsAbs(link) {
+		link = filepath.Join(filepath.Dir(targetPath), link)
+	}
+	return !within(targetDir, link)
+}
+
+// checkParents fails if a symlink already in the target would lead a restored
+// entry outside of it, e.g. a directory replaced with a link to /etc
+func checkParents(targetDir, targetPath string) error {
+	if filepath.Clean(targetPath) == filepath.Clean(targetDir) {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(targetDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(targetDir, filepath.Dir(targetPath))
+	if err != nil || rel == "." {
+		return err
+	}
+	current := targetDir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(current)
+		if err != nil {
+			return fmt.Errorf("restore target contains a broken symlink: %s", current)
+		}
+		if !within(root, resolved) {
+			return fmt.Errorf("restore target contains a symlink leading outside of it: %s -> %s (restore with --target instead)", current, resolved)
+		}
+	}
+	return nil
+}
+
+// ListBackups lists available backups, including those of other hosts sharing the backup path
 func (bm *BackupManager) ListBackups() ([]config.BackupMetadata, error) {
-	return bm.listBackupsFromPath(bm.backupPath)
+	return bm.ListBackupsFromPath(bm.backupPath)
 }
 
-// ListBackupsFromPath lists backups from a specific path (config-independent)
+// ListBackupsFromPath lists backups from a specific path and its per-host directories (config-independent)
 func (bm *BackupManager) ListBackupsFromPath(path string) ([]config.BackupMetadata, error) {
-	return bm.listBackupsFromPath(path)
+	var backups []config.BackupMetadata
+	for _, dir := range backupDirs(path) {
+		found, err := bm.listBackupsFromPath(dir)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, found...)
+	}
+	return backups, nil
 }
 
 // listBackupsFromPath is the internal implementation for listing backups
@@ -428,7 +936,7 @@ func (bm *BackupManager) listBackupsFromPath(path string) ([]config.BackupMetada
 			backupDir := filepath.Join(path, entry.Name())
 			metadata, err := bm.loadMetadata(backupDir)
 			if err != nil {
-				fmt.Printf("Warning: Failed to load metadata for %s: %v\n", entry.Name(), err)
+				render.Printf("Warning: Failed to load metadata for %s: %v\n", entry.Name(), err)
 				continue
 			}
 			backups = append(backups, *metadata)
@@ -447,12 +955,13 @@ func (bm *BackupManager) CleanupBackups() error {
 	job := bm.config.Jobs[0]
 	retention := job.Retention
 
-	backups, err := bm.ListBackups()
+	// Only backups stored directly in the backup path; other hosts' directories are left alone
+	backups, err := bm.listBackupsFromPath(bm.backupPath)
 	if err != nil {
 		return fmt.Errorf("failed to list backups: %w", err)
 	}
 
-	fmt.Printf("Cleaning up backups based on retention: %d days, %d recent, %d monthly\n",
+	render.Printf("Cleaning up backups based on retention: %d days, %d recent, %d monthly\n",
 		retention.KeepDays, retention.KeepCount, retention.KeepMonthly)
 
 	// Sort backups by timestamp (newest first)
@@ -465,9 +974,48 @@ func (bm *BackupManager) CleanupBackups() error {
 	}
 
 	removedCount := 0
-	cutoffTime := time.Now().AddDate(0, 0, -retention.KeepDays)
+	var removed, removedIDs []string
+	var removeErr error
+	remove := func(backup config.BackupMetadata, reason string) {
+		backupDir := filepath.Join(bm.backupPath, backup.ID)
+		if err := os.RemoveAll(backupDir); err != nil {
+			render.Printf("Warning: Failed to remove backup %s: %v\n", backup.ID, err)
+			removeErr = fmt.Errorf("failed to remove backup %s: %w", backup.ID, err)
+			return
+		}
+		render.Printf("Removed %s backup: %s (%s)\n", reason, backup.ID, backup.Timestamp.Format("2006-01-02"))
+		removed = append(removed, fmt.Sprintf("removed backup %s (%s) from %s", backup.ID, backup.Timestamp.Format("2006-01-02 15:04:05"), bm.backupPath))
+		removedCount++
+		removedIDs = append(removedIDs, backup.ID)
+		if err := state.RemoveBackup(bm.backupPath, backup.ID); err != nil {
+			render.Printf("Warning: Failed to update catalog: %v\n", err)
+		}
+	}
 
-	for i, backup := range backups {
+	// Pinned backups are kept and do not take the place of unpinned ones; backups
+	// with an explicit expiry follow it instead of the retention policy
+	now := time.Now()
+	retained := backups[:0]
+	for _, backup := range backups {
+		switch {
+		case backup.Pinned:
+			render.Printf("Keeping pinned backup: %s (%s)\n", backup.ID, backup.Timestamp.Format("2006-01-02"))
+		case !backup.ExpiresAt.IsZero() && now.Before(backup.ExpiresAt):
+			render.Printf("Keeping backup until %s: %s (%s)\n", backup.ExpiresAt.Format("2006-01-02"), backup.ID, backup.Timestamp.Format("2006-01-02"))
+		case !backup.ExpiresAt.IsZero():
+			remove(backup, "expired")
+		default:
+			retained = append(retained, backup)
+		}
+	}
+	backups = retained
+
+	cutoffTime := now.AddDate(0, 0, -retention.KeepDays)
+
+	// Undersized backups do not take the place of complete ones in keep_count
+	counted := 0
+	var kept []config.BackupMetadata
+	for _, backup := range backups {
 		shouldRemove := false
 
 		// Remove if older than retention days
@@ -476,36 +1024,114 @@ func (bm *BackupManager) CleanupBackups() error {
 		}
 
 		// Remove if beyond recent count (keep the most recent ones)
-		if i >= retention.KeepCount {
-			shouldRemove = true
+		if !backup.Undersized {
+			if counted >= retention.KeepCount {
+				shouldRemove = true
+			}
+			counted++
 		}
 
 		// TODO: Implement monthly retention logic
 
 		if shouldRemove {
-			backupDir := filepath.Join(bm.backupPath, backup.ID)
-			if err := os.RemoveAll(backupDir); err != nil {
-				fmt.Printf("Warning: Failed to remove backup %s: %v\n", backup.ID, err)
-			} else {
-				fmt.Printf("Removed old backup: %s (%s)\n", backup.ID, backup.Timestamp.Format("2006-01-02"))
-				removedCount++
+			remove(backup, "old")
+		} else {
+			kept = append(kept, backup)
+		}
+	}
+
+	// A full backup disk fails the next run, so min_free_space prunes the
+	// oldest backups beyond the policy, always keeping the newest one and the
+	// newest that is not undersized; bucket mounts do not report meaningful
+	// free space
+	if minFree, _ := retention.MinFreeBytes(); minFree > 0 && bm.jobBucket(job) == nil && len(kept) > 0 {
+		complete := kept[0].ID
+		for _, backup := range kept {
+			if !backup.Undersized {
+				complete = backup.ID
+				break
 			}
 		}
+		for i := len(kept) - 1; i > 0; i-- {
+			if kept[i].ID == complete {
+				continue
+			}
+			free, _, err := utils.GetDiskSpace(bm.backupPath)
+			if err != nil {
+				render.Printf("Warning: Failed to check free space of %s: %v\n", bm.backupPath, err)
+				break
+			}
+			if int64(free) >= minFree {
+				break
+			}
+			render.Printf("Free space %s is below min_free_space %s\n", utils.FormatBytes(int64(free)), utils.FormatBytes(minFree))
+			remove(kept[i], "oldest")
+		}
+	}
+
+	// Record deletions so it is traceable who removed which backups
+	if len(removed) > 0 || removeErr != nil {
+		audit.RecordResult("cleanup", job.Name, removed, removeErr)
 	}
 
-	fmt.Printf("✅ Cleanup completed: removed %d old backups\n", removedCount)
+	bm.cleanupVersions(job, removedIDs)
+
+	render.Printf("✅ Cleanup completed: removed %d old backups\n", removedCount)
 	return nil
 }
 
 // GetBackupInfo returns information about a specific backup
 func (bm *BackupManager) GetBackupInfo(backupID string) (*config.BackupMetadata, error) {
-	backupDir := filepath.Join(bm.backupPath, backupID)
+	backupDir, err := bm.BackupDir(backupID)
+	if err != nil {
+		return nil, err
+	}
 	return bm.loadMetadata(backupDir)
 }
 
-// generateBackupID generates a unique backup ID
-func generateBackupID() string {
-	return fmt.Sprintf("backup-%d", time.Now().Unix())
+// SetHost restricts restores and lookups to backups written by host, given as hostname or machine ID
+func (bm *BackupManager) SetHost(host string) {
+	bm.host = host
+}
+
+// SetRestoreTimes sets which recorded times restores apply: modification
+// times unless noTimes, and access times as well with atimes
+func (bm *BackupManager) SetRestoreTimes(noTimes, atimes bool) {
+	bm.noTimes = noTimes
+	bm.atimes = atimes
+}
+
+// SetRestoreSandbox makes restores run in a worker restricted to reading the
+// backup and writing the restore targets
+func (bm *BackupManager) SetRestoreSandbox(sandbox bool) {
+	bm.sandbox = sandbox
+}
+
+// SetAnnotations sets the comment and labels recorded in the metadata of created backups
+func (bm *BackupManager) SetAnnotations(annotations Annotations) {
+	bm.annotations = annotations
+}
+
+// generateBackupID generates a backup ID from the time, the job and a random
+// suffix, so jobs starting in the same second cannot collide
+func generateBackupID(job config.BackupJob) string {
+	jobID := job.ID
+	if jobID == "" {
+		jobID = job.Name
+	}
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("backup-%s-%s-%s", time.Now().Format("20060102-150405"), idSafe(jobID), hex.EncodeToString(suffix))
+}
+
+// idSafe replaces characters that are awkward in directory names
+func idSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
 }
 
 // saveMetadata saves backup metadata to a file
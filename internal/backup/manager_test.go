@@ -0,0 +1,499 @@
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/approval"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// unusualFiles maps relative paths that have broken archives before to their contents
+func unusualFiles() map[string]string {
+	longDir := filepath.Join(strings.Repeat("d", 60), strings.Repeat("e", 60), strings.Repeat("f", 60), strings.Repeat("g", 60))
+	return map[string]string{
+		filepath.Join(longDir, strings.Repeat("n", 200)): "long path",
+		strings.Repeat("x", 255):                         "longest file name",
+		"new\nline.txt":                                  "newline",
+		"tab\tand space .txt":                            "whitespace",
+		"ünïcödé-日本語-😀.txt":                              "utf-8",
+		"invalid-\xff\xfe.txt":                           "invalid utf-8",
+		"Case.txt":                                       "upper",
+		"case.txt":                                       "lower",
+		"CASE.TXT":                                       "all caps",
+	}
+}
+
+func newTestManager(t *testing.T, source string, compression bool) *BackupManager {
+	t.Helper()
+	return NewBackupManager(config.BackupConfig{
+		BackupPath: t.TempDir(),
+		Jobs: []config.BackupJob{{
+			Name:        "test",
+			Directories: []config.DirectoryConfig{{Path: source, Name: "data", Compression: compression}},
+		}},
+	})
+}
+
+func TestBackupRestoreUnusualFileNames(t *testing.T) {
+	for _, compression := range []bool{false, true} {
+		source := t.TempDir()
+		files := unusualFiles()
+		for name, content := range files {
+			path := filepath.Join(source, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to create %q: %v", name, err)
+			}
+		}
+
+		bm := newTestManager(t, source, compression)
+		metadata, err := bm.CreateBackup(context.Background())
+		if err != nil {
+			t.Fatalf("backup failed (compression=%v): %v", compression, err)
+		}
+		if got := metadata.Directories[0].FileCount; got != len(files) {
+			t.Errorf("backed up %d files, want %d", got, len(files))
+		}
+
+		target := t.TempDir()
+		if err := bm.RestoreBackupToPath(metadata.ID, target); err != nil {
+			t.Fatalf("restore failed (compression=%v): %v", compression, err)
+		}
+
+		for name, want := range files {
+			got, err := os.ReadFile(filepath.Join(target, "data", name))
+			if err != nil {
+				t.Errorf("restored file %q missing: %v", name, err)
+				continue
+			}
+			if string(got) != want {
+				t.Errorf("restored file %q = %q, want %q", name, got, want)
+			}
+		}
+	}
+}
+
+// writeTestArchive writes an uncompressed archive of headers, each regular
+// file containing its own name
+func writeTestArchive(t *testing.T, headers ...tar.Header) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "data.tar")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	tw := tar.NewWriter(file)
+	for _, header := range headers {
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(header.Name))
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			tw.Write([]byte(header.Name))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestRestoreDirectoryMetadata(t *testing.T) {
+	source := t.TempDir()
+	dirs := map[string]os.FileMode{
+		".":           0750,
+		"empty":       0700,
+		"spool":       0770 | os.ModeSetgid | os.ModeSticky,
+		"spool/cur":   0700,
+		"locked":      0555,
+		"locked/seed": 0755,
+	}
+	for _, name := range []string{"empty", "spool", "spool/cur", "locked", "locked/seed"} {
+		if err := os.Mkdir(filepath.Join(source, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(source, "spool", "cur", "mail"), []byte("mail"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"locked/seed", "locked", "spool/cur", "spool", "empty", "."} {
+		path := filepath.Join(source, name)
+		if err := os.Chmod(path, dirs[name]); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bm := newTestManager(t, source, false)
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	target := t.TempDir()
+	if err := bm.RestoreBackupToPath(metadata.ID, target); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	for name, mode := range dirs {
+		info, err := os.Stat(filepath.Join(target, "data", name))
+		if err != nil {
+			t.Errorf("directory %q not restored: %v", name, err)
+			continue
+		}
+		if got := info.Mode() & (os.ModePerm | os.ModeSetgid | os.ModeSticky); got != mode {
+			t.Errorf("directory %q mode = %v, want %v", name, got, mode)
+		}
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf("directory %q modified %v, want %v", name, info.ModTime(), modTime)
+		}
+	}
+}
+
+func TestRestoreFileTimes(t *testing.T) {
+	source := t.TempDir()
+	path := filepath.Join(source, "file")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	accessTime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, accessTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	bm := newTestManager(t, source, true)
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		noTimes        bool
+		atimes         bool
+		wantModTime    bool
+		wantAccessTime bool
+	}{
+		{name: "default", wantModTime: true},
+		{name: "atime", atimes: true, wantModTime: true, wantAccessTime: true},
+		{name: "no times", noTimes: true, atimes: true},
+	}
+	for _, tt := range tests {
+		bm.SetRestoreTimes(tt.noTimes, tt.atimes)
+		target := t.TempDir()
+		if err := bm.RestoreBackupToPath(metadata.ID, target); err != nil {
+			t.Fatalf("%s: restore failed: %v", tt.name, err)
+		}
+		info, err := os.Stat(filepath.Join(target, "data", "file"))
+		if err != nil {
+			t.Fatalf("%s: file not restored: %v", tt.name, err)
+		}
+		if got := info.ModTime().Equal(modTime); got != tt.wantModTime {
+			t.Errorf("%s: modification time %v restored = %v, want %v", tt.name, info.ModTime(), got, tt.wantModTime)
+		}
+		stat := info.Sys().(*syscall.Stat_t)
+		restoredAccess := time.Unix(stat.Atim.Sec, stat.Atim.Nsec)
+		if got := restoredAccess.Equal(accessTime); got != tt.wantAccessTime {
+			t.Errorf("%s: access time %v restored = %v, want %v", tt.name, restoredAccess, got, tt.wantAccessTime)
+		}
+	}
+}
+
+func TestRestoreRejectsEscapingPaths(t *testing.T) {
+	tests := map[string]string{
+		"parent":       "data/../../escaped.txt",
+		"absolute":     "/data/escaped.txt",
+		"inner parent": "data/sub/../escaped.txt",
+	}
+	for name, entry := range tests {
+		archive := writeTestArchive(t, tar.Header{Name: entry, Typeflag: tar.TypeReg})
+		target := filepath.Join(t.TempDir(), "target")
+		bm := NewBackupManager(config.BackupConfig{})
+		if err := bm.restoreFromTar(context.Background(), archive, target, false, false); err == nil {
+			t.Errorf("%s: restore of %q succeeded", name, entry)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(target), "escaped.txt")); !os.IsNotExist(err) {
+			t.Errorf("%s: escaping entry was written outside the target", name)
+		}
+	}
+}
+
+func TestRestoreRejectsEscapingDirectoryNames(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "escaped.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bm := newTestManager(t, source, false)
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	backupDir := filepath.Join(bm.backupPath, metadata.ID)
+	archive, err := archivePath(backupDir, metadata.Directories[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../../outside", "/tmp/outside", "..", ".", "", "sub/data"} {
+		// Metadata crafted on shared storage, with an archive placed where the name points
+		crafted := *metadata
+		crafted.Directories = []config.BackupDirectory{metadata.Directories[0]}
+		crafted.Directories[0].Name = name
+		if err := bm.saveMetadata(backupDir, &crafted); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(name, "../") {
+			data, err := os.ReadFile(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(backupDir, name+".tar"), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		parent := t.TempDir()
+		target := filepath.Join(parent, "a", "b")
+		if err := bm.RestoreBackupToPath(metadata.ID, target); err == nil {
+			t.Errorf("restore with directory name %q succeeded", name)
+		}
+		if _, err := bm.PlanRestore(metadata.ID, target, nil); err == nil {
+			t.Errorf("restore plan with directory name %q succeeded", name)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "outside")); !os.IsNotExist(err) {
+			t.Errorf("directory name %q restored outside the target", name)
+		}
+	}
+}
+
+func TestRestoreDoesNotFollowLinks(t *testing.T) {
+	outside := t.TempDir()
+	victim := filepath.Join(outside, "victim")
+	if err := os.WriteFile(victim, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bm := NewBackupManager(config.BackupConfig{})
+
+	// Links in the archive are skipped, so entries below them stay in the target
+	target := t.TempDir()
+	archive := writeTestArchive(t,
+		tar.Header{Name: "data/link", Typeflag: tar.TypeSymlink, Linkname: outside},
+		tar.Header{Name: "data/link/escaped.txt", Typeflag: tar.TypeReg},
+		tar.Header{Name: "data/hard", Typeflag: tar.TypeLink, Linkname: "data/../../victim"},
+	)
+	if err := bm.restoreFromTar(context.Background(), archive, target, false, false); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("entry below an archived symlink was written outside the target")
+	}
+	if _, err := os.Lstat(filepath.Join(target, "hard")); !os.IsNotExist(err) {
+		t.Error("hard link entry was restored")
+	}
+
+	// A symlink already in the target is not written through
+	target = t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(target, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	archive = writeTestArchive(t, tar.Header{Name: "data/sub/escaped.txt", Typeflag: tar.TypeReg})
+	if err := bm.restoreFromTar(context.Background(), archive, target, false, false); err == nil {
+		t.Error("restore through a symlink leading outside the target succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("entry was written through a symlink outside the target")
+	}
+
+	// A symlink in place of a file is replaced by the file
+	target = t.TempDir()
+	if err := os.Symlink(victim, filepath.Join(target, "victim")); err != nil {
+		t.Fatal(err)
+	}
+	archive = writeTestArchive(t, tar.Header{Name: "data/victim", Typeflag: tar.TypeReg})
+	if err := bm.restoreFromTar(context.Background(), archive, target, false, false); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if got, _ := os.ReadFile(victim); string(got) != "original" {
+		t.Errorf("file outside the target was overwritten with %q", got)
+	}
+	if info, err := os.Lstat(filepath.Join(target, "victim")); err != nil || !info.Mode().IsRegular() {
+		t.Error("symlink in the target was not replaced by the restored file")
+	}
+}
+
+// cancelAfter is a context that is cancelled once Err has been called calls times
+type cancelAfter struct {
+	context.Context
+	calls int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls--; c.calls < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestRestoreStopsWhenCancelled(t *testing.T) {
+	source := t.TempDir()
+	const files = 50
+	for i := 0; i < files; i++ {
+		if err := os.WriteFile(filepath.Join(source, fmt.Sprintf("file%02d.txt", i)), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bm := newTestManager(t, source, false)
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+
+	// Cancelled a few entries into the archive
+	target := t.TempDir()
+	err = bm.RestoreDirectories(&cancelAfter{Context: context.Background(), calls: 10}, metadata.ID, target, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled restore returned %v, want %v", err, context.Canceled)
+	}
+	restored, _ := os.ReadDir(filepath.Join(target, "data"))
+	if len(restored) >= files {
+		t.Errorf("cancelled restore restored all %d files", len(restored))
+	}
+}
+
+func TestRestoreDetectsTruncatedArchive(t *testing.T) {
+	source := t.TempDir()
+	for i := range 3 {
+		name := filepath.Join(source, strings.Repeat("f", i+1))
+		if err := os.WriteFile(name, []byte(strings.Repeat("x", 2000)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bm := newTestManager(t, source, false)
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	if !metadata.Directories[0].Footer {
+		t.Fatal("backup did not record the archive footer")
+	}
+
+	// Cut the archive at a block boundary, where tar itself sees a clean end
+	archive, err := archivePath(filepath.Join(bm.backupPath, metadata.ID), metadata.Directories[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(archive, info.Size()/2/512*512); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := checkArchive(archive, false); err == nil {
+		t.Error("check of a truncated archive succeeded")
+	}
+	if err := bm.RestoreBackupToPath(metadata.ID, t.TempDir()); err == nil {
+		t.Error("restore of a truncated archive succeeded")
+	}
+}
+
+func TestRestoreRequiresApproval(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bm := newTestManager(t, source, false)
+	bm.config.Jobs[0].RestoreApproval = true
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+
+	// Restores by path know nothing of the job but the backup's metadata
+	restorer := NewBackupManager(config.BackupConfig{BackupPath: bm.backupPath})
+	if err := restorer.RestoreBackupToPath(metadata.ID, t.TempDir()); err == nil || !strings.Contains(err.Error(), "need approval") {
+		t.Errorf("restore without approval: got %v, want an approval error", err)
+	}
+	restorer.SetRestoreApproval("123456")
+	if err := restorer.RestoreBackupToPath(metadata.ID, t.TempDir()); err == nil {
+		t.Error("restore approved with a TOTP code but no totp_secret_file succeeded")
+	}
+	if restorer.RestoreApprovedBy() != "" {
+		t.Errorf("RestoreApprovedBy() = %q for a refused restore", restorer.RestoreApprovedBy())
+	}
+
+	scratch := t.TempDir()
+	if err := restorer.RestoreToScratch(context.Background(), metadata.ID, scratch, nil); err != nil {
+		t.Fatalf("scratch restore failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(scratch, "data", "file")); err != nil {
+		t.Errorf("scratch restore is missing the file: %v", err)
+	}
+}
+
+func TestRestoreRejectsReplayedTOTP(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("the TOTP secret must be owned by root")
+	}
+	state.SetDir(t.TempDir())
+	t.Cleanup(func() { state.SetDir("") })
+
+	secret, err := approval.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretFile := filepath.Join(t.TempDir(), "approval.totp")
+	if err := os.WriteFile(secretFile, []byte(secret), 0600); err != nil {
+		t.Fatal(err)
+	}
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bm := newTestManager(t, source, false)
+	bm.config.Jobs[0].RestoreApproval = true
+	bm.config.Approval.TOTPSecretFile = secretFile
+	metadata, err := bm.CreateBackup(context.Background())
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+
+	code, err := approval.TOTP(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bm.SetRestoreApproval(code)
+	if err := bm.RestoreBackupToPath(metadata.ID, t.TempDir()); err != nil {
+		t.Fatalf("restore with a TOTP code failed: %v", err)
+	}
+
+	// Another session presenting the same code within its window
+	replay := NewBackupManager(bm.config)
+	replay.SetRestoreApproval(code)
+	if err := replay.RestoreBackupToPath(metadata.ID, t.TempDir()); !errors.Is(err, state.ErrTOTPUsed) {
+		t.Errorf("restore with a replayed TOTP code: got %v, want %v", err, state.ErrTOTPUsed)
+	}
+}
@@ -0,0 +1,72 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// manifestFileName is the file manifest written next to a backup's archives
+const manifestFileName = "manifest.jsonl"
+
+// ManifestRecord describes one file of a backup in its manifest. Records carry
+// the backup, job and host, so manifests of many backups and hosts can be
+// loaded into one index to find which backups contain a file.
+type ManifestRecord struct {
+	BackupID  string `json:"backup_id"`
+	Job       string `json:"job"`
+	Host      string `json:"host"`
+	Directory string `json:"directory"` // name of the backed up directory
+	Path      string `json:"path"`      // absolute path of the file when it was backed up
+	Size      int64  `json:"size"`
+	ModTime   string `json:"mod_time"`
+	SHA256    string `json:"sha256"`
+}
+
+// manifestWriter streams manifest records to a JSON lines file
+type manifestWriter struct {
+	file     *os.File
+	buffer   *bufio.Writer
+	encoder  *json.Encoder
+	template ManifestRecord
+	// sync flushes the file to stable storage on Close
+	sync bool
+}
+
+// newManifestWriter creates the manifest of the backup in backupDir
+func newManifestWriter(backupDir string, template ManifestRecord) (*manifestWriter, error) {
+	file, err := os.Create(filepath.Join(backupDir, manifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest: %w", err)
+	}
+	buffer := bufio.NewWriter(file)
+	return &manifestWriter{file: file, buffer: buffer, encoder: json.NewEncoder(buffer), template: template}, nil
+}
+
+// Add writes the record of a regular file
+func (w *manifestWriter) Add(directory, path string, info os.FileInfo, sha256 string) error {
+	record := w.template
+	record.Directory = directory
+	record.Path = path
+	record.Size = info.Size()
+	record.ModTime = info.ModTime().UTC().Format("2006-01-02T15:04:05Z")
+	record.SHA256 = sha256
+	return w.encoder.Encode(record)
+}
+
+// Close flushes and closes the manifest
+func (w *manifestWriter) Close() error {
+	if err := w.buffer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if w.sync {
+		if err := w.file.Sync(); err != nil {
+			w.file.Close()
+			return fmt.Errorf("failed to sync manifest: %w", err)
+		}
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/s3fs"
+)
+
+// BucketMount makes a bucket available at its mount point
+type BucketMount interface {
+	// Prepare installs s3fs and writes the bucket's credentials
+	Prepare() error
+	// Acquire mounts the bucket, or checks an existing mount, for holder and
+	// returns a function releasing it
+	Acquire(holder string, timeout time.Duration) (func(), error)
+}
+
+// s3fsMount mounts buckets with s3fs
+type s3fsMount struct {
+	*s3fs.S3FSManager
+}
+
+// Prepare implements BucketMount
+func (m s3fsMount) Prepare() error {
+	if err := m.InstallS3FS(); err != nil {
+		return fmt.Errorf("failed to install S3FS: %w", err)
+	}
+	if err := m.SetupS3FS(); err != nil {
+		return fmt.Errorf("failed to setup S3FS: %w", err)
+	}
+	return nil
+}
+
+// SetBucketMount replaces how buckets are mounted, e.g. with a directory
+// standing in for the bucket in tests
+func (br *BackupRunner) SetBucketMount(mount func(config.BucketConfig) BucketMount) {
+	br.bucketMount = mount
+}
+
+// mountFor returns the mount of a bucket
+func (br *BackupRunner) mountFor(bucket config.BucketConfig) BucketMount {
+	if br.bucketMount != nil {
+		return br.bucketMount(bucket)
+	}
+	return s3fsMount{s3fs.NewS3FSManager(bucket)}
+}
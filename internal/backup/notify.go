@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// notificationData is what notification templates see: the event's fields
+// and its outcome, e.g. "succeeded" for backup.succeeded
+type notificationData struct {
+	plugin.Event
+	Status string
+}
+
+// renderNotification sets the event's title and message from the plugin's
+// templates; if they fail, the event keeps its previous text
+func renderNotification(event plugin.Event, cfg config.PluginConfig) plugin.Event {
+	title, message, err := cfg.NotifyTemplates()
+	if err != nil {
+		render.Printf("Warning: Notifier %s: %v\n", cfg.Name, err)
+		return event
+	}
+	data := notificationData{Event: event}
+	_, data.Status, _ = strings.Cut(event.Type, ".")
+
+	var titleText, messageText strings.Builder
+	if err := title.Execute(&titleText, data); err != nil {
+		render.Printf("Warning: Notifier %s: failed to render title: %v\n", cfg.Name, err)
+		return event
+	}
+	if err := message.Execute(&messageText, data); err != nil {
+		render.Printf("Warning: Notifier %s: failed to render message: %v\n", cfg.Name, err)
+		return event
+	}
+	event.Title, event.Message = titleText.String(), messageText.String()
+	return event
+}
+
+// templatedNotifier renders a configured notifier's own title and message
+// templates before delivering an event
+type templatedNotifier struct {
+	plugin.Notifier
+	config config.PluginConfig
+}
+
+// Notify implements plugin.Notifier
+func (n *templatedNotifier) Notify(ctx context.Context, event plugin.Event) error {
+	if n.config.Title != "" || n.config.Message != "" {
+		event = renderNotification(event, n.config)
+	}
+	return n.Notifier.Notify(ctx, event)
+}
@@ -0,0 +1,272 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// UploadOutbox uploads the backups waiting in the outbox to their buckets,
+// oldest first, and returns how many were uploaded. Backups whose last
+// attempt failed within the retry interval are skipped unless force is set;
+// failed uploads stay queued and are returned as one joined error.
+func (br *BackupRunner) UploadOutbox(ctx context.Context, force bool) (int, error) {
+	uploaded := 0
+	var errs []error
+	err := state.WithUploadLock(func() error {
+		uploads, err := state.LoadUploads()
+		if err != nil {
+			return err
+		}
+		for _, upload := range uploads {
+			if upload.State() == state.UploadUploading || (!force && time.Since(upload.LastAttempt) < br.config.Outbox.Retry()) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("upload cancelled: %w", err)
+			}
+			if err := state.StartUpload(upload.BackupID, time.Now()); errors.Is(err, state.ErrUploadNotQueued) {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			render.Printf("📤 Uploading %s of job %s (%s) to bucket %s...\n", upload.BackupID, upload.Job, utils.FormatBytes(upload.Size), upload.BucketID)
+			err := br.upload(ctx, upload)
+			if errors.Is(err, errUploadCancelled) {
+				render.Printf("🛑 Upload of %s cancelled; the backup stays in %s\n", upload.BackupID, upload.Path)
+				continue
+			}
+			if err != nil {
+				render.Printf("⚠️  Upload of %s failed, it stays in the outbox: %v\n", upload.BackupID, err)
+				logging.Emit(br.config.Logging, logging.Record{
+					Priority: logging.PriorityWarning,
+					Event:    "upload.failed",
+					Message:  fmt.Sprintf("Upload of backup %s of job %s failed: %v", upload.BackupID, upload.Job, err),
+					Fields:   map[string]string{"job": upload.Job, "backup_id": upload.BackupID, "bucket": upload.BucketID, "error": err.Error()},
+				})
+				if err := state.RecordUploadFailure(upload.BackupID, time.Now(), err); err != nil {
+					render.Printf("Warning: %v\n", err)
+				}
+				errs = append(errs, fmt.Errorf("%s: %w", upload.BackupID, err))
+				continue
+			}
+
+			uploaded++
+			render.Printf("✅ Uploaded %s to bucket %s\n", upload.BackupID, upload.BucketID)
+			logging.Emit(br.config.Logging, logging.Record{
+				Priority: logging.PriorityInfo,
+				Event:    "upload.completed",
+				Message:  fmt.Sprintf("Uploaded backup %s of job %s", upload.BackupID, upload.Job),
+				Fields:   map[string]string{"job": upload.Job, "backup_id": upload.BackupID, "bucket": upload.BucketID},
+			})
+			if _, err := state.RemoveUpload(upload.BackupID); err != nil {
+				render.Printf("Warning: %v\n", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return uploaded, err
+	}
+	return uploaded, errors.Join(errs...)
+}
+
+// upload copies a backup from the outbox to this host's directory on its
+// bucket, moves its catalog entry there, removes the local copy and applies
+// the job's retention to the bucket
+func (br *BackupRunner) upload(ctx context.Context, upload state.Upload) error {
+	var bucket *config.BucketConfig
+	for i := range br.config.Buckets {
+		if br.config.Buckets[i].ID == upload.BucketID {
+			bucket = &br.config.Buckets[i]
+		}
+	}
+	if bucket == nil {
+		return fmt.Errorf("bucket %s is no longer configured", upload.BucketID)
+	}
+	metadata, err := config.LoadBackupMetadata(filepath.Join(upload.Path, "metadata.toml"))
+	if err != nil {
+		return err
+	}
+
+	mount := br.mountFor(*bucket)
+	if err := mount.Prepare(); err != nil {
+		return err
+	}
+	release, err := mount.Acquire("upload-"+upload.BackupID, br.config.Mounts.Timeout())
+	if err != nil {
+		return fmt.Errorf("failed to mount S3 bucket: %w", err)
+	}
+	defer release()
+
+	// 'backtide uploads cancel' removes the backup from the queue, which ends
+	// the upload before the next file
+	hostPath := HostPath(bucket.MountPoint)
+	target := filepath.Join(hostPath, upload.BackupID)
+	queued := func() error {
+		if _, err := state.LoadUpload(upload.BackupID); errors.Is(err, state.ErrUploadNotQueued) {
+			return errUploadCancelled
+		}
+		return nil
+	}
+	if err := copyBackup(ctx, upload.Path, target, br.config.Fsync(), queued); err != nil {
+		if errors.Is(err, errUploadCancelled) {
+			os.RemoveAll(target)
+		}
+		return err
+	}
+	job, jobErr := br.findJob(upload.Job)
+	if jobErr == nil {
+		if err := applyOutput(target, job.Output); err != nil {
+			return fmt.Errorf("failed to set permissions of backup %s: %w", upload.BackupID, err)
+		}
+	}
+
+	if err := state.RecordBucketWrite(bucket.ID, state.BucketWrite{BackupID: upload.BackupID, Job: upload.Job, At: time.Now()}); err != nil {
+		render.Printf("Warning: Failed to record bucket write: %v\n", err)
+	}
+	if jobErr == nil {
+		queueReplications(job, *bucket, target, upload.BackupID)
+	}
+	if err := state.RemoveBackup(filepath.Dir(upload.Path), upload.BackupID); err != nil {
+		render.Printf("Warning: Failed to update catalog: %v\n", err)
+	}
+	if err := state.RecordBackup(catalogEntry(*metadata, CatalogLocation{Path: hostPath, BucketID: bucket.ID, Job: upload.Job})); err != nil {
+		render.Printf("Warning: Failed to update catalog: %v\n", err)
+	}
+	if err := os.RemoveAll(upload.Path); err != nil {
+		render.Printf("Warning: Failed to remove uploaded backup %s: %v\n", upload.Path, err)
+	}
+
+	// Retention waits for the upload, so old backups are only removed once
+	// the new one is on the bucket
+	if jobErr != nil || metadata.Undersized {
+		return nil
+	}
+	manager := NewBackupManager(config.BackupConfig{
+		Jobs:       []config.BackupJob{*job},
+		Buckets:    br.config.Buckets,
+		BackupPath: hostPath,
+		TempPath:   br.config.TempPath,
+		Network:    br.config.Network,
+	})
+	if err := manager.CleanupBackups(); err != nil {
+		render.Printf("Warning: Failed to cleanup old backups: %v\n", err)
+	}
+	return nil
+}
+
+// errUploadCancelled ends an upload whose backup was removed from the queue
+var errUploadCancelled = errors.New("upload cancelled")
+
+// copyBackup copies a backup directory, writing its metadata last so the
+// copy only counts as a backup once complete. Files a previous attempt
+// already copied in full are skipped, so failed uploads resume; check is
+// called before each file and ends the copy with its error.
+func copyBackup(ctx context.Context, src, dst string, fsync bool, check func() error) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != "metadata.toml" {
+			names = append(names, entry.Name())
+		}
+	}
+	names = append(names, "metadata.toml")
+
+	for _, name := range names {
+		source, target := filepath.Join(src, name), filepath.Join(dst, name)
+		if err := check(); err != nil {
+			return err
+		}
+		info, err := os.Stat(source)
+		if err != nil {
+			return err
+		}
+		if existing, err := os.Stat(target); err == nil && existing.Size() == info.Size() {
+			continue
+		}
+		if err := copyFile(ctx, source, target); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		// On an s3fs mount the sync uploads the file, so errors surface here
+		if fsync {
+			if err := syncFile(target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UploadQueue returns the backups waiting in the outbox with their upload state
+func UploadQueue(cfg config.BackupConfig) ([]control.UploadStatus, error) {
+	uploads, err := state.LoadUploads()
+	if err != nil {
+		return nil, err
+	}
+	queue := make([]control.UploadStatus, 0, len(uploads))
+	for _, upload := range uploads {
+		status := control.UploadStatus{
+			BackupID:    upload.BackupID,
+			Job:         upload.Job,
+			BucketID:    upload.BucketID,
+			Size:        upload.Size,
+			State:       upload.State(),
+			QueuedAt:    upload.QueuedAt,
+			Attempts:    upload.Attempts,
+			LastAttempt: upload.LastAttempt,
+			Error:       upload.LastError,
+		}
+		switch status.State {
+		case state.UploadUploading:
+			status.StartedAt = upload.StartedAt
+		case state.UploadFailed:
+			status.NextAttempt = upload.LastAttempt.Add(cfg.Outbox.Retry())
+		}
+		queue = append(queue, status)
+	}
+	return queue, nil
+}
+
+// RetryUploads makes the uploads of the named backups due now, or those of
+// all failed uploads when none are named, and returns the affected backups
+func RetryUploads(backupIDs []string) ([]string, error) {
+	if len(backupIDs) == 0 {
+		uploads, err := state.LoadUploads()
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range uploads {
+			if upload.State() == state.UploadFailed {
+				backupIDs = append(backupIDs, upload.BackupID)
+			}
+		}
+	}
+	for _, id := range backupIDs {
+		if err := state.RetryUpload(id); err != nil {
+			return nil, err
+		}
+	}
+	return backupIDs, nil
+}
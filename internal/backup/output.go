@@ -0,0 +1,81 @@
+package backup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// applyOutput gives a backup's directory and files the job's configured mode
+// and ownership
+func applyOutput(backupDir string, output config.OutputConfig) error {
+	if !output.Set() {
+		return nil
+	}
+	mode, err := output.FileMode()
+	if err != nil {
+		return err
+	}
+	uid, gid, err := outputOwner(output)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(backupDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if uid >= 0 || gid >= 0 {
+			if err := os.Lchown(path, uid, gid); err != nil {
+				return fmt.Errorf("failed to set owner of %s: %w", path, err)
+			}
+		}
+		if mode != 0 && d.Type()&fs.ModeSymlink == 0 {
+			target := mode
+			if d.IsDir() {
+				target = config.DirMode(mode)
+			}
+			if err := os.Chmod(path, target); err != nil {
+				return fmt.Errorf("failed to set mode of %s: %w", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// outputOwner resolves the configured owner and group to IDs, -1 for unset
+func outputOwner(output config.OutputConfig) (int, int, error) {
+	uid, gid := -1, -1
+	if output.Owner != "" {
+		id, err := strconv.Atoi(output.Owner)
+		if err != nil {
+			account, err := user.Lookup(output.Owner)
+			if err != nil {
+				return 0, 0, fmt.Errorf("output owner %s: %w", output.Owner, err)
+			}
+			if id, err = strconv.Atoi(account.Uid); err != nil {
+				return 0, 0, fmt.Errorf("output owner %s has unsupported uid %s", output.Owner, account.Uid)
+			}
+		}
+		uid = id
+	}
+	if output.Group != "" {
+		id, err := strconv.Atoi(output.Group)
+		if err != nil {
+			group, err := user.LookupGroup(output.Group)
+			if err != nil {
+				return 0, 0, fmt.Errorf("output group %s: %w", output.Group, err)
+			}
+			if id, err = strconv.Atoi(group.Gid); err != nil {
+				return 0, 0, fmt.Errorf("output group %s has unsupported gid %s", output.Group, group.Gid)
+			}
+		}
+		gid = id
+	}
+	return uid, gid, nil
+}
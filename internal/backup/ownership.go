@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// OwnershipMap translates file ownership recorded in a backup to IDs on the restoring host
+type OwnershipMap struct {
+	// UIDs and GIDs map backup IDs to local IDs and take precedence over name resolution
+	UIDs map[int]int
+	GIDs map[int]int
+	// Numeric keeps the recorded IDs instead of resolving user and group names locally
+	Numeric bool
+
+	users  map[string]int
+	groups map[string]int
+}
+
+// ParseIDMap parses "from:to" pairs such as "1000:1001" into an ID map
+func ParseIDMap(specs []string) (map[int]int, error) {
+	ids := make(map[int]int)
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ID mapping %q: expected from:to", spec)
+		}
+		fromID, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || fromID < 0 {
+			return nil, fmt.Errorf("invalid ID mapping %q: %q is not a valid ID", spec, from)
+		}
+		toID, err := strconv.Atoi(strings.TrimSpace(to))
+		if err != nil || toID < 0 {
+			return nil, fmt.Errorf("invalid ID mapping %q: %q is not a valid ID", spec, to)
+		}
+		ids[fromID] = toID
+	}
+	return ids, nil
+}
+
+// SetOwnershipMap sets how ownership is applied to restored files
+func (bm *BackupManager) SetOwnershipMap(m *OwnershipMap) {
+	bm.ownership = m
+}
+
+// owner returns the local owner for a tar entry: an explicit mapping first, then
+// the recorded user/group name if it exists locally, then the recorded ID
+func (m *OwnershipMap) owner(header *tar.Header) (int, int) {
+	uid, gid := header.Uid, header.Gid
+
+	if mapped, ok := m.UIDs[header.Uid]; ok {
+		uid = mapped
+	} else if !m.Numeric && header.Uname != "" {
+		if id, ok := m.lookupUser(header.Uname); ok {
+			uid = id
+		}
+	}
+
+	if mapped, ok := m.GIDs[header.Gid]; ok {
+		gid = mapped
+	} else if !m.Numeric && header.Gname != "" {
+		if id, ok := m.lookupGroup(header.Gname); ok {
+			gid = id
+		}
+	}
+
+	return uid, gid
+}
+
+// lookupUser resolves a user name on this host, caching the result
+func (m *OwnershipMap) lookupUser(name string) (int, bool) {
+	if m.users == nil {
+		m.users = make(map[string]int)
+	}
+	if id, ok := m.users[name]; ok {
+		return id, id >= 0
+	}
+	id := -1
+	if u, err := user.Lookup(name); err == nil {
+		if parsed, err := strconv.Atoi(u.Uid); err == nil {
+			id = parsed
+		}
+	}
+	m.users[name] = id
+	return id, id >= 0
+}
+
+// lookupGroup resolves a group name on this host, caching the result
+func (m *OwnershipMap) lookupGroup(name string) (int, bool) {
+	if m.groups == nil {
+		m.groups = make(map[string]int)
+	}
+	if id, ok := m.groups[name]; ok {
+		return id, id >= 0
+	}
+	id := -1
+	if g, err := user.LookupGroup(name); err == nil {
+		if parsed, err := strconv.Atoi(g.Gid); err == nil {
+			id = parsed
+		}
+	}
+	m.groups[name] = id
+	return id, id >= 0
+}
+
+// applyOwnership sets the owner of a restored path; only root can change ownership,
+// so other users keep the default ownership of files they create
+func (bm *BackupManager) applyOwnership(path string, header *tar.Header) {
+	if os.Geteuid() != 0 {
+		return
+	}
+
+	m := bm.ownership
+	if m == nil {
+		m = &OwnershipMap{}
+		bm.ownership = m
+	}
+
+	uid, gid := m.owner(header)
+	if err := os.Lchown(path, uid, gid); err != nil {
+		render.Printf("⚠️  Warning: Failed to set ownership on %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// ParallelOptions controls how RunJobs runs several jobs at once
+type ParallelOptions struct {
+	// Parallel is the most jobs running at once; 1 or less runs them in turn
+	Parallel int
+	// SerializeBuckets keeps jobs writing to the same S3 bucket from overlapping
+	SerializeBuckets bool
+	// SerializeDisks keeps jobs reading from the same filesystem from overlapping
+	SerializeDisks bool
+}
+
+// JobResult is the outcome of one job run by RunJobs
+type JobResult struct {
+	Job      string
+	Metadata *config.BackupMetadata
+	Err      error
+	Duration time.Duration
+}
+
+// RunJobs runs jobs with up to opts.Parallel of them at once, starting them in
+// the given order as soon as a slot is free and no running job holds the same
+// bucket or disk. Once ctx is cancelled no further jobs are started. Results
+// are returned in the order of jobNames.
+func (br *BackupRunner) RunJobs(ctx context.Context, jobNames []string, opts ParallelOptions) []JobResult {
+	workers := max(opts.Parallel, 1)
+	results := make([]JobResult, len(jobNames))
+	resources := make([][]string, len(jobNames))
+	for i, name := range jobNames {
+		results[i].Job = name
+		resources[i] = br.jobResources(name, opts)
+	}
+
+	done := make(chan int)
+	busy := make(map[string]string)
+	pending := make([]int, len(jobNames))
+	for i := range pending {
+		pending[i] = i
+	}
+	running := 0
+	announced := make(map[int]bool)
+
+	for len(pending) > 0 || running > 0 {
+		for i := 0; i < len(pending) && running < workers && ctx.Err() == nil; {
+			job := pending[i]
+			if kind, holder, conflict := firstBusy(resources[job], busy); conflict {
+				if workers > 1 && !announced[job] {
+					announced[job] = true
+					render.Printf("⏸️  Job %s waits for job %s (same %s)\n", jobNames[job], holder, kind)
+				}
+				i++
+				continue
+			}
+			for _, resource := range resources[job] {
+				busy[resource] = jobNames[job]
+			}
+			pending = append(pending[:i], pending[i+1:]...)
+			running++
+			go func() {
+				started := time.Now()
+				metadata, err := br.RunJob(ctx, jobNames[job])
+				results[job].Metadata = metadata
+				results[job].Err = err
+				results[job].Duration = time.Since(started)
+				done <- job
+			}()
+		}
+
+		if running == 0 {
+			// Cancelled before the remaining jobs could start
+			for _, job := range pending {
+				results[job].Err = fmt.Errorf("backup cancelled: %w", ctx.Err())
+			}
+			break
+		}
+
+		job := <-done
+		running--
+		for _, resource := range resources[job] {
+			delete(busy, resource)
+		}
+		if err := results[job].Err; err != nil {
+			render.Printf("Failed to run job %s: %v\n", jobNames[job], err)
+		}
+	}
+	return results
+}
+
+// jobResources returns the buckets and disks a job needs exclusively under opts
+func (br *BackupRunner) jobResources(jobName string, opts ParallelOptions) []string {
+	job, err := br.findJob(jobName)
+	if err != nil {
+		return nil
+	}
+
+	var resources []string
+	if opts.SerializeBuckets && job.Storage.S3 && !job.SkipS3 && job.BucketID != "" {
+		resources = append(resources, "bucket:"+job.BucketID)
+	}
+	if opts.SerializeDisks {
+		seen := make(map[string]bool)
+		for _, dir := range job.Directories {
+			info, err := os.Stat(dir.Path)
+			if err != nil {
+				continue
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+				disk := fmt.Sprintf("disk:%d", stat.Dev)
+				if !seen[disk] {
+					seen[disk] = true
+					resources = append(resources, disk)
+				}
+			}
+		}
+	}
+	return resources
+}
+
+// firstBusy returns the kind of the first of resources in use and the job
+// holding it
+func firstBusy(resources []string, busy map[string]string) (string, string, bool) {
+	for _, resource := range resources {
+		if holder, ok := busy[resource]; ok {
+			kind, _, _ := strings.Cut(resource, ":")
+			return kind, holder, true
+		}
+	}
+	return "", "", false
+}
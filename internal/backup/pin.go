@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// SetPinned pins or unpins a backup, searching the locations it is recorded
+// at in the catalog and the storage locations of all configured jobs. Pinned
+// backups are never removed by retention cleanup.
+func (br *BackupRunner) SetPinned(backupID string, pinned bool) (*config.BackupMetadata, error) {
+	var locations []CatalogLocation
+	if entries, err := state.LoadCatalog(); err == nil {
+		for _, entry := range entries {
+			if entry.BackupID == backupID {
+				locations = append(locations, CatalogLocation{Path: entry.Location, BucketID: entry.BucketID, Job: entry.Job})
+			}
+		}
+	}
+	locations = append(locations, br.CatalogLocations()...)
+
+	for _, location := range locations {
+		release, err := br.mountLocation(location, fmt.Sprintf("pin-%d", os.Getpid()))
+		if err != nil {
+			render.Printf("Warning: %v\n", err)
+			continue
+		}
+		metadata, err := setPinnedIn(location, backupID, pinned)
+		release()
+		if err != nil || metadata != nil {
+			return metadata, err
+		}
+	}
+	return nil, fmt.Errorf("backup not found: %s", backupID)
+}
+
+// setPinnedIn updates the metadata and catalog entry of a backup stored at
+// location or in one of its per-host directories; it returns nil metadata if
+// the backup is not there
+func setPinnedIn(location CatalogLocation, backupID string, pinned bool) (*config.BackupMetadata, error) {
+	for _, dir := range backupDirs(location.Path) {
+		metadataPath := filepath.Join(dir, backupID, "metadata.toml")
+		if _, err := os.Stat(metadataPath); err != nil {
+			continue
+		}
+
+		metadata, err := config.LoadBackupMetadata(metadataPath)
+		if err != nil {
+			return nil, err
+		}
+		if metadata.Pinned != pinned {
+			metadata.Pinned = pinned
+			if err := config.SaveBackupMetadata(metadata, metadataPath); err != nil {
+				return nil, fmt.Errorf("failed to save metadata: %w", err)
+			}
+		}
+
+		dirLocation := location
+		dirLocation.Path = dir
+		if err := state.RecordBackup(catalogEntry(*metadata, dirLocation)); err != nil {
+			render.Printf("Warning: Failed to update catalog: %v\n", err)
+		}
+		return metadata, nil
+	}
+	return nil, nil
+}
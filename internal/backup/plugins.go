@@ -0,0 +1,174 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/docker"
+	"github.com/mitexleo/backtide/internal/fault"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3fs"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// jobPlugins holds the plugins applied to a job, grouped by type
+type jobPlugins struct {
+	storages  []plugin.Storage
+	notifiers []plugin.Notifier
+	hooks     []plugin.Hook
+}
+
+// NewExecPlugin creates an executable plugin from its configuration
+func NewExecPlugin(cfg config.PluginConfig) *plugin.Exec {
+	timeout, _ := time.ParseDuration(cfg.Timeout)
+	return plugin.NewExec(cfg.Name, cfg.Command, cfg.Args, cfg.Env, timeout)
+}
+
+// AddStorage registers an in-process storage plugin applied to every job
+func (br *BackupRunner) AddStorage(s plugin.Storage) {
+	br.plugins.storages = append(br.plugins.storages, s)
+}
+
+// AddNotifier registers an in-process notifier applied to every job
+func (br *BackupRunner) AddNotifier(n plugin.Notifier) {
+	br.plugins.notifiers = append(br.plugins.notifiers, n)
+}
+
+// AddHook registers an in-process hook applied to every job
+func (br *BackupRunner) AddHook(h plugin.Hook) {
+	br.plugins.hooks = append(br.plugins.hooks, h)
+}
+
+// loadJobPlugins resolves the configured plugins referenced by a job
+func (br *BackupRunner) loadJobPlugins(job *config.BackupJob) jobPlugins {
+	plugins := jobPlugins{
+		storages:  append([]plugin.Storage(nil), br.plugins.storages...),
+		notifiers: append([]plugin.Notifier(nil), br.plugins.notifiers...),
+		hooks:     append([]plugin.Hook(nil), br.plugins.hooks...),
+	}
+
+	for _, name := range job.Plugins {
+		for _, cfg := range br.config.Plugins {
+			if cfg.Name != name {
+				continue
+			}
+			p := NewExecPlugin(cfg)
+			switch cfg.Type {
+			case plugin.TypeStorage:
+				plugins.storages = append(plugins.storages, p)
+			case plugin.TypeNotifier:
+				plugins.notifiers = append(plugins.notifiers, &templatedNotifier{Notifier: p, config: cfg})
+			case plugin.TypeHook:
+				plugins.hooks = append(plugins.hooks, p)
+			}
+		}
+	}
+	return plugins
+}
+
+// runHooks runs all hooks and returns the first error
+func (p jobPlugins) runHooks(ctx context.Context, event plugin.Event) error {
+	for _, hook := range p.hooks {
+		render.Printf("🪝 Running hook %s (%s)\n", hook.Name(), event.Type)
+		if err := hook.Run(ctx, event); err != nil {
+			return fmt.Errorf("hook %s failed: %w", hook.Name(), err)
+		}
+	}
+	return nil
+}
+
+// notify delivers an event to all notifiers; failures are only reported
+func (p jobPlugins) notify(ctx context.Context, event plugin.Event) {
+	event = renderNotification(event, config.PluginConfig{})
+	for _, notifier := range p.notifiers {
+		if err := notifier.Notify(ctx, event); err != nil {
+			render.Printf("Warning: Notifier %s failed: %v\n", notifier.Name(), err)
+		}
+	}
+}
+
+// store hands a completed backup to all storage plugins
+func (p jobPlugins) store(ctx context.Context, req plugin.StoreRequest) error {
+	for _, storage := range p.storages {
+		render.Printf("📤 Storing backup with plugin %s...\n", storage.Name())
+		err := fault.Fail(fault.Upload)
+		if err == nil {
+			err = storage.Store(ctx, req)
+		}
+		if err != nil {
+			return fmt.Errorf("storage plugin %s failed: %w", storage.Name(), err)
+		}
+		render.Printf("✅ Stored backup with plugin %s\n", storage.Name())
+	}
+	return nil
+}
+
+// NotifyBucket delivers an event to the notifiers of enabled jobs storing to a bucket
+func NotifyBucket(ctx context.Context, cfg *config.BackupConfig, bucketID string, event plugin.Event) {
+	notifyJobs(ctx, cfg, event, func(job config.BackupJob) bool {
+		return job.Storage.S3 && job.BucketID == bucketID
+	})
+}
+
+// NotifyJob delivers an event to the notifiers of a job; without a job name it
+// goes to the notifiers of all enabled jobs that stop Docker containers
+func NotifyJob(ctx context.Context, cfg *config.BackupConfig, jobName string, event plugin.Event) {
+	notifyJobs(ctx, cfg, event, func(job config.BackupJob) bool {
+		if jobName == "" {
+			return !job.SkipDocker
+		}
+		return job.Name == jobName
+	})
+}
+
+// notifyJobs delivers an event once to each notifier of the enabled jobs matching match
+func notifyJobs(ctx context.Context, cfg *config.BackupConfig, event plugin.Event, match func(config.BackupJob) bool) {
+	seen := make(map[string]bool)
+	var plugins jobPlugins
+	for _, job := range cfg.Jobs {
+		if !job.Enabled || !match(job) {
+			continue
+		}
+		for _, name := range job.Plugins {
+			for _, pc := range cfg.Plugins {
+				if pc.Name == name && pc.Type == plugin.TypeNotifier && !seen[name] {
+					seen[name] = true
+					plugins.notifiers = append(plugins.notifiers, &templatedNotifier{Notifier: NewExecPlugin(pc), config: pc})
+				}
+			}
+		}
+	}
+	plugins.notify(ctx, event)
+}
+
+// NotifyPlugins delivers an event to the named notifier plugins, for events
+// not tied to a job
+func NotifyPlugins(ctx context.Context, cfg *config.BackupConfig, names []string, event plugin.Event) {
+	var plugins jobPlugins
+	for _, name := range names {
+		for _, pc := range cfg.Plugins {
+			if pc.Name == name && pc.Type == plugin.TypeNotifier {
+				plugins.notifiers = append(plugins.notifiers, &templatedNotifier{Notifier: NewExecPlugin(pc), config: pc})
+			}
+		}
+	}
+	plugins.notify(ctx, event)
+}
+
+// errorOutput returns the error output of the docker and s3fs commands that
+// caused err, for failure notifications
+func errorOutput(err error) []string {
+	var lines []string
+	var commandErr *docker.CommandError
+	if errors.As(err, &commandErr) {
+		lines = append(lines, commandErr.Lines()...)
+	}
+	var mountErr *s3fs.MountError
+	if errors.As(err, &mountErr) {
+		lines = append(lines, mountErr.Lines()...)
+	}
+	return lines
+}
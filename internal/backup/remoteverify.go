@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/s3api"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// RemoteCheck is the result of checking the backups in a bucket through the
+// S3 API, independently of the hosts that wrote them
+type RemoteCheck struct {
+	Bucket   string
+	Hosts    []RemoteHost
+	Backups  int
+	Archives int   // archives downloaded and checksummed
+	Bytes    int64 // size of the downloaded archives
+	Problems []string
+}
+
+// RemoteHost summarizes the backups of one host in a bucket
+type RemoteHost struct {
+	Name     string
+	Backups  int
+	Newest   string // ID of the newest backup
+	NewestAt time.Time
+}
+
+// remoteArchive is an archive a check may download
+type remoteArchive struct {
+	key      string
+	backup   string
+	checksum string
+}
+
+// CheckBucket lists the backups in a bucket, reads their metadata and checks
+// that the archives, indexes and manifests it names exist, then downloads a
+// random sample of archives and compares them with the checksums recorded
+// when they were written. Only list and read access to the bucket is needed.
+// Problems with backups are collected in the result; the error is set when
+// the bucket cannot be checked at all.
+func CheckBucket(ctx context.Context, bucket config.BucketConfig, netCfg config.NetworkConfig, agent config.VerifyAgentConfig) (*RemoteCheck, error) {
+	maxAge, err := agent.MaxBackupAge()
+	if err != nil {
+		return nil, err
+	}
+	client, err := s3api.NewClient(bucket, netCfg)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.ListObjects(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bucket %s: %w", bucket.Name, err)
+	}
+	exists := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		exists[object.Key] = true
+	}
+
+	check := &RemoteCheck{Bucket: bucket.Name}
+	hosts := make(map[string]*RemoteHost)
+	var archives []remoteArchive
+	for _, object := range objects {
+		if path.Base(object.Key) != "metadata.toml" || path.Dir(object.Key) == "." {
+			continue
+		}
+		backupDir := path.Dir(object.Key)
+		data, err := client.GetObject(ctx, object.Key)
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: failed to download metadata: %v", backupDir, err))
+			continue
+		}
+		metadata, err := config.ParseBackupMetadata(data)
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: %v", backupDir, err))
+			continue
+		}
+		check.Backups++
+
+		hostName := metadata.Hostname
+		if hostName == "" {
+			// Older backups name their host only by the per-host directory
+			if hostName = path.Base(path.Dir(backupDir)); hostName == "." {
+				hostName = "unknown host"
+			}
+		}
+		host := hosts[hostName]
+		if host == nil {
+			host = &RemoteHost{Name: hostName}
+			hosts[hostName] = host
+		}
+		host.Backups++
+		if metadata.Timestamp.After(host.NewestAt) {
+			host.Newest, host.NewestAt = metadata.ID, metadata.Timestamp
+		}
+
+		label := fmt.Sprintf("backup %s of %s", metadata.ID, hostName)
+		if metadata.ID != path.Base(backupDir) {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: metadata is stored in %s", label, backupDir))
+		}
+		if metadata.Checksum != "" && metadata.Checksum != overallChecksum(metadata.Directories) {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: checksums in the metadata do not match its overall checksum", label))
+		}
+		if metadata.Manifest != "" && !exists[backupDir+"/"+metadata.Manifest] {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: manifest %s is missing", label, metadata.Manifest))
+		}
+		for _, dir := range metadata.Directories {
+			key, err := archivePath(backupDir, dir)
+			if err != nil {
+				check.Problems = append(check.Problems, fmt.Sprintf("%s: %v", label, err))
+				continue
+			}
+			if !exists[key] {
+				check.Problems = append(check.Problems, fmt.Sprintf("%s: archive %s is missing", label, path.Base(key)))
+				continue
+			}
+			if dir.Index != "" && !exists[backupDir+"/"+dir.Index] {
+				check.Problems = append(check.Problems, fmt.Sprintf("%s: index %s is missing", label, dir.Index))
+			}
+			if dir.Checksum != "" {
+				archives = append(archives, remoteArchive{key: key, backup: label, checksum: dir.Checksum})
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, host := range hosts {
+		check.Hosts = append(check.Hosts, *host)
+		if maxAge > 0 && now.Sub(host.NewestAt) > maxAge {
+			check.Problems = append(check.Problems, fmt.Sprintf("newest backup of %s is %s old (max_age %s)",
+				host.Name, utils.FormatDuration(now.Sub(host.NewestAt)), agent.MaxAge))
+		}
+	}
+	sort.Slice(check.Hosts, func(i, j int) bool { return check.Hosts[i].Name < check.Hosts[j].Name })
+
+	rand.Shuffle(len(archives), func(i, j int) { archives[i], archives[j] = archives[j], archives[i] })
+	if len(archives) > agent.SampleSize() {
+		archives = archives[:agent.SampleSize()]
+	}
+	for _, archive := range archives {
+		size, err := checkRemoteArchive(ctx, client, archive)
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: %v", archive.backup, err))
+			continue
+		}
+		check.Archives++
+		check.Bytes += size
+	}
+	return check, nil
+}
+
+// checkRemoteArchive downloads an archive and compares its checksum with the
+// recorded one, returning its size
+func checkRemoteArchive(ctx context.Context, client *s3api.Client, archive remoteArchive) (int64, error) {
+	body, err := client.OpenObject(ctx, archive.key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", path.Base(archive.key), err)
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", path.Base(archive.key), err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != archive.checksum {
+		return 0, fmt.Errorf("archive %s does not match its checksum", path.Base(archive.key))
+	}
+	return size, nil
+}
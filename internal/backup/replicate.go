@@ -0,0 +1,265 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3api"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// errReplicaSourceGone ends the replication of a backup that was removed
+// from the job's bucket before it was copied
+var errReplicaSourceGone = errors.New("backup no longer exists on the source bucket")
+
+// queueReplications queues copying a backup that reached the job's bucket to
+// the job's secondary buckets
+func queueReplications(job *config.BackupJob, bucket config.BucketConfig, backupDir, backupID string) {
+	for _, rule := range job.Replicate {
+		replication := state.Replication{
+			BackupID:       backupID,
+			Job:            job.Name,
+			BucketID:       rule.BucketID,
+			SourceBucketID: bucket.ID,
+			Path:           backupDir,
+			QueuedAt:       time.Now(),
+		}
+		if err := state.QueueReplication(replication); err != nil {
+			render.Printf("Warning: Failed to queue replication to bucket %s: %v\n", rule.BucketID, err)
+			continue
+		}
+		render.Printf("📥 Backup queued for replication to bucket %s\n", rule.BucketID)
+	}
+}
+
+// Replicate copies the backups waiting in the replication queue to their
+// secondary buckets, oldest first, and returns how many were copied.
+// Replications whose last attempt failed within the outbox retry interval
+// are skipped unless force is set; failed ones stay queued and are returned
+// as one joined error.
+func (br *BackupRunner) Replicate(ctx context.Context, force bool) (int, error) {
+	replicated := 0
+	var errs []error
+	err := state.WithReplicationLock(func() error {
+		replications, err := state.LoadReplications()
+		if err != nil {
+			return err
+		}
+		for _, replication := range replications {
+			if !force && time.Since(replication.LastAttempt) < br.config.Outbox.Retry() {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("replication cancelled: %w", err)
+			}
+
+			render.Printf("🔁 Replicating %s of job %s to bucket %s...\n", replication.BackupID, replication.Job, replication.BucketID)
+			fields := map[string]string{"job": replication.Job, "backup_id": replication.BackupID, "bucket": replication.BucketID}
+			err := br.replicate(ctx, replication)
+			if errors.Is(err, errReplicaSourceGone) {
+				render.Printf("⚠️  %s was removed from bucket %s before it was replicated\n", replication.BackupID, replication.SourceBucketID)
+				if err := state.RemoveReplication(replication.BackupID, replication.BucketID); err != nil {
+					render.Printf("Warning: %v\n", err)
+				}
+				continue
+			}
+			if err != nil {
+				render.Printf("⚠️  Replication of %s failed, it stays queued: %v\n", replication.BackupID, err)
+				fields["error"] = err.Error()
+				logging.Emit(br.config.Logging, logging.Record{
+					Priority: logging.PriorityWarning,
+					Event:    "replication.failed",
+					Message:  fmt.Sprintf("Replication of backup %s of job %s to bucket %s failed: %v", replication.BackupID, replication.Job, replication.BucketID, err),
+					Fields:   fields,
+				})
+				if err := state.RecordReplicationFailure(replication.BackupID, replication.BucketID, time.Now(), err); err != nil {
+					render.Printf("Warning: %v\n", err)
+				}
+				errs = append(errs, fmt.Errorf("%s to %s: %w", replication.BackupID, replication.BucketID, err))
+				continue
+			}
+
+			replicated++
+			render.Printf("✅ Replicated %s to bucket %s\n", replication.BackupID, replication.BucketID)
+			logging.Emit(br.config.Logging, logging.Record{
+				Priority: logging.PriorityInfo,
+				Event:    "replication.completed",
+				Message:  fmt.Sprintf("Replicated backup %s of job %s to bucket %s", replication.BackupID, replication.Job, replication.BucketID),
+				Fields:   fields,
+			})
+			if err := state.RemoveReplication(replication.BackupID, replication.BucketID); err != nil {
+				render.Printf("Warning: %v\n", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return replicated, err
+	}
+	return replicated, errors.Join(errs...)
+}
+
+// replicate copies a backup to the same place on the secondary bucket,
+// hosts/<host>/<backup-id>, so it can be restored from there like from the
+// job's bucket. Buckets on the same endpoint copy server-side; otherwise, or
+// if that fails, the backup is copied from one mount to the other.
+func (br *BackupRunner) replicate(ctx context.Context, replication state.Replication) error {
+	source, target := br.findBucket(replication.SourceBucketID), br.findBucket(replication.BucketID)
+	if source == nil {
+		return fmt.Errorf("bucket %s is no longer configured", replication.SourceBucketID)
+	}
+	if target == nil {
+		return fmt.Errorf("bucket %s is no longer configured", replication.BucketID)
+	}
+
+	holder := "replicate-" + replication.BackupID
+	releaseSource, err := br.mountBucket(*source, holder)
+	if err != nil {
+		return err
+	}
+	defer releaseSource()
+
+	if _, err := os.Stat(filepath.Join(replication.Path, "metadata.toml")); os.IsNotExist(err) {
+		return errReplicaSourceGone
+	}
+	prefix, err := filepath.Rel(source.MountPoint, replication.Path)
+	if err != nil || strings.HasPrefix(prefix, "..") {
+		return fmt.Errorf("backup %s is not on bucket %s", replication.Path, source.Name)
+	}
+
+	copied := false
+	if source.Endpoint == target.Endpoint {
+		if err := copyServerSide(ctx, *source, *target, br.config.Network, replication.Path, filepath.ToSlash(prefix)); err != nil {
+			render.Printf("Server-side copy to bucket %s failed, copying through the mounts: %v\n", target.Name, err)
+		} else {
+			copied = true
+		}
+	}
+	if !copied {
+		releaseTarget, err := br.mountBucket(*target, holder)
+		if err != nil {
+			return err
+		}
+		defer releaseTarget()
+		if err := copyBackup(ctx, replication.Path, filepath.Join(target.MountPoint, prefix), br.config.Fsync(), ctx.Err); err != nil {
+			return err
+		}
+	}
+
+	if err := state.RecordBucketWrite(target.ID, state.BucketWrite{BackupID: replication.BackupID, Job: replication.Job, At: time.Now()}); err != nil {
+		render.Printf("Warning: Failed to record bucket write: %v\n", err)
+	}
+	return nil
+}
+
+// copyServerSide has the target bucket copy the files of a backup from the
+// source bucket, metadata last so the copy only counts once complete
+func copyServerSide(ctx context.Context, source, target config.BucketConfig, netCfg config.NetworkConfig, backupDir, prefix string) error {
+	client, err := s3api.NewClient(target, netCfg)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != "metadata.toml" {
+			names = append(names, entry.Name())
+		}
+	}
+	for _, name := range append(names, "metadata.toml") {
+		key := prefix + "/" + name
+		if err := client.CopyObject(ctx, source.Bucket, key, key, target.StorageClass); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// findBucket returns the configured bucket with an ID
+func (br *BackupRunner) findBucket(id string) *config.BucketConfig {
+	for i := range br.config.Buckets {
+		if br.config.Buckets[i].ID == id {
+			return &br.config.Buckets[i]
+		}
+	}
+	return nil
+}
+
+// mountBucket mounts a bucket for holder and returns a function releasing it
+func (br *BackupRunner) mountBucket(bucket config.BucketConfig, holder string) (func(), error) {
+	mount := br.mountFor(bucket)
+	if err := mount.Prepare(); err != nil {
+		return nil, err
+	}
+	release, err := mount.Acquire(holder, br.config.Mounts.Timeout())
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount S3 bucket %s: %w", bucket.Name, err)
+	}
+	return release, nil
+}
+
+// ReplicaStatus is how far the replicas of a job's backups in a secondary
+// bucket are behind
+type ReplicaStatus struct {
+	Job      string
+	BucketID string
+	Pending  int       // backups waiting to be copied
+	Oldest   time.Time // when the oldest waiting backup reached the job's bucket
+	// LastError is the latest failure of a waiting replication
+	LastError string
+	// LastCopy is when the job's newest replica was written, if known
+	LastCopy time.Time
+}
+
+// Lag returns how long the oldest waiting backup has been missing from the
+// secondary bucket, 0 when the replicas are up to date
+func (s ReplicaStatus) Lag(now time.Time) time.Duration {
+	if s.Pending == 0 {
+		return 0
+	}
+	return now.Sub(s.Oldest)
+}
+
+// ReplicationStatus returns the state of each replication rule of a job
+func ReplicationStatus(job config.BackupJob) ([]ReplicaStatus, error) {
+	if len(job.Replicate) == 0 {
+		return nil, nil
+	}
+	replications, err := state.LoadReplications()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []ReplicaStatus
+	for _, rule := range job.Replicate {
+		status := ReplicaStatus{Job: job.Name, BucketID: rule.BucketID}
+		for _, replication := range replications {
+			if replication.Job != job.Name || replication.BucketID != rule.BucketID {
+				continue
+			}
+			if status.Pending == 0 || replication.QueuedAt.Before(status.Oldest) {
+				status.Oldest = replication.QueuedAt
+			}
+			status.Pending++
+			if replication.LastError != "" {
+				status.LastError = replication.LastError
+			}
+		}
+		if write, err := state.LastBucketWrite(rule.BucketID); err == nil && write != nil && write.Job == job.Name {
+			status.LastCopy = write.At
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// DirectoryPlan lists what restoring one backup directory would change
+type DirectoryPlan struct {
+	Name   string
+	Target string
+	// Created are paths that do not exist yet
+	Created []string
+	// Overwritten are existing files whose content would be replaced
+	Overwritten []string
+	// OwnerChanged are existing paths whose owner would change; only root
+	// changes ownership, so this is empty for other users
+	OwnerChanged []string
+	// Skipped are special files a restore does not recreate
+	Skipped []string
+}
+
+// PlanRestore reports what restoring a backup would change without writing
+// anything; arguments are those of RestoreDirectories
+func (bm *BackupManager) PlanRestore(backupID string, targetPath string, names []string) ([]DirectoryPlan, error) {
+	backupDir, err := bm.BackupDir(backupID)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := bm.loadMetadata(backupDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata: %w", err)
+	}
+	directories, err := SelectDirectories(metadata, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []DirectoryPlan
+	for _, dir := range directories {
+		archive, err := archivePath(backupDir, dir)
+		if err != nil {
+			return nil, err
+		}
+		plan := DirectoryPlan{Name: dir.Name, Target: dir.Path}
+		if targetPath != "" {
+			if plan.Target, err = containedPath(targetPath, dir.Name); err != nil {
+				return nil, err
+			}
+		}
+		if err := bm.planFromTar(archive, dir.Compressed, dir.Footer, &plan); err != nil {
+			return nil, fmt.Errorf("failed to read archive of %s: %w", dir.Name, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// planFromTar compares the entries of an archive with what exists below the plan's target
+func (bm *BackupManager) planFromTar(tarPath string, compressed, requireFooter bool, plan *DirectoryPlan) error {
+	file, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if compressed {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	ownership := bm.ownership
+	if ownership == nil {
+		ownership = &OwnershipMap{}
+	}
+	changesOwners := os.Geteuid() == 0
+
+	tarReader := newArchiveReader(reader, requireFooter)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		targetPath, ok, err := entryTarget(plan.Target, header.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		// Mirrors restoreFromTar, which skips these
+		if skippedEntry(header) {
+			plan.Skipped = append(plan.Skipped, targetPath)
+			continue
+		}
+		if err := checkParents(plan.Target, targetPath); err != nil {
+			return err
+		}
+
+		info, err := os.Lstat(targetPath)
+		if os.IsNotExist(err) {
+			plan.Created = append(plan.Created, targetPath)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeDir {
+			plan.Overwritten = append(plan.Overwritten, targetPath)
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && changesOwners {
+			uid, gid := ownership.owner(header)
+			if int(stat.Uid) != uid || int(stat.Gid) != gid {
+				plan.OwnerChanged = append(plan.OwnerChanged, fmt.Sprintf("%s (%d:%d -> %d:%d)", targetPath, stat.Uid, stat.Gid, uid, gid))
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// RestoreWorkerCommand is the hidden command that extracts a backup in the sandbox
+const RestoreWorkerCommand = "restore-worker"
+
+// restoreRequest is what a sandboxed restore hands to the restore worker on stdin
+type restoreRequest struct {
+	BackupDir  string        `json:"backup_dir"`
+	BackupID   string        `json:"backup_id"`
+	TargetPath string        `json:"target_path,omitempty"`
+	Names      []string      `json:"names,omitempty"`
+	Ownership  *OwnershipMap `json:"ownership,omitempty"`
+	NoTimes    bool          `json:"no_times,omitempty"`
+	Atimes     bool          `json:"atimes,omitempty"`
+}
+
+// restoreSandboxRules returns the paths a restore worker needs: the backup
+// for reading and the directories it restores to for writing, which are
+// created first so the sandbox can allow them
+func restoreSandboxRules(backupDir, targetPath string, directories []config.BackupDirectory) (SandboxRules, error) {
+	rules := SandboxRules{Read: []string{backupDir}}
+	if targetPath != "" {
+		rules.Write = []string{targetPath}
+	} else {
+		for _, dir := range directories {
+			rules.Write = append(rules.Write, dir.Path)
+		}
+	}
+	for _, path := range rules.Write {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return rules, fmt.Errorf("failed to create target directory: %w", err)
+		}
+	}
+	return rules, nil
+}
+
+// restoreInWorker restores a backup in a worker process sandboxed to the
+// backup and the restore targets, so an archive entry or a mistyped target
+// cannot write anywhere else
+func (bm *BackupManager) restoreInWorker(ctx context.Context, backupDir, backupID, targetPath string, names []string) error {
+	metadata, err := bm.loadMetadata(backupDir)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+	directories, err := SelectDirectories(metadata, names)
+	if err != nil {
+		return err
+	}
+	if targetPath != "" {
+		if targetPath, err = filepath.Abs(targetPath); err != nil {
+			return fmt.Errorf("failed to resolve target path: %w", err)
+		}
+	}
+	rules, err := restoreSandboxRules(backupDir, targetPath, directories)
+	if err != nil {
+		return err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not determine current executable path: %w", err)
+	}
+	request, err := json.Marshal(restoreRequest{
+		BackupDir:  backupDir,
+		BackupID:   backupID,
+		TargetPath: targetPath,
+		Names:      names,
+		Ownership:  bm.ownership,
+		NoTimes:    bm.noTimes,
+		Atimes:     bm.atimes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode restore request: %w", err)
+	}
+
+	resultReader, resultWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create worker pipe: %w", err)
+	}
+	defer resultReader.Close()
+
+	render.Println("🔒 Restoring in a sandbox limited to the restore targets")
+	cmd := exec.CommandContext(ctx, self, append([]string{RestoreWorkerCommand}, rules.args()...)...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{resultWriter}
+	if render.Current().NoEmoji {
+		cmd.Env = append(os.Environ(), "BACKTIDE_NO_EMOJI=1")
+	}
+	if err := cmd.Start(); err != nil {
+		resultWriter.Close()
+		return fmt.Errorf("failed to start restore worker: %w", err)
+	}
+	resultWriter.Close()
+
+	var result workerResult
+	decodeErr := json.NewDecoder(resultReader).Decode(&result)
+	waitErr := cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("restore cancelled: %w", err)
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	if waitErr != nil || decodeErr != nil {
+		return fmt.Errorf("restore worker failed: %w", errors.Join(waitErr, decodeErr))
+	}
+	return nil
+}
+
+// RunRestoreWorker reads a restore request from in, restores the backup and
+// writes the result to out
+func RunRestoreWorker(in io.Reader, out io.Writer) error {
+	var request restoreRequest
+	if err := json.NewDecoder(in).Decode(&request); err != nil {
+		return fmt.Errorf("failed to read restore request: %w", err)
+	}
+
+	bm := NewBackupManager(config.BackupConfig{BackupPath: filepath.Dir(request.BackupDir)})
+	bm.SetOwnershipMap(request.Ownership)
+	bm.SetRestoreTimes(request.NoTimes, request.Atimes)
+	var result workerResult
+	if err := bm.restoreFromDir(context.Background(), request.BackupDir, request.BackupID, request.TargetPath, request.Names); err != nil {
+		result.Error = err.Error()
+	}
+	return json.NewEncoder(out).Encode(result)
+}
@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// WorkerCommand is the hidden command that archives a job as its run_as user
+// or in a sandbox
+const WorkerCommand = "archive-worker"
+
+// workerStopTimeout is how long a cancelled worker may take to remove its
+// partial backup before it is killed
+const workerStopTimeout = 30 * time.Second
+
+// workerRequest is what a run hands to the archive worker on stdin
+type workerRequest struct {
+	Config      config.BackupConfig `json:"config"`
+	Annotations Annotations         `json:"annotations"`
+}
+
+// workerResult is what the archive worker reports back
+type workerResult struct {
+	Metadata *config.BackupMetadata `json:"metadata,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// lookupRunAs resolves a run_as value of "user" or "user:group"; without a
+// group the user's primary group is used, and supplementary groups are kept
+// so group-readable data stays readable
+func lookupRunAs(runAs string) (*syscall.Credential, error) {
+	name, groupName, _ := strings.Cut(runAs, ":")
+	account, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("run_as user %s: %w", name, err)
+	}
+	uid, err := strconv.ParseUint(account.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("run_as user %s has unsupported uid %s", name, account.Uid)
+	}
+	gid, err := strconv.ParseUint(account.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("run_as user %s has unsupported gid %s", name, account.Gid)
+	}
+	if groupName != "" {
+		group, err := user.LookupGroup(groupName)
+		if err != nil {
+			return nil, fmt.Errorf("run_as group %s: %w", groupName, err)
+		}
+		if gid, err = strconv.ParseUint(group.Gid, 10, 32); err != nil {
+			return nil, fmt.Errorf("run_as group %s has unsupported gid %s", groupName, group.Gid)
+		}
+	}
+
+	credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	if ids, err := account.GroupIds(); err == nil {
+		for _, id := range ids {
+			if n, err := strconv.ParseUint(id, 10, 32); err == nil {
+				credential.Groups = append(credential.Groups, uint32(n))
+			}
+		}
+	}
+	return credential, nil
+}
+
+// createBackupInWorker runs CreateBackup in a worker process, with the
+// privileges of the job's run_as user if set, so the archived files are read
+// and the backup is written as that user, and restricted to the job's
+// directories if sandbox is set
+func (bm *BackupManager) createBackupInWorker(ctx context.Context, runAs string, sandbox bool, runID string) (*config.BackupMetadata, error) {
+	var credential *syscall.Credential
+	if runAs != "" {
+		if os.Geteuid() != 0 {
+			return nil, fmt.Errorf("run_as %s requires backtide to run as root", runAs)
+		}
+		var err error
+		if credential, err = lookupRunAs(runAs); err != nil {
+			return nil, err
+		}
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine current executable path: %w", err)
+	}
+
+	// The worker creates the backup in the backup path and needs a temp
+	// directory of its own, e.g. for filters
+	if _, err := os.Stat(bm.backupPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(bm.backupPath, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create backup path: %w", err)
+		}
+		if credential != nil {
+			if err := os.Chown(bm.backupPath, int(credential.Uid), int(credential.Gid)); err != nil {
+				return nil, fmt.Errorf("failed to hand backup path to %s: %w", runAs, err)
+			}
+		}
+	}
+	// The temp path is usually closed to other users, so the worker's temp
+	// directory goes to the system temp directory
+	tempDir, err := os.MkdirTemp("", "backtide-"+runID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if credential != nil {
+		if err := os.Chown(tempDir, int(credential.Uid), int(credential.Gid)); err != nil {
+			return nil, fmt.Errorf("failed to hand worker temp directory to %s: %w", runAs, err)
+		}
+	}
+
+	// Bucket credentials stay with the root process
+	workerConfig := bm.config
+	workerConfig.TempPath = tempDir
+	workerConfig.Buckets = nil
+	request, err := json.Marshal(workerRequest{Config: workerConfig, Annotations: bm.annotations})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode worker request: %w", err)
+	}
+
+	resultReader, resultWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker pipe: %w", err)
+	}
+	defer resultReader.Close()
+
+	args := []string{WorkerCommand}
+	if sandbox {
+		args = append(args, bm.sandboxRules(tempDir).args()...)
+	}
+	cmd := exec.CommandContext(ctx, self, args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{resultWriter}
+	if credential != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	}
+	if render.Current().NoEmoji {
+		cmd.Env = append(os.Environ(), "BACKTIDE_NO_EMOJI=1")
+	}
+	// Cancelling asks the worker to remove its partial backup before it is killed
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = workerStopTimeout
+
+	if err := cmd.Start(); err != nil {
+		resultWriter.Close()
+		return nil, fmt.Errorf("failed to start archive worker: %w", err)
+	}
+	resultWriter.Close()
+
+	var result workerResult
+	decodeErr := json.NewDecoder(resultReader).Decode(&result)
+	waitErr := cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("backup cancelled: %w", err)
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	if waitErr != nil || decodeErr != nil {
+		return nil, fmt.Errorf("archive worker failed: %w", errors.Join(waitErr, decodeErr))
+	}
+	return result.Metadata, nil
+}
+
+// RunArchiveWorker reads a worker request from in, creates the backup and
+// writes the result to out
+func RunArchiveWorker(ctx context.Context, in io.Reader, out io.Writer) error {
+	var request workerRequest
+	if err := json.NewDecoder(in).Decode(&request); err != nil {
+		return fmt.Errorf("failed to read worker request: %w", err)
+	}
+
+	bm := NewBackupManager(request.Config)
+	bm.SetAnnotations(request.Annotations)
+	metadata, err := bm.CreateBackup(ctx)
+	result := workerResult{Metadata: metadata}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return json.NewEncoder(out).Encode(result)
+}
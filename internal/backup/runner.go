@@ -2,21 +2,33 @@ package backup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
 	"github.com/mitexleo/backtide/internal/docker"
-	"github.com/mitexleo/backtide/internal/s3fs"
+	"github.com/mitexleo/backtide/internal/logging"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/mitexleo/backtide/pkg/plugin"
 )
 
 // BackupRunner handles execution of backup jobs
 type BackupRunner struct {
-	config     config.BackupConfig
-	backupPath string
-	dryRun     bool
+	config      config.BackupConfig
+	backupPath  string
+	dryRun      bool
+	plugins     jobPlugins
+	annotations Annotations
+	bucketMount func(config.BucketConfig) BucketMount
 }
 
 // NewBackupRunner creates a new backup runner instance
@@ -30,8 +42,13 @@ func NewBackupRunner(cfg config.BackupConfig) *BackupRunner {
 
 // RunJob executes a specific backup job
 func (br *BackupRunner) RunJob(ctx context.Context, jobName string) (*config.BackupMetadata, error) {
+	return br.RunJobWithID(ctx, jobName, state.NewRunID())
+}
+
+// RunJobWithID executes a specific backup job under a caller-assigned run ID
+func (br *BackupRunner) RunJobWithID(ctx context.Context, jobName, runID string) (metadata *config.BackupMetadata, err error) {
 	if br.dryRun {
-		fmt.Printf("DRY RUN: Would run backup job: %s\n", jobName)
+		render.Printf("DRY RUN: Would run backup job: %s\n", jobName)
 		return &config.BackupMetadata{
 			ID:        "dry-run-simulation",
 			Timestamp: time.Now(),
@@ -46,8 +63,136 @@ func (br *BackupRunner) RunJob(ctx context.Context, jobName string) (*config.Bac
 		return nil, fmt.Errorf("job %s is disabled", jobName)
 	}
 
-	fmt.Printf("Starting backup job: %s\n", job.Name)
-	fmt.Printf("Description: %s\n", job.Description)
+	// Frequent schedules must not start a run while the previous one is busy
+	if runs, err := state.ListRuns(); err == nil {
+		for _, run := range runs {
+			if run.JobName == job.Name && run.ID != runID {
+				return nil, fmt.Errorf("job %s is already running as %s (PID %d)", job.Name, run.ID, run.PID)
+			}
+		}
+	}
+
+	// Register the run so it can be listed and cancelled from another process
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := state.RegisterRun(state.RunRecord{
+		ID:        runID,
+		JobName:   job.Name,
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+		Phase:     "starting",
+	}); err != nil {
+		render.Printf("Warning: Failed to register run: %v\n", err)
+	} else {
+		defer state.FinishRun(runID)
+		go state.WatchCancel(ctx, runID, cancel)
+	}
+	setPhase := func(phase string) {
+		state.UpdateRunPhase(runID, phase)
+	}
+
+	// Keep a history of finished runs for 'history export' and report them to the log sink
+	startedAt := time.Now()
+	logging.Emit(br.config.Logging, logging.Record{
+		Priority: logging.PriorityInfo,
+		Event:    "backup.started",
+		Message:  fmt.Sprintf("Backup job %s started", job.Name),
+		Fields:   map[string]string{"job": job.Name, "run_id": runID},
+	})
+	defer func() {
+		entry := state.HistoryEntry{
+			RunID:      runID,
+			Job:        job.Name,
+			Host:       Hostname(),
+			State:      control.RunSucceeded,
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+		}
+		if err != nil {
+			entry.State = control.RunFailed
+			if errors.Is(err, context.Canceled) {
+				entry.State = control.RunCancelled
+			}
+			entry.Error = err.Error()
+		} else {
+			entry.BackupID = metadata.ID
+			entry.TotalSize = metadata.TotalSize
+			for _, dir := range metadata.Directories {
+				entry.FileCount += dir.FileCount
+			}
+		}
+		if historyErr := state.RecordHistory(entry); historyErr != nil {
+			render.Printf("Warning: Failed to record run history: %v\n", historyErr)
+		}
+
+		record := logging.Record{
+			Priority: logging.PriorityNotice,
+			Event:    "backup." + entry.State,
+			Message:  fmt.Sprintf("Backup job %s %s", job.Name, entry.State),
+			Fields: map[string]string{
+				"job":      job.Name,
+				"run_id":   runID,
+				"duration": entry.Duration().Round(time.Millisecond).String(),
+			},
+		}
+		switch entry.State {
+		case control.RunSucceeded:
+			record.Fields["backup_id"] = entry.BackupID
+			record.Fields["total_size"] = strconv.FormatInt(entry.TotalSize, 10)
+			if metadata.Undersized {
+				record.Priority = logging.PriorityWarning
+				record.Message += " with an undersized backup"
+				record.Fields["undersized"] = "true"
+			}
+			if len(metadata.Missing) > 0 {
+				record.Priority = logging.PriorityWarning
+				record.Message += fmt.Sprintf(" without %d missing source directories", len(metadata.Missing))
+				record.Fields["missing"] = strings.Join(metadata.Missing, ",")
+			}
+		case control.RunFailed:
+			record.Priority = logging.PriorityErr
+			record.Message += ": " + entry.Error
+			record.Fields["error"] = entry.Error
+		case control.RunCancelled:
+			record.Priority = logging.PriorityWarning
+		}
+		logging.Emit(br.config.Logging, record)
+	}()
+
+	// Keep the end of the run's output for failure notifications. Runs in
+	// parallel share the output, so their lines may be mixed in.
+	runLog := utils.NewTail(br.config.Logging.FailureLogLines())
+	defer render.Tee(runLog)()
+
+	// Report the outcome to notifier and hook plugins
+	plugins := br.loadJobPlugins(job)
+	defer func() {
+		event := plugin.Event{Type: plugin.EventBackupSucceeded, Job: job.Name, RunID: runID, Timestamp: time.Now(),
+			Duration: time.Since(startedAt).Round(time.Second).String()}
+		if err != nil {
+			event.Type = plugin.EventBackupFailed
+			event.Error = err.Error()
+			event.Log = runLog.Lines()
+			event.ErrorOutput = errorOutput(err)
+		} else {
+			event.BackupID = metadata.ID
+			event.TotalSize = metadata.TotalSize
+			event.Missing = metadata.Missing
+			event.Undersized = metadata.Undersized
+		}
+		// The run context may already be cancelled; deliver the result regardless
+		if hookErr := plugins.runHooks(context.Background(), event); hookErr != nil {
+			render.Printf("Warning: %v\n", hookErr)
+		}
+		// Events covered by the digest are summarized there instead
+		if !br.config.Digest.Mutes(event.Type) {
+			plugins.notify(context.Background(), event)
+		}
+	}()
+
+	render.Printf("Starting backup job: %s\n", job.Name)
+	render.Printf("Run ID: %s\n", runID)
+	render.Printf("Description: %s\n", job.Description)
 
 	// Find the bucket configuration for this job
 	var bucketConfig *config.BucketConfig
@@ -62,112 +207,307 @@ func (br *BackupRunner) RunJob(ctx context.Context, jobName string) (*config.Bac
 		return nil, fmt.Errorf("bucket configuration not found for job %s", job.Name)
 	}
 
-	// Use S3 mount point as backup path if S3 storage is enabled
+	// Use this host's directory on the S3 mount as backup path if S3 storage is enabled
 	backupPath := br.backupPath
 	if job.Storage.S3 && bucketConfig != nil {
-		backupPath = bucketConfig.MountPoint
-		fmt.Printf("Using S3 mount point for backup: %s\n", backupPath)
+		backupPath = HostPath(bucketConfig.MountPoint)
+		render.Printf("Using S3 mount point for backup: %s\n", backupPath)
+	}
+
+	// Forwarded backups are written to the outbox and uploaded after the run
+	forward := job.Storage.S3 && job.Storage.Forward && bucketConfig != nil
+	if forward {
+		backupPath = br.config.OutboxPath()
+		render.Printf("Writing backup to the outbox for upload: %s\n", backupPath)
+	}
+
+	// Jobs stored only through plugins are staged in the temp path
+	staged := !job.Storage.Local && !job.Storage.S3 && len(plugins.storages) > 0
+	if staged {
+		backupPath = filepath.Join(br.config.TempPath, "staging")
+		render.Printf("Staging backup for storage plugins in: %s\n", backupPath)
+	}
+
+	if err := plugins.runHooks(ctx, plugin.Event{Type: plugin.EventBackupPre, Job: job.Name, RunID: runID, Timestamp: time.Now()}); err != nil {
+		return nil, err
+	}
+
+	// Capture system state before containers are stopped to keep downtime short
+	backupJob := *job
+	if job.SystemState.Enabled {
+		setPhase("system-state")
+		render.Println("\nCapturing system state...")
+		stateDir := filepath.Join(br.config.TempPath, config.SystemStateComponent, runID)
+		defer os.RemoveAll(stateDir)
+		if err := CollectSystemState(ctx, stateDir, job.SystemState); err != nil {
+			return nil, fmt.Errorf("failed to capture system state: %w", err)
+		}
+		backupJob.Directories = append(append([]config.DirectoryConfig(nil), job.Directories...), config.DirectoryConfig{
+			Path:        stateDir,
+			Name:        config.SystemStateComponent,
+			Compression: true,
+		})
+		render.Println("✅ System state captured")
+	}
+
+	// Containers and volumes opt into the job with Docker labels
+	if job.Docker.Discover {
+		setPhase("docker-discover")
+		render.Println("\nDiscovering labeled Docker containers and volumes...")
+		discovered, err := docker.DiscoverDirectories(ctx, backupJob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover backup targets: %w", err)
+		}
+		backupJob.Directories = append(append([]config.DirectoryConfig(nil), backupJob.Directories...), discovered...)
+		render.Printf("✅ Discovered %d directories\n", len(discovered))
+	}
+	if len(backupJob.Directories) == 0 {
+		return nil, fmt.Errorf("job %s has no directories to back up", job.Name)
 	}
 
 	// Initialize managers
-	// Use user-writable directory for Docker state
-	dockerStateDir := filepath.Join(os.Getenv("HOME"), ".backtide")
-	if err := os.MkdirAll(dockerStateDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create backtide directory: %w", err)
-	}
-	dockerStateFile := filepath.Join(dockerStateDir, "containers.json")
-	dockerManager := docker.NewDockerManager(dockerStateFile)
-	var s3Manager *s3fs.S3FSManager
+	dockerManager := docker.NewDockerManager(runID, job.Name, br.config.Docker)
+	var s3Mount BucketMount
 	if bucketConfig != nil {
-		s3Manager = s3fs.NewS3FSManager(*bucketConfig)
+		s3Mount = br.mountFor(*bucketConfig)
 	}
 
-	var stoppedContainers []config.DockerContainerInfo
+	// Set once containers are recorded as held by this run
+	holdingContainers := false
+
+	// Restart stopped containers however the job ends, including failure and cancellation
+	restartContainers := func() {
+		if !holdingContainers {
+			return
+		}
+		setPhase("docker-start")
+		render.Println("\nStep 4: Restarting Docker containers...")
+		if err := dockerManager.RestoreContainers(); err != nil {
+			render.Printf("Warning: Failed to restart some Docker containers: %v\n", err)
+		} else {
+			render.Println("✅ Docker containers restarted")
+		}
+		holdingContainers = false
+	}
+	defer restartContainers()
+
+	// Containers with exec hooks are quiesced in place and keep running
+	quiesced := false
+	resumeContainers := func() {
+		if !quiesced {
+			return
+		}
+		quiesced = false
+		if len(job.Docker.ExecAfter) == 0 {
+			return
+		}
+		setPhase("docker-exec-after")
+		render.Println("\nStep 4: Resuming Docker containers...")
+		for _, hook := range job.Docker.ExecAfter {
+			render.Printf("Running in container %s: %s\n", hook.Container, hook.Cmd)
+			if err := docker.Exec(context.Background(), hook); err != nil {
+				render.Printf("Warning: %v\n", err)
+			}
+		}
+	}
+	defer resumeContainers()
+
+	// A warm copy is taken while containers run so they are only stopped for
+	// copying what changed meanwhile
+	warm := job.Docker.WarmCopy && !job.SkipDocker
+	warmDir := filepath.Join(br.config.TempPath, "warm", runID)
+	if warm {
+		setPhase("warm-copy")
+		render.Println("\nCopying directories while Docker containers run...")
+		defer os.RemoveAll(warmDir)
+		_, stats, err := warmCopy(ctx, backupJob.Directories, warmDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to take warm copy: %w", err)
+		}
+		render.Printf("✅ Warm copy taken: %s\n", stats)
+	}
 
-	// Step 1: Stop Docker containers if enabled
-	if !job.SkipDocker {
-		fmt.Println("\nStep 1: Managing Docker containers...")
+	// Step 1: Quiesce or stop Docker containers if enabled
+	if len(job.Docker.ExecBefore) > 0 {
+		setPhase("docker-exec")
+		render.Println("\nStep 1: Quiescing Docker containers...")
+		for _, hook := range job.Docker.ExecBefore {
+			render.Printf("Running in container %s: %s\n", hook.Container, hook.Cmd)
+			// Resume even if only some containers were quiesced
+			quiesced = true
+			if err := docker.Exec(ctx, hook); err != nil {
+				return nil, fmt.Errorf("failed to quiesce Docker containers: %w", err)
+			}
+		}
+		render.Println("✅ Docker containers quiesced without stopping them")
+	} else if !job.SkipDocker {
+		setPhase("docker-stop")
+		render.Println("\nStep 1: Managing Docker containers...")
 		if err := dockerManager.CheckDockerAvailable(); err != nil {
-			fmt.Printf("Warning: Docker is not available: %v\n", err)
+			render.Printf("Warning: Docker is not available: %v\n", err)
 		} else {
 			stopped, err := dockerManager.StopContainers()
+			holdingContainers = true
 			if err != nil {
 				return nil, fmt.Errorf("failed to stop Docker containers: %w", err)
 			}
-			stoppedContainers = stopped
-			fmt.Printf("✅ Stopped %d Docker containers\n", len(stoppedContainers))
+			render.Printf("✅ Stopped %d Docker containers\n", len(stopped))
 		}
 	}
 
-	// Step 2: Setup S3FS if S3 storage is enabled
-	if !job.SkipS3 && job.Storage.S3 && s3Manager != nil {
-		fmt.Println("\nStep 2: Setting up S3 storage...")
-		if err := s3Manager.InstallS3FS(); err != nil {
-			return nil, fmt.Errorf("failed to install S3FS: %w", err)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("backup cancelled: %w", err)
+	}
+
+	// The backup is archived from the warm copy once it has caught up, so
+	// containers restart before archiving instead of after
+	if warm {
+		setPhase("warm-copy-delta")
+		render.Println("\nCopying changes made since the warm copy...")
+		copies, stats, err := warmCopy(ctx, backupJob.Directories, warmDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update warm copy: %w", err)
 		}
-		if err := s3Manager.SetupS3FS(); err != nil {
-			return nil, fmt.Errorf("failed to setup S3FS: %w", err)
+		backupJob.Directories = copies
+		render.Printf("✅ Warm copy updated: %s\n", stats)
+		restartContainers()
+	}
+
+	// Step 2: Setup S3FS if S3 storage is enabled
+	if !job.SkipS3 && job.Storage.S3 && s3Mount != nil && !forward {
+		setPhase("s3-setup")
+		render.Println("\nStep 2: Setting up S3 storage...")
+		if err := s3Mount.Prepare(); err != nil {
+			return nil, err
 		}
-		if err := s3Manager.MountS3FS(); err != nil {
+		// Remount if a previous mount has gone stale instead of writing into a dead mount;
+		// on-demand buckets are unmounted again when the run finishes
+		release, err := s3Mount.Acquire(runID, br.config.Mounts.Timeout())
+		if err != nil {
 			return nil, fmt.Errorf("failed to mount S3 bucket: %w", err)
 		}
-		fmt.Println("✅ S3 storage setup completed")
+		defer release()
+		render.Println("✅ S3 storage setup completed")
 	}
 
 	// Step 3: Create backup configuration for this job
 	jobBackupConfig := config.BackupConfig{
-		Jobs:       []config.BackupJob{*job},
+		Jobs:       []config.BackupJob{backupJob},
 		Buckets:    br.config.Buckets,
 		BackupPath: backupPath,
 		TempPath:   br.config.TempPath,
+		Network:    br.config.Network,
+		Durability: br.config.Durability,
+		Memory:     br.config.Memory,
 	}
 
 	// Step 4: Run backup
-	fmt.Println("\nStep 3: Creating backup...")
+	setPhase("backup")
+	render.Println("\nStep 3: Creating backup...")
 	backupManager := NewBackupManager(jobBackupConfig)
-	metadata, err := backupManager.CreateBackup(ctx)
+	backupManager.SetAnnotations(br.annotations)
+	if job.RunAs != "" || job.Sandbox {
+		if job.RunAs != "" {
+			render.Printf("Archiving as %s\n", job.RunAs)
+		}
+		if job.Sandbox {
+			render.Println("Archiving in a sandbox limited to the job's directories")
+		}
+		metadata, err = backupManager.createBackupInWorker(ctx, job.RunAs, job.Sandbox, runID)
+	} else {
+		metadata, err = backupManager.CreateBackup(ctx)
+	}
+
+	// Step 5: Restart or resume Docker containers
+	restartContainers()
+	resumeContainers()
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to create backup: %w", err)
 	}
 
-	// Step 5: Restart Docker containers if they were stopped
-	if !job.SkipDocker && len(stoppedContainers) > 0 {
-		fmt.Println("\nStep 4: Restarting Docker containers...")
-		if err := dockerManager.RestoreContainers(); err != nil {
-			fmt.Printf("Warning: Failed to restart some Docker containers: %v\n", err)
-		} else {
-			fmt.Println("✅ Docker containers restarted")
-		}
+	// A suspiciously small backup, e.g. of an empty bind mount, is kept but must
+	// not let retention remove the good backups before it
+	shortfall, err := checkBackupSize(job, backupManager, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if shortfall != "" {
+		render.Printf("⚠️  Undersized: %s\n", shortfall)
 	}
 
-	// Step 6: Cleanup old backups
-	fmt.Println("\nStep 5: Cleaning up old backups...")
-	if err := backupManager.CleanupBackups(); err != nil {
-		fmt.Printf("Warning: Failed to cleanup old backups: %v\n", err)
-	} else {
-		fmt.Println("✅ Old backups cleaned up")
+	// Lock the backup down to the configured backup user and group
+	if err := applyOutput(filepath.Join(backupPath, metadata.ID), job.Output); err != nil {
+		return nil, fmt.Errorf("failed to set permissions of backup %s: %w", metadata.ID, err)
 	}
 
-	fmt.Printf("\n✅ Backup job completed successfully: %s\n", job.Name)
-	return metadata, nil
-}
+	finish := func() (*config.BackupMetadata, error) {
+		if shortfall != "" && job.FailUndersized() {
+			return nil, fmt.Errorf("%s; the backup was kept but old backups were not cleaned up", shortfall)
+		}
+		render.Printf("\n✅ Backup job completed successfully: %s\n", job.Name)
+		return metadata, nil
+	}
 
-// RunAllJobs executes all enabled backup jobs
-func (br *BackupRunner) RunAllJobs(ctx context.Context) ([]config.BackupMetadata, error) {
-	var allMetadata []config.BackupMetadata
+	// Remember the last successful write for 's3 status'
+	if job.Storage.S3 && bucketConfig != nil && !staged && !forward {
+		if err := state.RecordBucketWrite(bucketConfig.ID, state.BucketWrite{BackupID: metadata.ID, Job: job.Name, At: time.Now()}); err != nil {
+			render.Printf("Warning: Failed to record bucket write: %v\n", err)
+		}
+		queueReplications(job, *bucketConfig, filepath.Join(backupPath, metadata.ID), metadata.ID)
+	}
 
-	for _, job := range br.config.Jobs {
-		if job.Enabled {
-			metadata, err := br.RunJob(ctx, job.Name)
-			if err != nil {
-				fmt.Printf("Failed to run job %s: %v\n", job.Name, err)
-				continue
+	// Add the backup to the local catalog
+	if !staged {
+		location := CatalogLocation{Path: backupPath, Job: job.Name}
+		if job.Storage.S3 && bucketConfig != nil && !forward {
+			location.BucketID = bucketConfig.ID
+		}
+		if err := state.RecordBackup(catalogEntry(*metadata, location)); err != nil {
+			render.Printf("Warning: Failed to update catalog: %v\n", err)
+		}
+	}
+
+	// Hand the backup to storage plugins
+	if len(plugins.storages) > 0 {
+		setPhase("plugin-store")
+		backupDir := filepath.Join(backupPath, metadata.ID)
+		if err := plugins.store(ctx, plugin.StoreRequest{Job: job.Name, BackupID: metadata.ID, Path: backupDir}); err != nil {
+			return nil, err
+		}
+		if staged {
+			if err := os.RemoveAll(backupDir); err != nil {
+				render.Printf("Warning: Failed to remove staged backup %s: %v\n", backupDir, err)
 			}
-			allMetadata = append(allMetadata, *metadata)
+			return finish()
 		}
 	}
 
-	return allMetadata, nil
+	// Queue the upload; old backups are cleaned up once it is on the bucket
+	if forward {
+		backupDir := filepath.Join(backupPath, metadata.ID)
+		upload := state.Upload{BackupID: metadata.ID, Job: job.Name, BucketID: bucketConfig.ID, Path: backupDir, Size: metadata.TotalSize, QueuedAt: time.Now()}
+		if err := state.QueueUpload(upload); err != nil {
+			return nil, fmt.Errorf("failed to queue upload: %w", err)
+		}
+		render.Printf("\n📥 Backup queued in the outbox for upload to bucket %s\n", bucketConfig.Name)
+		return finish()
+	}
+
+	// Step 6: Cleanup old backups
+	if metadata.Undersized {
+		render.Println("\nStep 5: Skipping cleanup of old backups after an undersized backup")
+		return finish()
+	}
+	setPhase("cleanup")
+	render.Println("\nStep 5: Cleaning up old backups...")
+	if err := backupManager.CleanupBackups(); err != nil {
+		render.Printf("Warning: Failed to cleanup old backups: %v\n", err)
+	} else {
+		render.Println("✅ Old backups cleaned up")
+	}
+
+	return finish()
 }
 
 // SetDryRun enables or disables dry run mode
@@ -175,6 +515,11 @@ func (br *BackupRunner) SetDryRun(dryRun bool) {
 	br.dryRun = dryRun
 }
 
+// SetAnnotations sets the comment and labels recorded with the backups of this runner
+func (br *BackupRunner) SetAnnotations(annotations Annotations) {
+	br.annotations = annotations
+}
+
 // RunJobCleanup cleans up old backups for a specific job
 func (br *BackupRunner) RunJobCleanup(jobName string) error {
 	job, err := br.findJob(jobName)
@@ -186,25 +531,21 @@ func (br *BackupRunner) RunJobCleanup(jobName string) error {
 		return fmt.Errorf("job %s is disabled", jobName)
 	}
 
-	fmt.Printf("Cleaning up old backups for job: %s\n", job.Name)
-	fmt.Printf("Retention policy: %d days, %d recent, %d monthly\n",
+	render.Printf("Cleaning up old backups for job: %s\n", job.Name)
+	render.Printf("Retention policy: %d days, %d recent, %d monthly\n",
 		job.Retention.KeepDays, job.Retention.KeepCount, job.Retention.KeepMonthly)
 
-	// Find the bucket configuration for this job
-	var bucketConfig *config.BucketConfig
-	for _, bucket := range br.config.Buckets {
-		if bucket.ID == job.BucketID {
-			bucketConfig = &bucket
-			break
-		}
+	// Only clean up this host's backups on a shared S3 mount
+	backupPath := br.jobStoragePath(job)
+	if backupPath != br.backupPath {
+		render.Printf("Using S3 mount point for cleanup: %s\n", backupPath)
 	}
 
-	// Use S3 mount point as backup path if S3 storage is enabled
-	backupPath := br.backupPath
-	if job.Storage.S3 && bucketConfig != nil {
-		backupPath = bucketConfig.MountPoint
-		fmt.Printf("Using S3 mount point for cleanup: %s\n", backupPath)
+	release, err := br.MountJobStorage(job.Name, fmt.Sprintf("cleanup-%d", os.Getpid()))
+	if err != nil {
+		return err
 	}
+	defer release()
 
 	// Create job-specific backup config
 	jobBackupConfig := config.BackupConfig{
@@ -212,14 +553,27 @@ func (br *BackupRunner) RunJobCleanup(jobName string) error {
 		Buckets:    br.config.Buckets,
 		BackupPath: backupPath,
 		TempPath:   br.config.TempPath,
+		Network:    br.config.Network,
 	}
 
 	backupManager := NewBackupManager(jobBackupConfig)
 	if err := backupManager.CleanupBackups(); err != nil {
+		logging.Emit(br.config.Logging, logging.Record{
+			Priority: logging.PriorityErr,
+			Event:    "cleanup.failed",
+			Message:  fmt.Sprintf("Cleanup of job %s failed: %v", job.Name, err),
+			Fields:   map[string]string{"job": job.Name, "error": err.Error()},
+		})
 		return fmt.Errorf("failed to cleanup backups: %w", err)
 	}
-
-	fmt.Printf("✅ Cleanup completed for job: %s\n", job.Name)
+	logging.Emit(br.config.Logging, logging.Record{
+		Priority: logging.PriorityInfo,
+		Event:    "cleanup.completed",
+		Message:  fmt.Sprintf("Cleanup of job %s completed", job.Name),
+		Fields:   map[string]string{"job": job.Name},
+	})
+
+	render.Printf("✅ Cleanup completed for job: %s\n", job.Name)
 	return nil
 }
 
@@ -263,11 +617,17 @@ func (br *BackupRunner) ListBackups() ([]config.BackupMetadata, error) {
 			TempPath:   br.config.TempPath,
 		}
 
-		// List backups from this path
+		// List backups from this path, mounting on-demand buckets while reading
+		release, err := br.MountJobStorage(job.Name, fmt.Sprintf("list-%d", os.Getpid()))
+		if err != nil {
+			render.Printf("Warning: %v\n", err)
+			continue
+		}
 		backupManager := NewBackupManager(jobBackupConfig)
 		backups, err := backupManager.ListBackups()
+		release()
 		if err != nil {
-			fmt.Printf("Warning: Failed to list backups from %s: %v\n", backupPath, err)
+			render.Printf("Warning: Failed to list backups from %s: %v\n", backupPath, err)
 			continue
 		}
 
@@ -286,7 +646,7 @@ func (br *BackupRunner) ListBackupsFromPath(path string) ([]config.BackupMetadat
 	// Create a minimal backup config for the path
 	backupConfig := config.BackupConfig{
 		BackupPath: path,
-		TempPath:   "/tmp/backtide",
+		TempPath:   paths.TempDir(),
 	}
 
 	backupManager := NewBackupManager(backupConfig)
@@ -301,10 +661,10 @@ func (br *BackupRunner) DiscoverBackups() ([]config.BackupMetadata, error) {
 	// Check common backup locations
 	locations := []string{
 		br.backupPath, // Primary backup path from config
-		"/var/lib/backtide/backups",
+		filepath.Join(paths.SystemStateDir, "backups"),
 		"/opt/backtide/backups",
-		filepath.Join(os.Getenv("HOME"), ".backtide", "backups"),
-		"/tmp/backtide",
+		filepath.Join(paths.StateDir(), "backups"),
+		paths.TempDir(),
 	}
 
 	// Also check S3 mount points if any buckets are configured
@@ -326,12 +686,12 @@ func (br *BackupRunner) DiscoverBackups() ([]config.BackupMetadata, error) {
 
 		backups, err := br.ListBackupsFromPath(location)
 		if err != nil {
-			fmt.Printf("Warning: Failed to discover backups from %s: %v\n", location, err)
+			render.Printf("Warning: Failed to discover backups from %s: %v\n", location, err)
 			continue
 		}
 
 		if len(backups) > 0 {
-			fmt.Printf("Discovered %d backups in %s\n", len(backups), location)
+			render.Printf("Discovered %d backups in %s\n", len(backups), location)
 			allBackups = append(allBackups, backups...)
 		}
 
@@ -341,6 +701,57 @@ func (br *BackupRunner) DiscoverBackups() ([]config.BackupMetadata, error) {
 	return allBackups, nil
 }
 
+// JobBackupConfig returns the configuration scoped to one job, with the backup
+// path resolved to the job's S3 mount point when it stores backups on S3
+func (br *BackupRunner) JobBackupConfig(jobName string) (config.BackupConfig, error) {
+	job, err := br.findJob(jobName)
+	if err != nil {
+		return config.BackupConfig{}, err
+	}
+
+	backupPath := br.backupPath
+	if job.Storage.S3 {
+		for _, bucket := range br.config.Buckets {
+			if bucket.ID == job.BucketID {
+				backupPath = bucket.MountPoint
+				break
+			}
+		}
+	}
+
+	return config.BackupConfig{
+		Jobs:       []config.BackupJob{*job},
+		Buckets:    br.config.Buckets,
+		BackupPath: backupPath,
+		TempPath:   br.config.TempPath,
+		Network:    br.config.Network,
+		Approval:   br.config.Approval,
+	}, nil
+}
+
+// MountJobStorage mounts the job's on-demand S3 bucket for the duration of an
+// operation; the returned function releases it. Other storage is left as is.
+func (br *BackupRunner) MountJobStorage(jobName, holder string) (func(), error) {
+	job, err := br.findJob(jobName)
+	if err != nil {
+		return func() {}, err
+	}
+	if !job.Storage.S3 || job.SkipS3 {
+		return func() {}, nil
+	}
+
+	for _, bucket := range br.config.Buckets {
+		if bucket.ID == job.BucketID && bucket.MountOnDemand {
+			release, err := br.mountFor(bucket).Acquire(holder, br.config.Mounts.Timeout())
+			if err != nil {
+				return func() {}, fmt.Errorf("failed to mount S3 bucket %s: %w", bucket.Name, err)
+			}
+			return release, nil
+		}
+	}
+	return func() {}, nil
+}
+
 // findJob finds a job by name
 func (br *BackupRunner) findJob(jobName string) (*config.BackupJob, error) {
 	for i, job := range br.config.Jobs {
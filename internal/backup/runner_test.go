@@ -0,0 +1,837 @@
+package backup_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/backuptest"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/docker/dockertest"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// sourceFiles is the tree backed up by the runner tests
+var sourceFiles = map[string]string{
+	"app.conf":             "listen = 8080\n",
+	"data/users.db":        "users",
+	"data/orders/2024.csv": "id,total\n1,9.99\n",
+	"empty/":               "",
+}
+
+// compose returns the labels of a Compose service
+func compose(service string, dependsOn ...string) map[string]string {
+	labels := map[string]string{
+		"com.docker.compose.project": "shop",
+		"com.docker.compose.service": service,
+	}
+	if len(dependsOn) > 0 {
+		labels["com.docker.compose.depends_on"] = strings.Join(dependsOn, ",")
+	}
+	return labels
+}
+
+// shop returns containers of a Compose project, a paused container and one
+// that is not running
+func shop() *dockertest.Runtime {
+	return dockertest.New(
+		dockertest.Container{ID: "db-id", Name: "db", Image: "postgres:16", Running: true, RestartPolicy: "always", Labels: compose("db")},
+		dockertest.Container{ID: "app-id", Name: "app", Image: "shop:latest", Running: true, RestartPolicy: "on-failure:3", Labels: compose("app", "db:service_started:false")},
+		dockertest.Container{ID: "worker-id", Name: "worker", Image: "shop:latest", Running: true, Paused: true},
+		dockertest.Container{ID: "old-id", Name: "old", Image: "shop:0.9"},
+	)
+}
+
+// newRunner returns a runner for a job named "test" backing up a source tree
+// to a local backup path; configure may change the configuration first
+func newRunner(t *testing.T, configure func(*config.BackupConfig)) (*backup.BackupRunner, config.BackupConfig) {
+	t.Helper()
+	backuptest.Isolate(t)
+	source := t.TempDir()
+	backuptest.WriteTree(t, source, sourceFiles)
+
+	job := config.BackupJob{
+		Name:        "test",
+		Enabled:     true,
+		Storage:     config.StorageConfig{Local: true},
+		Directories: []config.DirectoryConfig{{Path: source, Name: "app", Compression: true}},
+		Retention:   config.RetentionPolicy{KeepDays: 30, KeepCount: 10},
+	}
+	cfg := config.BackupConfig{
+		BackupPath: t.TempDir(),
+		TempPath:   t.TempDir(),
+		Jobs:       []config.BackupJob{job},
+		Docker:     config.DockerConfig{Parallelism: 1},
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	return backup.NewBackupRunner(cfg), cfg
+}
+
+// position returns the index of a call, failing the test if it was not made
+func position(t *testing.T, calls []string, call string) int {
+	t.Helper()
+	for i, c := range calls {
+		if c == call {
+			return i
+		}
+	}
+	t.Fatalf("docker %s was not run; calls:\n%s", call, strings.Join(calls, "\n"))
+	return -1
+}
+
+// checkRestored fails the test unless the shop containers are back in the
+// state they had before the run
+func checkRestored(t *testing.T, runtime *dockertest.Runtime) {
+	t.Helper()
+	for _, want := range []dockertest.Container{
+		{Name: "db", Running: true, RestartPolicy: "always"},
+		{Name: "app", Running: true, RestartPolicy: "on-failure:3"},
+		{Name: "worker", Running: true, Paused: true},
+		{Name: "old"},
+	} {
+		got, _ := runtime.Container(want.Name)
+		if got.Running != want.Running || got.Paused != want.Paused || got.RestartPolicy != want.RestartPolicy {
+			t.Errorf("%s: running=%v paused=%v restart=%q, want running=%v paused=%v restart=%q", want.Name,
+				got.Running, got.Paused, got.RestartPolicy, want.Running, want.Paused, want.RestartPolicy)
+		}
+	}
+}
+
+func TestRunJobStopsAndRestartsContainers(t *testing.T) {
+	runtime := shop()
+	runtime.Install(t)
+	runner, cfg := newRunner(t, nil)
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+
+	calls := runtime.Calls()
+	// Dependents stop first and start last, without Docker restarting them meanwhile
+	if position(t, calls, "stop app-id") > position(t, calls, "stop db-id") {
+		t.Error("db was stopped before app, which depends on it")
+	}
+	if position(t, calls, "start db-id") > position(t, calls, "start app-id") {
+		t.Error("app was started before db, which it depends on")
+	}
+	if position(t, calls, "update --restart=no db-id") > position(t, calls, "stop db-id") {
+		t.Error("the restart policy of db was disabled after stopping it")
+	}
+	position(t, calls, "pause worker-id")
+	for _, call := range calls {
+		if strings.HasSuffix(call, " old-id") {
+			t.Errorf("docker %s was run for a container that was not running", call)
+		}
+	}
+	checkRestored(t, runtime)
+
+	backuptest.Golden(t, "runjob-archive", backuptest.ListArchive(t, filepath.Join(cfg.BackupPath, metadata.ID, "app.tar.gz")))
+
+	target := t.TempDir()
+	if err := backup.NewBackupManager(cfg).RestoreBackupToPath(metadata.ID, target); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	backuptest.Golden(t, "runjob-restore", backuptest.ListTree(t, target))
+}
+
+func TestRunJobExecHooksKeepContainersRunning(t *testing.T) {
+	runtime := shop()
+	var ran []string
+	runtime.OnExec = func(container, command string) error {
+		ran = append(ran, container+": "+command)
+		return nil
+	}
+	runtime.Install(t)
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Jobs[0].Docker.ExecBefore = []config.ContainerExec{{Container: "db", Cmd: "pg_backup_start"}}
+		cfg.Jobs[0].Docker.ExecAfter = []config.ContainerExec{{Container: "db", Cmd: "pg_backup_stop"}}
+	})
+
+	if _, err := runner.RunJob(context.Background(), "test"); err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+
+	if want := []string{"db: pg_backup_start", "db: pg_backup_stop"}; strings.Join(ran, "\n") != strings.Join(want, "\n") {
+		t.Errorf("exec hooks ran %q, want %q", ran, want)
+	}
+	for _, call := range runtime.Calls() {
+		if strings.HasPrefix(call, "stop ") {
+			t.Errorf("docker %s was run for a job with exec hooks", call)
+		}
+	}
+	checkRestored(t, runtime)
+}
+
+func TestRunJobFailureNotificationCarriesOutput(t *testing.T) {
+	runtime := shop()
+	runtime.OnExec = func(container, command string) error {
+		return errors.New("could not connect to server: Connection refused")
+	}
+	runtime.Install(t)
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Jobs[0].Docker.ExecBefore = []config.ContainerExec{{Container: "db", Cmd: "pg_backup_start"}}
+		cfg.Logging.FailureLines = 5
+	})
+	notifier := &backuptest.Notifier{}
+	runner.AddNotifier(notifier)
+
+	if _, err := runner.RunJob(context.Background(), "test"); err == nil {
+		t.Fatal("RunJob succeeded with a failing exec hook")
+	}
+
+	events := notifier.Events()
+	if len(events) != 1 || events[0].Type != plugin.EventBackupFailed {
+		t.Fatalf("notifier received %+v, want one backup.failed event", events)
+	}
+	event := events[0]
+	if len(event.Log) == 0 || len(event.Log) > 5 {
+		t.Errorf("event carries %d log lines, want 1 to 5: %q", len(event.Log), event.Log)
+	}
+	want := []string{"docker exec:", "Error: could not connect to server: Connection refused"}
+	if strings.Join(event.ErrorOutput, "\n") != strings.Join(want, "\n") {
+		t.Errorf("event error output is %q, want %q", event.ErrorOutput, want)
+	}
+}
+
+func TestRunJobNotificationTemplates(t *testing.T) {
+	shop().Install(t)
+	request := filepath.Join(t.TempDir(), "request.json")
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Plugins = []config.PluginConfig{{
+			Name:    "chat",
+			Type:    plugin.TypeNotifier,
+			Command: "sh",
+			Args:    []string{"-c", `cat > "$REQUEST"; echo '{"ok": true}'`},
+			Env:     map[string]string{"REQUEST": request},
+			Title:   `[{{upper .Status}}] {{.Job}}`,
+			Message: `Sauvegarde {{.BackupID}} terminée ({{size .TotalSize}})`,
+		}}
+		cfg.Jobs[0].Plugins = []string{"chat"}
+	})
+	notifier := &backuptest.Notifier{}
+	runner.AddNotifier(notifier)
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+
+	// Notifiers without templates get the default text
+	events := notifier.Events()
+	if len(events) != 1 || !strings.HasPrefix(events[0].Message, "Backup "+metadata.ID+" of job test succeeded: ") {
+		t.Errorf("notifier received %+v, want the default message", events)
+	}
+
+	data, err := os.ReadFile(request)
+	if err != nil {
+		t.Fatalf("notifier plugin was not called: %v", err)
+	}
+	var call struct {
+		Event plugin.Event `json:"event"`
+	}
+	if err := json.Unmarshal(data, &call); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[SUCCEEDED] test"; call.Event.Title != want {
+		t.Errorf("title is %q, want %q", call.Event.Title, want)
+	}
+	if want := fmt.Sprintf("Sauvegarde %s terminée (%s)", metadata.ID, utils.FormatBytes(metadata.TotalSize)); call.Event.Message != want {
+		t.Errorf("message is %q, want %q", call.Event.Message, want)
+	}
+}
+
+func TestRunJobRestartsContainersAfterFailure(t *testing.T) {
+	runtime := shop()
+	runtime.Install(t)
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Jobs[0].Directories = append(cfg.Jobs[0].Directories, config.DirectoryConfig{
+			Path: filepath.Join(t.TempDir(), "missing"), Name: "missing", Required: true,
+		})
+	})
+
+	if _, err := runner.RunJob(context.Background(), "test"); err == nil {
+		t.Fatal("RunJob succeeded without a required directory")
+	}
+	position(t, runtime.Calls(), "stop db-id")
+	checkRestored(t, runtime)
+}
+
+func TestRunJobWarmCopy(t *testing.T) {
+	runtime := shop()
+	runtime.Install(t)
+	runner, cfg := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Jobs[0].Docker.WarmCopy = true
+	})
+	source := cfg.Jobs[0].Directories[0].Path
+
+	// Files changed while containers shut down must reach the backup through
+	// the second pass
+	runtime.OnStop = func(container string) {
+		if container != "db" {
+			return
+		}
+		backuptest.WriteTree(t, source, map[string]string{"data/users.db": "users, updated", "data/wal/0001": "wal"})
+		if err := os.RemoveAll(filepath.Join(source, "data", "orders")); err != nil {
+			t.Error(err)
+		}
+	}
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	checkRestored(t, runtime)
+	if got := metadata.Directories[0].Path; got != source {
+		t.Errorf("backup recorded directory %s, want the original %s", got, source)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(cfg.TempPath, "warm")); len(entries) > 0 {
+		t.Errorf("warm copy was left in the temp path: %v", entries)
+	}
+
+	target := t.TempDir()
+	if err := backup.NewBackupManager(cfg).RestoreBackupToPath(metadata.ID, target); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if got, want := backuptest.ListTree(t, filepath.Join(target, "app")), backuptest.ListTree(t, source); got != want {
+		t.Errorf("restored tree differs from the source after containers stopped:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunJobAppliesOutputPermissions(t *testing.T) {
+	shop().Install(t)
+	runner, cfg := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Jobs[0].Output = config.OutputConfig{Mode: "0640", Group: strconv.Itoa(os.Getgid())}
+	})
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	backupDir := filepath.Join(cfg.BackupPath, metadata.ID)
+	err = filepath.WalkDir(backupDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		want := os.FileMode(0640)
+		if d.IsDir() {
+			want = 0750
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s has mode %o, want %o", path, got, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunJobPrunesForFreeSpace(t *testing.T) {
+	shop().Install(t)
+	runner, cfg := newRunner(t, func(cfg *config.BackupConfig) {
+		// No disk has this much free space, so only the newest backup stays
+		cfg.Jobs[0].Retention.MinFreeSpace = "100000TB"
+	})
+
+	var newest *config.BackupMetadata
+	for i := 0; i < 3; i++ {
+		metadata, err := runner.RunJob(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("RunJob failed: %v", err)
+		}
+		newest = metadata
+	}
+	entries, err := os.ReadDir(cfg.BackupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	for _, entry := range entries {
+		kept = append(kept, entry.Name())
+	}
+	if len(kept) != 1 || kept[0] != newest.ID {
+		t.Errorf("backups kept: %v, want only the newest %s", kept, newest.ID)
+	}
+}
+
+func TestRunJobPrunesForFreeSpaceKeepsCompleteBackup(t *testing.T) {
+	shop().Install(t)
+	runner, cfg := newRunner(t, nil)
+	var complete *config.BackupMetadata
+	for i := 0; i < 2; i++ {
+		metadata, err := runner.RunJob(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("RunJob failed: %v", err)
+		}
+		complete = metadata
+	}
+
+	// The next backup is undersized and the disk never has enough free space
+	cfg.Jobs[0].ExpectedMinSize = "100000TB"
+	cfg.Jobs[0].UndersizedAction = config.UndersizedWarn
+	cfg.Jobs[0].Retention.MinFreeSpace = "100000TB"
+	undersized, err := backup.NewBackupRunner(cfg).RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	if !undersized.Undersized {
+		t.Fatal("backup was not marked undersized")
+	}
+
+	// Runs skip cleanup after an undersized backup, a later cleanup does not
+	jobConfig, err := backup.NewBackupRunner(cfg).JobBackupConfig("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.NewBackupManager(jobConfig).CleanupBackups(); err != nil {
+		t.Fatalf("CleanupBackups failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(cfg.BackupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	for _, entry := range entries {
+		kept = append(kept, entry.Name())
+	}
+	want := []string{complete.ID, undersized.ID}
+	sort.Strings(want)
+	if !slices.Equal(kept, want) {
+		t.Errorf("backups kept: %v, want the newest complete and the undersized one %v", kept, want)
+	}
+}
+
+func TestRunJobWithoutDocker(t *testing.T) {
+	runtime := shop()
+	runtime.Unavailable = true
+	runtime.Install(t)
+	runner, _ := newRunner(t, nil)
+
+	// An unavailable Docker daemon is only a warning
+	if _, err := runner.RunJob(context.Background(), "test"); err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+}
+
+func TestRunJobToBucketAndStoragePlugin(t *testing.T) {
+	dockertest.New().Install(t)
+	bucket := &backuptest.Bucket{}
+	mountPoint := t.TempDir()
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Buckets = []config.BucketConfig{{ID: "offsite", Bucket: "backups", MountPoint: mountPoint}}
+		cfg.Jobs[0].Storage = config.StorageConfig{S3: true}
+		cfg.Jobs[0].BucketID = "offsite"
+	})
+	runner.SetBucketMount(func(config.BucketConfig) backup.BucketMount { return bucket })
+	storage := backuptest.NewStorage()
+	runner.AddStorage(storage)
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+
+	if acquired, released := bucket.Mounts(); acquired != 1 || released != 1 {
+		t.Errorf("bucket was acquired %d and released %d times, want once each", acquired, released)
+	}
+	hostDir := filepath.Join(backup.HostPath(mountPoint), metadata.ID)
+	if _, err := os.Stat(filepath.Join(hostDir, "app.tar.gz")); err != nil {
+		t.Errorf("backup was not written to this host's directory on the bucket: %v", err)
+	}
+	if got := storage.Backups(); len(got) != 1 || got[0] != metadata.ID {
+		t.Fatalf("storage plugin received backups %v, want %s", got, metadata.ID)
+	}
+	archive, err := os.ReadFile(filepath.Join(hostDir, "app.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(storage.Files(metadata.ID)["app.tar.gz"]) != string(archive) {
+		t.Error("storage plugin received a different archive than was written to the bucket")
+	}
+}
+
+func TestRunJobForwardsThroughOutbox(t *testing.T) {
+	dockertest.New().Install(t)
+	bucket := &backuptest.Bucket{Err: errors.New("bucket unreachable")}
+	mountPoint := t.TempDir()
+	runner, cfg := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Buckets = []config.BucketConfig{{ID: "offsite", Bucket: "backups", MountPoint: mountPoint}}
+		cfg.Jobs[0].Storage = config.StorageConfig{S3: true, Forward: true}
+		cfg.Jobs[0].BucketID = "offsite"
+	})
+	runner.SetBucketMount(func(config.BucketConfig) backup.BucketMount { return bucket })
+
+	// The run completes while the bucket is unreachable
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed with the bucket unreachable: %v", err)
+	}
+	queued := filepath.Join(cfg.OutboxPath(), metadata.ID)
+	if _, err := os.Stat(filepath.Join(queued, "app.tar.gz")); err != nil {
+		t.Fatalf("backup was not written to the outbox: %v", err)
+	}
+
+	if uploaded, err := runner.UploadOutbox(context.Background(), false); err == nil || uploaded != 0 {
+		t.Fatalf("upload to an unreachable bucket uploaded %d backups, err %v", uploaded, err)
+	}
+	uploads, err := state.LoadUploads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads) != 1 || uploads[0].BackupID != metadata.ID || uploads[0].Attempts != 1 || uploads[0].LastError == "" {
+		t.Fatalf("upload queue after a failed attempt is %+v", uploads)
+	}
+
+	if queue, err := backup.UploadQueue(cfg); err != nil || len(queue) != 1 || queue[0].State != state.UploadFailed {
+		t.Errorf("upload queue status after a failed attempt is %+v, err %v", queue, err)
+	}
+
+	// Failed uploads wait for the retry interval unless retried
+	bucket.Err = nil
+	if uploaded, err := runner.UploadOutbox(context.Background(), false); err != nil || uploaded != 0 {
+		t.Errorf("upload retried before the retry interval: uploaded %d, err %v", uploaded, err)
+	}
+	if retried, err := backup.RetryUploads(nil); err != nil || len(retried) != 1 {
+		t.Fatalf("retry of failed uploads returned %v, err %v", retried, err)
+	}
+	if uploaded, err := runner.UploadOutbox(context.Background(), false); err != nil || uploaded != 1 {
+		t.Fatalf("retried upload uploaded %d backups, err %v", uploaded, err)
+	}
+
+	hostDir := filepath.Join(backup.HostPath(mountPoint), metadata.ID)
+	for _, name := range []string{"app.tar.gz", "metadata.toml"} {
+		if _, err := os.Stat(filepath.Join(hostDir, name)); err != nil {
+			t.Errorf("%s was not uploaded: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(queued); !os.IsNotExist(err) {
+		t.Errorf("uploaded backup is still in the outbox: %v", err)
+	}
+	if uploads, _ := state.LoadUploads(); len(uploads) != 0 {
+		t.Errorf("upload queue after the upload is %+v", uploads)
+	}
+}
+
+func TestCancelledUploadStaysInOutbox(t *testing.T) {
+	dockertest.New().Install(t)
+	bucket := &backuptest.Bucket{}
+	mountPoint := t.TempDir()
+	runner, cfg := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Buckets = []config.BucketConfig{{ID: "offsite", Bucket: "backups", MountPoint: mountPoint}}
+		cfg.Jobs[0].Storage = config.StorageConfig{S3: true, Forward: true}
+		cfg.Jobs[0].BucketID = "offsite"
+	})
+	runner.SetBucketMount(func(config.BucketConfig) backup.BucketMount { return bucket })
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	if _, err := state.RemoveUpload(metadata.ID); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if _, err := state.RemoveUpload(metadata.ID); !errors.Is(err, state.ErrUploadNotQueued) {
+		t.Errorf("cancelling twice returned %v", err)
+	}
+
+	if uploaded, err := runner.UploadOutbox(context.Background(), true); err != nil || uploaded != 0 {
+		t.Errorf("upload after cancelling uploaded %d backups, err %v", uploaded, err)
+	}
+	if acquired, _ := bucket.Mounts(); acquired != 0 {
+		t.Errorf("bucket was mounted %d times for a cancelled upload", acquired)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutboxPath(), metadata.ID, "metadata.toml")); err != nil {
+		t.Errorf("cancelled backup was not kept in the outbox: %v", err)
+	}
+}
+
+func TestRunJobPurgesNoncurrentVersions(t *testing.T) {
+	dockertest.New().Install(t)
+	var mu sync.Mutex
+	var listed, purged []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && query.Has("versioning"):
+			fmt.Fprint(w, "<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>")
+		case r.Method == http.MethodGet && query.Has("versions"):
+			prefix := query.Get("prefix")
+			listed = append(listed, prefix)
+			fmt.Fprintf(w, `<ListVersionsResult><IsTruncated>false</IsTruncated>
+<DeleteMarker><Key>%[1]sapp.tar.gz</Key><VersionId>marker</VersionId><IsLatest>true</IsLatest></DeleteMarker>
+<Version><Key>%[1]sapp.tar.gz</Key><VersionId>old</VersionId><IsLatest>false</IsLatest><Size>100</Size></Version>
+<Version><Key>%[1]skept</Key><VersionId>current</VersionId><IsLatest>true</IsLatest><Size>5</Size></Version>
+</ListVersionsResult>`, prefix)
+		case r.Method == http.MethodDelete:
+			purged = append(purged, strings.TrimPrefix(r.URL.Path, "/backups/")+"@"+query.Get("versionId"))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	mountPoint := t.TempDir()
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Buckets = []config.BucketConfig{{ID: "offsite", Name: "offsite", Bucket: "backups", MountPoint: mountPoint,
+			Endpoint: server.URL, UsePathStyle: true, AccessKey: "key", SecretKey: "secret", PurgeVersions: true}}
+		cfg.Jobs[0].Storage = config.StorageConfig{S3: true}
+		cfg.Jobs[0].BucketID = "offsite"
+		cfg.Jobs[0].Retention.KeepCount = 1
+	})
+	runner.SetBucketMount(func(config.BucketConfig) backup.BucketMount { return &backuptest.Bucket{} })
+
+	first, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("first RunJob failed: %v", err)
+	}
+	if _, err := runner.RunJob(context.Background(), "test"); err != nil {
+		t.Fatalf("second RunJob failed: %v", err)
+	}
+
+	prefix := "hosts/" + filepath.Base(backup.HostPath(mountPoint)) + "/" + first.ID + "/"
+	if len(listed) != 1 || listed[0] != prefix {
+		t.Errorf("listed versions under %q, want only %q", listed, prefix)
+	}
+	want := []string{prefix + "app.tar.gz@old", prefix + "app.tar.gz@marker"}
+	if strings.Join(purged, "\n") != strings.Join(want, "\n") {
+		t.Errorf("purged %q, want %q", purged, want)
+	}
+}
+
+func TestCheckBucketFindsCorruptedArchives(t *testing.T) {
+	dockertest.New().Install(t)
+	mountPoint := t.TempDir()
+	// Serves the bucket mount as a read-only bucket
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "read-only", http.StatusForbidden)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/backups/")
+		if r.URL.Query().Get("list-type") == "2" {
+			fmt.Fprint(w, "<ListBucketResult><IsTruncated>false</IsTruncated>")
+			filepath.WalkDir(mountPoint, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					rel, _ := filepath.Rel(mountPoint, path)
+					fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", rel)
+				}
+				return err
+			})
+			fmt.Fprint(w, "</ListBucketResult>")
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(mountPoint, key))
+	}))
+	defer server.Close()
+
+	bucket := config.BucketConfig{ID: "offsite", Name: "offsite", Bucket: "backups", MountPoint: mountPoint,
+		Endpoint: server.URL, UsePathStyle: true, AccessKey: "key", SecretKey: "secret"}
+	runner, _ := newRunner(t, func(cfg *config.BackupConfig) {
+		cfg.Buckets = []config.BucketConfig{bucket}
+		cfg.Jobs[0].Storage = config.StorageConfig{S3: true}
+		cfg.Jobs[0].BucketID = "offsite"
+	})
+	runner.SetBucketMount(func(config.BucketConfig) backup.BucketMount { return &backuptest.Bucket{} })
+
+	var backups []*config.BackupMetadata
+	for i := 0; i < 2; i++ {
+		metadata, err := runner.RunJob(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("RunJob failed: %v", err)
+		}
+		backups = append(backups, metadata)
+	}
+
+	agent := config.VerifyAgentConfig{Buckets: []string{"offsite"}, Sample: 10, MaxAge: "1h"}
+	check, err := backup.CheckBucket(context.Background(), bucket, config.NetworkConfig{}, agent)
+	if err != nil {
+		t.Fatalf("CheckBucket failed: %v", err)
+	}
+	if len(check.Problems) > 0 || check.Backups != 2 || check.Archives != 2 {
+		t.Fatalf("intact bucket: %d backups, %d archives checksummed, problems %q; want 2, 2 and none",
+			check.Backups, check.Archives, check.Problems)
+	}
+	if len(check.Hosts) != 1 || check.Hosts[0].Newest != backups[1].ID {
+		t.Errorf("hosts = %+v, want one with newest backup %s", check.Hosts, backups[1].ID)
+	}
+
+	hostPath := backup.HostPath(mountPoint)
+	corrupted := filepath.Join(hostPath, backups[0].ID, "app.tar.gz")
+	file, err := os.OpenFile(corrupted, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("x"))
+	file.Close()
+	if err := os.Remove(filepath.Join(hostPath, backups[1].ID, "app.tar.gz")); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = backup.CheckBucket(context.Background(), bucket, config.NetworkConfig{}, agent)
+	if err != nil {
+		t.Fatalf("CheckBucket failed: %v", err)
+	}
+	problems := strings.Join(check.Problems, "\n")
+	for _, want := range []string{
+		backups[0].ID + " of " + backup.Hostname() + ": archive app.tar.gz does not match its checksum",
+		backups[1].ID + " of " + backup.Hostname() + ": archive app.tar.gz is missing",
+	} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("problems %q do not mention %q", check.Problems, want)
+		}
+	}
+}
+
+// newReplicatingRunner returns a runner whose job stores to bucket primary
+// and replicates to bucket secondary, with the mounts of both
+func newReplicatingRunner(t *testing.T, primaryEndpoint, secondaryEndpoint string) (*backup.BackupRunner, map[string]*backuptest.Bucket, config.BackupConfig) {
+	t.Helper()
+	mounts := map[string]*backuptest.Bucket{"primary": {}, "secondary": {}}
+	runner, cfg := newRunner(t, func(cfg *config.BackupConfig) {
+		for _, id := range []string{"primary", "secondary"} {
+			endpoint := primaryEndpoint
+			if id == "secondary" {
+				endpoint = secondaryEndpoint
+			}
+			cfg.Buckets = append(cfg.Buckets, config.BucketConfig{ID: id, Name: id, Bucket: id, MountPoint: t.TempDir(),
+				Endpoint: endpoint, UsePathStyle: true, AccessKey: "key", SecretKey: "secret"})
+		}
+		cfg.Jobs[0].Storage = config.StorageConfig{S3: true}
+		cfg.Jobs[0].BucketID = "primary"
+		cfg.Jobs[0].Replicate = []config.ReplicationRule{{BucketID: "secondary"}}
+	})
+	runner.SetBucketMount(func(bucket config.BucketConfig) backup.BucketMount { return mounts[bucket.ID] })
+	return runner, mounts, cfg
+}
+
+// checkReplicated fails the test unless the secondary bucket holds a copy of
+// the backup at the same path and the replication queue is empty
+func checkReplicated(t *testing.T, cfg config.BackupConfig, metadata *config.BackupMetadata) {
+	t.Helper()
+	primary, err := filepath.Rel(cfg.Buckets[0].MountPoint, backup.HostPath(cfg.Buckets[0].MountPoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(cfg.Buckets[0].MountPoint, primary, metadata.ID)
+	replica := filepath.Join(cfg.Buckets[1].MountPoint, primary, metadata.ID)
+	if got, want := backuptest.ListTree(t, replica), backuptest.ListTree(t, source); got != want {
+		t.Errorf("replica differs from the backup:\n%s\nwant:\n%s", got, want)
+	}
+	if queue, err := state.LoadReplications(); err != nil || len(queue) != 0 {
+		t.Errorf("replication queue = %+v, %v; want empty", queue, err)
+	}
+	statuses, err := backup.ReplicationStatus(cfg.Jobs[0])
+	if err != nil || len(statuses) != 1 || statuses[0].Pending != 0 || statuses[0].LastCopy.IsZero() {
+		t.Errorf("replication status = %+v, %v; want up to date with a last copy", statuses, err)
+	}
+}
+
+func TestRunJobReplicatesThroughMounts(t *testing.T) {
+	dockertest.New().Install(t)
+	runner, mounts, cfg := newReplicatingRunner(t, "http://127.0.0.1:1", "http://127.0.0.1:2")
+
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	statuses, err := backup.ReplicationStatus(cfg.Jobs[0])
+	if err != nil || len(statuses) != 1 || statuses[0].Pending != 1 {
+		t.Fatalf("replication status after the run = %+v, %v; want one backup pending", statuses, err)
+	}
+
+	mounts["secondary"].Err = errors.New("bucket unreachable")
+	if _, err := runner.Replicate(context.Background(), false); err == nil {
+		t.Fatal("Replicate succeeded without the secondary bucket")
+	}
+	if statuses, _ := backup.ReplicationStatus(cfg.Jobs[0]); len(statuses) != 1 || !strings.Contains(statuses[0].LastError, "bucket unreachable") {
+		t.Errorf("replication status after a failure = %+v, want the error", statuses)
+	}
+
+	mounts["secondary"].Err = nil
+	if replicated, err := runner.Replicate(context.Background(), true); err != nil || replicated != 1 {
+		t.Fatalf("Replicate = %d, %v; want 1 backup", replicated, err)
+	}
+	checkReplicated(t, cfg, metadata)
+}
+
+func TestRunJobReplicatesServerSide(t *testing.T) {
+	dockertest.New().Install(t)
+	var cfg config.BackupConfig
+	var copied []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source := r.Header.Get("x-amz-copy-source")
+		if r.Method != http.MethodPut || !strings.HasPrefix(source, "/primary/") || !strings.HasPrefix(r.URL.Path, "/secondary/") {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/secondary/")
+		data, err := os.ReadFile(filepath.Join(cfg.Buckets[0].MountPoint, strings.TrimPrefix(source, "/primary/")))
+		if err == nil {
+			target := filepath.Join(cfg.Buckets[1].MountPoint, key)
+			os.MkdirAll(filepath.Dir(target), 0755)
+			err = os.WriteFile(target, data, 0644)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		copied = append(copied, filepath.Base(key))
+		fmt.Fprint(w, "<CopyObjectResult></CopyObjectResult>")
+	}))
+	defer server.Close()
+
+	runner, mounts, replicatingCfg := newReplicatingRunner(t, server.URL, server.URL)
+	cfg = replicatingCfg
+	metadata, err := runner.RunJob(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	if replicated, err := runner.Replicate(context.Background(), false); err != nil || replicated != 1 {
+		t.Fatalf("Replicate = %d, %v; want 1 backup", replicated, err)
+	}
+
+	if acquired, _ := mounts["secondary"].Mounts(); acquired != 0 {
+		t.Errorf("secondary bucket was mounted %d times for a server-side copy", acquired)
+	}
+	if len(copied) == 0 || copied[len(copied)-1] != "metadata.toml" {
+		t.Errorf("copied %q, want metadata.toml last", copied)
+	}
+	checkReplicated(t, cfg, metadata)
+}
+
+func TestRunJobStoragePluginFailure(t *testing.T) {
+	dockertest.New().Install(t)
+	runner, _ := newRunner(t, nil)
+	storage := backuptest.NewStorage()
+	storage.Err = errors.New("quota exceeded")
+	runner.AddStorage(storage)
+
+	if _, err := runner.RunJob(context.Background(), "test"); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("RunJob returned %v, want the storage plugin's error", err)
+	}
+}
@@ -0,0 +1,41 @@
+package backup
+
+// SandboxEnv marks an archive worker that already runs inside its sandbox
+const SandboxEnv = "BACKTIDE_SANDBOXED"
+
+// sandboxSystemPaths may be read and executed in the sandbox so the worker
+// and filter commands can load shared libraries and run the shell, and owner
+// names can be archived
+var sandboxSystemPaths = []string{
+	"/bin", "/sbin", "/usr", "/lib", "/lib32", "/lib64",
+	"/etc/ld.so.cache", "/etc/nsswitch.conf", "/etc/passwd", "/etc/group",
+}
+
+// SandboxRules are the paths a sandboxed archive worker may use
+type SandboxRules struct {
+	// Read are the job's source directories
+	Read []string
+	// Write are the backup path and the worker's temp directory
+	Write []string
+}
+
+// sandboxRules returns the paths the worker of this backup needs
+func (bm *BackupManager) sandboxRules(tempDir string) SandboxRules {
+	rules := SandboxRules{Write: []string{bm.backupPath, tempDir}}
+	for _, dir := range bm.config.Jobs[0].Directories {
+		rules.Read = append(rules.Read, dir.SourcePath())
+	}
+	return rules
+}
+
+// args returns the worker flags that pass the rules on
+func (r SandboxRules) args() []string {
+	args := []string{"--sandbox"}
+	for _, path := range r.Read {
+		args = append(args, "--read", path)
+	}
+	for _, path := range r.Write {
+		args = append(args, "--write", path)
+	}
+	return args
+}
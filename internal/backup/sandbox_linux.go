@@ -0,0 +1,151 @@
+//go:build linux
+
+package backup
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock system calls and flags, see linux/landlock.h
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+	prSetNoNewPrivs              = 38
+	oPath                        = 0x200000
+)
+
+// Landlock file system access rights
+const (
+	accessExecute    = 1 << 0
+	accessWriteFile  = 1 << 1
+	accessReadFile   = 1 << 2
+	accessReadDir    = 1 << 3
+	accessRefer      = 1 << 13
+	accessTruncate   = 1 << 14
+	accessIoctlDev   = 1 << 15
+	accessBindTCP    = 1 << 0
+	accessConnectTCP = 1 << 1
+
+	// accessFileRights are the rights that apply to files rather than directories
+	accessFileRights = accessExecute | accessWriteFile | accessReadFile | accessTruncate | accessIoctlDev
+)
+
+type landlockRulesetAttr struct {
+	handledAccessFS  uint64
+	handledAccessNet uint64
+}
+
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+// EnterSandbox restricts the worker to the paths in rules with Landlock and
+// starts it again, so the restriction covers every thread; it returns nil
+// without doing anything once the worker runs inside the sandbox
+func EnterSandbox(rules SandboxRules) error {
+	if os.Getenv(SandboxEnv) == "1" {
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not determine current executable path: %w", err)
+	}
+
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return fmt.Errorf("sandbox needs Landlock, which this kernel does not provide: %w", errno)
+	}
+	attr := landlockRulesetAttr{handledAccessFS: 1<<13 - 1}
+	if abi >= 2 {
+		attr.handledAccessFS |= accessRefer
+	}
+	if abi >= 3 {
+		attr.handledAccessFS |= accessTruncate
+	}
+	if abi >= 5 {
+		attr.handledAccessFS |= accessIoctlDev
+	}
+	// Kernels before ABI 4 reject the network field; with it, no TCP is allowed
+	attrSize := unsafe.Sizeof(attr.handledAccessFS)
+	if abi >= 4 {
+		attr.handledAccessNet = accessBindTCP | accessConnectTCP
+		attrSize = unsafe.Sizeof(attr)
+	}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), attrSize, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create sandbox ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer syscall.Close(ruleset)
+
+	readDir := uint64(accessReadFile | accessReadDir)
+	allow := func(path string, access uint64, required bool) error {
+		pathFd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			if !required && os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to open %s for the sandbox: %w", path, err)
+		}
+		defer syscall.Close(pathFd)
+		var stat syscall.Stat_t
+		if err := syscall.Fstat(pathFd, &stat); err != nil {
+			return fmt.Errorf("failed to open %s for the sandbox: %w", path, err)
+		}
+		access &= attr.handledAccessFS
+		if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+			access &= accessFileRights
+		}
+		rule := landlockPathBeneathAttr{allowedAccess: access, parentFd: int32(pathFd)}
+		if _, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(ruleset), landlockRulePathBeneath, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+			return fmt.Errorf("failed to allow %s in the sandbox: %w", path, errno)
+		}
+		return nil
+	}
+
+	// Sources are read-only; missing ones are left out of the backup anyway
+	for _, path := range rules.Read {
+		if err := allow(path, readDir, false); err != nil {
+			return err
+		}
+	}
+	for _, path := range rules.Write {
+		if err := allow(path, attr.handledAccessFS, true); err != nil {
+			return err
+		}
+	}
+	// The worker itself, shared libraries and the shell for filter commands
+	if err := allow(self, accessExecute|accessReadFile, true); err != nil {
+		return err
+	}
+	for _, path := range sandboxSystemPaths {
+		if err := allow(path, accessExecute|readDir, false); err != nil {
+			return err
+		}
+	}
+	if err := allow(os.DevNull, accessReadFile|accessWriteFile, true); err != nil {
+		return err
+	}
+
+	// Landlock restricts only the calling thread; the new program started
+	// from it inherits the restriction for all of its threads
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to enter sandbox: %w", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(sysLandlockRestrictSelf, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enter sandbox: %w", errno)
+	}
+	err = syscall.Exec(self, os.Args, append(os.Environ(), SandboxEnv+"=1"))
+	return fmt.Errorf("failed to restart worker in sandbox: %w", err)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package backup
+
+import "fmt"
+
+// EnterSandbox fails outside Linux, which provides the Landlock sandbox
+func EnterSandbox(rules SandboxRules) error {
+	return fmt.Errorf("sandbox needs Linux Landlock and is not available on this system")
+}
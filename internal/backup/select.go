@@ -0,0 +1,61 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// BackupSelector picks the backup of a job to restore when no ID is given
+type BackupSelector struct {
+	// Labels the backup must carry, all of them
+	Labels map[string]string
+	// At, unless zero, excludes backups taken after it (point-in-time restore)
+	At time.Time
+}
+
+// String describes the selection criteria
+func (s BackupSelector) String() string {
+	var criteria []string
+	if len(s.Labels) > 0 {
+		criteria = append(criteria, "labels "+FormatLabels(s.Labels))
+	}
+	if !s.At.IsZero() {
+		criteria = append(criteria, "at or before "+s.At.Format("2006-01-02 15:04:05"))
+	}
+	if len(criteria) == 0 {
+		return "newest"
+	}
+	return strings.Join(criteria, ", ")
+}
+
+// Latest returns the newest backup of a job matching selector and written by
+// host, if given; backups without a job name match any job
+func (bm *BackupManager) Latest(jobName string, selector BackupSelector) (*config.BackupMetadata, error) {
+	backups, err := bm.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *config.BackupMetadata
+	for i, metadata := range backups {
+		if metadata.JobName != "" && metadata.JobName != jobName {
+			continue
+		}
+		if !MatchesHost(metadata, bm.host) || !MatchesLabels(metadata.Labels, selector.Labels) {
+			continue
+		}
+		if !selector.At.IsZero() && metadata.Timestamp.After(selector.At) {
+			continue
+		}
+		if latest == nil || metadata.Timestamp.After(latest.Timestamp) {
+			latest = &backups[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no backup of job %s found (selection: %s)", jobName, selector)
+	}
+	return latest, nil
+}
@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Limits keeping the summary in metadata.toml small
+const (
+	summaryTopLevel  = 30   // top-level entries listed
+	summaryFileTypes = 20   // file extensions listed
+	summaryLargest   = 20   // largest files listed
+	summaryMaxGroups = 5000 // distinct entries counted before the rest go to "(other)"
+)
+
+// summaryOther names the group of entries beyond the listed ones
+const summaryOther = "(other)"
+
+// summaryBuilder collects the contents summary of a backup while it is archived
+type summaryBuilder struct {
+	topLevel  map[string]*config.SummaryEntry
+	fileTypes map[string]*config.SummaryEntry
+	largest   []config.SummaryEntry // ascending by size
+}
+
+// newSummaryBuilder returns an empty summary builder
+func newSummaryBuilder() *summaryBuilder {
+	return &summaryBuilder{
+		topLevel:  make(map[string]*config.SummaryEntry),
+		fileTypes: make(map[string]*config.SummaryEntry),
+	}
+}
+
+// Add records a regular file by its path in the archive
+func (b *summaryBuilder) Add(tarPath string, size int64) {
+	// TOML strings must be valid UTF-8, file names need not be
+	tarPath = strings.ToValidUTF8(tarPath, "\uFFFD")
+
+	// The top level is the first component below the backup directory
+	parts := strings.SplitN(tarPath, "/", 3)
+	top := parts[0]
+	if len(parts) > 1 {
+		top += "/" + parts[1]
+	}
+	addToGroup(b.topLevel, top, size)
+
+	ext := strings.ToLower(path.Ext(tarPath))
+	if ext == "" || ext == path.Base(tarPath) {
+		ext = "(none)"
+	}
+	addToGroup(b.fileTypes, ext, size)
+
+	if len(b.largest) < summaryLargest || size > b.largest[0].Size {
+		i := sort.Search(len(b.largest), func(i int) bool { return b.largest[i].Size >= size })
+		b.largest = append(b.largest, config.SummaryEntry{})
+		copy(b.largest[i+1:], b.largest[i:])
+		b.largest[i] = config.SummaryEntry{Name: tarPath, Files: 1, Size: size}
+		if len(b.largest) > summaryLargest {
+			b.largest = b.largest[1:]
+		}
+	}
+}
+
+// addToGroup counts a file in its group, or in "(other)" once there are too many groups
+func addToGroup(groups map[string]*config.SummaryEntry, name string, size int64) {
+	entry, ok := groups[name]
+	if !ok {
+		if len(groups) >= summaryMaxGroups {
+			name = summaryOther
+			entry = groups[name]
+		}
+		if entry == nil {
+			entry = &config.SummaryEntry{Name: name}
+			groups[name] = entry
+		}
+	}
+	entry.Files++
+	entry.Size += size
+}
+
+// Summary returns the collected summary
+func (b *summaryBuilder) Summary() *config.BackupSummary {
+	largest := make([]config.SummaryEntry, len(b.largest))
+	for i, entry := range b.largest {
+		largest[len(largest)-1-i] = entry
+	}
+	return &config.BackupSummary{
+		TopLevel:  topGroups(b.topLevel, summaryTopLevel),
+		FileTypes: topGroups(b.fileTypes, summaryFileTypes),
+		Largest:   largest,
+	}
+}
+
+// topGroups returns the limit largest groups, folding the rest into "(other)"
+func topGroups(groups map[string]*config.SummaryEntry, limit int) []config.SummaryEntry {
+	var entries []config.SummaryEntry
+	var other *config.SummaryEntry
+	for _, entry := range groups {
+		if entry.Name == summaryOther {
+			other = entry
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	if len(entries) > limit || other != nil {
+		rest := config.SummaryEntry{Name: summaryOther}
+		if other != nil {
+			rest = *other
+		}
+		if len(entries) > limit {
+			for _, entry := range entries[limit:] {
+				rest.Files += entry.Files
+				rest.Size += entry.Size
+			}
+			entries = entries[:limit]
+		}
+		entries = append(entries, rest)
+	}
+	return entries
+}
@@ -0,0 +1,185 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// packageManagers lists the commands used to capture installed packages, in order of preference
+var packageManagers = []struct {
+	name string
+	args []string
+}{
+	{"dpkg-query", []string{"-W", "-f", "${Package}\t${Version}\n"}},
+	{"rpm", []string{"-qa", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n"}},
+	{"apk", []string{"info", "-v"}},
+	{"pacman", []string{"-Q"}},
+}
+
+// crontabPaths are copied when capturing crontabs
+var crontabPaths = []string{"/etc/crontab", "/etc/cron.d", "/var/spool/cron"}
+
+// systemStateReadme explains how to use a restored system state snapshot
+const systemStateReadme = `Backtide system state snapshot
+
+packages-*.txt    installed packages (name and version) per package manager
+services.txt      systemd unit files enabled at backup time
+crontabs/         /etc/crontab, /etc/cron.d and per-user crontabs
+systemd/          unit files from /etc/systemd/system
+etc/              snapshot of /etc
+symlinks.txt      symbolic links found in the copied trees (path -> target)
+
+Restore this component to a review location rather than over a live system:
+
+  backtide restore <backup-id> --only system-state --target /root/rebuild
+
+then reinstall packages, copy back configuration and re-enable services as needed.
+`
+
+// CollectSystemState captures host state into dir so it can be backed up as a component
+func CollectSystemState(ctx context.Context, dir string, cfg config.SystemStateConfig) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create system state directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte(systemStateReadme), 0644); err != nil {
+		return fmt.Errorf("failed to write system state readme: %w", err)
+	}
+
+	include := func(item string) bool {
+		return len(cfg.Include) == 0 || slices.Contains(cfg.Include, item)
+	}
+	var symlinks []string
+
+	if include("packages") {
+		render.Println("Capturing installed packages...")
+		captured := 0
+		for _, pm := range packageManagers {
+			if !utils.IsCommandAvailable(pm.name) {
+				continue
+			}
+			output, err := exec.CommandContext(ctx, pm.name, pm.args...).Output()
+			if err != nil {
+				render.Printf("⚠️  Warning: Failed to list packages with %s: %v\n", pm.name, err)
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(dir, "packages-"+pm.name+".txt"), output, 0644); err != nil {
+				return fmt.Errorf("failed to write package list: %w", err)
+			}
+			captured++
+		}
+		if captured == 0 {
+			render.Println("⚠️  Warning: No supported package manager found")
+		}
+	}
+
+	if include("services") {
+		render.Println("Capturing enabled services...")
+		if utils.IsCommandAvailable("systemctl") {
+			output, err := exec.CommandContext(ctx, "systemctl", "list-unit-files", "--state=enabled", "--no-legend", "--no-pager").Output()
+			if err != nil {
+				render.Printf("⚠️  Warning: Failed to list enabled services: %v\n", err)
+			} else if err := os.WriteFile(filepath.Join(dir, "services.txt"), output, 0644); err != nil {
+				return fmt.Errorf("failed to write service list: %w", err)
+			}
+		} else {
+			render.Println("⚠️  Warning: systemctl not found; skipping services")
+		}
+	}
+
+	if include("crontabs") {
+		render.Println("Capturing crontabs...")
+		for _, path := range crontabPaths {
+			if _, err := os.Lstat(path); os.IsNotExist(err) {
+				continue
+			}
+			links, err := copyTree(ctx, path, filepath.Join(dir, "crontabs", strings.TrimPrefix(path, "/")))
+			if err != nil {
+				return fmt.Errorf("failed to capture %s: %w", path, err)
+			}
+			symlinks = append(symlinks, links...)
+		}
+	}
+
+	if include("systemd") {
+		render.Println("Capturing systemd units...")
+		if utils.DirectoryExists("/etc/systemd/system") {
+			links, err := copyTree(ctx, "/etc/systemd/system", filepath.Join(dir, "systemd"))
+			if err != nil {
+				return fmt.Errorf("failed to capture systemd units: %w", err)
+			}
+			symlinks = append(symlinks, links...)
+		}
+	}
+
+	if include("etc") {
+		render.Println("Capturing /etc snapshot...")
+		links, err := copyTree(ctx, "/etc", filepath.Join(dir, "etc"))
+		if err != nil {
+			return fmt.Errorf("failed to capture /etc: %w", err)
+		}
+		symlinks = append(symlinks, links...)
+	}
+
+	if len(symlinks) > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "symlinks.txt"), []byte(strings.Join(symlinks, "\n")+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write symlink list: %w", err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// copyTree copies regular files and directories from src to dst, skipping
+// unreadable entries; symbolic links are returned as "path -> target" lines
+func copyTree(ctx context.Context, src, dst string) ([]string, error) {
+	var symlinks []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			render.Printf("⚠️  Warning: Skipping %s: %v\n", path, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err == nil {
+				symlinks = append(symlinks, fmt.Sprintf("%s -> %s", path, link))
+			}
+		case d.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := utils.CopyFile(path, target); err != nil {
+				render.Printf("⚠️  Warning: Skipping %s: %v\n", path, err)
+			}
+		}
+		return nil
+	})
+	return symlinks, err
+}
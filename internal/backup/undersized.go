@@ -0,0 +1,60 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// checkBackupSize marks a new backup smaller than the job's expected minimum
+// as undersized and returns a description of the shortfall, or "" when the
+// size is as expected
+func checkBackupSize(job *config.BackupJob, bm *BackupManager, metadata *config.BackupMetadata) (string, error) {
+	if job.ExpectedMinSize == "" {
+		return "", nil
+	}
+	minSize, err := job.MinSize(previousBackupSize(job, bm, metadata.ID))
+	if err != nil || metadata.TotalSize >= minSize {
+		return "", err
+	}
+
+	metadata.Undersized = true
+	backupDir := filepath.Join(bm.backupPath, metadata.ID)
+	if err := bm.saveMetadata(backupDir, metadata); err != nil {
+		return "", fmt.Errorf("failed to mark backup as undersized: %w", err)
+	}
+	if bm.config.Fsync() {
+		if err := syncFile(filepath.Join(backupDir, "metadata.toml")); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("backup %s is only %s, below the expected minimum of %s (expected_min_size = %q)",
+		metadata.ID, utils.FormatBytes(metadata.TotalSize), utils.FormatBytes(minSize), job.ExpectedMinSize), nil
+}
+
+// previousBackupSize returns the size of the newest backup of a job other
+// than exclude that was not undersized itself, or 0 if there is none
+func previousBackupSize(job *config.BackupJob, bm *BackupManager, exclude string) int64 {
+	backups, err := bm.listBackupsFromPath(bm.backupPath)
+	if err != nil {
+		return 0
+	}
+	var previous *config.BackupMetadata
+	for i, metadata := range backups {
+		if metadata.ID == exclude || metadata.Undersized {
+			continue
+		}
+		if metadata.JobID != job.ID && (metadata.JobID != "" || metadata.JobName != job.Name) {
+			continue
+		}
+		if previous == nil || metadata.Timestamp.After(previous.Timestamp) {
+			previous = &backups[i]
+		}
+	}
+	if previous == nil {
+		return 0
+	}
+	return previous.TotalSize
+}
@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// VerifyBackup restores a backup of a job into a scratch directory and runs an
+// application-aware check against it, such as pg_verifybackup or a throwaway
+// container, so a backup counts as usable only when the application can read
+// it. The command runs with sh in the scratch directory; BACKTIDE_RESTORE_DIR,
+// BACKTIDE_BACKUP_ID and BACKTIDE_JOB describe what was restored. An empty
+// backupID verifies the newest backup and an empty command uses the job's
+// verify_command. The scratch directory is removed afterwards.
+func (br *BackupRunner) VerifyBackup(ctx context.Context, jobName, backupID, command string) (*config.BackupMetadata, error) {
+	job, err := br.findJob(jobName)
+	if err != nil {
+		return nil, err
+	}
+	if command == "" {
+		command = job.VerifyCommand
+	}
+	if command == "" {
+		return nil, fmt.Errorf("job %s has no verify_command", jobName)
+	}
+
+	release, err := br.MountJobStorage(jobName, fmt.Sprintf("verify-%d", os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	jobConfig, err := br.JobBackupConfig(jobName)
+	if err != nil {
+		return nil, err
+	}
+	manager := NewBackupManager(jobConfig)
+
+	var metadata *config.BackupMetadata
+	if backupID == "" {
+		metadata, err = manager.Latest(jobName, BackupSelector{})
+	} else {
+		metadata, err = manager.GetBackupInfo(backupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tempPath := br.config.TempPath
+	if tempPath == "" {
+		tempPath = paths.TempDir()
+	}
+	if err := os.MkdirAll(filepath.Join(tempPath, "verify"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	scratch, err := os.MkdirTemp(filepath.Join(tempPath, "verify"), metadata.ID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := manager.RestoreToScratch(ctx, metadata.ID, scratch, nil); err != nil {
+		return metadata, fmt.Errorf("failed to restore backup for verification: %w", err)
+	}
+
+	render.Printf("🔎 Running verify command for %s: %s\n", metadata.ID, command)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = scratch
+	cmd.Env = append(os.Environ(),
+		"BACKTIDE_RESTORE_DIR="+scratch,
+		"BACKTIDE_BACKUP_ID="+metadata.ID,
+		"BACKTIDE_JOB="+jobName,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return metadata, fmt.Errorf("verify command failed: %w", err)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/s3api"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// cleanupVersions runs after cleanup removed backups from a bucket. With
+// versioning, deleting through s3fs only adds delete markers and the removed
+// backups keep using storage as noncurrent versions. They are purged with
+// purge_noncurrent_versions; otherwise, unless a lifecycle rule expires
+// them, the user is told how to reclaim the space.
+func (bm *BackupManager) cleanupVersions(job config.BackupJob, backupIDs []string) {
+	bucket := bm.jobBucket(job)
+	if bucket == nil || len(backupIDs) == 0 {
+		return
+	}
+	client, err := s3api.NewClient(*bucket, bm.config.Network)
+	if err != nil {
+		// Buckets using an IAM role cannot be queried through the API
+		if bucket.PurgeVersions {
+			render.Printf("Warning: Cannot purge old versions in bucket %s: %v\n", bucket.Name, err)
+		}
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	status, err := client.GetBucketVersioning(ctx)
+	if err != nil {
+		if bucket.PurgeVersions {
+			render.Printf("Warning: Failed to check versioning of bucket %s: %v\n", bucket.Name, err)
+		}
+		return
+	}
+	if status == "" {
+		return
+	}
+
+	if !bucket.PurgeVersions {
+		if expire, err := client.NoncurrentVersionsExpire(ctx); err == nil && expire {
+			return
+		}
+		render.Printf("⚠️  Bucket %s has versioning %s: removed backups remain as noncurrent versions and still use storage\n",
+			bucket.Name, strings.ToLower(status))
+		render.Println("💡 Set purge_noncurrent_versions = true on the bucket, or add a lifecycle rule expiring noncurrent versions")
+		return
+	}
+
+	purged, size := 0, int64(0)
+	for _, backupID := range backupIDs {
+		prefix, err := filepath.Rel(bucket.MountPoint, filepath.Join(bm.backupPath, backupID))
+		if err != nil || strings.HasPrefix(prefix, "..") {
+			continue
+		}
+		versions, err := client.ListObjectVersions(ctx, filepath.ToSlash(prefix)+"/")
+		if err != nil {
+			render.Printf("Warning: Failed to list versions of backup %s: %v\n", backupID, err)
+			continue
+		}
+		for _, version := range versions {
+			// A current version means the object was not removed; leave it
+			if version.IsLatest && !version.DeleteMarker {
+				continue
+			}
+			if err := client.DeleteObjectVersion(ctx, version.Key, version.VersionID); err != nil {
+				render.Printf("Warning: Failed to purge version %s of %s: %v\n", version.VersionID, version.Key, err)
+				continue
+			}
+			purged++
+			size += version.Size
+		}
+	}
+	if purged > 0 {
+		render.Printf("🧹 Purged %d noncurrent versions (%s) of removed backups from bucket %s\n", purged, utils.FormatBytes(size), bucket.Name)
+	}
+}
+
+// jobBucket returns the bucket a job stores its backups in when the manager
+// works on its mount, or nil
+func (bm *BackupManager) jobBucket(job config.BackupJob) *config.BucketConfig {
+	if !job.Storage.S3 {
+		return nil
+	}
+	for i, bucket := range bm.config.Buckets {
+		mountPoint := filepath.Clean(bucket.MountPoint)
+		if bucket.ID == job.BucketID && strings.HasPrefix(filepath.Clean(bm.backupPath)+"/", mountPoint+"/") {
+			return &bm.config.Buckets[i]
+		}
+	}
+	return nil
+}
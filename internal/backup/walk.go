@@ -0,0 +1,155 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// walkBatched walks a tree like filepath.Walk without following symlinks, but
+// reads directories batch entries at a time instead of loading and sorting all
+// names, so memory stays bounded for directories with millions of files.
+// Entries are visited in directory order; fn returns filepath.SkipDir to skip
+// a directory's contents.
+func walkBatched(ctx context.Context, dir string, batch int, fn func(path string, info os.FileInfo) error) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("backup cancelled: %w", err)
+		}
+
+		entries, err := f.ReadDir(batch)
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if err := fn(path, info); err == filepath.SkipDir && info.IsDir() {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if err := walkBatched(ctx, path, batch, fn); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// IndexEntry describes one file in a backup directory's index
+type IndexEntry struct {
+	Path    string `json:"path"`
+	Mode    string `json:"mode"`
+	UID     int    `json:"uid"`
+	GID     int    `json:"gid"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+}
+
+// indexFileName returns the name of the index written next to a directory archive
+func indexFileName(name string) string {
+	return name + ".index.jsonl.gz"
+}
+
+// indexWriter streams index entries to a gzip-compressed JSON lines file
+type indexWriter struct {
+	file    *os.File
+	gzip    *gzip.Writer
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	// sync flushes the file to stable storage on Close
+	sync bool
+}
+
+// newIndexWriter creates an index file at path
+func newIndexWriter(path string) (*indexWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index: %w", err)
+	}
+	gzipWriter := gzip.NewWriter(file)
+	buffer := bufio.NewWriter(gzipWriter)
+	return &indexWriter{file: file, gzip: gzipWriter, buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+}
+
+// Add writes the entry for a file
+func (w *indexWriter) Add(path string, info os.FileInfo) error {
+	entry := IndexEntry{
+		Path:    path,
+		Mode:    info.Mode().String(),
+		Size:    info.Size(),
+		ModTime: info.ModTime().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		entry.UID = int(stat.Uid)
+		entry.GID = int(stat.Gid)
+	}
+	return w.encoder.Encode(entry)
+}
+
+// Close flushes and closes the index file
+func (w *indexWriter) Close() error {
+	if err := w.buffer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := w.gzip.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if w.sync {
+		if err := w.file.Sync(); err != nil {
+			w.file.Close()
+			return fmt.Errorf("failed to sync index: %w", err)
+		}
+	}
+	return w.file.Close()
+}
+
+// readIndex calls fn for every entry of the index file at path
+func readIndex(path string, fn func(entry IndexEntry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	defer gzipReader.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(gzipReader))
+	for {
+		var entry IndexEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read index: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
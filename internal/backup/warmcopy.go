@@ -0,0 +1,228 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// mirrorStats counts what a pass changed in the copy
+type mirrorStats struct {
+	Copied  int
+	Bytes   int64
+	Removed int
+}
+
+// add accumulates the counts of another pass
+func (s *mirrorStats) add(other mirrorStats) {
+	s.Copied += other.Copied
+	s.Bytes += other.Bytes
+	s.Removed += other.Removed
+}
+
+// String describes the pass, e.g. "12 entries (3.4 MB) copied, 2 removed"
+func (s mirrorStats) String() string {
+	return fmt.Sprintf("%d entries (%s) copied, %d removed", s.Copied, utils.FormatBytes(s.Bytes), s.Removed)
+}
+
+// warmCopy syncs the directories into stagingDir and returns them with Source
+// set to their copies. The first call copies everything while containers
+// keep running; a second call with containers stopped only copies what
+// changed in between. Directories that do not exist are returned unchanged.
+func warmCopy(ctx context.Context, dirs []config.DirectoryConfig, stagingDir string) ([]config.DirectoryConfig, mirrorStats, error) {
+	var total mirrorStats
+	copies := append([]config.DirectoryConfig(nil), dirs...)
+	for i, dir := range copies {
+		// System state is captured into the temp path and does not change
+		if dir.Name == config.SystemStateComponent {
+			continue
+		}
+		if _, err := os.Lstat(dir.Path); os.IsNotExist(err) {
+			continue
+		}
+		copies[i].Source = filepath.Join(stagingDir, dir.Name)
+		stats, err := mirrorTree(ctx, dir.Path, copies[i].Source)
+		if err != nil {
+			return nil, total, fmt.Errorf("failed to copy %s: %w", dir.Path, err)
+		}
+		total.add(stats)
+	}
+	return copies, total, nil
+}
+
+// mirrorTree makes dst a copy of src like rsync -a --delete: regular files
+// whose size, modification time and mode match are skipped, and entries no
+// longer in src are removed. Sockets, pipes and devices are not copied.
+func mirrorTree(ctx context.Context, src, dst string) (mirrorStats, error) {
+	var stats mirrorStats
+	info, err := os.Lstat(src)
+	if err != nil {
+		return stats, err
+	}
+	if !info.IsDir() {
+		return stats, fmt.Errorf("%s is not a directory", src)
+	}
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return stats, fmt.Errorf("failed to create copy: %w", err)
+	}
+	err = mirrorDir(ctx, src, dst, info, &stats)
+	return stats, err
+}
+
+// mirrorDir syncs the contents of directory src into dst, then its attributes
+func mirrorDir(ctx context.Context, src, dst string, info os.FileInfo, stats *mirrorStats) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("backup cancelled: %w", err)
+	}
+	// A read-only original made the copy read-only in the previous pass
+	if err := os.Chmod(dst, 0700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		present[entry.Name()] = true
+		if err := mirrorEntry(ctx, filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), stats); err != nil {
+			return err
+		}
+	}
+
+	existing, err := os.ReadDir(dst)
+	if err != nil {
+		return err
+	}
+	for _, entry := range existing {
+		if present[entry.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+		stats.Removed++
+	}
+
+	// Copying into the directory changed its time, so attributes go last
+	return copyAttributes(dst, info)
+}
+
+// mirrorEntry syncs one entry of a directory
+func mirrorEntry(ctx context.Context, src, dst string, stats *mirrorStats) error {
+	info, err := os.Lstat(src)
+	if os.IsNotExist(err) {
+		// Removed while copying; the pass with containers stopped catches up
+		return nil
+	} else if err != nil {
+		return err
+	}
+	current, err := os.Lstat(dst)
+	if err == nil && current.Mode().Type() != info.Mode().Type() {
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+		current = nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch {
+	case info.IsDir():
+		if current == nil {
+			if err := os.Mkdir(dst, 0700); err != nil {
+				return err
+			}
+		}
+		return mirrorDir(ctx, src, dst, info, stats)
+
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			if existing, err := os.Readlink(dst); err == nil && existing == target {
+				return nil
+			}
+			if err := os.Remove(dst); err != nil {
+				return err
+			}
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+		stats.Copied++
+		return copyOwner(dst, info)
+
+	case info.Mode().IsRegular():
+		if current != nil && current.Size() == info.Size() && current.ModTime().Equal(info.ModTime()) &&
+			current.Mode() == info.Mode() && sameOwner(current, info) {
+			return nil
+		}
+		if err := copyFile(ctx, src, dst); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		stats.Copied++
+		stats.Bytes += info.Size()
+		// The time is the one from before the copy, so a file written while it
+		// was copied is copied again in the next pass
+		return copyAttributes(dst, info)
+	}
+	return nil
+}
+
+// copyFile copies the content of a regular file
+func copyFile(ctx context.Context, src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, &contextReader{ctx: ctx, reader: in}); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyAttributes gives a copied file or directory the mode, owner and
+// modification time of the original, which the archive records
+func copyAttributes(dst string, info os.FileInfo) error {
+	if err := copyOwner(dst, info); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// copyOwner gives a copy the original's owner when running as root
+func copyOwner(dst string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || os.Geteuid() != 0 {
+		return nil
+	}
+	return os.Lchown(dst, int(stat.Uid), int(stat.Gid))
+}
+
+// sameOwner reports whether a copy has the original's owner, or the owner
+// cannot be copied anyway
+func sameOwner(copied, original os.FileInfo) bool {
+	a, ok := copied.Sys().(*syscall.Stat_t)
+	b, ok2 := original.Sys().(*syscall.Stat_t)
+	return !ok || !ok2 || os.Geteuid() != 0 || (a.Uid == b.Uid && a.Gid == b.Gid)
+}
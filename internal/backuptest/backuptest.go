@@ -0,0 +1,19 @@
+// Package backuptest provides what tests of backup runs need in place of the
+// host: an isolated state directory, an in-memory storage plugin, a bucket
+// mount backed by a directory, source trees and golden files. Docker is
+// replaced with the runtime of package dockertest.
+package backuptest
+
+import (
+	"testing"
+
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// Isolate keeps the state of runs, such as run records, history, catalogs
+// and container holds, in a temporary directory until the test ends
+func Isolate(t testing.TB) {
+	t.Helper()
+	state.SetDir(t.TempDir())
+	t.Cleanup(func() { state.SetDir("") })
+}
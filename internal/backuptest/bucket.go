@@ -0,0 +1,44 @@
+package backuptest
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket stands in for the s3fs mount of a bucket; its mount point is a
+// plain directory, so backups written to the bucket land there
+type Bucket struct {
+	// Err makes Acquire fail with it
+	Err error
+
+	mu       sync.Mutex
+	acquired int
+	released int
+}
+
+// Prepare implements backup.BucketMount; nothing needs installing
+func (b *Bucket) Prepare() error {
+	return nil
+}
+
+// Acquire implements backup.BucketMount
+func (b *Bucket) Acquire(holder string, timeout time.Duration) (func(), error) {
+	if b.Err != nil {
+		return func() {}, b.Err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.acquired++
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.released++
+	}, nil
+}
+
+// Mounts returns how often the bucket was acquired and released
+func (b *Bucket) Mounts() (acquired, released int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acquired, b.released
+}
@@ -0,0 +1,34 @@
+package backuptest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// Notifier is a notifier plugin that records the events delivered to it
+type Notifier struct {
+	mu     sync.Mutex
+	events []plugin.Event
+}
+
+// Name implements plugin.Notifier
+func (n *Notifier) Name() string {
+	return "recorder"
+}
+
+// Notify implements plugin.Notifier
+func (n *Notifier) Notify(ctx context.Context, event plugin.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return nil
+}
+
+// Events returns the delivered events in order
+func (n *Notifier) Events() []plugin.Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]plugin.Event(nil), n.events...)
+}
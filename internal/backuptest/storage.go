@@ -0,0 +1,78 @@
+package backuptest
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// Storage is a storage plugin that keeps the backups handed to it in memory
+type Storage struct {
+	// Err makes Store fail with it
+	Err error
+
+	mu      sync.Mutex
+	backups map[string]map[string][]byte
+}
+
+// NewStorage returns an empty in-memory storage
+func NewStorage() *Storage {
+	return &Storage{backups: make(map[string]map[string][]byte)}
+}
+
+// Name implements plugin.Storage
+func (s *Storage) Name() string {
+	return "memory"
+}
+
+// Store implements plugin.Storage by reading every file of the backup
+func (s *Storage) Store(ctx context.Context, req plugin.StoreRequest) error {
+	if s.Err != nil {
+		return s.Err
+	}
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(req.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(req.Path, path)
+		if err != nil {
+			return err
+		}
+		files[rel], err = os.ReadFile(path)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backups[req.BackupID] = files
+	return nil
+}
+
+// Backups returns the IDs of the stored backups in order
+func (s *Storage) Backups() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.backups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Files returns the files of a stored backup by path within it
+func (s *Storage) Files(backupID string) map[string][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backups[backupID]
+}
+
+var _ plugin.Storage = (*Storage)(nil)
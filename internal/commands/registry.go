@@ -84,3 +84,30 @@ func GetCommandNames() []string {
 func RegisterAllWithRoot(rootCmd *cobra.Command) error {
 	return globalRegistry.RegisterWithRoot(rootCmd)
 }
+
+// readOnlyAnnotation marks commands that never modify backups or configuration
+const readOnlyAnnotation = "backtide.read-only"
+
+// MarkReadOnly marks commands as safe to run in read-only mode
+func MarkReadOnly(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[readOnlyAnnotation] = "true"
+	}
+}
+
+// IsReadOnly reports whether a command may run in read-only mode
+func IsReadOnly(cmd *cobra.Command) bool {
+	if cmd.Annotations[readOnlyAnnotation] == "true" {
+		return true
+	}
+	// Built-in help and shell completion never modify anything
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "help" || c.Name() == "completion" || c.Name() == cobra.ShellCompRequestCmd {
+			return true
+		}
+	}
+	return !cmd.HasParent()
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"github.com/pelletier/go-toml/v2"
+)
+
+// LegacyConfig is the pre-jobs configuration format, where a single set of
+// directories and one S3 bucket were configured at the top level
+type LegacyConfig struct {
+	BackupPath  string            `toml:"backup_path"`
+	TempPath    string            `toml:"temp_path"`
+	Directories []DirectoryConfig `toml:"directories"`
+	S3Config    LegacyS3Config    `toml:"s3_config"`
+	Retention   RetentionPolicy   `toml:"retention"`
+	SkipDocker  bool              `toml:"skip_docker"`
+	SkipS3      bool              `toml:"skip_s3"`
+}
+
+// LegacyS3Config is the top-level S3 section of the legacy format
+type LegacyS3Config struct {
+	Bucket       string `toml:"bucket"`
+	Region       string `toml:"region"`
+	AccessKey    string `toml:"access_key"`
+	SecretKey    string `toml:"secret_key"`
+	Endpoint     string `toml:"endpoint"`
+	MountPoint   string `toml:"mount_point"`
+	UsePathStyle bool   `toml:"use_path_style"`
+}
+
+// legacyProbe detects whether a file uses the job/bucket model
+type legacyProbe struct {
+	Jobs    []BackupJob    `toml:"jobs"`
+	Buckets []BucketConfig `toml:"buckets"`
+}
+
+// ParseLegacyConfig parses data in the legacy format; ok is false if it is not legacy
+func ParseLegacyConfig(data []byte) (*LegacyConfig, bool) {
+	var probe legacyProbe
+	if err := toml.Unmarshal(data, &probe); err != nil || len(probe.Jobs) > 0 || len(probe.Buckets) > 0 {
+		return nil, false
+	}
+
+	var legacy LegacyConfig
+	if err := toml.Unmarshal(data, &legacy); err != nil {
+		return nil, false
+	}
+	if len(legacy.Directories) == 0 && legacy.S3Config.Bucket == "" {
+		return nil, false
+	}
+	return &legacy, true
+}
+
+// Migrate converts a legacy configuration into a single job and bucket
+func (l *LegacyConfig) Migrate() *BackupConfig {
+	cfg := DefaultConfig()
+	cfg.BackupPath = l.BackupPath
+	if l.TempPath != "" {
+		cfg.TempPath = l.TempPath
+	}
+
+	job := BackupJob{
+		ID:          "job-legacy",
+		Name:        "default-backup",
+		Description: "Migrated from legacy configuration",
+		Enabled:     true,
+		Schedule: ScheduleConfig{
+			Type:    "manual",
+			Enabled: false,
+		},
+		Directories: l.Directories,
+		Retention:   l.Retention,
+		SkipDocker:  l.SkipDocker,
+		SkipS3:      l.SkipS3,
+		Storage: StorageConfig{
+			Local: l.BackupPath != "",
+		},
+	}
+
+	if l.S3Config.Bucket != "" {
+		mountPoint := l.S3Config.MountPoint
+		if mountPoint == "" {
+			mountPoint = "/mnt/s3backup"
+		}
+		provider := "aws"
+		if l.S3Config.Endpoint != "" {
+			provider = "custom"
+		}
+		bucket := BucketConfig{
+			ID:           "bucket-legacy",
+			Name:         "legacy-s3",
+			Bucket:       l.S3Config.Bucket,
+			Region:       l.S3Config.Region,
+			AccessKey:    l.S3Config.AccessKey,
+			SecretKey:    l.S3Config.SecretKey,
+			Endpoint:     l.S3Config.Endpoint,
+			MountPoint:   mountPoint,
+			UsePathStyle: l.S3Config.UsePathStyle,
+			Provider:     provider,
+			Description:  "Migrated from legacy configuration",
+		}
+		cfg.Buckets = append(cfg.Buckets, bucket)
+		job.BucketID = bucket.ID
+		job.Storage.S3 = !l.SkipS3
+	}
+
+	cfg.Jobs = append(cfg.Jobs, job)
+	return cfg
+}
@@ -1,10 +1,21 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
 	"github.com/pelletier/go-toml/v2"
 )
 
@@ -12,7 +23,7 @@ import (
 func DefaultConfig() *BackupConfig {
 	return &BackupConfig{
 		BackupPath: "", // Empty = no local storage, use S3 only
-		TempPath:   "/tmp/backtide",
+		TempPath:   paths.TempDir(),
 		Jobs:       []BackupJob{},
 		Buckets:    []BucketConfig{},
 	}
@@ -31,8 +42,13 @@ func LoadConfig(configPath string) (*BackupConfig, error) {
 
 	config := DefaultConfig()
 
-	// Parse as TOML
-	if err := toml.Unmarshal(data, config); err != nil {
+	// Convert legacy single-job files transparently so they keep working
+	if legacy, ok := ParseLegacyConfig(data); ok {
+		render.Printf("⚠️  %s uses the legacy configuration format; converting it in memory\n", configPath)
+		render.Println("💡 Run 'backtide config migrate' to update the file")
+		config = legacy.Migrate()
+	} else if err := toml.Unmarshal(data, config); err != nil {
+		// Parse as TOML
 		return nil, fmt.Errorf("failed to parse config file as TOML: %w", err)
 	}
 
@@ -70,6 +86,11 @@ func SaveConfig(config *BackupConfig, configPath string) error {
 
 // ValidateConfig validates the configuration
 func ValidateConfig(config *BackupConfig) error {
+	// Verify agents store no backups, so their configuration has no jobs
+	if err := validateVerifyAgent(config); err != nil {
+		return err
+	}
+
 	// Allow empty config for S3 management operations
 	if len(config.Jobs) == 0 {
 		return nil
@@ -98,15 +119,164 @@ func ValidateConfig(config *BackupConfig) error {
 		if bucket.Bucket == "" {
 			return fmt.Errorf("S3 bucket name cannot be empty for bucket %s", bucket.ID)
 		}
-		if bucket.AccessKey == "" {
-			return fmt.Errorf("S3 access key cannot be empty for bucket %s", bucket.ID)
+		// Buckets authenticated with an instance IAM role have no access keys
+		if bucket.S3FS.IAMRole == "" {
+			if bucket.AccessKey == "" {
+				return fmt.Errorf("S3 access key cannot be empty for bucket %s", bucket.ID)
+			}
+			if bucket.SecretKey == "" {
+				return fmt.Errorf("S3 secret key cannot be empty for bucket %s", bucket.ID)
+			}
+		}
+		if bucket.S3FS.MultipartSize < 0 || bucket.S3FS.ParallelCount < 0 || bucket.S3FS.EnsureDiskFree < 0 ||
+			bucket.S3FS.Retries < 0 || bucket.S3FS.ConnectTimeout < 0 {
+			return fmt.Errorf("s3fs options cannot be negative for bucket %s", bucket.ID)
 		}
-		if bucket.SecretKey == "" {
-			return fmt.Errorf("S3 secret key cannot be empty for bucket %s", bucket.ID)
+		if (bucket.TLS.CertFile == "") != (bucket.TLS.KeyFile == "") {
+			return fmt.Errorf("tls cert_file and key_file must be set together for bucket %s", bucket.ID)
 		}
 		if bucket.MountPoint == "" {
 			return fmt.Errorf("S3 mount point cannot be empty for bucket %s", bucket.ID)
 		}
+		if bucket.StorageClass != "" && strings.Trim(strings.ToUpper(bucket.StorageClass), "ABCDEFGHIJKLMNOPQRSTUVWXYZ_") != "" {
+			return fmt.Errorf("invalid storage_class %q for bucket %s (e.g., STANDARD_IA)", bucket.StorageClass, bucket.ID)
+		}
+	}
+
+	if config.Mounts.CheckInterval != "" {
+		if _, err := time.ParseDuration(config.Mounts.CheckInterval); err != nil {
+			return fmt.Errorf("invalid mounts check_interval: %w", err)
+		}
+	}
+	if config.Mounts.CheckTimeout != "" {
+		if _, err := time.ParseDuration(config.Mounts.CheckTimeout); err != nil {
+			return fmt.Errorf("invalid mounts check_timeout: %w", err)
+		}
+	}
+
+	if config.Updates.CheckInterval != "" {
+		if _, err := utils.ParseDuration(config.Updates.CheckInterval); err != nil {
+			return fmt.Errorf("invalid updates check_interval: %w", err)
+		}
+	}
+
+	if config.Outbox.RetryInterval != "" {
+		if d, err := utils.ParseDuration(config.Outbox.RetryInterval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid outbox retry_interval %q (use a duration such as 5m)", config.Outbox.RetryInterval)
+		}
+	}
+	if config.Approval.TokenValidity != "" {
+		if d, err := utils.ParseDuration(config.Approval.TokenValidity); err != nil || d <= 0 {
+			return fmt.Errorf("invalid approval token_validity %q (use a duration such as 15m)", config.Approval.TokenValidity)
+		}
+	}
+
+	if err := validateDigest(config.Digest); err != nil {
+		return err
+	}
+	for i, pricing := range config.Pricing {
+		if pricing.Provider == "" {
+			return fmt.Errorf("pricing provider cannot be empty for pricing %d", i)
+		}
+		if pricing.StorageGB < 0 || pricing.EgressGB < 0 {
+			return fmt.Errorf("pricing for %s cannot be negative", pricing.Provider)
+		}
+	}
+
+	if config.Network.Proxy != "" {
+		if u, err := url.Parse(config.Network.Proxy); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid network proxy: %s (use http://host:port)", config.Network.Proxy)
+		}
+	}
+
+	if config.Docker.Parallelism < 0 {
+		return fmt.Errorf("docker parallelism cannot be negative")
+	}
+	if config.Docker.StopTimeout != "" {
+		if _, err := time.ParseDuration(config.Docker.StopTimeout); err != nil {
+			return fmt.Errorf("invalid docker stop_timeout: %w", err)
+		}
+	}
+	if config.Docker.OrphanThreshold != "" {
+		if _, err := time.ParseDuration(config.Docker.OrphanThreshold); err != nil {
+			return fmt.Errorf("invalid docker orphan_threshold: %w", err)
+		}
+	}
+
+	if config.Durability != "" && config.Durability != DurabilityNone && config.Durability != DurabilityFsync {
+		return fmt.Errorf("invalid durability %q: use none or fsync", config.Durability)
+	}
+
+	if config.Memory.Limit != "" {
+		if _, err := utils.ParseSize(config.Memory.Limit); err != nil {
+			return fmt.Errorf("invalid memory limit: %w", err)
+		}
+	}
+	if config.Memory.DirBatch < 0 {
+		return fmt.Errorf("memory dir_batch cannot be negative")
+	}
+
+	if config.Fleet.ReportTo != "" {
+		if !strings.HasPrefix(config.Fleet.ReportTo, "http://") && !strings.HasPrefix(config.Fleet.ReportTo, "https://") {
+			return fmt.Errorf("fleet report_to must be an http or https URL")
+		}
+		if config.Fleet.Secret == "" {
+			return fmt.Errorf("fleet secret is required when report_to is set")
+		}
+	}
+	switch config.Logging.Sink {
+	case "", "syslog", "journald":
+	default:
+		return fmt.Errorf("invalid logging sink: %s (use syslog or journald)", config.Logging.Sink)
+	}
+	if f := config.Logging.Facility; f != "" && f != "daemon" && f != "user" && !(len(f) == 6 && strings.HasPrefix(f, "local") && f[5] >= '0' && f[5] <= '7') {
+		return fmt.Errorf("invalid logging facility: %s (use daemon, user or local0-local7)", f)
+	}
+	if address := config.Logging.Address; address != "" && !strings.HasPrefix(address, "udp://") && !strings.HasPrefix(address, "tcp://") {
+		return fmt.Errorf("invalid logging address: %s (use udp://host:port or tcp://host:port)", address)
+	}
+
+	if config.Fleet.StaleAfter != "" {
+		if _, err := utils.ParseDuration(config.Fleet.StaleAfter); err != nil {
+			return fmt.Errorf("invalid fleet stale_after: %w", err)
+		}
+	}
+
+	if err := validateSystemdConfig(config.Systemd); err != nil {
+		return err
+	}
+
+	pluginNames := make(map[string]bool)
+	for i, plugin := range config.Plugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("plugin name cannot be empty for plugin %d", i)
+		}
+		if pluginNames[plugin.Name] {
+			return fmt.Errorf("duplicate plugin name: %s", plugin.Name)
+		}
+		pluginNames[plugin.Name] = true
+
+		switch plugin.Type {
+		case "storage", "notifier", "hook":
+		default:
+			return fmt.Errorf("plugin %s has invalid type %q (expected storage, notifier or hook)", plugin.Name, plugin.Type)
+		}
+		if plugin.Command == "" {
+			return fmt.Errorf("plugin %s has no command", plugin.Name)
+		}
+		if plugin.Timeout != "" {
+			if _, err := time.ParseDuration(plugin.Timeout); err != nil {
+				return fmt.Errorf("plugin %s has invalid timeout: %w", plugin.Name, err)
+			}
+		}
+		if plugin.Title != "" || plugin.Message != "" {
+			if plugin.Type != "notifier" {
+				return fmt.Errorf("plugin %s sets title or message, which only apply to notifiers", plugin.Name)
+			}
+			if _, _, err := plugin.NotifyTemplates(); err != nil {
+				return fmt.Errorf("plugin %s has an %w", plugin.Name, err)
+			}
+		}
 	}
 
 	// Validate jobs if using job-based config
@@ -123,13 +293,97 @@ func ValidateConfig(config *BackupConfig) error {
 				// This allows initial configuration to be created without directories
 			}
 
+			if _, err := job.Schedule.Location(); err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			if _, err := job.Schedule.JitterDuration(); err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			if job.RunAs != "" {
+				user, group, _ := strings.Cut(job.RunAs, ":")
+				if user == "" || strings.HasSuffix(job.RunAs, ":") || strings.ContainsAny(group, ":") {
+					return fmt.Errorf("job %s has invalid run_as %q (expected user or user:group)", job.Name, job.RunAs)
+				}
+				if job.SystemState.Enabled {
+					return fmt.Errorf("job %s: system_state needs root and cannot be combined with run_as", job.Name)
+				}
+			}
+			switch job.Schedule.Misfire() {
+			case MisfireRunImmediately, MisfireSkip, MisfireNextWindow:
+			default:
+				return fmt.Errorf("job %s has invalid misfire_policy %q (expected %s, %s or %s)",
+					job.Name, job.Schedule.MisfirePolicy, MisfireRunImmediately, MisfireSkip, MisfireNextWindow)
+			}
+
+			for _, name := range job.Plugins {
+				if !pluginNames[name] {
+					return fmt.Errorf("job %s references unknown plugin: %s", job.Name, name)
+				}
+			}
+
+			for _, item := range job.SystemState.Include {
+				if !slices.Contains(SystemStateItems, item) {
+					return fmt.Errorf("job %s has invalid system_state item %q (expected one of: %s)",
+						job.Name, item, strings.Join(SystemStateItems, ", "))
+				}
+			}
+
+			if _, err := job.MinSize(0); err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			switch job.UndersizedAction {
+			case "", UndersizedFail, UndersizedWarn:
+			default:
+				return fmt.Errorf("job %s has invalid undersized_action %q (use fail or warn)", job.Name, job.UndersizedAction)
+			}
+			if job.MaxAge != "" {
+				if d, err := utils.ParseDuration(job.MaxAge); err != nil || d <= 0 {
+					return fmt.Errorf("job %s has invalid max_age %q (use a duration such as 26h or 8d)", job.Name, job.MaxAge)
+				}
+			}
+
+			for _, hook := range append(append([]ContainerExec(nil), job.Docker.ExecBefore...), job.Docker.ExecAfter...) {
+				if hook.Container == "" || hook.Cmd == "" {
+					return fmt.Errorf("job %s has a docker exec hook without container or cmd", job.Name)
+				}
+				if hook.Timeout != "" {
+					if _, err := time.ParseDuration(hook.Timeout); err != nil {
+						return fmt.Errorf("job %s has invalid docker exec timeout for %s: %w", job.Name, hook.Container, err)
+					}
+				}
+			}
+
+			if _, err := job.Retention.MinFreeBytes(); err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			if _, err := job.Output.FileMode(); err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			if job.Storage.Forward && !job.Storage.S3 {
+				return fmt.Errorf("job %s sets storage forward without s3; forward applies to S3 storage", job.Name)
+			}
+			if job.Docker.WarmCopy && len(job.Docker.ExecBefore) > 0 {
+				return fmt.Errorf("job %s cannot combine docker warm_copy with exec_before; warm_copy applies when containers are stopped", job.Name)
+			}
+			if job.Docker.WarmCopy && job.RunAs != "" {
+				return fmt.Errorf("job %s cannot combine docker warm_copy with run_as; the copy in the temp path is not readable by other users", job.Name)
+			}
+
 			for j, dir := range job.Directories {
+				if job.SystemState.Enabled && dir.Name == SystemStateComponent {
+					return fmt.Errorf("directory name %s is reserved for system state in job %s", SystemStateComponent, job.Name)
+				}
 				if dir.Path == "" {
 					return fmt.Errorf("directory path cannot be empty for directory %d in job %s", j, job.Name)
 				}
 				if dir.Name == "" {
 					return fmt.Errorf("directory name cannot be empty for directory %d in job %s", j, job.Name)
 				}
+				for k, filter := range dir.Filters {
+					if err := validateFileFilter(filter); err != nil {
+						return fmt.Errorf("filter %d of directory %s in job %s: %w", k, dir.Name, job.Name, err)
+					}
+				}
 			}
 
 			// Validate S3 storage configuration
@@ -153,6 +407,23 @@ func ValidateConfig(config *BackupConfig) error {
 				// Job has S3 storage enabled but no bucket configured
 				return fmt.Errorf("job %s has S3 storage enabled but no bucket ID configured", job.Name)
 			}
+
+			replicas := make(map[string]bool)
+			for _, rule := range job.Replicate {
+				if !job.Storage.S3 {
+					return fmt.Errorf("job %s replicates backups but does not store them in a bucket", job.Name)
+				}
+				if !bucketIDs[rule.BucketID] {
+					return fmt.Errorf("job %s replicates to non-existent bucket ID: %s", job.Name, rule.BucketID)
+				}
+				if rule.BucketID == job.BucketID {
+					return fmt.Errorf("job %s replicates to its own bucket %s", job.Name, rule.BucketID)
+				}
+				if replicas[rule.BucketID] {
+					return fmt.Errorf("job %s replicates to bucket %s twice", job.Name, rule.BucketID)
+				}
+				replicas[rule.BucketID] = true
+			}
 		}
 	}
 
@@ -179,14 +450,13 @@ func ValidateConfig(config *BackupConfig) error {
 
 // EnsureSystemDirectories creates necessary system directories for Backtide
 func EnsureSystemDirectories() error {
-	// Create /etc/backtide directory for configuration
-	if err := os.MkdirAll("/etc/backtide", 0755); err != nil {
+	// Create the configuration directory (/etc/backtide for root)
+	if err := os.MkdirAll(paths.ConfigDir(), 0755); err != nil {
 		return fmt.Errorf("failed to create configuration directory: %w", err)
 	}
 
-	// Create /etc/backtide/s3-credentials directory for credentials
-	credsDir := filepath.Join("/etc", "backtide", "s3-credentials")
-	if err := os.MkdirAll(credsDir, 0700); err != nil {
+	// Create the credentials directory inside it
+	if err := os.MkdirAll(paths.CredentialsDir(), 0700); err != nil {
 		return fmt.Errorf("failed to create credentials directory: %w", err)
 	}
 
@@ -195,10 +465,20 @@ func EnsureSystemDirectories() error {
 
 // FindConfigFile searches for configuration file in common locations
 func FindConfigFile() string {
+	return findConfigFile(false)
+}
+
+// LocateConfigFile searches the same locations as FindConfigFile without printing warnings
+func LocateConfigFile() string {
+	return findConfigFile(true)
+}
+
+// findConfigFile searches for a configuration file, optionally without output
+func findConfigFile(quiet bool) string {
 	// System-wide configuration locations (preferred)
 	locations := []string{
-		"/etc/backtide/config.toml",
-		"/etc/backtide/backtide.toml",
+		filepath.Join(paths.SystemConfigDir, "config.toml"),
+		filepath.Join(paths.SystemConfigDir, "backtide.toml"),
 	}
 
 	for _, location := range locations {
@@ -217,7 +497,7 @@ func FindConfigFile() string {
 	// If no system configuration found, check for development locations
 	devLocations := []string{
 		"/usr/local/etc/backtide/config.toml",
-		"~/.config/backtide/config.toml",
+		filepath.Join(paths.New().ConfigDir(), "config.toml"),
 		"~/.backtide.toml",
 		"./backtide.toml",
 		"./config.toml",
@@ -232,8 +512,10 @@ func FindConfigFile() string {
 		}
 
 		if _, err := os.Stat(location); err == nil {
-			fmt.Printf("⚠️  Using development configuration: %s\n", location)
-			fmt.Println("💡 For production, use: /etc/backtide/config.toml")
+			if !quiet {
+				render.Printf("⚠️  Using development configuration: %s\n", location)
+				render.Println("💡 For production, use: /etc/backtide/config.toml")
+			}
 			return location
 		}
 	}
@@ -241,6 +523,22 @@ func FindConfigFile() string {
 	return ""
 }
 
+// LoadAccessConfig reads only the access section of a configuration file
+func LoadAccessConfig(configPath string) (AccessConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return AccessConfig{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var partial struct {
+		Access AccessConfig `toml:"access"`
+	}
+	if err := toml.Unmarshal(data, &partial); err != nil {
+		return AccessConfig{}, fmt.Errorf("failed to parse config file as TOML: %w", err)
+	}
+	return partial.Access, nil
+}
+
 // SaveBackupMetadata saves backup metadata to a file
 func SaveBackupMetadata(metadata *BackupMetadata, filePath string) error {
 	if filePath == "" {
@@ -275,15 +573,58 @@ func LoadBackupMetadata(filePath string) (*BackupMetadata, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read metadata file: %w", err)
 	}
+	return ParseBackupMetadata(data)
+}
 
+// ParseBackupMetadata parses the content of a metadata.toml file
+func ParseBackupMetadata(data []byte) (*BackupMetadata, error) {
 	var metadata BackupMetadata
 	if err := toml.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("failed to parse metadata file: %w", err)
 	}
 
+	if err := migrateMetadata(&metadata); err != nil {
+		return nil, err
+	}
 	return &metadata, nil
 }
 
+// migrateMetadata upgrades metadata read from an older schema to the current one
+func migrateMetadata(metadata *BackupMetadata) error {
+	if metadata.SchemaVersion == 0 {
+		metadata.SchemaVersion = 1
+	}
+	if metadata.SchemaVersion > MetadataSchemaVersion {
+		return fmt.Errorf("metadata schema version %d is newer than supported version %d; upgrade backtide",
+			metadata.SchemaVersion, MetadataSchemaVersion)
+	}
+
+	// Version 2 only adds fields, which stay empty for older backups
+	if metadata.SchemaVersion == 1 {
+		metadata.SchemaVersion = 2
+	}
+	return nil
+}
+
+// JobHash returns a SHA-256 hash identifying a job's configuration; directories
+// generated for the run, such as captured system state, are excluded
+func JobHash(job BackupJob) string {
+	var dirs []DirectoryConfig
+	for _, dir := range job.Directories {
+		if dir.Name != SystemStateComponent {
+			dirs = append(dirs, dir)
+		}
+	}
+	job.Directories = dirs
+
+	data, err := toml.Marshal(job)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // CreateDefaultConfig creates a default configuration file
 func CreateDefaultConfig(configPath string) error {
 	defaultConfig := DefaultConfig()
@@ -326,3 +667,126 @@ func CreateDefaultConfig(configPath string) error {
 	defaultConfig.Jobs = []BackupJob{defaultJob}
 	return SaveConfig(defaultConfig, configPath)
 }
+
+// validateFileFilter checks a directory's file filter
+func validateFileFilter(f FileFilter) error {
+	if f.Builtin == "" && len(f.Exclude) == 0 && f.Command == "" {
+		return fmt.Errorf("set builtin, exclude or command")
+	}
+	if f.Builtin != "" && BuiltinFilters[f.Builtin] == nil {
+		return fmt.Errorf("unknown builtin %q", f.Builtin)
+	}
+	if len(f.Match) > 0 && f.Command == "" {
+		return fmt.Errorf("match needs a command")
+	}
+	for _, pattern := range append(append([]string(nil), f.Exclude...), f.Match...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateSystemdConfig checks the resource and sandbox settings of generated services
+func validateSystemdConfig(s SystemdConfig) error {
+	if s.Nice < -20 || s.Nice > 19 {
+		return fmt.Errorf("systemd nice must be between -20 and 19")
+	}
+	switch s.IOSchedulingClass {
+	case "", "realtime", "best-effort", "idle":
+	default:
+		return fmt.Errorf("invalid systemd io_scheduling_class: %s (use realtime, best-effort or idle)", s.IOSchedulingClass)
+	}
+	if s.CPUQuota != "" {
+		percent, err := strconv.Atoi(strings.TrimSuffix(s.CPUQuota, "%"))
+		if err != nil || !strings.HasSuffix(s.CPUQuota, "%") || percent <= 0 {
+			return fmt.Errorf("invalid systemd cpu_quota: %s (use a percentage such as 50%%)", s.CPUQuota)
+		}
+	}
+	if s.MemoryMax != "" {
+		if _, err := utils.ParseSize(s.MemoryMax); err != nil {
+			return fmt.Errorf("invalid systemd memory_max: %w", err)
+		}
+	}
+	switch s.ProtectSystem {
+	case "", "true", "false", "full", "strict":
+	default:
+		return fmt.Errorf("invalid systemd protect_system: %s (use true, full or strict)", s.ProtectSystem)
+	}
+	for _, path := range s.ReadWritePaths {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("systemd read_write_paths must be absolute: %s", path)
+		}
+	}
+	return nil
+}
+
+// validateVerifyAgent checks that the verify agent names existing buckets
+// and notifiers
+func validateVerifyAgent(config *BackupConfig) error {
+	agent := config.VerifyAgent
+	for _, id := range agent.Buckets {
+		found := false
+		for _, bucket := range config.Buckets {
+			found = found || bucket.ID == id
+		}
+		if !found {
+			return fmt.Errorf("verify_agent bucket %s does not exist", id)
+		}
+	}
+	if agent.Interval != "" {
+		if d, err := utils.ParseDuration(agent.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid verify_agent interval %q (use a duration such as 24h)", agent.Interval)
+		}
+	}
+	if agent.Sample < 0 {
+		return fmt.Errorf("verify_agent sample cannot be negative")
+	}
+	if _, err := agent.MaxBackupAge(); err != nil {
+		return err
+	}
+	for _, name := range agent.Plugins {
+		found := false
+		for _, plugin := range config.Plugins {
+			found = found || (plugin.Name == name && plugin.Type == "notifier")
+		}
+		if !found {
+			return fmt.Errorf("verify_agent plugin %s is not a configured notifier", name)
+		}
+	}
+	return nil
+}
+
+// validateDigest checks the digest schedule and that it has somewhere to go
+func validateDigest(digest DigestConfig) error {
+	switch digest.Interval {
+	case "":
+		return nil
+	case DigestDaily, DigestWeekly:
+	default:
+		return fmt.Errorf("invalid digest interval %q (use daily or weekly)", digest.Interval)
+	}
+	if _, _, err := digest.SendTime(); err != nil {
+		return err
+	}
+	if _, err := digest.SendWeekday(); err != nil {
+		return err
+	}
+	if len(digest.Email) == 0 && digest.Webhook == "" {
+		return fmt.Errorf("digest needs email recipients or a webhook")
+	}
+	if digest.Webhook != "" && !strings.HasPrefix(digest.Webhook, "http://") && !strings.HasPrefix(digest.Webhook, "https://") {
+		return fmt.Errorf("digest webhook must be an http or https URL")
+	}
+	for _, address := range append([]string{digest.From}, digest.Email...) {
+		if strings.ContainsAny(address, "\r\n") {
+			return fmt.Errorf("invalid digest email address %q", address)
+		}
+	}
+	for _, event := range digest.MuteEvents {
+		if event != "backup.succeeded" && event != "backup.failed" {
+			return fmt.Errorf("invalid digest mute_events entry %q (use backup.succeeded or backup.failed)", event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/paths"
+)
+
+// SystemProfileDir holds system-wide profile configurations
+var SystemProfileDir = filepath.Join(paths.SystemConfigDir, "profiles")
+
+// ProfileEnv selects a profile when --profile is not given
+const ProfileEnv = "BACKTIDE_PROFILE"
+
+// profileNamePattern restricts profile names to safe file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Profile is a named configuration file
+type Profile struct {
+	Name string
+	Path string
+}
+
+// ProfileDirs returns the directories searched for profiles, system first
+func ProfileDirs() []string {
+	dirs := []string{SystemProfileDir}
+	if userDir := (&paths.Resolver{Home: userHome(), Getenv: os.Getenv}).ProfileDir(); userDir != SystemProfileDir {
+		dirs = append(dirs, userDir)
+	}
+	return dirs
+}
+
+// ValidateProfileName checks that a profile name is usable as a file name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// ProfilePath returns the path a profile would be created at: the system
+// profile directory for root, the XDG one otherwise
+func ProfilePath(name string) string {
+	return filepath.Join(paths.ProfileDir(), name+".toml")
+}
+
+// userHome returns the home directory of the current user
+func userHome() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// FindProfile returns the configuration file of an existing profile
+func FindProfile(name string) (string, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+	for _, dir := range ProfileDirs() {
+		path := filepath.Join(dir, name+".toml")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("profile %q not found (looked in %s)", name, strings.Join(ProfileDirs(), ", "))
+}
+
+// ListProfiles returns all available profiles; system profiles shadow user ones
+func ListProfiles() ([]Profile, error) {
+	seen := make(map[string]bool)
+	var profiles []Profile
+	for _, dir := range ProfileDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read profile directory %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".toml")
+			if entry.IsDir() || name == entry.Name() || seen[name] || ValidateProfileName(name) != nil {
+				continue
+			}
+			seen[name] = true
+			profiles = append(profiles, Profile{Name: name, Path: filepath.Join(dir, entry.Name())})
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
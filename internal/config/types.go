@@ -1,7 +1,17 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
 	"time"
+
+	"github.com/mitexleo/backtide/internal/utils"
 )
 
 // BucketConfig represents a standalone S3 bucket configuration
@@ -17,6 +27,34 @@ type BucketConfig struct {
 	UsePathStyle bool   `toml:"use_path_style"`
 	Provider     string `toml:"provider"`
 	Description  string `toml:"description"`
+	StorageClass string `toml:"storage_class"` // S3 storage class backups are written with, e.g. STANDARD_IA; default the bucket's
+	// MountOnDemand mounts the bucket only while a backup or restore uses it instead of permanently via fstab
+	MountOnDemand bool        `toml:"mount_on_demand"`
+	S3FS          S3FSOptions `toml:"s3fs"`
+	TLS           BucketTLS   `toml:"tls"`
+	// PurgeVersions permanently deletes the old versions of backups removed
+	// by cleanup from a bucket with versioning, so they stop using storage
+	PurgeVersions bool `toml:"purge_noncurrent_versions"`
+}
+
+// BucketTLS configures TLS for endpoints with private CAs or client certificates
+type BucketTLS struct {
+	CAFile             string `toml:"ca_file"`              // PEM bundle of CAs trusted for the endpoint
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"` // disable certificate verification (testing only)
+	CertFile           string `toml:"cert_file"`            // PEM client certificate
+	KeyFile            string `toml:"key_file"`             // PEM private key for cert_file
+}
+
+// S3FSOptions tunes the s3fs mount; zero values leave the s3fs defaults
+type S3FSOptions struct {
+	MultipartSize  int      `toml:"multipart_size"`  // multipart upload part size in MB
+	ParallelCount  int      `toml:"parallel_count"`  // parallel requests for multipart uploads
+	UseCache       string   `toml:"use_cache"`       // local cache directory
+	EnsureDiskFree int      `toml:"ensure_diskfree"` // MB of disk space to keep free for the cache
+	Retries        int      `toml:"retries"`         // retries for failed S3 requests
+	ConnectTimeout int      `toml:"connect_timeout"` // seconds to wait for a connection
+	IAMRole        string   `toml:"iam_role"`        // instance IAM role, or "auto"; replaces access keys
+	ExtraOptions   []string `toml:"options"`         // additional raw s3fs -o options
 }
 
 // BackupConfig represents the configuration for backup operations
@@ -25,6 +63,450 @@ type BackupConfig struct {
 	Buckets    []BucketConfig `toml:"buckets"`
 	BackupPath string         `toml:"backup_path"`
 	TempPath   string         `toml:"temp_path"`
+	Durability string         `toml:"durability"` // "fsync" syncs backups to disk before they count as complete; default none
+	Access     AccessConfig   `toml:"access"`
+	Plugins    []PluginConfig `toml:"plugins"`
+	Mounts     MountsConfig   `toml:"mounts"`
+	Docker     DockerConfig   `toml:"docker"`
+	Network    NetworkConfig  `toml:"network"`
+	Updates    UpdatesConfig  `toml:"updates"`
+	Memory     MemoryConfig   `toml:"memory"`
+	Fleet      FleetConfig    `toml:"fleet"`
+	Logging    LoggingConfig  `toml:"logging"`
+	Systemd    SystemdConfig  `toml:"systemd"`
+	Digest     DigestConfig   `toml:"digest"`
+	Outbox     OutboxConfig   `toml:"outbox"`
+	Approval   ApprovalConfig `toml:"approval"`
+	// VerifyAgent makes the daemon check backups in buckets independently of
+	// the hosts that write them
+	VerifyAgent VerifyAgentConfig `toml:"verify_agent"`
+	// Pricing overrides the built-in provider prices used by 'stats' to
+	// estimate storage costs
+	Pricing []PricingConfig `toml:"pricing"`
+}
+
+// PricingConfig is the price of storing data with a provider, in any currency
+type PricingConfig struct {
+	Provider string `toml:"provider"` // as in the bucket's provider, e.g. "Backblaze B2"
+	// StorageClass restricts the prices to buckets with this storage class;
+	// empty applies to all of the provider's buckets without a more specific entry
+	StorageClass string  `toml:"storage_class"`
+	StorageGB    float64 `toml:"storage_gb_month"` // per GB stored for a month
+	EgressGB     float64 `toml:"egress_gb"`        // per GB downloaded
+	Currency     string  `toml:"currency"`         // symbol or code shown with costs; default $
+}
+
+// SystemdConfig controls the per-job units installed by 'systemd-jobs sync'
+// and the resources and sandboxing of the generated services
+type SystemdConfig struct {
+	// SyncTimers makes the daemon keep job timers in sync with the configuration
+	// and leave scheduled runs to them
+	SyncTimers bool `toml:"sync_timers"`
+
+	Nice              int    `toml:"nice"`                // CPU scheduling priority, -20 to 19; 0 leaves it unset
+	IOSchedulingClass string `toml:"io_scheduling_class"` // realtime, best-effort or idle
+	CPUQuota          string `toml:"cpu_quota"`           // share of one CPU such as "50%"
+	MemoryMax         string `toml:"memory_max"`          // hard memory limit such as "1GB"
+	ProtectSystem     string `toml:"protect_system"`      // true, full or strict
+	// ReadWritePaths stay writable under protect_system; the backup, temp and
+	// state paths and bucket mount points are added automatically
+	ReadWritePaths []string `toml:"read_write_paths"`
+}
+
+// LoggingConfig sends structured records of backup events to syslog or journald
+type LoggingConfig struct {
+	Sink     string `toml:"sink"`     // "syslog" or "journald"; empty logs to stdout only
+	Tag      string `toml:"tag"`      // syslog tag / SYSLOG_IDENTIFIER; default backtide
+	Facility string `toml:"facility"` // syslog facility such as daemon or local0; default daemon
+	Address  string `toml:"address"`  // remote syslog server, e.g. udp://logs.example.com:514; default local
+	// FailureLines is how many of the last lines of a failed run's output are
+	// sent with its backup.failed notification; default 50, -1 sends none
+	FailureLines int `toml:"failure_lines"`
+}
+
+// FailureLogLines returns how many lines of output failure notifications carry
+func (l LoggingConfig) FailureLogLines() int {
+	switch {
+	case l.FailureLines < 0:
+		return 0
+	case l.FailureLines == 0:
+		return 50
+	}
+	return l.FailureLines
+}
+
+// Identifier returns the tag records are logged under
+func (l LoggingConfig) Identifier() string {
+	if l.Tag != "" {
+		return l.Tag
+	}
+	return "backtide"
+}
+
+// FleetConfig sends run summaries to, or collects them as, a central fleet collector
+type FleetConfig struct {
+	ReportTo string `toml:"report_to"` // collector URL the daemon POSTs run summaries to, e.g. https://backups.example.com:8750/v1/reports
+	Secret   string `toml:"secret"`    // shared secret signing the reports
+	Listen   string `toml:"listen"`    // address 'fleet serve' listens on; default :8750
+	// StaleAfter marks hosts without a successful backup for this long as stale in 'fleet status'; default 26h
+	StaleAfter string `toml:"stale_after"`
+}
+
+// ListenAddress returns the address the collector listens on
+func (f FleetConfig) ListenAddress() string {
+	if f.Listen != "" {
+		return f.Listen
+	}
+	return ":8750"
+}
+
+// StaleDuration returns how long a job may go without success before it is reported stale
+func (f FleetConfig) StaleDuration() time.Duration {
+	if d, err := utils.ParseDuration(f.StaleAfter); err == nil && d > 0 {
+		return d
+	}
+	return 26 * time.Hour
+}
+
+// MemoryConfig bounds memory use on small hosts
+type MemoryConfig struct {
+	Limit    string `toml:"limit"`     // soft heap limit such as "512MB"; empty for no limit
+	DirBatch int    `toml:"dir_batch"` // directory entries read at a time while walking; default 1000
+}
+
+// LimitBytes returns the configured heap limit in bytes, or 0 for no limit
+func (m MemoryConfig) LimitBytes() int64 {
+	if limit, err := utils.ParseSize(m.Limit); err == nil {
+		return limit
+	}
+	return 0
+}
+
+// Batch returns how many directory entries are read at a time
+func (m MemoryConfig) Batch() int {
+	if m.DirBatch > 0 {
+		return m.DirBatch
+	}
+	return 1000
+}
+
+// MountsConfig controls how the daemon supervises S3 mounts
+type MountsConfig struct {
+	CheckInterval string `toml:"check_interval"` // how often mounts are checked; default 5m
+	CheckTimeout  string `toml:"check_timeout"`  // how long a check may block on a hung mount; default 10s
+	SkipRemount   bool   `toml:"skip_remount"`   // only report broken mounts instead of remounting them
+}
+
+// Interval returns how often mounts are checked
+func (m MountsConfig) Interval() time.Duration {
+	if d, err := time.ParseDuration(m.CheckInterval); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Minute
+}
+
+// Timeout returns how long a single mount check may take
+func (m MountsConfig) Timeout() time.Duration {
+	if d, err := time.ParseDuration(m.CheckTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 10 * time.Second
+}
+
+// Durability modes
+const (
+	DurabilityNone  = "none"
+	DurabilityFsync = "fsync"
+)
+
+// Fsync reports whether backups are synced to disk before they count as complete
+func (c BackupConfig) Fsync() bool {
+	return c.Durability == DurabilityFsync
+}
+
+// OutboxConfig controls where backups of jobs with storage.forward wait for
+// their upload and how often failed uploads are retried
+type OutboxConfig struct {
+	Path          string `toml:"path"`           // default: outbox in the backup path
+	RetryInterval string `toml:"retry_interval"` // wait after a failed upload; default 5m
+}
+
+// Retry returns how long to wait before retrying a failed upload
+func (o OutboxConfig) Retry() time.Duration {
+	if d, err := utils.ParseDuration(o.RetryInterval); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Minute
+}
+
+// OutboxPath returns the directory holding backups waiting to be uploaded
+func (c BackupConfig) OutboxPath() string {
+	if c.Outbox.Path != "" {
+		return c.Outbox.Path
+	}
+	return filepath.Join(c.BackupPath, "outbox")
+}
+
+// ApprovalConfig sets up the second-channel approval that restores of jobs
+// with restore_approval need
+type ApprovalConfig struct {
+	// TOTPSecretFile holds the base32 secret of the authenticator app codes
+	// accepted as approval; it must be owned and only readable by root
+	TOTPSecretFile string `toml:"totp_secret_file"`
+	TokenValidity  string `toml:"token_validity"` // how long daemon approval tokens are valid; default 15m
+}
+
+// Validity returns how long an approval token issued by the daemon is valid
+func (a ApprovalConfig) Validity() time.Duration {
+	if d, err := utils.ParseDuration(a.TokenValidity); err == nil && d > 0 {
+		return d
+	}
+	return 15 * time.Minute
+}
+
+// TOTPSecret reads the TOTP secret, refusing a file other users can read
+func (a ApprovalConfig) TOTPSecret() (string, error) {
+	if a.TOTPSecretFile == "" {
+		return "", fmt.Errorf("no approval totp_secret_file is configured")
+	}
+	info, err := os.Stat(a.TOTPSecretFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read TOTP secret: %w", err)
+	}
+	// Only root can place the secret, so a chosen one cannot be configured
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid != 0 {
+		return "", fmt.Errorf("TOTP secret %s must be owned by root", a.TOTPSecretFile)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("TOTP secret %s is readable by other users; chmod 600 it", a.TOTPSecretFile)
+	}
+	data, err := os.ReadFile(a.TOTPSecretFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read TOTP secret: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// VerifyAgentConfig makes the daemon check the backups other hosts store in
+// buckets: it lists them with the bucket's credentials, which only need read
+// access, checks that every archive their metadata names exists, and
+// downloads a sample of archives to compare with their recorded checksums
+type VerifyAgentConfig struct {
+	Buckets  []string `toml:"buckets"`  // IDs of the buckets to check; empty checks none
+	Interval string   `toml:"interval"` // time between checks; default 24h
+	Sample   int      `toml:"sample"`   // archives downloaded and checksummed per check; default 3
+	// MaxAge fails the check when the newest backup of a host is older;
+	// empty does not check
+	MaxAge  string   `toml:"max_age"`
+	Plugins []string `toml:"plugins"` // notifier plugins told about failed checks
+}
+
+// Enabled reports whether the daemon checks any buckets
+func (v VerifyAgentConfig) Enabled() bool {
+	return len(v.Buckets) > 0
+}
+
+// Period returns the time between checks
+func (v VerifyAgentConfig) Period() time.Duration {
+	if d, err := utils.ParseDuration(v.Interval); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// SampleSize returns how many archives a check downloads
+func (v VerifyAgentConfig) SampleSize() int {
+	if v.Sample > 0 {
+		return v.Sample
+	}
+	return 3
+}
+
+// MaxBackupAge returns the age the newest backup of each host must not
+// exceed, 0 if unchecked
+func (v VerifyAgentConfig) MaxBackupAge() (time.Duration, error) {
+	if v.MaxAge == "" {
+		return 0, nil
+	}
+	d, err := utils.ParseDuration(v.MaxAge)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid verify_agent max_age %q (use a duration such as 26h)", v.MaxAge)
+	}
+	return d, nil
+}
+
+// UpdatesConfig controls how the daemon checks for new backtide releases
+type UpdatesConfig struct {
+	CheckInterval string `toml:"check_interval"` // how often the latest release is looked up; default 24h
+	SkipCheck     bool   `toml:"skip_check"`     // never contact GitHub, e.g. on isolated hosts
+}
+
+// Interval returns how often the latest release is looked up
+func (u UpdatesConfig) Interval() time.Duration {
+	if d, err := utils.ParseDuration(u.CheckInterval); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// DigestConfig makes the daemon send one daily or weekly summary of all jobs'
+// runs by email or webhook, for operators who do not want a message per backup
+type DigestConfig struct {
+	Interval string   `toml:"interval"` // "daily" or "weekly"; empty sends no digest
+	At       string   `toml:"at"`       // local time the digest is sent, e.g. "08:00"; default 08:00
+	Weekday  string   `toml:"weekday"`  // day weekly digests are sent; default monday
+	Email    []string `toml:"email"`    // recipients, delivered with the local sendmail
+	From     string   `toml:"from"`     // sender address; default backtide@<hostname>
+	Webhook  string   `toml:"webhook"`  // URL the digest is POSTed to as JSON
+	// MuteEvents are per-run events, such as backup.succeeded, that are no
+	// longer sent to notifier plugins because the digest covers them
+	MuteEvents []string `toml:"mute_events"`
+}
+
+// Digest intervals
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Enabled reports whether a digest is sent
+func (d DigestConfig) Enabled() bool {
+	return d.Interval != ""
+}
+
+// Period returns how long a digest covers
+func (d DigestConfig) Period() time.Duration {
+	if d.Interval == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// SendTime returns the hour and minute digests are sent at
+func (d DigestConfig) SendTime() (int, int, error) {
+	if d.At == "" {
+		return 8, 0, nil
+	}
+	t, err := time.Parse("15:04", d.At)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid digest at %q (use HH:MM such as 08:00)", d.At)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// SendWeekday returns the day weekly digests are sent on
+func (d DigestConfig) SendWeekday() (time.Weekday, error) {
+	if d.Weekday == "" {
+		return time.Monday, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(d.Weekday, day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid digest weekday %q (use a day such as monday)", d.Weekday)
+}
+
+// Mutes reports whether the digest replaces per-run notifications of an event type
+func (d DigestConfig) Mutes(eventType string) bool {
+	return d.Enabled() && slices.Contains(d.MuteEvents, eventType)
+}
+
+// NetworkConfig applies to all outbound HTTP requests: update checks, fleet
+// reports and S3 API calls
+type NetworkConfig struct {
+	// CABundle is a PEM bundle of CAs trusted in addition to the system roots,
+	// e.g. for a TLS-inspecting corporate proxy
+	CABundle string `toml:"ca_bundle"`
+	// Proxy overrides HTTPS_PROXY/HTTP_PROXY, which services started by systemd do not inherit
+	Proxy   string `toml:"proxy"`
+	NoProxy string `toml:"no_proxy"` // comma-separated hosts and domains reached directly; overrides NO_PROXY with proxy
+}
+
+// DockerConfig controls how containers are stopped and started around backups
+// and how containers left stopped by crashed runs are handled
+type DockerConfig struct {
+	Parallelism int    `toml:"parallelism"`  // containers stopped or started at once; default 4
+	StopTimeout string `toml:"stop_timeout"` // grace period before a container is killed; default Docker's 10s
+	// DependsOn lists, by container name, the containers a container needs; it is
+	// stopped before and started after them. Compose depends_on is applied as well.
+	DependsOn map[string][]string `toml:"depends_on"`
+
+	OrphanThreshold string `toml:"orphan_threshold"` // how long containers of a crashed run stay down before alerting; default 15m
+	SkipRecover     bool   `toml:"skip_recover"`     // only report orphaned containers instead of restarting them
+}
+
+// Workers returns how many containers are stopped or started at once
+func (d DockerConfig) Workers() int {
+	if d.Parallelism > 0 {
+		return d.Parallelism
+	}
+	return 4
+}
+
+// OrphanAfter returns how long containers of a crashed run may stay stopped
+func (d DockerConfig) OrphanAfter() time.Duration {
+	if duration, err := time.ParseDuration(d.OrphanThreshold); err == nil && duration >= 0 {
+		return duration
+	}
+	return 15 * time.Minute
+}
+
+// PluginConfig declares an external executable plugin
+type PluginConfig struct {
+	Name    string            `toml:"name"`
+	Type    string            `toml:"type"` // storage, notifier or hook
+	Command string            `toml:"command"`
+	Args    []string          `toml:"args"`
+	Env     map[string]string `toml:"env"`
+	Timeout string            `toml:"timeout"`
+	// Title and Message are Go templates for the title and message passed
+	// to a notifier with each event; unset ones use the defaults
+	Title   string `toml:"title,omitempty"`
+	Message string `toml:"message,omitempty"`
+}
+
+// Default notification templates
+const (
+	DefaultNotifyTitle   = `{{if .Job}}{{.Job}}{{else}}backtide{{end}}: {{.Type}}`
+	DefaultNotifyMessage = `{{if .BackupID}}Backup {{.BackupID}} of job {{.Job}} {{.Status}}: {{size .TotalSize}} in {{.Duration}}` +
+		`{{else}}{{.Type}}{{if .Job}} for job {{.Job}}{{end}}{{if .Bucket}} on bucket {{.Bucket}}{{end}}{{end}}` +
+		`{{if .Error}}: {{.Error}}{{end}}`
+)
+
+// notifyFuncs are the functions available in notification templates
+var notifyFuncs = template.FuncMap{
+	"size":  utils.FormatBytes,
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NotifyTemplates parses the plugin's title and message templates, using
+// the defaults for unset ones
+func (p PluginConfig) NotifyTemplates() (title, message *template.Template, err error) {
+	titleText, messageText := p.Title, p.Message
+	if titleText == "" {
+		titleText = DefaultNotifyTitle
+	}
+	if messageText == "" {
+		messageText = DefaultNotifyMessage
+	}
+	if title, err = template.New("title").Funcs(notifyFuncs).Parse(titleText); err != nil {
+		return nil, nil, fmt.Errorf("invalid title template: %w", err)
+	}
+	if message, err = template.New("message").Funcs(notifyFuncs).Parse(messageText); err != nil {
+		return nil, nil, fmt.Errorf("invalid message template: %w", err)
+	}
+	return title, message, nil
+}
+
+// AccessConfig restricts who may modify backups and configuration on shared hosts
+type AccessConfig struct {
+	// OperatorGroups may run, delete and restore backups and edit configuration; root always may
+	OperatorGroups []string `toml:"operator_groups"`
+	// SocketGroup may connect to the daemon control socket for read-only queries
+	SocketGroup string `toml:"socket_group"`
 }
 
 // BackupJob represents a complete backup configuration with scheduling
@@ -38,8 +520,162 @@ type BackupJob struct {
 	BucketID    string            `toml:"bucket_id"`
 	Retention   RetentionPolicy   `toml:"retention"`
 	SkipDocker  bool              `toml:"skip_docker"`
+	Docker      JobDockerConfig   `toml:"docker"`
 	SkipS3      bool              `toml:"skip_s3"`
 	Storage     StorageConfig     `toml:"storage"`
+	// Replicate copies each backup to secondary buckets once it is on the job's bucket
+	Replicate   []ReplicationRule `toml:"replicate,omitempty"`
+	Plugins     []string          `toml:"plugins"` // names of plugins applied to this job
+	SystemState SystemStateConfig `toml:"system_state"`
+	// VerifyCommand checks that restored data is usable ('backtide verify'); it runs
+	// with sh in the directory the backup was restored to
+	VerifyCommand string `toml:"verify_command,omitempty"`
+	// Manifest writes manifest.jsonl with each file's path, size, modification
+	// time and SHA-256 next to every backup, for external search and indexing
+	Manifest bool `toml:"manifest,omitempty"`
+	// ExpectedMinSize marks backups smaller than this size ("500MB") or share
+	// of the previous backup ("50%") as undersized
+	ExpectedMinSize string `toml:"expected_min_size,omitempty"`
+	// UndersizedAction is "fail" (default) or "warn"; undersized backups are
+	// kept either way, but retention cleanup does not run after them
+	UndersizedAction string `toml:"undersized_action,omitempty"`
+	// RunAs archives the directories as this user ("backup" or
+	// "backup:backup") instead of root; containers, mounts and retention are
+	// still handled as root
+	RunAs string `toml:"run_as,omitempty"`
+	// Sandbox archives the directories in a process that Landlock restricts
+	// to reading the job's directories and writing the backup, so symlinks
+	// or filters cannot reach other files; Linux only
+	Sandbox bool `toml:"sandbox,omitempty"`
+	// MaxAge is the freshness SLO ("26h"): the newest successful backup must
+	// not be older, or status, the daemon and fleet metrics report the job
+	MaxAge string `toml:"max_age,omitempty"`
+	// Output sets the permissions and ownership of the backup files written
+	Output OutputConfig `toml:"output,omitempty"`
+	// RestoreApproval makes restores of the job's backups wait for a second
+	// operator's approval token or a TOTP code, e.g. for production jobs
+	RestoreApproval bool `toml:"restore_approval,omitempty"`
+}
+
+// OutputConfig locks a job's backups down to a backup user and group
+type OutputConfig struct {
+	// Mode is the octal mode of backup files, e.g. "0640"; directories also
+	// get execute permission where the mode grants read
+	Mode  string `toml:"mode,omitempty"`
+	Owner string `toml:"owner,omitempty"` // user name or UID
+	Group string `toml:"group,omitempty"` // group name or GID
+}
+
+// Set reports whether any of the output settings is configured
+func (o OutputConfig) Set() bool {
+	return o.Mode != "" || o.Owner != "" || o.Group != ""
+}
+
+// FileMode returns the mode of backup files, or 0 when unset
+func (o OutputConfig) FileMode() (os.FileMode, error) {
+	if o.Mode == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(o.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid output mode %q (use octal permissions such as 0640)", o.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// DirMode returns the mode of backup directories for a file mode: execute
+// permission is added wherever the file mode grants read
+func DirMode(fileMode os.FileMode) os.FileMode {
+	return fileMode | (fileMode&0444)>>2
+}
+
+// Actions for undersized backups
+const (
+	UndersizedFail = "fail"
+	UndersizedWarn = "warn"
+)
+
+// MinSize returns the smallest expected backup size given the size of the
+// previous backup, or 0 when there is no expectation. A percentage without a
+// previous backup expects nothing.
+func (j BackupJob) MinSize(previous int64) (int64, error) {
+	if j.ExpectedMinSize == "" {
+		return 0, nil
+	}
+	if percent, ok := strings.CutSuffix(j.ExpectedMinSize, "%"); ok {
+		share, err := strconv.ParseFloat(percent, 64)
+		if err != nil || share <= 0 || share > 100 {
+			return 0, fmt.Errorf("invalid expected_min_size %s (use a size such as 500MB or a percentage such as 50%%)", j.ExpectedMinSize)
+		}
+		return int64(float64(previous) * share / 100), nil
+	}
+	size, err := utils.ParseSize(j.ExpectedMinSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expected_min_size: %w", err)
+	}
+	return size, nil
+}
+
+// FailUndersized reports whether an undersized backup fails the run
+func (j BackupJob) FailUndersized() bool {
+	return j.UndersizedAction != UndersizedWarn
+}
+
+// MaxAgeDuration returns the job's freshness SLO, or 0 without one
+func (j BackupJob) MaxAgeDuration() time.Duration {
+	if d, err := utils.ParseDuration(j.MaxAge); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// UsesDocker reports whether backups of the job stop containers or run commands in them
+func (j BackupJob) UsesDocker() bool {
+	return !j.SkipDocker || len(j.Docker.ExecBefore) > 0 || len(j.Docker.ExecAfter) > 0 || j.Docker.Discover
+}
+
+// JobDockerConfig quiesces containers in place instead of stopping them and
+// discovers what to back up from Docker labels
+type JobDockerConfig struct {
+	// ExecBefore runs in containers before the backup, e.g. to flush a database;
+	// when set, containers keep running instead of being stopped
+	ExecBefore []ContainerExec `toml:"exec_before,omitempty"`
+	// ExecAfter runs once the backup has been taken, even if it failed
+	ExecAfter []ContainerExec `toml:"exec_after,omitempty"`
+	// Discover adds the mounts of containers and the volumes labeled
+	// backtide.enable=true to the job's directories at run time
+	Discover bool `toml:"discover,omitempty"`
+	// WarmCopy copies the directories to the temp path while containers run and
+	// stops them only for a second pass copying what changed meanwhile; the
+	// backup is archived from the copy after the containers are restarted
+	WarmCopy bool `toml:"warm_copy,omitempty"`
+}
+
+// ContainerExec is a command run with sh inside a running container
+type ContainerExec struct {
+	Container string `toml:"container"`
+	Cmd       string `toml:"cmd"`
+	Timeout   string `toml:"timeout,omitempty"` // default 5m
+}
+
+// TimeoutDuration returns how long the command may run
+func (e ContainerExec) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(e.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Minute
+}
+
+// SystemStateComponent is the backup directory name holding captured system state
+const SystemStateComponent = "system-state"
+
+// SystemStateItems are the parts of the system state that can be captured
+var SystemStateItems = []string{"packages", "services", "crontabs", "systemd", "etc"}
+
+// SystemStateConfig captures host state (packages, services, crontabs, /etc) as a backup component
+type SystemStateConfig struct {
+	Enabled bool     `toml:"enabled"`
+	Include []string `toml:"include"` // packages, services, crontabs, systemd, etc; empty captures all
 }
 
 // ScheduleConfig represents backup scheduling configuration
@@ -47,6 +683,56 @@ type ScheduleConfig struct {
 	Type     string `toml:"type"`
 	Interval string `toml:"interval"`
 	Enabled  bool   `toml:"enabled"`
+	Timezone string `toml:"timezone"`
+	// MisfirePolicy decides what happens to a run missed while the host was
+	// off or asleep or the daemon was down (default run_immediately)
+	MisfirePolicy string `toml:"misfire_policy,omitempty"`
+	// Jitter delays calendar schedules by up to this long (e.g. "15m"), by a
+	// fixed amount per host, so hosts sharing a schedule start at different times
+	Jitter string `toml:"jitter,omitempty"`
+}
+
+// JitterDuration returns the maximum start delay, 0 when no jitter is set
+func (s ScheduleConfig) JitterDuration() (time.Duration, error) {
+	if s.Jitter == "" {
+		return 0, nil
+	}
+	jitter, err := utils.ParseDuration(s.Jitter)
+	if err != nil || jitter < 0 {
+		return 0, fmt.Errorf("invalid schedule jitter %q", s.Jitter)
+	}
+	return jitter, nil
+}
+
+// Misfire policies for scheduled runs that were missed
+const (
+	// MisfireRunImmediately makes up a missed run as soon as possible
+	MisfireRunImmediately = "run_immediately"
+	// MisfireSkip drops missed runs; the job waits for its next scheduled time
+	MisfireSkip = "skip"
+	// MisfireNextWindow makes up a missed run only within the first half of
+	// the time until the next scheduled run, and drops it after that
+	MisfireNextWindow = "next_window"
+)
+
+// Misfire returns the misfire policy, defaulting to run_immediately
+func (s ScheduleConfig) Misfire() string {
+	if s.MisfirePolicy == "" {
+		return MisfireRunImmediately
+	}
+	return s.MisfirePolicy
+}
+
+// Location returns the time zone the schedule should be evaluated in
+func (s ScheduleConfig) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule timezone %q: %w", s.Timezone, err)
+	}
+	return location, nil
 }
 
 // DirectoryConfig represents configuration for a single directory to backup
@@ -54,12 +740,61 @@ type DirectoryConfig struct {
 	Path        string `toml:"path"`
 	Name        string `toml:"name"`
 	Compression bool   `toml:"compression"`
+	// Required fails the backup when the directory does not exist instead of
+	// leaving it out with a warning
+	Required bool `toml:"required,omitempty"`
+	// Filters leave files out of the archive or replace their content
+	Filters []FileFilter `toml:"filters,omitempty"`
+	// Source is read instead of Path when set, e.g. a warm copy of it; Path
+	// is still what the backup records
+	Source string `toml:"-"`
+}
+
+// SourcePath returns the directory the archive is read from
+func (d DirectoryConfig) SourcePath() string {
+	if d.Source != "" {
+		return d.Source
+	}
+	return d.Path
+}
+
+// FileFilter leaves files out of a directory's archive or replaces their
+// content while it is written, e.g. to keep secrets or personal data from
+// leaving the host. Patterns are globs matched against the file name, or
+// against the end of the path when they contain a slash.
+type FileFilter struct {
+	Name    string   `toml:"name"`              // recorded in the backup metadata
+	Builtin string   `toml:"builtin,omitempty"` // a predefined exclude list, see BuiltinFilters
+	Exclude []string `toml:"exclude,omitempty"` // files and directories to leave out
+	// Command runs with sh for each file matching Match, reading the file on
+	// stdin and writing the content to archive on stdout; exit status 3 leaves
+	// the file out and any other failure fails the backup
+	Command string   `toml:"command,omitempty"`
+	Match   []string `toml:"match,omitempty"` // files Command applies to; default all
+}
+
+// FilterDropExitCode is the exit status with which a filter command leaves a file out
+const FilterDropExitCode = 3
+
+// BuiltinFilters are the exclude lists a filter can name with builtin
+var BuiltinFilters = map[string][]string{
+	"secrets": {".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+		"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", ".netrc", ".pgpass", ".git-credentials", "credentials.json"},
 }
 
 // StorageConfig defines where backups should be stored
 type StorageConfig struct {
 	Local bool `toml:"local"`
 	S3    bool `toml:"s3"`
+	// Forward writes S3 backups to the local outbox and uploads them once the
+	// run is done, retrying until the bucket is reachable
+	Forward bool `toml:"forward,omitempty"`
+}
+
+// ReplicationRule copies a job's backups to a secondary bucket, e.g. in
+// another region or with another provider
+type ReplicationRule struct {
+	BucketID string `toml:"bucket_id"`
 }
 
 // RetentionPolicy defines how long to keep backups
@@ -67,27 +802,109 @@ type RetentionPolicy struct {
 	KeepDays    int `toml:"keep_days"`
 	KeepCount   int `toml:"keep_count"`
 	KeepMonthly int `toml:"keep_monthly"`
+	// MinFreeSpace ("100GB") prunes the oldest local backups while the
+	// backup filesystem has less free space
+	MinFreeSpace string `toml:"min_free_space,omitempty"`
+}
+
+// MinFreeBytes returns the free space cleanup keeps on the backup
+// filesystem, or 0 without a minimum
+func (r RetentionPolicy) MinFreeBytes() (int64, error) {
+	if r.MinFreeSpace == "" {
+		return 0, nil
+	}
+	size, err := utils.ParseSize(r.MinFreeSpace)
+	if err != nil {
+		return 0, fmt.Errorf("invalid min_free_space: %w", err)
+	}
+	return size, nil
 }
 
 // BackupMetadata stores information about each backup
 type BackupMetadata struct {
-	ID          string            `toml:"id"`
-	Timestamp   time.Time         `toml:"timestamp"`
-	Directories []BackupDirectory `toml:"directories"`
-	TotalSize   int64             `toml:"total_size"`
-	Checksum    string            `toml:"checksum"`
-	Compressed  bool              `toml:"compressed"`
+	// SchemaVersion is absent in metadata written before versioning (version 1); older
+	// metadata is migrated to the current version when loaded
+	SchemaVersion int               `toml:"schema_version"`
+	ID            string            `toml:"id"`
+	Timestamp     time.Time         `toml:"timestamp"`
+	Directories   []BackupDirectory `toml:"directories"`
+	TotalSize     int64             `toml:"total_size"`
+	Checksum      string            `toml:"checksum"`
+	Compressed    bool              `toml:"compressed"`
+
+	// Added in version 2; empty when read from version 1 metadata
+	JobID           string `toml:"job_id"`
+	JobName         string `toml:"job_name"`
+	Hostname        string `toml:"hostname"`
+	MachineID       string `toml:"machine_id,omitempty"` // machine ID of the host, when it has one
+	BacktideVersion string `toml:"backtide_version"`
+	Duration        string `toml:"duration"`    // time taken to write the backup, e.g. "1m30s"
+	ConfigHash      string `toml:"config_hash"` // SHA-256 of the job configuration that produced the backup
+
+	// Optional annotations given with 'backtide backup --comment/--label'
+	Comment string            `toml:"comment,omitempty"`
+	Labels  map[string]string `toml:"labels,omitempty"`
+
+	// Pinned backups are kept regardless of the retention policy ('backtide pin')
+	Pinned bool `toml:"pinned,omitempty"`
+	// ExpiresAt, unless zero, replaces the retention policy for this backup
+	// ('backtide backup --keep-for')
+	ExpiresAt time.Time `toml:"expires_at"`
+	// Manifest names the file manifest written with the backup, if any
+	Manifest string `toml:"manifest,omitempty"`
+	// Missing lists the source directories that did not exist and are not in the backup
+	Missing []string `toml:"missing,omitempty"`
+	// Undersized backups were smaller than the job's expected_min_size; they do
+	// not count toward keep_count
+	Undersized bool `toml:"undersized,omitempty"`
+	// RestoreApproval is copied from the job, so restoring the backup needs
+	// approval even with another configuration or from its path
+	RestoreApproval bool `toml:"restore_approval,omitempty"`
+	// Summary outlines the contents, for judging whether a backup looks complete
+	Summary *BackupSummary `toml:"summary,omitempty"`
 }
 
+// BackupSummary outlines what a backup contains
+type BackupSummary struct {
+	TopLevel  []SummaryEntry `toml:"top_level"`  // bytes per top-level entry of each directory, largest first
+	FileTypes []SummaryEntry `toml:"file_types"` // files and bytes per file extension, largest first
+	Largest   []SummaryEntry `toml:"largest"`    // the largest files
+}
+
+// SummaryEntry is a path, file extension or group with its file count and size
+type SummaryEntry struct {
+	Name  string `toml:"name"`
+	Files int    `toml:"files"`
+	Size  int64  `toml:"size"`
+}
+
+// MetadataSchemaVersion is the version of the backup metadata format written by this build
+const MetadataSchemaVersion = 2
+
 // BackupDirectory contains metadata for each backed up directory
 type BackupDirectory struct {
 	Path        string              `toml:"path"`
 	Name        string              `toml:"name"`
 	Size        int64               `toml:"size"`
 	FileCount   int                 `toml:"file_count"`
-	Permissions map[string]FilePerm `toml:"permissions"`
+	Permissions map[string]FilePerm `toml:"permissions"` // unused; file details are in Index
+	Index       string              `toml:"index"`       // gzip-compressed JSON lines file listing every entry
 	Checksum    string              `toml:"checksum"`
 	Compressed  bool                `toml:"compressed"`
+	Footer      bool                `toml:"footer"` // archive ends with an integrity footer checked on restore
+	// Filters records the filters applied while the archive was written
+	Filters []AppliedFilter `toml:"filters,omitempty"`
+}
+
+// AppliedFilter records a file filter applied to a backup directory
+type AppliedFilter struct {
+	Name        string   `toml:"name"`
+	Builtin     string   `toml:"builtin,omitempty"`
+	Exclude     []string `toml:"exclude,omitempty"`
+	Command     string   `toml:"command,omitempty"`
+	Match       []string `toml:"match,omitempty"`
+	Dropped     int      `toml:"dropped"`     // files and directories left out
+	Transformed int      `toml:"transformed"` // files archived with content from the command
 }
 
 // FilePerm stores file permission information
@@ -106,6 +923,12 @@ type DockerContainerInfo struct {
 	Image   string    `toml:"image"`
 	Status  string    `toml:"status"`
 	Stopped time.Time `toml:"stopped"`
+	// DependsOn holds the IDs of containers this one needs running
+	DependsOn []string `toml:"depends_on,omitempty"`
+	// Paused and RestartPolicy record the state to return the container to;
+	// an empty RestartPolicy means "no"
+	Paused        bool   `toml:"paused,omitempty"`
+	RestartPolicy string `toml:"restart_policy,omitempty"`
 }
 
 // BackupState tracks the current state of backup operations
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Client talks to the daemon control API over its unix socket
+type Client struct {
+	socketPath string
+	httpClient *http.Client
+}
+
+// NewClient creates a new control API client
+func NewClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{
+		socketPath: socketPath,
+		httpClient: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// Available reports whether a daemon is listening on the socket
+func (c *Client) Available() bool {
+	var status DaemonStatus
+	return c.Get("/v1/status", &status) == nil
+}
+
+// Get performs a GET request and decodes the JSON response into out
+func (c *Client) Get(path string, out interface{}) error {
+	return c.do(http.MethodGet, path, nil, out)
+}
+
+// Post performs a POST request with an optional JSON body
+func (c *Client) Post(path string, in, out interface{}) error {
+	return c.do(http.MethodPost, path, in, out)
+}
+
+// do performs a request against the daemon
+func (c *Client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, "http://backtide"+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr ErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("daemon: %s", apiErr.Error)
+		}
+		return fmt.Errorf("daemon returned status: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse daemon response: %w", err)
+	}
+	return nil
+}
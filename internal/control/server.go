@@ -0,0 +1,182 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// Server exposes the daemon control API on a unix socket
+type Server struct {
+	socketPath string
+	socketGID  int
+	mux        *http.ServeMux
+	server     *http.Server
+}
+
+// peerKey is the context key holding the credentials of the connected client
+type peerKey struct{}
+
+// peer is the process at the other end of a connection
+type peer struct {
+	uid      int
+	loginUID int // -1 when the process's session has none
+}
+
+// procDir is where the login UIDs of connecting processes are read
+var procDir = "/proc"
+
+// unsetLoginUID is the login UID of processes outside a login session
+const unsetLoginUID = 4294967295
+
+// NewServer creates a new control API server
+func NewServer(socketPath string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		socketPath: socketPath,
+		socketGID:  -1,
+		mux:        mux,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ConnContext:       withPeer,
+		},
+	}
+}
+
+// SetSocketGroup lets members of the group connect to the socket
+func (s *Server) SetSocketGroup(gid int) {
+	s.socketGID = gid
+}
+
+// HandleFunc registers a handler for a route pattern (e.g. "POST /v1/jobs/{name}/run")
+func (s *Server) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// Start begins serving the API in the background
+func (s *Server) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Remove a stale socket left by a previous daemon
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+
+	mode := os.FileMode(0600)
+	if s.socketGID >= 0 {
+		if err := os.Chown(s.socketPath, -1, s.socketGID); err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to set socket group: %w", err)
+		}
+		mode = 0660
+	}
+	if err := os.Chmod(s.socketPath, mode); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			render.Printf("⚠️  Control API stopped: %v\n", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop shuts the API down and removes the socket
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	os.Remove(s.socketPath)
+	return err
+}
+
+// SocketPath returns the path of the unix socket
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
+// PeerUID returns the UID of the process that sent the request
+func PeerUID(r *http.Request) (int, bool) {
+	p, ok := r.Context().Value(peerKey{}).(peer)
+	return p.uid, ok
+}
+
+// PeerLoginUID returns the login UID of the process that sent the request:
+// the user who logged in to its session, which the kernel keeps through su
+// and sudo and the process cannot change. It is false for processes outside
+// a login session, such as services.
+func PeerLoginUID(r *http.Request) (int, bool) {
+	p, ok := r.Context().Value(peerKey{}).(peer)
+	if !ok || p.loginUID < 0 {
+		return 0, false
+	}
+	return p.loginUID, true
+}
+
+// withPeer records the connecting process's UID using SO_PEERCRED, and its
+// login UID while the process still exists
+func withPeer(ctx context.Context, conn net.Conn) context.Context {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return ctx
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerKey{}, peer{uid: int(cred.Uid), loginUID: loginUID(int(cred.Pid))})
+}
+
+// loginUID reads the login UID of a process, -1 if it has none
+func loginUID(pid int) int {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "loginuid"))
+	if err != nil {
+		return -1
+	}
+	uid, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil || uid == unsetLoginUID {
+		return -1
+	}
+	return int(uid)
+}
+
+// WriteJSON writes a JSON response with the given status code
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes a JSON error response
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,54 @@
+package control
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestPeerLoginUID(t *testing.T) {
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir = "/proc" })
+	pidDir := filepath.Join(procDir, strconv.Itoa(os.Getpid()))
+	if err := os.MkdirAll(pidDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(filepath.Join(t.TempDir(), "control.sock"))
+	server.HandleFunc("GET /v1/peer", func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := PeerUID(r)
+		loginUID, ok := PeerLoginUID(r)
+		if !ok {
+			loginUID = -1
+		}
+		WriteJSON(w, http.StatusOK, map[string]int{"uid": uid, "login_uid": loginUID})
+	})
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	for _, tc := range []struct {
+		loginuid string
+		want     int
+	}{
+		{"1000", 1000},
+		{"4294967295", -1}, // outside a login session
+	} {
+		if err := os.WriteFile(filepath.Join(pidDir, "loginuid"), []byte(tc.loginuid), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]int
+		if err := NewClient(server.SocketPath()).Get("/v1/peer", &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["uid"] != os.Geteuid() {
+			t.Errorf("uid = %d, want %d", got["uid"], os.Geteuid())
+		}
+		if got["login_uid"] != tc.want {
+			t.Errorf("login uid for loginuid %s = %d, want %d", tc.loginuid, got["login_uid"], tc.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package control
+
+import "time"
+
+// Run states reported by the daemon
+const (
+	RunQueued    = "queued"
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// ErrorResponse is returned by the API on failure
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// DaemonStatus describes the running daemon
+type DaemonStatus struct {
+	PID             int           `json:"pid"`
+	Version         string        `json:"version"`
+	LatestVersion   string        `json:"latest_version,omitempty"`
+	UpdateAvailable bool          `json:"update_available"`
+	UpdateCheckedAt time.Time     `json:"update_checked_at,omitempty"`
+	StartedAt       time.Time     `json:"started_at"`
+	ConfigPath      string        `json:"config_path"`
+	Runs            []RunStatus   `json:"runs"`
+	ActiveRuns      int           `json:"active_runs"`
+	Goroutines      int           `json:"goroutines"`
+	Mounts          []MountStatus `json:"mounts,omitempty"`
+}
+
+// MountStatus describes the health of a supervised S3 mount
+type MountStatus struct {
+	Bucket     string    `json:"bucket"`
+	MountPoint string    `json:"mount_point"`
+	Healthy    bool      `json:"healthy"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Remounts   int       `json:"remounts"`
+}
+
+// RunRequest asks the daemon to run a job now
+type RunRequest struct {
+	Trigger string            `json:"trigger"`
+	Comment string            `json:"comment,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	KeepFor string            `json:"keep_for,omitempty"` // e.g. "180d", overriding the job retention
+}
+
+// RunStatus describes a run known to the daemon
+type RunStatus struct {
+	ID         string    `json:"id"`
+	Job        string    `json:"job"`
+	State      string    `json:"state"`
+	Phase      string    `json:"phase"`
+	Trigger    string    `json:"trigger"`
+	QueuedAt   time.Time `json:"queued_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	BackupID   string    `json:"backup_id,omitempty"`
+	TotalSize  int64     `json:"total_size,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Finished reports whether the run has reached a terminal state
+func (r RunStatus) Finished() bool {
+	return r.State == RunSucceeded || r.State == RunFailed || r.State == RunCancelled
+}
+
+// UploadStatus describes a backup waiting in the outbox to be uploaded
+type UploadStatus struct {
+	BackupID    string    `json:"backup_id"`
+	Job         string    `json:"job"`
+	BucketID    string    `json:"bucket_id"`
+	Size        int64     `json:"size"`
+	State       string    `json:"state"` // pending, uploading or failed
+	QueuedAt    time.Time `json:"queued_at"`
+	StartedAt   time.Time `json:"started_at,omitempty"`
+	Attempts    int       `json:"attempts"`
+	LastAttempt time.Time `json:"last_attempt,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// UploadsRequest names the backups a retry or cancel applies to; a retry
+// without names applies to every failed upload
+type UploadsRequest struct {
+	BackupIDs []string `json:"backup_ids,omitempty"`
+}
+
+// ApprovalRequest asks the daemon to approve a restore of a backup, or to
+// redeem the approval token given for one
+type ApprovalRequest struct {
+	Job      string `json:"job"`
+	BackupID string `json:"backup_id"`
+	Token    string `json:"token,omitempty"`
+}
+
+// Approval is an approved restore; the token is only returned when issued
+type Approval struct {
+	Job       string    `json:"job"`
+	BackupID  string    `json:"backup_id"`
+	Token     string    `json:"token,omitempty"`
+	Approver  int       `json:"approver"` // UID of the approving operator
+	ExpiresAt time.Time `json:"expires_at"`
+}
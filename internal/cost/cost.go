@@ -0,0 +1,161 @@
+// Package cost estimates what storing and restoring backups costs with S3
+// providers, from built-in list prices or prices set in the configuration
+package cost
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Price is what a provider charges for a storage class
+type Price struct {
+	StorageGB float64 // per GB stored for a month
+	EgressGB  float64 // per GB downloaded
+	Currency  string
+	// Source tells where the price came from: "list price" or "configured"
+	Source string
+}
+
+// gigabyte is the unit providers bill in
+const gigabyte = 1 << 30
+
+// StorageMonth returns the cost of storing size bytes for a month
+func (p Price) StorageMonth(size int64) float64 {
+	return float64(size) / gigabyte * p.StorageGB
+}
+
+// Egress returns the cost of downloading size bytes
+func (p Price) Egress(size int64) float64 {
+	return float64(size) / gigabyte * p.EgressGB
+}
+
+// Format formats an amount in the price's currency
+func (p Price) Format(amount float64) string {
+	if amount > 0 && amount < 0.01 {
+		return "<" + p.Currency + "0.01"
+	}
+	return fmt.Sprintf("%s%.2f", p.Currency, amount)
+}
+
+// listPrices are the providers' published prices in USD, by provider and
+// storage class; "" is the class of buckets without one. Egress of the
+// infrequent-access and archive classes includes their retrieval fee. Prices
+// change over time, so [[pricing]] entries take precedence.
+var listPrices = map[string]map[string]Price{
+	"aws": {
+		"":                    {StorageGB: 0.023, EgressGB: 0.09},
+		"STANDARD":            {StorageGB: 0.023, EgressGB: 0.09},
+		"INTELLIGENT_TIERING": {StorageGB: 0.023, EgressGB: 0.09},
+		"STANDARD_IA":         {StorageGB: 0.0125, EgressGB: 0.09 + 0.01},
+		"ONEZONE_IA":          {StorageGB: 0.01, EgressGB: 0.09 + 0.01},
+		"GLACIER_IR":          {StorageGB: 0.004, EgressGB: 0.09 + 0.03},
+		"GLACIER":             {StorageGB: 0.0036, EgressGB: 0.09 + 0.01},
+		"DEEP_ARCHIVE":        {StorageGB: 0.00099, EgressGB: 0.09 + 0.02},
+		"REDUCED_REDUNDANCY":  {StorageGB: 0.024, EgressGB: 0.09},
+	},
+	"gcs": {
+		"":         {StorageGB: 0.020, EgressGB: 0.12},
+		"STANDARD": {StorageGB: 0.020, EgressGB: 0.12},
+		"NEARLINE": {StorageGB: 0.010, EgressGB: 0.12 + 0.01},
+		"COLDLINE": {StorageGB: 0.004, EgressGB: 0.12 + 0.02},
+		"ARCHIVE":  {StorageGB: 0.0012, EgressGB: 0.12 + 0.05},
+	},
+	"b2":           {"": {StorageGB: 0.006, EgressGB: 0.01}},
+	"wasabi":       {"": {StorageGB: 0.0069, EgressGB: 0}},
+	"r2":           {"": {StorageGB: 0.015, EgressGB: 0}, "STANDARD_IA": {StorageGB: 0.01, EgressGB: 0.01}},
+	"digitalocean": {"": {StorageGB: 0.02, EgressGB: 0.01}},
+	// Self-hosted storage has no per-GB price
+	"minio": {"": {}},
+}
+
+// providerNames maps words in a bucket's provider or endpoint to the
+// providers of the list prices
+var providerNames = []struct {
+	word, provider string
+}{
+	{"amazonaws.com", "aws"},
+	{"aws", "aws"},
+	{"amazon", "aws"},
+	{"storage.googleapis.com", "gcs"},
+	{"google", "gcs"},
+	{"backblaze", "b2"},
+	{"b2", "b2"},
+	{"wasabi", "wasabi"},
+	{"r2.cloudflarestorage.com", "r2"},
+	{"cloudflare", "r2"},
+	{"digitalocean", "digitalocean"},
+	{"minio", "minio"},
+}
+
+// Provider returns the provider of the list prices that stores a bucket,
+// from its provider name or endpoint, or "" if it is not known
+func Provider(bucket config.BucketConfig) string {
+	if provider := providerOf(bucket.Provider); provider != "" {
+		return provider
+	}
+	// A bucket without an endpoint is on AWS, see the s3fs mount options
+	if bucket.Endpoint == "" {
+		return "aws"
+	}
+	if u, err := url.Parse(bucket.Endpoint); err == nil {
+		return providerOf(u.Host)
+	}
+	return ""
+}
+
+// providerOf returns the provider a provider name or host refers to
+func providerOf(name string) string {
+	name = strings.ToLower(name)
+	for _, known := range providerNames {
+		if strings.Contains(name, known.word) {
+			return known.provider
+		}
+	}
+	return ""
+}
+
+// Lookup returns the price of a bucket's storage: a [[pricing]] entry for
+// its provider and storage class, else one for its provider, else the list
+// price. ok is false when no price is known.
+func Lookup(cfg *config.BackupConfig, bucket config.BucketConfig) (price Price, ok bool) {
+	class := strings.ToUpper(bucket.StorageClass)
+	var general *config.PricingConfig
+	for i, pricing := range cfg.Pricing {
+		provider := providerOf(pricing.Provider)
+		if !strings.EqualFold(pricing.Provider, bucket.Provider) && (provider == "" || provider != Provider(bucket)) {
+			continue
+		}
+		switch {
+		case strings.EqualFold(pricing.StorageClass, class):
+			return configured(pricing), true
+		case pricing.StorageClass == "" && general == nil:
+			general = &cfg.Pricing[i]
+		}
+	}
+	if general != nil {
+		return configured(*general), true
+	}
+
+	prices, found := listPrices[Provider(bucket)]
+	if !found {
+		return Price{}, false
+	}
+	if price, ok = prices[class]; !ok {
+		return Price{}, false
+	}
+	price.Currency = "$"
+	price.Source = "list price"
+	return price, true
+}
+
+// configured returns the price set by a [[pricing]] entry
+func configured(pricing config.PricingConfig) Price {
+	currency := pricing.Currency
+	if currency == "" {
+		currency = "$"
+	}
+	return Price{StorageGB: pricing.StorageGB, EgressGB: pricing.EgressGB, Currency: currency, Source: "configured"}
+}
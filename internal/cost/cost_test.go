@@ -0,0 +1,72 @@
+package cost
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+func TestLookup(t *testing.T) {
+	cfg := &config.BackupConfig{Pricing: []config.PricingConfig{
+		{Provider: "Backblaze", StorageGB: 0.005, EgressGB: 0},
+		{Provider: "Hetzner", StorageGB: 4.99 / 1024, Currency: "€"},
+		{Provider: "aws", StorageClass: "glacier", StorageGB: 0.003, EgressGB: 0.1},
+	}}
+
+	for _, tc := range []struct {
+		name   string
+		bucket config.BucketConfig
+		want   Price
+		ok     bool
+	}{
+		{"aws default", config.BucketConfig{Provider: "AWS S3"}, Price{StorageGB: 0.023, EgressGB: 0.09, Currency: "$", Source: "list price"}, true},
+		{"aws class", config.BucketConfig{Provider: "AWS S3", StorageClass: "standard_ia"}, Price{StorageGB: 0.0125, EgressGB: 0.1, Currency: "$", Source: "list price"}, true},
+		{"configured class", config.BucketConfig{StorageClass: "GLACIER"}, Price{StorageGB: 0.003, EgressGB: 0.1, Currency: "$", Source: "configured"}, true},
+		{"configured provider", config.BucketConfig{Provider: "Backblaze B2", Endpoint: "https://s3.us-west-004.backblazeb2.com"}, Price{StorageGB: 0.005, Currency: "$", Source: "configured"}, true},
+		{"by endpoint", config.BucketConfig{Provider: "S3", Endpoint: "https://s3.eu-central-1.wasabisys.com"}, Price{StorageGB: 0.0069, Currency: "$", Source: "list price"}, true},
+		{"other provider", config.BucketConfig{Provider: "hetzner", Endpoint: "https://fsn1.your-objectstorage.com"}, Price{StorageGB: 4.99 / 1024, Currency: "€", Source: "configured"}, true},
+		{"unknown", config.BucketConfig{Provider: "Garage", Endpoint: "https://garage.internal"}, Price{}, false},
+		{"unknown class", config.BucketConfig{Provider: "Wasabi", StorageClass: "GLACIER"}, Price{}, false},
+	} {
+		got, ok := Lookup(cfg, tc.bucket)
+		if ok != tc.ok || math.Abs(got.StorageGB-tc.want.StorageGB) > 1e-9 || math.Abs(got.EgressGB-tc.want.EgressGB) > 1e-9 ||
+			got.Currency != tc.want.Currency || got.Source != tc.want.Source {
+			t.Errorf("%s: Lookup = %+v, %v, want %+v, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestEstimates(t *testing.T) {
+	cfg := &config.BackupConfig{Buckets: []config.BucketConfig{{ID: "b1", Name: "offsite", Provider: "Wasabi"}}}
+	now := time.Now()
+	catalog := []state.CatalogEntry{
+		{Job: "db", BucketID: "b1", Timestamp: now.Add(-2 * time.Hour), TotalSize: 4 << 30},
+		{Job: "db", BucketID: "b1", Timestamp: now.Add(-time.Hour), TotalSize: 6 << 30},
+		{Job: "db", Timestamp: now, TotalSize: 1 << 30},
+		{Job: "files", BucketID: "gone", Timestamp: now, TotalSize: 1 << 30},
+	}
+
+	estimates := Estimates(cfg, catalog)
+	if len(estimates) != 3 {
+		t.Fatalf("got %d estimates, want 3: %+v", len(estimates), estimates)
+	}
+	offsite := estimates[1]
+	if offsite.Job != "db" || offsite.Storage != "offsite" || offsite.Backups != 2 || offsite.Stored != 10<<30 || offsite.Newest != 6<<30 || !offsite.Priced {
+		t.Errorf("offsite estimate = %+v", offsite)
+	}
+	if got := offsite.StorageMonth(); math.Abs(got-0.069) > 1e-9 {
+		t.Errorf("StorageMonth() = %v, want 0.069", got)
+	}
+	if got := offsite.PerBackupMonth(); math.Abs(got-0.0345) > 1e-9 {
+		t.Errorf("PerBackupMonth() = %v, want 0.0345", got)
+	}
+	if estimates[0].Storage != "local" || estimates[0].Priced {
+		t.Errorf("local estimate = %+v", estimates[0])
+	}
+	if estimates[2].Storage != "gone" || estimates[2].Priced {
+		t.Errorf("estimate of an unconfigured bucket = %+v", estimates[2])
+	}
+}
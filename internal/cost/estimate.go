@@ -0,0 +1,87 @@
+package cost
+
+import (
+	"sort"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// Estimate is what a job's backups in one storage location cost per month
+type Estimate struct {
+	Job string
+	// Storage is the bucket's name, or "local" for the backup path
+	Storage string
+	Backups int
+	Stored  int64
+	// Newest is the size of the newest backup, which a restore downloads
+	Newest int64
+	// Priced is false for local storage and buckets without a known price
+	Priced bool
+	Price  Price
+}
+
+// StorageMonth returns the cost of keeping the stored backups for a month
+func (e Estimate) StorageMonth() float64 {
+	return e.Price.StorageMonth(e.Stored)
+}
+
+// PerBackupMonth returns what keeping one more backup of average size costs
+// per month, to weigh retention settings against
+func (e Estimate) PerBackupMonth() float64 {
+	if e.Backups == 0 {
+		return 0
+	}
+	return e.Price.StorageMonth(e.Stored / int64(e.Backups))
+}
+
+// Restore returns the egress cost of restoring the newest backup
+func (e Estimate) Restore() float64 {
+	return e.Price.Egress(e.Newest)
+}
+
+// Estimates groups the catalog's backups by job and storage location and
+// prices them, ordered by job and storage
+func Estimates(cfg *config.BackupConfig, catalog []state.CatalogEntry) []Estimate {
+	buckets := make(map[string]config.BucketConfig)
+	for _, bucket := range cfg.Buckets {
+		buckets[bucket.ID] = bucket
+	}
+
+	type key struct{ job, bucketID string }
+	byKey := make(map[key]*Estimate)
+	newest := make(map[key]state.CatalogEntry)
+	for _, entry := range catalog {
+		k := key{entry.Job, entry.BucketID}
+		estimate := byKey[k]
+		if estimate == nil {
+			estimate = &Estimate{Job: entry.Job, Storage: "local"}
+			if entry.BucketID != "" {
+				estimate.Storage = entry.BucketID
+				if bucket, ok := buckets[entry.BucketID]; ok {
+					estimate.Storage = bucket.Name
+					estimate.Price, estimate.Priced = Lookup(cfg, bucket)
+				}
+			}
+			byKey[k] = estimate
+		}
+		estimate.Backups++
+		estimate.Stored += entry.TotalSize
+		if entry.Timestamp.After(newest[k].Timestamp) || estimate.Backups == 1 {
+			newest[k] = entry
+			estimate.Newest = entry.TotalSize
+		}
+	}
+
+	estimates := make([]Estimate, 0, len(byKey))
+	for _, estimate := range byKey {
+		estimates = append(estimates, *estimate)
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].Job != estimates[j].Job {
+			return estimates[i].Job < estimates[j].Job
+		}
+		return estimates[i].Storage < estimates[j].Storage
+	})
+	return estimates
+}
@@ -0,0 +1,173 @@
+// Package digest builds and sends the periodic summary of all jobs' runs
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// Digest summarizes the runs of all jobs on a host over a period
+type Digest struct {
+	Host  string       `json:"host"`
+	Since time.Time    `json:"since"`
+	Until time.Time    `json:"until"`
+	Jobs  []JobSummary `json:"jobs"`
+}
+
+// JobSummary is one job's runs in a digest
+type JobSummary struct {
+	Job       string `json:"job"`
+	Runs      int    `json:"runs"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+	// LastError is the error of the newest failed run in the period
+	LastError string `json:"last_error,omitempty"`
+	// LastSuccess is when the newest successful run finished, in or before
+	// the period; zero if the job never succeeded
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	// Size is the size of the newest successful backup in the period
+	Size int64 `json:"size"`
+	// Growth is how much Size grew over the newest success before the period,
+	// or over the first success in it for a new job
+	Growth int64 `json:"growth"`
+	// Stored is the total size of the job's backups in the catalog
+	Stored int64 `json:"stored"`
+}
+
+// Build summarizes the runs of the configured jobs that started in [since, until)
+func Build(cfg *config.BackupConfig, host string, since, until time.Time) (*Digest, error) {
+	history, err := state.LoadHistory(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := state.LoadCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Digest{Host: host, Since: since, Until: until}
+	summaries := make(map[string]*JobSummary)
+	for _, job := range cfg.Jobs {
+		d.Jobs = append(d.Jobs, JobSummary{Job: job.Name})
+	}
+	for i := range d.Jobs {
+		summaries[d.Jobs[i].Job] = &d.Jobs[i]
+	}
+
+	// Newest success before the period, or else the first in it, to measure
+	// growth against
+	baseline := make(map[string]state.HistoryEntry)
+	for _, entry := range history {
+		summary := summaries[entry.Job]
+		if summary == nil || !entry.StartedAt.Before(until) {
+			continue
+		}
+		if entry.State == control.RunSucceeded && entry.FinishedAt.After(summary.LastSuccess) {
+			summary.LastSuccess = entry.FinishedAt
+		}
+		if entry.StartedAt.Before(since) {
+			if entry.State == control.RunSucceeded && entry.FinishedAt.After(baseline[entry.Job].FinishedAt) {
+				baseline[entry.Job] = entry
+			}
+			continue
+		}
+
+		summary.Runs++
+		switch entry.State {
+		case control.RunSucceeded:
+			if _, ok := baseline[entry.Job]; !ok {
+				baseline[entry.Job] = entry
+			}
+			summary.Succeeded++
+			summary.Size = entry.TotalSize
+		case control.RunFailed:
+			summary.Failed++
+			summary.LastError = entry.Error
+		case control.RunCancelled:
+			summary.Cancelled++
+		}
+	}
+	for i := range d.Jobs {
+		summary := &d.Jobs[i]
+		if previous, ok := baseline[summary.Job]; ok && summary.Succeeded > 0 {
+			summary.Growth = summary.Size - previous.TotalSize
+		}
+	}
+
+	for _, entry := range catalog {
+		if summary := summaries[entry.Job]; summary != nil {
+			summary.Stored += entry.TotalSize
+		}
+	}
+	return d, nil
+}
+
+// Totals returns the number of runs, successes and failures of all jobs
+func (d *Digest) Totals() (runs, succeeded, failed int) {
+	for _, job := range d.Jobs {
+		runs += job.Runs
+		succeeded += job.Succeeded
+		failed += job.Failed
+	}
+	return runs, succeeded, failed
+}
+
+// Subject returns the digest's one-line summary, used as the email subject
+func (d *Digest) Subject() string {
+	runs, succeeded, failed := d.Totals()
+	status := "OK"
+	if failed > 0 {
+		status = "FAILURES"
+	}
+	return fmt.Sprintf("[backtide] %s on %s: %d runs, %d succeeded, %d failed", status, d.Host, runs, succeeded, failed)
+}
+
+// Text renders the digest as a plain-text report
+func (d *Digest) Text() string {
+	var b strings.Builder
+	runs, succeeded, failed := d.Totals()
+	fmt.Fprintf(&b, "Backtide digest for %s\n", d.Host)
+	fmt.Fprintf(&b, "Period: %s to %s\n", d.Since.Format(time.RFC1123), d.Until.Format(time.RFC1123))
+	fmt.Fprintf(&b, "Runs: %d, succeeded: %d, failed: %d\n", runs, succeeded, failed)
+
+	jobs := append([]JobSummary(nil), d.Jobs...)
+	// Jobs with failures first, so they are not missed in a long report
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].Failed > 0 && jobs[j].Failed == 0 })
+	for _, job := range jobs {
+		fmt.Fprintf(&b, "\n%s\n", job.Job)
+		if job.Runs == 0 {
+			b.WriteString("  No runs\n")
+		} else {
+			fmt.Fprintf(&b, "  Runs: %d (%d succeeded, %d failed, %d cancelled)\n", job.Runs, job.Succeeded, job.Failed, job.Cancelled)
+		}
+		if job.LastError != "" {
+			fmt.Fprintf(&b, "  Last error: %s\n", job.LastError)
+		}
+		if job.LastSuccess.IsZero() {
+			b.WriteString("  Last success: never\n")
+		} else {
+			fmt.Fprintf(&b, "  Last success: %s ago\n", utils.FormatDuration(d.Until.Sub(job.LastSuccess).Truncate(time.Minute)))
+		}
+		if job.Succeeded > 0 {
+			fmt.Fprintf(&b, "  Backup size: %s (%s)\n", utils.FormatBytes(job.Size), formatGrowth(job.Growth))
+		}
+		fmt.Fprintf(&b, "  Stored: %s\n", utils.FormatBytes(job.Stored))
+	}
+	return b.String()
+}
+
+// formatGrowth formats a size change with its sign
+func formatGrowth(growth int64) string {
+	if growth < 0 {
+		return "-" + utils.FormatBytes(-growth)
+	}
+	return "+" + utils.FormatBytes(growth)
+}
@@ -0,0 +1,86 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/backuptest"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+func TestBuild(t *testing.T) {
+	backuptest.Isolate(t)
+	until := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	since := until.Add(-24 * time.Hour)
+	at := func(d time.Duration) time.Time { return since.Add(d) }
+	for _, entry := range []state.HistoryEntry{
+		{Job: "db", State: control.RunSucceeded, StartedAt: at(-time.Hour), FinishedAt: at(-time.Hour), TotalSize: 1000},
+		{Job: "db", State: control.RunSucceeded, StartedAt: at(time.Hour), FinishedAt: at(time.Hour), TotalSize: 1200},
+		{Job: "db", State: control.RunSucceeded, StartedAt: at(2 * time.Hour), FinishedAt: at(2 * time.Hour), TotalSize: 1500},
+		{Job: "files", State: control.RunFailed, StartedAt: at(3 * time.Hour), FinishedAt: at(3 * time.Hour), Error: "disk full"},
+		{Job: "files", State: control.RunCancelled, StartedAt: at(4 * time.Hour), FinishedAt: at(4 * time.Hour)},
+		{Job: "db", State: control.RunFailed, StartedAt: until, FinishedAt: until, Error: "after the period"},
+		{Job: "removed", State: control.RunSucceeded, StartedAt: at(time.Hour), FinishedAt: at(time.Hour)},
+	} {
+		if err := state.RecordHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, entry := range []state.CatalogEntry{
+		{BackupID: "a", Job: "db", Location: "/backups", TotalSize: 1200},
+		{BackupID: "b", Job: "db", Location: "/backups", TotalSize: 1500},
+	} {
+		if err := state.RecordBackup(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.BackupConfig{Jobs: []config.BackupJob{{Name: "db"}, {Name: "files"}, {Name: "idle"}}}
+	d, err := Build(cfg, "host1", since, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []JobSummary{
+		{Job: "db", Runs: 2, Succeeded: 2, LastSuccess: at(2 * time.Hour), Size: 1500, Growth: 500, Stored: 2700},
+		{Job: "files", Runs: 2, Failed: 1, Cancelled: 1, LastError: "disk full"},
+		{Job: "idle"},
+	}
+	if len(d.Jobs) != len(want) {
+		t.Fatalf("got %d job summaries, want %d: %+v", len(d.Jobs), len(want), d.Jobs)
+	}
+	for i := range want {
+		if d.Jobs[i] != want[i] {
+			t.Errorf("summary %d = %+v, want %+v", i, d.Jobs[i], want[i])
+		}
+	}
+	if got, want := d.Subject(), "[backtide] FAILURES on host1: 4 runs, 2 succeeded, 1 failed"; got != want {
+		t.Errorf("Subject() = %q, want %q", got, want)
+	}
+}
+
+func TestDue(t *testing.T) {
+	daily := config.DigestConfig{Interval: config.DigestDaily, At: "08:00"}
+	weekly := config.DigestConfig{Interval: config.DigestWeekly, Weekday: "Friday"}
+	// A Wednesday
+	now := time.Date(2026, 3, 11, 9, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		cfg      config.DigestConfig
+		lastSent time.Time
+		due      bool
+	}{
+		{daily, now.Add(-2 * time.Hour), true},
+		{daily, now.Add(-time.Hour), false},
+		{daily, now.Add(-25 * time.Hour), true},
+		{weekly, time.Date(2026, 3, 6, 7, 0, 0, 0, time.UTC), true},
+		{weekly, time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC), false},
+		{config.DigestConfig{}, now.Add(-48 * time.Hour), false},
+	} {
+		if got := Due(tc.cfg, tc.lastSent, now); got != tc.due {
+			t.Errorf("Due(%s, %v) = %v, want %v", tc.cfg.Interval, tc.lastSent, got, tc.due)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package digest
+
+import (
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Previous returns the newest time a digest was scheduled at, at or before now
+func Previous(cfg config.DigestConfig, now time.Time) time.Time {
+	hour, minute, _ := cfg.SendTime()
+	slot := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if cfg.Interval == config.DigestWeekly {
+		weekday, _ := cfg.SendWeekday()
+		for slot.Weekday() != weekday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+// Due reports whether a digest is due at now when the last one was sent at lastSent
+func Due(cfg config.DigestConfig, lastSent, now time.Time) bool {
+	return cfg.Enabled() && Previous(cfg, now).After(lastSent)
+}
@@ -0,0 +1,103 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/network"
+)
+
+// Send delivers the digest to the configured email recipients and webhook,
+// returning the errors of the deliveries that failed
+func Send(ctx context.Context, cfg *config.BackupConfig, d *Digest) error {
+	var errs []error
+	if len(cfg.Digest.Email) > 0 {
+		if err := sendEmail(ctx, cfg.Digest, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.Digest.Webhook != "" {
+		if err := sendWebhook(ctx, cfg, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendmailPath returns the local sendmail binary
+func sendmailPath() (string, error) {
+	if path, err := exec.LookPath("sendmail"); err == nil {
+		return path, nil
+	}
+	if _, err := os.Stat("/usr/sbin/sendmail"); err == nil {
+		return "/usr/sbin/sendmail", nil
+	}
+	return "", fmt.Errorf("no sendmail found; install an MTA such as postfix or msmtp-mta to email digests")
+}
+
+// sendEmail pipes the digest to sendmail, which reads recipients from the headers
+func sendEmail(ctx context.Context, cfg config.DigestConfig, d *Digest) error {
+	sendmail, err := sendmailPath()
+	if err != nil {
+		return err
+	}
+	from := cfg.From
+	if from == "" {
+		from = "backtide@" + d.Host
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(cfg.Email, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", d.Subject())
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(d.Text(), "\n", "\r\n"))
+
+	cmd := exec.CommandContext(ctx, sendmail, "-t", "-oi")
+	cmd.Stdin = &message
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to email digest: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// sendWebhook POSTs the digest as JSON
+func sendWebhook(ctx context.Context, cfg *config.BackupConfig, d *Digest) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest: %w", err)
+	}
+	client, err := network.Client(cfg.Network, 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Digest.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create digest request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post digest to %s: %w", cfg.Digest.Webhook, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("digest webhook rejected the digest: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// Labels read when discovering backup targets
+const (
+	// EnableLabel set to "true" on a container or volume opts it into discovery
+	EnableLabel = "backtide.enable"
+	// JobLabel restricts a container or volume to the job with this name or ID
+	JobLabel = "backtide.job"
+	// ExcludeLabel lists container mounts to skip, by destination path or volume name
+	ExcludeLabel = "backtide.exclude"
+)
+
+// unsafeNameChars are replaced in the names of discovered directories
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// DiscoverDirectories returns the directories of containers and volumes
+// labeled backtide.enable=true that belong to job: the volumes and bind
+// mounts of each container, except those listed in backtide.exclude, and
+// labeled volumes themselves. Paths the job already backs up are left out.
+func DiscoverDirectories(ctx context.Context, job config.BackupJob) ([]config.DirectoryConfig, error) {
+	compression := true
+	if len(job.Directories) > 0 {
+		compression = job.Directories[0].Compression
+	}
+
+	seen := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, dir := range job.Directories {
+		seen[dir.Path] = true
+		names[dir.Name] = true
+	}
+	var dirs []config.DirectoryConfig
+	add := func(name, path string) {
+		if path == "" || seen[path] {
+			return
+		}
+		seen[path] = true
+		name = strings.Trim(unsafeNameChars.ReplaceAllString(name, "-"), "-.")
+		if name == "" {
+			name = "docker"
+		}
+		for base, i := name, 2; names[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		names[name] = true
+		dirs = append(dirs, config.DirectoryConfig{Path: path, Name: name, Compression: compression})
+		render.Printf("🔍 Discovered %s: %s\n", name, path)
+	}
+
+	// Containers, whether running or not
+	lines, err := dockerLines(ctx, "ps", "--all", "--filter", "label="+EnableLabel+"=true",
+		"--format", `{{.ID}}|{{.Names}}|{{.Label "`+JobLabel+`"}}|{{.Label "`+ExcludeLabel+`"}}`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labeled containers: %w", err)
+	}
+	for _, line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) != 4 || !forJob(parts[2], job) {
+			continue
+		}
+		name, exclude := parts[1], splitList(parts[3])
+
+		mounts, err := dockerLines(ctx, "inspect", "--format",
+			`{{range .Mounts}}{{.Type}}|{{.Name}}|{{.Source}}|{{.Destination}}{{"\n"}}{{end}}`, parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container %s: %w", name, err)
+		}
+		for _, mount := range mounts {
+			fields := strings.Split(mount, "|")
+			if len(fields) != 4 || (fields[0] != "volume" && fields[0] != "bind") {
+				continue
+			}
+			volume, source, destination := fields[1], fields[2], fields[3]
+			if slices.Contains(exclude, destination) || (volume != "" && slices.Contains(exclude, volume)) {
+				continue
+			}
+			add(name+"/"+destination, source)
+		}
+	}
+
+	// Volumes labeled themselves, e.g. in the volumes section of a Compose file
+	lines, err = dockerLines(ctx, "volume", "ls", "--filter", "label="+EnableLabel+"=true",
+		"--format", `{{.Name}}|{{.Label "`+JobLabel+`"}}`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labeled volumes: %w", err)
+	}
+	for _, line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) != 2 || !forJob(parts[1], job) {
+			continue
+		}
+		mountpoint, err := dockerLines(ctx, "volume", "inspect", "--format", "{{.Mountpoint}}", parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect volume %s: %w", parts[0], err)
+		}
+		if len(mountpoint) == 0 {
+			continue
+		}
+		add("volume-"+parts[0], mountpoint[0])
+	}
+	return dirs, nil
+}
+
+// forJob reports whether a backtide.job label value selects job; an empty
+// value selects every job that discovers
+func forJob(label string, job config.BackupJob) bool {
+	label = strings.TrimSpace(label)
+	return label == "" || label == job.Name || (job.ID != "" && label == job.ID)
+}
+
+// splitList splits a comma-separated label value
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// dockerLines runs a docker command and returns its non-empty output lines
+func dockerLines(ctx context.Context, args ...string) ([]string, error) {
+	listed, err := output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(listed), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
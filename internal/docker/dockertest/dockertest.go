@@ -0,0 +1,314 @@
+// Package dockertest provides an in-memory container runtime that stands in
+// for Docker in tests. It understands the docker commands backtide runs to
+// list, stop, start, pause and update containers and to run exec hooks.
+package dockertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mitexleo/backtide/internal/docker"
+)
+
+// Container is a container of the fake runtime
+type Container struct {
+	ID    string
+	Name  string
+	Image string
+	// Running and Paused describe the state of the container
+	Running bool
+	Paused  bool
+	// RestartPolicy is given as to docker update, e.g. "always" or
+	// "on-failure:3"; empty means no
+	RestartPolicy string
+	Labels        map[string]string
+}
+
+// Runtime is a fake container runtime; the zero value has no containers
+type Runtime struct {
+	// Unavailable makes every command fail as if the Docker daemon were down
+	Unavailable bool
+	// OnExec is called for docker exec with the container name and command
+	OnExec func(container, command string) error
+	// OnStop is called for docker stop with the container name, e.g. to
+	// change files the way a container shutting down would
+	OnStop func(container string)
+
+	mu         sync.Mutex
+	containers []*Container
+	calls      []string
+	failures   map[string]bool
+}
+
+// New returns a runtime with the given containers
+func New(containers ...Container) *Runtime {
+	r := &Runtime{}
+	for _, container := range containers {
+		r.containers = append(r.containers, &container)
+	}
+	return r
+}
+
+// Install sends backtide's docker commands to r until the test ends
+func (r *Runtime) Install(t testing.TB) {
+	t.Helper()
+	previous := docker.SetRuntime(r)
+	t.Cleanup(func() { docker.SetRuntime(previous) })
+}
+
+// FailOn makes a command fail for a container, e.g. FailOn("stop", "db")
+func (r *Runtime) FailOn(command, container string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures == nil {
+		r.failures = make(map[string]bool)
+	}
+	r.failures[command+" "+container] = true
+}
+
+// Calls returns the commands run so far, e.g. "stop --time 10 c1"
+func (r *Runtime) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// Container returns the current state of a container by name
+func (r *Runtime) Container(name string) (Container, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c := r.find(name); c != nil {
+		return *c, true
+	}
+	return Container{}, false
+}
+
+// find returns a container by ID or name
+func (r *Runtime) find(ref string) *Container {
+	for _, c := range r.containers {
+		if c.ID == ref || c.Name == ref {
+			return c
+		}
+	}
+	return nil
+}
+
+// Run implements docker.Runtime
+func (r *Runtime) Run(ctx context.Context, stdout, stderr io.Writer, args ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, strings.Join(args, " "))
+
+	fail := func(format string, a ...any) error {
+		fmt.Fprintf(stderr, "Error: "+format+"\n", a...)
+		return errors.New("exit status 1")
+	}
+	if r.Unavailable {
+		return fail("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?")
+	}
+	if len(args) == 0 {
+		return fail("no command")
+	}
+	if len(args) > 1 {
+		if c := r.find(args[len(args)-1]); c != nil && (r.failures[args[0]+" "+c.Name] || r.failures[args[0]+" "+c.ID]) {
+			return fail("%s failed for %s", args[0], c.Name)
+		}
+	}
+	// The container a command acts on is its last argument
+	target := func() (*Container, error) {
+		if len(args) < 2 {
+			return nil, fail("%s needs a container", args[0])
+		}
+		c := r.find(args[len(args)-1])
+		if c == nil {
+			return nil, fail("No such container: %s", args[len(args)-1])
+		}
+		return c, nil
+	}
+
+	switch args[0] {
+	case "info":
+		fmt.Fprintln(stdout, "Server Version: dockertest")
+		return nil
+
+	case "ps":
+		all, format, filters := false, "{{.ID}}", map[string]string{}
+		for i := 1; i < len(args); i++ {
+			switch args[i] {
+			case "--all", "-a":
+				all = true
+			case "--format":
+				i++
+				format = args[i]
+			case "--filter":
+				i++
+				key, value, _ := strings.Cut(strings.TrimPrefix(args[i], "label="), "=")
+				filters[key] = value
+			default:
+				return fail("unsupported ps argument %s", args[i])
+			}
+		}
+		for _, c := range r.containers {
+			if !c.Running && !all || !hasLabels(c, filters) {
+				continue
+			}
+			line, err := render(format, c)
+			if err != nil {
+				return fail("%v", err)
+			}
+			fmt.Fprintln(stdout, line)
+		}
+		return nil
+
+	case "inspect":
+		if len(args) < 4 || args[1] != "--format" {
+			return fail("unsupported inspect arguments")
+		}
+		for _, ref := range args[3:] {
+			c := r.find(ref)
+			if c == nil {
+				return fail("No such object: %s", ref)
+			}
+			line, err := render(args[2], c)
+			if err != nil {
+				return fail("%v", err)
+			}
+			fmt.Fprintln(stdout, line)
+		}
+		return nil
+
+	case "stop":
+		c, err := target()
+		if err != nil {
+			return err
+		}
+		c.Running, c.Paused = false, false
+		if r.OnStop != nil {
+			r.OnStop(c.Name)
+		}
+		fmt.Fprintln(stdout, c.ID)
+		return nil
+
+	case "start":
+		c, err := target()
+		if err != nil {
+			return err
+		}
+		c.Running = true
+		fmt.Fprintln(stdout, c.ID)
+		return nil
+
+	case "pause":
+		c, err := target()
+		if err != nil {
+			return err
+		}
+		if !c.Running {
+			return fail("container %s is not running", c.ID)
+		}
+		c.Paused = true
+		fmt.Fprintln(stdout, c.ID)
+		return nil
+
+	case "update":
+		c, err := target()
+		if err != nil {
+			return err
+		}
+		policy, ok := strings.CutPrefix(args[1], "--restart=")
+		if !ok {
+			return fail("unsupported update argument %s", args[1])
+		}
+		if policy == "no" {
+			policy = ""
+		}
+		c.RestartPolicy = policy
+		fmt.Fprintln(stdout, c.ID)
+		return nil
+
+	case "exec":
+		// exec <container> sh -c <command>
+		if len(args) != 5 {
+			return fail("unsupported exec arguments")
+		}
+		c := r.find(args[1])
+		if c == nil {
+			return fail("No such container: %s", args[1])
+		}
+		if !c.Running {
+			return fail("container %s is not running", c.ID)
+		}
+		if r.OnExec != nil {
+			if err := r.OnExec(c.Name, args[4]); err != nil {
+				return fail("%v", err)
+			}
+		}
+		return nil
+	}
+	return fail("dockertest does not support docker %s", args[0])
+}
+
+// hasLabels reports whether a container carries the labels, with the given
+// values unless empty
+func hasLabels(c *Container, labels map[string]string) bool {
+	for key, value := range labels {
+		got, ok := c.Labels[key]
+		if !ok || value != "" && got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// field matches the template actions docker formats support, e.g. {{.Names}}
+// or {{.Label "com.docker.compose.service"}}
+var field = regexp.MustCompile(`\{\{\s*(\.[A-Za-z.]+)(?:\s+"([^"]*)")?\s*\}\}`)
+
+// render fills a docker --format template for a container
+func render(format string, c *Container) (string, error) {
+	var err error
+	out := field.ReplaceAllStringFunc(format, func(action string) string {
+		m := field.FindStringSubmatch(action)
+		policy, retries, _ := strings.Cut(c.RestartPolicy, ":")
+		switch m[1] {
+		case ".ID":
+			return c.ID
+		case ".Names", ".Name":
+			return c.Name
+		case ".Image":
+			return c.Image
+		case ".Status":
+			switch {
+			case !c.Running:
+				return "Exited (0) 1 minute ago"
+			case c.Paused:
+				return "Up 1 hour (Paused)"
+			}
+			return "Up 1 hour"
+		case ".Label":
+			return c.Labels[m[2]]
+		case ".HostConfig.RestartPolicy.Name":
+			if policy == "" {
+				return "no"
+			}
+			return policy
+		case ".HostConfig.RestartPolicy.MaximumRetryCount":
+			if retries == "" {
+				return "0"
+			}
+			return retries
+		}
+		err = fmt.Errorf("dockertest does not support %s in formats", action)
+		return ""
+	})
+	if err == nil && strings.Contains(out, "{{") {
+		err = fmt.Errorf("dockertest does not support the format %s", format)
+	}
+	return out, err
+}
@@ -0,0 +1,32 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// execErrorLines is how much of a hook command's error output is kept
+const execErrorLines = 20
+
+// Exec runs a hook command with sh inside a running container, e.g. to flush
+// or lock a database so its files can be copied without stopping it
+func Exec(ctx context.Context, hook config.ContainerExec) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.TimeoutDuration())
+	defer cancel()
+
+	// Keep the end of the command's error output for failure notifications
+	stderr := utils.NewTail(execErrorLines)
+	args := []string{"exec", hook.Container, "sh", "-c", hook.Cmd}
+	if err := run(ctx, os.Stdout, io.MultiWriter(os.Stderr, stderr), args...); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("command in container %s timed out after %s", hook.Container, hook.TimeoutDuration())
+		}
+		return fmt.Errorf("command in container %s failed: %w", hook.Container, &CommandError{Args: args, Stderr: stderr.Lines(), Err: err})
+	}
+	return nil
+}
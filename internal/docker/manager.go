@@ -2,26 +2,34 @@ package docker
 
 import (
 	"bufio"
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
 )
 
 // DockerManager handles Docker container operations
 type DockerManager struct {
-	stateFile string
+	// runID and job key the record of stopped containers, so concurrent
+	// runs do not overwrite each other's stop lists
+	runID string
+	job   string
+
+	config config.DockerConfig
 }
 
-// NewDockerManager creates a new Docker manager instance
-func NewDockerManager(stateFile string) *DockerManager {
+// NewDockerManager creates a Docker manager for a backup run
+func NewDockerManager(runID, job string, cfg config.DockerConfig) *DockerManager {
 	return &DockerManager{
-		stateFile: stateFile,
+		runID:  runID,
+		job:    job,
+		config: cfg,
 	}
 }
 
@@ -33,90 +41,168 @@ func (dm *DockerManager) StopContainers() ([]config.DockerContainerInfo, error)
 	}
 
 	if len(containers) == 0 {
-		fmt.Println("No running containers found to stop")
+		render.Println("No running containers found to stop")
+		// Containers stopped by other runs in progress stay stopped until this run ends too
+		if _, err := state.HoldContainers(dm.runID, dm.job, nil); err != nil {
+			return nil, fmt.Errorf("failed to save container state: %w", err)
+		}
 		return []config.DockerContainerInfo{}, nil
 	}
 
-	fmt.Printf("Found %d running containers\n", len(containers))
+	render.Printf("Found %d running containers\n", len(containers))
 
 	var stoppedContainers []config.DockerContainerInfo
 	var failedContainers []string
+	var mu sync.Mutex
 	currentTime := time.Now()
 
+	// Containers are stopped before the containers they depend on
+	byID := make(map[string]config.DockerContainerInfo, len(containers))
+	var ids []string
 	for _, container := range containers {
-		fmt.Printf("Attempting to stop container: %s (%s) - Status: %s\n",
-			container.Name, container.ID[:12], container.Status)
-
-		// Stop the container
-		cmd := exec.Command("docker", "stop", container.ID)
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Warning: Failed to stop container %s: %v\n", container.Name, err)
-			failedContainers = append(failedContainers, container.Name)
-			continue
-		}
+		byID[container.ID] = container
+		ids = append(ids, container.ID)
+	}
+	waves := dependencyWaves(ids, func(id string) []string { return byID[id].DependsOn })
+	for i := len(waves) - 1; i >= 0; i-- {
+		runParallel(waves[i], dm.config.Workers(), func(id string) {
+			container := byID[id]
+			render.Printf("Attempting to stop container: %s (%s) - Status: %s\n",
+				container.Name, shortID(container.ID), container.Status)
+
+			// Keep Docker from restarting the container while the backup runs
+			if container.RestartPolicy != "" {
+				if err := setRestartPolicy(container.ID, "no"); err != nil {
+					render.Printf("Warning: Failed to disable restart policy of %s: %v\n", container.Name, err)
+				}
+			}
 
-		// Update container status and timestamp
-		container.Status = "stopped"
-		container.Stopped = currentTime
-		stoppedContainers = append(stoppedContainers, container)
+			if _, err := output(context.Background(), dm.stopArgs(container.ID)...); err != nil {
+				render.Printf("Warning: Failed to stop container %s: %v\n", container.Name, err)
+				if container.RestartPolicy != "" {
+					if err := setRestartPolicy(container.ID, container.RestartPolicy); err != nil {
+						render.Printf("Warning: Failed to restore restart policy of %s: %v\n", container.Name, err)
+					}
+				}
+				mu.Lock()
+				failedContainers = append(failedContainers, container.Name)
+				mu.Unlock()
+				return
+			}
+
+			// Update container status and timestamp
+			container.Status = "stopped"
+			container.Stopped = currentTime
+			mu.Lock()
+			stoppedContainers = append(stoppedContainers, container)
+			mu.Unlock()
 
-		fmt.Printf("✅ Successfully stopped container: %s (%s)\n", container.Name, container.ID[:12])
+			render.Printf("✅ Successfully stopped container: %s (%s)\n", container.Name, shortID(container.ID))
+		})
 	}
 
-	// Save stopped containers to state file even if some failed
-	if len(stoppedContainers) > 0 {
-		if err := dm.saveStoppedContainers(stoppedContainers); err != nil {
-			return stoppedContainers, fmt.Errorf("failed to save container state: %w", err)
-		}
+	// Record stopped containers even if some failed
+	if _, err := state.HoldContainers(dm.runID, dm.job, toStoppedContainers(stoppedContainers)); err != nil {
+		return stoppedContainers, fmt.Errorf("failed to save container state: %w", err)
 	}
 
 	// Report results
 	if len(failedContainers) > 0 {
-		fmt.Printf("Warning: Failed to stop %d containers: %s\n",
+		render.Printf("Warning: Failed to stop %d containers: %s\n",
 			len(failedContainers), strings.Join(failedContainers, ", "))
 	}
 
-	fmt.Printf("✅ Successfully stopped %d out of %d containers\n",
+	render.Printf("✅ Successfully stopped %d out of %d containers\n",
 		len(stoppedContainers), len(containers))
 
 	return stoppedContainers, nil
 }
 
-// RestoreContainers restores previously stopped containers
+// RestoreContainers restarts the containers held by this run, and those left
+// stopped by crashed runs, unless another run in progress still needs them stopped
 func (dm *DockerManager) RestoreContainers() error {
-	stoppedContainers, err := dm.loadStoppedContainers()
+	stoppedContainers, err := state.ReleaseContainers(dm.runID)
 	if err != nil {
 		return fmt.Errorf("failed to load container state: %w", err)
 	}
 
 	if len(stoppedContainers) == 0 {
-		fmt.Println("No containers to restore")
+		render.Println("No containers to restore")
 		return nil
 	}
 
-	fmt.Printf("Attempting to restore %d containers\n", len(stoppedContainers))
+	render.Printf("Attempting to restore %d containers\n", len(stoppedContainers))
+	return dm.startContainers(stoppedContainers)
+}
+
+// RecoverOrphaned restarts the containers left stopped by runs that exited
+// without restarting them at least olderThan ago, and returns the released holds
+func (dm *DockerManager) RecoverOrphaned(olderThan time.Duration) ([]state.ContainerHold, error) {
+	// Keep the holds until Docker can actually start the containers again
+	if err := dm.CheckDockerAvailable(); err != nil {
+		return nil, err
+	}
 
+	released, containers, err := state.ReleaseOrphanedHolds(olderThan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load container state: %w", err)
+	}
+	if len(containers) == 0 {
+		return released, nil
+	}
+
+	render.Printf("Attempting to restore %d orphaned containers\n", len(containers))
+	return released, dm.startContainers(containers)
+}
+
+// startContainers starts stopped containers after the containers they depend
+// on, reporting those that fail to start
+func (dm *DockerManager) startContainers(containers []state.StoppedContainer) error {
 	var restoredCount int
 	var failedContainers []string
+	var mu sync.Mutex
 
-	for _, container := range stoppedContainers {
-		fmt.Printf("Attempting to start container: %s (%s)\n", container.Name, container.ID[:12])
-
-		// Start the container
-		cmd := exec.Command("docker", "start", container.ID)
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Warning: Failed to start container %s: %v\n", container.Name, err)
-			failedContainers = append(failedContainers, container.Name)
-			continue
+	byID := make(map[string]state.StoppedContainer, len(containers))
+	nameToID := make(map[string]string, len(containers))
+	var ids []string
+	for _, container := range containers {
+		byID[container.ID] = container
+		nameToID[container.Name] = container.ID
+		ids = append(ids, container.ID)
+	}
+	dependsOn := func(id string) []string {
+		deps := byID[id].DependsOn
+		// Stop lists recorded without dependencies still follow the configured ones
+		for _, name := range dm.config.DependsOn[byID[id].Name] {
+			if depID, ok := nameToID[name]; ok {
+				deps = append(deps, depID)
+			}
 		}
-
-		fmt.Printf("✅ Successfully restarted container: %s (%s)\n", container.Name, container.ID[:12])
-		restoredCount++
+		return deps
 	}
 
-	// Clear the state file after restoration attempt
-	if err := dm.clearStoppedContainers(); err != nil {
-		fmt.Printf("Warning: Failed to clear container state: %v\n", err)
+	for _, wave := range dependencyWaves(ids, dependsOn) {
+		runParallel(wave, dm.config.Workers(), func(id string) {
+			container := byID[id]
+			render.Printf("Attempting to start container: %s (%s)\n", container.Name, shortID(container.ID))
+
+			if err := restoreContainerState(container); err != nil {
+				render.Printf("Warning: Failed to start container %s: %v\n", container.Name, err)
+				mu.Lock()
+				failedContainers = append(failedContainers, container.Name)
+				mu.Unlock()
+				return
+			}
+
+			if container.Paused {
+				render.Printf("✅ Successfully restarted and paused container: %s (%s)\n", container.Name, shortID(container.ID))
+			} else {
+				render.Printf("✅ Successfully restarted container: %s (%s)\n", container.Name, shortID(container.ID))
+			}
+			mu.Lock()
+			restoredCount++
+			mu.Unlock()
+		})
 	}
 
 	// Report results
@@ -125,15 +211,106 @@ func (dm *DockerManager) RestoreContainers() error {
 			len(failedContainers), strings.Join(failedContainers, ", "))
 	}
 
-	fmt.Printf("✅ Successfully restored %d containers\n", restoredCount)
+	render.Printf("✅ Successfully restored %d containers\n", restoredCount)
+	return nil
+}
+
+// restoreContainerState returns a stopped container to the state recorded
+// before the backup: its restart policy, running and paused
+func restoreContainerState(container state.StoppedContainer) error {
+	if container.RestartPolicy != "" {
+		if err := setRestartPolicy(container.ID, container.RestartPolicy); err != nil {
+			return fmt.Errorf("failed to restore restart policy %s: %w", container.RestartPolicy, err)
+		}
+	}
+	if _, err := output(context.Background(), "start", container.ID); err != nil {
+		return err
+	}
+	if container.Paused {
+		if _, err := output(context.Background(), "pause", container.ID); err != nil {
+			return fmt.Errorf("started but failed to pause: %w", err)
+		}
+	}
 	return nil
 }
 
-// GetStoppedContainers returns the list of currently stopped containers
+// setRestartPolicy changes the restart policy of a container
+func setRestartPolicy(id, policy string) error {
+	_, err := output(context.Background(), "update", "--restart="+policy, id)
+	return err
+}
+
+// restartPolicies returns the restart policies of containers other than "no",
+// in the form accepted by docker update
+func restartPolicies(ids []string) (map[string]string, error) {
+	args := append([]string{"inspect", "--format", "{{.HostConfig.RestartPolicy.Name}}|{{.HostConfig.RestartPolicy.MaximumRetryCount}}"}, ids...)
+	inspected, err := output(context.Background(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect containers: %w", err)
+	}
+
+	// docker inspect prints one line per container in argument order
+	lines := strings.Split(strings.TrimSpace(string(inspected)), "\n")
+	if len(lines) != len(ids) {
+		return nil, fmt.Errorf("unexpected docker inspect output")
+	}
+	policies := make(map[string]string)
+	for i, line := range lines {
+		name, retries, _ := strings.Cut(strings.TrimSpace(line), "|")
+		switch {
+		case name == "" || name == "no":
+		case name == "on-failure" && retries != "" && retries != "0":
+			policies[ids[i]] = name + ":" + retries
+		default:
+			policies[ids[i]] = name
+		}
+	}
+	return policies, nil
+}
+
+// stopArgs returns the docker arguments stopping a container, with the
+// configured grace period
+func (dm *DockerManager) stopArgs(id string) []string {
+	if timeout, err := time.ParseDuration(dm.config.StopTimeout); err == nil && timeout >= 0 {
+		return []string{"stop", "--time", fmt.Sprint(int(timeout.Seconds())), id}
+	}
+	return []string{"stop", id}
+}
+
+// shortID returns the abbreviated form of a container ID
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// GetStoppedContainers returns the containers held stopped by this run
 func (dm *DockerManager) GetStoppedContainers() ([]config.DockerContainerInfo, error) {
-	return dm.loadStoppedContainers()
+	holds, err := state.ContainerHolds()
+	if err != nil {
+		return nil, err
+	}
+	for _, hold := range holds {
+		if hold.RunID == dm.runID {
+			var containers []config.DockerContainerInfo
+			for _, container := range hold.Containers {
+				containers = append(containers, config.DockerContainerInfo{
+					ID: container.ID, Name: container.Name, Image: container.Image, Status: "stopped", Stopped: container.Stopped,
+					DependsOn: container.DependsOn, Paused: container.Paused, RestartPolicy: container.RestartPolicy,
+				})
+			}
+			return containers, nil
+		}
+	}
+	return []config.DockerContainerInfo{}, nil
 }
 
+// PsFormat is the docker ps format running containers are listed with: ID,
+// name, image, status and the Compose project, service and depends_on labels
+const PsFormat = "{{.ID}}|{{.Names}}|{{.Image}}|{{.Status}}|" +
+	"{{.Label \"" + composeProjectLabel + "\"}}|{{.Label \"" + composeServiceLabel + "\"}}|{{.Label \"" + composeDependsOnLabel + "\"}}"
+
 // GetRunningContainers returns the list of currently running containers (for testing)
 func (dm *DockerManager) GetRunningContainers() ([]config.DockerContainerInfo, error) {
 	return dm.getRunningContainers()
@@ -143,25 +320,21 @@ func (dm *DockerManager) GetRunningContainers() ([]config.DockerContainerInfo, e
 func (dm *DockerManager) getRunningContainers() ([]config.DockerContainerInfo, error) {
 	// Use docker ps without status filter to get all containers that are not stopped/exited
 	// This includes running, restarting, paused, and other active states
-	cmd := exec.Command("docker", "ps", "--format", "{{.ID}}|{{.Names}}|{{.Image}}|{{.Status}}")
-
-	output, err := cmd.Output()
+	listed, err := output(context.Background(), "ps", "--format", PsFormat)
 	if err != nil {
 		// Check if Docker is available
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			stderr := string(exitErr.Stderr)
-			if strings.Contains(stderr, "permission denied") {
-				return nil, fmt.Errorf("docker permission denied - try running with sudo or add user to docker group")
-			}
-			if strings.Contains(stderr, "Cannot connect") {
-				return nil, fmt.Errorf("docker daemon not running - start docker service first")
-			}
+		if strings.Contains(err.Error(), "permission denied") {
+			return nil, fmt.Errorf("docker permission denied - try running with sudo or add user to docker group")
+		}
+		if strings.Contains(err.Error(), "Cannot connect") {
+			return nil, fmt.Errorf("docker daemon not running - start docker service first")
 		}
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
 
 	var containers []config.DockerContainerInfo
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	labels := make(map[string]map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(listed)))
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -170,8 +343,8 @@ func (dm *DockerManager) getRunningContainers() ([]config.DockerContainerInfo, e
 		}
 
 		parts := strings.Split(line, "|")
-		if len(parts) != 4 {
-			fmt.Printf("Warning: Skipping malformed container line: %s\n", line)
+		if len(parts) != 7 {
+			render.Printf("Warning: Skipping malformed container line: %s\n", line)
 			continue
 		}
 
@@ -181,12 +354,18 @@ func (dm *DockerManager) getRunningContainers() ([]config.DockerContainerInfo, e
 			Image:  strings.TrimSpace(parts[2]),
 			Status: strings.TrimSpace(parts[3]),
 		}
+		container.Paused = strings.HasSuffix(container.Status, "(Paused)")
 
 		// Skip containers that are already stopped or exited
 		if strings.Contains(strings.ToLower(container.Status), "exited") {
 			continue
 		}
 
+		labels[container.ID] = map[string]string{
+			composeProjectLabel:   strings.TrimSpace(parts[4]),
+			composeServiceLabel:   strings.TrimSpace(parts[5]),
+			composeDependsOnLabel: strings.TrimSpace(parts[6]),
+		}
 		containers = append(containers, container)
 	}
 
@@ -194,74 +373,56 @@ func (dm *DockerManager) getRunningContainers() ([]config.DockerContainerInfo, e
 		return nil, fmt.Errorf("error scanning container output: %w", err)
 	}
 
-	return containers, nil
-}
-
-// saveStoppedContainers saves stopped containers to the state file
-func (dm *DockerManager) saveStoppedContainers(containers []config.DockerContainerInfo) error {
-	data, err := json.MarshalIndent(containers, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal container data: %w", err)
-	}
-
-	// Ensure directory exists
-	dir := dm.getStateFileDir()
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create state directory: %w", err)
-	}
-
-	// Write to temporary file first, then rename for atomic operation
-	tempFile := dm.stateFile + ".tmp"
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write temporary state file: %w", err)
-	}
-
-	if err := os.Rename(tempFile, dm.stateFile); err != nil {
-		// Clean up temp file if rename fails
-		os.Remove(tempFile)
-		return fmt.Errorf("failed to rename state file: %w", err)
+	// Resolve dependencies from Compose labels and the configuration to container IDs
+	nameToID := make(map[string]string, len(containers))
+	for _, container := range containers {
+		nameToID[container.Name] = container.ID
 	}
-
-	return nil
-}
-
-// loadStoppedContainers loads stopped containers from the state file
-func (dm *DockerManager) loadStoppedContainers() ([]config.DockerContainerInfo, error) {
-	data, err := os.ReadFile(dm.stateFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []config.DockerContainerInfo{}, nil
+	for i, container := range containers {
+		deps := composeDependencies(container.ID, labels)
+		for _, name := range dm.config.DependsOn[container.Name] {
+			if id, ok := nameToID[name]; ok {
+				deps = append(deps, id)
+			}
 		}
-		return nil, fmt.Errorf("failed to read state file: %w", err)
+		containers[i].DependsOn = deps
 	}
 
-	var containers []config.DockerContainerInfo
-	if err := json.Unmarshal(data, &containers); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal container data: %w", err)
+	// Without the restart policies, containers are still stopped but may be restarted by Docker
+	if len(containers) > 0 {
+		ids := make([]string, len(containers))
+		for i, container := range containers {
+			ids[i] = container.ID
+		}
+		policies, err := restartPolicies(ids)
+		if err != nil {
+			render.Printf("Warning: Failed to read container restart policies: %v\n", err)
+		}
+		for i, container := range containers {
+			containers[i].RestartPolicy = policies[container.ID]
+		}
 	}
 
 	return containers, nil
 }
 
-// clearStoppedContainers clears the stopped containers state file
-func (dm *DockerManager) clearStoppedContainers() error {
-	if err := os.Remove(dm.stateFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove state file: %w", err)
+// toStoppedContainers converts container info into the recorded form
+func toStoppedContainers(containers []config.DockerContainerInfo) []state.StoppedContainer {
+	var stopped []state.StoppedContainer
+	for _, container := range containers {
+		stopped = append(stopped, state.StoppedContainer{
+			ID: container.ID, Name: container.Name, Image: container.Image, Stopped: container.Stopped,
+			DependsOn: container.DependsOn, Paused: container.Paused, RestartPolicy: container.RestartPolicy,
+		})
 	}
-	return nil
-}
-
-// getStateFileDir returns the directory containing the state file
-func (dm *DockerManager) getStateFileDir() string {
-	return filepath.Dir(dm.stateFile)
+	return stopped
 }
 
 // CheckDockerAvailable checks if Docker is available and running
 func (dm *DockerManager) CheckDockerAvailable() error {
-	cmd := exec.Command("docker", "info")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		errorMsg := string(output)
+	var combined bytes.Buffer
+	if err := run(context.Background(), &combined, &combined, "info"); err != nil {
+		errorMsg := combined.String()
 		if strings.Contains(errorMsg, "permission denied") {
 			return fmt.Errorf("docker permission denied - try running with sudo or add user to docker group")
 		}
@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// Compose labels describing which services a container depends on
+const (
+	composeProjectLabel   = "com.docker.compose.project"
+	composeServiceLabel   = "com.docker.compose.service"
+	composeDependsOnLabel = "com.docker.compose.depends_on"
+)
+
+// dependencyWaves groups container IDs so that every container comes in a later
+// wave than the containers it depends on. Dependencies outside ids are ignored;
+// containers in a dependency cycle are placed together in the last wave.
+func dependencyWaves(ids []string, dependsOn func(id string) []string) [][]string {
+	remaining := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remaining[id] = true
+	}
+
+	var waves [][]string
+	for len(remaining) > 0 {
+		var wave []string
+		for _, id := range ids {
+			if !remaining[id] {
+				continue
+			}
+			ready := true
+			for _, dep := range dependsOn(id) {
+				if dep != id && remaining[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, id)
+			}
+		}
+
+		if len(wave) == 0 {
+			// A cycle cannot be ordered; handle what is left at once
+			for _, id := range ids {
+				if remaining[id] {
+					wave = append(wave, id)
+				}
+			}
+			render.Printf("Warning: Container dependencies form a cycle, ignoring order for %d containers\n", len(wave))
+		}
+		for _, id := range wave {
+			delete(remaining, id)
+		}
+		waves = append(waves, wave)
+	}
+	return waves
+}
+
+// runParallel calls fn for every ID with at most workers calls at a time
+func runParallel(ids []string, workers int, fn func(id string)) {
+	if workers < 1 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		slots <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-slots }()
+			fn(id)
+		})
+	}
+	wg.Wait()
+}
+
+// composeDependencies returns the IDs of the containers a container depends on
+// through its Compose depends_on label, given the labels of all containers
+func composeDependencies(id string, labels map[string]map[string]string) []string {
+	own := labels[id]
+	if own[composeDependsOnLabel] == "" {
+		return nil
+	}
+
+	// The label lists services as "service:condition:restart"
+	needed := make(map[string]bool)
+	for _, entry := range strings.Split(own[composeDependsOnLabel], ",") {
+		if service, _, _ := strings.Cut(strings.TrimSpace(entry), ":"); service != "" {
+			needed[service] = true
+		}
+	}
+
+	var deps []string
+	for other, otherLabels := range labels {
+		if other != id && otherLabels[composeProjectLabel] == own[composeProjectLabel] && needed[otherLabels[composeServiceLabel]] {
+			deps = append(deps, other)
+		}
+	}
+	return deps
+}
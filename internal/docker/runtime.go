@@ -0,0 +1,103 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/mitexleo/backtide/internal/fault"
+)
+
+// Runtime runs docker commands. Backtide talks to Docker only through the
+// docker CLI, so a fake runtime that understands the same commands stands in
+// for Docker in tests.
+type Runtime interface {
+	// Run runs docker with args, writing its standard output to stdout and
+	// its standard error to stderr
+	Run(ctx context.Context, stdout, stderr io.Writer, args ...string) error
+}
+
+// cliRuntime runs the docker binary
+type cliRuntime struct{}
+
+// Run implements Runtime
+func (cliRuntime) Run(ctx context.Context, stdout, stderr io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+var (
+	runtimeMu sync.RWMutex
+	current   Runtime = cliRuntime{}
+)
+
+// SetRuntime replaces the runtime docker commands are sent to and returns the
+// previous one; nil restores the docker CLI
+func SetRuntime(r Runtime) Runtime {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	previous := current
+	if r == nil {
+		r = cliRuntime{}
+	}
+	current = r
+	return previous
+}
+
+// run runs a docker command, passing its output to stdout and stderr
+func run(ctx context.Context, stdout, stderr io.Writer, args ...string) error {
+	fault.Delay(ctx, fault.Docker)
+	runtimeMu.RLock()
+	r := current
+	runtimeMu.RUnlock()
+	return r.Run(ctx, stdout, stderr, args...)
+}
+
+// output runs a docker command and returns its standard output; errors are a
+// *CommandError carrying its standard error
+func output(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	if err := run(ctx, &stdout, &stderr, args...); err != nil {
+		var lines []string
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			lines = strings.Split(message, "\n")
+		}
+		return nil, &CommandError{Args: args, Stderr: lines, Err: err}
+	}
+	return stdout.Bytes(), nil
+}
+
+// CommandError is a failed docker command with the end of its standard error,
+// which failure notifications pass on
+type CommandError struct {
+	Args   []string
+	Stderr []string
+	Err    error
+}
+
+// Error returns the error with the last line of standard error
+func (e *CommandError) Error() string {
+	if len(e.Stderr) > 0 {
+		return fmt.Sprintf("%v: %s", e.Err, e.Stderr[len(e.Stderr)-1])
+	}
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Lines returns the docker subcommand and its standard error, for failure
+// reports; the arguments are left out as hook commands may hold credentials
+func (e *CommandError) Lines() []string {
+	if len(e.Stderr) == 0 {
+		return nil
+	}
+	return append([]string{"docker " + e.Args[0] + ":"}, e.Stderr...)
+}
@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ScratchMountPoint is where RunScratch mounts the restored data in the container
+const ScratchMountPoint = "/restore"
+
+// RunScratch starts a throwaway container of image with dir mounted at
+// ScratchMountPoint and waits for it to exit. The terminal is attached when
+// interactive, so the data can be inspected from a shell; the container is
+// removed afterwards.
+func RunScratch(image, dir string, interactive bool) error {
+	args := []string{"run", "--rm", "-v", dir + ":" + ScratchMountPoint, "-w", ScratchMountPoint}
+	if interactive {
+		args = append(args, "-it")
+	}
+	args = append(args, image)
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("container %s failed: %w", image, err)
+	}
+	return nil
+}
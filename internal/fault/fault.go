@@ -0,0 +1,209 @@
+// Package fault injects failures and delays at points where backups meet the
+// outside world, so retries, container recovery and cleanup after failed runs
+// can be exercised in CI and staging. It does nothing unless enabled with the
+// hidden --fault-inject flag or BACKTIDE_FAULT_INJECT.
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Env enables fault injection with the same spec as --fault-inject; archive
+// workers and plugins started by a run inherit it
+const Env = "BACKTIDE_FAULT_INJECT"
+
+// Points where faults are injected
+const (
+	// Upload fails writing archives to their storage and storage plugins
+	Upload = "upload"
+	// Compress cuts archives off partway through writing them
+	Compress = "compress"
+	// Docker delays docker commands
+	Docker = "docker"
+)
+
+// Points lists the injection points
+var Points = []string{Upload, Compress, Docker}
+
+// defaultRate is how often a point named without a rate fails
+const defaultRate = 0.5
+
+// defaultDelay is the longest delay of docker commands unless set with delay=
+const defaultDelay = 5 * time.Second
+
+// ErrInjected is the error of every injected failure
+var ErrInjected = errors.New("injected fault")
+
+var (
+	mu       sync.Mutex
+	rates    map[string]float64
+	maxDelay time.Duration
+	rng      *rand.Rand
+)
+
+// Configure enables fault injection from a comma-separated spec of points with
+// optional rates between 0 and 1, e.g. "upload=0.2,compress,docker". "all"
+// enables every point, delay= sets the longest docker delay and seed= makes
+// the faults repeatable. An empty spec disables fault injection.
+func Configure(spec string) error {
+	parsed := make(map[string]float64)
+	delay := defaultDelay
+	seed := time.Now().UnixNano()
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(item, "=")
+		switch key {
+		case "delay":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid fault delay %q: must be a positive duration such as 2s", value)
+			}
+			delay = d
+			continue
+		case "seed":
+			s, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid fault seed %q: must be an integer", value)
+			}
+			seed = s
+			continue
+		}
+
+		rate := defaultRate
+		if hasValue {
+			r, err := strconv.ParseFloat(value, 64)
+			if err != nil || r < 0 || r > 1 {
+				return fmt.Errorf("invalid fault rate %q for %s: must be between 0 and 1", value, key)
+			}
+			rate = r
+		}
+		switch {
+		case key == "all":
+			for _, point := range Points {
+				parsed[point] = rate
+			}
+		case isPoint(key):
+			parsed[key] = rate
+		default:
+			return fmt.Errorf("unknown fault point %q: use %s or all", key, strings.Join(Points, ", "))
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	rates, maxDelay, rng = nil, 0, nil
+	if len(parsed) > 0 {
+		rates, maxDelay, rng = parsed, delay, rand.New(rand.NewSource(seed))
+	}
+	return nil
+}
+
+// isPoint reports whether name is an injection point
+func isPoint(name string) bool {
+	for _, point := range Points {
+		if point == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether any fault is injected
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return rates != nil
+}
+
+// Describe returns the enabled points and their rates, e.g. "compress=0.5, upload=0.2"
+func Describe() string {
+	mu.Lock()
+	defer mu.Unlock()
+	var parts []string
+	for point, rate := range rates {
+		parts = append(parts, fmt.Sprintf("%s=%g", point, rate))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// hit reports whether a fault occurs at point this time
+func hit(point string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	rate, ok := rates[point]
+	return ok && rng.Float64() < rate
+}
+
+// Fail returns ErrInjected at the rate configured for point, and nil otherwise
+func Fail(point string) error {
+	if hit(point) {
+		return fmt.Errorf("%s: %w", point, ErrInjected)
+	}
+	return nil
+}
+
+// Delay waits up to the configured delay at the rate configured for point, or
+// until ctx is done
+func Delay(ctx context.Context, point string) {
+	if !hit(point) {
+		return
+	}
+	mu.Lock()
+	delay := time.Duration(rng.Int63n(int64(maxDelay))) + 1
+	mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// Writer returns w, which at the rate configured for point fails after
+// writing a random number of bytes of up to 64 KiB
+func Writer(point string, w io.Writer) io.Writer {
+	if !hit(point) {
+		return w
+	}
+	mu.Lock()
+	limit := rng.Int63n(64 << 10)
+	mu.Unlock()
+	return &failingWriter{w: w, remaining: limit, point: point}
+}
+
+// failingWriter passes remaining bytes on to w and then fails
+type failingWriter struct {
+	w         io.Writer
+	remaining int64
+	point     string
+}
+
+// Write implements io.Writer
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= f.remaining {
+		n, err := f.w.Write(p)
+		f.remaining -= int64(n)
+		return n, err
+	}
+	n, err := f.w.Write(p[:f.remaining])
+	f.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, fmt.Errorf("%s: %w", f.point, ErrInjected)
+}
@@ -0,0 +1,69 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { Configure("") })
+
+	for _, tc := range []struct {
+		spec string
+		want string
+	}{
+		{"", ""},
+		{"upload", "upload=0.5"},
+		{"upload=0.2,compress=1", "compress=1, upload=0.2"},
+		{"all=0.1,delay=1s,seed=3", "compress=0.1, docker=0.1, upload=0.1"},
+	} {
+		if err := Configure(tc.spec); err != nil {
+			t.Errorf("Configure(%q) failed: %v", tc.spec, err)
+			continue
+		}
+		if got := Describe(); got != tc.want {
+			t.Errorf("Configure(%q) enabled %q, want %q", tc.spec, got, tc.want)
+		}
+		if Enabled() != (tc.want != "") {
+			t.Errorf("Configure(%q): Enabled() = %v", tc.spec, Enabled())
+		}
+	}
+
+	for _, spec := range []string{"disk", "upload=2", "upload=often", "delay=soon", "delay=-1s", "seed=x"} {
+		if err := Configure(spec); err == nil {
+			t.Errorf("Configure(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestInjectedFaults(t *testing.T) {
+	t.Cleanup(func() { Configure("") })
+
+	// Nothing is injected unless configured
+	if err := Fail(Upload); err != nil {
+		t.Errorf("Fail without configuration returned %v", err)
+	}
+
+	if err := Configure("upload=1,compress=1,docker=1,delay=1ms,seed=1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Fail(Upload); !errors.Is(err, ErrInjected) {
+		t.Errorf("Fail(upload) at rate 1 returned %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := Writer(Compress, &buf)
+	n, err := w.Write(make([]byte, 128<<10))
+	if !errors.Is(err, ErrInjected) {
+		t.Errorf("Writer at rate 1 returned %v after %d bytes", err, n)
+	}
+	if n != buf.Len() {
+		t.Errorf("Writer reported %d bytes written but passed on %d", n, buf.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Delay(ctx, Docker)
+}
@@ -0,0 +1,165 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/control"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// maxReportSize bounds the body of a report accepted by the collector
+const maxReportSize = 64 << 10
+
+// Status is the latest known state of one job on one host
+type Status struct {
+	Host      string `json:"host"`
+	MachineID string `json:"machine_id,omitempty"`
+	Job       string `json:"job"`
+	Last      Report `json:"last"`
+	// LastSuccess is when the job last succeeded, zero if it never did
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	// Failures counts failed runs since the last success
+	Failures   int       `json:"failures"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// statusFile returns the path of the collector's status file
+func statusFile() string {
+	return filepath.Join(state.Dir(), "fleet.json")
+}
+
+// LoadStatus returns the status of all reporting hosts and jobs, sorted by host and job
+func LoadStatus() ([]Status, error) {
+	data, err := os.ReadFile(statusFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet status: %w", err)
+	}
+
+	var statuses []Status
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to parse fleet status: %w", err)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Host != statuses[j].Host {
+			return statuses[i].Host < statuses[j].Host
+		}
+		return statuses[i].Job < statuses[j].Job
+	})
+	return statuses, nil
+}
+
+// Collector receives signed reports and keeps the latest status of each host and job
+type Collector struct {
+	secret   string
+	mu       sync.Mutex
+	statuses map[string]*Status
+}
+
+// NewCollector creates a collector accepting reports signed with secret
+func NewCollector(secret string) (*Collector, error) {
+	statuses, err := LoadStatus()
+	if err != nil {
+		return nil, err
+	}
+	c := &Collector{secret: secret, statuses: make(map[string]*Status)}
+	for i := range statuses {
+		c.statuses[statuses[i].Host+"/"+statuses[i].Job] = &statuses[i]
+	}
+	return c, nil
+}
+
+// Handler returns the collector's HTTP handler
+func (c *Collector) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/reports", c.handleReport)
+	mux.HandleFunc("GET /metrics", c.handleMetrics)
+	return mux
+}
+
+// handleReport verifies and records a report
+func (c *Collector) handleReport(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReportSize))
+	if err != nil {
+		http.Error(w, "failed to read report", http.StatusBadRequest)
+		return
+	}
+	if !Verify(body, r.Header.Get(SignatureHeader), c.secret) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var report Report
+	if err := json.Unmarshal(body, &report); err != nil || report.Host == "" || report.Job == "" {
+		http.Error(w, "invalid report", http.StatusBadRequest)
+		return
+	}
+	// Refuse replays of old reports
+	if skew := time.Since(report.SentAt); skew > maxClockSkew || skew < -maxClockSkew {
+		http.Error(w, "report timestamp outside allowed clock skew", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.record(report); err != nil {
+		render.Printf("⚠️  %v\n", err)
+		http.Error(w, "failed to store report", http.StatusInternalServerError)
+		return
+	}
+	render.Printf("📥 %s/%s: %s\n", report.Host, report.Job, report.State)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// record updates the status of the report's host and job and saves all statuses
+func (c *Collector) record(report Report) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := report.Host + "/" + report.Job
+	status, ok := c.statuses[key]
+	if !ok {
+		status = &Status{Host: report.Host, Job: report.Job}
+		c.statuses[key] = status
+	}
+	status.MachineID = report.MachineID
+	status.Last = report
+	status.ReceivedAt = time.Now()
+	if report.State == control.RunSucceeded {
+		status.LastSuccess = report.FinishedAt
+		status.Failures = 0
+	} else if report.State == control.RunFailed {
+		status.Failures++
+	}
+
+	statuses := make([]Status, 0, len(c.statuses))
+	for _, s := range c.statuses {
+		statuses = append(statuses, *s)
+	}
+	data, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fleet status: %w", err)
+	}
+
+	if err := os.MkdirAll(state.Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tempFile := statusFile() + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fleet status: %w", err)
+	}
+	if err := os.Rename(tempFile, statusFile()); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename fleet status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package fleet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// MaxAge returns the freshness SLO the host last reported for the job, or 0
+// without one
+func (s Status) MaxAge() time.Duration {
+	if d, err := utils.ParseDuration(s.Last.MaxAge); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Overdue reports whether the job's last success is older than maxAge at now;
+// a job that never succeeded is overdue
+func (s Status) Overdue(maxAge time.Duration, now time.Time) bool {
+	return s.LastSuccess.IsZero() || now.Sub(s.LastSuccess) > maxAge
+}
+
+// writeFreshnessMetrics writes when each job last succeeded and, for jobs with
+// a max_age, whether it is overdue
+func writeFreshnessMetrics(w io.Writer, statuses []Status, now time.Time) {
+	sorted := append([]Status(nil), statuses...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Host != sorted[j].Host {
+			return sorted[i].Host < sorted[j].Host
+		}
+		return sorted[i].Job < sorted[j].Job
+	})
+
+	fmt.Fprintln(w, "# HELP backtide_job_last_success_timestamp_seconds When each job last succeeded on each host.")
+	fmt.Fprintln(w, "# TYPE backtide_job_last_success_timestamp_seconds gauge")
+	for _, s := range sorted {
+		if !s.LastSuccess.IsZero() {
+			fmt.Fprintf(w, "backtide_job_last_success_timestamp_seconds{host=%s,job=%s} %d\n",
+				metricLabel(s.Host), metricLabel(s.Job), s.LastSuccess.Unix())
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP backtide_job_max_age_seconds Freshness SLO of each job with a max_age.")
+	fmt.Fprintln(w, "# TYPE backtide_job_max_age_seconds gauge")
+	for _, s := range sorted {
+		if maxAge := s.MaxAge(); maxAge > 0 {
+			fmt.Fprintf(w, "backtide_job_max_age_seconds{host=%s,job=%s} %g\n",
+				metricLabel(s.Host), metricLabel(s.Job), maxAge.Seconds())
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP backtide_job_overdue Whether a job's last success is older than its max_age.")
+	fmt.Fprintln(w, "# TYPE backtide_job_overdue gauge")
+	for _, s := range sorted {
+		if maxAge := s.MaxAge(); maxAge > 0 {
+			value := 0
+			if s.Overdue(maxAge, now) {
+				value = 1
+			}
+			fmt.Fprintf(w, "backtide_job_overdue{host=%s,job=%s} %d\n", metricLabel(s.Host), metricLabel(s.Job), value)
+		}
+	}
+}
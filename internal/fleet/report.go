@@ -0,0 +1,84 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, as "sha256=<hex>"
+const SignatureHeader = "X-Backtide-Signature"
+
+// maxClockSkew is how far a report's send time may differ from the collector's clock
+const maxClockSkew = 5 * time.Minute
+
+// Report summarises one backup run on one host
+type Report struct {
+	Host      string `json:"host"`
+	MachineID string `json:"machine_id,omitempty"`
+	Version   string `json:"version,omitempty"`
+	// LatestVersion is the newest release the host has seen, if it checks for updates
+	LatestVersion string    `json:"latest_version,omitempty"`
+	RunID         string    `json:"run_id"`
+	Job           string    `json:"job"`
+	State         string    `json:"state"` // succeeded, failed or cancelled
+	Trigger       string    `json:"trigger,omitempty"`
+	BackupID      string    `json:"backup_id,omitempty"`
+	TotalSize     int64     `json:"total_size,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	SentAt        time.Time `json:"sent_at"`
+	// MaxAge is the job's freshness SLO, e.g. "26h", if it has one
+	MaxAge string `json:"max_age,omitempty"`
+}
+
+// Sign returns the signature header value for body
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body
+func Verify(body []byte, signature, secret string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(body, secret)))
+}
+
+// Send signs a report and POSTs it to the collector at url using client
+func Send(ctx context.Context, client *http.Client, url, secret string, report Report) error {
+	report.SentAt = time.Now().UTC()
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(body, secret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send report to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector rejected report: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
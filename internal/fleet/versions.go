@@ -0,0 +1,118 @@
+package fleet
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/utils"
+)
+
+// HostVersion is the backtide version a host last reported
+type HostVersion struct {
+	Host          string    `json:"host"`
+	Version       string    `json:"version"`
+	LatestVersion string    `json:"latest_version,omitempty"`
+	ReportedAt    time.Time `json:"reported_at"`
+	// Lagging is set when a newer release is known, from the host itself or
+	// from any other host in the fleet
+	Lagging bool `json:"lagging"`
+}
+
+// HostVersions returns the version of each host, from its most recent report,
+// and the newest release known across the fleet
+func HostVersions(statuses []Status) ([]HostVersion, string) {
+	byHost := make(map[string]*HostVersion)
+	for _, s := range statuses {
+		hv, ok := byHost[s.Host]
+		if ok && !s.ReceivedAt.After(hv.ReportedAt) {
+			continue
+		}
+		if !ok {
+			hv = &HostVersion{Host: s.Host}
+			byHost[s.Host] = hv
+		}
+		hv.Version = s.Last.Version
+		hv.LatestVersion = s.Last.LatestVersion
+		hv.ReportedAt = s.ReceivedAt
+	}
+
+	newest := ""
+	for _, hv := range byHost {
+		for _, v := range []string{hv.Version, hv.LatestVersion} {
+			if utils.IsReleaseVersion(v) && (newest == "" || utils.CompareVersions(v, newest) > 0) {
+				newest = v
+			}
+		}
+	}
+
+	hosts := make([]HostVersion, 0, len(byHost))
+	for _, hv := range byHost {
+		// Development builds cannot be compared and are never reported as lagging
+		hv.Lagging = newest != "" && utils.IsReleaseVersion(hv.Version) && utils.CompareVersions(hv.Version, newest) < 0
+		hosts = append(hosts, *hv)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Host < hosts[j].Host
+	})
+	return hosts, newest
+}
+
+// handleMetrics exposes host versions and job freshness in the Prometheus text format
+func (c *Collector) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	statuses := make([]Status, 0, len(c.statuses))
+	for _, s := range c.statuses {
+		statuses = append(statuses, *s)
+	}
+	c.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	WriteMetrics(w, statuses)
+}
+
+// WriteMetrics writes the version and freshness metrics of the fleet in the
+// Prometheus text format
+func WriteMetrics(w io.Writer, statuses []Status) {
+	hosts, newest := HostVersions(statuses)
+
+	fmt.Fprintln(w, "# HELP backtide_host_info Backtide version last reported by each host.")
+	fmt.Fprintln(w, "# TYPE backtide_host_info gauge")
+	for _, hv := range hosts {
+		fmt.Fprintf(w, "backtide_host_info{host=%s,version=%s,latest_version=%s} 1\n",
+			metricLabel(hv.Host), metricLabel(hv.Version), metricLabel(hv.LatestVersion))
+	}
+
+	fmt.Fprintln(w, "# HELP backtide_update_available Whether a newer backtide release than the host's is known.")
+	fmt.Fprintln(w, "# TYPE backtide_update_available gauge")
+	for _, hv := range hosts {
+		value := 0
+		if hv.Lagging {
+			value = 1
+		}
+		fmt.Fprintf(w, "backtide_update_available{host=%s} %d\n", metricLabel(hv.Host), value)
+	}
+
+	fmt.Fprintln(w, "# HELP backtide_host_last_report_timestamp_seconds When each host last reported a run.")
+	fmt.Fprintln(w, "# TYPE backtide_host_last_report_timestamp_seconds gauge")
+	for _, hv := range hosts {
+		fmt.Fprintf(w, "backtide_host_last_report_timestamp_seconds{host=%s} %d\n", metricLabel(hv.Host), hv.ReportedAt.Unix())
+	}
+
+	if newest != "" {
+		fmt.Fprintln(w, "# HELP backtide_latest_version_info Newest backtide release known across the fleet.")
+		fmt.Fprintln(w, "# TYPE backtide_latest_version_info gauge")
+		fmt.Fprintf(w, "backtide_latest_version_info{version=%s} 1\n", metricLabel(newest))
+	}
+
+	writeFreshnessMetrics(w, statuses, time.Now())
+}
+
+// metricLabel quotes a Prometheus label value
+func metricLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
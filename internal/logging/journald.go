@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// journalSocket is the socket of the systemd journal's native protocol
+const journalSocket = "/run/systemd/journal/socket"
+
+// writeJournald sends a record to the systemd journal with each field as a
+// BACKTIDE_* journal field, so records can be filtered with e.g. journalctl BACKTIDE_JOB=daily
+func writeJournald(cfg config.LoggingConfig, record Record) error {
+	var data bytes.Buffer
+	writeJournalField(&data, "MESSAGE", record.Message)
+	writeJournalField(&data, "PRIORITY", strconv.Itoa(int(record.Priority)))
+	writeJournalField(&data, "SYSLOG_IDENTIFIER", cfg.Identifier())
+	writeJournalField(&data, "BACKTIDE_EVENT", record.Event)
+	for _, key := range sortedKeys(record.Fields) {
+		writeJournalField(&data, "BACKTIDE_"+journalFieldName(key), record.Fields[key])
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(data.Bytes())
+	return err
+}
+
+// writeJournalField appends a field, using the length-prefixed form for multi-line values
+func writeJournalField(data *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		data.WriteString(name + "=" + value + "\n")
+		return
+	}
+	data.WriteString(name + "\n")
+	binary.Write(data, binary.LittleEndian, uint64(len(value)))
+	data.WriteString(value + "\n")
+}
+
+// journalFieldName converts a field key to a valid journal field name
+func journalFieldName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// Priority is a syslog severity level
+type Priority int
+
+// Priorities used for backtide events
+const (
+	PriorityErr     Priority = 3
+	PriorityWarning Priority = 4
+	PriorityNotice  Priority = 5
+	PriorityInfo    Priority = 6
+)
+
+// Record is a structured log record
+type Record struct {
+	Priority Priority
+	Event    string // machine-readable event name, e.g. backup.failed
+	Message  string
+	// Fields are extra key/value pairs such as job, run_id and backup_id
+	Fields map[string]string
+}
+
+// Emit sends a record to the configured sink; it does nothing when no sink is
+// configured and only warns on failure, so logging never breaks a backup
+func Emit(cfg config.LoggingConfig, record Record) {
+	var err error
+	switch cfg.Sink {
+	case "":
+		return
+	case "syslog":
+		err = writeSyslog(cfg, record)
+	case "journald":
+		err = writeJournald(cfg, record)
+	default:
+		err = fmt.Errorf("unknown logging sink: %s", cfg.Sink)
+	}
+	if err != nil {
+		render.Printf("Warning: Failed to write to %s: %v\n", cfg.Sink, err)
+	}
+}
+
+// sortedKeys returns the field names of a record in a stable order
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// logfmt renders the event and fields as key=value pairs, quoting values with spaces
+func logfmt(record Record) string {
+	pairs := []string{"event=" + quote(record.Event)}
+	for _, key := range sortedKeys(record.Fields) {
+		pairs = append(pairs, key+"="+quote(record.Fields[key]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// quote quotes a logfmt value when needed
+func quote(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// syslogFacilities maps facility names to syslog facilities
+var syslogFacilities = map[string]syslog.Priority{
+	"daemon": syslog.LOG_DAEMON,
+	"user":   syslog.LOG_USER,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// syslogFacility returns the syslog facility for a name, defaulting to daemon
+func syslogFacility(name string) (syslog.Priority, error) {
+	if name == "" {
+		return syslog.LOG_DAEMON, nil
+	}
+	facility, ok := syslogFacilities[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility: %s", name)
+	}
+	return facility, nil
+}
+
+// writeSyslog sends a record to the local syslog daemon, or to cfg.Address
+// ("udp://host:514" or "tcp://host:514"), with the fields appended as key=value pairs
+func writeSyslog(cfg config.LoggingConfig, record Record) error {
+	facility, err := syslogFacility(cfg.Facility)
+	if err != nil {
+		return err
+	}
+
+	network, address := "", ""
+	if cfg.Address != "" {
+		var ok bool
+		network, address, ok = strings.Cut(cfg.Address, "://")
+		if !ok {
+			return fmt.Errorf("invalid syslog address %s, expected udp://host:port or tcp://host:port", cfg.Address)
+		}
+	}
+
+	writer, err := syslog.Dial(network, address, facility|syslog.Priority(record.Priority), cfg.Identifier())
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	message := record.Message + " " + logfmt(record)
+	switch record.Priority {
+	case PriorityErr:
+		return writer.Err(message)
+	case PriorityWarning:
+		return writer.Warning(message)
+	case PriorityNotice:
+		return writer.Notice(message)
+	default:
+		return writer.Info(message)
+	}
+}
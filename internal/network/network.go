@@ -0,0 +1,102 @@
+// Package network builds the HTTP clients used for outbound requests, applying
+// the configured proxy and CA bundle
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Transport returns an HTTP transport using the proxy and CA settings; without
+// a configured proxy, HTTPS_PROXY, HTTP_PROXY and NO_PROXY are honored
+func Transport(cfg config.NetworkConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network proxy %q: %w", cfg.Proxy, err)
+		}
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL.Hostname(), cfg.NoProxy) {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+	}
+
+	if cfg.CABundle != "" {
+		pool, err := CertPool(cfg, "")
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
+
+// Client returns an HTTP client using the network settings
+func Client(cfg config.NetworkConfig, timeout time.Duration) (*http.Client, error) {
+	transport, err := Transport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// CertPool returns the system roots plus the configured CA bundle and extra,
+// a further PEM file such as the CA of a single endpoint
+func CertPool(cfg config.NetworkConfig, extra string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, file := range []string{cfg.CABundle, extra} {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", file)
+		}
+	}
+	return pool, nil
+}
+
+// bypassProxy reports whether host is reached directly according to a
+// NO_PROXY style list of hosts, domains, IP addresses and "*"
+func bypassProxy(host, noProxy string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip := net.ParseIP(host); ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
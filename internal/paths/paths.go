@@ -0,0 +1,156 @@
+// Package paths resolves where backtide keeps its configuration, credentials,
+// state, logs, temporary files and mounts: the system locations when running
+// as root and the XDG base directories otherwise.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// System locations used when running as root
+const (
+	SystemConfigDir = "/etc/backtide"
+	SystemStateDir  = "/var/lib/backtide"
+	SystemLogDir    = "/var/log/backtide"
+	SystemLogFile   = "/var/log/backtide.log"
+	SystemTempDir   = "/tmp/backtide"
+	SystemMountDir  = "/mnt"
+)
+
+// Resolver resolves backtide's directories for one user
+type Resolver struct {
+	Root   bool
+	Home   string
+	Getenv func(string) string
+}
+
+// New returns a resolver for the current user
+func New() *Resolver {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return &Resolver{Root: os.Geteuid() == 0, Home: home, Getenv: os.Getenv}
+}
+
+// xdg returns the XDG base directory in env, or fallback below the home
+// directory when it is unset or relative, as the specification requires
+func (r *Resolver) xdg(env, fallback string) string {
+	if dir := r.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(r.Home, fallback)
+}
+
+// ConfigDir returns the directory holding the configuration
+func (r *Resolver) ConfigDir() string {
+	if r.Root {
+		return SystemConfigDir
+	}
+	return filepath.Join(r.xdg("XDG_CONFIG_HOME", ".config"), "backtide")
+}
+
+// ConfigFile returns the default configuration file
+func (r *Resolver) ConfigFile() string {
+	return filepath.Join(r.ConfigDir(), "config.toml")
+}
+
+// ProfileDir returns the directory profiles are created in
+func (r *Resolver) ProfileDir() string {
+	return filepath.Join(r.ConfigDir(), "profiles")
+}
+
+// CredentialsDir returns the directory holding s3fs credential files
+func (r *Resolver) CredentialsDir() string {
+	return filepath.Join(r.ConfigDir(), "s3-credentials")
+}
+
+// CredentialsFile returns the s3fs credential file of a bucket
+func (r *Resolver) CredentialsFile(bucketID string) string {
+	return filepath.Join(r.CredentialsDir(), fmt.Sprintf("passwd-s3fs-%s", bucketID))
+}
+
+// StateDir returns the directory holding runs, catalogs, history and other
+// state. Users who already have the pre-XDG ~/.backtide keep using it.
+func (r *Resolver) StateDir() string {
+	if r.Root {
+		return SystemStateDir
+	}
+	legacy := filepath.Join(r.Home, ".backtide")
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return legacy
+	}
+	return filepath.Join(r.xdg("XDG_DATA_HOME", ".local/share"), "backtide")
+}
+
+// LogDir returns the directory for log files
+func (r *Resolver) LogDir() string {
+	if r.Root {
+		return SystemLogDir
+	}
+	return filepath.Join(r.xdg("XDG_STATE_HOME", ".local/state"), "backtide")
+}
+
+// LogFile returns the file scheduled runs append their output to
+func (r *Resolver) LogFile() string {
+	if r.Root {
+		return SystemLogFile
+	}
+	return filepath.Join(r.LogDir(), "backtide.log")
+}
+
+// TempDir returns the default directory for temporary files
+func (r *Resolver) TempDir() string {
+	if r.Root {
+		return SystemTempDir
+	}
+	if dir := r.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "backtide")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("backtide-%d", os.Geteuid()))
+}
+
+// MountDir returns the directory bucket mount points are created below by default
+func (r *Resolver) MountDir() string {
+	if r.Root {
+		return SystemMountDir
+	}
+	return filepath.Join(r.xdg("XDG_DATA_HOME", ".local/share"), "backtide", "mnt")
+}
+
+// Dirs returns the directories 'init' creates
+func (r *Resolver) Dirs() []string {
+	return []string{r.ConfigDir(), r.CredentialsDir(), r.StateDir(), r.LogDir(), r.TempDir()}
+}
+
+// ConfigDir returns the configuration directory of the current user
+func ConfigDir() string { return New().ConfigDir() }
+
+// ConfigFile returns the default configuration file of the current user
+func ConfigFile() string { return New().ConfigFile() }
+
+// ProfileDir returns the profile directory of the current user
+func ProfileDir() string { return New().ProfileDir() }
+
+// CredentialsDir returns the credentials directory of the current user
+func CredentialsDir() string { return New().CredentialsDir() }
+
+// CredentialsFile returns the s3fs credential file of a bucket for the current user
+func CredentialsFile(bucketID string) string { return New().CredentialsFile(bucketID) }
+
+// StateDir returns the state directory of the current user
+func StateDir() string { return New().StateDir() }
+
+// LogDir returns the log directory of the current user
+func LogDir() string { return New().LogDir() }
+
+// LogFile returns the scheduled-run log file of the current user
+func LogFile() string { return New().LogFile() }
+
+// TempDir returns the temporary directory of the current user
+func TempDir() string { return New().TempDir() }
+
+// MountDir returns the default mount directory of the current user
+func MountDir() string { return New().MountDir() }
@@ -0,0 +1,176 @@
+// Package prompt asks questions on the terminal, with defaults, validation
+// of the answers and support for unattended use.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoInput is returned when an answer is needed but stdin has none left,
+// e.g. because it is not a terminal
+var ErrNoInput = errors.New("no answer available on stdin; pass the value as a flag or use --yes to accept defaults")
+
+// Prompter reads answers to questions
+type Prompter struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+
+	// AssumeYes answers confirmations with yes and every other question
+	// with its default, without reading input
+	AssumeYes bool
+}
+
+// New returns a prompter reading answers from in and writing questions to out.
+// Invalid answers are asked again when in is a terminal and are an error otherwise.
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out, interactive: isTerminal(in)}
+}
+
+// Stdio returns a prompter using stdin and stdout
+func Stdio() *Prompter {
+	return New(os.Stdin, os.Stdout)
+}
+
+// IsInteractive reports whether answers are read from a terminal
+func (p *Prompter) IsInteractive() bool {
+	return p.interactive
+}
+
+// Printf writes a message between questions
+func (p *Prompter) Printf(format string, args ...any) {
+	fmt.Fprintf(p.out, format, args...)
+}
+
+// Println writes a line between questions
+func (p *Prompter) Println(args ...any) {
+	fmt.Fprintln(p.out, args...)
+}
+
+// isTerminal reports whether r is a character device such as a TTY
+func isTerminal(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// String asks for a text answer; an empty answer selects def
+func (p *Prompter) String(question, def string, validators ...Validator) (string, error) {
+	label := question
+	if def != "" {
+		label = fmt.Sprintf("%s [%s]", question, def)
+	}
+	return p.ask(label, def, func(answer string) (string, error) {
+		for _, validate := range validators {
+			if err := validate(answer); err != nil {
+				return "", err
+			}
+		}
+		return answer, nil
+	})
+}
+
+// Int asks for a whole number between min and max
+func (p *Prompter) Int(question string, def, min, max int) (int, error) {
+	answer, err := p.ask(fmt.Sprintf("%s [%d]", question, def), strconv.Itoa(def), func(answer string) (string, error) {
+		return answer, Range(min, max)(answer)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(answer)
+}
+
+// Confirm asks a yes/no question
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	hint, defAnswer := "y/N", "n"
+	if def {
+		hint, defAnswer = "Y/n", "y"
+	}
+	if p.AssumeYes {
+		fmt.Fprintf(p.out, "%s (%s): y\n", question, hint)
+		return true, nil
+	}
+	answer, err := p.ask(fmt.Sprintf("%s (%s)", question, hint), defAnswer, func(answer string) (string, error) {
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return "y", nil
+		case "n", "no":
+			return "n", nil
+		}
+		return "", fmt.Errorf("please answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	return answer == "y", nil
+}
+
+// Select lists numbered options and returns the index of the chosen one;
+// def is the index selected by an empty answer, or -1 to require a choice
+func (p *Prompter) Select(question string, options []string, def int) (int, error) {
+	if len(options) == 0 {
+		return 0, fmt.Errorf("%s: nothing to choose from", question)
+	}
+	for i, option := range options {
+		fmt.Fprintf(p.out, "%d. %s\n", i+1, option)
+	}
+
+	label, defAnswer := fmt.Sprintf("%s (1-%d)", question, len(options)), ""
+	if def >= 0 && def < len(options) {
+		defAnswer = strconv.Itoa(def + 1)
+		label = fmt.Sprintf("%s [%s]", label, defAnswer)
+	}
+	answer, err := p.ask(label, defAnswer, func(answer string) (string, error) {
+		return answer, Range(1, len(options))(answer)
+	})
+	if err != nil {
+		return 0, err
+	}
+	choice, _ := strconv.Atoi(answer)
+	return choice - 1, nil
+}
+
+// ask prints label and reads an answer, substituting def for an empty one,
+// until check accepts it
+func (p *Prompter) ask(label, def string, check func(string) (string, error)) (string, error) {
+	if p.AssumeYes {
+		answer, err := check(def)
+		if err != nil {
+			return "", fmt.Errorf("%s: no usable default for --yes: %w", label, err)
+		}
+		fmt.Fprintf(p.out, "%s: %s\n", label, def)
+		return answer, nil
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s: ", label)
+		line, err := p.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(p.out)
+			return "", ErrNoInput
+		}
+
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		checked, err := check(answer)
+		if err == nil {
+			return checked, nil
+		}
+		if !p.interactive {
+			return "", fmt.Errorf("%s: %w", label, err)
+		}
+		fmt.Fprintf(p.out, "❌ %v\n", err)
+	}
+}
@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/schedule"
+)
+
+// Validator checks an answer and explains why it is not accepted
+type Validator func(answer string) error
+
+// Required rejects empty answers
+func Required(answer string) error {
+	if strings.TrimSpace(answer) == "" {
+		return fmt.Errorf("a value is required")
+	}
+	return nil
+}
+
+// Optional accepts empty answers and checks all others with validate
+func Optional(validate Validator) Validator {
+	return func(answer string) error {
+		if answer == "" {
+			return nil
+		}
+		return validate(answer)
+	}
+}
+
+// Range accepts whole numbers between min and max
+func Range(min, max int) Validator {
+	return func(answer string) error {
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < min || n > max {
+			return fmt.Errorf("enter a number from %d to %d", min, max)
+		}
+		return nil
+	}
+}
+
+// PathExists accepts paths of existing files or directories
+func PathExists(answer string) error {
+	if _, err := os.Stat(answer); err != nil {
+		return fmt.Errorf("%s does not exist", answer)
+	}
+	return nil
+}
+
+// AbsolutePath accepts absolute paths
+func AbsolutePath(answer string) error {
+	if !strings.HasPrefix(answer, "/") {
+		return fmt.Errorf("%s is not an absolute path", answer)
+	}
+	return nil
+}
+
+// Schedule accepts cron expressions, named schedules and durations
+func Schedule(answer string) error {
+	_, err := schedule.Parse(config.ScheduleConfig{Interval: answer})
+	return err
+}
+
+// URL accepts http and https URLs
+func URL(answer string) error {
+	u, err := url.Parse(answer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s is not an http:// or https:// URL", answer)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package redact masks secrets before configuration values are displayed,
+// exported or written to the audit log.
+package redact
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// secretFields names the configuration struct fields holding secrets
+var secretFields = map[string]bool{
+	"AccessKey": true,
+	"SecretKey": true,
+	"Secret":    true,
+}
+
+// secretEnvWords mark plugin environment variables that hold secrets
+var secretEnvWords = []string{"KEY", "SECRET", "TOKEN", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH"}
+
+// IsSecretField reports whether a configuration struct field holds a secret
+func IsSecretField(name string) bool {
+	return secretFields[name]
+}
+
+// IsSecretEnv reports whether a plugin environment variable name suggests a secret
+func IsSecretEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range secretEnvWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks a secret, keeping the first and last four characters of long
+// values so keys can still be told apart
+func String(s string) string {
+	if s == "" {
+		return "(not set)"
+	}
+	if len(s) <= 12 {
+		return "****"
+	}
+	return s[:4] + "****" + s[len(s)-4:]
+}
+
+// URL masks the password of a URL with user information, such as a webhook
+// or endpoint URL with embedded credentials
+func URL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// Config returns a copy of cfg with all secrets masked
+func Config(cfg *config.BackupConfig) *config.BackupConfig {
+	masked := *cfg
+
+	masked.Buckets = make([]config.BucketConfig, len(cfg.Buckets))
+	for i, bucket := range cfg.Buckets {
+		bucket.AccessKey = String(bucket.AccessKey)
+		bucket.SecretKey = String(bucket.SecretKey)
+		bucket.Endpoint = URL(bucket.Endpoint)
+		masked.Buckets[i] = bucket
+	}
+
+	masked.Plugins = make([]config.PluginConfig, len(cfg.Plugins))
+	for i, plugin := range cfg.Plugins {
+		if len(plugin.Env) > 0 {
+			env := make(map[string]string, len(plugin.Env))
+			for name, value := range plugin.Env {
+				if IsSecretEnv(name) {
+					value = String(value)
+				}
+				env[name] = URL(value)
+			}
+			plugin.Env = env
+		}
+		masked.Plugins[i] = plugin
+	}
+
+	if masked.Fleet.Secret != "" {
+		masked.Fleet.Secret = String(masked.Fleet.Secret)
+	}
+	masked.Fleet.ReportTo = URL(masked.Fleet.ReportTo)
+	return &masked
+}
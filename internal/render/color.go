@@ -0,0 +1,58 @@
+package render
+
+// ANSI color codes
+const (
+	codeBold   = "1"
+	codeRed    = "31"
+	codeGreen  = "32"
+	codeYellow = "33"
+	codeFaint  = "2"
+)
+
+// Green colors s for success when color is on
+func Green(s string) string {
+	return paint(codeGreen, s)
+}
+
+// Red colors s for failure when color is on
+func Red(s string) string {
+	return paint(codeRed, s)
+}
+
+// Yellow colors s for warnings when color is on
+func Yellow(s string) string {
+	return paint(codeYellow, s)
+}
+
+// Bold makes s bold when color is on
+func Bold(s string) string {
+	return paint(codeBold, s)
+}
+
+// Faint dims s when color is on
+func Faint(s string) string {
+	return paint(codeFaint, s)
+}
+
+// Status colors a result word: green for ok, red for failed or cancelled and
+// yellow for anything else
+func Status(s string) string {
+	switch s {
+	case "ok", "success", "completed":
+		return Green(s)
+	case "failed", "cancelled", "error":
+		return Red(s)
+	}
+	return Yellow(s)
+}
+
+// paint wraps s in the ANSI code when color is on
+func paint(code, s string) string {
+	mu.RLock()
+	on := color
+	mu.RUnlock()
+	if !on || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,251 @@
+// Package render writes command output for terminals, scripts and log files:
+// it can replace emoji with ASCII markers, adds color only on terminals and
+// lays out tables to a fixed width
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options selects how output is rendered
+type Options struct {
+	// NoEmoji replaces status emoji with ASCII markers and drops the others
+	NoEmoji bool
+	// NoColor never colors output, even on a terminal
+	NoColor bool
+	// Width is the number of columns tables are fitted into; 0 means unlimited
+	Width int
+}
+
+var (
+	mu      sync.RWMutex
+	current Options
+	color   bool
+
+	// tees receive a copy of everything written to Stdout
+	teeMu sync.Mutex
+	tees  []*tee
+)
+
+// tee is a writer added with Tee
+type tee struct {
+	w io.Writer
+}
+
+// Stdout writes to standard output, rendered with the configured options
+var Stdout io.Writer = writer{os.Stdout}
+
+// Status emoji and the ASCII markers that replace them with NoEmoji
+var markers = map[rune]string{
+	'✅': "[OK]",
+	'❌': "[FAIL]",
+	'⚠': "[WARN]",
+	'💡': "[HINT]",
+	'🛑': "[STOP]",
+}
+
+// Configure sets the rendering options. BACKTIDE_NO_EMOJI and TERM=dumb turn
+// on NoEmoji, NO_COLOR turns on NoColor, and color is only used when standard
+// output is a terminal.
+func Configure(opts Options) {
+	if os.Getenv("BACKTIDE_NO_EMOJI") != "" || os.Getenv("TERM") == "dumb" {
+		opts.NoEmoji = true
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		opts.NoColor = true
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = opts
+	color = !opts.NoColor && isTerminal(os.Stdout)
+}
+
+// Current returns the configured options
+func Current() Options {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Printf formats and writes to standard output like fmt.Printf
+func Printf(format string, a ...any) (int, error) {
+	return io.WriteString(Stdout, fmt.Sprintf(format, a...))
+}
+
+// Println writes its operands and a newline to standard output like fmt.Println
+func Println(a ...any) (int, error) {
+	return io.WriteString(Stdout, fmt.Sprintln(a...))
+}
+
+// Print writes its operands to standard output like fmt.Print
+func Print(a ...any) (int, error) {
+	return io.WriteString(Stdout, fmt.Sprint(a...))
+}
+
+// Text returns s as it is written: with NoEmoji, status emoji become ASCII
+// markers and other emoji are removed
+func Text(s string) string {
+	if !Current().NoEmoji || !hasEmoji(s) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if !isEmoji(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		// Drop the variation selector and spacing that follow the emoji
+		for i < len(s) {
+			next, size := utf8.DecodeRuneInString(s[i:])
+			if next != '\uFE0F' && next != '\u200D' && next != ' ' {
+				break
+			}
+			i += size
+		}
+		marker, ok := markers[r]
+		switch {
+		case ok:
+			b.WriteString(marker)
+			if i < len(s) && s[i] != '\n' {
+				b.WriteByte(' ')
+			}
+		case i == len(s) || s[i] == '\n':
+			// Nothing followed the emoji, so drop the space in front of it too
+			trimmed := strings.TrimRight(b.String(), " ")
+			b.Reset()
+			b.WriteString(trimmed)
+		}
+	}
+	return b.String()
+}
+
+// hasEmoji reports whether s contains any emoji
+func hasEmoji(s string) bool {
+	return strings.IndexFunc(s, isEmoji) >= 0
+}
+
+// isEmoji reports whether r is an emoji or a symbol from the ranges the
+// commands use as emoji
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	case r >= 0x2300 && r <= 0x23FF:
+		return true
+	}
+	return false
+}
+
+// Width returns the number of terminal columns s takes up: color codes and
+// combining marks take none, wide characters and emoji two
+func Width(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns the number of columns r takes up
+func runeWidth(r rune) int {
+	switch {
+	case r == '\uFE0F' || r == '\u200D' || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	case isEmoji(r), isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isWide reports whether r is an East Asian wide or full-width character
+func isWide(r rune) bool {
+	return r >= 0x1100 && r <= 0x115F ||
+		r >= 0x2E80 && r <= 0xA4CF ||
+		r >= 0xAC00 && r <= 0xD7A3 ||
+		r >= 0xF900 && r <= 0xFAFF ||
+		r >= 0xFE30 && r <= 0xFE4F ||
+		r >= 0xFF00 && r <= 0xFF60 ||
+		r >= 0xFFE0 && r <= 0xFFE6 ||
+		r >= 0x20000 && r <= 0x3FFFD
+}
+
+// escapeLen returns the length of the ANSI color sequence s starts with, or 0
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	if end := strings.IndexByte(s, 'm'); end > 0 {
+		return end + 1
+	}
+	return 0
+}
+
+// Tee copies everything written to Stdout, as rendered, to w until the
+// returned function is called, e.g. to keep the output of a backup run
+func Tee(w io.Writer) func() {
+	t := &tee{w: w}
+	teeMu.Lock()
+	tees = append(tees, t)
+	teeMu.Unlock()
+
+	return func() {
+		teeMu.Lock()
+		defer teeMu.Unlock()
+		for i := range tees {
+			if tees[i] == t {
+				tees = append(tees[:i], tees[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// writer renders everything written to it before passing it on
+type writer struct {
+	out io.Writer
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	text := string(p)
+	if Current().NoEmoji {
+		text = Text(text)
+	}
+
+	teeMu.Lock()
+	for _, t := range tees {
+		io.WriteString(t.w, text)
+	}
+	teeMu.Unlock()
+
+	if _, err := io.WriteString(w.out, text); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
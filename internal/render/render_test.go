@@ -0,0 +1,81 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextNoEmoji(t *testing.T) {
+	Configure(Options{NoEmoji: true})
+	defer Configure(Options{})
+
+	tests := map[string]string{
+		"✅ Backup completed\n":      "[OK] Backup completed\n",
+		"⚠️  Warning: disk full\n":  "[WARN] Warning: disk full\n",
+		"   ❌ failed: %v":           "   [FAIL] failed: %v",
+		"📦 Binary\n📁 Directories\n": "Binary\nDirectories\n",
+		"Done 🎉\n":                  "Done\n",
+		"plain text":                "plain text",
+	}
+	for in, want := range tests {
+		if got := Text(in); got != want {
+			t.Errorf("Text(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTee(t *testing.T) {
+	Configure(Options{NoEmoji: true})
+	defer Configure(Options{})
+
+	var out, copied strings.Builder
+	w := writer{&out}
+	w.Write([]byte("before\n"))
+	remove := Tee(&copied)
+	w.Write([]byte("✅ teed\n"))
+	remove()
+	w.Write([]byte("after\n"))
+
+	if want := "before\n[OK] teed\nafter\n"; out.String() != want {
+		t.Errorf("wrote %q, want %q", out.String(), want)
+	}
+	if want := "[OK] teed\n"; copied.String() != want {
+		t.Errorf("tee received %q, want %q", copied.String(), want)
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := map[string]int{
+		"abc":               3,
+		"✅":                 2,
+		"日本":                4,
+		"\x1b[32mok\x1b[0m": 2,
+		"e\u0301":           1,
+	}
+	for in, want := range tests {
+		if got := Width(in); got != want {
+			t.Errorf("Width(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestTableFitsWidth(t *testing.T) {
+	Configure(Options{NoColor: true, Width: 24})
+	defer Configure(Options{})
+
+	table := NewTable("JOB", "BACKUP", "SIZE")
+	table.Right[2] = true
+	table.Row("app", "backup-20250101-000000-j1", "3 B")
+	table.Row("database", "-", "12.0 MB")
+
+	var out strings.Builder
+	if err := table.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := "JOB     BACKUP      SIZE\n" +
+		"app     back...      3 B\n" +
+		"dat...  -        12.0 MB\n"
+	if out.String() != want {
+		t.Errorf("table =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// columnGap separates table columns
+const columnGap = "  "
+
+// Table lays out rows in aligned columns separated by spaces, measuring cells
+// by display width so emoji and wide characters line up
+type Table struct {
+	header []string
+	rows   [][]string
+	// Indent is written before every line
+	Indent string
+	// Right lists the columns that are right-aligned, such as sizes
+	Right map[int]bool
+}
+
+// NewTable returns a table with the given column headers; without headers no
+// header line is written
+func NewTable(header ...string) *Table {
+	return &Table{header: header, Right: map[int]bool{}}
+}
+
+// Row adds a row; missing cells are left empty
+func (t *Table) Row(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Len returns the number of rows
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// Print writes the table to standard output
+func (t *Table) Print() error {
+	return t.Write(Stdout)
+}
+
+// Write writes the table to w, shortening the widest columns when the table
+// is wider than the configured width
+func (t *Table) Write(w io.Writer) error {
+	var lines [][]string
+	if len(t.header) > 0 {
+		lines = append(lines, t.header)
+	}
+	lines = append(lines, t.rows...)
+
+	columns := 0
+	for _, line := range lines {
+		columns = max(columns, len(line))
+	}
+	widths := make([]int, columns)
+	for _, line := range lines {
+		for i, cell := range line {
+			widths[i] = max(widths[i], Width(Text(cell)))
+		}
+	}
+	t.fit(widths)
+
+	var b strings.Builder
+	for n, line := range lines {
+		b.WriteString(t.Indent)
+		for i := range columns {
+			cell := ""
+			if i < len(line) {
+				cell = truncate(Text(line[i]), widths[i])
+			}
+			if n == 0 && len(t.header) > 0 {
+				cell = Bold(cell)
+			}
+			pad := strings.Repeat(" ", widths[i]-Width(cell))
+			last := i == columns-1
+			switch {
+			case t.Right[i]:
+				b.WriteString(pad + cell)
+			case last:
+				b.WriteString(cell)
+			default:
+				b.WriteString(cell + pad)
+			}
+			if !last {
+				b.WriteString(columnGap)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fit shrinks the widest columns until the table fits the configured width,
+// keeping every column at least a few characters wide
+func (t *Table) fit(widths []int) {
+	limit := Current().Width
+	if limit <= 0 {
+		return
+	}
+	const minWidth = 4
+	total := func() int {
+		sum := Width(t.Indent) + len(columnGap)*(len(widths)-1)
+		for _, width := range widths {
+			sum += width
+		}
+		return sum
+	}
+	for total() > limit {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// truncate shortens s to width columns, marking the cut with "..."
+func truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	const ellipsis = "..."
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if used+runeWidth(r) > width-len(ellipsis) {
+			break
+		}
+		b.WriteRune(r)
+		used += runeWidth(r)
+		i += size
+	}
+	return b.String() + ellipsis
+}
@@ -0,0 +1,173 @@
+package s3api
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// s3Namespace is the XML namespace of S3 request bodies
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// LifecycleRules configures the bucket lifecycle; zero values disable a rule
+type LifecycleRules struct {
+	AbortMultipartDays    int // abort incomplete multipart uploads after this many days
+	NoncurrentVersionDays int // expire overwritten or deleted object versions after this many days
+}
+
+// CreateBucket creates the bucket in the client's region; a bucket already
+// owned by the caller is not an error
+func (c *Client) CreateBucket(ctx context.Context) error {
+	var body []byte
+	// us-east-1 is the default location and must not be sent as a constraint
+	if c.region != DefaultRegion {
+		config := struct {
+			XMLName            xml.Name `xml:"CreateBucketConfiguration"`
+			Xmlns              string   `xml:"xmlns,attr"`
+			LocationConstraint string   `xml:"LocationConstraint"`
+		}{Xmlns: s3Namespace, LocationConstraint: c.region}
+
+		var err error
+		if body, err = xml.Marshal(config); err != nil {
+			return fmt.Errorf("failed to marshal bucket configuration: %w", err)
+		}
+	}
+
+	_, err := c.Do(ctx, http.MethodPut, "", nil, body, nil)
+	var s3Err *Error
+	if errors.As(err, &s3Err) && s3Err.Code == "BucketAlreadyOwnedByYou" {
+		return nil
+	}
+	return err
+}
+
+// PutBucketVersioning enables or suspends object versioning
+func (c *Client) PutBucketVersioning(ctx context.Context, enabled bool) error {
+	status := "Suspended"
+	if enabled {
+		status = "Enabled"
+	}
+	config := struct {
+		XMLName xml.Name `xml:"VersioningConfiguration"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Status  string   `xml:"Status"`
+	}{Xmlns: s3Namespace, Status: status}
+
+	return c.putBucketSubresource(ctx, "versioning", config)
+}
+
+// PutBucketEncryption sets default server-side encryption with S3-managed keys
+func (c *Client) PutBucketEncryption(ctx context.Context) error {
+	type defaultEncryption struct {
+		SSEAlgorithm string `xml:"SSEAlgorithm"`
+	}
+	type rule struct {
+		ApplyServerSideEncryptionByDefault defaultEncryption `xml:"ApplyServerSideEncryptionByDefault"`
+	}
+	config := struct {
+		XMLName xml.Name `xml:"ServerSideEncryptionConfiguration"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Rule    rule     `xml:"Rule"`
+	}{Xmlns: s3Namespace, Rule: rule{defaultEncryption{SSEAlgorithm: "AES256"}}}
+
+	return c.putBucketSubresource(ctx, "encryption", config)
+}
+
+// PutBucketLifecycle replaces the bucket lifecycle configuration
+func (c *Client) PutBucketLifecycle(ctx context.Context, rules LifecycleRules) error {
+	type days struct {
+		DaysAfterInitiation int `xml:"DaysAfterInitiation,omitempty"`
+		NoncurrentDays      int `xml:"NoncurrentDays,omitempty"`
+	}
+	type rule struct {
+		ID                             string `xml:"ID"`
+		Prefix                         string `xml:"Filter>Prefix"`
+		Status                         string `xml:"Status"`
+		AbortIncompleteMultipartUpload *days  `xml:"AbortIncompleteMultipartUpload,omitempty"`
+		NoncurrentVersionExpiration    *days  `xml:"NoncurrentVersionExpiration,omitempty"`
+	}
+
+	var ruleList []rule
+	if rules.AbortMultipartDays > 0 {
+		ruleList = append(ruleList, rule{
+			ID:                             "backtide-abort-incomplete-uploads",
+			Status:                         "Enabled",
+			AbortIncompleteMultipartUpload: &days{DaysAfterInitiation: rules.AbortMultipartDays},
+		})
+	}
+	if rules.NoncurrentVersionDays > 0 {
+		ruleList = append(ruleList, rule{
+			ID:                          "backtide-expire-old-versions",
+			Status:                      "Enabled",
+			NoncurrentVersionExpiration: &days{NoncurrentDays: rules.NoncurrentVersionDays},
+		})
+	}
+	if len(ruleList) == 0 {
+		return nil
+	}
+
+	config := struct {
+		XMLName xml.Name `xml:"LifecycleConfiguration"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Rules   []rule   `xml:"Rule"`
+	}{Xmlns: s3Namespace, Rules: ruleList}
+
+	return c.putBucketSubresource(ctx, "lifecycle", config)
+}
+
+// putBucketSubresource PUTs an XML configuration to a bucket subresource such as ?versioning
+func (c *Client) putBucketSubresource(ctx context.Context, subresource string, config interface{}) error {
+	body, err := xml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s configuration: %w", subresource, err)
+	}
+
+	// Lifecycle and encryption requests are rejected without Content-MD5
+	sum := md5.Sum(body)
+	headers := map[string]string{
+		"Content-MD5":  base64.StdEncoding.EncodeToString(sum[:]),
+		"Content-Type": "application/xml",
+	}
+
+	_, err = c.Do(ctx, http.MethodPut, "", url.Values{subresource: {""}}, body, headers)
+	return err
+}
+
+// MinimalPolicy returns an IAM policy granting only the access backtide needs to a bucket
+func MinimalPolicy(bucket string) (string, error) {
+	type statement struct {
+		Effect   string   `json:"Effect"`
+		Action   []string `json:"Action"`
+		Resource string   `json:"Resource"`
+	}
+	policy := struct {
+		Version   string      `json:"Version"`
+		Statement []statement `json:"Statement"`
+	}{
+		Version: "2012-10-17",
+		Statement: []statement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:ListBucket", "s3:GetBucketLocation", "s3:ListBucketMultipartUploads", "s3:ListBucketVersions", "s3:GetBucketVersioning", "s3:GetLifecycleConfiguration"},
+				Resource: "arn:aws:s3:::" + bucket,
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:GetObject", "s3:PutObject", "s3:DeleteObject", "s3:DeleteObjectVersion", "s3:AbortMultipartUpload", "s3:ListMultipartUploadParts"},
+				Resource: "arn:aws:s3:::" + bucket + "/*",
+			},
+		},
+	}
+
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal policy: %w", err)
+	}
+	return string(data), nil
+}
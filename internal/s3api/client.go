@@ -0,0 +1,336 @@
+// Package s3api talks to S3-compatible object storage over signed HTTP
+// requests, for operations that must not depend on an s3fs mount.
+package s3api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// DefaultRegion is used for signing when a bucket has no region configured
+const DefaultRegion = "us-east-1"
+
+// Client performs SigV4-signed requests against one bucket
+type Client struct {
+	bucket    string
+	region    string
+	endpoint  *url.URL
+	pathStyle bool
+	accessKey string
+	secretKey string
+	http      *http.Client
+}
+
+// Error is an S3 error response
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	// Region is the bucket's actual region when the server reports one
+	Region string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("%s (HTTP %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s (HTTP %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// NewClient creates a client for a configured bucket, sending requests with
+// the network settings
+func NewClient(bucket config.BucketConfig, netCfg config.NetworkConfig) (*Client, error) {
+	if bucket.S3FS.IAMRole != "" && bucket.AccessKey == "" {
+		return nil, fmt.Errorf("bucket %s uses an IAM role; API access requires access keys", bucket.Name)
+	}
+
+	region := bucket.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	endpoint := bucket.Endpoint
+	if endpoint == "" {
+		if bucket.Region != "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", bucket.Region)
+		} else {
+			endpoint = "https://s3.amazonaws.com"
+		}
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	transport, err := newTransport(netCfg, bucket.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		bucket:    bucket.Bucket,
+		region:    region,
+		endpoint:  endpointURL,
+		pathStyle: bucket.UsePathStyle,
+		accessKey: bucket.AccessKey,
+		secretKey: bucket.SecretKey,
+		http:      &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}, nil
+}
+
+// Region returns the region requests are signed for
+func (c *Client) Region() string {
+	return c.region
+}
+
+// HeadBucket checks that the bucket exists and is accessible
+func (c *Client) HeadBucket(ctx context.Context) error {
+	_, err := c.Do(ctx, http.MethodHead, "", nil, nil, nil)
+	return err
+}
+
+// PutObject uploads an object
+func (c *Client) PutObject(ctx context.Context, key string, data []byte) error {
+	_, err := c.Do(ctx, http.MethodPut, key, nil, data, nil)
+	return err
+}
+
+// GetObject downloads an object
+func (c *Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+	return c.Do(ctx, http.MethodGet, key, nil, nil, nil)
+}
+
+// DeleteObject removes an object
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	_, err := c.Do(ctx, http.MethodDelete, key, nil, nil, nil)
+	return err
+}
+
+// Do sends a signed request for the bucket (empty key) or an object and
+// returns the response body; non-2xx responses are returned as *Error
+func (c *Client) Do(ctx context.Context, method, key string, query url.Values, body []byte, headers map[string]string) ([]byte, error) {
+	resp, err := c.send(ctx, c.http, method, key, query, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return data, nil
+}
+
+// send sends a signed request with client and returns the response of a
+// successful request with its body unread; non-2xx responses are returned
+// as *Error
+func (c *Client) send(ctx context.Context, client *http.Client, method, key string, query url.Values, body []byte, headers map[string]string) (*http.Response, error) {
+	reqURL := *c.endpoint
+	if c.pathStyle {
+		reqURL.Path = "/" + c.bucket
+		if key != "" {
+			reqURL.Path += "/" + key
+		}
+	} else {
+		reqURL.Host = c.bucket + "." + c.endpoint.Host
+		reqURL.Path = "/" + key
+	}
+	reqURL.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = int64(len(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	c.sign(req, body, time.Now().UTC())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		s3Err := &Error{StatusCode: resp.StatusCode, Region: resp.Header.Get("x-amz-bucket-region")}
+		xml.Unmarshal(data, s3Err)
+		if s3Err.Code == "" {
+			s3Err.Code = defaultErrorCode(resp.StatusCode)
+		}
+		return nil, s3Err
+	}
+	return resp, nil
+}
+
+// defaultErrorCode names errors for responses without a body, such as HEAD
+func defaultErrorCode(status int) string {
+	switch status {
+	case http.StatusMovedPermanently:
+		return "PermanentRedirect"
+	case http.StatusForbidden:
+		return "AccessDenied"
+	case http.StatusNotFound:
+		return "NoSuchBucket"
+	}
+	return ""
+}
+
+// sign adds AWS Signature Version 4 headers to a request
+func (c *Client) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	req.Header.Set("Host", req.URL.Host)
+
+	// Canonical headers: host and all x-amz-* and content headers, sorted
+	var names []string
+	values := map[string]string{"host": req.URL.Host}
+	names = append(names, "host")
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "host" {
+			continue
+		}
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-md5" || lower == "content-type" {
+			names = append(names, lower)
+			values[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, c.region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Explain turns an S3 error into a hint about what is misconfigured
+func Explain(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &unknownAuthority) {
+		return "the endpoint's certificate is not trusted; set ca_file under [buckets.tls]"
+	}
+	if errors.As(err, &hostnameErr) {
+		return "the endpoint's certificate does not match its host name; check the endpoint URL"
+	}
+
+	s3Err, ok := err.(*Error)
+	if !ok {
+		return "check the endpoint URL and network connectivity"
+	}
+
+	switch s3Err.Code {
+	case "InvalidAccessKeyId":
+		return "the access key is not recognised by this endpoint"
+	case "SignatureDoesNotMatch":
+		return "the secret key does not match the access key"
+	case "NoSuchBucket":
+		return "the bucket does not exist at this endpoint"
+	case "PermanentRedirect", "AuthorizationHeaderMalformed", "IllegalLocationConstraintException":
+		if s3Err.Region != "" {
+			return fmt.Sprintf("the bucket is in region %s; set region = %q", s3Err.Region, s3Err.Region)
+		}
+		return "the bucket is in a different region or needs a different endpoint"
+	case "AccessDenied":
+		return "the credentials are valid but lack permission for this operation"
+	case "RequestTimeTooSkewed":
+		return "the system clock differs too much from the server; check NTP"
+	}
+	return "see the error code above"
+}
@@ -0,0 +1,95 @@
+package s3api
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Object is an object listed in a bucket
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ListObjects returns the objects whose keys start with prefix
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]Object, error) {
+	type entry struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	}
+	var objects []Object
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		data, err := c.Do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			IsTruncated           bool    `xml:"IsTruncated"`
+			NextContinuationToken string  `xml:"NextContinuationToken"`
+			Contents              []entry `xml:"Contents"`
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse object list: %w", err)
+		}
+		for _, o := range page.Contents {
+			objects = append(objects, Object{Key: o.Key, Size: o.Size, LastModified: o.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+// OpenObject starts downloading an object and returns its content as a
+// stream. Unlike GetObject, the download is not limited by the client's
+// request timeout, so large archives can be read; ctx bounds it instead.
+func (c *Client) OpenObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	client := *c.http
+	client.Timeout = 0
+	resp, err := c.send(ctx, &client, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CopyObject copies an object of another bucket on the same endpoint into
+// this bucket without downloading it; the client's credentials must be able
+// to read the source. S3 copies objects of up to 5 GB this way. An empty
+// storage class keeps the bucket's default.
+func (c *Client) CopyObject(ctx context.Context, srcBucket, srcKey, key, storageClass string) error {
+	headers := map[string]string{"x-amz-copy-source": "/" + srcBucket + "/" + (&url.URL{Path: srcKey}).EscapedPath()}
+	if storageClass != "" {
+		headers["x-amz-storage-class"] = storageClass
+	}
+	// The copy is done by the server and may take longer than the request timeout
+	client := *c.http
+	client.Timeout = 0
+	resp, err := c.send(ctx, &client, http.MethodPut, key, nil, nil, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	// A copy failing after it started is reported in a 200 response
+	if bytes.Contains(data, []byte("<Error>")) {
+		s3Err := &Error{StatusCode: resp.StatusCode}
+		xml.Unmarshal(data, s3Err)
+		return s3Err
+	}
+	return nil
+}
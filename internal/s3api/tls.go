@@ -0,0 +1,47 @@
+package s3api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/network"
+)
+
+// newTransport returns an HTTP transport using the network settings and the
+// bucket's TLS settings
+func newTransport(netCfg config.NetworkConfig, cfg config.BucketTLS) (*http.Transport, error) {
+	transport, err := network.Transport(netCfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == (config.BucketTLS{}) {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if transport.TLSClientConfig != nil {
+		tlsConfig.RootCAs = transport.TLSClientConfig.RootCAs
+	}
+
+	if cfg.CAFile != "" {
+		// Trust the private CA in addition to the system roots and CA bundle
+		pool, err := network.CertPool(netCfg, cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
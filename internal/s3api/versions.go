@@ -0,0 +1,112 @@
+package s3api
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ObjectVersion is a version of an object, or a delete marker, in a
+// versioned bucket
+type ObjectVersion struct {
+	Key          string
+	VersionID    string
+	IsLatest     bool
+	DeleteMarker bool
+	Size         int64
+}
+
+// GetBucketVersioning returns the versioning status of the bucket: "Enabled",
+// "Suspended", or "" if versioning was never enabled
+func (c *Client) GetBucketVersioning(ctx context.Context) (string, error) {
+	data, err := c.Do(ctx, http.MethodGet, "", url.Values{"versioning": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	var config struct {
+		Status string `xml:"Status"`
+	}
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse versioning configuration: %w", err)
+	}
+	return config.Status, nil
+}
+
+// NoncurrentVersionsExpire reports whether an enabled lifecycle rule of the
+// bucket expires noncurrent object versions
+func (c *Client) NoncurrentVersionsExpire(ctx context.Context) (bool, error) {
+	data, err := c.Do(ctx, http.MethodGet, "", url.Values{"lifecycle": {""}}, nil, nil)
+	var s3Err *Error
+	if errors.As(err, &s3Err) && s3Err.Code == "NoSuchLifecycleConfiguration" {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var config struct {
+		Rules []struct {
+			Status         string `xml:"Status"`
+			NoncurrentDays int    `xml:"NoncurrentVersionExpiration>NoncurrentDays"`
+		} `xml:"Rule"`
+	}
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return false, fmt.Errorf("failed to parse lifecycle configuration: %w", err)
+	}
+	for _, rule := range config.Rules {
+		if rule.Status == "Enabled" && rule.NoncurrentDays > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListObjectVersions returns all versions and delete markers of the objects
+// whose keys start with prefix
+func (c *Client) ListObjectVersions(ctx context.Context, prefix string) ([]ObjectVersion, error) {
+	type entry struct {
+		Key       string `xml:"Key"`
+		VersionID string `xml:"VersionId"`
+		IsLatest  bool   `xml:"IsLatest"`
+		Size      int64  `xml:"Size"`
+	}
+	var versions []ObjectVersion
+	query := url.Values{"versions": {""}, "prefix": {prefix}}
+	for {
+		data, err := c.Do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			IsTruncated         bool    `xml:"IsTruncated"`
+			NextKeyMarker       string  `xml:"NextKeyMarker"`
+			NextVersionIDMarker string  `xml:"NextVersionIdMarker"`
+			Versions            []entry `xml:"Version"`
+			DeleteMarkers       []entry `xml:"DeleteMarker"`
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse object versions: %w", err)
+		}
+		for _, v := range page.Versions {
+			versions = append(versions, ObjectVersion{Key: v.Key, VersionID: v.VersionID, IsLatest: v.IsLatest, Size: v.Size})
+		}
+		for _, m := range page.DeleteMarkers {
+			versions = append(versions, ObjectVersion{Key: m.Key, VersionID: m.VersionID, IsLatest: m.IsLatest, DeleteMarker: true})
+		}
+		if !page.IsTruncated {
+			return versions, nil
+		}
+		query.Set("key-marker", page.NextKeyMarker)
+		query.Set("version-id-marker", page.NextVersionIDMarker)
+	}
+}
+
+// DeleteObjectVersion permanently removes one version of an object, or a
+// delete marker
+func (c *Client) DeleteObjectVersion(ctx context.Context, key, versionID string) error {
+	_, err := c.Do(ctx, http.MethodDelete, key, url.Values{"versionId": {versionID}}, nil, nil)
+	return err
+}
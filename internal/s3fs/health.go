@@ -0,0 +1,101 @@
+package s3fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/state"
+)
+
+// DefaultCheckTimeout bounds how long a mount health check may block on a hung FUSE mount
+const DefaultCheckTimeout = 10 * time.Second
+
+// CheckMount verifies that the bucket is mounted and its mount point responds
+func (sm *S3FSManager) CheckMount(timeout time.Duration) error {
+	if !sm.isMounted() {
+		return fmt.Errorf("%s is not mounted", sm.config.MountPoint)
+	}
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	// A dead s3fs process leaves a mount that fails or hangs on access
+	result := make(chan error, 1)
+	go func() {
+		_, err := os.ReadDir(sm.config.MountPoint)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if errors.Is(err, syscall.ENOTCONN) {
+			return fmt.Errorf("%s is stale: transport endpoint is not connected", sm.config.MountPoint)
+		}
+		if err != nil {
+			return fmt.Errorf("%s is not accessible: %w", sm.config.MountPoint, err)
+		}
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("%s did not respond within %s", sm.config.MountPoint, timeout)
+	}
+}
+
+// Remount lazily detaches a broken mount and mounts the bucket again
+func (sm *S3FSManager) Remount() error {
+	if sm.isMounted() {
+		// Lazy unmount so a hung mount cannot block the detach
+		if _, err := exec.Command("fusermount", "-uz", sm.config.MountPoint).CombinedOutput(); err != nil {
+			if output, err := exec.Command("umount", "-l", sm.config.MountPoint).CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to detach broken mount: %s, error: %w", string(output), err)
+			}
+		}
+	}
+	return sm.MountS3FS()
+}
+
+// EnsureMounted mounts the bucket if needed and remounts it if the mount is broken
+func (sm *S3FSManager) EnsureMounted(timeout time.Duration) error {
+	if !sm.isMounted() {
+		return sm.MountS3FS()
+	}
+	if err := sm.CheckMount(timeout); err != nil {
+		render.Printf("⚠️  S3 mount unhealthy (%v), remounting...\n", err)
+		if err := sm.Remount(); err != nil {
+			return err
+		}
+		return sm.CheckMount(timeout)
+	}
+	render.Printf("S3 bucket is already mounted at %s\n", sm.config.MountPoint)
+	return nil
+}
+
+// Acquire mounts the bucket for holder and returns a function releasing it; on-demand
+// buckets are unmounted once the last holder across all backtide processes releases them
+func (sm *S3FSManager) Acquire(holder string, timeout time.Duration) (func(), error) {
+	if !sm.config.MountOnDemand {
+		return func() {}, sm.EnsureMounted(timeout)
+	}
+
+	err := state.AcquireMount(sm.config.ID, holder, func() error {
+		render.Printf("🔌 Mounting on-demand bucket %s\n", sm.config.Name)
+		return sm.EnsureMounted(timeout)
+	})
+	if err != nil {
+		return func() {}, err
+	}
+
+	return func() {
+		err := state.ReleaseMount(sm.config.ID, holder, func() error {
+			render.Printf("🔌 Unmounting on-demand bucket %s\n", sm.config.Name)
+			return sm.UnmountS3FS()
+		})
+		if err != nil {
+			render.Printf("Warning: Failed to release mount %s: %v\n", sm.config.MountPoint, err)
+		}
+	}, nil
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/prompt"
+	"github.com/mitexleo/backtide/internal/render"
 )
 
 // S3FSManager handles S3FS mount operations
@@ -27,12 +29,12 @@ func NewS3FSManager(cfg config.BucketConfig) *S3FSManager {
 func (sm *S3FSManager) InstallS3FS() error {
 	// Check if s3fs is already installed
 	if sm.isS3FSInstalled() {
-		fmt.Println("✅ s3fs is already installed")
+		render.Println("✅ s3fs is already installed")
 		return nil
 	}
 
-	fmt.Println("Installing s3fs...")
-	fmt.Println("⚠️  This operation requires sudo privileges.")
+	render.Println("Installing s3fs...")
+	render.Println("⚠️  This operation requires sudo privileges.")
 
 	// Try different package managers
 	packageManagers := []string{"apt-get", "yum", "dnf", "zypper", "apk"}
@@ -74,8 +76,8 @@ func (sm *S3FSManager) InstallS3FS() error {
 	}
 
 	if needsSudo {
-		fmt.Println("🔐 Running with sudo to install s3fs...")
-		fmt.Println("   You may be prompted for your password")
+		render.Println("🔐 Running with sudo to install s3fs...")
+		render.Println("   You may be prompted for your password")
 	}
 
 	if err := installCmd.Run(); err != nil {
@@ -85,7 +87,7 @@ func (sm *S3FSManager) InstallS3FS() error {
 		return fmt.Errorf("failed to install s3fs: %w", err)
 	}
 
-	fmt.Println("✅ s3fs installed successfully")
+	render.Println("✅ s3fs installed successfully")
 	return nil
 }
 
@@ -96,43 +98,54 @@ func (sm *S3FSManager) SetupS3FS() error {
 		return fmt.Errorf("failed to create mount point directory: %w", err)
 	}
 
-	// Create credentials file in system-wide location
-	credsDir := filepath.Join("/etc", "backtide", "s3-credentials")
+	// Create the s3fs cache directory if caching is enabled
+	if sm.config.S3FS.UseCache != "" {
+		if err := os.MkdirAll(sm.config.S3FS.UseCache, 0700); err != nil {
+			return fmt.Errorf("failed to create s3fs cache directory: %w", err)
+		}
+	}
+
+	// Buckets using an instance IAM role need no credentials file
+	if sm.config.S3FS.IAMRole != "" {
+		render.Printf("S3FS setup completed. Mount point: %s (IAM role %s)\n", sm.config.MountPoint, sm.config.S3FS.IAMRole)
+		return nil
+	}
+
+	// Create credentials file in the credentials directory
+	credsDir := paths.CredentialsDir()
 	if err := os.MkdirAll(credsDir, 0700); err != nil {
 		return fmt.Errorf("failed to create credentials directory: %w", err)
 	}
 
 	// Create unique credential file per bucket using bucket ID
-	credsFile := filepath.Join(credsDir, fmt.Sprintf("passwd-s3fs-%s", sm.config.ID))
+	credsFile := paths.CredentialsFile(sm.config.ID)
 	credsContent := fmt.Sprintf("%s:%s", sm.config.AccessKey, sm.config.SecretKey)
 	if err := os.WriteFile(credsFile, []byte(credsContent), 0600); err != nil {
 		return fmt.Errorf("failed to create credentials file: %w", err)
 	}
 
-	fmt.Printf("S3FS setup completed. Mount point: %s\n", sm.config.MountPoint)
+	render.Printf("S3FS setup completed. Mount point: %s\n", sm.config.MountPoint)
 	return nil
 }
 
 // InstallS3FSWithPrompt installs s3fs with user confirmation
-func (sm *S3FSManager) InstallS3FSWithPrompt() error {
+func (sm *S3FSManager) InstallS3FSWithPrompt(p *prompt.Prompter) error {
 	// Check if s3fs is already installed
 	if sm.isS3FSInstalled() {
-		fmt.Println("s3fs is already installed")
+		render.Println("s3fs is already installed")
 		return nil
 	}
 
-	fmt.Println("s3fs is required for S3 bucket operations but is not installed.")
-	fmt.Print("Do you want to install it now? (y/N): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-
-	if response != "y" && response != "yes" {
+	render.Println("s3fs is required for S3 bucket operations but is not installed.")
+	install, err := p.Confirm("Do you want to install it now?", false)
+	if err != nil {
+		return err
+	}
+	if !install {
 		return fmt.Errorf("s3fs installation cancelled by user")
 	}
 
-	fmt.Println("Installing s3fs...")
+	render.Println("Installing s3fs...")
 	return sm.InstallS3FS()
 }
 
@@ -140,52 +153,99 @@ func (sm *S3FSManager) InstallS3FSWithPrompt() error {
 func (sm *S3FSManager) MountS3FS() error {
 	// Check if already mounted
 	if sm.isMounted() {
-		fmt.Printf("S3 bucket is already mounted at %s\n", sm.config.MountPoint)
+		render.Printf("S3 bucket is already mounted at %s\n", sm.config.MountPoint)
 		return nil
 	}
 
-	// Get credentials file path for this specific bucket
-	credsFile := filepath.Join("/etc", "backtide", "s3-credentials", fmt.Sprintf("passwd-s3fs-%s", sm.config.ID))
-
 	// Build mount command
-	args := []string{
-		sm.config.Bucket,
-		sm.config.MountPoint,
-		"-o", fmt.Sprintf("passwd_file=%s", credsFile),
-		"-o", "allow_other",
-		"-o", "umask=000",
+	args := []string{sm.config.Bucket, sm.config.MountPoint}
+	for _, option := range append([]string{"allow_other", "umask=000"}, sm.mountOptions()...) {
+		args = append(args, "-o", option)
+	}
+
+	cmd := exec.Command("s3fs", args...)
+	// s3fs reads a custom CA bundle only from the environment
+	cmd.Env = os.Environ()
+	if sm.config.TLS.CAFile != "" {
+		cmd.Env = append(cmd.Env, "CURL_CA_BUNDLE="+sm.config.TLS.CAFile)
+	}
+	if sm.config.TLS.CertFile != "" {
+		render.Println("Warning: s3fs cannot present client certificates; tls.cert_file is used for API requests only")
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return &MountError{Output: string(output), Err: err}
+	}
+
+	render.Printf("Successfully mounted S3 bucket %s at %s\n", sm.config.Bucket, sm.config.MountPoint)
+	return nil
+}
+
+// mountOptions returns the s3fs options shared by mounts and fstab entries
+func (sm *S3FSManager) mountOptions() []string {
+	var options []string
+	opts := sm.config.S3FS
+
+	// Authenticate with the instance IAM role or the per-bucket credentials file
+	if opts.IAMRole != "" {
+		options = append(options, fmt.Sprintf("iam_role=%s", opts.IAMRole))
+	} else {
+		credsFile := paths.CredentialsFile(sm.config.ID)
+		options = append(options, fmt.Sprintf("passwd_file=%s", credsFile))
 	}
 
 	// Use custom endpoint if specified, otherwise use region-based endpoint
 	if sm.config.Endpoint != "" {
-		args = append(args, "-o", fmt.Sprintf("url=%s", sm.config.Endpoint))
+		options = append(options, fmt.Sprintf("url=%s", sm.config.Endpoint))
 	} else if sm.config.Region != "" {
 		// Use region-specific endpoint for AWS
-		args = append(args, "-o", fmt.Sprintf("url=https://s3.%s.amazonaws.com", sm.config.Region))
+		options = append(options, fmt.Sprintf("url=https://s3.%s.amazonaws.com", sm.config.Region))
 	} else {
 		// Default to global AWS endpoint
-		args = append(args, "-o", "url=https://s3.amazonaws.com")
+		options = append(options, "url=https://s3.amazonaws.com")
 	}
 
 	// Add path style if specified
 	if sm.config.UsePathStyle {
-		args = append(args, "-o", "use_path_request_style")
+		options = append(options, "use_path_request_style")
 	}
 
-	cmd := exec.Command("s3fs", args...)
+	if sm.config.StorageClass != "" {
+		options = append(options, "storage_class="+strings.ToLower(sm.config.StorageClass))
+	}
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to mount S3 bucket: %s, error: %w", string(output), err)
+	if sm.config.TLS.InsecureSkipVerify {
+		options = append(options, "no_check_certificate", "ssl_verify_hostname=0")
 	}
 
-	fmt.Printf("Successfully mounted S3 bucket %s at %s\n", sm.config.Bucket, sm.config.MountPoint)
-	return nil
+	// Tuning options
+	if opts.MultipartSize > 0 {
+		options = append(options, fmt.Sprintf("multipart_size=%d", opts.MultipartSize))
+	}
+	if opts.ParallelCount > 0 {
+		options = append(options, fmt.Sprintf("parallel_count=%d", opts.ParallelCount))
+	}
+	if opts.UseCache != "" {
+		options = append(options, fmt.Sprintf("use_cache=%s", opts.UseCache))
+	}
+	if opts.EnsureDiskFree > 0 {
+		options = append(options, fmt.Sprintf("ensure_diskfree=%d", opts.EnsureDiskFree))
+	}
+	if opts.Retries > 0 {
+		options = append(options, fmt.Sprintf("retries=%d", opts.Retries))
+	}
+	if opts.ConnectTimeout > 0 {
+		options = append(options, fmt.Sprintf("connect_timeout=%d", opts.ConnectTimeout))
+	}
+	options = append(options, opts.ExtraOptions...)
+
+	return options
 }
 
 // UnmountS3FS unmounts the S3 bucket
 func (sm *S3FSManager) UnmountS3FS() error {
 	if !sm.isMounted() {
-		fmt.Println("S3 bucket is not mounted")
+		render.Println("S3 bucket is not mounted")
 		return nil
 	}
 
@@ -194,35 +254,15 @@ func (sm *S3FSManager) UnmountS3FS() error {
 		return fmt.Errorf("failed to unmount S3 bucket: %s, error: %w", string(output), err)
 	}
 
-	fmt.Printf("Successfully unmounted S3 bucket from %s\n", sm.config.MountPoint)
+	render.Printf("Successfully unmounted S3 bucket from %s\n", sm.config.MountPoint)
 	return nil
 }
 
-// AddToFstab adds S3FS mount to /etc/fstab for persistence
+// AddToFstab adds S3FS mount to /etc/fstab for persistence, replacing an
+// existing entry for the same bucket and mount point
 func (sm *S3FSManager) AddToFstab() error {
-	// Get credentials file path for fstab for this specific bucket
-	credsFile := filepath.Join("/etc", "backtide", "s3-credentials", fmt.Sprintf("passwd-s3fs-%s", sm.config.ID))
-
 	// Build fstab options
-	options := []string{
-		"_netdev",
-		"allow_other",
-		fmt.Sprintf("passwd_file=%s", credsFile),
-	}
-
-	// Add endpoint URL
-	if sm.config.Endpoint != "" {
-		options = append(options, fmt.Sprintf("url=%s", sm.config.Endpoint))
-	} else if sm.config.Region != "" {
-		options = append(options, fmt.Sprintf("url=https://s3.%s.amazonaws.com", sm.config.Region))
-	} else {
-		options = append(options, "url=https://s3.amazonaws.com")
-	}
-
-	// Add path style if specified
-	if sm.config.UsePathStyle {
-		options = append(options, "use_path_request_style")
-	}
+	options := append([]string{"_netdev", "allow_other"}, sm.mountOptions()...)
 
 	fstabEntry := fmt.Sprintf(
 		"s3fs#%s %s fuse %s 0 0",
@@ -231,6 +271,11 @@ func (sm *S3FSManager) AddToFstab() error {
 		strings.Join(options, ","),
 	)
 
+	// fstab mounts cannot set CURL_CA_BUNDLE, so the CA must be trusted system-wide
+	if sm.config.TLS.CAFile != "" {
+		render.Printf("Warning: fstab mounts ignore tls.ca_file; add %s to the system trust store or use mount_on_demand\n", sm.config.TLS.CAFile)
+	}
+
 	// Read current fstab
 	data, err := os.ReadFile("/etc/fstab")
 	if err != nil {
@@ -239,22 +284,33 @@ func (sm *S3FSManager) AddToFstab() error {
 
 	// Check if entry already exists
 	if strings.Contains(string(data), fstabEntry) {
-		fmt.Println("S3FS entry already exists in /etc/fstab")
+		render.Println("S3FS entry already exists in /etc/fstab")
 		return nil
 	}
 
-	// Append entry to fstab
-	f, err := os.OpenFile("/etc/fstab", os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open /etc/fstab: %w", err)
+	// Replace an outdated entry for this mount so configuration changes take effect
+	entryPattern := fmt.Sprintf("s3fs#%s %s fuse", sm.config.Bucket, sm.config.MountPoint)
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	replaced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), entryPattern) {
+			lines[i] = fstabEntry
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, fstabEntry)
 	}
-	defer f.Close()
 
-	if _, err := f.WriteString(fstabEntry + "\n"); err != nil {
+	if err := os.WriteFile("/etc/fstab", []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
 		return fmt.Errorf("failed to write to /etc/fstab: %w", err)
 	}
 
-	fmt.Println("Successfully added S3FS entry to /etc/fstab")
+	if replaced {
+		render.Println("Successfully updated S3FS entry in /etc/fstab")
+	} else {
+		render.Println("Successfully added S3FS entry to /etc/fstab")
+	}
 	return nil
 }
 
@@ -279,7 +335,7 @@ func (sm *S3FSManager) RemoveFromFstab() error {
 		return fmt.Errorf("failed to write /etc/fstab: %w", err)
 	}
 
-	fmt.Println("Successfully removed S3FS entry from /etc/fstab")
+	render.Println("Successfully removed S3FS entry from /etc/fstab")
 	return nil
 }
 
@@ -318,7 +374,36 @@ func (sm *S3FSManager) isMounted() bool {
 	return false
 }
 
+// IsMounted checks if the S3 bucket is currently mounted (exported version)
+func (sm *S3FSManager) IsMounted() bool {
+	return sm.isMounted()
+}
+
 // GetMountPoint returns the configured mount point
 func (sm *S3FSManager) GetMountPoint() string {
 	return sm.config.MountPoint
 }
+
+// MountError is a failed s3fs mount with the output of s3fs, which failure
+// notifications pass on
+type MountError struct {
+	Output string
+	Err    error
+}
+
+func (e *MountError) Error() string {
+	return fmt.Sprintf("failed to mount S3 bucket: %s, error: %v", e.Output, e.Err)
+}
+
+func (e *MountError) Unwrap() error {
+	return e.Err
+}
+
+// Lines returns the output of s3fs, for failure reports
+func (e *MountError) Lines() []string {
+	output := strings.TrimSpace(e.Output)
+	if output == "" {
+		return nil
+	}
+	return append([]string{"s3fs:"}, strings.Split(output, "\n")...)
+}
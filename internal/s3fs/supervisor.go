@@ -0,0 +1,133 @@
+package s3fs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// MountStatus is the last known health of a supervised mount
+type MountStatus struct {
+	BucketID   string
+	Bucket     string
+	MountPoint string
+	Healthy    bool
+	Error      string
+	CheckedAt  time.Time
+	Remounts   int
+}
+
+// Supervisor periodically checks bucket mounts and remounts broken ones
+type Supervisor struct {
+	mu          sync.Mutex
+	buckets     []config.BucketConfig
+	timeout     time.Duration
+	autoRemount bool
+	status      map[string]*MountStatus
+
+	// OnFailure is called when a mount becomes unhealthy and could not be repaired
+	OnFailure func(bucket config.BucketConfig, err error)
+	// OnRecover is called when a broken mount was remounted or is healthy again
+	OnRecover func(bucket config.BucketConfig)
+}
+
+// NewSupervisor creates a mount supervisor
+func NewSupervisor(timeout time.Duration, autoRemount bool) *Supervisor {
+	return &Supervisor{
+		timeout:     timeout,
+		autoRemount: autoRemount,
+		status:      make(map[string]*MountStatus),
+	}
+}
+
+// SetBuckets replaces the supervised buckets, dropping status for removed ones
+func (s *Supervisor) SetBuckets(buckets []config.BucketConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buckets = buckets
+	keep := make(map[string]bool)
+	for _, bucket := range buckets {
+		keep[bucket.ID] = true
+	}
+	for id := range s.status {
+		if !keep[id] {
+			delete(s.status, id)
+		}
+	}
+}
+
+// CheckAll checks every supervised mount once
+func (s *Supervisor) CheckAll() {
+	s.mu.Lock()
+	buckets := append([]config.BucketConfig(nil), s.buckets...)
+	s.mu.Unlock()
+
+	for _, bucket := range buckets {
+		s.check(bucket)
+	}
+}
+
+// check checks one mount, remounting it if allowed, and reports state changes
+func (s *Supervisor) check(bucket config.BucketConfig) {
+	manager := NewS3FSManager(bucket)
+	err := manager.CheckMount(s.timeout)
+	remounted := false
+	if err != nil && s.autoRemount {
+		render.Printf("⚠️  S3 mount %s unhealthy: %v; remounting\n", bucket.MountPoint, err)
+		if remountErr := manager.Remount(); remountErr != nil {
+			err = fmt.Errorf("%v; remount failed: %w", err, remountErr)
+		} else if checkErr := manager.CheckMount(s.timeout); checkErr != nil {
+			err = fmt.Errorf("remounted but still unhealthy: %w", checkErr)
+		} else {
+			render.Printf("✅ Remounted S3 bucket %s at %s\n", bucket.Name, bucket.MountPoint)
+			err = nil
+			remounted = true
+		}
+	}
+
+	s.mu.Lock()
+	status, known := s.status[bucket.ID]
+	if !known {
+		status = &MountStatus{BucketID: bucket.ID, Healthy: true}
+		s.status[bucket.ID] = status
+	}
+	wasHealthy := status.Healthy
+	status.Bucket = bucket.Name
+	status.MountPoint = bucket.MountPoint
+	status.CheckedAt = time.Now()
+	status.Healthy = err == nil
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if remounted {
+		status.Remounts++
+	}
+	s.mu.Unlock()
+
+	// Only report transitions so a dead mount is not reported every interval
+	if err != nil && wasHealthy && s.OnFailure != nil {
+		s.OnFailure(bucket, err)
+	}
+	if err == nil && (remounted || !wasHealthy) && s.OnRecover != nil {
+		s.OnRecover(bucket)
+	}
+}
+
+// Status returns the last known health of each supervised mount
+func (s *Supervisor) Status() []MountStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var statuses []MountStatus
+	for _, bucket := range s.buckets {
+		if status, ok := s.status[bucket.ID]; ok {
+			statuses = append(statuses, *status)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,470 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Named schedules and the cron expressions they expand to
+var namedSchedules = map[string]string{
+	"hourly":  "0 * * * *",
+	"daily":   "0 2 * * *",
+	"1d":      "0 2 * * *",
+	"weekly":  "0 2 * * 0",
+	"7d":      "0 2 * * 0",
+	"monthly": "0 2 1 * *",
+	"30d":     "0 2 1 * *",
+}
+
+// MinInterval is the shortest time allowed between two runs of a job
+const MinInterval = 5 * time.Minute
+
+// Schedule computes run times for a backup job
+type Schedule struct {
+	expr     string
+	interval time.Duration
+	cron     *cronSpec
+	location *time.Location
+}
+
+// Parse builds a Schedule from a job schedule configuration, rejecting
+// schedules that run more often than MinInterval or whose jitter would let
+// runs overlap
+func Parse(cfg config.ScheduleConfig) (*Schedule, error) {
+	s, err := parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	spacing := s.MinSpacing()
+	if spacing == 0 {
+		return nil, fmt.Errorf("schedule %s never runs", cfg.Interval)
+	}
+	if spacing < MinInterval {
+		return nil, fmt.Errorf("schedule %s runs every %s; the minimum is %s", cfg.Interval, spacing, MinInterval)
+	}
+	if jitter, err := cfg.JitterDuration(); err != nil {
+		return nil, err
+	} else if jitter >= spacing {
+		return nil, fmt.Errorf("schedule jitter %s must be shorter than the %s between runs", cfg.Jitter, spacing)
+	}
+	return s, nil
+}
+
+// parse builds a Schedule from the schedule expression
+func parse(cfg config.ScheduleConfig) (*Schedule, error) {
+	location, err := cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	expr := strings.TrimSpace(cfg.Interval)
+	s := &Schedule{expr: expr, location: location}
+
+	if named, ok := namedSchedules[strings.ToLower(expr)]; ok {
+		spec, err := parseCron(named)
+		if err != nil {
+			return nil, err
+		}
+		s.cron = spec
+		return s, nil
+	}
+
+	if len(strings.Fields(expr)) == 5 {
+		spec, err := parseCron(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		s.cron = spec
+		return s, nil
+	}
+
+	// Clock-aligned steps, e.g. "*/15m" at :00, :15, :30 and :45
+	if step, ok := strings.CutPrefix(expr, "*/"); ok {
+		cronExpr, err := stepCron(step)
+		if err != nil {
+			return nil, err
+		}
+		if s.cron, err = parseCron(cronExpr); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	// Fall back to a fixed interval (e.g., "24h", "15m", "15min")
+	durationExpr := strings.TrimSuffix(strings.ToLower(expr), "in")
+	duration, err := time.ParseDuration(durationExpr)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("unknown schedule interval: %s", cfg.Interval)
+	}
+	s.interval = duration
+	return s, nil
+}
+
+// stepCron returns the cron expression of a clock-aligned step such as "15m"
+// or "2h", which must divide an hour or a day evenly
+func stepCron(step string) (string, error) {
+	d, err := time.ParseDuration(strings.TrimSuffix(strings.ToLower(step), "in"))
+	switch {
+	case err != nil || d <= 0:
+		return "", fmt.Errorf("invalid schedule step: */%s", step)
+	case d%time.Hour == 0 && d < 24*time.Hour && 24%int(d/time.Hour) == 0:
+		if d == time.Hour {
+			return "0 * * * *", nil
+		}
+		return fmt.Sprintf("0 */%d * * *", int(d/time.Hour)), nil
+	case d%time.Minute == 0 && d < time.Hour && 60%int(d/time.Minute) == 0:
+		return fmt.Sprintf("*/%d * * * *", int(d/time.Minute)), nil
+	}
+	return "", fmt.Errorf("schedule step */%s must divide an hour or a day evenly, e.g. */15m or */2h", step)
+}
+
+// MinSpacing returns the shortest time between two runs of the schedule
+func (s *Schedule) MinSpacing() time.Duration {
+	if s.cron == nil {
+		return s.interval
+	}
+
+	// Consecutive runs cover every gap of the minute and hour fields
+	var spacing time.Duration
+	prev := s.cron.next(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+	for i := 0; i < 64 && !prev.IsZero(); i++ {
+		next := s.cron.next(prev)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(prev); spacing == 0 || gap < spacing {
+			spacing = gap
+		}
+		prev = next
+	}
+	return spacing
+}
+
+// Location returns the time zone the schedule is evaluated in
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// IsInterval reports whether the schedule is a fixed interval rather than calendar based
+func (s *Schedule) IsInterval() bool {
+	return s.cron == nil
+}
+
+// Interval returns the fixed interval for interval schedules
+func (s *Schedule) Interval() time.Duration {
+	return s.interval
+}
+
+// String returns the original schedule expression
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first run time strictly after the given time
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s.cron == nil {
+		return after.Add(s.interval)
+	}
+	return s.cron.next(after.In(s.location))
+}
+
+// Previous returns the last run time at or before the given time, or the zero
+// time if there is none; interval schedules count from anchor
+func (s *Schedule) Previous(anchor, at time.Time) time.Time {
+	if s.cron == nil {
+		if anchor.IsZero() || at.Before(anchor) {
+			return time.Time{}
+		}
+		return anchor.Add(at.Sub(anchor) / s.interval * s.interval)
+	}
+	return s.cron.previous(at.In(s.location))
+}
+
+// JitterOffset returns a delay below jitter that is fixed for a seed, such as
+// the host and job, so hosts sharing a schedule spread their runs evenly
+func JitterOffset(jitter time.Duration, seed string) time.Duration {
+	seconds := uint64(jitter / time.Second)
+	if seconds == 0 {
+		return 0
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	return time.Duration(hash.Sum64()%seconds) * time.Second
+}
+
+// NextN returns the next n run times after the given time
+func (s *Schedule) NextN(after time.Time, n int) []time.Time {
+	var times []time.Time
+	t := after
+	for i := 0; i < n; i++ {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// CronExpression returns the schedule as a five-field cron expression
+func (s *Schedule) CronExpression() (string, error) {
+	if s.cron != nil {
+		return s.cron.String(), nil
+	}
+
+	switch {
+	case s.interval == 24*time.Hour:
+		return "0 0 * * *", nil
+	case s.interval%time.Hour == 0 && 24%int(s.interval/time.Hour) == 0:
+		hours := int(s.interval / time.Hour)
+		if hours == 1 {
+			return "0 * * * *", nil
+		}
+		return fmt.Sprintf("0 */%d * * *", hours), nil
+	case s.interval%time.Minute == 0 && s.interval < time.Hour && 60%int(s.interval/time.Minute) == 0:
+		return fmt.Sprintf("*/%d * * * *", int(s.interval/time.Minute)), nil
+	}
+
+	return "", fmt.Errorf("interval %s cannot be expressed as a cron schedule", s.interval)
+}
+
+// OnCalendar returns systemd OnCalendar= values equivalent to the schedule
+func (s *Schedule) OnCalendar() ([]string, error) {
+	spec := s.cron
+	if spec == nil {
+		expr, err := s.CronExpression()
+		if err != nil {
+			return nil, err
+		}
+		if spec, err = parseCron(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	suffix := ""
+	if s.location != time.Local && s.location.String() != "Local" {
+		suffix = " " + s.location.String()
+	}
+
+	timePart := fmt.Sprintf("%s:%s:00", spec.hour.calendar(2), spec.minute.calendar(2))
+	month := spec.month.calendar(2)
+
+	// Cron ORs day-of-month and day-of-week when both are restricted,
+	// while systemd ANDs them, so emit one entry for each.
+	if !spec.dom.all && !spec.dow.all {
+		return []string{
+			fmt.Sprintf("*-%s-%s %s%s", month, spec.dom.calendar(2), timePart, suffix),
+			fmt.Sprintf("%s *-%s-* %s%s", spec.dow.weekdays(), month, timePart, suffix),
+		}, nil
+	}
+
+	prefix := ""
+	if !spec.dow.all {
+		prefix = spec.dow.weekdays() + " "
+	}
+	return []string{
+		fmt.Sprintf("%s*-%s-%s %s%s", prefix, month, spec.dom.calendar(2), timePart, suffix),
+	}, nil
+}
+
+// cronField is the set of values allowed for one cron field
+type cronField struct {
+	min, max int
+	bits     uint64
+	all      bool
+	source   string
+}
+
+// cronSpec is a parsed five-field cron expression
+type cronSpec struct {
+	minute, hour, dom, month, dow cronField
+}
+
+// parseCron parses a standard five-field cron expression
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var spec cronSpec
+	var err error
+	if spec.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if spec.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if spec.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if spec.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if spec.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// Both 0 and 7 mean Sunday
+	if spec.dow.has(7) {
+		spec.dow.bits = (spec.dow.bits | 1) &^ (1 << 7)
+	}
+	spec.dow.max = 6
+
+	return &spec, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges, and steps
+func parseCronField(field string, min, max int) (cronField, error) {
+	f := cronField{min: min, max: max, source: field}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return f, fmt.Errorf("invalid step in %q", part)
+			}
+			step = s
+			part = part[:idx]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+			if step == 1 {
+				f.all = true
+			}
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return f, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return f, fmt.Errorf("invalid value %q", part)
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return f, fmt.Errorf("value out of range %d-%d in %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			f.bits |= 1 << uint(v)
+		}
+	}
+
+	return f, nil
+}
+
+// has reports whether the field allows the given value
+func (f cronField) has(v int) bool {
+	return f.bits&(1<<uint(v)) != 0
+}
+
+// values returns the allowed values in ascending order
+func (f cronField) values() []int {
+	var values []int
+	for v := f.min; v <= f.max; v++ {
+		if f.has(v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// calendar renders the field for a systemd calendar expression
+func (f cronField) calendar(width int) string {
+	if f.all {
+		return "*"
+	}
+	var parts []string
+	for _, v := range f.values() {
+		parts = append(parts, fmt.Sprintf("%0*d", width, v))
+	}
+	return strings.Join(parts, ",")
+}
+
+// weekdays renders a day-of-week field as systemd weekday names
+func (f cronField) weekdays() string {
+	names := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+	var parts []string
+	for _, v := range f.values() {
+		parts = append(parts, names[v])
+	}
+	return strings.Join(parts, ",")
+}
+
+// String returns the cron expression
+func (c *cronSpec) String() string {
+	return strings.Join([]string{c.minute.source, c.hour.source, c.dom.source, c.month.source, c.dow.source}, " ")
+}
+
+// dayMatches applies cron's day-of-month/day-of-week semantics
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom.has(t.Day())
+	dowMatch := c.dow.has(int(t.Weekday()))
+	if !c.dom.all && !c.dow.all {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// next returns the first matching time strictly after t, in t's location
+// Times that fall into a DST gap are moved forward rather than skipped.
+func (c *cronSpec) next(t time.Time) time.Time {
+	loc := t.Location()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	for i := 0; i < 366*5; i++ {
+		if c.month.has(int(day.Month())) && c.dayMatches(day) {
+			for _, hour := range c.hour.values() {
+				for _, minute := range c.minute.values() {
+					candidate := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+					if candidate.After(t) {
+						return candidate
+					}
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+
+	return time.Time{}
+}
+
+// previous returns the last matching time at or before t, in t's location
+func (c *cronSpec) previous(t time.Time) time.Time {
+	loc := t.Location()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	hours, minutes := c.hour.values(), c.minute.values()
+
+	for i := 0; i < 366*5; i++ {
+		if c.month.has(int(day.Month())) && c.dayMatches(day) {
+			for h := len(hours) - 1; h >= 0; h-- {
+				for m := len(minutes) - 1; m >= 0; m-- {
+					candidate := time.Date(day.Year(), day.Month(), day.Day(), hours[h], minutes[m], 0, 0, loc)
+					if !candidate.After(t) {
+						return candidate
+					}
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()-1, 0, 0, 0, 0, loc)
+	}
+
+	return time.Time{}
+}
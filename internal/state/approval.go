@@ -0,0 +1,57 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrTOTPUsed is returned for a TOTP code whose time step was already used
+var ErrTOTPUsed = errors.New("the TOTP code was already used")
+
+// totpFile returns the path recording the last TOTP time step used per
+// approver; profiles share it, as they may share a secret
+func totpFile() string {
+	return filepath.Join(baseDir(), "totp-used.json")
+}
+
+// UseTOTPStep records that an approver, such as a TOTP secret file, used the
+// code of a time step. It fails with ErrTOTPUsed for a step at or before the
+// approver's last used one, so a code approves only once.
+func UseTOTPStep(approver string, step uint64) error {
+	if err := os.MkdirAll(baseDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return withFileLock(totpFile()+".lock", func() error {
+		used := make(map[string]uint64)
+		data, err := os.ReadFile(totpFile())
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read used TOTP codes: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &used); err != nil {
+				return fmt.Errorf("failed to parse used TOTP codes: %w", err)
+			}
+		}
+		if last, ok := used[approver]; ok && step <= last {
+			return ErrTOTPUsed
+		}
+		used[approver] = step
+
+		if data, err = json.MarshalIndent(used, "", "  "); err != nil {
+			return fmt.Errorf("failed to marshal used TOTP codes: %w", err)
+		}
+		tempFile := totpFile() + ".tmp"
+		if err := os.WriteFile(tempFile, data, 0600); err != nil {
+			return fmt.Errorf("failed to write used TOTP codes: %w", err)
+		}
+		if err := os.Rename(tempFile, totpFile()); err != nil {
+			os.Remove(tempFile)
+			return fmt.Errorf("failed to rename used TOTP codes: %w", err)
+		}
+		return nil
+	})
+}
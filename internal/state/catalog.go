@@ -0,0 +1,122 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CatalogEntry records a backup known to this host and where it is stored
+type CatalogEntry struct {
+	BackupID  string    `json:"backup_id"`
+	Job       string    `json:"job,omitempty"`
+	Location  string    `json:"location"` // directory containing the backup directory
+	BucketID  string    `json:"bucket_id,omitempty"`
+	Host      string    `json:"host,omitempty"` // host that wrote the backup, if recorded
+	Timestamp time.Time `json:"timestamp"`
+	TotalSize int64     `json:"total_size"`
+	Checksum  string    `json:"checksum"`
+
+	Comment string            `json:"comment,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Pinned  bool              `json:"pinned,omitempty"`
+	// ExpiresAt overrides the job retention unless zero
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// catalogFile returns the path of the backup catalog
+func catalogFile() string {
+	return filepath.Join(Dir(), "catalog.json")
+}
+
+// LoadCatalog returns the catalog entries, newest first
+func LoadCatalog() ([]CatalogEntry, error) {
+	data, err := os.ReadFile(catalogFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	var entries []CatalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+	return entries, nil
+}
+
+// RecordBackup adds a backup to the catalog, replacing an entry for the same backup and location
+func RecordBackup(entry CatalogEntry) error {
+	return updateCatalog(func(entries []CatalogEntry) []CatalogEntry {
+		return append(removeEntries(entries, func(e CatalogEntry) bool {
+			return e.Location == entry.Location && e.BackupID == entry.BackupID
+		}), entry)
+	})
+}
+
+// RemoveBackup removes a backup from the catalog
+func RemoveBackup(location, backupID string) error {
+	return updateCatalog(func(entries []CatalogEntry) []CatalogEntry {
+		return removeEntries(entries, func(e CatalogEntry) bool {
+			return e.Location == location && e.BackupID == backupID
+		})
+	})
+}
+
+// ReplaceLocation replaces all catalog entries for a storage location with entries
+func ReplaceLocation(location string, entries []CatalogEntry) error {
+	return updateCatalog(func(existing []CatalogEntry) []CatalogEntry {
+		return append(removeEntries(existing, func(e CatalogEntry) bool {
+			return e.Location == location
+		}), entries...)
+	})
+}
+
+// removeEntries returns entries without those matching drop
+func removeEntries(entries []CatalogEntry, drop func(CatalogEntry) bool) []CatalogEntry {
+	var kept []CatalogEntry
+	for _, entry := range entries {
+		if !drop(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// updateCatalog applies update to the catalog under a lock and saves it atomically
+func updateCatalog(update func([]CatalogEntry) []CatalogEntry) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return withFileLock(catalogFile()+".lock", func() error {
+		entries, err := LoadCatalog()
+		if err != nil {
+			return err
+		}
+
+		entries = update(entries)
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Timestamp.After(entries[j].Timestamp)
+		})
+
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal catalog: %w", err)
+		}
+
+		tempFile := catalogFile() + ".tmp"
+		if err := os.WriteFile(tempFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write catalog: %w", err)
+		}
+		if err := os.Rename(tempFile, catalogFile()); err != nil {
+			os.Remove(tempFile)
+			return fmt.Errorf("failed to rename catalog: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// digestFile returns the path recording when the last digest was sent
+func digestFile() string {
+	return filepath.Join(Dir(), "digest.json")
+}
+
+// digestRecord is the persisted form of the last digest
+type digestRecord struct {
+	SentAt time.Time `json:"sent_at"`
+}
+
+// LoadDigestSent returns when the daemon last sent a digest, zero if never
+func LoadDigestSent() (time.Time, error) {
+	var record digestRecord
+	data, err := os.ReadFile(digestFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to read digest state: %w", err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse digest state: %w", err)
+	}
+	return record.SentAt, nil
+}
+
+// SaveDigestSent atomically records when a digest was sent
+func SaveDigestSent(sentAt time.Time) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(digestRecord{SentAt: sentAt}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest state: %w", err)
+	}
+
+	tempFile := digestFile() + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write digest state: %w", err)
+	}
+	if err := os.Rename(tempFile, digestFile()); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename digest state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StoppedContainer is a Docker container stopped for a backup
+type StoppedContainer struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Image   string    `json:"image"`
+	Stopped time.Time `json:"stopped"`
+	// DependsOn holds the IDs of containers that must be started first
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Paused and RestartPolicy are restored when the container is started;
+	// the restart policy is disabled while the container is held stopped
+	Paused        bool   `json:"paused,omitempty"`
+	RestartPolicy string `json:"restart_policy,omitempty"`
+}
+
+// ContainerHold records the containers a run needs to stay stopped: those it
+// stopped and those already stopped by runs in progress when it started
+type ContainerHold struct {
+	RunID      string             `json:"run_id"`
+	Job        string             `json:"job"`
+	PID        int                `json:"pid"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Containers []StoppedContainer `json:"containers"`
+}
+
+// legacyRunID names the hold read from the single stop list of older versions
+const legacyRunID = "legacy"
+
+// Orphaned reports whether the process that created the hold has exited
+// without releasing it
+func (h ContainerHold) Orphaned() bool {
+	return !processAlive(h.PID)
+}
+
+// Age returns how long the hold has existed
+func (h ContainerHold) Age() time.Duration {
+	return time.Since(h.CreatedAt)
+}
+
+// dockerDir returns the directory holding one container hold per run
+func dockerDir() string {
+	return filepath.Join(Dir(), "docker")
+}
+
+// legacyContainersFile is the single stop list written before holds were kept per run
+func legacyContainersFile() string {
+	return filepath.Join(baseDir(), "containers.json")
+}
+
+// HoldContainers records the containers a run stopped, adding those held by
+// other live runs so they are not restarted while this run still needs them
+// stopped; it returns the containers now held by the run
+func HoldContainers(runID, job string, stopped []StoppedContainer) ([]StoppedContainer, error) {
+	var held []StoppedContainer
+	err := withDockerLock(func(holds []ContainerHold) error {
+		held = mergeContainers(nil, stopped)
+		for _, hold := range holds {
+			if hold.RunID != runID && processAlive(hold.PID) {
+				held = mergeContainers(held, hold.Containers)
+			}
+		}
+		if len(held) == 0 {
+			return nil
+		}
+		return writeContainerHold(ContainerHold{RunID: runID, Job: job, PID: os.Getpid(), CreatedAt: time.Now(), Containers: held})
+	})
+	return held, err
+}
+
+// ReleaseContainers removes a run's hold and returns the containers to restart:
+// those it held, plus those of holds left behind by exited processes, that no
+// other live run still holds
+func ReleaseContainers(runID string) ([]StoppedContainer, error) {
+	restart, _, err := releaseHolds(func(hold ContainerHold) bool {
+		return hold.RunID == runID || hold.Orphaned()
+	})
+	return restart, err
+}
+
+// ReleaseOrphanedHolds removes the holds of exited processes that are at least
+// olderThan old; it returns the released holds and the containers to restart
+func ReleaseOrphanedHolds(olderThan time.Duration) ([]ContainerHold, []StoppedContainer, error) {
+	restart, released, err := releaseHolds(func(hold ContainerHold) bool {
+		return hold.Orphaned() && hold.Age() >= olderThan
+	})
+	return released, restart, err
+}
+
+// OrphanedHolds returns the holds of exited processes that are at least olderThan old
+func OrphanedHolds(olderThan time.Duration) ([]ContainerHold, error) {
+	var orphaned []ContainerHold
+	err := withDockerLock(func(holds []ContainerHold) error {
+		for _, hold := range holds {
+			if hold.Orphaned() && hold.Age() >= olderThan {
+				orphaned = append(orphaned, hold)
+			}
+		}
+		return nil
+	})
+	return orphaned, err
+}
+
+// releaseHolds removes the holds selected by release and returns the containers
+// they held that no remaining hold of a live run still holds
+func releaseHolds(release func(ContainerHold) bool) ([]StoppedContainer, []ContainerHold, error) {
+	var restart []StoppedContainer
+	var released []ContainerHold
+	err := withDockerLock(func(holds []ContainerHold) error {
+		var candidates []StoppedContainer
+		stillHeld := make(map[string]bool)
+		for _, hold := range holds {
+			if !release(hold) {
+				if !hold.Orphaned() {
+					for _, container := range hold.Containers {
+						stillHeld[container.ID] = true
+					}
+				}
+				continue
+			}
+			candidates = mergeContainers(candidates, hold.Containers)
+			if err := os.Remove(containerHoldFile(hold.RunID)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove container hold: %w", err)
+			}
+			released = append(released, hold)
+		}
+
+		for _, container := range candidates {
+			if !stillHeld[container.ID] {
+				restart = append(restart, container)
+			}
+		}
+		return nil
+	})
+	return restart, released, err
+}
+
+// ContainerHolds returns the holds of all runs, including those of exited processes
+func ContainerHolds() ([]ContainerHold, error) {
+	var result []ContainerHold
+	err := withDockerLock(func(holds []ContainerHold) error {
+		result = holds
+		return nil
+	})
+	return result, err
+}
+
+// withDockerLock runs fn on the saved holds while holding an exclusive lock
+// shared by all backtide processes
+func withDockerLock(fn func([]ContainerHold) error) error {
+	if err := os.MkdirAll(dockerDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create docker state directory: %w", err)
+	}
+
+	return withFileLock(filepath.Join(dockerDir(), ".lock"), func() error {
+		entries, err := os.ReadDir(dockerDir())
+		if err != nil {
+			return fmt.Errorf("failed to read docker state: %w", err)
+		}
+		var holds []ContainerHold
+		for _, entry := range entries {
+			if !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dockerDir(), entry.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to read container hold: %w", err)
+			}
+			var hold ContainerHold
+			if err := json.Unmarshal(data, &hold); err != nil {
+				return fmt.Errorf("failed to parse container hold %s: %w", entry.Name(), err)
+			}
+			holds = append(holds, hold)
+		}
+
+		// Stop list of runs that crashed before holds were kept per run
+		if info, err := os.Stat(legacyContainersFile()); err == nil {
+			data, err := os.ReadFile(legacyContainersFile())
+			if err != nil {
+				return fmt.Errorf("failed to read container state: %w", err)
+			}
+			hold := ContainerHold{RunID: legacyRunID, CreatedAt: info.ModTime()}
+			if json.Unmarshal(data, &hold.Containers) == nil && len(hold.Containers) > 0 {
+				holds = append(holds, hold)
+			} else {
+				os.Remove(legacyContainersFile())
+			}
+		}
+		return fn(holds)
+	})
+}
+
+// containerHoldFile returns the hold path of a run
+func containerHoldFile(runID string) string {
+	if runID == legacyRunID {
+		return legacyContainersFile()
+	}
+	return filepath.Join(dockerDir(), runID+".json")
+}
+
+// writeContainerHold atomically saves a run's hold; the caller holds the lock
+func writeContainerHold(hold ContainerHold) error {
+	data, err := json.MarshalIndent(hold, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal container hold: %w", err)
+	}
+	path := containerHoldFile(hold.RunID)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write container hold: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("failed to write container hold: %w", err)
+	}
+	return nil
+}
+
+// mergeContainers appends the containers of add not yet in list
+func mergeContainers(list, add []StoppedContainer) []StoppedContainer {
+	seen := make(map[string]bool)
+	for _, container := range list {
+		seen[container.ID] = true
+	}
+	for _, container := range add {
+		if !seen[container.ID] {
+			seen[container.ID] = true
+			list = append(list, container)
+		}
+	}
+	return list
+}
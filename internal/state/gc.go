@@ -0,0 +1,64 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StaleFile is a state file left behind by a run or bucket that no longer exists
+type StaleFile struct {
+	Path   string
+	Reason string
+}
+
+// StaleFiles returns state files that are safe to remove: records of runs whose
+// process exited, interrupted atomic writes, and mount state of buckets that are
+// no longer configured
+func StaleFiles(bucketIDs []string) []StaleFile {
+	var stale []StaleFile
+
+	// Interrupted atomic writes
+	for _, dir := range []string{Dir(), runsDir(), mountsDir(), dockerDir()} {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+		for _, path := range matches {
+			stale = append(stale, StaleFile{Path: path, Reason: "interrupted state write"})
+		}
+	}
+
+	// Run records and cancel markers of processes that exited without cleaning up
+	if entries, err := os.ReadDir(runsDir()); err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			runID := strings.TrimSuffix(strings.TrimSuffix(name, ".json"), ".cancel")
+			if runID == name {
+				continue
+			}
+			if record, err := LoadRun(runID); err == nil && processAlive(record.PID) {
+				continue
+			}
+			stale = append(stale, StaleFile{Path: filepath.Join(runsDir(), name), Reason: "record of a run that is no longer running"})
+		}
+	}
+
+	// Leases, locks and write records of removed buckets
+	known := make(map[string]bool)
+	for _, id := range bucketIDs {
+		known[id] = true
+	}
+	if entries, err := os.ReadDir(mountsDir()); err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			id := name
+			for _, suffix := range []string{".last-write.json", ".json", ".lock"} {
+				id = strings.TrimSuffix(id, suffix)
+			}
+			if id == name || known[id] {
+				continue
+			}
+			stale = append(stale, StaleFile{Path: filepath.Join(mountsDir(), name), Reason: "mount state of removed bucket " + id})
+		}
+	}
+
+	return stale
+}
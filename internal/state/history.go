@@ -0,0 +1,87 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryEntry records a finished backup run
+type HistoryEntry struct {
+	RunID      string    `json:"run_id"`
+	Job        string    `json:"job"`
+	Host       string    `json:"host"`
+	State      string    `json:"state"` // succeeded, failed or cancelled
+	BackupID   string    `json:"backup_id,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	TotalSize  int64     `json:"total_size"`
+	FileCount  int       `json:"file_count"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Duration returns how long the run took
+func (e HistoryEntry) Duration() time.Duration {
+	return e.FinishedAt.Sub(e.StartedAt)
+}
+
+// historyFile returns the path of the run history
+func historyFile() string {
+	return filepath.Join(Dir(), "history.jsonl")
+}
+
+// RecordHistory appends a finished run to the run history
+func RecordHistory(entry HistoryEntry) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	file, err := os.OpenFile(historyFile(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open run history: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	return nil
+}
+
+// LoadHistory returns runs that started at or after since, oldest first
+func LoadHistory(since time.Time) ([]HistoryEntry, error) {
+	file, err := os.Open(historyFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		// Skip a line torn by a crash rather than failing the whole export
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.StartedAt.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,139 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Maintenance describes the backups in progress on this host. Its flag files
+// exist only while a backup runs, so load balancers, health checks and
+// monitoring can test for them: MaintenanceFile for any job and
+// MaintenanceJobFile for one job.
+type Maintenance struct {
+	Active bool             `json:"active"`
+	Runs   []MaintenanceRun `json:"runs"`
+}
+
+// MaintenanceRun is one backup run in progress
+type MaintenanceRun struct {
+	RunID     string    `json:"run_id"`
+	Job       string    `json:"job"`
+	Phase     string    `json:"phase"`
+	StartedAt time.Time `json:"started_at"`
+	PID       int       `json:"pid"`
+}
+
+// unsafeJobChars are replaced in the names of per-job flag files
+var unsafeJobChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// MaintenanceFile returns the path of the flag file present while any backup runs
+func MaintenanceFile() string {
+	return filepath.Join(Dir(), "maintenance.json")
+}
+
+// MaintenanceJobFile returns the path of the flag file present while a job's backup runs
+func MaintenanceJobFile(job string) string {
+	name := strings.Trim(unsafeJobChars.ReplaceAllString(job, "-"), "-")
+	return filepath.Join(maintenanceDir(), name+".json")
+}
+
+// maintenanceDir returns the directory holding per-job flag files
+func maintenanceDir() string {
+	return filepath.Join(Dir(), "maintenance")
+}
+
+// CurrentMaintenance returns the backups in progress
+func CurrentMaintenance() (Maintenance, error) {
+	runs, err := ListRuns()
+	if err != nil {
+		return Maintenance{}, err
+	}
+	m := Maintenance{Active: len(runs) > 0, Runs: []MaintenanceRun{}}
+	for _, run := range runs {
+		m.Runs = append(m.Runs, MaintenanceRun{
+			RunID:     run.ID,
+			Job:       run.JobName,
+			Phase:     run.Phase,
+			StartedAt: run.StartedAt,
+			PID:       run.PID,
+		})
+	}
+	return m, nil
+}
+
+// SyncMaintenance rewrites the flag files from the runs in progress, removing
+// those of finished runs and of runs whose process died
+func SyncMaintenance() error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return withFileLock(MaintenanceFile()+".lock", syncMaintenance)
+}
+
+// syncMaintenance rewrites the flag files; the caller holds the lock
+func syncMaintenance() error {
+	m, err := CurrentMaintenance()
+	if err != nil {
+		return err
+	}
+
+	if !m.Active {
+		if err := os.Remove(MaintenanceFile()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove maintenance flag: %w", err)
+		}
+	} else if err := writeMaintenanceFile(MaintenanceFile(), m); err != nil {
+		return err
+	}
+
+	// One file per job, listing that job's runs
+	jobs := make(map[string]Maintenance)
+	for _, run := range m.Runs {
+		path := MaintenanceJobFile(run.Job)
+		jobs[path] = Maintenance{Active: true, Runs: append(jobs[path].Runs, run)}
+	}
+	if len(jobs) > 0 {
+		if err := os.MkdirAll(maintenanceDir(), 0755); err != nil {
+			return fmt.Errorf("failed to create maintenance directory: %w", err)
+		}
+	}
+	for path, jm := range jobs {
+		if err := writeMaintenanceFile(path, jm); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(maintenanceDir())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read maintenance directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(maintenanceDir(), entry.Name())
+		if _, active := jobs[path]; !active {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// writeMaintenanceFile atomically writes a flag file
+func writeMaintenanceFile(path string, m Maintenance) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance flag: %w", err)
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write maintenance flag: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename maintenance flag: %w", err)
+	}
+	return nil
+}
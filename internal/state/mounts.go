@@ -0,0 +1,157 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MountLease records that a run is using an on-demand bucket mount
+type MountLease struct {
+	Holder     string    `json:"holder"`
+	PID        int       `json:"pid"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// mountsDir returns the directory holding mount leases
+func mountsDir() string {
+	return filepath.Join(Dir(), "mounts")
+}
+
+// AcquireMount registers holder as a user of a bucket mount; mount is called
+// when the holder is the first live user
+func AcquireMount(bucketID, holder string, mount func() error) error {
+	return withMountLock(bucketID, func(leases []MountLease) ([]MountLease, error) {
+		if len(leases) == 0 {
+			if err := mount(); err != nil {
+				return leases, err
+			}
+		}
+		return append(leases, MountLease{Holder: holder, PID: os.Getpid(), AcquiredAt: time.Now()}), nil
+	})
+}
+
+// ReleaseMount removes holder's lease; unmount is called when no live users remain
+func ReleaseMount(bucketID, holder string, unmount func() error) error {
+	return withMountLock(bucketID, func(leases []MountLease) ([]MountLease, error) {
+		var remaining []MountLease
+		for _, lease := range leases {
+			if lease.Holder != holder {
+				remaining = append(remaining, lease)
+			}
+		}
+		if len(remaining) == 0 {
+			if err := unmount(); err != nil {
+				return remaining, err
+			}
+		}
+		return remaining, nil
+	})
+}
+
+// MountLeases returns the live leases on a bucket mount
+func MountLeases(bucketID string) ([]MountLease, error) {
+	var live []MountLease
+	err := withMountLock(bucketID, func(leases []MountLease) ([]MountLease, error) {
+		live = leases
+		return leases, nil
+	})
+	return live, err
+}
+
+// withMountLock runs update on the bucket's live leases while holding an
+// exclusive lock shared by all backtide processes, then saves the result
+func withMountLock(bucketID string, update func([]MountLease) ([]MountLease, error)) error {
+	if err := os.MkdirAll(mountsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create mounts directory: %w", err)
+	}
+
+	return withFileLock(filepath.Join(mountsDir(), bucketID+".lock"), func() error {
+		return updateMountLeases(bucketID, update)
+	})
+}
+
+// updateMountLeases applies update to the saved leases; the caller holds the lock
+func updateMountLeases(bucketID string, update func([]MountLease) ([]MountLease, error)) error {
+	leaseFile := filepath.Join(mountsDir(), bucketID+".json")
+	var leases []MountLease
+	if data, err := os.ReadFile(leaseFile); err == nil {
+		if err := json.Unmarshal(data, &leases); err != nil {
+			return fmt.Errorf("failed to parse mount leases: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read mount leases: %w", err)
+	}
+
+	// Drop leases left behind by processes that exited without releasing
+	var live []MountLease
+	for _, lease := range leases {
+		if processAlive(lease.PID) {
+			live = append(live, lease)
+		}
+	}
+
+	updated, updateErr := update(live)
+
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal mount leases: %w", err)
+	}
+	if err := os.WriteFile(leaseFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write mount leases: %w", err)
+	}
+	return updateErr
+}
+
+// BucketWrite records the last backup successfully written to a bucket
+type BucketWrite struct {
+	BackupID string    `json:"backup_id"`
+	Job      string    `json:"job"`
+	At       time.Time `json:"at"`
+}
+
+// bucketWriteFile returns the path recording a bucket's last successful write
+func bucketWriteFile(bucketID string) string {
+	return filepath.Join(mountsDir(), bucketID+".last-write.json")
+}
+
+// RecordBucketWrite records a backup successfully written to a bucket
+func RecordBucketWrite(bucketID string, write BucketWrite) error {
+	if err := os.MkdirAll(mountsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create mounts directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(write, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bucket write: %w", err)
+	}
+
+	tempFile := bucketWriteFile(bucketID) + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write bucket write: %w", err)
+	}
+	if err := os.Rename(tempFile, bucketWriteFile(bucketID)); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename bucket write: %w", err)
+	}
+	return nil
+}
+
+// LastBucketWrite returns the last backup written to a bucket, or nil if none was recorded
+func LastBucketWrite(bucketID string) (*BucketWrite, error) {
+	data, err := os.ReadFile(bucketWriteFile(bucketID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bucket write: %w", err)
+	}
+
+	var write BucketWrite
+	if err := json.Unmarshal(data, &write); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket write: %w", err)
+	}
+	return &write, nil
+}
@@ -0,0 +1,193 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Upload is a backup in the outbox waiting to be uploaded to its bucket
+type Upload struct {
+	BackupID string    `json:"backup_id"`
+	Job      string    `json:"job"`
+	BucketID string    `json:"bucket_id"`
+	Path     string    `json:"path"` // the backup's directory in the outbox
+	Size     int64     `json:"size"`
+	QueuedAt time.Time `json:"queued_at"`
+	Attempts int       `json:"attempts,omitempty"`
+	// LastAttempt and LastError describe the latest failed upload
+	LastAttempt time.Time `json:"last_attempt,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	// PID and StartedAt identify the process uploading the backup right now
+	PID       int       `json:"pid,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+}
+
+// Upload states
+const (
+	UploadPending   = "pending"
+	UploadUploading = "uploading"
+	UploadFailed    = "failed"
+)
+
+// State returns whether the backup is waiting, being uploaded or waiting
+// for a retry after a failed upload
+func (u Upload) State() string {
+	switch {
+	case u.PID != 0 && processAlive(u.PID):
+		return UploadUploading
+	case u.LastError != "":
+		return UploadFailed
+	}
+	return UploadPending
+}
+
+// ErrUploadNotQueued is returned for a backup that is not in the upload queue
+var ErrUploadNotQueued = errors.New("backup is not queued for upload")
+
+// outboxFile returns the path of the upload queue
+func outboxFile() string {
+	return filepath.Join(Dir(), "outbox.json")
+}
+
+// QueueUpload adds a backup to the upload queue
+func QueueUpload(upload Upload) error {
+	return updateOutbox(func(uploads []Upload) []Upload {
+		return append(uploads, upload)
+	})
+}
+
+// LoadUploads returns the queued uploads, oldest first
+func LoadUploads() ([]Upload, error) {
+	var uploads []Upload
+	data, err := os.ReadFile(outboxFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read upload queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &uploads); err != nil {
+		return nil, fmt.Errorf("failed to parse upload queue: %w", err)
+	}
+	return uploads, nil
+}
+
+// LoadUpload returns the queued upload of a backup
+func LoadUpload(backupID string) (Upload, error) {
+	uploads, err := LoadUploads()
+	if err != nil {
+		return Upload{}, err
+	}
+	for _, upload := range uploads {
+		if upload.BackupID == backupID {
+			return upload, nil
+		}
+	}
+	return Upload{}, fmt.Errorf("%s: %w", backupID, ErrUploadNotQueued)
+}
+
+// StartUpload records that this process is uploading a backup
+func StartUpload(backupID string, at time.Time) error {
+	return updateUpload(backupID, func(upload *Upload) {
+		upload.PID = os.Getpid()
+		upload.StartedAt = at
+	})
+}
+
+// RecordUploadFailure counts a failed attempt to upload a backup
+func RecordUploadFailure(backupID string, at time.Time, uploadErr error) error {
+	return updateUpload(backupID, func(upload *Upload) {
+		upload.Attempts++
+		upload.LastAttempt = at
+		upload.LastError = uploadErr.Error()
+		upload.PID, upload.StartedAt = 0, time.Time{}
+	})
+}
+
+// RetryUpload makes a failed upload due again without waiting for the retry interval
+func RetryUpload(backupID string) error {
+	return updateUpload(backupID, func(upload *Upload) {
+		upload.LastAttempt = time.Time{}
+	})
+}
+
+// RemoveUpload removes a backup from the queue, after it was uploaded or to
+// cancel its upload, and returns its entry
+func RemoveUpload(backupID string) (Upload, error) {
+	var removed Upload
+	found := false
+	err := updateOutbox(func(uploads []Upload) []Upload {
+		var remaining []Upload
+		for _, upload := range uploads {
+			if upload.BackupID == backupID {
+				removed, found = upload, true
+				continue
+			}
+			remaining = append(remaining, upload)
+		}
+		return remaining
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("%s: %w", backupID, ErrUploadNotQueued)
+	}
+	return removed, err
+}
+
+// updateUpload applies update to the queued upload of a backup
+func updateUpload(backupID string, update func(*Upload)) error {
+	found := false
+	err := updateOutbox(func(uploads []Upload) []Upload {
+		for i := range uploads {
+			if uploads[i].BackupID == backupID {
+				update(&uploads[i])
+				found = true
+			}
+		}
+		return uploads
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("%s: %w", backupID, ErrUploadNotQueued)
+	}
+	return err
+}
+
+// WithUploadLock runs fn while no other backtide process uploads from the
+// outbox, so a backup is never uploaded twice at once
+func WithUploadLock(fn func() error) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return withFileLock(filepath.Join(Dir(), "outbox-upload.lock"), fn)
+}
+
+// updateOutbox applies update to the upload queue while holding its lock
+func updateOutbox(update func([]Upload) []Upload) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return withFileLock(outboxFile()+".lock", func() error {
+		uploads, err := LoadUploads()
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(update(uploads), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal upload queue: %w", err)
+		}
+		tempFile := outboxFile() + ".tmp"
+		if err := os.WriteFile(tempFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write upload queue: %w", err)
+		}
+		if err := os.Rename(tempFile, outboxFile()); err != nil {
+			os.Remove(tempFile)
+			return fmt.Errorf("failed to rename upload queue: %w", err)
+		}
+		return nil
+	})
+}
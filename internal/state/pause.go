@@ -0,0 +1,149 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// AllJobs is the pause key that suppresses scheduling for every job
+const AllJobs = "*"
+
+// Pause suppresses scheduled runs of a job until a point in time
+type Pause struct {
+	Job      string    `json:"job"`
+	Until    time.Time `json:"until"`
+	PausedAt time.Time `json:"paused_at"`
+	PausedBy string    `json:"paused_by"`
+	Reason   string    `json:"reason"`
+}
+
+// pausesFile returns the path of the persisted pause list
+func pausesFile() string {
+	return filepath.Join(Dir(), "pauses.json")
+}
+
+// PauseJob pauses scheduled runs of a job (or AllJobs) until the given time
+func PauseJob(job string, until time.Time, reason string) error {
+	pauses, err := ListPauses()
+	if err != nil {
+		return err
+	}
+
+	user := os.Getenv("SUDO_USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	updated := pauses[:0]
+	for _, p := range pauses {
+		if p.Job != job {
+			updated = append(updated, p)
+		}
+	}
+	updated = append(updated, Pause{
+		Job:      job,
+		Until:    until,
+		PausedAt: time.Now(),
+		PausedBy: user,
+		Reason:   reason,
+	})
+
+	return savePauses(updated)
+}
+
+// ResumeJob removes the pause for a job (or AllJobs), reporting whether one existed
+func ResumeJob(job string) (bool, error) {
+	pauses, err := ListPauses()
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	updated := pauses[:0]
+	for _, p := range pauses {
+		if p.Job == job {
+			found = true
+			continue
+		}
+		updated = append(updated, p)
+	}
+
+	if !found {
+		return false, nil
+	}
+	return true, savePauses(updated)
+}
+
+// ListPauses returns the active pauses, dropping expired ones
+func ListPauses() ([]Pause, error) {
+	data, err := os.ReadFile(pausesFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Pause{}, nil
+		}
+		return nil, fmt.Errorf("failed to read pause state: %w", err)
+	}
+
+	var pauses []Pause
+	if err := json.Unmarshal(data, &pauses); err != nil {
+		return nil, fmt.Errorf("failed to parse pause state: %w", err)
+	}
+
+	now := time.Now()
+	active := pauses[:0]
+	for _, p := range pauses {
+		if p.Until.After(now) {
+			active = append(active, p)
+		}
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Job < active[j].Job
+	})
+	return active, nil
+}
+
+// ActivePause returns the pause affecting a job, if any
+func ActivePause(job string) (*Pause, bool) {
+	pauses, err := ListPauses()
+	if err != nil {
+		return nil, false
+	}
+
+	var match *Pause
+	for i, p := range pauses {
+		if p.Job == job || p.Job == AllJobs {
+			// Report whichever pause lasts longest
+			if match == nil || p.Until.After(match.Until) {
+				match = &pauses[i]
+			}
+		}
+	}
+	return match, match != nil
+}
+
+// savePauses atomically writes the pause list
+func savePauses(pauses []Pause) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(pauses, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pause state: %w", err)
+	}
+
+	tempFile := pausesFile() + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pause state: %w", err)
+	}
+	if err := os.Rename(tempFile, pausesFile()); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename pause state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Replication is a backup on its job's bucket waiting to be copied to a
+// secondary bucket
+type Replication struct {
+	BackupID       string    `json:"backup_id"`
+	Job            string    `json:"job"`
+	BucketID       string    `json:"bucket_id"`        // the secondary bucket
+	SourceBucketID string    `json:"source_bucket_id"` // the job's bucket
+	Path           string    `json:"path"`             // the backup's directory on the job's bucket mount
+	QueuedAt       time.Time `json:"queued_at"`        // when the backup reached the job's bucket
+	Attempts       int       `json:"attempts,omitempty"`
+	// LastAttempt and LastError describe the latest failed replication
+	LastAttempt time.Time `json:"last_attempt,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// replicationFile returns the path of the replication queue
+func replicationFile() string {
+	return filepath.Join(Dir(), "replication.json")
+}
+
+// QueueReplication adds a backup to the replication queue
+func QueueReplication(replication Replication) error {
+	return updateReplications(func(replications []Replication) []Replication {
+		return append(replications, replication)
+	})
+}
+
+// LoadReplications returns the queued replications, oldest first
+func LoadReplications() ([]Replication, error) {
+	var replications []Replication
+	data, err := os.ReadFile(replicationFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read replication queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &replications); err != nil {
+		return nil, fmt.Errorf("failed to parse replication queue: %w", err)
+	}
+	return replications, nil
+}
+
+// RecordReplicationFailure counts a failed attempt to replicate a backup
+func RecordReplicationFailure(backupID, bucketID string, at time.Time, replicationErr error) error {
+	return updateReplications(func(replications []Replication) []Replication {
+		for i := range replications {
+			if replications[i].BackupID == backupID && replications[i].BucketID == bucketID {
+				replications[i].Attempts++
+				replications[i].LastAttempt = at
+				replications[i].LastError = replicationErr.Error()
+			}
+		}
+		return replications
+	})
+}
+
+// RemoveReplication removes a backup's replication to a bucket from the queue
+func RemoveReplication(backupID, bucketID string) error {
+	return updateReplications(func(replications []Replication) []Replication {
+		var remaining []Replication
+		for _, replication := range replications {
+			if replication.BackupID != backupID || replication.BucketID != bucketID {
+				remaining = append(remaining, replication)
+			}
+		}
+		return remaining
+	})
+}
+
+// WithReplicationLock runs fn while no other backtide process replicates
+// backups, so a backup is never copied twice at once
+func WithReplicationLock(fn func() error) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return withFileLock(filepath.Join(Dir(), "replication-run.lock"), fn)
+}
+
+// updateReplications applies update to the replication queue while holding its lock
+func updateReplications(update func([]Replication) []Replication) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return withFileLock(replicationFile()+".lock", func() error {
+		replications, err := LoadReplications()
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(update(replications), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal replication queue: %w", err)
+		}
+		tempFile := replicationFile() + ".tmp"
+		if err := os.WriteFile(tempFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write replication queue: %w", err)
+		}
+		if err := os.Rename(tempFile, replicationFile()); err != nil {
+			os.Remove(tempFile)
+			return fmt.Errorf("failed to rename replication queue: %w", err)
+		}
+		return nil
+	})
+}
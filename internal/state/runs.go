@@ -0,0 +1,200 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// RunRecord describes a backup run that is currently in progress
+type RunRecord struct {
+	ID        string    `json:"id"`
+	JobName   string    `json:"job_name"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Phase     string    `json:"phase"`
+}
+
+// NewRunID generates a unique identifier for a backup run
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("run-%s-%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
+
+// runsDir returns the directory holding active run records
+func runsDir() string {
+	return filepath.Join(Dir(), "runs")
+}
+
+// runFile returns the record path for a run
+func runFile(runID string) string {
+	return filepath.Join(runsDir(), runID+".json")
+}
+
+// cancelFile returns the cancel marker path for a run
+func cancelFile(runID string) string {
+	return filepath.Join(runsDir(), runID+".cancel")
+}
+
+// RegisterRun records a run as in progress
+func RegisterRun(record RunRecord) error {
+	if err := os.MkdirAll(runsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create runs directory: %w", err)
+	}
+	if err := writeRunRecord(record); err != nil {
+		return err
+	}
+	syncMaintenanceFlags()
+	return nil
+}
+
+// UpdateRunPhase records the phase a run is currently in
+func UpdateRunPhase(runID, phase string) error {
+	record, err := LoadRun(runID)
+	if err != nil {
+		return err
+	}
+	record.Phase = phase
+	if err := writeRunRecord(*record); err != nil {
+		return err
+	}
+	syncMaintenanceFlags()
+	return nil
+}
+
+// FinishRun removes the run record and any pending cancel request
+func FinishRun(runID string) error {
+	os.Remove(cancelFile(runID))
+	if err := os.Remove(runFile(runID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove run record: %w", err)
+	}
+	syncMaintenanceFlags()
+	return nil
+}
+
+// syncMaintenanceFlags updates the maintenance flag files after a run changed;
+// failures are reported but do not affect the run
+func syncMaintenanceFlags() {
+	if err := SyncMaintenance(); err != nil {
+		render.Printf("Warning: Failed to update maintenance flag: %v\n", err)
+	}
+}
+
+// LoadRun loads a single run record
+func LoadRun(runID string) (*RunRecord, error) {
+	data, err := os.ReadFile(runFile(runID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run record: %w", err)
+	}
+
+	var record RunRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse run record: %w", err)
+	}
+	return &record, nil
+}
+
+// ListRuns returns all runs whose owning process is still alive
+func ListRuns() ([]RunRecord, error) {
+	entries, err := os.ReadDir(runsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []RunRecord{}, nil
+		}
+		return nil, fmt.Errorf("failed to read runs directory: %w", err)
+	}
+
+	var runs []RunRecord
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		record, err := LoadRun(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		if !processAlive(record.PID) {
+			continue
+		}
+		runs = append(runs, *record)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+// RequestCancel asks the process owning a run to cancel it
+func RequestCancel(runID string) error {
+	if _, err := os.Stat(runFile(runID)); err != nil {
+		return fmt.Errorf("run not found: %s", runID)
+	}
+	if err := os.WriteFile(cancelFile(runID), []byte(time.Now().Format(time.RFC3339)), 0644); err != nil {
+		return fmt.Errorf("failed to write cancel request: %w", err)
+	}
+	return nil
+}
+
+// CancelRequested reports whether a cancel has been requested for a run
+func CancelRequested(runID string) bool {
+	_, err := os.Stat(cancelFile(runID))
+	return err == nil
+}
+
+// WatchCancel cancels the run context once a cancel request is seen
+func WatchCancel(ctx context.Context, runID string, cancel context.CancelFunc) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if CancelRequested(runID) {
+				render.Printf("🛑 Cancel requested for run %s\n", runID)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// writeRunRecord atomically writes a run record
+func writeRunRecord(record RunRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run record: %w", err)
+	}
+
+	tempFile := runFile(record.ID) + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run record: %w", err)
+	}
+	if err := os.Rename(tempFile, runFile(record.ID)); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename run record: %w", err)
+	}
+	return nil
+}
+
+// processAlive checks whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
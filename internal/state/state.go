@@ -0,0 +1,83 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/mitexleo/backtide/internal/paths"
+)
+
+// SystemStateDir is the state directory used when running as root
+const SystemStateDir = paths.SystemStateDir
+
+// profile is the active configuration profile; each profile keeps separate state
+var profile string
+
+// dir replaces the user's state directory when set
+var dir string
+
+// SetDir keeps all state in stateDir instead of the user's state directory,
+// e.g. to run backups in tests without touching the host; empty restores the
+// default
+func SetDir(stateDir string) {
+	dir = stateDir
+}
+
+// SetProfile selects the profile whose state is used; empty selects the default
+func SetProfile(name string) {
+	profile = name
+}
+
+// baseDir returns the state directory of the default profile
+func baseDir() string {
+	if dir != "" {
+		return dir
+	}
+	return paths.StateDir()
+}
+
+// Dir returns the directory where Backtide keeps runtime state
+func Dir() string {
+	base := baseDir()
+	if profile != "" {
+		return filepath.Join(base, "profiles", profile)
+	}
+	return base
+}
+
+// SocketPath returns the path of the daemon control socket
+func SocketPath() string {
+	return filepath.Join(Dir(), "daemon.sock")
+}
+
+// FindSocket returns the control socket of a running daemon, preferring the caller's own
+// state directory and falling back to the system daemon's socket
+func FindSocket() string {
+	systemDir := SystemStateDir
+	if profile != "" {
+		systemDir = filepath.Join(SystemStateDir, "profiles", profile)
+	}
+	for _, path := range []string{SocketPath(), filepath.Join(systemDir, "daemon.sock")} {
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return path
+		}
+	}
+	return SocketPath()
+}
+
+// withFileLock runs fn while holding an exclusive lock on path, shared by all backtide processes
+func withFileLock(path string, fn func() error) error {
+	lockFile, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer lockFile.Close()
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
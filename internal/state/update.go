@@ -0,0 +1,66 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// UpdateCheck records the latest backtide release seen on GitHub
+type UpdateCheck struct {
+	LatestVersion string    `json:"latest_version"`
+	CheckedAt     time.Time `json:"checked_at"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// updateCheckFile returns the path of the persisted update check
+func updateCheckFile() string {
+	return filepath.Join(Dir(), "update.json")
+}
+
+// LoadUpdateCheck returns the last update check, or a zero value if none was made
+func LoadUpdateCheck() (UpdateCheck, error) {
+	var check UpdateCheck
+	data, err := os.ReadFile(updateCheckFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return check, nil
+		}
+		return check, fmt.Errorf("failed to read update check: %w", err)
+	}
+	if err := json.Unmarshal(data, &check); err != nil {
+		return check, fmt.Errorf("failed to parse update check: %w", err)
+	}
+	return check, nil
+}
+
+// SaveUpdateCheck atomically writes the result of an update check. A failed
+// check keeps the previously seen latest version.
+func SaveUpdateCheck(check UpdateCheck) error {
+	if check.LatestVersion == "" {
+		if previous, err := LoadUpdateCheck(); err == nil {
+			check.LatestVersion = previous.LatestVersion
+		}
+	}
+
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal update check: %w", err)
+	}
+
+	tempFile := updateCheckFile() + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write update check: %w", err)
+	}
+	if err := os.Rename(tempFile, updateCheckFile()); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename update check: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// verifyAgentFile returns the path recording the last check of the verify agent
+func verifyAgentFile() string {
+	return filepath.Join(Dir(), "verify-agent.json")
+}
+
+// VerifyAgentCheck is the result of the verify agent's last check of its buckets
+type VerifyAgentCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Buckets   []string  `json:"buckets"`
+	Backups   int       `json:"backups"`
+	Archives  int       `json:"archives"` // archives downloaded and checksummed
+	Problems  []string  `json:"problems,omitempty"`
+}
+
+// LoadVerifyAgentCheck returns the verify agent's last check, zero if it never ran
+func LoadVerifyAgentCheck() (VerifyAgentCheck, error) {
+	var check VerifyAgentCheck
+	data, err := os.ReadFile(verifyAgentFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return check, nil
+		}
+		return check, fmt.Errorf("failed to read verify agent state: %w", err)
+	}
+	if err := json.Unmarshal(data, &check); err != nil {
+		return check, fmt.Errorf("failed to parse verify agent state: %w", err)
+	}
+	return check, nil
+}
+
+// SaveVerifyAgentCheck atomically records the verify agent's last check
+func SaveVerifyAgentCheck(check VerifyAgentCheck) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal verify agent state: %w", err)
+	}
+
+	tempFile := verifyAgentFile() + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write verify agent state: %w", err)
+	}
+	if err := os.Rename(tempFile, verifyAgentFile()); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename verify agent state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// unitDir is where backtide installs its unit files
+const unitDir = "/etc/systemd/system"
+
+// jobUnitPrefix is the name prefix of the service and timer of each backup job
+const jobUnitPrefix = "backtide-job-"
+
+// UnitChange describes how a unit file differs from the installed one
+type UnitChange struct {
+	File   string // unit file name, e.g. backtide-job-web.timer
+	Action string // "added", "updated" or "removed"
+}
+
+// JobUnitName returns the unit name, without suffix, of the job with the given key
+func JobUnitName(jobKey string) string {
+	var b strings.Builder
+	for _, r := range jobKey {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return jobUnitPrefix + b.String()
+}
+
+// GenerateJobServiceFile generates the oneshot service a job timer starts
+func (sm *ServiceManager) GenerateJobServiceFile(jobName string) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Backtide backup of job " + jobName + "\n")
+	b.WriteString("Documentation=https://github.com/mitexleo/backtide\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString(sm.dockerDependencies())
+	b.WriteString("\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	if sm.User != "" {
+		b.WriteString("User=" + sm.User + "\n")
+	}
+	b.WriteString(fmt.Sprintf("ExecStart=%s backup --config %s --job %s\n", sm.BinaryPath, quoteArg(sm.ConfigPath), quoteArg(jobName)))
+	b.WriteString(sm.resourceDirectives())
+	return b.String()
+}
+
+// quoteArg quotes an ExecStart argument containing spaces or quotes
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\%$") {
+		return arg
+	}
+	arg = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(arg)
+	return `"` + arg + `"`
+}
+
+// InstalledJobUnits returns the contents of the installed job units by file name
+func InstalledJobUnits() (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(unitDir, jobUnitPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+
+	units := make(map[string]string)
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read unit %s: %w", path, err)
+		}
+		units[filepath.Base(path)] = string(data)
+	}
+	return units, nil
+}
+
+// DiffJobUnits compares the desired job units with the installed ones
+func DiffJobUnits(desired map[string]string) ([]UnitChange, error) {
+	installed, err := InstalledJobUnits()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []UnitChange
+	for file, content := range desired {
+		current, ok := installed[file]
+		switch {
+		case !ok:
+			changes = append(changes, UnitChange{File: file, Action: "added"})
+		case current != content:
+			changes = append(changes, UnitChange{File: file, Action: "updated"})
+		}
+	}
+	for file := range installed {
+		if _, ok := desired[file]; !ok {
+			changes = append(changes, UnitChange{File: file, Action: "removed"})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].File < changes[j].File })
+	return changes, nil
+}
+
+// ApplyJobUnits writes and removes job unit files as described by changes,
+// then enables and restarts changed timers so new OnCalendar values take effect
+func (sm *ServiceManager) ApplyJobUnits(desired map[string]string, changes []UnitChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Stop removed timers before their files go away
+	for _, change := range changes {
+		if change.Action == "removed" && strings.HasSuffix(change.File, ".timer") {
+			if output, err := exec.Command("systemctl", "disable", "--now", change.File).CombinedOutput(); err != nil {
+				render.Printf("⚠️  Warning: Could not disable %s: %s\n", change.File, strings.TrimSpace(string(output)))
+			}
+		}
+	}
+
+	for _, change := range changes {
+		path := filepath.Join(unitDir, change.File)
+		if change.Action == "removed" {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove unit %s: %w", change.File, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(desired[change.File]), 0644); err != nil {
+			return fmt.Errorf("failed to write unit %s: %w", change.File, err)
+		}
+	}
+
+	if err := sm.ReloadDaemon(); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.Action == "removed" || !strings.HasSuffix(change.File, ".timer") {
+			continue
+		}
+		if output, err := exec.Command("systemctl", "enable", change.File).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to enable %s: %s, error: %v", change.File, strings.TrimSpace(string(output)), err)
+		}
+		// Restarting a timer recomputes its next elapse from the new schedule
+		if output, err := exec.Command("systemctl", "restart", change.File).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to start %s: %s, error: %v", change.File, strings.TrimSpace(string(output)), err)
+		}
+	}
+	return nil
+}
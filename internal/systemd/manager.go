@@ -6,6 +6,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/render"
+	"github.com/mitexleo/backtide/internal/utils"
 )
 
 // ServiceManager provides abstraction for systemd service operations
@@ -14,6 +19,10 @@ type ServiceManager struct {
 	BinaryPath  string
 	ConfigPath  string
 	User        string
+	// Resources limits and sandboxes the generated services
+	Resources config.SystemdConfig
+	// NoDocker leaves docker.service out of the unit dependencies when no job manages containers
+	NoDocker bool
 }
 
 // NewServiceManager creates a new systemd service manager
@@ -64,6 +73,11 @@ func (sm *ServiceManager) IsServiceInstalled() (bool, error) {
 	return strings.Contains(string(output), sm.ServiceName+".service"), nil
 }
 
+// IsServiceEnabled reports whether the service is enabled to start at boot
+func (sm *ServiceManager) IsServiceEnabled() bool {
+	return exec.Command("systemctl", "is-enabled", "--quiet", sm.ServiceName+".service").Run() == nil
+}
+
 // GetServiceStatus retrieves detailed status of the systemd service
 func (sm *ServiceManager) GetServiceStatus() (*ServiceStatus, error) {
 	// First check if service is installed
@@ -119,9 +133,8 @@ func (sm *ServiceManager) GenerateServiceFile() string {
 	return `[Unit]
 Description=Backtide Backup Service
 Documentation=https://github.com/mitexleo/backtide
-After=network.target docker.service
-Requires=docker.service
-
+After=network.target
+` + sm.dockerDependencies() + `
 [Service]
 Type=simple
 User=` + sm.User + `
@@ -131,18 +144,84 @@ StandardError=journal
 Restart=always
 RestartSec=10
 TimeoutStopSec=30
-
+` + sm.resourceDirectives() + `
 [Install]
 WantedBy=multi-user.target
 `
 }
 
+// dockerDependencies returns the [Unit] lines ordering a service after Docker.
+// Wants= rather than Requires= keeps the service usable where Docker is
+// missing or stopped; backups then skip container handling with a warning.
+func (sm *ServiceManager) dockerDependencies() string {
+	if sm.NoDocker {
+		return ""
+	}
+	return "After=docker.service\nWants=docker.service\n"
+}
+
+// resourceDirectives returns the [Service] lines limiting the CPU, I/O and
+// memory a backup may use and the paths it may write to
+func (sm *ServiceManager) resourceDirectives() string {
+	r := sm.Resources
+	var b strings.Builder
+	if r.Nice != 0 {
+		fmt.Fprintf(&b, "Nice=%d\n", r.Nice)
+	}
+	if r.IOSchedulingClass != "" {
+		b.WriteString("IOSchedulingClass=" + r.IOSchedulingClass + "\n")
+	}
+	if r.CPUQuota != "" {
+		b.WriteString("CPUQuota=" + r.CPUQuota + "\n")
+	}
+	if r.MemoryMax != "" {
+		if limit, err := utils.ParseSize(r.MemoryMax); err == nil {
+			fmt.Fprintf(&b, "MemoryMax=%d\n", limit)
+		}
+	}
+	if r.ProtectSystem != "" {
+		b.WriteString("ProtectSystem=" + r.ProtectSystem + "\n")
+	}
+	if len(r.ReadWritePaths) > 0 {
+		// The "-" prefix tolerates paths that do not exist yet
+		paths := make([]string, len(r.ReadWritePaths))
+		for i, path := range r.ReadWritePaths {
+			paths[i] = "-" + quoteArg(path)
+		}
+		b.WriteString("ReadWritePaths=" + strings.Join(paths, " ") + "\n")
+	}
+	return b.String()
+}
+
 // GenerateTimerFile generates the systemd timer file content
 // DEPRECATED: Backtide now uses continuous daemon for scheduling
 func (sm *ServiceManager) GenerateTimerFile(schedule string) string {
 	return ""
 }
 
+// GenerateJobTimerFile generates a timer unit for a single backup job
+// onCalendar values may carry a trailing timezone (e.g. "*-*-* 02:00:00 Europe/Berlin");
+// persistent timers catch up on runs missed while the host was off, and jitter
+// delays runs by a random amount that stays fixed for the host
+func (sm *ServiceManager) GenerateJobTimerFile(jobName string, onCalendar []string, persistent bool, jitter time.Duration) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Backtide backup timer for job " + jobName + "\n")
+	b.WriteString("Documentation=https://github.com/mitexleo/backtide\n\n")
+	b.WriteString("[Timer]\n")
+	for _, calendar := range onCalendar {
+		b.WriteString("OnCalendar=" + calendar + "\n")
+	}
+	if jitter > 0 {
+		b.WriteString(fmt.Sprintf("RandomizedDelaySec=%d\n", int(jitter.Seconds())))
+		b.WriteString("FixedRandomDelay=true\n")
+	}
+	b.WriteString(fmt.Sprintf("Persistent=%t\n\n", persistent))
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=timers.target\n")
+	return b.String()
+}
+
 // ReloadDaemon reloads the systemd daemon
 func (sm *ServiceManager) ReloadDaemon() error {
 	cmd := exec.Command("systemctl", "daemon-reload")
@@ -227,9 +306,9 @@ func (sm *ServiceManager) UpdateServiceFile() error {
 
 	// Provide feedback about what was done
 	if serviceExists {
-		fmt.Printf("  🔄 Updated existing service file\n")
+		render.Printf("  🔄 Updated existing service file\n")
 	} else {
-		fmt.Printf("  📝 Created new service file\n")
+		render.Printf("  📝 Created new service file\n")
 	}
 
 	return nil
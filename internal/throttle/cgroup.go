@@ -0,0 +1,167 @@
+package throttle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// enterCgroup moves the process into a new cgroup below backtide/ with the CPU
+// and I/O limits of o
+func enterCgroup(o Options) (func(), error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("CPU and I/O limits need cgroup v2 mounted at %s", cgroupRoot)
+	}
+	original, err := currentCgroup()
+	if err != nil {
+		return nil, err
+	}
+
+	// The parent only delegates controllers; processes live in per-run children
+	parent := filepath.Join(cgroupRoot, "backtide")
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	for _, controller := range []string{"cpu", "io"} {
+		for _, dir := range []string{cgroupRoot, parent} {
+			if err := enableController(dir, controller); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Runs that exited without leaving their cgroup left it behind empty;
+	// removing a cgroup that still has processes fails and keeps it
+	if stale, err := filepath.Glob(filepath.Join(parent, "run-*")); err == nil {
+		for _, dir := range stale {
+			os.Remove(dir)
+		}
+	}
+	group := filepath.Join(parent, fmt.Sprintf("run-%d", os.Getpid()))
+	if err := os.Mkdir(group, 0755); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	if o.CPULimit != "" {
+		limit, _ := cpuMax(o.CPULimit)
+		if err := os.WriteFile(filepath.Join(group, "cpu.max"), []byte(limit), 0644); err != nil {
+			os.Remove(group)
+			return nil, fmt.Errorf("failed to set CPU limit: %w", err)
+		}
+	}
+	if o.IOLimit > 0 {
+		for _, device := range diskDevices(o.Paths) {
+			line := fmt.Sprintf("%s rbps=%d wbps=%d", device, o.IOLimit, o.IOLimit)
+			if err := os.WriteFile(filepath.Join(group, "io.max"), []byte(line), 0644); err != nil {
+				render.Printf("Warning: Failed to limit I/O on device %s: %v\n", device, err)
+			}
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(group, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.Remove(group)
+		return nil, fmt.Errorf("failed to enter cgroup: %w", err)
+	}
+
+	return func() {
+		if err := os.WriteFile(filepath.Join(cgroupRoot, original, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			render.Printf("Warning: Failed to leave cgroup %s: %v\n", group, err)
+			return
+		}
+		// A mount helper such as s3fs keeps running in the cgroup after the run
+		if err := os.Remove(group); err != nil {
+			render.Printf("Warning: Cgroup %s is still in use and was kept\n", group)
+		}
+	}, nil
+}
+
+// currentCgroup returns the cgroup v2 path of the process
+func currentCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("failed to read cgroup: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("process is not in a cgroup v2 hierarchy")
+}
+
+// enableController makes a controller available to the children of dir
+func enableController(dir, controller string) error {
+	enabled, err := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control"))
+	if err == nil && containsWord(string(enabled), controller) {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte("+"+controller), 0644); err != nil {
+		return fmt.Errorf("failed to enable the %s cgroup controller in %s: %w", controller, dir, err)
+	}
+	return nil
+}
+
+// containsWord reports whether a space separated list contains word
+func containsWord(list, word string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == word {
+			return true
+		}
+	}
+	return false
+}
+
+// cpuMax returns the cpu.max setting of a CPU share such as "50%"
+func cpuMax(limit string) (string, error) {
+	percent, err := strconv.Atoi(strings.TrimSuffix(limit, "%"))
+	if err != nil || !strings.HasSuffix(limit, "%") || percent <= 0 {
+		return "", fmt.Errorf("invalid CPU limit %s (use a share of one CPU such as 50%%)", limit)
+	}
+	const period = 100000
+	return fmt.Sprintf("%d %d", percent*period/100, period), nil
+}
+
+// diskDevices returns the whole-disk devices ("major:minor") holding paths;
+// io.max only accepts disks, so partitions are resolved to their disk
+func diskDevices(paths []string) []string {
+	seen := make(map[string]bool)
+	var devices []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			continue
+		}
+		major := (stat.Dev>>8)&0xfff | (stat.Dev>>32)&^0xfff
+		minor := stat.Dev&0xff | (stat.Dev>>12)&^0xff
+		if major == 0 {
+			continue // tmpfs, overlay and other virtual filesystems
+		}
+		device := fmt.Sprintf("%d:%d", major, minor)
+		// A partition's sysfs directory sits inside the one of its disk
+		sysPath, err := filepath.EvalSymlinks(filepath.Join("/sys/dev/block", device))
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(sysPath, "partition")); err == nil {
+			if parent, err := os.ReadFile(filepath.Join(filepath.Dir(sysPath), "dev")); err == nil {
+				device = strings.TrimSpace(string(parent))
+			}
+		}
+		if !seen[device] {
+			seen[device] = true
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
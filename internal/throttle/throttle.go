@@ -0,0 +1,144 @@
+// Package throttle lowers the CPU and I/O priority of backups run by hand and
+// can confine them to a cgroup with CPU and I/O limits. Child processes such as
+// docker and s3fs inherit the priority and the cgroup.
+package throttle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/mitexleo/backtide/internal/render"
+)
+
+// I/O scheduling classes of ioprio_set
+var ioClasses = map[string]int{"realtime": 1, "best-effort": 2, "idle": 3}
+
+// Options describes how a run is throttled
+type Options struct {
+	Nice     int    // CPU scheduling priority, -20 to 19; 0 leaves it unchanged
+	IONice   string // idle, best-effort[:level] or realtime[:level], level 0-7
+	CPULimit string // share of one CPU such as "50%", enforced with a cgroup
+	IOLimit  int64  // bytes per second read and written per disk, enforced with a cgroup
+	// Paths are the directories whose disks IOLimit applies to
+	Paths []string
+}
+
+// Validate checks the options
+func (o Options) Validate() error {
+	if o.Nice < -20 || o.Nice > 19 {
+		return fmt.Errorf("nice must be between -20 and 19")
+	}
+	if o.IONice != "" {
+		if _, err := ioPriority(o.IONice); err != nil {
+			return err
+		}
+	}
+	if o.CPULimit != "" {
+		if _, err := cpuMax(o.CPULimit); err != nil {
+			return err
+		}
+	}
+	if o.IOLimit < 0 {
+		return fmt.Errorf("I/O limit cannot be negative")
+	}
+	return nil
+}
+
+// cgroup reports whether the options need a cgroup
+func (o Options) cgroup() bool {
+	return o.CPULimit != "" || o.IOLimit > 0
+}
+
+// Apply throttles the current process and returns a function that moves it
+// out of the cgroup again once the run is over
+func Apply(o Options) (func(), error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if o.Nice != 0 {
+		if err := forEachThread(func(tid int) error {
+			return syscall.Setpriority(syscall.PRIO_PROCESS, tid, o.Nice)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to set nice %d: %w", o.Nice, err)
+		}
+	}
+	if o.IONice != "" {
+		prio, _ := ioPriority(o.IONice)
+		if err := forEachThread(func(tid int) error {
+			// IOPRIO_WHO_PROCESS addresses a single thread
+			if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, 1, uintptr(tid), uintptr(prio)); errno != 0 {
+				return errno
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to set I/O priority %s: %w", o.IONice, err)
+		}
+	}
+	if !o.cgroup() {
+		return func() {}, nil
+	}
+	if os.Getenv("INVOCATION_ID") != "" {
+		// systemd owns the cgroups of its services; cpu_quota in [systemd] applies there
+		render.Println("Warning: Running under systemd, CPU and I/O limits are left to the service ([systemd] cpu_quota)")
+		return func() {}, nil
+	}
+	return enterCgroup(o)
+}
+
+// forEachThread calls fn for every thread of the process. Priorities are per
+// thread on Linux; threads and processes started later inherit them from the
+// thread that creates them, so every existing thread is changed.
+func forEachThread(fn func(tid int) error) error {
+	done := make(map[int]bool)
+	// A thread may be started while the list is walked, so repeat until no
+	// new ones turn up
+	for {
+		tasks, err := os.ReadDir("/proc/self/task")
+		if err != nil {
+			return err
+		}
+		changed := false
+		for _, task := range tasks {
+			tid, err := strconv.Atoi(task.Name())
+			if err != nil || done[tid] {
+				continue
+			}
+			if err := fn(tid); err != nil {
+				if _, statErr := os.Stat(filepath.Join("/proc/self/task", task.Name())); os.IsNotExist(statErr) {
+					continue // the thread has exited
+				}
+				return err
+			}
+			done[tid] = true
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+	}
+}
+
+// ioPriority returns the ioprio_set value of an ionice setting
+func ioPriority(value string) (int, error) {
+	name, levelText, hasLevel := strings.Cut(value, ":")
+	class, ok := ioClasses[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid ionice %q (use idle, best-effort[:0-7] or realtime[:0-7])", value)
+	}
+	level := 4
+	if hasLevel {
+		var err error
+		level, err = strconv.Atoi(levelText)
+		if err != nil || level < 0 || level > 7 || name == "idle" {
+			return 0, fmt.Errorf("invalid ionice %q (use idle, best-effort[:0-7] or realtime[:0-7])", value)
+		}
+	}
+	if name == "idle" {
+		level = 0
+	}
+	return class<<13 | level, nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 )
@@ -70,6 +71,53 @@ func GetDirectorySize(path string) (int64, error) {
 	return size, err
 }
 
+// GetDiskSpace returns the free and total bytes of the filesystem containing path
+func GetDiskSpace(path string) (free, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
+
+// FormatBytes formats a byte count with a binary unit suffix
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseSize parses a byte size such as "512MB", "1.5G" or "1GiB"; units are binary
+func ParseSize(value string) (int64, error) {
+	value = strings.TrimSpace(strings.ToUpper(value))
+	if value == "" {
+		return 0, fmt.Errorf("size cannot be empty")
+	}
+
+	number := strings.TrimSuffix(strings.TrimSuffix(value, "B"), "I")
+	multiplier := float64(1)
+	for i, unit := range "KMGT" {
+		if strings.HasSuffix(number, string(unit)) {
+			number = strings.TrimSuffix(number, string(unit))
+			multiplier = float64(int64(1) << (10 * (i + 1)))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+	return int64(n * multiplier), nil
+}
+
 // CopyFile copies a file from source to destination
 func CopyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+)
+
+// Tail is a writer keeping the last lines written to it
+type Tail struct {
+	mu      sync.Mutex
+	max     int
+	lines   []string
+	partial string
+}
+
+// NewTail returns a Tail keeping up to max lines
+func NewTail(max int) *Tail {
+	return &Tail{max: max}
+}
+
+// Write implements io.Writer
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	text := t.partial + string(p)
+	lines := strings.Split(text, "\n")
+	t.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		t.add(line)
+	}
+	// Keep a runaway line without newlines from growing without bound
+	if len(t.partial) > 4096 {
+		t.add(t.partial[:4096])
+		t.partial = ""
+	}
+	return len(p), nil
+}
+
+// add appends a line, dropping the oldest beyond max; callers must hold mu
+func (t *Tail) add(line string) {
+	if t.max <= 0 {
+		return
+	}
+	t.lines = append(t.lines, strings.TrimRight(line, "\r"))
+	if len(t.lines) > t.max {
+		t.lines = append(t.lines[:0], t.lines[len(t.lines)-t.max:]...)
+	}
+}
+
+// Lines returns the kept lines, oldest first, including an unterminated last line
+func (t *Tail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := append([]string(nil), t.lines...)
+	if t.partial != "" {
+		lines = append(lines, t.partial)
+	}
+	if len(lines) > t.max {
+		lines = lines[len(lines)-t.max:]
+	}
+	return lines
+}
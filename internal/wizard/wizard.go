@@ -0,0 +1,366 @@
+// Package wizard builds backup jobs, directories and buckets from interactive
+// answers, shared by every command that configures them on the terminal.
+package wizard
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/paths"
+	"github.com/mitexleo/backtide/internal/prompt"
+)
+
+// Placeholders stored for bucket credentials that are entered later
+const (
+	PlaceholderAccessKey = "YOUR_ACCESS_KEY_HERE"
+	PlaceholderSecretKey = "YOUR_SECRET_KEY_HERE"
+)
+
+// scheduleChoices are the schedules offered for a job
+var scheduleChoices = []struct {
+	label    string
+	interval string
+	summary  string
+}{
+	{"Daily (at 2 AM)", "daily", "daily at 2 AM"},
+	{"Weekly (Sunday at 2 AM)", "weekly", "weekly on Sunday at 2 AM"},
+	{"Monthly (1st at 2 AM)", "monthly", "monthly on the 1st at 2 AM"},
+	{"Hourly (on the hour)", "hourly", "hourly on the hour"},
+	{"Every 15 minutes", "*/15m", "every 15 minutes"},
+	{"Custom cron schedule", "", ""},
+	{"Manual only (no automatic scheduling)", "", ""},
+}
+
+// NewJobID returns a unique job ID
+func NewJobID() string {
+	// The random suffix keeps IDs unique when jobs are added within the same second
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("job-%s-%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
+
+// NewBucketID returns a bucket ID
+func NewBucketID() string {
+	// Simple ID generation - in production you might want something more robust
+	return fmt.Sprintf("bucket-%d", time.Now().Unix())
+}
+
+// Job asks for a complete backup job. A bucket created for the job is
+// appended to cfg.Buckets.
+func Job(p *prompt.Prompter, cfg *config.BackupConfig) (config.BackupJob, error) {
+	job := config.BackupJob{
+		ID:      NewJobID(),
+		Enabled: true,
+	}
+
+	// Job name and description
+	name, err := p.String("Backup job name (e.g., 'daily-docker-backup')", "default-backup", prompt.Required, uniqueJobName(cfg))
+	if err != nil {
+		return job, err
+	}
+	job.Name = name
+	p.Printf("Job ID: %s\n", job.ID)
+
+	if job.Description, err = p.String("Job description", ""); err != nil {
+		return job, err
+	}
+
+	// Schedule configuration
+	p.Println("\n=== Backup Schedule ===")
+	p.Println("When should this backup run automatically?")
+	if job.Schedule, err = Schedule(p); err != nil {
+		return job, err
+	}
+
+	// Retention policy
+	p.Println("\n=== Retention Policy ===")
+	p.Println("How long should we keep backups?")
+	if job.Retention, err = Retention(p); err != nil {
+		return job, err
+	}
+
+	// Storage location configuration
+	p.Println("\n=== Storage Location Configuration ===")
+	p.Println("Where should backups be stored?")
+	if job.Storage, err = Storage(p, len(cfg.Buckets) > 0); err != nil {
+		return job, err
+	}
+	job.SkipS3 = !job.Storage.S3
+	if job.Storage.S3 {
+		if len(cfg.Buckets) > 0 {
+			if job.BucketID, err = SelectBucket(p, cfg); err != nil {
+				return job, err
+			}
+		} else {
+			p.Println("⚠️  No S3 buckets configured. You can add one later with 'backtide s3 add'")
+		}
+	}
+
+	// Docker configuration
+	p.Println("\n=== Docker Configuration ===")
+	stopDocker, err := p.Confirm("Stop Docker containers during backup?", true)
+	if err != nil {
+		return job, err
+	}
+	job.SkipDocker = !stopDocker
+	if stopDocker {
+		p.Println("✅ Docker containers will be stopped during backup")
+	} else {
+		p.Println("✅ Docker containers will NOT be stopped")
+	}
+
+	// Directory configuration
+	p.Println("\n=== Directory Configuration ===")
+	if job.Directories, err = Directories(p); err != nil {
+		return job, err
+	}
+
+	return job, nil
+}
+
+// uniqueJobName rejects names of jobs that already exist
+func uniqueJobName(cfg *config.BackupConfig) prompt.Validator {
+	return func(answer string) error {
+		for _, job := range cfg.Jobs {
+			if job.Name == answer {
+				return fmt.Errorf("a job named '%s' already exists", answer)
+			}
+		}
+		return nil
+	}
+}
+
+// Schedule asks when a job runs automatically
+func Schedule(p *prompt.Prompter) (config.ScheduleConfig, error) {
+	labels := make([]string, len(scheduleChoices))
+	for i, choice := range scheduleChoices {
+		labels[i] = choice.label
+	}
+	choice, err := p.Select("Choose schedule", labels, 0)
+	if err != nil {
+		return config.ScheduleConfig{}, err
+	}
+
+	// The last two choices are the custom schedule and manual mode
+	switch len(scheduleChoices) - choice {
+	case 2:
+		cronExpr, err := p.String("Enter cron expression (e.g., '0 2 * * *' for daily at 2 AM, '*/15m' every 15 minutes)", "", prompt.Required, prompt.Schedule)
+		if err != nil {
+			return config.ScheduleConfig{}, err
+		}
+		p.Printf("✅ Set to run with cron: %s\n", cronExpr)
+		return config.ScheduleConfig{Type: "cron", Interval: cronExpr, Enabled: true}, nil
+	case 1:
+		p.Println("✅ Set to manual mode (no automatic scheduling)")
+		return config.ScheduleConfig{}, nil
+	}
+	p.Printf("✅ Set to run %s\n", scheduleChoices[choice].summary)
+	return config.ScheduleConfig{Type: "systemd", Interval: scheduleChoices[choice].interval, Enabled: true}, nil
+}
+
+// Retention asks how long backups are kept
+func Retention(p *prompt.Prompter) (config.RetentionPolicy, error) {
+	var retention config.RetentionPolicy
+	var err error
+	if retention.KeepDays, err = p.Int("Keep backups for how many days?", 30, 1, 36500); err != nil {
+		return retention, err
+	}
+	if retention.KeepCount, err = p.Int("Keep how many recent backups?", 10, 1, 10000); err != nil {
+		return retention, err
+	}
+	if retention.KeepMonthly, err = p.Int("Keep how many monthly backups?", 6, 0, 1200); err != nil {
+		return retention, err
+	}
+	p.Printf("✅ Retention: %d days, %d recent, %d monthly\n",
+		retention.KeepDays, retention.KeepCount, retention.KeepMonthly)
+	return retention, nil
+}
+
+// Storage asks where backups are stored. S3 is only the default when a
+// bucket exists, as a job without one cannot store its backups anywhere.
+func Storage(p *prompt.Prompter, haveBuckets bool) (config.StorageConfig, error) {
+	defaultChoice := 1
+	if haveBuckets {
+		defaultChoice = 0
+	}
+	choice, err := p.Select("Choose storage location", []string{
+		"S3 only (recommended - prevents local disk exhaustion)",
+		"Local only (no S3)",
+		"Both S3 and local (redundant storage)",
+	}, defaultChoice)
+	if err != nil {
+		return config.StorageConfig{}, err
+	}
+
+	switch choice {
+	case 0:
+		p.Println("✅ Backups will be stored in S3 only")
+		return config.StorageConfig{S3: true}, nil
+	case 1:
+		p.Println("✅ Backups will be stored locally only")
+		return config.StorageConfig{Local: true}, nil
+	}
+	p.Println("✅ Backups will be stored in both S3 and locally")
+	return config.StorageConfig{S3: true, Local: true}, nil
+}
+
+// Directories asks for the directories a job backs up until an empty path
+// is entered; with --yes none are added
+func Directories(p *prompt.Prompter) ([]config.DirectoryConfig, error) {
+	var directories []config.DirectoryConfig
+
+	p.Println("Configure directories to backup:")
+	p.Println("Enter directory paths one by one (empty line to finish)")
+
+	for !p.AssumeYes {
+		path, err := p.String("Directory path (e.g., /var/lib/docker/volumes)", "", prompt.Optional(prompt.AbsolutePath))
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			break
+		}
+
+		// Check if directory exists
+		if prompt.PathExists(path) != nil {
+			p.Printf("⚠️  Warning: Directory does not exist: %s\n", path)
+			keep, err := p.Confirm("Continue anyway?", false)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				continue
+			}
+		}
+
+		name, err := p.String("Backup name", filepath.Base(path), prompt.Required)
+		if err != nil {
+			return nil, err
+		}
+
+		compression, err := p.Confirm("Enable compression?", true)
+		if err != nil {
+			return nil, err
+		}
+
+		directories = append(directories, config.DirectoryConfig{
+			Path:        path,
+			Name:        name,
+			Compression: compression,
+		})
+		p.Printf("✅ Added: %s -> %s (compression: %v)\n", path, name, compression)
+	}
+
+	if len(directories) == 0 {
+		p.Println("⚠️  No directories configured. You can add them later in the configuration file.")
+	}
+
+	return directories, nil
+}
+
+// SelectBucket asks for one of the configured buckets or a new one, which is
+// appended to cfg.Buckets with placeholder credentials, and returns its ID
+func SelectBucket(p *prompt.Prompter, cfg *config.BackupConfig) (string, error) {
+	if len(cfg.Buckets) > 0 {
+		p.Println("\n=== Existing Bucket Configurations ===")
+		p.Println("Choose from existing buckets or create new:")
+
+		options := []string{"Create new bucket configuration"}
+		for _, bucket := range cfg.Buckets {
+			options = append(options, fmt.Sprintf("%s - %s (%s)", bucket.Name, bucket.Bucket, bucket.Provider))
+		}
+		choice, err := p.Select("Choose bucket", options, 1)
+		if err != nil {
+			return "", err
+		}
+		if choice > 0 {
+			selected := cfg.Buckets[choice-1]
+			p.Printf("✅ Using existing bucket: %s (%s)\n", selected.Name, selected.Bucket)
+			return selected.ID, nil
+		}
+		p.Println("Creating new bucket configuration...")
+	}
+
+	bucket, err := Bucket(p, false)
+	if err != nil {
+		return "", err
+	}
+	cfg.Buckets = append(cfg.Buckets, bucket)
+	p.Printf("✅ New bucket configuration '%s' added!\n", bucket.Name)
+
+	return bucket.ID, nil
+}
+
+// Bucket asks for an S3 bucket configuration. Without credentials the keys
+// are set to placeholders to be replaced with 'backtide s3 edit'.
+func Bucket(p *prompt.Prompter, credentials bool) (config.BucketConfig, error) {
+	bucket := config.BucketConfig{ID: NewBucketID()}
+	var err error
+
+	if bucket.Name, err = p.String("Bucket name (display name)", "", prompt.Required); err != nil {
+		return bucket, err
+	}
+	if bucket.Description, err = p.String("Description (optional)", ""); err != nil {
+		return bucket, err
+	}
+	if bucket.Provider, err = p.String("Provider name (e.g., AWS S3, Backblaze B2, MinIO)", "AWS S3", prompt.Required); err != nil {
+		return bucket, err
+	}
+	if bucket.Bucket, err = p.String("S3 Bucket name", "", prompt.Required); err != nil {
+		return bucket, err
+	}
+	if bucket.Region, err = p.String("Region (leave empty if not applicable)", ""); err != nil {
+		return bucket, err
+	}
+	if bucket.UsePathStyle, err = p.Confirm("Use path-style endpoints?", DefaultPathStyle(bucket.Provider)); err != nil {
+		return bucket, err
+	}
+
+	if bucket.Endpoint, err = p.String("Endpoint URL (leave empty for AWS default)", "", prompt.Optional(prompt.URL)); err != nil {
+		return bucket, err
+	}
+	if strings.HasPrefix(bucket.Endpoint, "https://") {
+		if bucket.TLS.CAFile, err = p.String("CA bundle for the endpoint (leave empty for system CAs)", "", prompt.Optional(prompt.PathExists)); err != nil {
+			return bucket, err
+		}
+	}
+
+	if bucket.MountPoint, err = p.String("Mount point", filepath.Join(paths.MountDir(), "backtide-"+bucket.Bucket), prompt.AbsolutePath); err != nil {
+		return bucket, err
+	}
+	if bucket.MountOnDemand, err = p.Confirm("Mount only while backups and restores run instead of permanently?", false); err != nil {
+		return bucket, err
+	}
+
+	if credentials {
+		if bucket.AccessKey, err = p.String("Access Key", "", prompt.Required); err != nil {
+			return bucket, err
+		}
+		if bucket.SecretKey, err = p.String("Secret Key", "", prompt.Required); err != nil {
+			return bucket, err
+		}
+	} else {
+		bucket.AccessKey = PlaceholderAccessKey
+		bucket.SecretKey = PlaceholderSecretKey
+	}
+
+	p.Printf("✅ S3 bucket configuration for %s completed!\n", bucket.Provider)
+	if !credentials {
+		p.Println("💡 Note: You'll need to update the bucket credentials later using 'backtide s3 edit'")
+	}
+
+	return bucket, nil
+}
+
+// DefaultPathStyle reports whether a provider usually needs path-style endpoints
+func DefaultPathStyle(provider string) bool {
+	provider = strings.ToLower(provider)
+	return strings.Contains(provider, "backblaze") ||
+		strings.Contains(provider, "b2") ||
+		strings.Contains(provider, "minio")
+}
@@ -0,0 +1,213 @@
+package wizard
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/prompt"
+)
+
+// answers returns a prompter reading one answer per line
+func answers(lines ...string) *prompt.Prompter {
+	return prompt.New(strings.NewReader(strings.Join(lines, "\n")+"\n"), io.Discard)
+}
+
+func TestJobFromAnswers(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.BackupConfig{Buckets: []config.BucketConfig{{ID: "b1", Name: "main", Bucket: "acme"}}}
+
+	job, err := Job(answers(
+		"app", "Application data", // name, description
+		"6", "30 3 * * 1-5", // custom cron schedule
+		"14", "", "0", // retention: days, count (default), monthly
+		"3", "", // both storages, first existing bucket
+		"n",              // keep containers running
+		dir, "data", "n", // directory without compression
+		"",
+	), cfg)
+	if err != nil {
+		t.Fatalf("Job: %v", err)
+	}
+
+	if job.Name != "app" || job.Description != "Application data" || !job.Enabled {
+		t.Errorf("name/description/enabled = %q/%q/%v", job.Name, job.Description, job.Enabled)
+	}
+	if job.Schedule != (config.ScheduleConfig{Type: "cron", Interval: "30 3 * * 1-5", Enabled: true}) {
+		t.Errorf("schedule = %+v", job.Schedule)
+	}
+	if job.Retention != (config.RetentionPolicy{KeepDays: 14, KeepCount: 10, KeepMonthly: 0}) {
+		t.Errorf("retention = %+v", job.Retention)
+	}
+	if !job.Storage.S3 || !job.Storage.Local || job.SkipS3 || job.BucketID != "b1" {
+		t.Errorf("storage = %+v, skip_s3 = %v, bucket = %q", job.Storage, job.SkipS3, job.BucketID)
+	}
+	if !job.SkipDocker {
+		t.Error("SkipDocker = false, want true")
+	}
+	want := []config.DirectoryConfig{{Path: dir, Name: "data", Compression: false}}
+	if !reflect.DeepEqual(job.Directories, want) {
+		t.Errorf("directories = %+v, want %+v", job.Directories, want)
+	}
+	if len(cfg.Buckets) != 1 {
+		t.Errorf("buckets = %d, want the existing one only", len(cfg.Buckets))
+	}
+}
+
+func TestJobDefaultsWithYes(t *testing.T) {
+	p := answers()
+	p.AssumeYes = true
+
+	job, err := Job(p, &config.BackupConfig{})
+	if err != nil {
+		t.Fatalf("Job: %v", err)
+	}
+	if job.Name != "default-backup" {
+		t.Errorf("name = %q", job.Name)
+	}
+	if job.Schedule != (config.ScheduleConfig{Type: "systemd", Interval: "daily", Enabled: true}) {
+		t.Errorf("schedule = %+v", job.Schedule)
+	}
+	if job.Retention != (config.RetentionPolicy{KeepDays: 30, KeepCount: 10, KeepMonthly: 6}) {
+		t.Errorf("retention = %+v", job.Retention)
+	}
+	// Without a bucket S3 storage cannot work, so local is the default
+	if job.Storage != (config.StorageConfig{Local: true}) || !job.SkipS3 {
+		t.Errorf("storage = %+v, skip_s3 = %v", job.Storage, job.SkipS3)
+	}
+	if job.SkipDocker || len(job.Directories) != 0 {
+		t.Errorf("skip_docker = %v, directories = %+v", job.SkipDocker, job.Directories)
+	}
+}
+
+func TestJobRejectsInvalidAnswers(t *testing.T) {
+	cfg := &config.BackupConfig{Jobs: []config.BackupJob{{Name: "app"}}}
+	tests := map[string][]string{
+		"duplicate name":    {"app"},
+		"invalid cron":      {"new", "", "6", "61 * * * *"},
+		"schedule range":    {"new", "", "8"},
+		"negative days":     {"new", "", "1", "-1"},
+		"relative dir path": {"new", "", "7", "", "", "", "2", "", "var/lib/app"},
+	}
+	for name, lines := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Job(answers(lines...), cfg); err == nil {
+				t.Error("Job accepted invalid answer")
+			}
+		})
+	}
+}
+
+func TestJobFailsWithoutInput(t *testing.T) {
+	_, err := Job(prompt.New(strings.NewReader(""), io.Discard), &config.BackupConfig{})
+	if err != prompt.ErrNoInput {
+		t.Errorf("err = %v, want ErrNoInput", err)
+	}
+}
+
+func TestScheduleSubHourly(t *testing.T) {
+	sched, err := Schedule(answers("5"))
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if sched != (config.ScheduleConfig{Type: "systemd", Interval: "*/15m", Enabled: true}) {
+		t.Errorf("schedule = %+v", sched)
+	}
+
+	if _, err := Schedule(answers("6", "*/1m")); err == nil {
+		t.Error("Schedule accepted a schedule below the minimum interval")
+	}
+}
+
+func TestSelectBucketCreatesNew(t *testing.T) {
+	cfg := &config.BackupConfig{Buckets: []config.BucketConfig{{ID: "b1", Name: "main"}}}
+
+	id, err := SelectBucket(answers(
+		"1",                            // create new
+		"offsite", "", "MinIO", "acme", // display name, description, provider, bucket
+		"", "", "", // region, path style (default), endpoint
+		"/mnt/offsite", "y", // mount point, on demand
+	), cfg)
+	if err != nil {
+		t.Fatalf("SelectBucket: %v", err)
+	}
+	if len(cfg.Buckets) != 2 || cfg.Buckets[1].ID != id {
+		t.Fatalf("new bucket not appended: %+v", cfg.Buckets)
+	}
+
+	bucket := cfg.Buckets[1]
+	if bucket.Name != "offsite" || bucket.Bucket != "acme" || bucket.MountPoint != "/mnt/offsite" {
+		t.Errorf("bucket = %+v", bucket)
+	}
+	if !bucket.UsePathStyle {
+		t.Error("MinIO bucket should default to path-style endpoints")
+	}
+	if !bucket.MountOnDemand {
+		t.Error("MountOnDemand = false, want true")
+	}
+	if bucket.AccessKey != PlaceholderAccessKey || bucket.SecretKey != PlaceholderSecretKey {
+		t.Errorf("credentials = %q/%q, want placeholders", bucket.AccessKey, bucket.SecretKey)
+	}
+}
+
+func TestBucketWithCredentials(t *testing.T) {
+	bucket, err := Bucket(answers(
+		"main", "Primary", "", "acme", "eu-central-1", // provider defaults to AWS S3
+		"", "https://s3.example.com", "", // path style, endpoint, system CAs
+		"/mnt/acme", "", // mount point, permanent mount
+		"AKIA", "secret",
+	), true)
+	if err != nil {
+		t.Fatalf("Bucket: %v", err)
+	}
+	if bucket.Provider != "AWS S3" || bucket.UsePathStyle {
+		t.Errorf("provider = %q, path style = %v", bucket.Provider, bucket.UsePathStyle)
+	}
+	if bucket.Endpoint != "https://s3.example.com" || bucket.Region != "eu-central-1" {
+		t.Errorf("endpoint/region = %q/%q", bucket.Endpoint, bucket.Region)
+	}
+	if bucket.AccessKey != "AKIA" || bucket.SecretKey != "secret" || bucket.MountOnDemand {
+		t.Errorf("bucket = %+v", bucket)
+	}
+}
+
+func TestBucketRequiresCredentialsWithYes(t *testing.T) {
+	p := answers()
+	p.AssumeYes = true
+	if _, err := Bucket(p, true); err == nil {
+		t.Error("Bucket accepted missing values with --yes")
+	}
+}
+
+func TestDirectoriesSkipsDeclinedMissingPaths(t *testing.T) {
+	dir := t.TempDir()
+	dirs, err := Directories(answers(
+		"/does/not/exist", "n", // declined
+		dir, "", "", // default name and compression
+		"",
+	))
+	if err != nil {
+		t.Fatalf("Directories: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0].Path != dir || !dirs[0].Compression {
+		t.Fatalf("directories = %+v", dirs)
+	}
+	if dirs[0].Name != dir[strings.LastIndex(dir, "/")+1:] {
+		t.Errorf("name = %q, want the base name of %s", dirs[0].Name, dir)
+	}
+}
+
+func TestDefaultPathStyle(t *testing.T) {
+	for provider, want := range map[string]bool{
+		"AWS S3":       false,
+		"Backblaze B2": true,
+		"minio":        true,
+		"Wasabi":       false,
+	} {
+		if got := DefaultPathStyle(provider); got != want {
+			t.Errorf("DefaultPathStyle(%q) = %v, want %v", provider, got, want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package backtide exposes Backtide's backup, restore, catalog and
+// configuration operations for embedding in other Go programs.
+//
+// Operations return typed results instead of the CLI's formatted output.
+// Progress messages are still written to standard output.
+package backtide
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitexleo/backtide/internal/audit"
+	"github.com/mitexleo/backtide/internal/backup"
+	"github.com/mitexleo/backtide/internal/config"
+	"github.com/mitexleo/backtide/internal/state"
+	"github.com/mitexleo/backtide/pkg/plugin"
+)
+
+// Client runs Backtide operations against one configuration
+type Client struct {
+	config     *Config
+	configPath string
+	storages   []plugin.Storage
+	notifiers  []plugin.Notifier
+	hooks      []plugin.Hook
+}
+
+// Open loads and validates the configuration file at configPath
+func Open(configPath string) (*Client, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{config: cfg, configPath: configPath}, nil
+}
+
+// New creates a client from an in-memory configuration
+func New(cfg Config) (*Client, error) {
+	if err := config.ValidateConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &Client{config: &cfg}, nil
+}
+
+// LoadConfig loads and validates a configuration file
+func LoadConfig(configPath string) (*Config, error) {
+	return config.LoadConfig(configPath)
+}
+
+// ValidateConfig checks a configuration for errors
+func ValidateConfig(cfg *Config) error {
+	return config.ValidateConfig(cfg)
+}
+
+// SaveConfig validates and writes a configuration file, recording the
+// changes in the audit log as the CLI does
+func SaveConfig(cfg *Config, configPath string) error {
+	if err := config.ValidateConfig(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Diff against what is on disk, not what the caller started from
+	previous, _ := config.LoadConfig(configPath)
+
+	err := config.SaveConfig(cfg, configPath)
+	audit.RecordResult("config.save", configPath, audit.DiffConfig(previous, cfg), err)
+	return err
+}
+
+// Config returns a copy of the client's configuration
+func (c *Client) Config() Config {
+	return *c.config
+}
+
+// Reload re-reads the configuration file the client was opened with
+func (c *Client) Reload() error {
+	if c.configPath == "" {
+		return fmt.Errorf("client was not opened from a configuration file")
+	}
+	cfg, err := config.LoadConfig(c.configPath)
+	if err != nil {
+		return err
+	}
+	c.config = cfg
+	return nil
+}
+
+// Jobs returns the configured backup jobs
+func (c *Client) Jobs() []Job {
+	return append([]Job(nil), c.config.Jobs...)
+}
+
+// Job returns the job with the given name
+func (c *Client) Job(name string) (Job, error) {
+	for _, job := range c.config.Jobs {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return Job{}, fmt.Errorf("job not found: %s", name)
+}
+
+// AddStorage registers a storage plugin used for every backup run by this client
+func (c *Client) AddStorage(s plugin.Storage) {
+	c.storages = append(c.storages, s)
+}
+
+// AddNotifier registers a notifier used for every backup run by this client
+func (c *Client) AddNotifier(n plugin.Notifier) {
+	c.notifiers = append(c.notifiers, n)
+}
+
+// AddHook registers a hook used for every backup run by this client
+func (c *Client) AddHook(h plugin.Hook) {
+	c.hooks = append(c.hooks, h)
+}
+
+// Backup runs a backup job; cancelling ctx aborts the run and removes the partial backup
+func (c *Client) Backup(ctx context.Context, jobName string) (*BackupResult, error) {
+	runner := backup.NewBackupRunner(*c.config)
+	for _, s := range c.storages {
+		runner.AddStorage(s)
+	}
+	for _, n := range c.notifiers {
+		runner.AddNotifier(n)
+	}
+	for _, h := range c.hooks {
+		runner.AddHook(h)
+	}
+	jobConfig, err := runner.JobBackupConfig(jobName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupResult{
+		RunID:     state.NewRunID(),
+		Job:       jobName,
+		StartedAt: time.Now(),
+	}
+	metadata, err := runner.RunJobWithID(ctx, jobName, result.RunID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.FinishedAt = time.Now()
+	result.Backup = newBackup(*metadata, filepath.Join(jobConfig.BackupPath, metadata.ID))
+	return result, nil
+}
+
+// ListBackups returns backups from the configured storage locations, newest first
+func (c *Client) ListBackups(ctx context.Context, opts ListOptions) ([]Backup, error) {
+	var jobs []Job
+	if opts.Job != "" {
+		job, err := c.Job(opts.Job)
+		if err != nil {
+			return nil, err
+		}
+		jobs = []Job{job}
+	} else {
+		for _, job := range c.config.Jobs {
+			if job.Enabled {
+				jobs = append(jobs, job)
+			}
+		}
+	}
+
+	runner := backup.NewBackupRunner(*c.config)
+	seen := make(map[string]bool)
+	var backups []Backup
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		jobConfig, err := runner.JobBackupConfig(job.Name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[jobConfig.BackupPath] {
+			continue
+		}
+		seen[jobConfig.BackupPath] = true
+
+		release, err := runner.MountJobStorage(job.Name, "list-"+state.NewRunID())
+		if err != nil {
+			return nil, err
+		}
+		manager := backup.NewBackupManager(jobConfig)
+		metadatas, err := manager.ListBackups()
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("failed to list backups in %s: %w", jobConfig.BackupPath, err)
+		}
+		for _, metadata := range metadatas {
+			if !opts.Since.IsZero() && metadata.Timestamp.Before(opts.Since) {
+				continue
+			}
+			if !backup.MatchesHost(metadata, opts.Host) {
+				continue
+			}
+			manager.SetHost(metadata.Hostname)
+			location, err := manager.BackupDir(metadata.ID)
+			if err != nil {
+				location = filepath.Join(jobConfig.BackupPath, metadata.ID)
+			}
+			backups = append(backups, newBackup(metadata, location))
+		}
+		release()
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+	return backups, nil
+}
+
+// GetBackup returns a single backup by ID
+func (c *Client) GetBackup(ctx context.Context, backupID string) (*Backup, error) {
+	backups, err := c.ListBackups(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		if backups[i].ID == backupID {
+			return &backups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("backup not found: %s", backupID)
+}
+
+// Restore restores a backup to its original locations or to opts.TargetPath
+func (c *Client) Restore(ctx context.Context, backupID string, opts RestoreOptions) (*RestoreResult, error) {
+	jobName := opts.Job
+	if jobName == "" {
+		for _, job := range c.config.Jobs {
+			if job.Enabled {
+				jobName = job.Name
+				break
+			}
+		}
+	}
+	if jobName == "" {
+		return nil, fmt.Errorf("no backup job configured to restore from")
+	}
+
+	runner := backup.NewBackupRunner(*c.config)
+	jobConfig, err := runner.JobBackupConfig(jobName)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	release, err := runner.MountJobStorage(jobName, "restore-"+state.NewRunID())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	manager := backup.NewBackupManager(jobConfig)
+	manager.SetOwnershipMap(&backup.OwnershipMap{UIDs: opts.UIDMap, GIDs: opts.GIDMap, Numeric: opts.NumericOwner})
+	manager.SetHost(opts.Host)
+	manager.SetRestoreApproval(opts.Approval)
+	metadata, err := manager.GetBackupInfo(backupID)
+	if err != nil {
+		return nil, fmt.Errorf("backup not found: %s: %w", backupID, err)
+	}
+
+	directories, err := backup.SelectDirectories(metadata, opts.Directories)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{
+		BackupID:    backupID,
+		Target:      "original locations",
+		Directories: len(directories),
+		StartedAt:   time.Now(),
+	}
+	if opts.TargetPath != "" {
+		result.Target = opts.TargetPath
+	}
+	err = manager.RestoreDirectories(ctx, backupID, opts.TargetPath, opts.Directories)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		err = ctxErr
+	}
+
+	changes := []string{"restored to " + result.Target}
+	if len(opts.Directories) > 0 {
+		changes = append(changes, "directories: "+strings.Join(opts.Directories, ", "))
+	}
+	if approvedBy := manager.RestoreApprovedBy(); approvedBy != "" {
+		changes = append(changes, approvedBy)
+	}
+	audit.RecordResult("restore", backupID, changes, err)
+	if err != nil {
+		return nil, err
+	}
+
+	result.FinishedAt = time.Now()
+	return result, nil
+}
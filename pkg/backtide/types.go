@@ -0,0 +1,127 @@
+package backtide
+
+import (
+	"time"
+
+	"github.com/mitexleo/backtide/internal/config"
+)
+
+// Configuration types shared with the CLI
+type (
+	// Config is a complete Backtide configuration
+	Config = config.BackupConfig
+	// Job is a backup job definition
+	Job = config.BackupJob
+	// Bucket is an S3 bucket definition
+	Bucket = config.BucketConfig
+)
+
+// Backup describes a backup in the catalog
+type Backup struct {
+	ID          string
+	Timestamp   time.Time
+	Location    string
+	TotalSize   int64
+	Checksum    string
+	Compressed  bool
+	Directories []BackupDirectory
+	// Job, Host and Version are empty for backups written before metadata version 2
+	Job        string
+	Host       string
+	MachineID  string
+	Version    string
+	ConfigHash string
+}
+
+// BackupDirectory describes one directory stored in a backup
+type BackupDirectory struct {
+	Name      string
+	Path      string
+	Size      int64
+	FileCount int
+	Checksum  string
+	// Index is the path of the directory's file index, relative to the backup
+	Index string
+}
+
+// BackupResult is returned by a completed backup run
+type BackupResult struct {
+	RunID      string
+	Job        string
+	Backup     Backup
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Duration returns how long the run took
+func (r BackupResult) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// ListOptions filters catalog listings
+type ListOptions struct {
+	// Job restricts the listing to backups in the job's storage location
+	Job string
+	// Since excludes backups taken before this time
+	Since time.Time
+	// Host restricts the listing to backups written by this hostname or machine ID
+	Host string
+}
+
+// RestoreOptions controls a restore
+type RestoreOptions struct {
+	// Job selects the storage location to restore from; defaults to the first enabled job
+	Job string
+	// TargetPath restores into TargetPath/<directory-name> instead of the original locations
+	TargetPath string
+	// Directories restricts the restore to these backup directory names; empty restores all
+	Directories []string
+	// UIDMap and GIDMap translate backup IDs to local IDs when restoring as root
+	UIDMap map[int]int
+	GIDMap map[int]int
+	// NumericOwner keeps recorded IDs instead of resolving user and group names locally
+	NumericOwner bool
+	// Host selects the backup written by this hostname or machine ID when several
+	// hosts sharing a bucket have a backup with the same ID
+	Host string
+	// Approval is a token from 'backtide approve' or a TOTP code; restores of
+	// jobs with restore_approval fail without one
+	Approval string
+}
+
+// RestoreResult is returned by a completed restore
+type RestoreResult struct {
+	BackupID    string
+	Target      string
+	Directories int
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// newBackup converts stored metadata into a catalog entry
+func newBackup(metadata config.BackupMetadata, location string) Backup {
+	b := Backup{
+		ID:         metadata.ID,
+		Timestamp:  metadata.Timestamp,
+		Location:   location,
+		TotalSize:  metadata.TotalSize,
+		Checksum:   metadata.Checksum,
+		Compressed: metadata.Compressed,
+		Job:        metadata.JobName,
+		Host:       metadata.Hostname,
+		MachineID:  metadata.MachineID,
+		Version:    metadata.BacktideVersion,
+		ConfigHash: metadata.ConfigHash,
+	}
+	for _, dir := range metadata.Directories {
+		b.Directories = append(b.Directories, BackupDirectory{
+			Name:      dir.Name,
+			Path:      dir.Path,
+			Size:      dir.Size,
+			FileCount: dir.FileCount,
+			Checksum:  dir.Checksum,
+			Index:     dir.Index,
+		})
+	}
+	return b
+}
@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is the version of the JSON protocol spoken with executables
+const ProtocolVersion = 1
+
+// Methods understood by executable plugins
+const (
+	MethodPing   = "ping"
+	MethodStore  = "store"
+	MethodNotify = "notify"
+	MethodHook   = "hook"
+)
+
+// Request is written as a single JSON document to the plugin's stdin
+type Request struct {
+	ProtocolVersion int           `json:"protocol_version"`
+	Plugin          string        `json:"plugin"`
+	Method          string        `json:"method"`
+	Event           *Event        `json:"event,omitempty"`
+	Store           *StoreRequest `json:"store,omitempty"`
+}
+
+// Response is read as a single JSON document from the plugin's stdout
+type Response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Exec is a plugin implemented by an external executable; it implements
+// Storage, Notifier and Hook, and is invoked once per call
+type Exec struct {
+	name    string
+	command string
+	args    []string
+	env     map[string]string
+	timeout time.Duration
+}
+
+// NewExec creates an executable plugin; a zero timeout defaults to five minutes
+func NewExec(name, command string, args []string, env map[string]string, timeout time.Duration) *Exec {
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &Exec{name: name, command: command, args: args, env: env, timeout: timeout}
+}
+
+// Name returns the plugin name
+func (e *Exec) Name() string {
+	return e.name
+}
+
+// Ping checks that the executable starts and speaks the protocol
+func (e *Exec) Ping(ctx context.Context) error {
+	return e.call(ctx, Request{Method: MethodPing})
+}
+
+// Store asks the plugin to store a backup
+func (e *Exec) Store(ctx context.Context, req StoreRequest) error {
+	return e.call(ctx, Request{Method: MethodStore, Store: &req})
+}
+
+// Notify delivers an event to the plugin
+func (e *Exec) Notify(ctx context.Context, event Event) error {
+	return e.call(ctx, Request{Method: MethodNotify, Event: &event})
+}
+
+// Run executes the plugin as a hook
+func (e *Exec) Run(ctx context.Context, event Event) error {
+	return e.call(ctx, Request{Method: MethodHook, Event: &event})
+}
+
+// call runs the executable with one request and waits for its response
+func (e *Exec) call(ctx context.Context, req Request) error {
+	req.ProtocolVersion = ProtocolVersion
+	req.Plugin = e.name
+	input, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.command, e.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+	for key, value := range e.env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("plugin %s timed out after %s", e.name, e.timeout)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &resp); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("plugin %s failed: %w%s", e.name, runErr, stderrSuffix(stderr.String()))
+		}
+		return fmt.Errorf("plugin %s returned an invalid response: %w", e.name, err)
+	}
+	if !resp.OK {
+		if resp.Error == "" {
+			resp.Error = "unknown error"
+		}
+		return fmt.Errorf("plugin %s: %s", e.name, resp.Error)
+	}
+	if runErr != nil {
+		return fmt.Errorf("plugin %s failed: %w%s", e.name, runErr, stderrSuffix(stderr.String()))
+	}
+	return nil
+}
+
+// stderrSuffix formats the last line of plugin stderr for error messages
+func stderrSuffix(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	lines := strings.Split(stderr, "\n")
+	return ": " + lines[len(lines)-1]
+}
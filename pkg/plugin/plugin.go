@@ -0,0 +1,95 @@
+// Package plugin defines the extension points for storage destinations,
+// notifications and hooks, and an adapter for plugins implemented as external
+// executables that speak JSON over stdio.
+package plugin
+
+import (
+	"context"
+	"time"
+)
+
+// Plugin types
+const (
+	TypeStorage  = "storage"
+	TypeNotifier = "notifier"
+	TypeHook     = "hook"
+)
+
+// Event types delivered to notifiers and hooks
+const (
+	EventBackupPre       = "backup.pre"
+	EventBackupSucceeded = "backup.succeeded"
+	EventBackupFailed    = "backup.failed"
+	EventMountFailed     = "mount.failed"
+	EventMountRecovered  = "mount.recovered"
+
+	EventContainersOrphaned  = "containers.orphaned"
+	EventContainersRecovered = "containers.recovered"
+
+	// EventBackupOverdue is sent by the daemon when a job's newest successful
+	// backup becomes older than its max_age
+	EventBackupOverdue = "backup.overdue"
+
+	// EventVerifyFailed is sent by the daemon's verify agent when checking a
+	// bucket finds missing or corrupted backups
+	EventVerifyFailed = "verify.failed"
+)
+
+// Event describes something that happened during a backup run
+type Event struct {
+	Type      string    `json:"type"`
+	Job       string    `json:"job"`
+	RunID     string    `json:"run_id,omitempty"`
+	BackupID  string    `json:"backup_id,omitempty"`
+	TotalSize int64     `json:"total_size,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Bucket and MountPoint identify the mount for mount events
+	Bucket     string `json:"bucket,omitempty"`
+	MountPoint string `json:"mount_point,omitempty"`
+	// Containers names the containers of container events
+	Containers []string `json:"containers,omitempty"`
+	// Missing lists the source directories a succeeded backup left out
+	// because they did not exist
+	Missing []string `json:"missing,omitempty"`
+	// Undersized is set when a succeeded backup was smaller than the job's
+	// expected_min_size (with undersized_action = "warn")
+	Undersized bool `json:"undersized,omitempty"`
+	// Log holds the last lines of a failed run's output
+	Log []string `json:"log,omitempty"`
+	// ErrorOutput holds the error output of the docker or s3fs command that
+	// failed the run, if any
+	ErrorOutput []string `json:"error_output,omitempty"`
+	// Duration is how long the run took, e.g. "1m30s", for run results
+	Duration string `json:"duration,omitempty"`
+	// Title and Message are the notification text rendered from the
+	// notifier's templates, ready to post as they are
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// StoreRequest asks a storage plugin to store a completed backup
+type StoreRequest struct {
+	Job      string `json:"job"`
+	BackupID string `json:"backup_id"`
+	// Path is the local directory containing the backup archives and metadata.toml
+	Path string `json:"path"`
+}
+
+// Storage stores completed backups in an additional destination
+type Storage interface {
+	Name() string
+	Store(ctx context.Context, req StoreRequest) error
+}
+
+// Notifier delivers run results to an external system
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// Hook runs custom actions around a backup; an error from a backup.pre hook aborts the run
+type Hook interface {
+	Name() string
+	Run(ctx context.Context, event Event) error
+}